@@ -0,0 +1,7 @@
+package pool
+
+import "time"
+
+func (cn *Conn) SetCreatedAt(tm time.Time) {
+	cn.createdAt = tm
+}
@@ -2,31 +2,57 @@
 package admin
 
 import (
+	"encoding/base64"
+	"errors"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/client"
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/schema/adminschema"
 	"github.com/coreos/dex/user"
 	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
 )
 
 // AdminAPI provides the logic necessary to implement the Admin API.
 type AdminAPI struct {
-	userManager      *manager.UserManager
-	userRepo         user.UserRepo
-	passwordInfoRepo user.PasswordInfoRepo
-	localConnectorID string
+	userManager        *manager.UserManager
+	userRepo           user.UserRepo
+	passwordInfoRepo   user.PasswordInfoRepo
+	clientIdentityRepo client.ClientIdentityRepo
+	totpInfoRepo       user.TOTPInfoRepo
+	webAuthnRepo       user.WebAuthnCredentialRepo
+	loginThrottler     *user.LoginThrottler
+	auditEventRepo     audit.EventRepo
+	webhookRepo        webhook.SubscriptionRepo
+	reencrypter        repo.Reencrypter
+	localConnectorID   string
 }
 
-func NewAdminAPI(userManager *manager.UserManager, userRepo user.UserRepo, pwiRepo user.PasswordInfoRepo, localConnectorID string) *AdminAPI {
+func NewAdminAPI(userManager *manager.UserManager, userRepo user.UserRepo, pwiRepo user.PasswordInfoRepo, ciRepo client.ClientIdentityRepo, totpRepo user.TOTPInfoRepo, webAuthnRepo user.WebAuthnCredentialRepo, loginThrottler *user.LoginThrottler, auditEventRepo audit.EventRepo, webhookRepo webhook.SubscriptionRepo, reencrypter repo.Reencrypter, localConnectorID string) *AdminAPI {
 	if localConnectorID == "" {
 		panic("must specify non-blank localConnectorID")
 	}
 
 	return &AdminAPI{
-		userManager:      userManager,
-		userRepo:         userRepo,
-		passwordInfoRepo: pwiRepo,
-		localConnectorID: localConnectorID,
+		userManager:        userManager,
+		userRepo:           userRepo,
+		passwordInfoRepo:   pwiRepo,
+		clientIdentityRepo: ciRepo,
+		totpInfoRepo:       totpRepo,
+		webAuthnRepo:       webAuthnRepo,
+		loginThrottler:     loginThrottler,
+		auditEventRepo:     auditEventRepo,
+		webhookRepo:        webhookRepo,
+		reencrypter:        reencrypter,
+		localConnectorID:   localConnectorID,
 	}
 }
 
@@ -63,7 +89,32 @@ var (
 		user.ErrorNotFound:       errorMaker("resource_not_found", "Resource could not be found.", http.StatusNotFound),
 		user.ErrorDuplicateEmail: errorMaker("bad_request", "Email already in use.", http.StatusBadRequest),
 		user.ErrorInvalidEmail:   errorMaker("bad_request", "invalid email.", http.StatusBadRequest),
+		client.ErrorNotFound:     errorMaker("resource_not_found", "Resource could not be found.", http.StatusNotFound),
+
+		user.ErrorInvalidAttributeName:  errorMaker("bad_request", "invalid attribute name.", http.StatusBadRequest),
+		user.ErrorInvalidAttributeValue: errorMaker("bad_request", "attribute values must be strings, numbers, booleans or lists of strings.", http.StatusBadRequest),
+		user.ErrorTooManyAttributes:     errorMaker("bad_request", "too many attributes.", http.StatusBadRequest),
+		client.ErrorInvalidClaimMapping: errorMaker("bad_request", "claims must not be reserved and must map to attribute names.", http.StatusBadRequest),
+		errorInvalidAttributes:          errorMaker("bad_request", "attributes must be an object.", http.StatusBadRequest),
+
+		audit.ErrorInvalidEventType:     errorMaker("bad_request", "invalid event type.", http.StatusBadRequest),
+		audit.ErrorInvalidMaxResults:    errorMaker("bad_request", "maxResults must be positive.", http.StatusBadRequest),
+		audit.ErrorInvalidNextPageToken: errorMaker("bad_request", "invalid nextPageToken.", http.StatusBadRequest),
+
+		webhook.ErrorNotFound:         errorMaker("resource_not_found", "Resource could not be found.", http.StatusNotFound),
+		webhook.ErrorInvalidURL:       errorMaker("bad_request", "url must be an absolute http or https URL.", http.StatusBadRequest),
+		webhook.ErrorInvalidEventType: errorMaker("bad_request", "invalid event type.", http.StatusBadRequest),
+		webhook.ErrorNoEvents:         errorMaker("bad_request", "events must not be empty.", http.StatusBadRequest),
+
+		errorNegativeGracePeriod: errorMaker("bad_request", "gracePeriodSeconds must not be negative.", http.StatusBadRequest),
+		errorInvalidRedirectURIs: errorMaker("bad_request", "missing or invalid field: redirectURIs.", http.StatusBadRequest),
+		errorReencryptDisabled:   errorMaker("not_implemented", "the storage backend does not support re-encryption.", http.StatusNotImplemented),
 	}
+
+	errorNegativeGracePeriod = errors.New("negative grace period")
+	errorInvalidRedirectURIs = errors.New("invalid redirect URIs")
+	errorInvalidAttributes   = errors.New("invalid attributes")
+	errorReencryptDisabled   = errors.New("re-encryption disabled")
 )
 
 func (a *AdminAPI) GetAdmin(id string) (adminschema.Admin, error) {
@@ -108,6 +159,332 @@ func (a *AdminAPI) GetState() (adminschema.State, error) {
 	return state, nil
 }
 
+func (a *AdminAPI) RotateClientSecret(clientID string, req adminschema.ClientRotateSecretRequest) (adminschema.ClientRotateSecretResponse, error) {
+	if req.GracePeriodSeconds < 0 {
+		return adminschema.ClientRotateSecretResponse{}, mapError(errorNegativeGracePeriod)
+	}
+
+	creds, err := a.clientIdentityRepo.RotateSecret(clientID, time.Duration(req.GracePeriodSeconds)*time.Second)
+	if err != nil {
+		return adminschema.ClientRotateSecretResponse{}, mapError(err)
+	}
+
+	return adminschema.ClientRotateSecretResponse{
+		Id:     creds.ID,
+		Secret: creds.Secret,
+	}, nil
+}
+
+func (a *AdminAPI) ListClients() (adminschema.ClientsResponse, error) {
+	cis, err := a.clientIdentityRepo.All()
+	if err != nil {
+		return adminschema.ClientsResponse{}, mapError(err)
+	}
+
+	clients := make([]*adminschema.Client, len(cis))
+	for i, ci := range cis {
+		c, err := a.mapClientIdentityToSchemaClient(ci.Credentials.ID, ci.Metadata)
+		if err != nil {
+			return adminschema.ClientsResponse{}, mapError(err)
+		}
+		clients[i] = &c
+	}
+
+	return adminschema.ClientsResponse{Clients: clients}, nil
+}
+
+func (a *AdminAPI) GetClient(clientID string) (adminschema.Client, error) {
+	meta, err := a.clientIdentityRepo.Metadata(clientID)
+	if err != nil {
+		return adminschema.Client{}, mapError(err)
+	}
+
+	c, err := a.mapClientIdentityToSchemaClient(clientID, *meta)
+	if err != nil {
+		return adminschema.Client{}, mapError(err)
+	}
+	return c, nil
+}
+
+// UpdateClient replaces the redirect URIs of a client. The admin status of
+// the client is left untouched; use SetClientAdmin to change it.
+func (a *AdminAPI) UpdateClient(clientID string, c adminschema.Client) (adminschema.Client, error) {
+	meta := oidc.ClientMetadata{
+		RedirectURIs: make([]url.URL, len(c.RedirectURIs)),
+	}
+	for i, ru := range c.RedirectURIs {
+		u, err := url.Parse(ru)
+		if err != nil {
+			return adminschema.Client{}, mapError(errorInvalidRedirectURIs)
+		}
+		meta.RedirectURIs[i] = *u
+	}
+
+	if err := meta.Valid(); err != nil {
+		return adminschema.Client{}, errorMaker("bad_request", err.Error(), http.StatusBadRequest)(err)
+	}
+
+	if err := a.clientIdentityRepo.Update(clientID, meta); err != nil {
+		return adminschema.Client{}, mapError(err)
+	}
+
+	return a.GetClient(clientID)
+}
+
+func (a *AdminAPI) DeleteClient(clientID string) error {
+	if err := a.clientIdentityRepo.Delete(clientID); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func (a *AdminAPI) SetClientAdmin(clientID string, req adminschema.ClientSetAdminRequest) error {
+	if err := a.clientIdentityRepo.SetDexAdmin(clientID, req.IsAdmin); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func (a *AdminAPI) GetClientClaimMapping(clientID string) (adminschema.ClientClaimMapping, error) {
+	if _, err := a.clientIdentityRepo.Metadata(clientID); err != nil {
+		return adminschema.ClientClaimMapping{}, mapError(err)
+	}
+
+	cm, err := a.clientIdentityRepo.ClaimMapping(clientID)
+	if err != nil {
+		return adminschema.ClientClaimMapping{}, mapError(err)
+	}
+	return adminschema.ClientClaimMapping{Claims: cm}, nil
+}
+
+func (a *AdminAPI) SetClientClaimMapping(clientID string, req adminschema.ClientClaimMapping) (adminschema.ClientClaimMapping, error) {
+	cm := client.ClaimMapping(req.Claims)
+	if err := cm.Valid(); err != nil {
+		return adminschema.ClientClaimMapping{}, mapError(err)
+	}
+
+	if err := a.clientIdentityRepo.SetClaimMapping(clientID, cm); err != nil {
+		return adminschema.ClientClaimMapping{}, mapError(err)
+	}
+	return a.GetClientClaimMapping(clientID)
+}
+
+func (a *AdminAPI) mapClientIdentityToSchemaClient(clientID string, meta oidc.ClientMetadata) (adminschema.Client, error) {
+	isAdmin, err := a.clientIdentityRepo.IsDexAdmin(clientID)
+	if err != nil {
+		return adminschema.Client{}, err
+	}
+
+	c := adminschema.Client{
+		Id:           clientID,
+		IsAdmin:      isAdmin,
+		RedirectURIs: make([]string, len(meta.RedirectURIs)),
+	}
+	for i, u := range meta.RedirectURIs {
+		c.RedirectURIs[i] = u.String()
+	}
+	return c, nil
+}
+
+// ResetSecondFactor removes the second factors of a user, their TOTP secret
+// and any security keys, so that they can log in with their first factor
+// alone and enroll again.
+func (a *AdminAPI) ResetSecondFactor(userID string) error {
+	if _, err := a.userRepo.Get(nil, userID); err != nil {
+		return mapError(err)
+	}
+
+	if err := a.totpInfoRepo.Delete(nil, userID); err != nil && err != user.ErrorNotFound {
+		return mapError(err)
+	}
+
+	if err := a.webAuthnRepo.DeleteByUserID(nil, userID); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// UnlockUser lifts the lockout of a user's account and forgets their failed
+// logins.
+func (a *AdminAPI) UnlockUser(userID string) error {
+	if _, err := a.userRepo.Get(nil, userID); err != nil {
+		return mapError(err)
+	}
+
+	if err := a.loginThrottler.Unlock(userID); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+func (a *AdminAPI) GetUserAttributes(userID string) (adminschema.UserAttributes, error) {
+	attrs, err := a.userManager.GetAttributes(userID)
+	if err != nil {
+		return adminschema.UserAttributes{}, mapError(err)
+	}
+	return adminschema.UserAttributes{Attributes: map[string]interface{}(attrs)}, nil
+}
+
+// SetUserAttributes replaces the custom attributes of a user.
+func (a *AdminAPI) SetUserAttributes(userID string, req adminschema.UserAttributes) (adminschema.UserAttributes, error) {
+	attrs, ok := schemaAttributes(req.Attributes)
+	if !ok {
+		return adminschema.UserAttributes{}, mapError(errorInvalidAttributes)
+	}
+
+	attrs, err := a.userManager.SetAttributes(userID, attrs)
+	if err != nil {
+		return adminschema.UserAttributes{}, mapError(err)
+	}
+	return adminschema.UserAttributes{Attributes: map[string]interface{}(attrs)}, nil
+}
+
+// schemaAttributes returns the attributes held by the attributes field of a
+// schema object, which is decoded from an arbitrary JSON value.
+func schemaAttributes(v interface{}) (user.Attributes, bool) {
+	if v == nil {
+		return user.Attributes{}, true
+	}
+	m, ok := v.(map[string]interface{})
+	return user.Attributes(m), ok
+}
+
+// DeleteUser removes a user for good, along with all data dex holds about
+// them.
+func (a *AdminAPI) DeleteUser(userID string) error {
+	if err := a.userManager.Delete(userID); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// ListAuditEvents returns a page of the audit events matching the filter,
+// newest first.
+func (a *AdminAPI) ListAuditEvents(filter audit.EventFilter, maxResults int, nextPageToken string) (adminschema.AuditEventsResponse, error) {
+	if filter.Type != "" && !filter.Type.Valid() {
+		return adminschema.AuditEventsResponse{}, mapError(audit.ErrorInvalidEventType)
+	}
+
+	events, tok, err := a.auditEventRepo.List(filter, maxResults, nextPageToken)
+	if err != nil {
+		return adminschema.AuditEventsResponse{}, mapError(err)
+	}
+
+	resp := adminschema.AuditEventsResponse{
+		Events:        make([]*adminschema.AuditEvent, len(events)),
+		NextPageToken: tok,
+	}
+	for i, e := range events {
+		resp.Events[i] = &adminschema.AuditEvent{
+			Id:          strconv.FormatInt(e.ID, 10),
+			Type:        string(e.Type),
+			Time:        e.Time.UTC().Format(time.RFC3339),
+			UserId:      e.UserID,
+			ClientId:    e.ClientID,
+			ConnectorId: e.ConnectorID,
+			RemoteAddr:  e.RemoteAddr,
+			Details:     e.Details,
+		}
+	}
+	return resp, nil
+}
+
+func (a *AdminAPI) ListWebhooks() (adminschema.WebhooksResponse, error) {
+	subs, err := a.webhookRepo.All(nil)
+	if err != nil {
+		return adminschema.WebhooksResponse{}, mapError(err)
+	}
+
+	webhooks := make([]*adminschema.Webhook, len(subs))
+	for i, sub := range subs {
+		wh := schemaWebhook(sub)
+		webhooks[i] = &wh
+	}
+	return adminschema.WebhooksResponse{Webhooks: webhooks}, nil
+}
+
+func (a *AdminAPI) GetWebhook(id string) (adminschema.Webhook, error) {
+	sub, err := a.webhookRepo.Get(nil, id)
+	if err != nil {
+		return adminschema.Webhook{}, mapError(err)
+	}
+	return schemaWebhook(sub), nil
+}
+
+// CreateWebhook subscribes the URL of wh to its events, and returns the new
+// subscription along with the secret its payloads are signed with.
+func (a *AdminAPI) CreateWebhook(wh adminschema.Webhook) (adminschema.Webhook, error) {
+	b, err := pcrypto.RandBytes(32)
+	if err != nil {
+		return adminschema.Webhook{}, mapError(err)
+	}
+	secret := base64.URLEncoding.EncodeToString(b)
+
+	sub := webhook.Subscription{
+		ID:        webhook.NewSubscriptionID(),
+		URL:       wh.Url,
+		Secret:    []byte(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, e := range wh.Events {
+		sub.Events = append(sub.Events, webhook.EventType(e))
+	}
+	if err := a.webhookRepo.Create(nil, sub); err != nil {
+		return adminschema.Webhook{}, mapError(err)
+	}
+
+	created := schemaWebhook(sub)
+	created.Secret = secret
+	return created, nil
+}
+
+func (a *AdminAPI) DeleteWebhook(id string) error {
+	if err := a.webhookRepo.Delete(nil, id); err != nil {
+		return mapError(err)
+	}
+	return nil
+}
+
+// schemaWebhook returns sub without its secret.
+func schemaWebhook(sub webhook.Subscription) adminschema.Webhook {
+	wh := adminschema.Webhook{
+		Id:        sub.ID,
+		Url:       sub.URL,
+		CreatedAt: sub.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for _, e := range sub.Events {
+		wh.Events = append(wh.Events, string(e))
+	}
+	return wh
+}
+
+// Reencrypt re-encrypts the data encrypted with the key secrets with the
+// active one, and reports the records which were not encrypted with it.
+func (a *AdminAPI) Reencrypt(req adminschema.ReencryptRequest) (adminschema.ReencryptResponse, error) {
+	if a.reencrypter == nil {
+		return adminschema.ReencryptResponse{}, mapError(errorReencryptDisabled)
+	}
+
+	report, err := a.reencrypter.Reencrypt(req.DryRun)
+	if err != nil {
+		return adminschema.ReencryptResponse{}, mapError(err)
+	}
+
+	resp := adminschema.ReencryptResponse{
+		Records:     int64(report.Records),
+		Reencrypted: int64(report.Reencrypted),
+	}
+	for _, rec := range report.Stale {
+		resp.StaleRecords = append(resp.StaleRecords, &adminschema.StaleRecord{
+			Table:            rec.Table,
+			Id:               rec.ID,
+			Secret:           int64(rec.Secret),
+			DeprecatedFormat: rec.DeprecatedFormat,
+		})
+	}
+	return resp, nil
+}
+
 func mapError(e error) error {
 	if mapped, ok := errorMap[e]; ok {
 		return mapped(e)
@@ -1,13 +1,21 @@
 package admin
 
 import (
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
+	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/schema/adminschema"
 	"github.com/coreos/dex/user"
 	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
 
 	"github.com/kylelemons/godebug/pretty"
 )
@@ -15,6 +23,13 @@ import (
 type testFixtures struct {
 	ur    user.UserRepo
 	pwr   user.PasswordInfoRepo
+	cir   client.ClientIdentityRepo
+	totpr user.TOTPInfoRepo
+	war   user.WebAuthnCredentialRepo
+	lt    *user.LoginThrottler
+	aer   audit.EventRepo
+	whr   webhook.SubscriptionRepo
+	rr    *fakeReencrypter
 	mgr   *manager.UserManager
 	adAPI *AdminAPI
 }
@@ -47,8 +62,30 @@ func makeTestFixtures() *testFixtures {
 	ccr := connector.NewConnectorConfigRepoFromConfigs([]connector.ConnectorConfig{
 		&connector.LocalConnectorConfig{ID: "local"},
 	})
-	f.mgr = manager.NewUserManager(f.ur, f.pwr, ccr, repo.InMemTransactionFactory, manager.ManagerOptions{})
-	f.adAPI = NewAdminAPI(f.mgr, f.ur, f.pwr, "local")
+	f.cir = client.NewClientIdentityRepo([]oidc.ClientIdentity{
+		{
+			Credentials: oidc.ClientCredentials{
+				ID:     "client-1",
+				Secret: "secret-1",
+			},
+			Metadata: oidc.ClientMetadata{
+				RedirectURIs: []url.URL{
+					{Scheme: "https", Host: "client-1.example.com", Path: "/callback"},
+				},
+			},
+		},
+	})
+	f.aer = audit.NewEventRepo()
+	f.whr = webhook.NewSubscriptionRepo()
+	f.rr = &fakeReencrypter{}
+	f.mgr = manager.NewUserManager(f.ur, f.pwr, ccr, repo.InMemTransactionFactory, manager.ManagerOptions{
+		AttributeRepo: user.NewAttributeRepo(),
+		AuditSink:     f.aer,
+	})
+	f.totpr = user.NewTOTPInfoRepo()
+	f.war = user.NewWebAuthnCredentialRepo()
+	f.lt = user.NewLoginThrottler(user.NewLoginAttemptRepo(), repo.InMemTransactionFactory, user.DefaultLockoutPolicy)
+	f.adAPI = NewAdminAPI(f.mgr, f.ur, f.pwr, f.cir, f.totpr, f.war, f.lt, f.aer, f.whr, f.rr, "local")
 
 	return f
 }
@@ -226,3 +263,800 @@ func TestGetState(t *testing.T) {
 		}
 	}
 }
+
+func TestRotateClientSecret(t *testing.T) {
+	tests := []struct {
+		id          string
+		gracePeriod int64
+
+		wantOldValid bool
+		wantErr      error
+	}{
+		{
+			id:           "client-1",
+			gracePeriod:  3600,
+			wantOldValid: true,
+		},
+		{
+			id:           "client-1",
+			gracePeriod:  0,
+			wantOldValid: false,
+		},
+		{
+			id:          "client-1",
+			gracePeriod: -1,
+			wantErr:     errorNegativeGracePeriod,
+		},
+		{
+			// Not found
+			id:      "client-2",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+
+		resp, err := f.adAPI.RotateClientSecret(tt.id, adminschema.ClientRotateSecretRequest{
+			GracePeriodSeconds: tt.gracePeriod,
+		})
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if resp.Id != tt.id || resp.Secret == "" || resp.Secret == "secret-1" {
+			t.Errorf("case %d: unexpected response: %#v", i, resp)
+		}
+
+		ok, err := f.cir.Authenticate(oidc.ClientCredentials{ID: tt.id, Secret: resp.Secret})
+		if err != nil || !ok {
+			t.Errorf("case %d: new secret not accepted: ok=%v err=%v", i, ok, err)
+		}
+
+		ok, err = f.cir.Authenticate(oidc.ClientCredentials{ID: tt.id, Secret: "secret-1"})
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+		}
+		if ok != tt.wantOldValid {
+			t.Errorf("case %d: old secret valid: want=%v, got=%v", i, tt.wantOldValid, ok)
+		}
+	}
+}
+
+func TestListClients(t *testing.T) {
+	f := makeTestFixtures()
+	if err := f.cir.SetDexAdmin("client-1", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := f.adAPI.ListClients()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := adminschema.ClientsResponse{
+		Clients: []*adminschema.Client{
+			{
+				Id:           "client-1",
+				IsAdmin:      true,
+				RedirectURIs: []string{"https://client-1.example.com/callback"},
+			},
+		},
+	}
+	if diff := pretty.Compare(want, resp); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+func TestGetClient(t *testing.T) {
+	tests := []struct {
+		id      string
+		want    adminschema.Client
+		wantErr error
+	}{
+		{
+			id: "client-1",
+			want: adminschema.Client{
+				Id:           "client-1",
+				RedirectURIs: []string{"https://client-1.example.com/callback"},
+			},
+		},
+		{
+			id:      "client-2",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+
+		c, err := f.adAPI.GetClient(tt.id)
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if diff := pretty.Compare(tt.want, c); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestUpdateClient(t *testing.T) {
+	tests := []struct {
+		id           string
+		redirectURIs []string
+
+		wantCode int
+	}{
+		{
+			id:           "client-1",
+			redirectURIs: []string{"https://new.example.com/callback", "https://new.example.com/other"},
+		},
+		{
+			// No redirect URIs
+			id:       "client-1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			// Redirect URI without a host
+			id:           "client-1",
+			redirectURIs: []string{"/callback"},
+			wantCode:     http.StatusBadRequest,
+		},
+		{
+			// Not found
+			id:           "client-2",
+			redirectURIs: []string{"https://new.example.com/callback"},
+			wantCode:     http.StatusNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+
+		c, err := f.adAPI.UpdateClient(tt.id, adminschema.Client{RedirectURIs: tt.redirectURIs})
+		if tt.wantCode != 0 {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Code != tt.wantCode {
+				t.Errorf("case %d: want=%d, got=%d", i, tt.wantCode, aErr.Code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		want := adminschema.Client{
+			Id:           tt.id,
+			RedirectURIs: tt.redirectURIs,
+		}
+		if diff := pretty.Compare(want, c); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		{
+			id: "client-1",
+		},
+		{
+			id:      "client-2",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+
+		err := f.adAPI.DeleteClient(tt.id)
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if _, err := f.cir.Metadata(tt.id); err != client.ErrorNotFound {
+			t.Errorf("case %d: want=%v, got=%v", i, client.ErrorNotFound, err)
+		}
+	}
+}
+
+func TestSetClientAdmin(t *testing.T) {
+	tests := []struct {
+		id      string
+		isAdmin bool
+		wantErr error
+	}{
+		{
+			id:      "client-1",
+			isAdmin: true,
+		},
+		{
+			id:      "client-1",
+			isAdmin: false,
+		},
+		{
+			id:      "client-2",
+			isAdmin: true,
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		if err := f.cir.SetDexAdmin("client-1", !tt.isAdmin); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		err := f.adAPI.SetClientAdmin(tt.id, adminschema.ClientSetAdminRequest{IsAdmin: tt.isAdmin})
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		isAdmin, err := f.cir.IsDexAdmin(tt.id)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if isAdmin != tt.isAdmin {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.isAdmin, isAdmin)
+		}
+	}
+}
+
+func TestSetClientClaimMapping(t *testing.T) {
+	tests := []struct {
+		id      string
+		claims  map[string]string
+		wantErr error
+	}{
+		{
+			id:     "client-1",
+			claims: map[string]string{"department": "dept"},
+		},
+		{
+			id:     "client-1",
+			claims: map[string]string{},
+		},
+		{
+			id:      "client-1",
+			claims:  map[string]string{"email": "work_email"},
+			wantErr: client.ErrorInvalidClaimMapping,
+		},
+		{
+			id:      "client-2",
+			claims:  map[string]string{"department": "dept"},
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		got, err := f.adAPI.SetClientClaimMapping(tt.id, adminschema.ClientClaimMapping{Claims: tt.claims})
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		want := adminschema.ClientClaimMapping{Claims: tt.claims}
+		if diff := pretty.Compare(want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+		stored, err := f.adAPI.GetClientClaimMapping(tt.id)
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+		if diff := pretty.Compare(want, stored); diff != "" {
+			t.Errorf("case %d: Compare(want, stored): %v", i, diff)
+		}
+	}
+}
+
+func TestSetUserAttributes(t *testing.T) {
+	tests := []struct {
+		id      string
+		attrs   interface{}
+		want    map[string]interface{}
+		wantErr error
+	}{
+		{
+			id:    "ID-1",
+			attrs: map[string]interface{}{"department": "Engineering", "level": float64(3)},
+			want:  map[string]interface{}{"department": "Engineering", "level": float64(3)},
+		},
+		{
+			id:    "ID-1",
+			attrs: nil,
+			want:  map[string]interface{}{},
+		},
+		{
+			id:      "ID-1",
+			attrs:   []interface{}{"department"},
+			wantErr: errorInvalidAttributes,
+		},
+		{
+			id:      "ID-1",
+			attrs:   map[string]interface{}{"first name": "Jane"},
+			wantErr: user.ErrorInvalidAttributeName,
+		},
+		{
+			id:      "ID-3",
+			attrs:   map[string]interface{}{"department": "Engineering"},
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		got, err := f.adAPI.SetUserAttributes(tt.id, adminschema.UserAttributes{Attributes: tt.attrs})
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		want := adminschema.UserAttributes{Attributes: tt.want}
+		if diff := pretty.Compare(want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+		stored, err := f.adAPI.GetUserAttributes(tt.id)
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+		if diff := pretty.Compare(want, stored); diff != "" {
+			t.Errorf("case %d: Compare(want, stored): %v", i, diff)
+		}
+	}
+}
+
+func TestResetSecondFactor(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		// enrolled user
+		{
+			id: "ID-1",
+		},
+		// user without a second factor
+		{
+			id: "ID-2",
+		},
+		{
+			id:      "ID-3",
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		if err := f.totpr.Create(nil, user.TOTPInfo{UserID: "ID-1", Secret: []byte("secret"), Confirmed: true}); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if err := f.war.Create(nil, user.WebAuthnCredential{ID: []byte("cred-1"), UserID: "ID-1"}); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		err := f.adAPI.ResetSecondFactor(tt.id)
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if _, err := f.totpr.Get(nil, tt.id); err != user.ErrorNotFound {
+			t.Errorf("case %d: want=%v, got=%v", i, user.ErrorNotFound, err)
+		}
+		if creds, err := f.war.GetByUserID(nil, tt.id); err != nil || len(creds) != 0 {
+			t.Errorf("case %d: want no security keys, got %d, err=%v", i, len(creds), err)
+		}
+	}
+}
+
+func TestUnlockUser(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		// locked user
+		{
+			id: "ID-1",
+		},
+		// user without failed logins
+		{
+			id: "ID-2",
+		},
+		{
+			id:      "ID-3",
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		for j := 0; j < user.DefaultLockoutPolicy.MaxFailures; j++ {
+			if _, err := f.lt.RecordFailure("ID-1", ""); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+		}
+		if err := f.lt.Locked("ID-1", ""); err != user.ErrorLockedOut {
+			t.Fatalf("case %d: want=%v, got=%v", i, user.ErrorLockedOut, err)
+		}
+
+		err := f.adAPI.UnlockUser(tt.id)
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if err := f.lt.Locked(tt.id, ""); err != nil {
+			t.Errorf("case %d: want unlocked user, got %v", i, err)
+		}
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		id      string
+		wantErr error
+	}{
+		{
+			id: "ID-1",
+		},
+		{
+			id:      "ID-3",
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		err := f.adAPI.DeleteUser(tt.id)
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr {
+				t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		if _, err := f.ur.Get(nil, tt.id); err != user.ErrorNotFound {
+			t.Errorf("case %d: want deleted user, got err=%v", i, err)
+		}
+		if _, err := f.pwr.Get(nil, tt.id); err != user.ErrorNotFound {
+			t.Errorf("case %d: want deleted password, got err=%v", i, err)
+		}
+	}
+}
+
+func TestListAuditEvents(t *testing.T) {
+	now := time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+	events := []audit.Event{
+		{Type: audit.EventLogin, Time: now, UserID: "ID-1", ClientID: "client-1", RemoteAddr: "192.0.2.1"},
+		{Type: audit.EventLoginFailed, Time: now.Add(time.Minute), UserID: "ID-2", Details: "wrong password"},
+		{Type: audit.EventLogin, Time: now.Add(2 * time.Minute), UserID: "ID-2", ClientID: "client-1"},
+	}
+
+	tests := []struct {
+		filter     audit.EventFilter
+		maxResults int
+		want       []string
+		wantErr    error
+	}{
+		{
+			maxResults: 10,
+			want:       []string{"3", "2", "1"},
+		},
+		{
+			filter:     audit.EventFilter{UserID: "ID-2"},
+			maxResults: 10,
+			want:       []string{"3", "2"},
+		},
+		{
+			filter:     audit.EventFilter{Type: audit.EventLogin, Before: now.Add(time.Minute)},
+			maxResults: 10,
+			want:       []string{"1"},
+		},
+		{
+			filter:     audit.EventFilter{Type: "bogus"},
+			maxResults: 10,
+			wantErr:    audit.ErrorInvalidEventType,
+		},
+		{
+			maxResults: 0,
+			wantErr:    audit.ErrorInvalidMaxResults,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		for _, e := range events {
+			if err := f.aer.Record(e); err != nil {
+				t.Fatalf("case %d: err != nil: %v", i, err)
+			}
+		}
+
+		resp, err := f.adAPI.ListAuditEvents(tt.filter, tt.maxResults, "")
+		if tt.wantErr != nil {
+			aErr, ok := err.(Error)
+			if !ok {
+				t.Errorf("case %d: not an admin.Error: %#v", i, err)
+				continue
+			}
+			if aErr.Internal != tt.wantErr || aErr.Code != http.StatusBadRequest {
+				t.Errorf("case %d: want=%q, got=%q (%d)", i, tt.wantErr, aErr.Internal, aErr.Code)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: err != nil: %q", i, err)
+			continue
+		}
+
+		var got []string
+		for _, e := range resp.Events {
+			got = append(got, e.Id)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+
+	f := makeTestFixtures()
+	f.aer.Record(events[1])
+	resp, err := f.adAPI.ListAuditEvents(audit.EventFilter{}, 10, "")
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	want := &adminschema.AuditEvent{
+		Id:      "1",
+		Type:    "login_failed",
+		Time:    "2016-03-01T12:01:00Z",
+		UserId:  "ID-2",
+		Details: "wrong password",
+	}
+	if len(resp.Events) != 1 {
+		t.Fatalf("want 1 event, got %d", len(resp.Events))
+	}
+	if diff := pretty.Compare(want, resp.Events[0]); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+func TestWebhooks(t *testing.T) {
+	f := makeTestFixtures()
+
+	created, err := f.adAPI.CreateWebhook(adminschema.Webhook{
+		Url:    "https://example.com/hook",
+		Events: []string{"user_created", "user_login"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if created.Id == "" || created.Secret == "" || created.CreatedAt == "" {
+		t.Fatalf("want ID, secret and creation time, got %#v", created)
+	}
+
+	sub, err := f.whr.Get(nil, created.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(sub.Secret) != created.Secret {
+		t.Errorf("want stored secret %q, got %q", created.Secret, sub.Secret)
+	}
+
+	// The secret is only shown when the subscription is created.
+	want := created
+	want.Secret = ""
+	got, err := f.adAPI.GetWebhook(created.Id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+	list, err := f.adAPI.ListWebhooks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare(adminschema.WebhooksResponse{Webhooks: []*adminschema.Webhook{&want}}, list); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if err := f.adAPI.DeleteWebhook(created.Id); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		err     error
+		wantErr error
+	}{
+		{f.adAPI.DeleteWebhook(created.Id), webhook.ErrorNotFound},
+		{webhookErr(f.adAPI.GetWebhook(created.Id)), webhook.ErrorNotFound},
+		{webhookErr(f.adAPI.CreateWebhook(adminschema.Webhook{Url: "example.com", Events: []string{"user_login"}})), webhook.ErrorInvalidURL},
+		{webhookErr(f.adAPI.CreateWebhook(adminschema.Webhook{Url: "https://example.com"})), webhook.ErrorNoEvents},
+		{webhookErr(f.adAPI.CreateWebhook(adminschema.Webhook{Url: "https://example.com", Events: []string{"bogus"}})), webhook.ErrorInvalidEventType},
+	}
+	for i, tt := range tests {
+		aErr, ok := tt.err.(Error)
+		if !ok {
+			t.Errorf("case %d: not an admin.Error: %#v", i, tt.err)
+			continue
+		}
+		if aErr.Internal != tt.wantErr {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, aErr.Internal)
+		}
+	}
+}
+
+func webhookErr(_ adminschema.Webhook, err error) error {
+	return err
+}
+
+// fakeReencrypter reports a fixed set of stale records, and re-encrypts
+// those it can unless asked for a dry run.
+type fakeReencrypter struct {
+	dryRun bool
+}
+
+func (r *fakeReencrypter) Reencrypt(dryRun bool) (repo.ReencryptionReport, error) {
+	r.dryRun = dryRun
+	report := repo.ReencryptionReport{
+		Records: 3,
+		Stale: []repo.StaleRecord{
+			{Table: "key", ID: "signing-keys", Secret: 1, DeprecatedFormat: true},
+			{Table: "user_totp", ID: "ID-1", Secret: -1},
+		},
+	}
+	if !dryRun {
+		report.Reencrypted = 1
+	}
+	return report, nil
+}
+
+func TestReencrypt(t *testing.T) {
+	stale := []*adminschema.StaleRecord{
+		{Table: "key", Id: "signing-keys", Secret: 1, DeprecatedFormat: true},
+		{Table: "user_totp", Id: "ID-1", Secret: -1},
+	}
+	tests := []struct {
+		dryRun bool
+		want   adminschema.ReencryptResponse
+	}{
+		{true, adminschema.ReencryptResponse{Records: 3, StaleRecords: stale}},
+		{false, adminschema.ReencryptResponse{Records: 3, Reencrypted: 1, StaleRecords: stale}},
+	}
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		got, err := f.adAPI.Reencrypt(adminschema.ReencryptRequest{DryRun: tt.dryRun})
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if f.rr.dryRun != tt.dryRun {
+			t.Errorf("case %d: want dryRun=%t, got %t", i, tt.dryRun, f.rr.dryRun)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+
+	// Without a re-encrypter, as for storage backends not supporting it.
+	f := makeTestFixtures()
+	f.adAPI.reencrypter = nil
+	_, err := f.adAPI.Reencrypt(adminschema.ReencryptRequest{})
+	aErr, ok := err.(Error)
+	if !ok {
+		t.Fatalf("not an admin.Error: %#v", err)
+	}
+	if aErr.Code != http.StatusNotImplemented {
+		t.Errorf("want code %d, got %d", http.StatusNotImplemented, aErr.Code)
+	}
+}
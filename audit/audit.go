@@ -0,0 +1,154 @@
+// Package audit records security-relevant events, such as logins, the
+// issuance of tokens and changes to accounts, so they can be inspected later.
+package audit
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/coreos/dex/pkg/log"
+)
+
+type EventType string
+
+const (
+	EventLogin            EventType = "login"
+	EventLoginFailed      EventType = "login_failed"
+	EventTokenIssued      EventType = "token_issued"
+	EventTokenRefreshed   EventType = "token_refreshed"
+	EventClientRegistered EventType = "client_registered"
+	EventPasswordReset    EventType = "password_reset"
+	EventPasswordChanged  EventType = "password_changed"
+	EventAdminCreated     EventType = "admin_created"
+	EventUserDisabled     EventType = "user_disabled"
+	EventUserEnabled      EventType = "user_enabled"
+)
+
+var (
+	ErrorInvalidEventType     = errors.New("invalid event type")
+	ErrorInvalidMaxResults    = errors.New("maxResults must be positive")
+	ErrorInvalidNextPageToken = errors.New("invalid next page token")
+
+	eventTypes = map[EventType]bool{
+		EventLogin:            true,
+		EventLoginFailed:      true,
+		EventTokenIssued:      true,
+		EventTokenRefreshed:   true,
+		EventClientRegistered: true,
+		EventPasswordReset:    true,
+		EventPasswordChanged:  true,
+		EventAdminCreated:     true,
+		EventUserDisabled:     true,
+		EventUserEnabled:      true,
+	}
+)
+
+// Valid reports whether t is one of the known event types.
+func (t EventType) Valid() bool {
+	return eventTypes[t]
+}
+
+// Event is a security-relevant event. Fields which do not apply to the event
+// are left empty.
+type Event struct {
+	// ID is assigned by the EventRepo storing the event; later events have
+	// greater IDs.
+	ID int64 `json:"id,omitempty"`
+
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	UserID      string `json:"userId,omitempty"`
+	ClientID    string `json:"clientId,omitempty"`
+	ConnectorID string `json:"connectorId,omitempty"`
+	RemoteAddr  string `json:"remoteAddr,omitempty"`
+
+	// Details describes the event further, for example why a login failed.
+	Details string `json:"details,omitempty"`
+}
+
+// AuditSink is where events are sent to be kept.
+type AuditSink interface {
+	Record(e Event) error
+}
+
+// Record sends the event to the sink, setting its time if it has none. A nil
+// sink discards the event. Failing to record an event is logged rather than
+// returned, so that it does not fail the operation being audited.
+func Record(sink AuditSink, e Event) {
+	if sink == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	if err := sink.Record(e); err != nil {
+		log.Errorf("Failed to record audit event %#v: %v", e, err)
+	}
+}
+
+// EventFilter selects the events returned by EventRepo.List. Events must
+// match every field which is set; the zero EventFilter matches all events.
+type EventFilter struct {
+	UserID   string    `json:",omitempty"`
+	ClientID string    `json:",omitempty"`
+	Type     EventType `json:",omitempty"`
+
+	// After and Before match events at or after, and before, the given
+	// times.
+	After  time.Time
+	Before time.Time
+}
+
+// Matches reports whether e matches the filter.
+func (f EventFilter) Matches(e Event) bool {
+	switch {
+	case f.UserID != "" && e.UserID != f.UserID:
+		return false
+	case f.ClientID != "" && e.ClientID != f.ClientID:
+		return false
+	case f.Type != "" && e.Type != f.Type:
+		return false
+	case !f.After.IsZero() && e.Time.Before(f.After):
+		return false
+	case !f.Before.IsZero() && !e.Time.Before(f.Before):
+		return false
+	}
+	return true
+}
+
+type nextPageToken struct {
+	Filter     EventFilter
+	MaxResults int
+	BeforeID   int64
+}
+
+// EncodeNextPageToken returns a token for the page of events matching the
+// filter which follows the event with the given ID.
+func EncodeNextPageToken(filter EventFilter, maxResults int, beforeID int64) (string, error) {
+	b, err := json.Marshal(nextPageToken{
+		Filter:     filter,
+		MaxResults: maxResults,
+		BeforeID:   beforeID,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(b), nil
+}
+
+// DecodeNextPageToken reverses EncodeNextPageToken.
+func DecodeNextPageToken(tok string) (EventFilter, int, int64, error) {
+	b, err := base64.URLEncoding.DecodeString(tok)
+	if err != nil {
+		return EventFilter{}, 0, 0, ErrorInvalidNextPageToken
+	}
+
+	var npt nextPageToken
+	if err := json.Unmarshal(b, &npt); err != nil || npt.BeforeID <= 0 || npt.MaxResults <= 0 {
+		return EventFilter{}, 0, 0, ErrorInvalidNextPageToken
+	}
+	return npt.Filter, npt.MaxResults, npt.BeforeID, nil
+}
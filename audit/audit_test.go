@@ -0,0 +1,147 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestEventFilterMatches(t *testing.T) {
+	now := time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+	e := Event{
+		Type:     EventLogin,
+		Time:     now,
+		UserID:   "ID-1",
+		ClientID: "client-1",
+	}
+
+	tests := []struct {
+		filter EventFilter
+		want   bool
+	}{
+		{EventFilter{}, true},
+		{EventFilter{UserID: "ID-1"}, true},
+		{EventFilter{UserID: "ID-2"}, false},
+		{EventFilter{ClientID: "client-1", Type: EventLogin}, true},
+		{EventFilter{ClientID: "client-2"}, false},
+		{EventFilter{Type: EventLoginFailed}, false},
+		{EventFilter{After: now}, true},
+		{EventFilter{After: now.Add(time.Second)}, false},
+		{EventFilter{Before: now.Add(time.Second)}, true},
+		{EventFilter{Before: now}, false},
+	}
+
+	for i, tt := range tests {
+		if got := tt.filter.Matches(e); got != tt.want {
+			t.Errorf("case %d: want=%t, got=%t", i, tt.want, got)
+		}
+	}
+}
+
+func TestNextPageToken(t *testing.T) {
+	filter := EventFilter{
+		UserID: "ID-1",
+		Type:   EventLogin,
+		After:  time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC),
+	}
+	tok, err := EncodeNextPageToken(filter, 10, 42)
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+
+	gotFilter, gotMax, gotBefore, err := DecodeNextPageToken(tok)
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if diff := pretty.Compare(filter, gotFilter); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+	if gotMax != 10 || gotBefore != 42 {
+		t.Errorf("want maxResults=10 beforeID=42, got maxResults=%d beforeID=%d", gotMax, gotBefore)
+	}
+
+	zero, err := EncodeNextPageToken(filter, 10, 0)
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	for i, tok := range []string{"", "not-base64!", "bm90IGpzb24=", zero} {
+		if _, _, _, err := DecodeNextPageToken(tok); err != ErrorInvalidNextPageToken {
+			t.Errorf("case %d: want=%v, got=%v", i, ErrorInvalidNextPageToken, err)
+		}
+	}
+}
+
+type failingSink struct {
+	events []Event
+	err    error
+}
+
+func (s *failingSink) Record(e Event) error {
+	s.events = append(s.events, e)
+	return s.err
+}
+
+func TestRecord(t *testing.T) {
+	// A nil sink must be safe to record to.
+	Record(nil, Event{Type: EventLogin})
+
+	s := &failingSink{err: errors.New("oops")}
+	Record(s, Event{Type: EventLogin})
+	if len(s.events) != 1 {
+		t.Fatalf("want 1 event, got %d", len(s.events))
+	}
+	if s.events[0].Time.IsZero() {
+		t.Errorf("want time to be set")
+	}
+
+	ts := time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC)
+	Record(s, Event{Type: EventLogin, Time: ts})
+	if !s.events[1].Time.Equal(ts) {
+		t.Errorf("want time=%v, got %v", ts, s.events[1].Time)
+	}
+}
+
+func TestFileSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewFileSink(&buf)
+
+	events := []Event{
+		{Type: EventLogin, Time: time.Date(2016, time.March, 1, 12, 0, 0, 0, time.UTC), UserID: "ID-1"},
+		{Type: EventLoginFailed, Time: time.Date(2016, time.March, 1, 12, 1, 0, 0, time.UTC), Details: "wrong password"},
+	}
+	for _, e := range events {
+		if err := s.Record(e); err != nil {
+			t.Fatalf("err != nil: %v", err)
+		}
+	}
+
+	var got []Event
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var e Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("err != nil: %v", err)
+		}
+		got = append(got, e)
+	}
+	if diff := pretty.Compare(events, got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+func TestMultiSink(t *testing.T) {
+	errOops := errors.New("oops")
+	a := &failingSink{err: errOops}
+	b := &failingSink{}
+
+	if err := MultiSink(a, b).Record(Event{Type: EventLogin}); err != errOops {
+		t.Errorf("want err=%v, got %v", errOops, err)
+	}
+	if len(a.events) != 1 || len(b.events) != 1 {
+		t.Errorf("want the event recorded by every sink, got %d and %d", len(a.events), len(b.events))
+	}
+}
@@ -0,0 +1,96 @@
+package audit
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRetention is how long events are kept by default.
+	DefaultRetention = 90 * 24 * time.Hour
+)
+
+// EventRepo is an AuditSink which can be queried.
+type EventRepo interface {
+	AuditSink
+
+	// List returns the events matching the filter, newest first.
+	// A nextPageToken is returned when there are further results to be had,
+	// with the expectation that it will be passed into a subsequent List
+	// call. When nextPageToken is non-empty, filter and maxResults are
+	// ignored.
+	List(filter EventFilter, maxResults int, nextPageToken string) ([]Event, string, error)
+}
+
+func NewEventRepo() EventRepo {
+	return &memEventRepo{}
+}
+
+type memEventRepo struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+func (r *memEventRepo) Record(e Event) error {
+	if !e.Type.Valid() {
+		return ErrorInvalidEventType
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e.ID = int64(len(r.events) + 1)
+	r.events = append(r.events, e)
+	return nil
+}
+
+func (r *memEventRepo) List(filter EventFilter, maxResults int, nextPageToken string) ([]Event, string, error) {
+	var beforeID int64
+	if nextPageToken != "" {
+		var err error
+		if filter, maxResults, beforeID, err = DecodeNextPageToken(nextPageToken); err != nil {
+			return nil, "", err
+		}
+	}
+	if maxResults <= 0 {
+		return nil, "", ErrorInvalidMaxResults
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events []Event
+	for i := len(r.events) - 1; i >= 0; i-- {
+		e := r.events[i]
+		if beforeID != 0 && e.ID >= beforeID {
+			continue
+		}
+		if !filter.Matches(e) {
+			continue
+		}
+		if len(events) == maxResults {
+			tok, err := EncodeNextPageToken(filter, maxResults, events[len(events)-1].ID)
+			return events, tok, err
+		}
+		events = append(events, e)
+	}
+	return events, "", nil
+}
+
+func (r *memEventRepo) Snapshot() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.Marshal(r.events)
+}
+
+func (r *memEventRepo) Restore(b []byte) error {
+	var events []Event
+	if err := json.Unmarshal(b, &events); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = events
+	return nil
+}
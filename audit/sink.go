@@ -0,0 +1,41 @@
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// NewFileSink returns an AuditSink writing each event to w as a line of JSON.
+func NewFileSink(w io.Writer) AuditSink {
+	return &fileSink{enc: json.NewEncoder(w)}
+}
+
+type fileSink struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func (s *fileSink) Record(e Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.enc.Encode(e)
+}
+
+// MultiSink returns an AuditSink recording events to each of the given
+// sinks. Every sink is tried, and the first error is returned.
+func MultiSink(sinks ...AuditSink) AuditSink {
+	return multiSink(sinks)
+}
+
+type multiSink []AuditSink
+
+func (m multiSink) Record(e Event) error {
+	var err error
+	for _, s := range m {
+		if serr := s.Record(e); serr != nil && err == nil {
+			err = serr
+		}
+	}
+	return err
+}
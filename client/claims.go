@@ -0,0 +1,55 @@
+package client
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/coreos/go-oidc/jose"
+
+	"github.com/coreos/dex/user"
+)
+
+var (
+	ErrorInvalidClaimMapping = errors.New("invalid claim mapping")
+
+	claimNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.:/-]{0,127}$`)
+
+	// reservedClaims are set by dex itself and cannot be mapped.
+	reservedClaims = map[string]bool{
+		"iss": true, "sub": true, "aud": true, "exp": true, "iat": true,
+		"nbf": true, "jti": true, "nonce": true, "azp": true, "at_hash": true,
+		"c_hash": true, "auth_time": true, "acr": true, "amr": true,
+		"name": true, "given_name": true, "family_name": true, "picture": true,
+		"locale": true, "email": true, "email_verified": true,
+	}
+)
+
+// ClaimMapping selects the custom attributes of users which are added to
+// the ID tokens of a client. It maps the names of the claims to the names of
+// the attributes they hold.
+type ClaimMapping map[string]string
+
+// Valid returns ErrorInvalidClaimMapping if a claim is reserved by dex or has
+// an invalid name, or an attribute name is empty.
+func (m ClaimMapping) Valid() error {
+	for claim, attr := range m {
+		if reservedClaims[claim] || !claimNameRegexp.MatchString(claim) || attr == "" {
+			return ErrorInvalidClaimMapping
+		}
+	}
+	return nil
+}
+
+// AddToClaims adds the mapped attributes to the given Claims. Attributes the
+// user does not have are left out, as are reserved claims, which mappings
+// stored before they were reserved may still hold.
+func (m ClaimMapping) AddToClaims(claims jose.Claims, attrs user.Attributes) {
+	for claim, attr := range m {
+		if reservedClaims[claim] {
+			continue
+		}
+		if v, ok := attrs[attr]; ok {
+			claims.Add(claim, v)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/user"
+)
+
+func TestClaimMappingValid(t *testing.T) {
+	tests := []struct {
+		mapping ClaimMapping
+		valid   bool
+	}{
+		{ClaimMapping{}, true},
+		{ClaimMapping{"department": "dept", "https://example.com/roles": "roles"}, true},
+		{ClaimMapping{"email": "work_email"}, false},
+		{ClaimMapping{"sub": "employee_id"}, false},
+		{ClaimMapping{"given_name": "first_name"}, false},
+		{ClaimMapping{"family_name": "last_name"}, false},
+		{ClaimMapping{"picture": "avatar"}, false},
+		{ClaimMapping{"locale": "language"}, false},
+		{ClaimMapping{"department": ""}, false},
+		{ClaimMapping{"": "dept"}, false},
+		{ClaimMapping{"my department": "dept"}, false},
+	}
+
+	for i, tt := range tests {
+		err := tt.mapping.Valid()
+		if tt.valid && err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+		if !tt.valid && err != ErrorInvalidClaimMapping {
+			t.Errorf("case %d: want err=%v, got %v", i, ErrorInvalidClaimMapping, err)
+		}
+	}
+}
+
+func TestClaimMappingAddToClaims(t *testing.T) {
+	m := ClaimMapping{
+		"department": "dept",
+		"dept":       "dept",
+		"groups":     "roles",
+		"missing":    "unset",
+		"picture":    "avatar",
+	}
+	attrs := user.Attributes{
+		"dept":   "Engineering",
+		"roles":  []string{"dev"},
+		"salary": float64(100),
+		"avatar": "https://example.com/avatar.png",
+	}
+
+	claims := jose.Claims{"picture": "https://example.com/test.png"}
+	m.AddToClaims(claims, attrs)
+
+	want := jose.Claims{
+		"department": "Engineering",
+		"dept":       "Engineering",
+		"groups":     []string{"dev"},
+		"picture":    "https://example.com/test.png",
+	}
+	if diff := pretty.Compare(want, claims); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+}
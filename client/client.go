@@ -9,9 +9,19 @@ import (
 	"net/url"
 	"reflect"
 	"sort"
+	"sync"
+	"time"
 
-	pcrypto "github.com/coreos/dex/pkg/crypto"
 	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+)
+
+const (
+	// DefaultSecretRotationGracePeriod is how long the previous secrets of a
+	// client remain valid after a rotation, unless told otherwise.
+	DefaultSecretRotationGracePeriod = 24 * time.Hour
 )
 
 var (
@@ -41,15 +51,47 @@ type ClientIdentityRepo interface {
 	// in a ClientCredentials struct along with the provided ID.
 	New(id string, meta oidc.ClientMetadata) (*oidc.ClientCredentials, error)
 
+	// RotateSecret generates a new secret for the client with the given ID
+	// and returns it in a ClientCredentials struct. Secrets issued before the
+	// rotation remain valid until gracePeriod has elapsed, so a gracePeriod
+	// of zero revokes them immediately. ErrorNotFound is returned if the
+	// client does not exist.
+	RotateSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error)
+
+	// Update replaces the metadata of the client with the given ID.
+	// ErrorNotFound is returned if the client does not exist.
+	Update(clientID string, meta oidc.ClientMetadata) error
+
+	// Delete removes the client with the given ID along with all of its
+	// secrets. ErrorNotFound is returned if the client does not exist.
+	Delete(clientID string) error
+
+	// SetDexAdmin grants or revokes the dex admin status of a client.
+	// ErrorNotFound is returned if the client does not exist.
 	SetDexAdmin(clientID string, isAdmin bool) error
 
 	IsDexAdmin(clientID string) (bool, error)
+
+	// ClaimMapping returns the claim mapping of the client with the given
+	// ID, which is empty if none was set.
+	ClaimMapping(clientID string) (ClaimMapping, error)
+
+	// SetClaimMapping replaces the claim mapping of a client.
+	// ErrorNotFound is returned if the client does not exist.
+	SetClaimMapping(clientID string, m ClaimMapping) error
 }
 
 func NewClientIdentityRepo(cs []oidc.ClientIdentity) ClientIdentityRepo {
+	return NewClientIdentityRepoWithClock(cs, clockwork.NewRealClock())
+}
+
+func NewClientIdentityRepoWithClock(cs []oidc.ClientIdentity, clock clockwork.Clock) ClientIdentityRepo {
 	cr := memClientIdentityRepo{
-		idents: make(map[string]oidc.ClientIdentity, len(cs)),
-		admins: make(map[string]bool),
+		idents:     make(map[string]oidc.ClientIdentity, len(cs)),
+		oldSecrets: make(map[string][]expiringSecret),
+		admins:     make(map[string]bool),
+		claims:     make(map[string]ClaimMapping),
+		clock:      clock,
 	}
 
 	for _, c := range cs {
@@ -60,24 +102,46 @@ func NewClientIdentityRepo(cs []oidc.ClientIdentity) ClientIdentityRepo {
 	return &cr
 }
 
+// expiringSecret is a secret which was replaced by a rotation but is still
+// accepted until expiresAt.
+type expiringSecret struct {
+	secret    string
+	expiresAt time.Time
+}
+
 type memClientIdentityRepo struct {
+	mu     sync.Mutex
 	idents map[string]oidc.ClientIdentity
-	admins map[string]bool
+	// oldSecrets holds the rotated-out secrets of each client.
+	oldSecrets map[string][]expiringSecret
+	admins     map[string]bool
+	claims     map[string]ClaimMapping
+	clock      clockwork.Clock
+}
+
+func newSecret() (string, error) {
+	secret, err := pcrypto.RandBytes(32)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(secret), nil
 }
 
 func (cr *memClientIdentityRepo) New(id string, meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
 	if _, ok := cr.idents[id]; ok {
 		return nil, errors.New("client ID already exists")
 	}
 
-	secret, err := pcrypto.RandBytes(32)
+	secret, err := newSecret()
 	if err != nil {
 		return nil, err
 	}
 
 	cc := oidc.ClientCredentials{
 		ID:     id,
-		Secret: base64.URLEncoding.EncodeToString(secret),
+		Secret: secret,
 	}
 
 	cr.idents[id] = oidc.ClientIdentity{
@@ -89,6 +153,8 @@ func (cr *memClientIdentityRepo) New(id string, meta oidc.ClientMetadata) (*oidc
 }
 
 func (cr *memClientIdentityRepo) Metadata(clientID string) (*oidc.ClientMetadata, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
 	ci, ok := cr.idents[clientID]
 	if !ok {
 		return nil, ErrorNotFound
@@ -97,12 +163,92 @@ func (cr *memClientIdentityRepo) Metadata(clientID string) (*oidc.ClientMetadata
 }
 
 func (cr *memClientIdentityRepo) Authenticate(creds oidc.ClientCredentials) (bool, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
 	ci, ok := cr.idents[creds.ID]
-	ok = ok && ci.Credentials.Secret == creds.Secret
-	return ok, nil
+	if !ok {
+		return false, nil
+	}
+	if ci.Credentials.Secret == creds.Secret {
+		return true, nil
+	}
+
+	now := cr.clock.Now()
+	for _, s := range cr.oldSecrets[creds.ID] {
+		if s.secret == creds.Secret && now.Before(s.expiresAt) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (cr *memClientIdentityRepo) RotateSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	ci, ok := cr.idents[clientID]
+	if !ok {
+		return nil, ErrorNotFound
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	now := cr.clock.Now()
+	expiresAt := now.Add(gracePeriod)
+
+	// No secret may outlive the grace period, including those already on
+	// their way out from previous rotations.
+	var old []expiringSecret
+	prev := append(cr.oldSecrets[clientID], expiringSecret{secret: ci.Credentials.Secret, expiresAt: expiresAt})
+	for _, s := range prev {
+		if expiresAt.Before(s.expiresAt) {
+			s.expiresAt = expiresAt
+		}
+		if now.Before(s.expiresAt) {
+			old = append(old, s)
+		}
+	}
+	cr.oldSecrets[clientID] = old
+
+	ci.Credentials.Secret = secret
+	cr.idents[clientID] = ci
+
+	cc := ci.Credentials
+	return &cc, nil
+}
+
+func (cr *memClientIdentityRepo) Update(clientID string, meta oidc.ClientMetadata) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	ci, ok := cr.idents[clientID]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	ci.Metadata = meta
+	cr.idents[clientID] = ci
+	return nil
+}
+
+func (cr *memClientIdentityRepo) Delete(clientID string) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if _, ok := cr.idents[clientID]; !ok {
+		return ErrorNotFound
+	}
+
+	delete(cr.idents, clientID)
+	delete(cr.oldSecrets, clientID)
+	delete(cr.admins, clientID)
+	delete(cr.claims, clientID)
+	return nil
 }
 
 func (cr *memClientIdentityRepo) All() ([]oidc.ClientIdentity, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
 	cs := make(sortableClientIdentities, 0, len(cr.idents))
 	for _, ci := range cr.idents {
 		ci := ci
@@ -113,14 +259,109 @@ func (cr *memClientIdentityRepo) All() ([]oidc.ClientIdentity, error) {
 }
 
 func (cr *memClientIdentityRepo) SetDexAdmin(clientID string, isAdmin bool) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if _, ok := cr.idents[clientID]; !ok {
+		return ErrorNotFound
+	}
 	cr.admins[clientID] = isAdmin
 	return nil
 }
 
 func (cr *memClientIdentityRepo) IsDexAdmin(clientID string) (bool, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
 	return cr.admins[clientID], nil
 }
 
+func (cr *memClientIdentityRepo) ClaimMapping(clientID string) (ClaimMapping, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	return copyClaimMapping(cr.claims[clientID]), nil
+}
+
+func (cr *memClientIdentityRepo) SetClaimMapping(clientID string, m ClaimMapping) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	if _, ok := cr.idents[clientID]; !ok {
+		return ErrorNotFound
+	}
+	cr.claims[clientID] = copyClaimMapping(m)
+	return nil
+}
+
+// memClientSnapshot is a client in a snapshot of a memClientIdentityRepo.
+type memClientSnapshot struct {
+	Credentials  oidc.ClientCredentials
+	Metadata     oidc.ClientMetadata
+	OldSecrets   []memSecretSnapshot
+	DexAdmin     bool
+	ClaimMapping ClaimMapping
+}
+
+type memSecretSnapshot struct {
+	Secret    string
+	ExpiresAt time.Time
+}
+
+func (cr *memClientIdentityRepo) Snapshot() ([]byte, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cs := make([]memClientSnapshot, 0, len(cr.idents))
+	for id, ci := range cr.idents {
+		c := memClientSnapshot{
+			Credentials:  ci.Credentials,
+			Metadata:     ci.Metadata,
+			DexAdmin:     cr.admins[id],
+			ClaimMapping: cr.claims[id],
+		}
+		for _, s := range cr.oldSecrets[id] {
+			c.OldSecrets = append(c.OldSecrets, memSecretSnapshot{Secret: s.secret, ExpiresAt: s.expiresAt})
+		}
+		cs = append(cs, c)
+	}
+	return json.Marshal(cs)
+}
+
+func (cr *memClientIdentityRepo) Restore(b []byte) error {
+	var cs []memClientSnapshot
+	if err := json.Unmarshal(b, &cs); err != nil {
+		return err
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+	cr.idents = make(map[string]oidc.ClientIdentity, len(cs))
+	cr.oldSecrets = make(map[string][]expiringSecret)
+	cr.admins = make(map[string]bool)
+	cr.claims = make(map[string]ClaimMapping)
+	for _, c := range cs {
+		id := c.Credentials.ID
+		cr.idents[id] = oidc.ClientIdentity{
+			Credentials: c.Credentials,
+			Metadata:    c.Metadata,
+		}
+		for _, s := range c.OldSecrets {
+			cr.oldSecrets[id] = append(cr.oldSecrets[id], expiringSecret{secret: s.Secret, expiresAt: s.ExpiresAt})
+		}
+		if c.DexAdmin {
+			cr.admins[id] = true
+		}
+		if len(c.ClaimMapping) > 0 {
+			cr.claims[id] = c.ClaimMapping
+		}
+	}
+	return nil
+}
+
+func copyClaimMapping(m ClaimMapping) ClaimMapping {
+	c := make(ClaimMapping, len(m))
+	for claim, attr := range m {
+		c[claim] = attr
+	}
+	return c
+}
+
 type sortableClientIdentities []oidc.ClientIdentity
 
 func (s sortableClientIdentities) Len() int {
@@ -6,8 +6,10 @@ import (
 	"reflect"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
 )
 
 func TestMemClientIdentityRepoNew(t *testing.T) {
@@ -92,6 +94,95 @@ func TestMemClientIdentityRepoNewDuplicate(t *testing.T) {
 	}
 }
 
+func TestMemClientIdentityRepoRotateSecret(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	cr := NewClientIdentityRepoWithClock(nil, clock)
+
+	meta := oidc.ClientMetadata{
+		RedirectURIs: []url.URL{
+			url.URL{Scheme: "https", Host: "example.com"},
+		},
+	}
+
+	first, err := cr.New("foo", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	second, err := cr.RotateSecret("foo", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Secret == first.Secret {
+		t.Fatalf("expected a new secret")
+	}
+
+	clock.Advance(30 * time.Minute)
+	third, err := cr.RotateSecret("foo", 2*time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		advance time.Duration
+		valid   map[string]bool
+	}{
+		{
+			advance: 0,
+			valid: map[string]bool{
+				first.Secret:  true,
+				second.Secret: true,
+				third.Secret:  true,
+			},
+		},
+		{
+			// The first secret must not be extended past its original grace period.
+			advance: 45 * time.Minute,
+			valid: map[string]bool{
+				first.Secret:  false,
+				second.Secret: true,
+				third.Secret:  true,
+			},
+		},
+		{
+			advance: 2 * time.Hour,
+			valid: map[string]bool{
+				first.Secret:  false,
+				second.Secret: false,
+				third.Secret:  true,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		clock.Advance(tt.advance)
+		for secret, want := range tt.valid {
+			got, err := cr.Authenticate(oidc.ClientCredentials{ID: "foo", Secret: secret})
+			if err != nil {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			if got != want {
+				t.Errorf("case %d: secret %q: want=%v, got=%v", i, secret, want, got)
+			}
+		}
+	}
+
+	fourth, err := cr.RotateSecret("foo", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ok, _ := cr.Authenticate(oidc.ClientCredentials{ID: "foo", Secret: third.Secret}); ok {
+		t.Errorf("expected secret to be revoked immediately")
+	}
+	if ok, _ := cr.Authenticate(oidc.ClientCredentials{ID: "foo", Secret: fourth.Secret}); !ok {
+		t.Errorf("expected new secret to be valid")
+	}
+
+	if _, err := cr.RotateSecret("bar", time.Hour); err != ErrorNotFound {
+		t.Errorf("want=%v, got=%v", ErrorNotFound, err)
+	}
+}
+
 func TestMemClientIdentityRepoAll(t *testing.T) {
 	tests := []struct {
 		ids []string
@@ -188,3 +279,70 @@ func TestClientIdentityUnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestMemClientIdentityRepoSnapshot(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	cr := NewClientIdentityRepoWithClock(nil, clock)
+	meta := oidc.ClientMetadata{
+		RedirectURIs: []url.URL{
+			url.URL{Scheme: "https", Host: "example.com", Path: "/callback"},
+		},
+	}
+	oldCreds, err := cr.New("foo", meta)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	creds, err := cr.RotateSecret("foo", time.Hour)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cr.SetDexAdmin("foo", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cr.SetClaimMapping("foo", ClaimMapping{"groups": "teams"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := cr.(*memClientIdentityRepo).Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	restored := NewClientIdentityRepoWithClock(nil, clock)
+	if err := restored.(*memClientIdentityRepo).Restore(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := restored.Metadata("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(meta, *got) {
+		t.Errorf("metadata mismatch, want=%v, got=%v", meta, *got)
+	}
+
+	for i, c := range []*oidc.ClientCredentials{creds, oldCreds} {
+		ok, err := restored.Authenticate(*c)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !ok {
+			t.Errorf("case %d: secret not accepted", i)
+		}
+	}
+
+	clock.Advance(2 * time.Hour)
+	if ok, _ := restored.Authenticate(*oldCreds); ok {
+		t.Errorf("expired secret accepted")
+	}
+
+	if admin, _ := restored.IsDexAdmin("foo"); !admin {
+		t.Errorf("dex admin status not restored")
+	}
+	m, err := restored.ClaimMapping("foo")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := (ClaimMapping{"groups": "teams"}); !reflect.DeepEqual(want, m) {
+		t.Errorf("claim mapping mismatch, want=%v, got=%v", want, m)
+	}
+}
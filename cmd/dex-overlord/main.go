@@ -14,12 +14,18 @@ import (
 	"github.com/coreos/go-oidc/key"
 
 	"github.com/coreos/dex/admin"
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/db"
 	pflag "github.com/coreos/dex/pkg/flag"
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
 	ptime "github.com/coreos/dex/pkg/time"
+	"github.com/coreos/dex/redis"
 	"github.com/coreos/dex/server"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
 	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
 )
 
 var version = "DEV"
@@ -38,10 +44,14 @@ func main() {
 
 	dbURL := fs.String("db-url", "", "DSN-formatted database connection string")
 
+	sessionRedisURL := fs.String("session-redis-url", "", "URL of the Redis server in which the workers keep sessions, if they do so; sessions of deleted users are removed from it")
+
 	dbMigrate := fs.Bool("db-migrate", true, "perform database migrations when starting up overlord. This includes the initial DB objects creation.")
 
 	keyPeriod := fs.Duration("key-period", 24*time.Hour, "length of time for-which a given key will be valid")
 	gcInterval := fs.Duration("gc-interval", time.Hour, "length of time between garbage collection runs")
+	auditRetention := fs.Duration("audit-retention", audit.DefaultRetention, "length of time for which audit events are kept; 0 keeps them forever")
+	lockoutResetAfter := fs.Duration("lockout-reset-after", user.DefaultLockoutPolicy.ResetAfter, "period after which failed logins are deleted; set it to the --lockout-reset-after of the workers")
 
 	adminListen := fs.String("admin-listen", "http://127.0.0.1:5557", "scheme, host and port for listening for administrative operation requests ")
 
@@ -49,7 +59,9 @@ func main() {
 	fs.Var(adminAPISecret, "admin-api-secret", fmt.Sprintf("A base64-encoded %d byte string which is used to protect the Admin API.", server.AdminAPISecretLength))
 
 	localConnectorID := fs.String("local-connector", "local", "ID of the local connector")
-	logDebug := fs.Bool("log-debug", false, "log debug-level information")
+	logDebug := fs.Bool("log-debug", false, "log debug-level information; same as --log-level=debug")
+	logLevel := fs.String("log-level", "info", "minimum level of logged lines: debug, info, warning or error")
+	logFormat := fs.String("log-format", string(log.FormatText), "format of log lines: text, json or logfmt")
 	logTimestamps := fs.Bool("log-timestamps", false, "prefix log lines with timestamps")
 
 	printVersion := fs.Bool("version", false, "Print the version and exit")
@@ -69,12 +81,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	if *logDebug {
-		log.EnableDebug()
-	}
-	if *logTimestamps {
-		log.EnableTimestamps()
-	}
+	configureLogging(*logLevel, *logFormat, *logDebug, *logTimestamps)
 
 	adminURL, err := url.Parse(*adminListen)
 	if err != nil {
@@ -113,9 +120,47 @@ func main() {
 	userRepo := db.NewUserRepo(dbc)
 	pwiRepo := db.NewPasswordInfoRepo(dbc)
 	connCfgRepo := db.NewConnectorConfigRepo(dbc)
+	ciRepo := db.NewClientIdentityRepo(dbc)
+	totpRepo, err := db.NewTOTPInfoRepo(dbc, keySecrets.BytesSlice()...)
+	if err != nil {
+		log.Fatalf("Unable to create TOTPInfoRepo: %v", err)
+	}
+	webAuthnRepo := db.NewWebAuthnCredentialRepo(dbc)
+	loginAttemptRepo := db.NewLoginAttemptRepo(dbc)
+	auditRepo := db.NewAuditEventRepo(dbc)
+	webhookRepo, err := db.NewWebhookSubscriptionRepo(dbc, keySecrets.BytesSlice()...)
+	if err != nil {
+		log.Fatalf("Unable to create WebhookSubscriptionRepo: %v", err)
+	}
+	webhookQueue := db.NewWebhookDeliveryQueue(dbc)
+	var sRepo session.SessionRepo = db.NewSessionRepo(dbc)
+	if *sessionRedisURL != "" {
+		rc, err := redis.NewConnection(redis.Config{URL: *sessionRedisURL})
+		if err != nil {
+			log.Fatal(err)
+		}
+		sRepo = redis.NewSessionRepo(rc)
+	}
 	userManager := manager.NewUserManager(userRepo,
-		pwiRepo, connCfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{})
-	adminAPI := admin.NewAdminAPI(userManager, userRepo, pwiRepo, *localConnectorID)
+		pwiRepo, connCfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{
+			RefreshTokenRepo:       db.NewRefreshTokenRepo(dbc),
+			SessionRepo:            sRepo,
+			TOTPInfoRepo:           totpRepo,
+			WebAuthnCredentialRepo: webAuthnRepo,
+			LoginAttemptRepo:       loginAttemptRepo,
+			GroupRepo:              db.NewGroupRepo(dbc),
+			AttributeRepo:          db.NewAttributeRepo(dbc),
+			AuditSink:              auditRepo,
+			WebhookNotifier:        webhook.NewNotifier(webhookRepo, webhookQueue),
+		})
+	loginThrottler := user.NewLoginThrottler(loginAttemptRepo, db.TransactionFactory(dbc), user.DefaultLockoutPolicy)
+
+	reencrypter, err := db.NewReencrypter(dbc, keySecrets.BytesSlice()...)
+	if err != nil {
+		log.Fatalf("Unable to create Reencrypter: %v", err)
+	}
+
+	adminAPI := admin.NewAdminAPI(userManager, userRepo, pwiRepo, ciRepo, totpRepo, webAuthnRepo, loginThrottler, auditRepo, webhookRepo, reencrypter, *localConnectorID)
 	kRepo, err := db.NewPrivateKeySetRepo(dbc, *useOldFormat, keySecrets.BytesSlice()...)
 	if err != nil {
 		log.Fatalf(err.Error())
@@ -144,13 +189,14 @@ func main() {
 
 	krot := key.NewPrivateKeyRotator(kRepo, *keyPeriod)
 	s := server.NewAdminServer(adminAPI, krot, adminAPISecret.String())
-	h := s.HTTPHandler()
+	h := phttp.RequestIDHandler(s.HTTPHandler())
 	httpsrv := &http.Server{
 		Addr:    adminURL.Host,
 		Handler: h,
 	}
 
-	gc := db.NewGarbageCollector(dbc, *gcInterval)
+	gc := db.NewGarbageCollector(dbc, *gcInterval, *auditRetention, *lockoutResetAfter)
+	webhookSender := webhook.NewSender(webhookRepo, webhookQueue)
 
 	log.Infof("Binding to %s...", httpsrv.Addr)
 	go func() {
@@ -158,5 +204,28 @@ func main() {
 	}()
 
 	gc.Run()
+	webhookSender.Run()
 	<-krot.Run()
 }
+
+func configureLogging(level, format string, debug, timestamps bool) {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if debug {
+		lvl = log.LevelDebug
+	}
+	f, err := log.ParseFormat(format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	log.SetLevel(lvl)
+	log.SetFormat(f)
+	if timestamps {
+		log.EnableTimestamps()
+	}
+}
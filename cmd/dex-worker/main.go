@@ -4,22 +4,27 @@ import (
 	"expvar"
 	"flag"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"runtime"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/coreos/pkg/flagutil"
-	"github.com/gorilla/handlers"
 
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
 	pflag "github.com/coreos/dex/pkg/flag"
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
 	ptime "github.com/coreos/dex/pkg/time"
 	"github.com/coreos/dex/server"
+	"github.com/coreos/dex/user"
 )
 
 var version = "DEV"
@@ -49,8 +54,41 @@ func main() {
 	enableRegistration := fs.Bool("enable-registration", false, "Allows users to self-register")
 	enableClientRegistration := fs.Bool("enable-client-registration", false, "Allow dynamic registration of clients")
 
+	webAuthnClients := flagutil.StringSliceFlag{}
+	fs.Var(&webAuthnClients, "webauthn-required-clients", "comma separated list of client IDs for which users must authenticate with a security key")
+	webAuthnConnectors := flagutil.StringSliceFlag{}
+	fs.Var(&webAuthnConnectors, "webauthn-required-connectors", "comma separated list of connector IDs for which users must authenticate with a security key")
+
+	lockoutMaxFailures := fs.Int("lockout-max-failures", user.DefaultLockoutPolicy.MaxFailures, "number of consecutive failed logins after which a local account is locked; 0 disables account lockout")
+	lockoutMaxFailuresPerIP := fs.Int("lockout-max-failures-per-ip", user.DefaultLockoutPolicy.MaxFailuresPerIP, "number of consecutive failed logins from a single address after which logins from it are refused; 0 disables address lockout")
+	lockoutDuration := fs.Duration("lockout-duration", user.DefaultLockoutPolicy.Duration, "how long logins are refused once a limit is reached; doubled with every further failure")
+	lockoutMaxDuration := fs.Duration("lockout-max-duration", user.DefaultLockoutPolicy.MaxDuration, "maximum time for which logins are refused")
+	lockoutResetAfter := fs.Duration("lockout-reset-after", user.DefaultLockoutPolicy.ResetAfter, "period after which failed logins are forgotten")
+
+	trustedProxies := flagutil.StringSliceFlag{}
+	fs.Var(&trustedProxies, "trusted-proxies", "comma separated list of CIDRs of load balancers and proxies whose X-Forwarded-For header gives the client address used for address lockout and logging")
+
+	auditLogFile := fs.String("audit-log-file", "", "file to which audit events are appended as lines of JSON, in addition to being stored")
+
+	sessionRedisURL := fs.String("session-redis-url", "", "URL of a Redis server, of the form redis://[:password@]host[:port][/db], in which to keep sessions instead of the database")
+
+	passwordMinLength := fs.Int("password-min-length", 6, "minimum number of characters in passwords of local users")
+	passwordMinClasses := fs.Int("password-min-character-classes", 0, "minimum number of character classes (lowercase, uppercase, digits, symbols) in passwords of local users")
+	passwordDictionary := fs.String("password-dictionary", "", "file listing common passwords, one per line, which local users may not use")
+	passwordDisallowEmail := fs.Bool("password-disallow-email", false, "reject passwords containing the user's email address")
+	passwordHistory := fs.Int("password-history", 0, "number of a local user's last passwords, including the current one, which may not be reused")
+
+	passwordHash := fs.String("password-hash", user.PasswordHashBcrypt, "algorithm used to hash passwords of local users: bcrypt, argon2id or scrypt; existing passwords are rehashed when their users log in")
+	bcryptCost := fs.Int("bcrypt-cost", 10, "cost of bcrypt password hashes")
+	argon2Time := fs.Int("argon2-time", int(user.DefaultArgon2idAlgorithm.Time), "number of passes of argon2id password hashes")
+	argon2Memory := fs.Int("argon2-memory", int(user.DefaultArgon2idAlgorithm.Memory), "memory in KiB used by argon2id password hashes")
+	argon2Threads := fs.Int("argon2-threads", int(user.DefaultArgon2idAlgorithm.Threads), "degree of parallelism of argon2id password hashes")
+	scryptLogN := fs.Int("scrypt-ln", int(user.DefaultScryptAlgorithm.LogN), "base-2 logarithm of the CPU/memory cost of scrypt password hashes")
+
 	noDB := fs.Bool("no-db", false, "manage entities in-process w/o any encryption, used only for single-node testing")
 
+	useKubernetes := fs.Bool("kubernetes", false, "store entities as custom resources of the Kubernetes cluster this worker runs in, instead of in a database")
+
 	// UI-related:
 	issuerName := fs.String("issuer-name", "dex", "The name of this dex installation; will appear on most pages.")
 	issuerLogoURL := fs.String("issuer-logo-url", "https://coreos.com/assets/images/brand/coreos-wordmark-135x40px.png", "URL of an image representing the issuer")
@@ -67,12 +105,21 @@ func main() {
 	dbMaxOpenConns := fs.Int("db-max-open-conns", 0, "maximum number of open connections to the database")
 	printVersion := fs.Bool("version", false, "Print the version and exit")
 
+	// used only if --kubernetes is set
+	kubernetesNamespace := fs.String("kubernetes-namespace", "", "namespace of the custom resources; defaults to the namespace of the pod")
+	keyPeriod := fs.Duration("key-period", 24*time.Hour, "length of time for-which a given key will be valid")
+	gcInterval := fs.Duration("gc-interval", time.Hour, "length of time between garbage collection runs")
+
 	// used only if --no-db is set
 	connectors := fs.String("connectors", "./static/fixtures/connectors.json", "JSON file containg set of IDPC configs")
 	clients := fs.String("clients", "./static/fixtures/clients.json", "json file containing set of clients")
 	users := fs.String("users", "./static/fixtures/users.json", "json file containing set of users")
+	snapshotFile := fs.String("no-db-snapshot-file", "", "file to which in-process entities are saved, encrypted with --key-secrets, and from which they are restored on startup")
+	snapshotInterval := fs.Duration("no-db-snapshot-interval", time.Minute, "how often in-process entities are saved to --no-db-snapshot-file")
 
-	logDebug := fs.Bool("log-debug", false, "log debug-level information")
+	logDebug := fs.Bool("log-debug", false, "log debug-level information; same as --log-level=debug")
+	logLevel := fs.String("log-level", "info", "minimum level of logged lines: debug, info, warning or error")
+	logFormat := fs.String("log-format", string(log.FormatText), "format of log lines: text, json or logfmt")
 	logTimestamps := fs.Bool("log-timestamps", false, "prefix log lines with timestamps")
 
 	if err := fs.Parse(os.Args[1:]); err != nil {
@@ -90,14 +137,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	configureLogging(*logLevel, *logFormat, *logDebug, *logTimestamps)
 	if *logDebug {
-		log.EnableDebug()
 		log.Infof("Debug logging enabled.")
 		log.Debugf("Debug logging enabled.")
 	}
-	if *logTimestamps {
-		log.EnableTimestamps()
-	}
 
 	// Validate listen address.
 	lu, err := url.Parse(*listen)
@@ -125,6 +169,53 @@ func main() {
 		log.Fatalf("Only 'http' and 'https' schemes are supported")
 	}
 
+	passwordPolicy := user.PasswordPolicy{user.MinLengthRule(*passwordMinLength)}
+	if *passwordMinClasses > 0 {
+		passwordPolicy = append(passwordPolicy, user.CharacterClassesRule(*passwordMinClasses))
+	}
+	if *passwordDictionary != "" {
+		dict, err := user.NewDictionaryRuleFromFile(*passwordDictionary)
+		if err != nil {
+			log.Fatalf("Unable to load password dictionary: %v", err)
+		}
+		passwordPolicy = append(passwordPolicy, dict)
+	}
+	if *passwordDisallowEmail {
+		passwordPolicy = append(passwordPolicy, user.EmailRule{})
+	}
+	if *passwordHistory > 0 {
+		passwordPolicy = append(passwordPolicy, user.HistoryRule(*passwordHistory))
+	}
+
+	switch *passwordHash {
+	case user.PasswordHashBcrypt:
+		user.DefaultPasswordHashAlgorithm = user.BcryptAlgorithm{Cost: *bcryptCost}
+	case user.PasswordHashArgon2id:
+		alg := user.Argon2idAlgorithm{
+			Time:    uint32(*argon2Time),
+			Memory:  uint32(*argon2Memory),
+			Threads: uint8(*argon2Threads),
+		}
+		if int(alg.Time) != *argon2Time || int(alg.Memory) != *argon2Memory || int(alg.Threads) != *argon2Threads {
+			log.Fatalf("--argon2-time, --argon2-memory or --argon2-threads out of range")
+		}
+		user.DefaultPasswordHashAlgorithm = alg
+	case user.PasswordHashScrypt:
+		alg := user.DefaultScryptAlgorithm
+		alg.LogN = uint8(*scryptLogN)
+		if int(alg.LogN) != *scryptLogN {
+			log.Fatalf("--scrypt-ln out of range")
+		}
+		user.DefaultPasswordHashAlgorithm = alg
+	default:
+		log.Fatalf("Unknown password hash algorithm %q", *passwordHash)
+	}
+	// Hashes made with parameters ComparePassword rejects would lock their
+	// users out.
+	if err := user.DefaultPasswordHashAlgorithm.Valid(); err != nil {
+		log.Fatalf("Invalid --password-hash parameters: %v", err)
+	}
+
 	scfg := server.ServerConfig{
 		IssuerURL:                *issuer,
 		TemplateDir:              *templates,
@@ -135,6 +226,25 @@ func main() {
 		IssuerLogoURL:            *issuerLogoURL,
 		EnableRegistration:       *enableRegistration,
 		EnableClientRegistration: *enableClientRegistration,
+
+		WebAuthnRequiredClients:    webAuthnClients,
+		WebAuthnRequiredConnectors: webAuthnConnectors,
+
+		LockoutPolicy: user.LockoutPolicy{
+			MaxFailures:      *lockoutMaxFailures,
+			MaxFailuresPerIP: *lockoutMaxFailuresPerIP,
+			Duration:         *lockoutDuration,
+			MaxDuration:      *lockoutMaxDuration,
+			ResetAfter:       *lockoutResetAfter,
+		},
+		PasswordPolicy: passwordPolicy,
+		AuditLogFile:   *auditLogFile,
+
+		SessionRedisURL: *sessionRedisURL,
+	}
+
+	if *noDB && *useKubernetes {
+		log.Fatalf("Only one of --no-db and --kubernetes may be set")
 	}
 
 	if *noDB {
@@ -143,6 +253,27 @@ func main() {
 			ClientsFile:    *clients,
 			ConnectorsFile: *connectors,
 			UsersFile:      *users,
+
+			SnapshotFile:       *snapshotFile,
+			SnapshotInterval:   *snapshotInterval,
+			SnapshotKeySecrets: keySecrets.BytesSlice(),
+		}
+	} else if *useKubernetes {
+		if len(keySecrets.BytesSlice()) == 0 {
+			log.Fatalf("Must specify at least one key secret")
+		}
+		kCfg, err := kubernetes.InClusterConfig()
+		if err != nil {
+			log.Fatalf("Unable to configure Kubernetes client: %v", err)
+		}
+		if *kubernetesNamespace != "" {
+			kCfg.Namespace = *kubernetesNamespace
+		}
+		scfg.StateConfig = &server.KubernetesServerConfig{
+			KeySecrets: keySecrets.BytesSlice(),
+			Config:     kCfg,
+			KeyPeriod:  *keyPeriod,
+			GCInterval: *gcInterval,
 		}
 	} else {
 		if len(keySecrets.BytesSlice()) == 0 {
@@ -197,7 +328,19 @@ func main() {
 
 	h := srv.HTTPHandler()
 
-	h = handlers.LoggingHandler(log.InfoWriter(), h)
+	h = phttp.LoggingHandler(h)
+
+	if len(trustedProxies) > 0 {
+		var proxies []*net.IPNet
+		for _, cidr := range trustedProxies {
+			_, n, err := net.ParseCIDR(cidr)
+			if err != nil {
+				log.Fatalf("Invalid --trusted-proxies: %v", err)
+			}
+			proxies = append(proxies, n)
+		}
+		h = phttp.TrustedProxyHandler(h, proxies)
+	}
 
 	httpsrv := &http.Server{
 		Addr:    lu.Host,
@@ -213,5 +356,39 @@ func main() {
 		}
 	}()
 
-	<-srv.Run()
+	stop := srv.Run()
+
+	sigc := make(chan os.Signal, 1)
+	signal.Notify(sigc, os.Interrupt, syscall.SIGTERM)
+	sig := <-sigc
+	log.Infof("Received %v, shutting down", sig)
+	close(stop)
+
+	if srv.Snapshots != nil {
+		if err := srv.Snapshots.Save(); err != nil {
+			log.Fatalf("Unable to save snapshot: %v", err)
+		}
+	}
+}
+
+func configureLogging(level, format string, debug, timestamps bool) {
+	lvl, err := log.ParseLevel(level)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if debug {
+		lvl = log.LevelDebug
+	}
+	f, err := log.ParseFormat(format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+
+	log.SetLevel(lvl)
+	log.SetFormat(f)
+	if timestamps {
+		log.EnableTimestamps()
+	}
 }
@@ -1,10 +1,15 @@
 package main
 
 import (
+	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/spf13/cobra"
+
+	"github.com/coreos/dex/client"
 )
 
 var (
@@ -15,27 +20,95 @@ var (
 		Example: `  dexctl new-client --db-url=${DB_URL} 'https://example.com/callback'`,
 		Run:     wrapRun(runNewClient),
 	}
+
+	cmdRotateClientSecret = &cobra.Command{
+		Use:     "rotate-client-secret",
+		Short:   "Issue a new secret for a client.",
+		Long:    "Issue a new secret for a client. Previous secrets remain valid for the duration given by --grace-period.",
+		Example: `  dexctl rotate-client-secret --db-url=${DB_URL} --grace-period=1h ${CLIENT_ID}`,
+		Run:     wrapRun(runRotateClientSecret),
+	}
+
+	cmdListClients = &cobra.Command{
+		Use:     "list-clients",
+		Short:   "List all clients.",
+		Long:    "List all clients, one per line, along with their redirect URLs.",
+		Example: `  dexctl list-clients --db-url=${DB_URL}`,
+		Run:     wrapRun(runListClients),
+	}
+
+	cmdGetClient = &cobra.Command{
+		Use:     "get-client",
+		Short:   "Show a single client.",
+		Long:    "Show the ID and redirect URLs of a single client.",
+		Example: `  dexctl get-client --db-url=${DB_URL} ${CLIENT_ID}`,
+		Run:     wrapRun(runGetClient),
+	}
+
+	cmdUpdateClient = &cobra.Command{
+		Use:     "update-client",
+		Short:   "Replace the redirect URLs of a client.",
+		Long:    "Replace the redirect URLs of a client with one or more new ones.",
+		Example: `  dexctl update-client --db-url=${DB_URL} ${CLIENT_ID} 'https://example.com/callback'`,
+		Run:     wrapRun(runUpdateClient),
+	}
+
+	cmdDeleteClient = &cobra.Command{
+		Use:     "delete-client",
+		Short:   "Delete a client.",
+		Long:    "Delete a client along with all of its secrets.",
+		Example: `  dexctl delete-client --db-url=${DB_URL} ${CLIENT_ID}`,
+		Run:     wrapRun(runDeleteClient),
+	}
+
+	cmdSetClientAdmin = &cobra.Command{
+		Use:     "set-client-admin",
+		Short:   "Grant or revoke the admin status of a client.",
+		Long:    "Grant or revoke the admin status of a client. Admin clients may manage other clients and users through the API.",
+		Example: `  dexctl set-client-admin --db-url=${DB_URL} --admin=false ${CLIENT_ID}`,
+		Run:     wrapRun(runSetClientAdmin),
+	}
+
+	rotateClientSecretGracePeriod time.Duration
+	setClientAdminIsAdmin         bool
 )
 
 func init() {
 	rootCmd.AddCommand(cmdNewClient)
-}
+	rootCmd.AddCommand(cmdRotateClientSecret)
+	rootCmd.AddCommand(cmdListClients)
+	rootCmd.AddCommand(cmdGetClient)
+	rootCmd.AddCommand(cmdUpdateClient)
+	rootCmd.AddCommand(cmdDeleteClient)
+	rootCmd.AddCommand(cmdSetClientAdmin)
 
-func runNewClient(cmd *cobra.Command, args []string) int {
-	if len(args) < 1 {
-		stderr("Provide at least one redirect URL.")
-		return 2
-	}
+	cmdRotateClientSecret.Flags().DurationVar(&rotateClientSecretGracePeriod, "grace-period", client.DefaultSecretRotationGracePeriod, "Length of time for which the previous secrets remain valid. Zero revokes them immediately.")
+	cmdSetClientAdmin.Flags().BoolVar(&setClientAdminIsAdmin, "admin", true, "Whether the client should be an admin client.")
+}
 
+func parseRedirectURLs(args []string) ([]url.URL, error) {
 	redirectURLs := make([]url.URL, len(args))
 	for i, ua := range args {
 		u, err := url.Parse(ua)
 		if err != nil {
-			stderr("Malformed URL %q: %v", ua, err)
-			return 1
+			return nil, fmt.Errorf("malformed URL %q: %v", ua, err)
 		}
 		redirectURLs[i] = *u
 	}
+	return redirectURLs, nil
+}
+
+func runNewClient(cmd *cobra.Command, args []string) int {
+	if len(args) < 1 {
+		stderr("Provide at least one redirect URL.")
+		return 2
+	}
+
+	redirectURLs, err := parseRedirectURLs(args)
+	if err != nil {
+		stderr("Invalid redirect URL: %v", err)
+		return 1
+	}
 
 	cc, err := getDriver().NewClient(oidc.ClientMetadata{RedirectURIs: redirectURLs})
 	if err != nil {
@@ -52,3 +125,125 @@ func runNewClient(cmd *cobra.Command, args []string) int {
 
 	return 0
 }
+
+func runRotateClientSecret(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	if rotateClientSecretGracePeriod < 0 {
+		stderr("--grace-period must not be negative.")
+		return 2
+	}
+
+	cc, err := getDriver().RotateClientSecret(args[0], rotateClientSecretGracePeriod)
+	if err != nil {
+		stderr("Failed rotating client secret: %v", err)
+		return 1
+	}
+
+	stdout("# Rotated client secret:")
+	stdout("DEX_APP_CLIENT_ID=%s", cc.ID)
+	stdout("DEX_APP_CLIENT_SECRET=%s", cc.Secret)
+
+	return 0
+}
+
+func runListClients(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	cis, err := getDriver().Clients()
+	if err != nil {
+		stderr("Failed listing clients: %v", err)
+		return 1
+	}
+
+	for _, ci := range cis {
+		redirectURLs := make([]string, len(ci.Metadata.RedirectURIs))
+		for i, u := range ci.Metadata.RedirectURIs {
+			redirectURLs[i] = u.String()
+		}
+		stdout("%s\t%s", ci.Credentials.ID, strings.Join(redirectURLs, " "))
+	}
+
+	return 0
+}
+
+func runGetClient(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	ci, err := getDriver().Client(args[0])
+	if err != nil {
+		stderr("Failed retrieving client: %v", err)
+		return 1
+	}
+
+	stdout("DEX_APP_CLIENT_ID=%s", ci.Credentials.ID)
+	for i, u := range ci.Metadata.RedirectURIs {
+		stdout("DEX_APP_REDIRECTURL_%d=%s", i, u.String())
+	}
+
+	return 0
+}
+
+func runUpdateClient(cmd *cobra.Command, args []string) int {
+	if len(args) < 2 {
+		stderr("Provide a client ID and at least one redirect URL.")
+		return 2
+	}
+
+	redirectURLs, err := parseRedirectURLs(args[1:])
+	if err != nil {
+		stderr("Invalid redirect URL: %v", err)
+		return 1
+	}
+
+	if err := getDriver().UpdateClient(args[0], oidc.ClientMetadata{RedirectURIs: redirectURLs}); err != nil {
+		stderr("Failed updating client: %v", err)
+		return 1
+	}
+
+	stdout("Updated client %s", args[0])
+	return 0
+}
+
+func runDeleteClient(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	if err := getDriver().DeleteClient(args[0]); err != nil {
+		stderr("Failed deleting client: %v", err)
+		return 1
+	}
+
+	stdout("Deleted client %s", args[0])
+	return 0
+}
+
+func runSetClientAdmin(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	if err := getDriver().SetClientAdmin(args[0], setClientAdminIsAdmin); err != nil {
+		stderr("Failed setting client admin status: %v", err)
+		return 1
+	}
+
+	if setClientAdminIsAdmin {
+		stdout("Client %s is now an admin client", args[0])
+	} else {
+		stdout("Client %s is no longer an admin client", args[0])
+	}
+	return 0
+}
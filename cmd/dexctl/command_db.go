@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/coreos/dex/db"
+	flagutil "github.com/coreos/dex/pkg/flag"
+	"github.com/go-gorp/gorp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdDB = &cobra.Command{
+		Use:   "db",
+		Short: "Manage the migrations of the database.",
+		Run: func(cmd *cobra.Command, args []string) {
+			cmd.Help()
+			os.Exit(2)
+		},
+	}
+
+	cmdDBStatus = &cobra.Command{
+		Use:     "status",
+		Short:   "List the migrations of the database and whether they were applied.",
+		Example: `  dexctl db status --db-url=${DB_URL}`,
+		Run:     wrapRun(runDBStatus),
+	}
+
+	cmdDBPlan = &cobra.Command{
+		Use:     "plan",
+		Short:   "List the migrations the overlord would apply on startup.",
+		Example: `  dexctl db plan --db-url=${DB_URL} --sql`,
+		Run:     wrapRun(runDBPlan),
+	}
+
+	cmdDBMigrate = &cobra.Command{
+		Use:     "migrate",
+		Short:   "Migrate the database to the latest or a given version.",
+		Long:    "Apply the migrations up to the version given with --to, or all of them, and roll back those after it. --to=0 rolls back every migration. Concurrent overlords wait until the migrations are done.",
+		Example: `  dexctl db migrate --db-url=${DB_URL} --to=22`,
+		Run:     wrapRun(runDBMigrate),
+	}
+
+	cmdDBReencrypt = &cobra.Command{
+		Use:     "reencrypt",
+		Short:   "Re-encrypt the signing keys and other encrypted data with the active key secret.",
+		Long:    "Re-encrypt the signing keys, TOTP secrets and webhook secrets with the first of --key-secrets, in the current format, and list the rows which were not encrypted that way. Rows which none of the key secrets decrypts are listed and left as they are.",
+		Example: `  dexctl db reencrypt --db-url=${DB_URL} --key-secrets=${NEW_KEY_SECRET},${OLD_KEY_SECRET} --dry-run`,
+		Run:     wrapRun(runDBReencrypt),
+	}
+
+	dbPlanSQL bool
+
+	dbMigrateTo int64
+
+	dbReencryptDryRun bool
+)
+
+func init() {
+	rootCmd.AddCommand(cmdDB)
+	cmdDB.AddCommand(cmdDBStatus)
+	cmdDB.AddCommand(cmdDBPlan)
+	cmdDB.AddCommand(cmdDBMigrate)
+	cmdDB.AddCommand(cmdDBReencrypt)
+
+	cmdDBPlan.Flags().BoolVar(&dbPlanSQL, "sql", false, "Print the statements of each migration.")
+	cmdDBMigrate.Flags().Int64Var(&dbMigrateTo, "to", -1, "Version to migrate to. Defaults to the latest.")
+	cmdDBReencrypt.Flags().BoolVar(&dbReencryptDryRun, "dry-run", false, "Only list the rows which would be re-encrypted.")
+}
+
+func getDBConnection() (*gorp.DbMap, error) {
+	if global.dbURL == "" {
+		return nil, errors.New("--db-url flag unset")
+	}
+	return db.NewConnection(db.Config{DSN: global.dbURL})
+}
+
+func runDBStatus(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	dbc, err := getDBConnection()
+	if err != nil {
+		stderr("Unable to connect to database: %v", err)
+		return 1
+	}
+	ms, err := db.GetMigrationStatus(dbc)
+	if err != nil {
+		stderr("Failed getting migrations: %v", err)
+		return 1
+	}
+
+	for _, m := range ms {
+		applied := "pending"
+		if m.AppliedAt != nil {
+			applied = m.AppliedAt.UTC().Format(time.RFC3339)
+		}
+		if !m.Known {
+			applied += " (unknown to this version of dex)"
+		}
+		stdout("%d\t%s\t%s", m.Version, m.ID, applied)
+	}
+	return 0
+}
+
+func runDBPlan(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	dbc, err := getDBConnection()
+	if err != nil {
+		stderr("Unable to connect to database: %v", err)
+		return 1
+	}
+	ms, err := db.GetPlannedMigrations(dbc)
+	if err != nil {
+		stderr("Failed planning migrations: %v", err)
+		return 1
+	}
+
+	if len(ms) == 0 {
+		stdout("# Database is up to date")
+		return 0
+	}
+	for _, m := range ms {
+		stdout("%s", m.Id)
+		if dbPlanSQL {
+			for _, q := range m.Queries {
+				stdout("%s", strings.TrimSpace(q))
+			}
+		}
+	}
+	return 0
+}
+
+func runDBMigrate(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	dbc, err := getDBConnection()
+	if err != nil {
+		stderr("Unable to connect to database: %v", err)
+		return 1
+	}
+
+	var n int
+	if dbMigrateTo < 0 {
+		n, err = db.MigrateToLatest(dbc)
+	} else {
+		n, err = db.MigrateTo(dbc, dbMigrateTo)
+	}
+	if err != nil {
+		stderr("Failed migrating database after %d migrations: %v", n, err)
+		return 1
+	}
+
+	stdout("Performed %d migrations", n)
+	return 0
+}
+
+func runDBReencrypt(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	keySecrets := flagutil.NewBase64List(32)
+	if err := keySecrets.Set(global.keySecrets); err != nil {
+		stderr("Invalid --key-secrets: %v", err)
+		return 2
+	}
+	if len(keySecrets.BytesSlice()) == 0 {
+		stderr("--key-secrets flag unset")
+		return 2
+	}
+
+	dbc, err := getDBConnection()
+	if err != nil {
+		stderr("Unable to connect to database: %v", err)
+		return 1
+	}
+	r, err := db.NewReencrypter(dbc, keySecrets.BytesSlice()...)
+	if err != nil {
+		stderr("Unable to create re-encrypter: %v", err)
+		return 1
+	}
+	report, err := r.Reencrypt(dbReencryptDryRun)
+	if err != nil {
+		stderr("Failed re-encrypting: %v", err)
+		return 1
+	}
+
+	for _, rec := range report.Stale {
+		var state string
+		switch {
+		case rec.Secret < 0:
+			state = "no key secret decrypts it"
+		case rec.DeprecatedFormat:
+			state = fmt.Sprintf("key secret %d, deprecated format", rec.Secret)
+		default:
+			state = fmt.Sprintf("key secret %d", rec.Secret)
+		}
+		stdout("%s\t%s\t%s", rec.Table, rec.ID, state)
+	}
+	if dbReencryptDryRun {
+		stdout("# Would re-encrypt %d of %d rows", len(report.Stale)-len(report.Undecryptable()), report.Records)
+	} else {
+		stdout("# Re-encrypted %d of %d rows", report.Reencrypted, report.Records)
+	}
+
+	if n := len(report.Undecryptable()); n > 0 {
+		stderr("%d rows could not be decrypted with any of the key secrets", n)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"io"
+	"os"
+
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdDeleteUser = &cobra.Command{
+		Use:     "delete-user",
+		Short:   "Delete a user.",
+		Long:    "Delete a user for good, along with their password, remote identities, second factors, refresh tokens and sessions.",
+		Example: `  dexctl delete-user --db-url=${DB_URL} --key-secrets=${KEY_SECRETS} ${USER_ID}`,
+		Run:     wrapRun(runDeleteUser),
+	}
+
+	cmdExportUsers = &cobra.Command{
+		Use:     "export-users",
+		Short:   "Export all users with their remote identities and password hashes.",
+		Long:    "Export all users with their remote identities and password hashes, as JSON lines or CSV.",
+		Example: `  dexctl export-users --db-url=${DB_URL} --format=csv --output=users.csv`,
+		Run:     wrapRun(runExportUsers),
+	}
+
+	cmdImportUsers = &cobra.Command{
+		Use:     "import-users",
+		Short:   "Import users from a local file. Provide the argument '-' to read from stdin.",
+		Long:    "Import users with their remote identities and password hashes from a local file, as written by export-users. Provide the argument '-' to read from stdin.",
+		Example: `  dexctl import-users --db-url=${DB_URL} --on-conflict=update --dry-run ./users.jsonl`,
+		Run:     wrapRun(runImportUsers),
+	}
+
+	exportUsersFormat string
+	exportUsersOutput string
+
+	importUsersFormat     string
+	importUsersOnConflict string
+	importUsersBatchSize  int
+	importUsersDryRun     bool
+)
+
+func init() {
+	rootCmd.AddCommand(cmdDeleteUser)
+	rootCmd.AddCommand(cmdExportUsers)
+	rootCmd.AddCommand(cmdImportUsers)
+
+	cmdExportUsers.Flags().StringVar(&exportUsersFormat, "format", user.RecordFormatJSONL, "Format of the exported users, either jsonl or csv.")
+	cmdExportUsers.Flags().StringVar(&exportUsersOutput, "output", "-", "File to write the exported users to. Defaults to stdout.")
+
+	cmdImportUsers.Flags().StringVar(&importUsersFormat, "format", user.RecordFormatJSONL, "Format of the imported users, either jsonl or csv.")
+	cmdImportUsers.Flags().StringVar(&importUsersOnConflict, "on-conflict", manager.ImportFail, "What to do with users that already exist: skip, update or fail.")
+	cmdImportUsers.Flags().IntVar(&importUsersBatchSize, "batch-size", manager.DefaultImportBatchSize, "Number of users imported in one transaction.")
+	cmdImportUsers.Flags().BoolVar(&importUsersDryRun, "dry-run", false, "Only check which users would be created, updated or skipped.")
+}
+
+func runDeleteUser(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	if err := getDriver().DeleteUser(args[0]); err != nil {
+		stderr("Failed deleting user: %v", err)
+		return 1
+	}
+
+	stdout("Deleted user %s", args[0])
+	return 0
+}
+
+func runExportUsers(cmd *cobra.Command, args []string) int {
+	if len(args) != 0 {
+		stderr("Provide zero arguments.")
+		return 2
+	}
+
+	var w io.Writer
+	if exportUsersOutput == "-" {
+		w = os.Stdout
+	} else {
+		f, err := os.Create(exportUsersOutput)
+		if err != nil {
+			stderr("Unable to create specified file: %v", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+
+	rw, err := user.NewUserRecordWriter(w, exportUsersFormat)
+	if err != nil {
+		stderr("Invalid --format %q: %v", exportUsersFormat, err)
+		return 2
+	}
+
+	if err := getDriver().ExportUsers(rw); err != nil {
+		stderr("Failed exporting users: %v", err)
+		return 1
+	}
+	return 0
+}
+
+func runImportUsers(cmd *cobra.Command, args []string) int {
+	if len(args) != 1 {
+		stderr("Provide a single argument.")
+		return 2
+	}
+
+	var r io.Reader
+	if from := args[0]; from == "-" {
+		r = os.Stdin
+	} else {
+		f, err := os.Open(from)
+		if err != nil {
+			stderr("Unable to open specified file: %v", err)
+			return 1
+		}
+		defer f.Close()
+		r = f
+	}
+
+	rr, err := user.NewUserRecordReader(r, importUsersFormat)
+	if err != nil {
+		stderr("Invalid --format %q: %v", importUsersFormat, err)
+		return 2
+	}
+
+	opts := manager.ImportOptions{
+		OnConflict: importUsersOnConflict,
+		BatchSize:  importUsersBatchSize,
+		DryRun:     importUsersDryRun,
+	}
+	res, err := getDriver().ImportUsers(rr, opts)
+	if err != nil {
+		stderr("Failed importing users: %v", err)
+		if res.Created+res.Updated+res.Skipped > 0 {
+			stderr("Imported before failing: %d created, %d updated, %d skipped", res.Created, res.Updated, res.Skipped)
+		}
+		return 1
+	}
+
+	if importUsersDryRun {
+		stdout("Would import users: %d created, %d updated, %d skipped", res.Created, res.Updated, res.Skipped)
+	} else {
+		stdout("Imported users: %d created, %d updated, %d skipped", res.Created, res.Updated, res.Skipped)
+	}
+	return 0
+}
@@ -1,12 +1,26 @@
 package main
 
 import (
+	"time"
+
 	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
 	"github.com/coreos/go-oidc/oidc"
 )
 
 type driver interface {
 	NewClient(oidc.ClientMetadata) (*oidc.ClientCredentials, error)
+	RotateClientSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error)
+	Clients() ([]oidc.ClientIdentity, error)
+	Client(clientID string) (*oidc.ClientIdentity, error)
+	UpdateClient(clientID string, meta oidc.ClientMetadata) error
+	DeleteClient(clientID string) error
+	SetClientAdmin(clientID string, isAdmin bool) error
+
+	DeleteUser(userID string) error
+	ImportUsers(r user.UserRecordReader, opts manager.ImportOptions) (manager.ImportResult, error)
+	ExportUsers(w user.UserRecordWriter) error
 
 	ConnectorConfigs() ([]connector.ConnectorConfig, error)
 	SetConnectorConfigs([]connector.ConnectorConfig) error
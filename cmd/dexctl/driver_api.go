@@ -3,9 +3,13 @@ package main
 import (
 	"errors"
 	"net/http"
+	"net/url"
+	"time"
 
 	"github.com/coreos/dex/connector"
 	schema "github.com/coreos/dex/schema/workerschema"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
 	"github.com/coreos/go-oidc/oidc"
 )
 
@@ -41,11 +45,7 @@ type apiDriver struct {
 
 func (d *apiDriver) NewClient(meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
 	sc := &schema.Client{
-		RedirectURIs: make([]string, len(meta.RedirectURIs)),
-	}
-
-	for i, u := range meta.RedirectURIs {
-		sc.RedirectURIs[i] = u.String()
+		RedirectURIs: urlsToStrings(meta.RedirectURIs),
 	}
 
 	call := d.svc.Clients.Create(sc)
@@ -62,6 +62,100 @@ func (d *apiDriver) NewClient(meta oidc.ClientMetadata) (*oidc.ClientCredentials
 	return creds, nil
 }
 
+func (d *apiDriver) RotateClientSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error) {
+	req := &schema.ClientRotateSecretRequest{
+		GracePeriodSeconds: int64(gracePeriod / time.Second),
+	}
+
+	call := d.svc.Clients.RotateSecret(clientID, req)
+	resp, err := call.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	creds := &oidc.ClientCredentials{
+		ID:     resp.Id,
+		Secret: resp.Secret,
+	}
+
+	return creds, nil
+}
+
+func (d *apiDriver) Clients() ([]oidc.ClientIdentity, error) {
+	page, err := d.svc.Clients.List().Do()
+	if err != nil {
+		return nil, err
+	}
+
+	cis := make([]oidc.ClientIdentity, len(page.Clients))
+	for i, sc := range page.Clients {
+		ci, err := schema.MapSchemaClientToClientIdentity(*sc)
+		if err != nil {
+			return nil, err
+		}
+		cis[i] = ci
+	}
+
+	return cis, nil
+}
+
+func (d *apiDriver) Client(clientID string) (*oidc.ClientIdentity, error) {
+	sc, err := d.svc.Clients.Get(clientID).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	ci, err := schema.MapSchemaClientToClientIdentity(*sc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ci, nil
+}
+
+func (d *apiDriver) UpdateClient(clientID string, meta oidc.ClientMetadata) error {
+	sc := &schema.Client{
+		Id:           clientID,
+		RedirectURIs: urlsToStrings(meta.RedirectURIs),
+	}
+
+	_, err := d.svc.Clients.Update(clientID, sc).Do()
+	return err
+}
+
+func (d *apiDriver) DeleteClient(clientID string) error {
+	return d.svc.Clients.Delete(clientID).Do()
+}
+
+func (d *apiDriver) SetClientAdmin(clientID string, isAdmin bool) error {
+	req := &schema.ClientSetAdminRequest{
+		IsAdmin: isAdmin,
+	}
+
+	return d.svc.Clients.SetAdmin(clientID, req).Do()
+}
+
+func (d *apiDriver) DeleteUser(userID string) error {
+	_, err := d.svc.Users.Delete(userID).Do()
+	return err
+}
+
+func (d *apiDriver) ImportUsers(r user.UserRecordReader, opts manager.ImportOptions) (manager.ImportResult, error) {
+	return manager.ImportResult{}, errors.New("unable to import users through HTTP API")
+}
+
+func (d *apiDriver) ExportUsers(w user.UserRecordWriter) error {
+	return errors.New("unable to export users through HTTP API")
+}
+
+func urlsToStrings(us []url.URL) []string {
+	ss := make([]string, len(us))
+	for i, u := range us {
+		ss[i] = u.String()
+	}
+	return ss
+}
+
 func (d *apiDriver) ConnectorConfigs() ([]connector.ConnectorConfig, error) {
 	return nil, errors.New("unable to get connector configs from HTTP API")
 }
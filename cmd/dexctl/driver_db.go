@@ -1,13 +1,18 @@
 package main
 
 import (
+	"errors"
+	"time"
+
 	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
 	"github.com/coreos/go-oidc/oidc"
 )
 
-func newDBDriver(dsn string) (driver, error) {
+func newDBDriver(dsn string, keySecrets [][]byte) (driver, error) {
 	dbc, err := db.NewConnection(db.Config{DSN: dsn})
 	if err != nil {
 		return nil, err
@@ -18,12 +23,39 @@ func newDBDriver(dsn string) (driver, error) {
 		cfgRepo: db.NewConnectorConfigRepo(dbc),
 	}
 
+	// Importing and exporting users touches no encrypted data, so it needs
+	// none of the further repos of users.
+	drv.userIOManager = manager.NewUserManager(db.NewUserRepo(dbc), db.NewPasswordInfoRepo(dbc),
+		drv.cfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{})
+
+	// The TOTP secrets of users are encrypted, so their repo, and with it
+	// the deletion of users, is only available given the key secrets.
+	if len(keySecrets) > 0 {
+		totpRepo, err := db.NewTOTPInfoRepo(dbc, keySecrets...)
+		if err != nil {
+			return nil, err
+		}
+		drv.userManager = manager.NewUserManager(db.NewUserRepo(dbc), db.NewPasswordInfoRepo(dbc),
+			drv.cfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{
+				RefreshTokenRepo:       db.NewRefreshTokenRepo(dbc),
+				SessionRepo:            db.NewSessionRepo(dbc),
+				TOTPInfoRepo:           totpRepo,
+				WebAuthnCredentialRepo: db.NewWebAuthnCredentialRepo(dbc),
+				LoginAttemptRepo:       db.NewLoginAttemptRepo(dbc),
+				GroupRepo:              db.NewGroupRepo(dbc),
+				AttributeRepo:          db.NewAttributeRepo(dbc),
+			})
+	}
+
 	return drv, nil
 }
 
 type dbDriver struct {
-	ciRepo  client.ClientIdentityRepo
-	cfgRepo *db.ConnectorConfigRepo
+	ciRepo      client.ClientIdentityRepo
+	cfgRepo     *db.ConnectorConfigRepo
+	userManager *manager.UserManager
+
+	userIOManager *manager.UserManager
 }
 
 func (d *dbDriver) NewClient(meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
@@ -39,6 +71,62 @@ func (d *dbDriver) NewClient(meta oidc.ClientMetadata) (*oidc.ClientCredentials,
 	return d.ciRepo.New(clientID, meta)
 }
 
+func (d *dbDriver) RotateClientSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error) {
+	return d.ciRepo.RotateSecret(clientID, gracePeriod)
+}
+
+func (d *dbDriver) Clients() ([]oidc.ClientIdentity, error) {
+	return d.ciRepo.All()
+}
+
+func (d *dbDriver) Client(clientID string) (*oidc.ClientIdentity, error) {
+	meta, err := d.ciRepo.Metadata(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	ci := &oidc.ClientIdentity{
+		Credentials: oidc.ClientCredentials{ID: clientID},
+		Metadata:    *meta,
+	}
+
+	return ci, nil
+}
+
+func (d *dbDriver) UpdateClient(clientID string, meta oidc.ClientMetadata) error {
+	if err := meta.Valid(); err != nil {
+		return err
+	}
+
+	return d.ciRepo.Update(clientID, meta)
+}
+
+func (d *dbDriver) DeleteClient(clientID string) error {
+	return d.ciRepo.Delete(clientID)
+}
+
+func (d *dbDriver) SetClientAdmin(clientID string, isAdmin bool) error {
+	return d.ciRepo.SetDexAdmin(clientID, isAdmin)
+}
+
+func (d *dbDriver) DeleteUser(userID string) error {
+	if d.userManager == nil {
+		return errors.New("--key-secrets flag unset")
+	}
+	return d.userManager.Delete(userID)
+}
+
+func (d *dbDriver) ImportUsers(r user.UserRecordReader, opts manager.ImportOptions) (manager.ImportResult, error) {
+	return d.userIOManager.ImportUsers(r, opts)
+}
+
+func (d *dbDriver) ExportUsers(w user.UserRecordWriter) error {
+	if err := d.userIOManager.ExportUsers(w.Write); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
 func (d *dbDriver) ConnectorConfigs() ([]connector.ConnectorConfig, error) {
 	return d.cfgRepo.All()
 }
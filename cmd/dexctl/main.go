@@ -2,10 +2,12 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"strings"
 
+	flagutil "github.com/coreos/dex/pkg/flag"
 	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/spf13/cobra"
@@ -44,11 +46,12 @@ var (
 	}
 
 	global struct {
-		endpoint string
-		creds    oidc.ClientCredentials
-		dbURL    string
-		help     bool
-		logDebug bool
+		endpoint   string
+		creds      oidc.ClientCredentials
+		dbURL      string
+		keySecrets string
+		help       bool
+		logDebug   bool
 	}
 )
 
@@ -59,6 +62,7 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&global.creds.ID, "client-id", "", "dex API user ID")
 	rootCmd.PersistentFlags().StringVar(&global.creds.Secret, "client-secret", "", "dex API user password")
 	rootCmd.PersistentFlags().StringVar(&global.dbURL, "db-url", "", "DSN-formatted database connection string")
+	rootCmd.PersistentFlags().StringVar(&global.keySecrets, "key-secrets", "", "A comma-separated list of base64 encoded 32 byte strings used as symmetric keys to encrypt data in the DB. Needed with --db-url to delete users and to re-encrypt data.")
 	rootCmd.PersistentFlags().BoolVar(&global.logDebug, "log-debug", false, "Log debug-level information")
 }
 
@@ -78,7 +82,12 @@ func getDriver() (drv driver) {
 	var err error
 	switch {
 	case len(global.dbURL) > 0:
-		drv, err = newDBDriver(global.dbURL)
+		keySecrets := flagutil.NewBase64List(32)
+		if err = keySecrets.Set(global.keySecrets); err != nil {
+			err = fmt.Errorf("invalid --key-secrets: %v", err)
+			break
+		}
+		drv, err = newDBDriver(global.dbURL, keySecrets.BytesSlice())
 	case len(global.endpoint) > 0:
 		if len(global.creds.ID) == 0 || len(global.creds.Secret) == 0 {
 			err = errors.New("--client-id/--client-secret flags unset")
@@ -11,6 +11,8 @@ import (
 	chttp "github.com/coreos/go-oidc/http"
 	"github.com/coreos/go-oidc/oauth2"
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 const (
@@ -39,7 +41,7 @@ func (cfg *BitbucketConnectorConfig) ConnectorType() string {
 	return BitbucketConnectorType
 }
 
-func (cfg *BitbucketConnectorConfig) Connector(ns url.URL, lf oidc.LoginFunc, tpls *template.Template) (Connector, error) {
+func (cfg *BitbucketConnectorConfig) Connector(ns url.URL, lf LoginFunc, tpls *template.Template) (Connector, error) {
 	ns.Path = path.Join(ns.Path, httpPathCallback)
 	oauth2Conn, err := newBitbucketConnector(cfg.ClientID, cfg.ClientSecret, ns.String())
 	if err != nil {
@@ -84,19 +86,24 @@ func (c *bitbucketOAuth2Connector) Client() *oauth2.Client {
 	return c.client
 }
 
-func (c *bitbucketOAuth2Connector) Identity(cli chttp.Client) (oidc.Identity, error) {
-	var user struct {
+func (c *bitbucketOAuth2Connector) Identity(cli chttp.Client) (oidc.Identity, user.Profile, error) {
+	var u struct {
 		UUID        string `json:"uuid"`
 		Username    string `json:"username"`
 		DisplayName string `json:"display_name"`
+		Links       struct {
+			Avatar struct {
+				Href string `json:"href"`
+			} `json:"avatar"`
+		} `json:"links"`
 	}
-	if err := getAndDecode(cli, bitbucketAPIUserURL, &user); err != nil {
-		return oidc.Identity{}, fmt.Errorf("getting user info: %v", err)
+	if err := getAndDecode(cli, bitbucketAPIUserURL, &u); err != nil {
+		return oidc.Identity{}, user.Profile{}, fmt.Errorf("getting user info: %v", err)
 	}
 
-	name := user.DisplayName
+	name := u.DisplayName
 	if name == "" {
-		name = user.Username
+		name = u.Username
 	}
 
 	var emails struct {
@@ -107,7 +114,7 @@ func (c *bitbucketOAuth2Connector) Identity(cli chttp.Client) (oidc.Identity, er
 		} `json:"values"`
 	}
 	if err := getAndDecode(cli, bitbucketAPIEmailURL, &emails); err != nil {
-		return oidc.Identity{}, fmt.Errorf("getting user email: %v", err)
+		return oidc.Identity{}, user.Profile{}, fmt.Errorf("getting user email: %v", err)
 	}
 	email := ""
 	for _, val := range emails.Values {
@@ -122,11 +129,12 @@ func (c *bitbucketOAuth2Connector) Identity(cli chttp.Client) (oidc.Identity, er
 		}
 	}
 
-	return oidc.Identity{
-		ID:    user.UUID,
+	ident := oidc.Identity{
+		ID:    u.UUID,
 		Name:  name,
 		Email: email,
-	}, nil
+	}
+	return ident, user.Profile{Picture: u.Links.Avatar.Href}, nil
 }
 
 func getAndDecode(cli chttp.Client, url string, v interface{}) error {
@@ -5,12 +5,15 @@ import (
 	"testing"
 
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 var bitbucketExampleUser1 = `{
     "display_name": "tutorials account",
     "username": "tutorials",
-    "uuid": "{c788b2da-b7a2-404c-9e26-d3f077557007}"
+    "uuid": "{c788b2da-b7a2-404c-9e26-d3f077557007}",
+    "links": {"avatar": {"href": "https://bitbucket.org/account/tutorials/avatar/32/"}}
 }`
 
 var bitbucketExampleUser2 = `{
@@ -38,6 +41,7 @@ func TestBitBucketIdentity(t *testing.T) {
 				ID:    "{c788b2da-b7a2-404c-9e26-d3f077557007}",
 				Email: "tutorials3@bitbucket.org",
 			},
+			wantProfile: user.Profile{Picture: "https://bitbucket.org/account/tutorials/avatar/32/"},
 		},
 		{
 			urlResps: map[string]response{
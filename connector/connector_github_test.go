@@ -5,10 +5,12 @@ import (
 	"testing"
 
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 var (
-	githubExampleUser  = `{"login":"octocat","id":1,"name": "monalisa octocat","email": "octocat@github.com"}`
+	githubExampleUser  = `{"login":"octocat","id":1,"name": "monalisa octocat","email": "octocat@github.com","avatar_url":"https://github.com/images/error/octocat_happy.gif"}`
 	githubExampleError = `{"message":"Bad credentials","documentation_url":"https://developer.github.com/v3"}`
 )
 
@@ -23,6 +25,7 @@ func TestGitHubIdentity(t *testing.T) {
 				ID:    "1",
 				Email: "octocat@github.com",
 			},
+			wantProfile: user.Profile{Picture: "https://github.com/images/error/octocat_happy.gif"},
 		},
 		{
 			urlResps: map[string]response{
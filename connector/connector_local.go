@@ -1,22 +1,38 @@
 package connector
 
 import (
+	"encoding/base64"
 	"fmt"
 	"html/template"
 	"net/http"
 	"net/url"
 	"path"
+	"sync"
+	"time"
 
+	"github.com/coreos/dex/audit"
 	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/user"
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
 	"github.com/coreos/go-oidc/oauth2"
 	"github.com/coreos/go-oidc/oidc"
+	"rsc.io/qr"
 )
 
 const (
-	LocalConnectorType    = "local"
-	LoginPageTemplateName = "local-login.html"
+	LocalConnectorType         = "local"
+	LoginPageTemplateName      = "local-login.html"
+	TOTPPageTemplateName       = "local-totp.html"
+	TOTPEnrollPageTemplateName = "local-totp-enroll.html"
+
+	// secondFactorChallengeValidity is how long a user has to provide their
+	// second factor after passing the password check.
+	secondFactorChallengeValidity = 5 * time.Minute
+
+	lockedOutMessage = "too many failed login attempts, please try again later"
 )
 
 func init() {
@@ -36,36 +52,82 @@ func (cfg *LocalConnectorConfig) ConnectorType() string {
 	return LocalConnectorType
 }
 
-func (cfg *LocalConnectorConfig) Connector(ns url.URL, lf oidc.LoginFunc, tpls *template.Template) (Connector, error) {
+func (cfg *LocalConnectorConfig) Connector(ns url.URL, lf LoginFunc, tpls *template.Template) (Connector, error) {
 	tpl := tpls.Lookup(LoginPageTemplateName)
 	if tpl == nil {
 		return nil, fmt.Errorf("unable to find necessary HTML template")
 	}
 
+	// The second-factor templates are only needed once a TOTPInfoRepo is
+	// configured, so their absence is not an error here.
 	idpc := &LocalConnector{
-		id:        cfg.ID,
-		namespace: ns,
-		loginFunc: lf,
-		loginTpl:  tpl,
+		id:            cfg.ID,
+		namespace:     ns,
+		loginFunc:     lf,
+		loginTpl:      tpl,
+		totpTpl:       tpls.Lookup(TOTPPageTemplateName),
+		totpEnrollTpl: tpls.Lookup(TOTPEnrollPageTemplateName),
 	}
 
 	return idpc, nil
 }
 
 type LocalConnector struct {
-	id        string
-	idp       *LocalIdentityProvider
-	namespace url.URL
-	loginFunc oidc.LoginFunc
-	loginTpl  *template.Template
+	id            string
+	idp           *LocalIdentityProvider
+	namespace     url.URL
+	loginFunc     LoginFunc
+	loginTpl      *template.Template
+	totpTpl       *template.Template
+	totpEnrollTpl *template.Template
 }
 
 type Page struct {
+	PostURL     string
+	Name        string
+	Error       bool
+	Message     string
+	SessionKey  string
+	TOTPEnabled bool
+}
+
+// TOTPPage is rendered by both the TOTP verification and enrollment
+// templates.
+type TOTPPage struct {
 	PostURL    string
-	Name       string
 	Error      bool
 	Message    string
 	SessionKey string
+
+	// Challenge is the serialized SecondFactorChallenge which must be
+	// posted back along with the code.
+	Challenge string
+
+	// Secret, KeyURI and QRCode are set during enrollment only. KeyURI is
+	// an otpauth:// URI and QRCode a data: URI of a PNG image of it, which
+	// html/template would otherwise refuse to use as link and image.
+	Secret string
+	KeyURI template.URL
+	QRCode template.URL
+
+	// RecoveryCodes and ContinueURL are set once enrollment is complete.
+	RecoveryCodes []string
+	ContinueURL   string
+}
+
+// setEnrollment fills in the pending TOTP enrollment of the page.
+func (p *TOTPPage) setEnrollment(info user.TOTPInfo, keyURI string) {
+	p.Secret = info.EncodedSecret()
+	p.KeyURI = template.URL(keyURI)
+
+	// The secret can still be entered by hand should the key URI not fit
+	// into a QR code.
+	code, err := qr.Encode(keyURI, qr.M)
+	if err != nil {
+		log.Errorf("Unable to encode key URI as QR code: %v", err)
+		return
+	}
+	p.QRCode = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(code.PNG()))
 }
 
 func (c *LocalConnector) ID() string {
@@ -91,7 +153,7 @@ func (c *LocalConnector) LoginURL(sessionKey, prompt string) (string, error) {
 
 func (c *LocalConnector) Register(mux *http.ServeMux, errorURL url.URL) {
 	route := c.namespace.Path + "/login"
-	mux.Handle(route, handleLoginFunc(c.loginFunc, c.loginTpl, c.idp, route, errorURL))
+	mux.Handle(route, handleLoginFunc(c.loginFunc, c.loginTpl, c.totpTpl, c.totpEnrollTpl, c.idp, route, errorURL))
 }
 
 func (c *LocalConnector) Sync() chan struct{} {
@@ -108,12 +170,16 @@ func redirectPostError(w http.ResponseWriter, errorURL url.URL, q url.Values) {
 	w.WriteHeader(http.StatusSeeOther)
 }
 
-func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdentityProvider, localErrorPath string, errorURL url.URL) http.HandlerFunc {
+func handleLoginFunc(lf LoginFunc, tpl, totpTpl, totpEnrollTpl *template.Template, idp *LocalIdentityProvider, localErrorPath string, errorURL url.URL) http.HandlerFunc {
+	logger := func(r *http.Request) *log.Logger {
+		return phttp.Logger(r).WithField("connector_id", idp.ConnectorID)
+	}
+
 	handleGET := func(w http.ResponseWriter, r *http.Request, errMsg string) {
 		q := r.URL.Query()
 		sessionKey := q.Get("session_key")
 
-		p := &Page{PostURL: r.URL.String(), Name: "Local", SessionKey: sessionKey}
+		p := &Page{PostURL: r.URL.String(), Name: "Local", SessionKey: sessionKey, TOTPEnabled: idp.TOTPEnabled()}
 		if errMsg != "" {
 			p.Error = true
 			p.Message = errMsg
@@ -124,6 +190,100 @@ func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdenti
 		}
 	}
 
+	renderTOTP := func(w http.ResponseWriter, tpl *template.Template, p *TOTPPage) {
+		if tpl == nil {
+			phttp.WriteError(w, http.StatusInternalServerError, "second factor templates not found")
+			return
+		}
+		if err := tpl.Execute(w, p); err != nil {
+			phttp.WriteError(w, http.StatusInternalServerError, err.Error())
+		}
+	}
+
+	login := func(w http.ResponseWriter, r *http.Request, ident oidc.Identity, sessionKey string) (string, bool) {
+		redirectURL, err := lf(r.Context(), ident, user.Profile{}, sessionKey)
+		if err != nil {
+			logger(r).Errorf("Unable to log in user %s: %v", ident.ID, err)
+			q := r.URL.Query()
+			q.Set("error", oauth2.ErrorAccessDenied)
+			q.Set("error_description", "login failed")
+			redirectPostError(w, errorURL, q)
+			return "", false
+		}
+		return redirectURL, true
+	}
+
+	// handleSecondFactor completes a login for which the password check has
+	// already passed, as proven by the challenge.
+	handleSecondFactor := func(w http.ResponseWriter, r *http.Request, sessionKey string) {
+		token := r.PostForm.Get("challenge")
+		ch, err := idp.parseChallenge(token)
+		if err != nil || ch.SessionKey() != sessionKey {
+			logger(r).Debugf("Invalid second factor challenge: %v", err)
+			handleGET(w, r, "login expired, please try again")
+			return
+		}
+
+		p := &TOTPPage{
+			PostURL:    r.URL.String(),
+			SessionKey: sessionKey,
+			Challenge:  token,
+		}
+
+		var ok bool
+		switch ch.Purpose() {
+		case user.SecondFactorPurposeTOTP:
+			ok, err = idp.VerifyTOTP(ch.UserID(), r.PostForm.Get("code"), phttp.RemoteIP(r))
+			if err == user.ErrorLockedOut {
+				handleGET(w, r, lockedOutMessage)
+				return
+			}
+			if err != nil {
+				logger(r).Errorf("Unable to verify TOTP code of user %s: %v", ch.UserID(), err)
+				phttp.WriteError(w, http.StatusInternalServerError, "unable to verify code")
+				return
+			}
+			if !ok {
+				p.Error, p.Message = true, "invalid code"
+				renderTOTP(w, totpTpl, p)
+				return
+			}
+
+			redirectURL, ok := login(w, r, oidc.Identity{ID: ch.UserID()}, sessionKey)
+			if !ok {
+				return
+			}
+			w.Header().Set("Location", redirectURL)
+			w.WriteHeader(http.StatusFound)
+
+		case user.SecondFactorPurposeTOTPEnroll:
+			info, codes, err := idp.ConfirmTOTP(ch.UserID(), r.PostForm.Get("code"))
+			if err != nil {
+				logger(r).Errorf("Unable to confirm TOTP enrollment of user %s: %v", ch.UserID(), err)
+				phttp.WriteError(w, http.StatusInternalServerError, "unable to verify code")
+				return
+			}
+			if codes == nil {
+				p.Error, p.Message = true, "invalid code"
+				p.setEnrollment(info, idp.keyURI(info))
+				renderTOTP(w, totpEnrollTpl, p)
+				return
+			}
+
+			redirectURL, ok := login(w, r, oidc.Identity{ID: ch.UserID()}, sessionKey)
+			if !ok {
+				return
+			}
+			p.Challenge = ""
+			p.RecoveryCodes = codes
+			p.ContinueURL = redirectURL
+			renderTOTP(w, totpEnrollTpl, p)
+
+		default:
+			handleGET(w, r, "login expired, please try again")
+		}
+	}
+
 	handlePOST := func(w http.ResponseWriter, r *http.Request) {
 		if err := r.ParseForm(); err != nil {
 			msg := fmt.Sprintf("unable to parse form from body: %v", err)
@@ -131,6 +291,20 @@ func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdenti
 			return
 		}
 
+		q := r.URL.Query()
+		sessionKey := r.FormValue("session_key")
+
+		if idp.TOTPEnabled() && r.PostForm.Get("challenge") != "" {
+			if sessionKey == "" {
+				q.Set("error", oauth2.ErrorInvalidRequest)
+				q.Set("error_description", "missing session_key")
+				redirectPostError(w, errorURL, q)
+				return
+			}
+			handleSecondFactor(w, r, sessionKey)
+			return
+		}
+
 		userid := r.PostForm.Get("userid")
 		if userid == "" {
 			handleGET(w, r, "missing email address")
@@ -143,16 +317,16 @@ func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdenti
 			return
 		}
 
-		ident, err := idp.Identity(userid, password)
-		log.Errorf("IDENTITY: err: %v", err)
-
+		ident, err := idp.Login(userid, password, phttp.RemoteIP(r))
+		if err == user.ErrorLockedOut {
+			handleGET(w, r, lockedOutMessage)
+			return
+		}
 		if ident == nil || err != nil {
 			handleGET(w, r, "invalid login")
 			return
 		}
 
-		q := r.URL.Query()
-		sessionKey := r.FormValue("session_key")
 		if sessionKey == "" {
 			q.Set("error", oauth2.ErrorInvalidRequest)
 			q.Set("error_description", "missing session_key")
@@ -160,12 +334,52 @@ func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdenti
 			return
 		}
 
-		redirectURL, err := lf(*ident, sessionKey)
-		if err != nil {
-			log.Errorf("Unable to log in %#v: %v", *ident, err)
-			q.Set("error", oauth2.ErrorAccessDenied)
-			q.Set("error_description", "login failed")
-			redirectPostError(w, errorURL, q)
+		if idp.TOTPEnabled() {
+			info, enrolled, err := idp.totpInfo(ident.ID)
+			if err != nil {
+				logger(r).Errorf("Unable to look up TOTP enrollment of user %s: %v", ident.ID, err)
+				phttp.WriteError(w, http.StatusInternalServerError, "unable to log in")
+				return
+			}
+
+			purpose := ""
+			switch {
+			case enrolled:
+				purpose = user.SecondFactorPurposeTOTP
+			case r.PostForm.Get("enroll_totp") != "":
+				purpose = user.SecondFactorPurposeTOTPEnroll
+				if info, err = idp.BeginTOTPEnrollment(ident.ID); err != nil {
+					logger(r).Errorf("Unable to begin TOTP enrollment of user %s: %v", ident.ID, err)
+					phttp.WriteError(w, http.StatusInternalServerError, "unable to enroll second factor")
+					return
+				}
+			}
+
+			if purpose != "" {
+				token, err := idp.newChallenge(ident.ID, sessionKey, purpose)
+				if err != nil {
+					logger(r).Errorf("Unable to create second factor challenge: %v", err)
+					phttp.WriteError(w, http.StatusInternalServerError, "unable to log in")
+					return
+				}
+
+				p := &TOTPPage{
+					PostURL:    r.URL.String(),
+					SessionKey: sessionKey,
+					Challenge:  token,
+				}
+				if purpose == user.SecondFactorPurposeTOTP {
+					renderTOTP(w, totpTpl, p)
+					return
+				}
+				p.setEnrollment(info, idp.keyURI(info))
+				renderTOTP(w, totpEnrollTpl, p)
+				return
+			}
+		}
+
+		redirectURL, ok := login(w, r, *ident, sessionKey)
+		if !ok {
 			return
 		}
 
@@ -189,6 +403,33 @@ func handleLoginFunc(lf oidc.LoginFunc, tpl *template.Template, idp *LocalIdenti
 type LocalIdentityProvider struct {
 	PasswordInfoRepo user.PasswordInfoRepo
 	UserRepo         user.UserRepo
+
+	// TOTPInfoRepo enables TOTP second factors when set. The remaining
+	// fields are then required as well: IssuerURL and the key functions
+	// are used to issue and check the challenges which carry a login from
+	// the password check to the second factor.
+	TOTPInfoRepo user.TOTPInfoRepo
+	IssuerURL    url.URL
+	SignerFunc   func() (jose.Signer, error)
+	KeysFunc     func() ([]key.PublicKey, error)
+
+	// TOTPTxnFactory begins the transactions in which TOTP codes are
+	// checked and used up, so that workers sharing the TOTPInfoRepo cannot
+	// accept the same code twice. It defaults to InMemTransactionFactory.
+	TOTPTxnFactory repo.TransactionFactory
+	totpMu         sync.Mutex
+
+	// Throttler, if set, limits the number of failed logins per account and
+	// per remote address. LockoutNotifier is then called whenever an
+	// account gets locked.
+	Throttler       *user.LoginThrottler
+	LockoutNotifier func(usr user.User, lockedUntil time.Time)
+
+	// AuditSink, if set, records failed logins.
+	AuditSink audit.AuditSink
+
+	// ConnectorID labels the failed logins of the provider in Logins.
+	ConnectorID string
 }
 
 func (m *LocalIdentityProvider) Identity(email, password string) (*oidc.Identity, error) {
@@ -204,5 +445,318 @@ func (m *LocalIdentityProvider) Identity(email, password string) (*oidc.Identity
 		return nil, err
 	}
 
-	return pi.Authenticate(password)
+	return m.authenticate(pi, password)
+}
+
+// authenticate checks password against pi. If it matches a hash created
+// with an outdated algorithm or cost, the password is rehashed with the
+// current one. Failing to do so does not fail the login.
+func (m *LocalIdentityProvider) authenticate(pi user.PasswordInfo, password string) (*oidc.Identity, error) {
+	ident, err := pi.Authenticate(password)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordNeedsRehash(pi.Password) {
+		if err := m.rehashPassword(pi, password); err != nil {
+			log.Errorf("Failed to rehash password of user %s: %v", pi.UserID, err)
+		}
+	}
+	return ident, nil
+}
+
+func (m *LocalIdentityProvider) rehashPassword(pi user.PasswordInfo, password string) error {
+	hash, err := user.NewPasswordFromPlaintext(password)
+	if err != nil {
+		return err
+	}
+
+	pi.Password = hash
+	if err := m.PasswordInfoRepo.Update(nil, pi); err != nil {
+		return err
+	}
+	log.Infof("Rehashed password of user %s", pi.UserID)
+	return nil
+}
+
+// Login checks the password of the user with the given email address like
+// Identity, but also enforces the lockout policy of the provider's Throttler
+// for both the account and the remote address the login comes from.
+// ErrorLockedOut is returned while either is locked, whether or not the
+// password is correct.
+func (m *LocalIdentityProvider) Login(email, password, ip string) (*oidc.Identity, error) {
+	if m.Throttler == nil {
+		ident, err := m.Identity(email, password)
+		switch err {
+		case user.ErrorNotFound:
+			m.auditFailure("", ip, "unknown email address "+email)
+		case user.ErrorPasswordHashNoMatch:
+			if usr, err := m.UserRepo.GetByEmail(nil, email); err == nil {
+				m.auditFailure(usr.ID, ip, "wrong password")
+			}
+		}
+		return ident, err
+	}
+
+	usr, err := m.UserRepo.GetByEmail(nil, email)
+	if err == user.ErrorNotFound {
+		if err := m.Throttler.Locked("", ip); err != nil {
+			m.auditFailure("", ip, "address locked out")
+			return nil, err
+		}
+		m.auditFailure("", ip, "unknown email address "+email)
+		if err := m.recordFailure(user.User{}, ip); err != nil {
+			return nil, err
+		}
+		return nil, user.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Throttler.Locked(usr.ID, ip); err != nil {
+		m.auditFailure(usr.ID, ip, "locked out")
+		return nil, err
+	}
+
+	pi, err := m.PasswordInfoRepo.Get(nil, usr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	ident, err := m.authenticate(pi, password)
+	if err == user.ErrorPasswordHashNoMatch {
+		m.auditFailure(usr.ID, ip, "wrong password")
+		if err := m.recordFailure(usr, ip); err != nil {
+			return nil, err
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Users with a second factor are only let off once it has been checked
+	// as well.
+	if m.TOTPEnabled() {
+		if _, enrolled, err := m.totpInfo(usr.ID); err != nil || enrolled {
+			return ident, err
+		}
+	}
+	if err := m.Throttler.RecordSuccess(usr.ID); err != nil {
+		return nil, err
+	}
+	return ident, nil
+}
+
+// auditFailure records a failed login to the AuditSink of the provider and
+// counts it in Logins.
+func (m *LocalIdentityProvider) auditFailure(userID, ip, details string) {
+	Logins.Inc(m.ConnectorID, LoginFailure)
+	audit.Record(m.AuditSink, audit.Event{
+		Type:       audit.EventLoginFailed,
+		UserID:     userID,
+		RemoteAddr: ip,
+		Details:    details,
+	})
+}
+
+// recordFailure records a failed login to the account of usr, if any, and
+// notifies the user if their account got locked because of it.
+func (m *LocalIdentityProvider) recordFailure(usr user.User, ip string) error {
+	lockedUntil, err := m.Throttler.RecordFailure(usr.ID, ip)
+	if err != nil {
+		return err
+	}
+
+	if !lockedUntil.IsZero() {
+		log.Infof("Locked account of user %s until %v after too many failed logins", usr.ID, lockedUntil)
+		if m.LockoutNotifier != nil {
+			m.LockoutNotifier(usr, lockedUntil)
+		}
+	}
+	return nil
+}
+
+// TOTPEnabled reports whether local users may use TOTP second factors.
+func (m *LocalIdentityProvider) TOTPEnabled() bool {
+	return m.TOTPInfoRepo != nil
+}
+
+// totpInfo returns the TOTPInfo of the given user, and whether the user has
+// completed enrollment and must therefore provide a TOTP code to log in.
+func (m *LocalIdentityProvider) totpInfo(userID string) (user.TOTPInfo, bool, error) {
+	info, err := m.TOTPInfoRepo.Get(nil, userID)
+	if err == user.ErrorNotFound {
+		return user.TOTPInfo{}, false, nil
+	}
+	if err != nil {
+		return user.TOTPInfo{}, false, err
+	}
+	return info, info.Confirmed, nil
+}
+
+// BeginTOTPEnrollment stores a new, unconfirmed TOTP secret for the given
+// user, replacing any earlier unconfirmed one.
+func (m *LocalIdentityProvider) BeginTOTPEnrollment(userID string) (user.TOTPInfo, error) {
+	old, enrolled, err := m.totpInfo(userID)
+	if err != nil {
+		return user.TOTPInfo{}, err
+	}
+	if enrolled {
+		return user.TOTPInfo{}, user.ErrorTOTPAlreadyEnrolled
+	}
+
+	info, err := user.NewTOTPInfo(userID, time.Now())
+	if err != nil {
+		return user.TOTPInfo{}, err
+	}
+
+	if old.UserID != "" {
+		err = m.TOTPInfoRepo.Update(nil, info)
+	} else {
+		err = m.TOTPInfoRepo.Create(nil, info)
+	}
+	if err != nil {
+		return user.TOTPInfo{}, err
+	}
+	return info, nil
+}
+
+// ConfirmTOTP completes the TOTP enrollment of the given user if code is
+// valid for the pending secret, and returns the user's new recovery codes.
+// If the code is invalid no recovery codes are returned.
+func (m *LocalIdentityProvider) ConfirmTOTP(userID, code string) (user.TOTPInfo, []string, error) {
+	var codes []string
+	info, _, err := m.useTOTPCode(userID, func(info *user.TOTPInfo) (bool, error) {
+		if info.Confirmed || !info.Validate(code, time.Now()) {
+			return false, nil
+		}
+
+		var err error
+		if codes, err = info.GenerateRecoveryCodes(); err != nil {
+			return false, err
+		}
+		info.Confirmed = true
+		return true, nil
+	})
+	if err != nil {
+		return user.TOTPInfo{}, nil, err
+	}
+	return info, codes, nil
+}
+
+// VerifyTOTP reports whether code is a valid TOTP code or an unused
+// recovery code of the given user. Accepted codes cannot be used again.
+// Invalid codes count as failed logins towards the lockout policy of the
+// provider's Throttler, if any.
+func (m *LocalIdentityProvider) VerifyTOTP(userID, code, ip string) (bool, error) {
+	if m.Throttler != nil {
+		if err := m.Throttler.Locked(userID, ip); err != nil {
+			m.auditFailure(userID, ip, "locked out")
+			return false, err
+		}
+	}
+
+	info, ok, err := m.useTOTPCode(userID, func(info *user.TOTPInfo) (bool, error) {
+		if !info.Confirmed {
+			return false, nil
+		}
+		return info.Validate(code, time.Now()) || info.UseRecoveryCode(code), nil
+	})
+	if err == user.ErrorNotFound || (err == nil && !info.Confirmed) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if !ok {
+		m.auditFailure(userID, ip, "wrong TOTP code")
+		if m.Throttler != nil {
+			usr, err := m.UserRepo.Get(nil, userID)
+			if err != nil {
+				return false, err
+			}
+			if err := m.recordFailure(usr, ip); err != nil {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+
+	if m.Throttler != nil {
+		if err := m.Throttler.RecordSuccess(userID); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// useTOTPCode reads the TOTPInfo of the given user and passes it to use,
+// which reports whether it accepted a code and changed the TOTPInfo
+// accordingly. Accepted changes are stored in the same transaction, which
+// holds a lock on the TOTPInfo where the repo supports it. The in-memory
+// transactions do not isolate, so the provider itself checks one code at a
+// time as well.
+func (m *LocalIdentityProvider) useTOTPCode(userID string, use func(info *user.TOTPInfo) (bool, error)) (user.TOTPInfo, bool, error) {
+	m.totpMu.Lock()
+	defer m.totpMu.Unlock()
+
+	begin := m.TOTPTxnFactory
+	if begin == nil {
+		begin = repo.InMemTransactionFactory
+	}
+	tx, err := begin()
+	if err != nil {
+		return user.TOTPInfo{}, false, err
+	}
+	defer tx.Rollback()
+
+	info, err := m.TOTPInfoRepo.Get(tx, userID)
+	if err != nil {
+		return user.TOTPInfo{}, false, err
+	}
+
+	ok, err := use(&info)
+	if err != nil || !ok {
+		return info, false, err
+	}
+
+	if err := m.TOTPInfoRepo.Update(tx, info); err != nil {
+		return user.TOTPInfo{}, false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return user.TOTPInfo{}, false, err
+	}
+	return info, true, nil
+}
+
+// keyURI returns the key URI of the given TOTPInfo, naming the account by
+// the user's email address where possible.
+func (m *LocalIdentityProvider) keyURI(info user.TOTPInfo) string {
+	accountName := info.UserID
+	if usr, err := m.UserRepo.Get(nil, info.UserID); err == nil {
+		accountName = usr.Email
+	}
+	return info.KeyURI(m.IssuerURL.Host, accountName)
+}
+
+func (m *LocalIdentityProvider) newChallenge(userID, sessionKey, purpose string) (string, error) {
+	signer, err := m.SignerFunc()
+	if err != nil {
+		return "", err
+	}
+
+	ch := user.NewSecondFactorChallenge(userID, sessionKey, purpose, m.IssuerURL, secondFactorChallengeValidity)
+	return ch.Token(signer)
+}
+
+func (m *LocalIdentityProvider) parseChallenge(token string) (user.SecondFactorChallenge, error) {
+	keys, err := m.KeysFunc()
+	if err != nil {
+		return user.SecondFactorChallenge{}, err
+	}
+
+	return user.ParseAndVerifySecondFactorChallengeToken(token, m.IssuerURL, keys)
 }
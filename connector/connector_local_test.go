@@ -0,0 +1,415 @@
+package connector
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+// testTOTPTemplates render the fields of TOTPPage separated by "|" so that
+// tests can pick them apart.
+const testTOTPTemplates = `
+{{ define "local-login.html" }}login|{{ .Message }}|{{ .TOTPEnabled }}{{ end }}
+{{ define "local-totp.html" }}totp|{{ .Challenge }}|{{ .Message }}{{ end }}
+{{ define "local-totp-enroll.html" }}enroll|{{ .Challenge }}|{{ .Message }}|{{ .Secret }}|{{ range .RecoveryCodes }}{{ . }},{{ end }}|{{ .ContinueURL }}|{{ if .QRCode }}qr{{ end }}{{ end }}
+`
+
+type totpTestFixtures struct {
+	handler  http.Handler
+	idp      *LocalIdentityProvider
+	totpRepo user.TOTPInfoRepo
+	loggedIn []string
+}
+
+func makeTOTPTestFixtures(t *testing.T) *totpTestFixtures {
+	f := &totpTestFixtures{}
+
+	pw, err := user.NewPasswordFromPlaintext("woof")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	privKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f.totpRepo = user.NewTOTPInfoRepo()
+	idp := &LocalIdentityProvider{
+		UserRepo: user.NewUserRepoFromUsers([]user.UserWithRemoteIdentities{
+			{User: user.User{ID: "ID-1", Email: "elroy@example.com"}},
+		}),
+		PasswordInfoRepo: user.NewPasswordInfoRepoFromPasswordInfos([]user.PasswordInfo{
+			{UserID: "ID-1", Password: pw},
+		}),
+		TOTPInfoRepo: f.totpRepo,
+		IssuerURL:    url.URL{Scheme: "https", Host: "dex.example.com"},
+		SignerFunc: func() (jose.Signer, error) {
+			return privKey.Signer(), nil
+		},
+		KeysFunc: func() ([]key.PublicKey, error) {
+			return []key.PublicKey{*key.NewPublicKey(privKey.JWK())}, nil
+		},
+	}
+
+	lf := func(_ context.Context, ident oidc.Identity, _ user.Profile, sessionKey string) (string, error) {
+		f.loggedIn = append(f.loggedIn, ident.ID)
+		return "https://client.example.com/callback?code=" + sessionKey, nil
+	}
+
+	tpls := template.Must(template.New("").Parse(testTOTPTemplates))
+	cfg := &LocalConnectorConfig{ID: "local"}
+	conn, err := cfg.Connector(url.URL{Path: "/auth/local"}, lf, tpls)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lc := conn.(*LocalConnector)
+	lc.SetLocalIdentityProvider(idp)
+	f.idp = idp
+
+	mux := http.NewServeMux()
+	lc.Register(mux, url.URL{Path: "/auth"})
+	f.handler = mux
+
+	return f
+}
+
+func (f *totpTestFixtures) post(t *testing.T, sessionKey string, form url.Values) (*httptest.ResponseRecorder, []string) {
+	r, err := http.NewRequest("POST", "/auth/local/login?session_key="+sessionKey, strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = "192.0.2.1:40000"
+
+	w := httptest.NewRecorder()
+	f.handler.ServeHTTP(w, r)
+	return w, strings.Split(w.Body.String(), "|")
+}
+
+func TestLocalLoginTOTP(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+	password := url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}}
+
+	// Without enrollment the password is enough.
+	w, _ := f.post(t, "key-1", password)
+	if w.Code != http.StatusFound || len(f.loggedIn) != 1 {
+		t.Fatalf("want redirect after password login, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// Ask to enroll along with the password.
+	enroll := url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}, "enroll_totp": {"1"}}
+	_, page := f.post(t, "key-2", enroll)
+	if page[0] != "enroll" || page[1] == "" || page[3] == "" || page[6] != "qr" {
+		t.Fatalf("want enrollment page, got %q", page)
+	}
+	challenge := page[1]
+
+	info, err := f.totpRepo.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Confirmed || info.EncodedSecret() != page[3] {
+		t.Fatalf("unexpected pending TOTPInfo: %#v", info)
+	}
+
+	// A wrong code keeps the user on the enrollment page.
+	_, page = f.post(t, "key-2", url.Values{"challenge": {challenge}, "code": {"000000"}})
+	if page[0] != "enroll" || page[2] != "invalid code" {
+		t.Fatalf("want enrollment page with error, got %q", page)
+	}
+
+	// The challenge is bound to the login session.
+	code := user.GenerateTOTPCode(info.Secret, time.Now())
+	_, page = f.post(t, "key-3", url.Values{"challenge": {challenge}, "code": {code}})
+	if page[0] != "login" {
+		t.Fatalf("want login page for foreign challenge, got %q", page)
+	}
+
+	_, page = f.post(t, "key-2", url.Values{"challenge": {challenge}, "code": {code}})
+	if page[0] != "enroll" || page[2] != "" {
+		t.Fatalf("want completed enrollment page, got %q", page)
+	}
+	recoveryCodes := strings.Split(strings.TrimSuffix(page[4], ","), ",")
+	if len(recoveryCodes) != user.RecoveryCodeCount {
+		t.Fatalf("want %d recovery codes, got %q", user.RecoveryCodeCount, page[4])
+	}
+	if page[5] != "https://client.example.com/callback?code=key-2" || len(f.loggedIn) != 2 {
+		t.Fatalf("want login completed, got continue URL %q", page[5])
+	}
+
+	// From now on the password alone is not enough.
+	_, page = f.post(t, "key-4", password)
+	if page[0] != "totp" || page[1] == "" {
+		t.Fatalf("want TOTP page, got %q", page)
+	}
+	challenge = page[1]
+	if len(f.loggedIn) != 2 {
+		t.Fatalf("logged in without second factor")
+	}
+
+	// The code used for enrollment may not be replayed.
+	_, page = f.post(t, "key-4", url.Values{"challenge": {challenge}, "code": {code}})
+	if page[0] != "totp" || page[2] != "invalid code" {
+		t.Fatalf("want TOTP page with error, got %q", page)
+	}
+
+	w, _ = f.post(t, "key-4", url.Values{"challenge": {challenge}, "code": {recoveryCodes[0]}})
+	if w.Code != http.StatusFound || len(f.loggedIn) != 3 {
+		t.Fatalf("want redirect after recovery code, got %d: %s", w.Code, w.Body.String())
+	}
+	if loc := w.Header().Get("Location"); loc != "https://client.example.com/callback?code=key-4" {
+		t.Errorf("unexpected redirect: %q", loc)
+	}
+
+	// Recovery codes are single-use.
+	_, page = f.post(t, "key-4", url.Values{"challenge": {challenge}, "code": {recoveryCodes[0]}})
+	if page[0] != "totp" || page[2] != "invalid code" {
+		t.Fatalf("want TOTP page with error, got %q", page)
+	}
+}
+
+func TestTOTPCodesUsedOnce(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+	info, err := f.idp.BeginTOTPEnrollment("ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	code := user.GenerateTOTPCode(info.Secret, time.Now())
+
+	// Concurrent submissions of the same code are accepted once.
+	const n = 10
+	results := make(chan []string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, codes, err := f.idp.ConfirmTOTP("ID-1", code)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- codes
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	var recoveryCodes []string
+	for codes := range results {
+		if codes == nil {
+			continue
+		}
+		if recoveryCodes != nil {
+			t.Fatalf("enrollment confirmed more than once")
+		}
+		recoveryCodes = codes
+	}
+	if recoveryCodes == nil {
+		t.Fatalf("enrollment not confirmed")
+	}
+
+	accepted := make(chan bool, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, err := f.idp.VerifyTOTP("ID-1", recoveryCodes[0], "192.0.2.1")
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			accepted <- ok
+		}()
+	}
+	wg.Wait()
+	close(accepted)
+
+	var count int
+	for ok := range accepted {
+		if ok {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("recovery code accepted %d times, want once", count)
+	}
+}
+
+func TestLocalLoginTOTPBadChallenge(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+
+	_, page := f.post(t, "key-1", url.Values{"challenge": {"not-a-jwt"}, "code": {"123456"}})
+	if page[0] != "login" || page[1] != "login expired, please try again" {
+		t.Fatalf("want login page with error, got %q", page)
+	}
+	if len(f.loggedIn) != 0 {
+		t.Fatalf("logged in with a bad challenge")
+	}
+}
+
+func TestLocalLoginLockout(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+	f.idp.Throttler = user.NewLoginThrottler(user.NewLoginAttemptRepo(), repo.InMemTransactionFactory, user.LockoutPolicy{
+		MaxFailures:      3,
+		MaxFailuresPerIP: 10,
+		Duration:         time.Minute,
+		MaxDuration:      time.Hour,
+	})
+	clock := clockwork.NewFakeClock()
+	f.idp.Throttler.Clock = clock
+
+	var notified []string
+	f.idp.LockoutNotifier = func(usr user.User, lockedUntil time.Time) {
+		notified = append(notified, usr.ID)
+		if want := clock.Now().Add(time.Minute); !lockedUntil.Equal(want) {
+			t.Errorf("want lockedUntil=%v, got %v", want, lockedUntil)
+		}
+	}
+
+	password := url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}}
+	wrong := url.Values{"userid": {"elroy@example.com"}, "password": {"meow"}}
+
+	// A successful login resets the count.
+	f.post(t, "key-1", wrong)
+	f.post(t, "key-1", wrong)
+	if w, _ := f.post(t, "key-1", password); w.Code != http.StatusFound {
+		t.Fatalf("want redirect after password login, got %d: %s", w.Code, w.Body.String())
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, page := f.post(t, "key-2", wrong); page[1] != "invalid login" {
+			t.Fatalf("attempt %d: want invalid login, got %q", i, page)
+		}
+	}
+	if len(notified) != 1 {
+		t.Fatalf("want one lockout notification, got %d", len(notified))
+	}
+
+	// While locked, even the right password is refused.
+	_, page := f.post(t, "key-2", password)
+	if page[0] != "login" || page[1] != lockedOutMessage {
+		t.Fatalf("want locked out, got %q", page)
+	}
+	if len(f.loggedIn) != 1 {
+		t.Fatalf("logged in while locked out")
+	}
+
+	// Once the lockout has expired, another failure locks the account for
+	// twice as long.
+	clock.Advance(time.Minute)
+	f.idp.LockoutNotifier = func(usr user.User, lockedUntil time.Time) {
+		notified = append(notified, usr.ID)
+		if want := clock.Now().Add(2 * time.Minute); !lockedUntil.Equal(want) {
+			t.Errorf("want lockedUntil=%v, got %v", want, lockedUntil)
+		}
+	}
+	f.post(t, "key-3", wrong)
+	if len(notified) != 2 {
+		t.Fatalf("want second lockout notification, got %d", len(notified))
+	}
+
+	clock.Advance(2 * time.Minute)
+	if w, _ := f.post(t, "key-4", password); w.Code != http.StatusFound {
+		t.Fatalf("want redirect after lockout expired, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLocalLoginAudit(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+	auditRepo := audit.NewEventRepo()
+	f.idp.AuditSink = auditRepo
+
+	f.post(t, "key-1", url.Values{"userid": {"elroy@example.com"}, "password": {"meow"}})
+	f.post(t, "key-1", url.Values{"userid": {"nobody@example.com"}, "password": {"woof"}})
+	if w, _ := f.post(t, "key-1", url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}}); w.Code != http.StatusFound {
+		t.Fatalf("want redirect after password login, got %d: %s", w.Code, w.Body.String())
+	}
+
+	events, _, err := auditRepo.List(audit.EventFilter{}, 10, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct{ userID, details string }{
+		{"", "unknown email address nobody@example.com"},
+		{"ID-1", "wrong password"},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("want %d audit events, got %#v", len(want), events)
+	}
+	for i, e := range events {
+		if e.Type != audit.EventLoginFailed || e.UserID != want[i].userID || e.Details != want[i].details || e.RemoteAddr != "192.0.2.1" {
+			t.Errorf("event %d: unexpected audit event: %#v", i, e)
+		}
+	}
+}
+
+func TestLocalLoginLockoutPerIP(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+	f.idp.Throttler = user.NewLoginThrottler(user.NewLoginAttemptRepo(), repo.InMemTransactionFactory, user.LockoutPolicy{
+		MaxFailures:      10,
+		MaxFailuresPerIP: 2,
+		Duration:         time.Minute,
+	})
+
+	// Guessing at unknown accounts counts against the address.
+	for _, email := range []string{"a@example.com", "b@example.com"} {
+		f.post(t, "key-1", url.Values{"userid": {email}, "password": {"woof"}})
+	}
+
+	_, page := f.post(t, "key-1", url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}})
+	if page[0] != "login" || page[1] != lockedOutMessage {
+		t.Fatalf("want locked out, got %q", page)
+	}
+}
+
+func TestLocalIdentityRehash(t *testing.T) {
+	f := makeTOTPTestFixtures(t)
+
+	defer func(alg user.PasswordHashAlgorithm) {
+		user.DefaultPasswordHashAlgorithm = alg
+	}(user.DefaultPasswordHashAlgorithm)
+	user.DefaultPasswordHashAlgorithm = user.ScryptAlgorithm{LogN: 4, R: 8, P: 1}
+
+	// A wrong password leaves the bcrypt hash alone.
+	if _, err := f.idp.Identity("elroy@example.com", "meow"); err != user.ErrorPasswordHashNoMatch {
+		t.Fatalf("want %v, got %v", user.ErrorPasswordHashNoMatch, err)
+	}
+	pi, err := f.idp.PasswordInfoRepo.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(string(pi.Password), "$2a$") {
+		t.Fatalf("want bcrypt hash, got %q", pi.Password)
+	}
+
+	if _, err := f.idp.Identity("elroy@example.com", "woof"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pi, err = f.idp.PasswordInfoRepo.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.PasswordNeedsRehash(pi.Password) {
+		t.Fatalf("want password rehashed with scrypt, got %q", pi.Password)
+	}
+
+	// The new hash works for the form login as well.
+	if w, _ := f.post(t, "key-1", url.Values{"userid": {"elroy@example.com"}, "password": {"woof"}}); w.Code != http.StatusFound {
+		t.Fatalf("want redirect after password login, got %d: %s", w.Code, w.Body.String())
+	}
+}
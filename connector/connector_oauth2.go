@@ -5,7 +5,8 @@ import (
 	"net/url"
 	"strings"
 
-	"github.com/coreos/dex/pkg/log"
+	phttp "github.com/coreos/dex/pkg/http"
+	"github.com/coreos/dex/user"
 	chttp "github.com/coreos/go-oidc/http"
 	"github.com/coreos/go-oidc/oauth2"
 	"github.com/coreos/go-oidc/oidc"
@@ -15,8 +16,8 @@ type oauth2Connector interface {
 	Client() *oauth2.Client
 
 	// Identity uses a HTTP client authenticated as the end user to construct
-	// an OIDC identity for that user.
-	Identity(cli chttp.Client) (oidc.Identity, error)
+	// an OIDC identity for that user, along with their profile.
+	Identity(cli chttp.Client) (oidc.Identity, user.Profile, error)
 
 	// Healthy it should attempt to determine if the connector's credientials
 	// are valid.
@@ -27,7 +28,7 @@ type oauth2Connector interface {
 
 type OAuth2Connector struct {
 	id        string
-	loginFunc oidc.LoginFunc
+	loginFunc LoginFunc
 	cbURL     url.URL
 	conn      oauth2Connector
 }
@@ -57,8 +58,9 @@ func (c *OAuth2Connector) Register(mux *http.ServeMux, errorURL url.URL) {
 	mux.Handle(c.cbURL.Path, c.handleCallbackFunc(c.loginFunc, errorURL))
 }
 
-func (c *OAuth2Connector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL) http.HandlerFunc {
+func (c *OAuth2Connector) handleCallbackFunc(lf LoginFunc, errorURL url.URL) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		l := phttp.Logger(r).WithField("connector_id", c.id)
 		q := r.URL.Query()
 
 		e := q.Get("error")
@@ -78,23 +80,23 @@ func (c *OAuth2Connector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL
 
 		token, err := c.conn.Client().RequestToken(oauth2.GrantTypeAuthCode, code)
 		if err != nil {
-			log.Errorf("Unable to verify auth code with issuer: %v", err)
+			l.Errorf("Unable to verify auth code with issuer: %v", err)
 			q.Set("error", oauth2.ErrorUnsupportedResponseType)
 			q.Set("error_description", "unable to verify auth code with issuer")
 			redirectError(w, errorURL, q)
 			return
 		}
-		ident, err := c.conn.Identity(newAuthenticatedClient(token, http.DefaultClient))
+		ident, profile, err := c.conn.Identity(newAuthenticatedClient(token, http.DefaultClient))
 		if err != nil {
-			log.Errorf("Unable to retrieve identity: %v", err)
+			l.Errorf("Unable to retrieve identity: %v", err)
 			q.Set("error", oauth2.ErrorUnsupportedResponseType)
 			q.Set("error_description", "unable to retrieve identity from issuer")
 			redirectError(w, errorURL, q)
 			return
 		}
-		redirectURL, err := lf(ident, sessionKey)
+		redirectURL, err := lf(r.Context(), ident, profile, sessionKey)
 		if err != nil {
-			log.Errorf("Unable to log in %#v: %v", ident, err)
+			l.Errorf("Unable to log in remote identity %s: %v", ident.ID, err)
 			q.Set("error", oauth2.ErrorAccessDenied)
 			q.Set("error_description", "login failed")
 			redirectError(w, errorURL, q)
@@ -9,6 +9,8 @@ import (
 
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/user"
 )
 
 type response struct {
@@ -17,9 +19,10 @@ type response struct {
 }
 
 type oauth2IdentityTest struct {
-	urlResps map[string]response
-	want     oidc.Identity
-	wantErr  error
+	urlResps    map[string]response
+	want        oidc.Identity
+	wantProfile user.Profile
+	wantErr     error
 }
 
 type fakeClient func(*http.Request) (*http.Response, error)
@@ -41,7 +44,7 @@ func runOAuth2IdentityTests(t *testing.T, conn oauth2Connector, tests []oauth2Id
 				Body:       ioutil.NopCloser(strings.NewReader(resp.body)),
 			}, nil
 		}
-		got, err := conn.Identity(fakeClient(f))
+		got, profile, err := conn.Identity(fakeClient(f))
 		if tt.wantErr == nil {
 			if err != nil {
 				t.Errorf("case %d: failed to get identity=%v", i, err)
@@ -50,6 +53,9 @@ func runOAuth2IdentityTests(t *testing.T, conn oauth2Connector, tests []oauth2Id
 			if diff := pretty.Compare(tt.want, got); diff != "" {
 				t.Errorf("case %d: Compare(want, got) = %v", i, diff)
 			}
+			if diff := pretty.Compare(tt.wantProfile, profile); diff != "" {
+				t.Errorf("case %d: Compare(wantProfile, profile) = %v", i, diff)
+			}
 		} else {
 			if err == nil {
 				t.Errorf("case %d: want error=%v, got=<nil>", i, tt.wantErr)
@@ -7,7 +7,8 @@ import (
 	"path"
 
 	phttp "github.com/coreos/dex/pkg/http"
-	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/oauth2"
 	"github.com/coreos/go-oidc/oidc"
 )
@@ -41,12 +42,12 @@ type OIDCConnector struct {
 	id                   string
 	issuerURL            string
 	cbURL                url.URL
-	loginFunc            oidc.LoginFunc
+	loginFunc            LoginFunc
 	client               *oidc.Client
 	trustedEmailProvider bool
 }
 
-func (cfg *OIDCConnectorConfig) Connector(ns url.URL, lf oidc.LoginFunc, tpls *template.Template) (Connector, error) {
+func (cfg *OIDCConnectorConfig) Connector(ns url.URL, lf LoginFunc, tpls *template.Template) (Connector, error) {
 	ns.Path = path.Join(ns.Path, httpPathCallback)
 
 	ccfg := oidc.ClientConfig{
@@ -108,8 +109,9 @@ func redirectError(w http.ResponseWriter, errorURL url.URL, q url.Values) {
 	w.WriteHeader(http.StatusSeeOther)
 }
 
-func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL) http.HandlerFunc {
+func (c *OIDCConnector) handleCallbackFunc(lf LoginFunc, errorURL url.URL) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		l := phttp.Logger(r).WithField("connector_id", c.id)
 		q := r.URL.Query()
 
 		e := q.Get("error")
@@ -128,7 +130,7 @@ func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL)
 
 		tok, err := c.client.ExchangeAuthCode(code)
 		if err != nil {
-			log.Errorf("Unable to verify auth code with issuer: %v", err)
+			l.Errorf("Unable to verify auth code with issuer: %v", err)
 			q.Set("error", oauth2.ErrorUnsupportedResponseType)
 			q.Set("error_description", "unable to verify auth code with issuer")
 			redirectError(w, errorURL, q)
@@ -137,7 +139,7 @@ func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL)
 
 		claims, err := tok.Claims()
 		if err != nil {
-			log.Errorf("Unable to construct claims: %v", err)
+			l.Errorf("Unable to construct claims: %v", err)
 			q.Set("error", oauth2.ErrorUnsupportedResponseType)
 			q.Set("error_description", "unable to construct claims")
 			redirectError(w, errorURL, q)
@@ -146,7 +148,7 @@ func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL)
 
 		ident, err := oidc.IdentityFromClaims(claims)
 		if err != nil {
-			log.Errorf("Failed parsing claims from remote provider: %v", err)
+			l.Errorf("Failed parsing claims from remote provider: %v", err)
 			q.Set("error", oauth2.ErrorUnsupportedResponseType)
 			q.Set("error_description", "unable to convert claims to identity")
 			redirectError(w, errorURL, q)
@@ -161,9 +163,9 @@ func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL)
 			return
 		}
 
-		redirectURL, err := lf(*ident, sessionKey)
+		redirectURL, err := lf(r.Context(), *ident, profileFromClaims(claims), sessionKey)
 		if err != nil {
-			log.Errorf("Unable to log in %#v: %v", *ident, err)
+			l.Errorf("Unable to log in remote identity %s: %v", ident.ID, err)
 			q.Set("error", oauth2.ErrorAccessDenied)
 			q.Set("error_description", "login failed")
 			redirectError(w, errorURL, q)
@@ -175,3 +177,19 @@ func (c *OIDCConnector) handleCallbackFunc(lf oidc.LoginFunc, errorURL url.URL)
 		return
 	}
 }
+
+// profileFromClaims returns the standard profile claims of an ID token.
+// The claims are optional, so those which are not strings are left out
+// rather than failing the login.
+func profileFromClaims(claims jose.Claims) user.Profile {
+	var p user.Profile
+	for claim, v := range map[string]*string{
+		"given_name":  &p.GivenName,
+		"family_name": &p.FamilyName,
+		"picture":     &p.Picture,
+		"locale":      &p.Locale,
+	} {
+		*v, _, _ = claims.StringClaim(claim)
+	}
+	return p
+}
@@ -1,17 +1,23 @@
 package connector
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"testing"
 
+	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 func TestLoginURL(t *testing.T) {
-	lf := func(ident oidc.Identity, sessionKey string) (redirectURL string, err error) { return }
+	lf := func(_ context.Context, ident oidc.Identity, profile user.Profile, sessionKey string) (redirectURL string, err error) {
+		return
+	}
 
 	tests := []struct {
 		cid    string
@@ -142,3 +148,28 @@ func TestRedirectError(t *testing.T) {
 		t.Errorf("Incorrect Location header: want=%s got=%s", wantLoc, gotLoc)
 	}
 }
+
+func TestProfileFromClaims(t *testing.T) {
+	tests := []struct {
+		claims jose.Claims
+		want   user.Profile
+	}{
+		{
+			claims: jose.Claims{"given_name": "Test", "family_name": "User", "picture": "https://example.com/test.png", "locale": "en-US"},
+			want:   user.Profile{GivenName: "Test", FamilyName: "User", Picture: "https://example.com/test.png", Locale: "en-US"},
+		},
+		{
+			// Malformed claims are left out rather than failing the login.
+			claims: jose.Claims{"given_name": "Test", "picture": map[string]interface{}{"url": "https://example.com/test.png"}, "locale": 3},
+			want:   user.Profile{GivenName: "Test"},
+		},
+		{
+			claims: jose.Claims{},
+		},
+	}
+	for i, tt := range tests {
+		if got := profileFromClaims(tt.claims); !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("case %d: want=%#v, got=%#v", i, tt.want, got)
+		}
+	}
+}
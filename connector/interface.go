@@ -1,18 +1,37 @@
 package connector
 
 import (
+	"context"
 	"errors"
 	"html/template"
 	"net/http"
 	"net/url"
 
+	"github.com/coreos/dex/pkg/metrics"
 	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/coreos/pkg/health"
 )
 
 var ErrorNotFound = errors.New("connector not found in repository")
 
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// Logins counts logins by connector ID and result, either LoginSuccess or
+// LoginFailure.
+var Logins = metrics.NewCounterVec("dex_logins_total",
+	"Logins, by connector and result.", "connector", "result")
+
+// LoginFunc associates a remote identity, along with the profile claims the
+// connector found for it, with the session of a session key. It returns the
+// URL to redirect the end user to. ctx is that of the request completing the
+// login, whose logger it uses.
+type LoginFunc func(ctx context.Context, ident oidc.Identity, profile user.Profile, sessionKey string) (redirectURL string, err error)
+
 type Connector interface {
 	// ID returns the ID of the ConnectorConfig used to create the Connector.
 	ID() string
@@ -57,7 +76,7 @@ type ConnectorConfig interface {
 	//
 	// Additional templates are passed for connectors that require rendering HTML
 	// pages, such as the "local" connector.
-	Connector(ns url.URL, loginFunc oidc.LoginFunc, tpls *template.Template) (Connector, error)
+	Connector(ns url.URL, loginFunc LoginFunc, tpls *template.Template) (Connector, error)
 }
 
 type ConnectorConfigRepo interface {
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	attributeTableName = "user_attribute"
+)
+
+func init() {
+	register(table{
+		name:    attributeTableName,
+		model:   attributeModel{},
+		autoinc: false,
+		pkey:    []string{"user_id", "name"},
+	})
+}
+
+// attributeModel is one custom attribute of a user. Value is the JSON
+// encoding of the attribute's value.
+type attributeModel struct {
+	UserID string `db:"user_id"`
+	Name   string `db:"name"`
+	Value  string `db:"value"`
+}
+
+func NewAttributeRepo(dbm *gorp.DbMap) user.AttributeRepo {
+	return &attributeRepo{
+		dbMap: dbm,
+	}
+}
+
+type attributeRepo struct {
+	dbMap *gorp.DbMap
+}
+
+func (r *attributeRepo) Get(tx repo.Transaction, userID string) (user.Attributes, error) {
+	qt := pq.QuoteIdentifier(attributeTableName)
+	ms, err := r.executor(tx).Select(&attributeModel{}, fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1", qt), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := make(user.Attributes, len(ms))
+	for _, m := range ms {
+		am, ok := m.(*attributeModel)
+		if !ok {
+			log.Errorf("expected attributeModel but found %v", reflect.TypeOf(m))
+			return nil, errors.New("unrecognized model")
+		}
+		v, err := user.DecodeAttributeValue(am.Value)
+		if err != nil {
+			return nil, err
+		}
+		attrs[am.Name] = v
+	}
+	return attrs, nil
+}
+
+func (r *attributeRepo) Set(tx repo.Transaction, userID string, attrs user.Attributes) error {
+	ex := r.executor(tx)
+	qt := pq.QuoteIdentifier(attributeTableName)
+	if _, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qt), userID); err != nil {
+		return err
+	}
+
+	for name, value := range attrs {
+		v, err := user.EncodeAttributeValue(value)
+		if err != nil {
+			return err
+		}
+		if err := ex.Insert(&attributeModel{UserID: userID, Name: name, Value: v}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *attributeRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
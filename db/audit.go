@@ -0,0 +1,193 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/jonboulle/clockwork"
+	"github.com/lib/pq"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/pkg/log"
+)
+
+const (
+	auditEventTableName = "audit_event"
+)
+
+func init() {
+	register(table{
+		name:    auditEventTableName,
+		model:   auditEventModel{},
+		autoinc: true,
+		pkey:    []string{"id"},
+	})
+}
+
+type auditEventModel struct {
+	ID          int64  `db:"id"`
+	Type        string `db:"type"`
+	CreatedAt   int64  `db:"created_at"`
+	UserID      string `db:"user_id"`
+	ClientID    string `db:"client_id"`
+	ConnectorID string `db:"connector_id"`
+	RemoteAddr  string `db:"remote_addr"`
+	Details     string `db:"details"`
+}
+
+func newAuditEventModel(e audit.Event) *auditEventModel {
+	return &auditEventModel{
+		Type:        string(e.Type),
+		CreatedAt:   e.Time.Unix(),
+		UserID:      e.UserID,
+		ClientID:    e.ClientID,
+		ConnectorID: e.ConnectorID,
+		RemoteAddr:  e.RemoteAddr,
+		Details:     e.Details,
+	}
+}
+
+func (m *auditEventModel) event() audit.Event {
+	return audit.Event{
+		ID:          m.ID,
+		Type:        audit.EventType(m.Type),
+		Time:        time.Unix(m.CreatedAt, 0).UTC(),
+		UserID:      m.UserID,
+		ClientID:    m.ClientID,
+		ConnectorID: m.ConnectorID,
+		RemoteAddr:  m.RemoteAddr,
+		Details:     m.Details,
+	}
+}
+
+func NewAuditEventRepo(dbm *gorp.DbMap) audit.EventRepo {
+	return newAuditEventRepo(dbm, 0)
+}
+
+// newAuditEventRepo returns an auditEventRepo which purges events older
+// than retention. A retention of 0 keeps events forever.
+func newAuditEventRepo(dbm *gorp.DbMap, retention time.Duration) *auditEventRepo {
+	return &auditEventRepo{
+		dbMap:     dbm,
+		retention: retention,
+		clock:     clockwork.NewRealClock(),
+	}
+}
+
+type auditEventRepo struct {
+	dbMap     *gorp.DbMap
+	retention time.Duration
+	clock     clockwork.Clock
+}
+
+func (r *auditEventRepo) Record(e audit.Event) error {
+	if !e.Type.Valid() {
+		return audit.ErrorInvalidEventType
+	}
+	return r.dbMap.Insert(newAuditEventModel(e))
+}
+
+func (r *auditEventRepo) List(filter audit.EventFilter, maxResults int, nextPageToken string) ([]audit.Event, string, error) {
+	var beforeID int64
+	if nextPageToken != "" {
+		var err error
+		if filter, maxResults, beforeID, err = audit.DecodeNextPageToken(nextPageToken); err != nil {
+			return nil, "", err
+		}
+	}
+	if maxResults <= 0 {
+		return nil, "", audit.ErrorInvalidMaxResults
+	}
+
+	qt := pq.QuoteIdentifier(auditEventTableName)
+	where, args := auditEventFilterWhere(filter, beforeID)
+
+	// Ask for one more than needed so we know if there's more results, and
+	// hence, whether a nextPageToken is necessary.
+	q := fmt.Sprintf("SELECT * FROM %s%s ORDER BY id DESC LIMIT $%d", qt, where, len(args)+1)
+	ms, err := r.dbMap.Select(&auditEventModel{}, q, append(args, maxResults+1)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	events := make([]audit.Event, 0, len(ms))
+	for _, m := range ms {
+		am, ok := m.(*auditEventModel)
+		if !ok {
+			log.Errorf("expected auditEventModel but found %v", reflect.TypeOf(m))
+			return nil, "", errors.New("unrecognized model")
+		}
+		events = append(events, am.event())
+	}
+
+	var tok string
+	if len(events) > maxResults {
+		events = events[:maxResults]
+		if tok, err = audit.EncodeNextPageToken(filter, maxResults, events[maxResults-1].ID); err != nil {
+			return nil, "", err
+		}
+	}
+	return events, tok, nil
+}
+
+func auditEventFilterWhere(filter audit.EventFilter, beforeID int64) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if beforeID != 0 {
+		add("id < $%d", beforeID)
+	}
+	if filter.UserID != "" {
+		add("user_id = $%d", filter.UserID)
+	}
+	if filter.ClientID != "" {
+		add("client_id = $%d", filter.ClientID)
+	}
+	if filter.Type != "" {
+		add("type = $%d", string(filter.Type))
+	}
+	if !filter.After.IsZero() {
+		add("created_at >= $%d", filter.After.Unix())
+	}
+	if !filter.Before.IsZero() {
+		add("created_at < $%d", filter.Before.Unix())
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+func (r *auditEventRepo) purge() error {
+	if r.retention == 0 {
+		return nil
+	}
+
+	qt := pq.QuoteIdentifier(auditEventTableName)
+	q := fmt.Sprintf("DELETE FROM %s WHERE created_at < $1", qt)
+	res, err := r.dbMap.Exec(q, r.clock.Now().Add(-r.retention).Unix())
+	if err != nil {
+		return err
+	}
+
+	d := "unknown # of"
+	if n, err := res.RowsAffected(); err == nil {
+		if n == 0 {
+			return nil
+		}
+		d = fmt.Sprintf("%d", n)
+		gcPurgedRows.Add(float64(n), auditEventTableName)
+	}
+
+	log.Infof("Deleted %s expired row(s) from %s table", d, auditEventTableName)
+	return nil
+}
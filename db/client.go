@@ -7,9 +7,11 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/coreos/go-oidc/oidc"
 	"github.com/go-gorp/gorp"
+	"github.com/jonboulle/clockwork"
 	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 
@@ -19,7 +21,8 @@ import (
 )
 
 const (
-	clientIdentityTableName = "client_identity"
+	clientIdentityTableName       = "client_identity"
+	clientIdentitySecretTableName = "client_identity_secret"
 
 	bcryptHashCost = 10
 
@@ -28,9 +31,6 @@ const (
 	// since the bcrypt library will silently ignore portions of
 	// a password past the first 72 characters.
 	maxSecretLength = 72
-
-	// postgres error codes
-	pgErrorCodeUniqueViolation = "23505" // unique_violation
 )
 
 func init() {
@@ -40,14 +40,16 @@ func init() {
 		autoinc: false,
 		pkey:    []string{"id"},
 	})
-}
 
-func newClientIdentityModel(id string, secret []byte, meta *oidc.ClientMetadata) (*clientIdentityModel, error) {
-	hashed, err := bcrypt.GenerateFromPassword(secret, bcryptHashCost)
-	if err != nil {
-		return nil, err
-	}
+	register(table{
+		name:    clientIdentitySecretTableName,
+		model:   clientIdentitySecretModel{},
+		autoinc: true,
+		pkey:    []string{"id"},
+	})
+}
 
+func newClientIdentityModel(id string, meta *oidc.ClientMetadata) (*clientIdentityModel, error) {
 	bmeta, err := json.Marshal(meta)
 	if err != nil {
 		return nil, err
@@ -55,7 +57,6 @@ func newClientIdentityModel(id string, secret []byte, meta *oidc.ClientMetadata)
 
 	cim := clientIdentityModel{
 		ID:       id,
-		Secret:   hashed,
 		Metadata: string(bmeta),
 	}
 
@@ -64,16 +65,44 @@ func newClientIdentityModel(id string, secret []byte, meta *oidc.ClientMetadata)
 
 type clientIdentityModel struct {
 	ID       string `db:"id"`
-	Secret   []byte `db:"secret"`
 	Metadata string `db:"metadata"`
 	DexAdmin bool   `db:"dex_admin"`
+
+	// ClaimMapping is the JSON encoding of the client's claim mapping, or
+	// empty if none was set.
+	ClaimMapping string `db:"claim_mapping"`
+}
+
+func newClientIdentitySecretModel(clientID string, secret []byte, createdAt time.Time) (*clientIdentitySecretModel, error) {
+	hashed, err := bcrypt.GenerateFromPassword(secret, bcryptHashCost)
+	if err != nil {
+		return nil, err
+	}
+
+	csm := clientIdentitySecretModel{
+		ClientID:  clientID,
+		Secret:    hashed,
+		CreatedAt: createdAt.Unix(),
+	}
+
+	return &csm, nil
+}
+
+// clientIdentitySecretModel is one of the secrets of a client. A client may
+// have several valid secrets during a rotation; ExpiresAt is zero for
+// secrets which do not expire.
+type clientIdentitySecretModel struct {
+	ID        int64  `db:"id"`
+	ClientID  string `db:"client_id"`
+	Secret    []byte `db:"secret"`
+	CreatedAt int64  `db:"created_at"`
+	ExpiresAt int64  `db:"expires_at"`
 }
 
 func (m *clientIdentityModel) ClientIdentity() (*oidc.ClientIdentity, error) {
 	ci := oidc.ClientIdentity{
 		Credentials: oidc.ClientCredentials{
-			ID:     m.ID,
-			Secret: string(m.Secret),
+			ID: m.ID,
 		},
 	}
 
@@ -85,7 +114,11 @@ func (m *clientIdentityModel) ClientIdentity() (*oidc.ClientIdentity, error) {
 }
 
 func NewClientIdentityRepo(dbm *gorp.DbMap) client.ClientIdentityRepo {
-	return &clientIdentityRepo{dbMap: dbm}
+	return NewClientIdentityRepoWithClock(dbm, clockwork.NewRealClock())
+}
+
+func NewClientIdentityRepoWithClock(dbm *gorp.DbMap, clock clockwork.Clock) client.ClientIdentityRepo {
+	return &clientIdentityRepo{dbMap: dbm, clock: clock}
 }
 
 func NewClientIdentityRepoFromClients(dbm *gorp.DbMap, clients []oidc.ClientIdentity) (client.ClientIdentityRepo, error) {
@@ -96,12 +129,7 @@ func NewClientIdentityRepoFromClients(dbm *gorp.DbMap, clients []oidc.ClientIden
 			return nil, err
 		}
 
-		cm, err := newClientIdentityModel(c.Credentials.ID, dec, &c.Metadata)
-		if err != nil {
-			return nil, err
-		}
-		err = repo.dbMap.Insert(cm)
-		if err != nil {
+		if err := repo.insert(c.Credentials.ID, dec, &c.Metadata); err != nil {
 			return nil, err
 		}
 	}
@@ -110,6 +138,37 @@ func NewClientIdentityRepoFromClients(dbm *gorp.DbMap, clients []oidc.ClientIden
 
 type clientIdentityRepo struct {
 	dbMap *gorp.DbMap
+	clock clockwork.Clock
+}
+
+// insert adds a client along with its first secret.
+func (r *clientIdentityRepo) insert(id string, secret []byte, meta *oidc.ClientMetadata) error {
+	cim, err := newClientIdentityModel(id, meta)
+	if err != nil {
+		return err
+	}
+
+	csm, err := newClientIdentitySecretModel(id, secret, r.clock.Now())
+	if err != nil {
+		return err
+	}
+
+	tx, err := r.dbMap.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Insert(cim); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Insert(csm); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	return tx.Commit()
 }
 
 func (r *clientIdentityRepo) Metadata(clientID string) (*oidc.ClientMetadata, error) {
@@ -151,16 +210,81 @@ func (r *clientIdentityRepo) IsDexAdmin(clientID string) (bool, error) {
 }
 
 func (r *clientIdentityRepo) SetDexAdmin(clientID string, isAdmin bool) error {
+	return r.update(clientID, func(cim *clientIdentityModel) error {
+		cim.DexAdmin = isAdmin
+		return nil
+	})
+}
+
+func (r *clientIdentityRepo) ClaimMapping(clientID string) (client.ClaimMapping, error) {
+	m, err := r.dbMap.Get(clientIdentityModel{}, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return client.ClaimMapping{}, nil
+	}
+
+	cim, ok := m.(*clientIdentityModel)
+	if !ok {
+		log.Errorf("expected clientIdentityModel but found %v", reflect.TypeOf(m))
+		return nil, errors.New("unrecognized model")
+	}
+
+	cm := client.ClaimMapping{}
+	if cim.ClaimMapping == "" {
+		return cm, nil
+	}
+	if err := json.Unmarshal([]byte(cim.ClaimMapping), &cm); err != nil {
+		return nil, err
+	}
+	return cm, nil
+}
+
+func (r *clientIdentityRepo) SetClaimMapping(clientID string, cm client.ClaimMapping) error {
+	var bcm []byte
+	if len(cm) > 0 {
+		var err error
+		if bcm, err = json.Marshal(cm); err != nil {
+			return err
+		}
+	}
+
+	return r.update(clientID, func(cim *clientIdentityModel) error {
+		cim.ClaimMapping = string(bcm)
+		return nil
+	})
+}
+
+func (r *clientIdentityRepo) Update(clientID string, meta oidc.ClientMetadata) error {
+	bmeta, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+
+	return r.update(clientID, func(cim *clientIdentityModel) error {
+		cim.Metadata = string(bmeta)
+		return nil
+	})
+}
+
+// update applies fn to the stored model of the given client within a
+// transaction and writes the result back.
+func (r *clientIdentityRepo) update(clientID string, fn func(*clientIdentityModel) error) error {
 	tx, err := r.dbMap.Begin()
 	if err != nil {
 		return err
 	}
 
-	m, err := r.dbMap.Get(clientIdentityModel{}, clientID)
-	if m == nil || err != nil {
+	m, err := tx.Get(clientIdentityModel{}, clientID)
+	if err != nil {
 		rollback(tx)
 		return err
 	}
+	if m == nil {
+		rollback(tx)
+		return client.ErrorNotFound
+	}
 
 	cim, ok := m.(*clientIdentityModel)
 	if !ok {
@@ -169,15 +293,48 @@ func (r *clientIdentityRepo) SetDexAdmin(clientID string, isAdmin bool) error {
 		return errors.New("unrecognized model")
 	}
 
-	cim.DexAdmin = isAdmin
-	_, err = r.dbMap.Update(cim)
-	if err != nil {
+	if err := fn(cim); err != nil {
 		rollback(tx)
 		return err
 	}
 
-	err = tx.Commit()
+	if _, err := tx.Update(cim); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	return nil
+}
+
+func (r *clientIdentityRepo) Delete(clientID string) error {
+	tx, err := r.dbMap.Begin()
 	if err != nil {
+		return err
+	}
+
+	n, err := tx.Delete(&clientIdentityModel{ID: clientID})
+	if err != nil {
+		rollback(tx)
+		return err
+	}
+	if n == 0 {
+		rollback(tx)
+		return client.ErrorNotFound
+	}
+
+	qt := pq.QuoteIdentifier(clientIdentitySecretTableName)
+	q := fmt.Sprintf("DELETE FROM %s WHERE client_id = $1", qt)
+	if _, err := tx.Exec(q, clientID); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
 		rollback(tx)
 		return err
 	}
@@ -207,23 +364,47 @@ func (r *clientIdentityRepo) Authenticate(creds oidc.ClientCredentials) (bool, e
 		return false, nil
 	}
 
-	ok = bcrypt.CompareHashAndPassword(cim.Secret, dec) == nil
-	return ok, nil
+	secrets, err := r.validSecrets(cim.ID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, csm := range secrets {
+		if bcrypt.CompareHashAndPassword(csm.Secret, dec) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
 }
 
-func (r *clientIdentityRepo) New(id string, meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
-	secret, err := pcrypto.RandBytes(maxSecretLength)
+// validSecrets returns the unexpired secrets of the given client.
+func (r *clientIdentityRepo) validSecrets(clientID string) ([]*clientIdentitySecretModel, error) {
+	qt := pq.QuoteIdentifier(clientIdentitySecretTableName)
+	q := fmt.Sprintf("SELECT * FROM %s WHERE client_id = $1 AND (expires_at = 0 OR expires_at > $2)", qt)
+	objs, err := r.dbMap.Select(&clientIdentitySecretModel{}, q, clientID, r.clock.Now().Unix())
 	if err != nil {
 		return nil, err
 	}
 
-	cim, err := newClientIdentityModel(id, secret, &meta)
+	secrets := make([]*clientIdentitySecretModel, len(objs))
+	for i, obj := range objs {
+		csm, ok := obj.(*clientIdentitySecretModel)
+		if !ok {
+			return nil, errors.New("unable to cast client secret to clientIdentitySecretModel")
+		}
+		secrets[i] = csm
+	}
+	return secrets, nil
+}
+
+func (r *clientIdentityRepo) New(id string, meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
+	secret, err := pcrypto.RandBytes(maxSecretLength)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := r.dbMap.Insert(cim); err != nil {
-		if perr, ok := err.(*pq.Error); ok && perr.Code == pgErrorCodeUniqueViolation {
+	if err := r.insert(id, secret, &meta); err != nil {
+		if isUniqueViolation(err) {
 			err = errors.New("client ID already exists")
 		}
 
@@ -261,3 +442,79 @@ func (r *clientIdentityRepo) All() ([]oidc.ClientIdentity, error) {
 	}
 	return cs, nil
 }
+
+func (r *clientIdentityRepo) RotateSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error) {
+	secret, err := pcrypto.RandBytes(maxSecretLength)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.clock.Now()
+	csm, err := newClientIdentitySecretModel(clientID, secret, now)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := r.dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := tx.Get(clientIdentityModel{}, clientID)
+	if err != nil {
+		rollback(tx)
+		return nil, err
+	}
+	if m == nil {
+		rollback(tx)
+		return nil, client.ErrorNotFound
+	}
+
+	// No secret may outlive the grace period, including those already on
+	// their way out from previous rotations.
+	expiresAt := now.Add(gracePeriod).Unix()
+	qt := pq.QuoteIdentifier(clientIdentitySecretTableName)
+	q := fmt.Sprintf("UPDATE %s SET expires_at = $1 WHERE client_id = $2 AND (expires_at = 0 OR expires_at > $1)", qt)
+	if _, err := tx.Exec(q, expiresAt, clientID); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
+	if err := tx.Insert(csm); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
+	cc := oidc.ClientCredentials{
+		ID:     clientID,
+		Secret: base64.URLEncoding.EncodeToString(secret),
+	}
+
+	return &cc, nil
+}
+
+func (r *clientIdentityRepo) purge() error {
+	qt := pq.QuoteIdentifier(clientIdentitySecretTableName)
+	q := fmt.Sprintf("DELETE FROM %s WHERE expires_at != 0 AND expires_at <= $1", qt)
+	res, err := r.dbMap.Exec(q, r.clock.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	d := "unknown # of"
+	if n, err := res.RowsAffected(); err == nil {
+		if n == 0 {
+			return nil
+		}
+		d = fmt.Sprintf("%d", n)
+		gcPurgedRows.Add(float64(n), clientIdentitySecretTableName)
+	}
+
+	log.Infof("Deleted %s stale row(s) from %s table", d, clientIdentitySecretTableName)
+	return nil
+}
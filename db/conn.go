@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/go-gorp/gorp"
 	_ "github.com/lib/pq"
 
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/pkg/metrics"
 	"github.com/coreos/dex/repo"
 )
 
@@ -25,14 +27,56 @@ type table struct {
 
 var (
 	tables []table
+
+	// statsDB is the connection pool reported by the connection metrics,
+	// the one most recently opened by NewConnection.
+	statsDB   *sql.DB
+	statsDBMu sync.Mutex
+
+	// dataSources are the driver names and data sources of the connection
+	// pools opened by NewConnection, for opening connections outside of
+	// the pools.
+	dataSources   = make(map[*sql.DB]dataSource)
+	dataSourcesMu sync.Mutex
 )
 
+type dataSource struct {
+	driverName string
+	source     string
+}
+
+func init() {
+	poolStat := func(f func(sql.DBStats) float64) func() float64 {
+		return func() float64 {
+			statsDBMu.Lock()
+			db := statsDB
+			statsDBMu.Unlock()
+			if db == nil {
+				return 0
+			}
+			return f(db.Stats())
+		}
+	}
+
+	metrics.NewGaugeFunc("dex_db_open_connections", "Open connections to the database.",
+		poolStat(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	metrics.NewGaugeFunc("dex_db_in_use_connections", "Database connections in use.",
+		poolStat(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	metrics.NewGaugeFunc("dex_db_idle_connections", "Idle database connections.",
+		poolStat(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	metrics.NewCounterFunc("dex_db_wait_count_total", "Waits for a free database connection.",
+		poolStat(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	metrics.NewCounterFunc("dex_db_wait_duration_seconds_total", "Time spent waiting for a free database connection.",
+		poolStat(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+}
+
 func register(t table) {
 	tables = append(tables, t)
 }
 
 type Config struct {
 	// Connection string in the format: <driver>://<username>:<password>@<host>:<port>/<database>
+	// where driver is postgres or mysql, or sqlite3://<path> for SQLite.
 	DSN string
 	// The maximum number of open connections to the database. The default is 0 (unlimited).
 	// For more details see: http://golang.org/pkg/database/sql/#DB.SetMaxOpenConns
@@ -43,21 +87,36 @@ type Config struct {
 }
 
 func NewConnection(cfg Config) (*gorp.DbMap, error) {
-	if !strings.HasPrefix(cfg.DSN, "postgres://") {
-		return nil, errors.New("unrecognized database driver")
+	dialect, driverName, source, err := parseDSN(cfg.DSN)
+	if err != nil {
+		return nil, err
 	}
 
-	db, err := sql.Open("postgres", cfg.DSN)
+	db, err := sql.Open(driverName, source)
 	if err != nil {
 		return nil, err
 	}
 
 	db.SetMaxIdleConns(cfg.MaxIdleConnections)
 	db.SetMaxOpenConns(cfg.MaxOpenConnections)
+	if dialect == DialectSQLite && strings.HasPrefix(source, ":memory:") {
+		// Every connection to :memory: opens a database of its own, which
+		// is gone once the connection is closed.
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+	}
+
+	statsDBMu.Lock()
+	statsDB = db
+	statsDBMu.Unlock()
+
+	dataSourcesMu.Lock()
+	dataSources[db] = dataSource{driverName: driverName, source: source}
+	dataSourcesMu.Unlock()
 
 	dbm := gorp.DbMap{
 		Db:      db,
-		Dialect: gorp.PostgresDialect{},
+		Dialect: gorpDialect(dialect),
 	}
 
 	for _, t := range tables {
@@ -74,6 +133,18 @@ func NewConnection(cfg Config) (*gorp.DbMap, error) {
 	return &dbm, nil
 }
 
+// openSeparately opens a connection pool to the database of dbMap, apart
+// from dbMap's own pool.
+func openSeparately(dbMap *gorp.DbMap) (*sql.DB, error) {
+	dataSourcesMu.Lock()
+	ds, ok := dataSources[dbMap.Db]
+	dataSourcesMu.Unlock()
+	if !ok {
+		return nil, errors.New("connection not opened by NewConnection")
+	}
+	return sql.Open(ds.driverName, ds.source)
+}
+
 func TransactionFactory(conn *gorp.DbMap) repo.TransactionFactory {
 	return func() (repo.Transaction, error) {
 		return conn.Begin()
@@ -122,13 +122,18 @@ func (r *ConnectorConfigRepo) Set(cfgs []connector.ConnectorConfig) error {
 		return err
 	}
 
+	// Both statements must run in tx: SQLite locks the whole database for
+	// a transaction, so a statement on another connection would wait for
+	// tx to end.
 	qt := pq.QuoteIdentifier(connectorConfigTableName)
 	q := fmt.Sprintf("DELETE FROM %s", qt)
-	if _, err = r.dbMap.Exec(q); err != nil {
+	if _, err = tx.Exec(q); err != nil {
+		rollback(tx)
 		return err
 	}
 
-	if err = r.dbMap.Insert(insert...); err != nil {
+	if err = tx.Insert(insert...); err != nil {
+		rollback(tx)
 		return fmt.Errorf("DB insert failed %#v: %v", insert, err)
 	}
 
@@ -7,9 +7,17 @@ import (
 	"github.com/jonboulle/clockwork"
 
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/pkg/metrics"
 	ptime "github.com/coreos/dex/pkg/time"
 )
 
+var (
+	gcRuns = metrics.NewCounterVec("dex_gc_runs_total",
+		"Garbage collection runs, by result.", "result")
+	gcPurgedRows = metrics.NewCounterVec("dex_gc_purged_rows_total",
+		"Rows deleted by garbage collection, by table.", "table")
+)
+
 type purger interface {
 	purge() error
 }
@@ -19,9 +27,14 @@ type namedPurger struct {
 	purger
 }
 
-func NewGarbageCollector(dbm *gorp.DbMap, ival time.Duration) *GarbageCollector {
+// NewGarbageCollector returns a GarbageCollector purging expired sessions and
+// client secrets, audit events older than auditRetention, and failed logins
+// which the workers forget after lockoutResetAfter, every ival. A duration
+// of 0 keeps the respective rows forever.
+func NewGarbageCollector(dbm *gorp.DbMap, ival, auditRetention, lockoutResetAfter time.Duration) *GarbageCollector {
 	sRepo := NewSessionRepo(dbm)
 	skRepo := NewSessionKeyRepo(dbm)
+	ciRepo := NewClientIdentityRepo(dbm).(*clientIdentityRepo)
 
 	purgers := []namedPurger{
 		namedPurger{
@@ -32,6 +45,18 @@ func NewGarbageCollector(dbm *gorp.DbMap, ival time.Duration) *GarbageCollector
 			name:   "session_key",
 			purger: skRepo,
 		},
+		namedPurger{
+			name:   "client_identity_secret",
+			purger: ciRepo,
+		},
+		namedPurger{
+			name:   "audit_event",
+			purger: newAuditEventRepo(dbm, auditRetention),
+		},
+		namedPurger{
+			name:   "login_attempt",
+			purger: newLoginAttemptRepo(dbm, lockoutResetAfter),
+		},
 	}
 
 	gc := GarbageCollector{
@@ -59,6 +84,7 @@ func (gc *GarbageCollector) Run() chan struct{} {
 			select {
 			case <-gc.clock.After(next):
 				if anyPurgeErrors(purgeAll(gc.purgers)) {
+					gcRuns.Inc("failure")
 					if !failing {
 						failing = true
 						next = time.Second
@@ -68,6 +94,7 @@ func (gc *GarbageCollector) Run() chan struct{} {
 					log.Errorf("Failed garbage collection, retrying in %v", next)
 					break
 				}
+				gcRuns.Inc("success")
 				failing = false
 				next = gc.interval
 				log.Infof("Garbage collection complete, running again in %v", next)
@@ -0,0 +1,261 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	groupTableName       = "user_group"
+	groupMemberTableName = "user_group_member"
+)
+
+func init() {
+	register(table{
+		name:    groupTableName,
+		model:   groupModel{},
+		autoinc: false,
+		pkey:    []string{"id"},
+		unique:  []string{"display_name"},
+	})
+
+	register(table{
+		name:    groupMemberTableName,
+		model:   groupMemberModel{},
+		autoinc: false,
+		pkey:    []string{"group_id", "user_id"},
+	})
+}
+
+type groupModel struct {
+	ID          string `db:"id"`
+	DisplayName string `db:"display_name"`
+	CreatedAt   int64  `db:"created_at"`
+}
+
+type groupMemberModel struct {
+	GroupID string `db:"group_id"`
+	UserID  string `db:"user_id"`
+}
+
+func NewGroupRepo(dbm *gorp.DbMap) user.GroupRepo {
+	return &groupRepo{
+		dbMap: dbm,
+	}
+}
+
+type groupRepo struct {
+	dbMap *gorp.DbMap
+}
+
+func (r *groupRepo) Get(tx repo.Transaction, id string) (user.Group, error) {
+	gm, err := r.get(tx, id)
+	if err != nil {
+		return user.Group{}, err
+	}
+	return r.group(tx, gm)
+}
+
+func (r *groupRepo) List(tx repo.Transaction) ([]user.Group, error) {
+	qt := pq.QuoteIdentifier(groupTableName)
+	return r.selectGroups(tx, fmt.Sprintf("SELECT * FROM %s ORDER BY display_name", qt))
+}
+
+func (r *groupRepo) GetByMember(tx repo.Transaction, userID string) ([]user.Group, error) {
+	qt := pq.QuoteIdentifier(groupTableName)
+	qm := pq.QuoteIdentifier(groupMemberTableName)
+	return r.selectGroups(tx, fmt.Sprintf("SELECT * FROM %s WHERE id IN (SELECT group_id FROM %s WHERE user_id = $1) ORDER BY display_name", qt, qm), userID)
+}
+
+func (r *groupRepo) Create(tx repo.Transaction, g user.Group) error {
+	if g.ID == "" {
+		return user.ErrorInvalidID
+	}
+	if g.DisplayName == "" {
+		return user.ErrorInvalidGroupName
+	}
+
+	_, err := r.get(tx, g.ID)
+	if err == nil {
+		return user.ErrorDuplicateID
+	}
+	if err != user.ErrorGroupNotFound {
+		return err
+	}
+	if err := r.checkDisplayName(tx, g); err != nil {
+		return err
+	}
+
+	gm := &groupModel{
+		ID:          g.ID,
+		DisplayName: g.DisplayName,
+		CreatedAt:   g.CreatedAt.Unix(),
+	}
+	if err := r.executor(tx).Insert(gm); err != nil {
+		return err
+	}
+	return r.insertMembers(tx, g)
+}
+
+func (r *groupRepo) Update(tx repo.Transaction, g user.Group) error {
+	if g.DisplayName == "" {
+		return user.ErrorInvalidGroupName
+	}
+
+	gm, err := r.get(tx, g.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.checkDisplayName(tx, g); err != nil {
+		return err
+	}
+
+	ex := r.executor(tx)
+	gm.DisplayName = g.DisplayName
+	if _, err := ex.Update(gm); err != nil {
+		return err
+	}
+
+	qm := pq.QuoteIdentifier(groupMemberTableName)
+	if _, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE group_id = $1", qm), g.ID); err != nil {
+		return err
+	}
+	return r.insertMembers(tx, g)
+}
+
+func (r *groupRepo) Delete(tx repo.Transaction, id string) error {
+	ex := r.executor(tx)
+	qm := pq.QuoteIdentifier(groupMemberTableName)
+	if _, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE group_id = $1", qm), id); err != nil {
+		return err
+	}
+
+	qt := pq.QuoteIdentifier(groupTableName)
+	result, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = $1", qt), id)
+	if err != nil {
+		return err
+	}
+
+	ct, err := result.RowsAffected()
+	switch {
+	case err != nil:
+		return err
+	case ct == 0:
+		return user.ErrorGroupNotFound
+	}
+	return nil
+}
+
+func (r *groupRepo) RemoveMember(tx repo.Transaction, userID string) error {
+	qm := pq.QuoteIdentifier(groupMemberTableName)
+	_, err := r.executor(tx).Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qm), userID)
+	return err
+}
+
+func (r *groupRepo) get(tx repo.Transaction, id string) (*groupModel, error) {
+	m, err := r.executor(tx).Get(groupModel{}, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return nil, user.ErrorGroupNotFound
+	}
+
+	gm, ok := m.(*groupModel)
+	if !ok {
+		log.Errorf("expected groupModel but found %v", reflect.TypeOf(m))
+		return nil, errors.New("unrecognized model")
+	}
+	return gm, nil
+}
+
+// checkDisplayName returns ErrorDuplicateGroupName if another group than g
+// has its display name.
+func (r *groupRepo) checkDisplayName(tx repo.Transaction, g user.Group) error {
+	qt := pq.QuoteIdentifier(groupTableName)
+	ct, err := r.executor(tx).SelectInt(fmt.Sprintf("SELECT count(*) FROM %s WHERE display_name = $1 AND id != $2", qt), g.DisplayName, g.ID)
+	if err != nil {
+		return err
+	}
+	if ct > 0 {
+		return user.ErrorDuplicateGroupName
+	}
+	return nil
+}
+
+func (r *groupRepo) insertMembers(tx repo.Transaction, g user.Group) error {
+	ex := r.executor(tx)
+	seen := make(map[string]bool)
+	for _, userID := range g.Members {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+		if err := ex.Insert(&groupMemberModel{GroupID: g.ID, UserID: userID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *groupRepo) selectGroups(tx repo.Transaction, q string, args ...interface{}) ([]user.Group, error) {
+	ms, err := r.executor(tx).Select(&groupModel{}, q, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []user.Group{}
+	for _, m := range ms {
+		gm, ok := m.(*groupModel)
+		if !ok {
+			log.Errorf("expected groupModel but found %v", reflect.TypeOf(m))
+			return nil, errors.New("unrecognized model")
+		}
+		g, err := r.group(tx, gm)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, g)
+	}
+	return groups, nil
+}
+
+// group returns the user.Group stored by gm, along with its members.
+func (r *groupRepo) group(tx repo.Transaction, gm *groupModel) (user.Group, error) {
+	qm := pq.QuoteIdentifier(groupMemberTableName)
+	var members []string
+	_, err := r.executor(tx).Select(&members,
+		fmt.Sprintf("SELECT user_id FROM %s WHERE group_id = $1 ORDER BY user_id", qm), gm.ID)
+	if err != nil {
+		return user.Group{}, err
+	}
+
+	return user.Group{
+		ID:          gm.ID,
+		DisplayName: gm.DisplayName,
+		Members:     members,
+		CreatedAt:   time.Unix(gm.CreatedAt, 0).UTC(),
+	}, nil
+}
+
+func (r *groupRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/lib/pq"
 
 	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/pkg/metrics"
 	"github.com/coreos/go-oidc/key"
 )
 
@@ -20,6 +21,11 @@ const (
 
 var (
 	ErrorCannotDecryptKeys = errors.New("Cannot Decrypt Keys")
+
+	keyRotationTime = metrics.NewGaugeVec("dex_key_rotation_timestamp_seconds",
+		"Unix time at which the signing keys were last rotated.")
+	keyExpiryTime = metrics.NewGaugeVec("dex_key_expiry_timestamp_seconds",
+		"Unix time at which the current signing keys expire.")
 )
 
 func init() {
@@ -148,7 +154,13 @@ func (r *PrivateKeySetRepo) Set(ks key.KeySet) error {
 	}
 
 	b := &privateKeySetBlob{Value: v}
-	return r.dbMap.Insert(b)
+	if err := r.dbMap.Insert(b); err != nil {
+		return err
+	}
+
+	keyRotationTime.Set(float64(time.Now().Unix()))
+	keyExpiryTime.Set(float64(pks.ExpiresAt().Unix()))
+	return nil
 }
 
 func (r *PrivateKeySetRepo) Get() (key.KeySet, error) {
@@ -167,36 +179,46 @@ func (r *PrivateKeySetRepo) Get() (key.KeySet, error) {
 		return nil, errors.New("unable to cast to KeySet")
 	}
 
-	var pks *key.PrivateKeySet
-	for _, secret := range r.secrets {
-		var j []byte
-
-		if r.useOldFormat {
-			j, err = pcrypto.AESDecrypt(b.Value, secret)
-		} else {
-			j, err = pcrypto.Decrypt(b.Value, secret)
-		}
-
-		if err != nil {
-			continue
-		}
-
-		var m privateKeySetModel
-		if err = json.Unmarshal(j, &m); err != nil {
-			continue
+	// Once re-encrypted, keys are in the current format even if the
+	// deprecated one is still being written.
+	formats := []bool{r.useOldFormat}
+	if r.useOldFormat {
+		formats = append(formats, false)
+	}
+	for _, oldFormat := range formats {
+		for _, secret := range r.secrets {
+			if pks, _, err := decryptPrivateKeySet(b.Value, secret, oldFormat); err == nil {
+				return key.KeySet(pks), nil
+			}
 		}
+	}
+	return nil, ErrorCannotDecryptKeys
+}
 
-		pks, err = m.PrivateKeySet()
-		if err != nil {
-			continue
-		}
-		break
+// decryptPrivateKeySet decrypts a stored key set with secret, returning it
+// along with its JSON. As AES-CBC does not authenticate what it decrypts,
+// keys in the deprecated format only count as decrypted once they parse.
+func decryptPrivateKeySet(value, secret []byte, oldFormat bool) (*key.PrivateKeySet, []byte, error) {
+	var j []byte
+	var err error
+	if oldFormat {
+		j, err = pcrypto.AESDecrypt(value, secret)
+	} else {
+		j, err = pcrypto.Decrypt(value, secret)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
+	var m privateKeySetModel
+	if err = json.Unmarshal(j, &m); err != nil {
+		return nil, nil, err
+	}
+	pks, err := m.PrivateKeySet()
 	if err != nil {
-		return nil, ErrorCannotDecryptKeys
+		return nil, nil, err
 	}
-	return key.KeySet(pks), nil
+	return pks, j, nil
 }
 
 func (r *PrivateKeySetRepo) active() []byte {
@@ -0,0 +1,171 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/jonboulle/clockwork"
+	"github.com/lib/pq"
+
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	loginAttemptTableName = "login_attempt"
+)
+
+func init() {
+	register(table{
+		name:    loginAttemptTableName,
+		model:   loginAttemptModel{},
+		autoinc: false,
+		pkey:    []string{"id"},
+	})
+}
+
+type loginAttemptModel struct {
+	ID          string `db:"id"`
+	Failures    int    `db:"failures"`
+	LastFailure int64  `db:"last_failure"`
+	LockedUntil int64  `db:"locked_until"`
+}
+
+func NewLoginAttemptRepo(dbm *gorp.DbMap) user.LoginAttemptRepo {
+	return newLoginAttemptRepo(dbm, 0)
+}
+
+// newLoginAttemptRepo returns a loginAttemptRepo which purges the failed
+// logins of keys which have not failed for resetAfter and are no longer
+// locked. A resetAfter of 0 keeps them forever.
+func newLoginAttemptRepo(dbm *gorp.DbMap, resetAfter time.Duration) *loginAttemptRepo {
+	return &loginAttemptRepo{
+		dbMap:      dbm,
+		resetAfter: resetAfter,
+		clock:      clockwork.NewRealClock(),
+	}
+}
+
+type loginAttemptRepo struct {
+	dbMap      *gorp.DbMap
+	resetAfter time.Duration
+	clock      clockwork.Clock
+}
+
+// Get returns the LoginAttempts with the given key. Within a transaction the
+// row is locked until the transaction ends, so that concurrent failures
+// recorded by other workers are not lost.
+func (r *loginAttemptRepo) Get(tx repo.Transaction, key string) (user.LoginAttempts, error) {
+	q := fmt.Sprintf("SELECT * FROM %s WHERE id = $1", pq.QuoteIdentifier(loginAttemptTableName))
+	if tx != nil {
+		q += forUpdate(r.dbMap)
+	}
+
+	var m loginAttemptModel
+	if err := r.executor(tx).SelectOne(&m, q, key); err != nil {
+		if err == sql.ErrNoRows {
+			return user.LoginAttempts{}, user.ErrorNotFound
+		}
+		return user.LoginAttempts{}, err
+	}
+	return m.loginAttempts(), nil
+}
+
+// Put inserts or replaces the LoginAttempts in a single statement, as two
+// workers may record the first failure for a key at the same time.
+func (r *loginAttemptRepo) Put(tx repo.Transaction, la user.LoginAttempts) error {
+	if la.Key == "" {
+		return user.ErrorInvalidID
+	}
+
+	m := newLoginAttemptModel(la)
+	upsert := "ON CONFLICT (id) DO UPDATE SET"
+	if dialectOf(r.dbMap) == DialectMySQL {
+		upsert = "ON DUPLICATE KEY UPDATE"
+	}
+	q := fmt.Sprintf(`INSERT INTO %s (id, failures, last_failure, locked_until) VALUES ($1, $2, $3, $4)
+		%s failures = $2, last_failure = $3, locked_until = $4`,
+		pq.QuoteIdentifier(loginAttemptTableName), upsert)
+	_, err := r.executor(tx).Exec(q, m.ID, m.Failures, m.LastFailure, m.LockedUntil)
+	return err
+}
+
+func (r *loginAttemptRepo) Delete(tx repo.Transaction, key string) error {
+	n, err := r.executor(tx).Delete(&loginAttemptModel{ID: key})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return user.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *loginAttemptRepo) purge() error {
+	if r.resetAfter == 0 {
+		return nil
+	}
+
+	now := r.clock.Now()
+	qt := pq.QuoteIdentifier(loginAttemptTableName)
+	q := fmt.Sprintf("DELETE FROM %s WHERE last_failure < $1 AND locked_until < $2", qt)
+	res, err := r.dbMap.Exec(q, now.Add(-r.resetAfter).Unix(), now.Unix())
+	if err != nil {
+		return err
+	}
+
+	d := "unknown # of"
+	if n, err := res.RowsAffected(); err == nil {
+		if n == 0 {
+			return nil
+		}
+		d = fmt.Sprintf("%d", n)
+		gcPurgedRows.Add(float64(n), loginAttemptTableName)
+	}
+
+	log.Infof("Deleted %s expired row(s) from %s table", d, loginAttemptTableName)
+	return nil
+}
+
+func (r *loginAttemptRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
+func newLoginAttemptModel(la user.LoginAttempts) loginAttemptModel {
+	m := loginAttemptModel{
+		ID:       la.Key,
+		Failures: la.Failures,
+	}
+	if !la.LastFailure.IsZero() {
+		m.LastFailure = la.LastFailure.Unix()
+	}
+	if !la.LockedUntil.IsZero() {
+		m.LockedUntil = la.LockedUntil.Unix()
+	}
+	return m
+}
+
+func (m loginAttemptModel) loginAttempts() user.LoginAttempts {
+	la := user.LoginAttempts{
+		Key:      m.ID,
+		Failures: m.Failures,
+	}
+	if m.LastFailure != 0 {
+		la.LastFailure = time.Unix(m.LastFailure, 0).UTC()
+	}
+	if m.LockedUntil != 0 {
+		la.LockedUntil = time.Unix(m.LockedUntil, 0).UTC()
+	}
+	return la
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coreos/dex/user"
+)
+
+func TestLoginAttemptPurge(t *testing.T) {
+	dbMap, err := NewConnection(Config{DSN: "sqlite3://:memory:"})
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	if _, err := MigrateToLatest(dbMap); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	now := time.Now()
+	r := newLoginAttemptRepo(dbMap, 24*time.Hour)
+
+	attempts := []user.LoginAttempts{
+		{Key: "recent", Failures: 1, LastFailure: now.Add(-time.Hour)},
+		{Key: "forgotten", Failures: 1, LastFailure: now.Add(-25 * time.Hour)},
+		{Key: "still-locked", Failures: 9, LastFailure: now.Add(-25 * time.Hour), LockedUntil: now.Add(time.Hour)},
+	}
+	for _, la := range attempts {
+		if err := r.Put(nil, la); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := r.purge(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for key, want := range map[string]bool{"recent": true, "forgotten": false, "still-locked": true} {
+		_, err := r.Get(nil, key)
+		if got := err == nil; got != want {
+			t.Errorf("%s: want kept=%t, got err=%v", key, want, err)
+		}
+	}
+}
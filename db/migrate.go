@@ -1,7 +1,13 @@
 package db
 
 import (
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"path"
+	"sort"
+	"time"
 
 	"github.com/go-gorp/gorp"
 	"github.com/lib/pq"
@@ -11,41 +17,200 @@ import (
 )
 
 const (
-	migrationDialect = "postgres"
-	migrationTable   = "dex_migrations"
-	migrationDir     = "db/migrations"
+	migrationTable = "dex_migrations"
+	migrationDir   = "db/migrations"
+
+	// migrationLockKey is the key of the Postgres advisory lock held while
+	// migrating. MySQL locks are named after the migration table instead.
+	migrationLockKey = 0x646578
 )
 
+var ErrorMigrationLockFailed = errors.New("unable to acquire migration lock")
+
+// MigrationStatus is a migration of the database, and the time it was
+// applied at, if it was. Migrations applied by a later version of dex are
+// not Known, and cannot be rolled back.
+type MigrationStatus struct {
+	ID        string
+	Version   int64
+	AppliedAt *time.Time
+	Known     bool
+}
+
 func init() {
 	migrate.SetTable(migrationTable)
 }
 
 func MigrateToLatest(dbMap *gorp.DbMap) (int, error) {
-	source := getSource()
+	dialect, source := getSource(dbMap)
 
-	return migrate.Exec(dbMap.Db, migrationDialect, source, migrate.Up)
+	return withMigrationLock(dbMap, func() (int, error) {
+		return migrate.Exec(dbMap.Db, dialect, source, migrate.Up)
+	})
 }
 
 func MigrateMaxMigrations(dbMap *gorp.DbMap, max int) (int, error) {
-	source := getSource()
+	dialect, source := getSource(dbMap)
 
-	return migrate.ExecMax(dbMap.Db, migrationDialect, source, migrate.Up, max)
+	return withMigrationLock(dbMap, func() (int, error) {
+		return migrate.ExecMax(dbMap.Db, dialect, source, migrate.Up, max)
+	})
+}
+
+// MigrateTo applies the migrations up to and including the given version,
+// and rolls back those after it. Version 0 rolls back every migration.
+func MigrateTo(dbMap *gorp.DbMap, version int64) (int, error) {
+	dialect, source := getSource(dbMap)
+
+	return withMigrationLock(dbMap, func() (int, error) {
+		ms, err := GetMigrationStatus(dbMap)
+		if err != nil {
+			return 0, err
+		}
+
+		var up, down int
+		for _, m := range ms {
+			switch {
+			case m.AppliedAt == nil && m.Version <= version:
+				up++
+			case m.AppliedAt != nil && m.Version > version:
+				if !m.Known {
+					return 0, fmt.Errorf("unable to roll back unknown migration %s", m.ID)
+				}
+				down++
+			}
+		}
+
+		// ExecMax takes a maximum of 0 to mean no maximum.
+		var n int
+		if down > 0 {
+			if n, err = migrate.ExecMax(dbMap.Db, dialect, source, migrate.Down, down); err != nil {
+				return n, err
+			}
+		}
+		if up > 0 {
+			m, err := migrate.ExecMax(dbMap.Db, dialect, source, migrate.Up, up)
+			return n + m, err
+		}
+		return n, nil
+	})
 }
 
 func GetPlannedMigrations(dbMap *gorp.DbMap) ([]*migrate.PlannedMigration, error) {
-	migrations, _, err := migrate.PlanMigration(dbMap.Db, migrationDialect, getSource(), migrate.Up, 0)
+	dialect, source := getSource(dbMap)
+	migrations, _, err := migrate.PlanMigration(dbMap.Db, dialect, source, migrate.Up, 0)
 	return migrations, err
 }
 
+// GetMigrationStatus returns the migrations of the database, known or
+// applied, ordered by version.
+func GetMigrationStatus(dbMap *gorp.DbMap) ([]MigrationStatus, error) {
+	dialect, source := getSource(dbMap)
+	known, err := source.FindMigrations()
+	if err != nil {
+		return nil, err
+	}
+	records, err := migrate.GetMigrationRecords(dbMap.Db, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	applied := make(map[string]time.Time)
+	for _, r := range records {
+		applied[r.Id] = r.AppliedAt
+	}
+
+	var ms []MigrationStatus
+	for _, m := range known {
+		s := MigrationStatus{ID: m.Id, Version: m.VersionInt(), Known: true}
+		if t, ok := applied[m.Id]; ok {
+			s.AppliedAt = &t
+			delete(applied, m.Id)
+		}
+		ms = append(ms, s)
+	}
+	for _, r := range records {
+		if _, ok := applied[r.Id]; !ok {
+			continue
+		}
+		m := migrate.Migration{Id: r.Id}
+		if len(m.NumberPrefixMatches()) == 0 {
+			return nil, fmt.Errorf("unrecognized migration %s", r.Id)
+		}
+		t := r.AppliedAt
+		ms = append(ms, MigrationStatus{ID: r.Id, Version: m.VersionInt(), AppliedAt: &t})
+	}
+	sort.Stable(migrationStatuses(ms))
+	return ms, nil
+}
+
+type migrationStatuses []MigrationStatus
+
+func (s migrationStatuses) Len() int           { return len(s) }
+func (s migrationStatuses) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s migrationStatuses) Less(i, j int) bool { return s[i].Version < s[j].Version }
+
+// withMigrationLock runs f while holding a lock of the database, so that
+// overlords started together do not run the same migrations at once. SQLite
+// databases, which only serve a single process, are not locked.
+func withMigrationLock(dbMap *gorp.DbMap, f func() (int, error)) (int, error) {
+	dialect := dialectOf(dbMap)
+	if dialect == DialectSQLite {
+		return f()
+	}
+
+	// Both kinds of lock belong to a connection, which must be the one
+	// releasing it. It is taken from a pool of its own, leaving dbMap's
+	// pool, which may hold a single connection, to the migrations.
+	lockDB, err := openSeparately(dbMap)
+	if err != nil {
+		return 0, err
+	}
+	defer lockDB.Close()
+	lockDB.SetMaxOpenConns(1)
+
+	ctx := context.Background()
+	conn, err := lockDB.Conn(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if dialect == DialectMySQL {
+		var ok sql.NullInt64
+		if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, -1)", migrationTable).Scan(&ok); err != nil {
+			return 0, err
+		}
+		if ok.Int64 != 1 {
+			return 0, ErrorMigrationLockFailed
+		}
+		defer conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", migrationTable)
+	} else {
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			return 0, err
+		}
+		defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", migrationLockKey)
+	}
+	return f()
+}
+
 func DropMigrationsTable(dbMap *gorp.DbMap) error {
 	qt := pq.QuoteIdentifier(migrationTable)
 	_, err := dbMap.Exec(fmt.Sprintf("drop table if exists %s ;", qt))
 	return err
 }
 
-func getSource() migrate.MigrationSource {
-	return &migrate.AssetMigrationSource{
-		Dir:      migrationDir,
+// getSource returns the dialect and the migrations of the database of dbMap.
+// The Postgres migrations are at the top of the migrations directory, those
+// of other databases in a directory named after their dialect.
+func getSource(dbMap *gorp.DbMap) (string, migrate.MigrationSource) {
+	dialect := dialectOf(dbMap)
+	dir := migrationDir
+	if dialect != DialectPostgres {
+		dir = path.Join(migrationDir, dialect)
+	}
+	return dialect, &migrate.AssetMigrationSource{
+		Dir:      dir,
 		Asset:    migrations.Asset,
 		AssetDir: migrations.AssetDir,
 	}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/go-gorp/gorp"
 )
@@ -85,7 +86,6 @@ func TestMigrateClientMetadata(t *testing.T) {
 	for i, tt := range tests {
 		model := &clientIdentityModel{
 			ID:       strconv.Itoa(i),
-			Secret:   []byte("verysecret"),
 			Metadata: tt.before,
 		}
 		if err := dbMap.Insert(model); err != nil {
@@ -119,3 +119,148 @@ func TestMigrateClientMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestMigrateClientSecrets(t *testing.T) {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		t.Skip("Test will not run without DEX_TEST_DSN environment variable.")
+		return
+	}
+	dbMap := initDB(dsn)
+
+	nMigrations := 10
+	n, err := MigrateMaxMigrations(dbMap, nMigrations)
+	if err != nil {
+		t.Fatalf("failed to perform initial migration: %v", err)
+	}
+	if n != nMigrations {
+		t.Fatalf("expected to perform %d migrations, got %d", nMigrations, n)
+	}
+
+	secrets := map[string]string{
+		"client-1": "secret-1",
+		"client-2": "secret-2",
+	}
+	for id, secret := range secrets {
+		q := "INSERT INTO client_identity (id, secret, metadata, dex_admin) VALUES ($1, $2, '{}', false)"
+		if _, err := dbMap.Exec(q, id, []byte(secret)); err != nil {
+			t.Fatalf("could not insert client: %v", err)
+		}
+	}
+
+	n, err = MigrateMaxMigrations(dbMap, 1)
+	if err != nil {
+		t.Fatalf("failed to perform migration: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected to perform 1 migration, got %d", n)
+	}
+
+	for id, secret := range secrets {
+		var got []clientIdentitySecretModel
+		_, err := dbMap.Select(&got, "SELECT * FROM client_identity_secret WHERE client_id = $1", id)
+		if err != nil {
+			t.Errorf("client %s: failed to select secrets: %v", id, err)
+			continue
+		}
+		if len(got) != 1 {
+			t.Errorf("client %s: want 1 secret, got %d", id, len(got))
+			continue
+		}
+		if string(got[0].Secret) != secret || got[0].ExpiresAt != 0 {
+			t.Errorf("client %s: unexpected secret: %#v", id, got[0])
+		}
+	}
+}
+
+func TestMigrateToAndBack(t *testing.T) {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		t.Skip("Test will not run without DEX_TEST_DSN environment variable.")
+		return
+	}
+	dbMap := initDB(dsn)
+	if err := DropMigrationsTable(dbMap); err != nil {
+		t.Fatalf("unable to drop migrations table: %v", err)
+	}
+
+	ms, err := GetMigrationStatus(dbMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ms) == 0 {
+		t.Fatalf("expected non-empty migrations")
+	}
+	mid := ms[(len(ms)-1)/2].Version
+	latest := ms[len(ms)-1].Version
+
+	tests := []struct {
+		version     int64
+		wantN       int
+		wantApplied int
+	}{
+		{mid, (len(ms)-1)/2 + 1, (len(ms)-1)/2 + 1},
+		{latest, len(ms) - (len(ms)-1)/2 - 1, len(ms)},
+		{0, len(ms), 0},
+		{latest, len(ms), len(ms)},
+	}
+	for i, tt := range tests {
+		n, err := MigrateTo(dbMap, tt.version)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if n != tt.wantN {
+			t.Errorf("case %d: want %d migrations, got %d", i, tt.wantN, n)
+		}
+
+		ms, err := GetMigrationStatus(dbMap)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		var applied int
+		for _, m := range ms {
+			if m.AppliedAt != nil {
+				applied++
+				if m.Version > tt.version {
+					t.Errorf("case %d: want migration %s not applied", i, m.ID)
+				}
+			}
+		}
+		if applied != tt.wantApplied {
+			t.Errorf("case %d: want %d applied migrations, got %d", i, tt.wantApplied, applied)
+		}
+	}
+}
+
+// TestMigrateToLatestSingleConnection checks that migrating does not wait
+// forever for a second connection while the migration lock holds the only
+// one, as the overlord opens its database with a single connection.
+func TestMigrateToLatestSingleConnection(t *testing.T) {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		t.Skip("Test will not run without DEX_TEST_DSN environment variable.")
+		return
+	}
+	dbMap := initDB(dsn)
+	if err := DropMigrationsTable(dbMap); err != nil {
+		t.Fatalf("unable to drop migrations table: %v", err)
+	}
+
+	dbMap, err := NewConnection(Config{DSN: dsn, MaxOpenConnections: 1, MaxIdleConnections: 1})
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		_, err := MigrateToLatest(dbMap)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Minute):
+		t.Fatalf("migrations did not finish")
+	}
+}
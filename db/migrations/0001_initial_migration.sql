@@ -45,3 +45,13 @@ CREATE TABLE IF NOT EXISTS "remote_identity_mapping" (
        "user_id" text,
        "remote_id" text not null,
        primary key ("connector_id", "remote_id")) ;
+
+-- +migrate Down
+DROP TABLE "remote_identity_mapping";
+DROP TABLE "session_key";
+DROP TABLE "session";
+DROP TABLE "password_info";
+DROP TABLE "key";
+DROP TABLE "connector_config";
+DROP TABLE "client_identity";
+DROP TABLE "authd_user";
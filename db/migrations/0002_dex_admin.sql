@@ -2,3 +2,6 @@
 ALTER TABLE client_identity ADD COLUMN "dex_admin" boolean;
 
 UPDATE "client_identity" SET "dex_admin" = false;
+
+-- +migrate Down
+ALTER TABLE client_identity DROP COLUMN "dex_admin";
@@ -2,3 +2,6 @@
 ALTER TABLE authd_user ADD COLUMN "created_at" bigint;
 
 UPDATE authd_user SET "created_at" = 0;
+
+-- +migrate Down
+ALTER TABLE authd_user DROP COLUMN "created_at";
@@ -1,2 +1,5 @@
 -- +migrate Up
 ALTER TABLE session ADD COLUMN "nonce" text;
+
+-- +migrate Down
+ALTER TABLE session DROP COLUMN "nonce";
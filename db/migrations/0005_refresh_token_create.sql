@@ -19,3 +19,6 @@ ALTER TABLE ONLY refresh_token ALTER COLUMN id SET DEFAULT nextval('refresh_toke
 
 ALTER TABLE ONLY refresh_token
     ADD CONSTRAINT refresh_token_pkey PRIMARY KEY (id);
+
+-- +migrate Down
+DROP TABLE refresh_token;
@@ -1,3 +1,7 @@
 -- +migrate Up
 ALTER TABLE ONLY authd_user
     ADD CONSTRAINT authd_user_email_key UNIQUE (email);
+
+-- +migrate Down
+ALTER TABLE ONLY authd_user
+    DROP CONSTRAINT authd_user_email_key;
@@ -1,2 +1,5 @@
 -- +migrate Up
 ALTER TABLE session ADD COLUMN "scope" text;
+
+-- +migrate Down
+ALTER TABLE session DROP COLUMN "scope";
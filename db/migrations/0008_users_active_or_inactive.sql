@@ -2,3 +2,6 @@
 ALTER TABLE authd_user ADD COLUMN disabled boolean;
 
 UPDATE authd_user SET "disabled" = FALSE;
+
+-- +migrate Down
+ALTER TABLE authd_user DROP COLUMN disabled;
@@ -3,3 +3,7 @@ ALTER TABLE key ADD COLUMN tmp_value bytea;
 UPDATE KEY SET tmp_value = value;
 ALTER TABLE key DROP COLUMN value;
 ALTER TABLE key RENAME COLUMN "tmp_value" to "value";
+
+-- +migrate Down
+-- The primary key is not restored: encrypted key sets outgrow the size of
+-- a btree index row, which is why it was dropped.
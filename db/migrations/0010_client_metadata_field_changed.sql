@@ -7,3 +7,6 @@ SET metadata = text(
     )
  )
 WHERE (json(metadata)->>'redirect_uris') IS NULL;
+
+-- +migrate Down
+-- The metadata keeps its redirectURLs, which is all older versions read.
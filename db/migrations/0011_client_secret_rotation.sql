@@ -0,0 +1,26 @@
+-- +migrate Up
+CREATE TABLE client_identity_secret (
+    id bigserial NOT NULL PRIMARY KEY,
+    client_id text NOT NULL,
+    secret bytea,
+    created_at bigint,
+    expires_at bigint
+);
+
+CREATE INDEX client_identity_secret_client_id_idx ON client_identity_secret (client_id);
+
+INSERT INTO client_identity_secret (client_id, secret, created_at, expires_at)
+    SELECT id, secret, 0, 0 FROM client_identity;
+
+ALTER TABLE client_identity DROP COLUMN secret;
+
+-- +migrate Down
+ALTER TABLE client_identity ADD COLUMN secret bytea;
+
+UPDATE client_identity SET secret = (
+    SELECT s.secret FROM client_identity_secret s
+    WHERE s.client_id = client_identity.id
+    ORDER BY s.id DESC LIMIT 1
+);
+
+DROP TABLE client_identity_secret;
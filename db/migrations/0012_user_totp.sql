@@ -0,0 +1,12 @@
+-- +migrate Up
+CREATE TABLE user_totp (
+    user_id text NOT NULL PRIMARY KEY,
+    secret bytea,
+    confirmed boolean,
+    recovery_codes text,
+    last_counter bigint,
+    created_at bigint
+);
+
+-- +migrate Down
+DROP TABLE user_totp;
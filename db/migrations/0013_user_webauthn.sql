@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE TABLE user_webauthn_credential (
+    id text NOT NULL PRIMARY KEY,
+    user_id text NOT NULL,
+    public_key bytea,
+    sign_count bigint,
+    created_at bigint
+);
+
+CREATE INDEX user_webauthn_credential_user_id ON user_webauthn_credential (user_id);
+
+-- +migrate Down
+DROP TABLE user_webauthn_credential;
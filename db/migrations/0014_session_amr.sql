@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE session ADD COLUMN "amr" text;
+
+-- +migrate Down
+ALTER TABLE session DROP COLUMN "amr";
@@ -0,0 +1,10 @@
+-- +migrate Up
+CREATE TABLE login_attempt (
+    id text NOT NULL PRIMARY KEY,
+    failures integer,
+    last_failure bigint,
+    locked_until bigint
+);
+
+-- +migrate Down
+DROP TABLE login_attempt;
@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE password_info ADD COLUMN "history" text;
+
+UPDATE password_info SET "history" = '';
+
+-- +migrate Down
+ALTER TABLE password_info DROP COLUMN "history";
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE session ADD COLUMN "link_token" text;
+
+-- +migrate Down
+ALTER TABLE session DROP COLUMN "link_token";
@@ -0,0 +1,13 @@
+-- +migrate Up
+CREATE INDEX authd_user_lower_email_idx ON authd_user (lower(email) text_pattern_ops);
+
+CREATE INDEX authd_user_created_at_idx ON authd_user (created_at);
+
+CREATE INDEX remote_identity_mapping_user_id_idx ON remote_identity_mapping (user_id);
+
+-- +migrate Down
+DROP INDEX remote_identity_mapping_user_id_idx;
+
+DROP INDEX authd_user_created_at_idx;
+
+DROP INDEX authd_user_lower_email_idx;
@@ -0,0 +1,19 @@
+-- +migrate Up
+CREATE TABLE user_group (
+    id text NOT NULL PRIMARY KEY,
+    display_name text NOT NULL UNIQUE,
+    created_at bigint
+);
+
+CREATE TABLE user_group_member (
+    group_id text NOT NULL,
+    user_id text NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX user_group_member_user_id_idx ON user_group_member (user_id);
+
+-- +migrate Down
+DROP TABLE user_group_member;
+
+DROP TABLE user_group;
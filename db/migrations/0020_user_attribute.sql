@@ -0,0 +1,16 @@
+-- +migrate Up
+CREATE TABLE user_attribute (
+    user_id text NOT NULL,
+    name text NOT NULL,
+    value text NOT NULL,
+    PRIMARY KEY (user_id, name)
+);
+
+ALTER TABLE client_identity ADD COLUMN "claim_mapping" text;
+
+UPDATE "client_identity" SET "claim_mapping" = '';
+
+-- +migrate Down
+ALTER TABLE client_identity DROP COLUMN "claim_mapping";
+
+DROP TABLE user_attribute;
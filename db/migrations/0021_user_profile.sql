@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE authd_user ADD COLUMN "given_name" text;
+ALTER TABLE authd_user ADD COLUMN "family_name" text;
+ALTER TABLE authd_user ADD COLUMN "picture" text;
+ALTER TABLE authd_user ADD COLUMN "locale" text;
+
+UPDATE authd_user SET "given_name" = '', "family_name" = '', "picture" = '', "locale" = '';
+
+-- +migrate Down
+ALTER TABLE authd_user DROP COLUMN "locale";
+ALTER TABLE authd_user DROP COLUMN "picture";
+ALTER TABLE authd_user DROP COLUMN "family_name";
+ALTER TABLE authd_user DROP COLUMN "given_name";
@@ -0,0 +1,18 @@
+-- +migrate Up
+CREATE TABLE audit_event (
+    id bigserial NOT NULL PRIMARY KEY,
+    type text NOT NULL,
+    created_at bigint NOT NULL,
+    user_id text,
+    client_id text,
+    connector_id text,
+    remote_addr text,
+    details text
+);
+
+CREATE INDEX audit_event_created_at_idx ON audit_event (created_at);
+CREATE INDEX audit_event_user_id_idx ON audit_event (user_id);
+CREATE INDEX audit_event_client_id_idx ON audit_event (client_id);
+
+-- +migrate Down
+DROP TABLE audit_event;
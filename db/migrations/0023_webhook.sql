@@ -0,0 +1,26 @@
+-- +migrate Up
+CREATE TABLE webhook_subscription (
+    id text NOT NULL PRIMARY KEY,
+    url text NOT NULL,
+    secret bytea NOT NULL,
+    events text NOT NULL,
+    created_at bigint NOT NULL
+);
+
+CREATE TABLE webhook_delivery (
+    id bigserial NOT NULL PRIMARY KEY,
+    subscription_id text NOT NULL,
+    event_id text NOT NULL,
+    event_type text NOT NULL,
+    payload text NOT NULL,
+    attempts integer NOT NULL,
+    next_attempt bigint NOT NULL,
+    last_error text
+);
+
+CREATE INDEX webhook_delivery_next_attempt_idx ON webhook_delivery (next_attempt);
+
+-- +migrate Down
+DROP TABLE webhook_delivery;
+
+DROP TABLE webhook_subscription;
@@ -10,6 +10,21 @@
 // 0008_users_active_or_inactive.sql
 // 0009_key_not_primary_key.sql
 // 0010_client_metadata_field_changed.sql
+// 0011_client_secret_rotation.sql
+// 0012_user_totp.sql
+// 0013_user_webauthn.sql
+// 0014_session_amr.sql
+// 0015_login_attempt.sql
+// 0016_password_history.sql
+// 0017_session_link_token.sql
+// 0018_user_search_indexes.sql
+// 0019_user_group.sql
+// 0020_user_attribute.sql
+// 0021_user_profile.sql
+// 0022_audit_event.sql
+// 0023_webhook.sql
+// mysql/0001_initial.sql
+// sqlite3/0001_initial.sql
 // DO NOT EDIT!
 
 package migrations
@@ -77,7 +92,7 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _dbMigrations0001_initial_migrationSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x9c\x93\xcf\x8a\xdb\x30\x10\xc6\xef\x79\x0a\x91\xd3\x86\x66\x9f\xa0\xa7\x6d\x71\x61\xa1\xb4\xd0\x75\xa1\x37\x31\xb5\x26\xee\x50\xfd\x43\x1a\x6f\xd7\x6f\x5f\xd9\x4e\x14\xc7\xce\xc6\x26\x81\x84\x58\xd6\xfc\xe6\x9b\x6f\x66\x1e\x1f\xc5\x07\x43\x75\x00\x46\xf1\xd3\x6f\x3e\xff\x28\x9e\xca\x42\x94\x4f\x9f\xbe\x16\xe2\xf9\x8b\xf8\xf6\xbd\x14\xc5\xaf\xe7\x97\xf2\x45\x6c\xa1\xe1\x3f\x4a\x36\x11\xc3\x56\x3c\x6c\xc4\xf0\xd9\x92\xda\x0a\xc6\x37\x16\xd6\xa5\x6f\xa3\xb5\xf0\x81\x0c\x84\x56\xfc\xc5\x76\x9f\xaf\xa1\x01\xd2\xc3\xcd\xc9\xa1\x7c\xc5\x40\x07\xc2\xc4\xf9\xed\x9c\x46\xb0\xe7\x0b\x8a\xa2\xd7\xd0\x4a\x0b\x06\xa7\xc1\xa0\x0c\xd9\x1c\xb3\x13\x1f\x37\x37\xd5\x57\x9a\xd0\xb2\x24\x95\x7e\x89\xdb\x3b\x4a\x88\x58\x05\xe4\x94\xb1\x65\x84\xf3\xb1\x41\x06\x05\x0c\x03\x63\xb7\x24\xc3\x59\x8b\x15\xbb\x20\xd3\xbf\x03\xd5\x77\xe8\xe0\xd6\x9f\xcc\xe8\x79\x3d\xa5\xcf\xbd\xe4\x41\xc2\x8c\xf3\xbd\x82\x6e\xf0\x58\xcf\xd5\x9c\x8b\x40\x0f\x31\xfe\x73\x41\x49\xb2\x07\x37\x46\x77\x53\x22\x57\xd6\x73\x82\x4c\x1b\x9c\xe1\xf8\xe6\x29\x60\x4c\x4a\xa9\x26\xbb\x5c\x66\xc4\x18\xc9\xd9\x7b\x5a\xcc\x69\x0f\xa6\x3a\x52\xd7\xd3\xa9\x92\xc0\x27\x05\xa3\x09\x1e\xa4\x5d\x7d\x97\x27\x6e\x06\x1c\x5e\x5c\x4d\x16\x50\x25\x60\xc5\xb2\x09\x3a\x37\xf9\x3c\xb4\x13\x50\x1e\xa6\x79\x92\x8b\x0e\xec\x3b\x70\x4d\x91\xbb\xdd\x5d\xbb\x31\x47\x1b\xe5\x64\x6a\xfa\xc7\x55\xeb\x32\x84\xcf\xa5\xdd\x32\x2d\x99\xa2\x71\xbd\xc6\x80\xc6\x31\xe6\xad\x96\x06\xbc\x27\x7b\xb1\x55\x73\x93\xb2\xf0\xf7\xdc\x1a\x75\xe3\x88\x7f\x2f\x70\x54\xb9\x78\xb8\x4c\xb5\x1f\x87\xef\xba\x4a\xfe\x07\x00\x00\xff\xff\xae\xbe\x65\x61\x6c\x05\x00\x00")
+var _dbMigrations0001_initial_migrationSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x9c\x55\x4d\x6f\xe2\x30\x10\xbd\xe7\x57\x8c\x72\x2a\x5a\xfa\x0b\x38\x75\xb7\xac\x54\x69\xb5\x5d\xb5\xac\xb4\x37\x6b\x88\x07\x76\x54\xc7\x8e\xec\x49\xdb\xfc\xfb\xaa\x24\x98\xd8\x81\x82\xe0\x86\x9f\xe7\xcd\x7b\xf3\xe1\xdc\xde\xc2\xb7\x9a\xb7\x1e\x85\xe0\x6f\x53\xfc\x78\x5a\xde\xad\x96\xb0\xba\xfb\xfe\x6b\x09\x0f\x3f\xe1\xf7\xe3\x0a\x96\xff\x1e\x9e\x57\xcf\x50\x62\x2b\xff\xb5\x6a\x03\xf9\x12\x6e\x0a\xe8\x7f\x25\xeb\x12\x84\xde\x05\xac\x13\xb0\xad\x31\xd0\x78\xae\xd1\x77\xf0\x42\xdd\x3c\x5e\xa3\x1a\xd9\xf4\x37\xb3\x43\xf5\x4a\x9e\x37\x4c\xba\x84\xb5\x73\x86\xd0\x1e\x2e\x68\x0e\x8d\xc1\x4e\x59\xac\x29\x0f\x46\x5d\xb3\x8d\x31\x33\x58\x14\x5f\xaa\xaf\x0c\x93\x15\xc5\x9a\xac\xb0\x74\x57\x58\x08\x54\x79\x92\x12\xd6\x9d\x10\x1e\x8e\x6b\x12\xd4\x28\xd8\x73\xcc\xce\xc9\x70\xd6\x52\x25\xce\xab\xca\xd9\x0d\x6f\xaf\xd0\x21\x5d\xb3\x2f\x06\x94\x7b\x96\x5d\xee\x73\x35\x78\xa1\xc4\xf7\x2b\x9a\x96\x06\x3f\x47\x73\x9e\x25\x6c\x30\x84\x37\xe7\xb5\x62\xbb\x71\x63\xea\xcf\x29\x51\x17\xfa\xd9\x93\xe4\x0d\x8e\xe4\xf4\xde\xb0\xa7\x50\xc2\x9a\xb7\x6c\xcf\xdb\x0c\x14\x02\x3b\x7b\x4d\x8b\x05\x65\x32\x68\x95\x27\x14\xd2\x0a\x65\xaf\xe0\x80\x0d\xd2\x8e\x62\x71\xe2\x26\x84\x3d\x70\x34\x99\x27\xcd\x9e\x2a\x51\xad\x37\xb1\xc9\x87\xa1\xcd\x88\xe2\x30\x4d\x93\x24\x1d\x98\x7f\x12\x6f\x39\x08\xf9\xcb\x37\x66\x28\xa3\xca\xa6\x66\xf7\xf7\xa2\x75\xe9\xc3\xa7\xd2\xbe\x2a\x5a\x10\x34\x74\xb9\x46\x4f\xb5\x13\x8a\x5b\xad\x6a\x6c\x1a\xb6\xc9\x56\x4d\x8b\x14\x85\x9f\xaa\xd6\xa8\x1b\x03\xfd\xa9\xc0\x91\x73\xb8\x49\x53\xcd\xc7\xe1\xb3\x9d\x93\xf1\x63\x7b\xef\xde\x6c\x71\xff\xf4\xf8\x67\x30\x76\xd2\xca\x22\xb9\x35\x6e\xca\x51\x24\x3b\x4d\x37\x34\xc5\xa6\x1c\x93\xd7\x29\x83\xb3\x37\x34\x45\x47\xdf\x87\x45\xf1\x31\x00\x6e\x6e\x46\xe0\x58\x06\x00\x00")
 
 func dbMigrations0001_initial_migrationSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -92,12 +107,12 @@ func dbMigrations0001_initial_migrationSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0001_initial_migration.sql", size: 1388, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0001_initial_migration.sql", size: 1624, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0002_dex_adminSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\xce\xc9\x4c\xcd\x2b\x89\xcf\x4c\x01\x92\x99\x25\x95\x0a\x8e\x2e\x2e\x0a\xce\xfe\x3e\xa1\xbe\x7e\x0a\x4a\x29\xa9\x15\xf1\x89\x29\xb9\x99\x79\x4a\x0a\x49\xf9\xf9\x39\xa9\x89\x79\xd6\x5c\x5c\xa1\x01\x2e\x8e\x21\xae\x0a\x4a\x68\x1a\x95\x14\x82\x5d\x43\x50\xb4\xd8\x2a\xa4\x25\xe6\x14\xa7\x5a\x73\x01\x02\x00\x00\xff\xff\x89\xb3\xb1\x5d\x7e\x00\x00\x00")
+var _dbMigrations0002_dex_adminSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x7c\xce\x41\x0a\x02\x21\x14\x87\xf1\xfd\x3b\xc5\x1f\xb7\x31\x27\x90\x16\x96\xee\xac\x19\x26\x5d\x0f\x96\xaf\x10\x1c\x8d\x12\xaa\xdb\xb7\xad\x88\x39\xc0\xc7\xf7\xeb\x3a\xac\xe6\x74\xb9\x85\xc6\xf0\x57\x52\xd6\x99\x11\x4e\x6d\xac\xc1\x29\x27\x2e\x6d\x4a\x91\x4b\x4b\xed\x05\xa5\x35\xb6\xbd\xf5\xbb\x3d\x44\xe4\xe7\x14\xe2\x9c\x8a\xc0\xb1\xd6\xcc\xa1\x48\x22\x3f\x68\xe5\x0c\xc4\x4f\x28\x70\x30\xee\x2b\x59\xe3\x1c\xf2\x9d\x25\xd1\xe7\x5e\xd7\x47\x59\x04\xe8\xb1\x1f\xfe\x08\x24\xbd\x07\x00\xed\x31\x18\x18\xc5\x00\x00\x00")
 
 func dbMigrations0002_dex_adminSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -112,12 +127,12 @@ func dbMigrations0002_dex_adminSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0002_dex_admin.sql", size: 126, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0002_dex_admin.sql", size: 197, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0003_user_created_atSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x4a\x2e\x4a\x05\x2a\x4e\x89\x4f\x2c\x51\x52\x48\xca\x4c\xcf\xcc\x2b\xb1\xe6\xe2\x0a\x0d\x70\x71\x0c\x41\xd1\x12\xec\x1a\x82\xaa\xd6\x56\xc1\xc0\x9a\x0b\x10\x00\x00\xff\xff\x28\xce\xe1\xe1\x6f\x00\x00\x00")
+var _dbMigrations0003_user_created_atSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x4a\x2e\x4a\x4d\x2c\x49\x4d\x89\x4f\x2c\x51\x52\x48\xca\x4c\xcf\xcc\x2b\xb1\xe6\xe2\x0a\x0d\x70\x71\x0c\x41\xd1\x12\xec\x1a\x82\xaa\xd6\x56\xc1\xc0\x9a\x8b\x0b\xd9\x3e\x97\xfc\xf2\x3c\x5c\x36\xba\x04\xf9\x07\x60\xb3\xd2\x9a\x0b\x30\x00\xa6\x75\xe0\x79\xb2\x00\x00\x00")
 
 func dbMigrations0003_user_created_atSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -132,12 +147,12 @@ func dbMigrations0003_user_created_atSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0003_user_created_at.sql", size: 111, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0003_user_created_at.sql", size: 178, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0004_session_nonceSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\xcb\xcf\x4b\x4e\x55\x52\x28\x49\xad\x28\xb1\xe6\x02\x04\x00\x00\xff\xff\x77\x11\x16\x8b\x3c\x00\x00\x00")
+var _dbMigrations0004_session_nonceSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\xcb\xcf\x4b\x4e\x55\x52\x28\x49\xad\x28\xb1\xe6\xe2\x42\xd6\xec\x92\x5f\x9e\x87\x55\xbb\x4b\x90\x7f\x00\x9a\x7e\x6b\x2e\xc0\x00\xc8\x33\x19\xa3\x77\x00\x00\x00")
 
 func dbMigrations0004_session_nonceSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -152,12 +167,12 @@ func dbMigrations0004_session_nonceSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0004_session_nonce.sql", size: 60, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0004_session_nonce.sql", size: 119, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0005_refresh_token_createSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x84\x90\x51\x4f\xc2\x30\x10\xc7\xdf\xf7\x29\xee\x0d\x88\x62\xc2\x2b\x3e\x95\xed\x0c\x8b\x5d\xa7\x5d\x2b\xf2\xb4\x14\x56\xa1\x61\x8e\xb9\x56\xe3\xbe\xbd\x65\x13\x0c\xc4\x68\xdf\xfa\xbb\xfb\xdf\x2f\x77\xe3\x31\x5c\xbd\x9a\x4d\xa3\x9c\x06\x59\x07\x21\x47\x22\x10\x04\x99\x51\x84\x46\xbf\x34\xda\x6e\x73\xb7\xdf\xe9\x0a\x86\x01\xf8\x67\x0a\x58\x99\x8d\xa9\x1c\xb0\x54\x00\x93\x94\x5e\x77\xbc\x56\x6d\xb9\x57\x45\xbe\x55\x76\x0b\xab\xd6\x69\xd5\xf3\x77\xab\x9b\xdc\x87\x9c\xfe\x74\x3d\x59\x97\x46\x57\xee\xc8\x82\xd1\x6d\x70\xb4\x66\xf8\x28\x91\x85\x17\x62\xdf\x99\x5b\xfd\xd6\x65\x33\x41\xb8\x80\x45\x2c\xe6\x30\xe9\x40\xcc\x7c\x36\x41\x26\x60\xb6\xfc\x46\x2c\x85\x24\x66\x4f\x84\x4a\x3c\xfd\xc9\xf3\xcf\x3f\x24\xe1\x1c\x61\xe2\xb5\x84\x0a\xe4\x7f\x5b\x21\x5d\x30\x8c\x0e\xc3\xcf\xaa\x37\xa6\x38\xe5\xfb\x5b\xa5\x8c\x5e\xf4\x40\x5f\x0e\x53\x2a\x13\x76\xb8\x5b\x86\x02\x22\xbc\x23\x92\x0a\xa8\xfc\xea\x1f\xaa\x1c\x0e\x7e\x93\x0e\xa6\xd3\x46\x6f\xd6\xa5\xb2\x76\xf4\xaf\xa6\xdb\x89\x44\x91\x17\xb1\x4c\x70\x12\xfb\x5b\x9c\x0f\xad\x77\xba\x85\x07\x1e\x27\x84\x2f\xe1\x1e\x97\x30\x34\x85\x9f\xfb\x15\x00\x00\xff\xff\xc4\xcc\xa8\xfd\xfa\x01\x00\x00")
+var _dbMigrations0005_refresh_token_createSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x84\x91\x4f\x4f\xf2\x40\x10\x87\xef\xfb\x29\x7e\x37\x20\xef\x8b\x09\x57\x38\x2d\xed\x18\x1a\xb7\x5b\xdc\x6e\x45\x4e\x4d\xa1\x2b\x6c\xa8\x05\xdb\x55\xe1\xdb\x1b\x5a\x41\x25\x44\x8f\xf3\xcc\x9f\x27\x33\xd3\xef\xe3\xdf\xb3\x5d\x55\x99\x33\x48\x76\xcc\x53\xc4\x35\x41\xf3\xb1\x20\x54\xe6\xa9\x32\xf5\x3a\x75\xdb\x8d\x29\xd1\x65\x00\x60\x73\x2c\xec\xca\x96\x0e\x32\xd2\x90\x89\x10\xff\x1b\xbe\xcb\x0e\xc5\x36\xcb\xd3\x75\x56\xaf\xb1\x38\x38\x93\xb5\xfc\xb5\x36\x55\x6a\x73\x38\xb3\x77\x2d\x59\x16\xd6\x94\xee\xc4\x58\x6f\xc4\x4e\xd6\x98\xee\x13\x92\xde\x85\x38\xb5\x79\x5a\x9b\x97\xa6\x37\xd6\x5c\x69\xcc\x02\x3d\xc1\xa0\x01\x81\xf4\x14\x85\x24\x35\xc6\xf3\x4f\x24\x23\x84\x81\x7c\xe0\x22\xa1\x73\xcc\x1f\xbf\x62\x8f\x7b\x13\xc2\x60\xc4\x18\x17\x9a\xd4\xef\x56\x44\x33\x49\xfe\x71\xf8\x8f\xec\x8d\xcd\xcf\xfd\xed\xad\x22\x29\x2e\x6a\xd0\xa6\xbd\x48\x24\xa1\x3c\xde\x2d\x26\x0d\x9f\x6e\x79\x22\x34\x4a\xb3\x77\x6f\x59\xd1\xed\x5c\x93\x76\x86\xc3\xca\xac\x96\x45\x56\xd7\xbd\x3f\x35\xcd\x4e\xdc\xf7\xe1\x45\x32\xd6\x8a\x07\x52\x5f\x6c\xb2\xdb\x98\x03\xa6\x2a\x08\xb9\x9a\xe3\x8e\xe6\xe8\xda\xfc\x38\xf7\xfb\xeb\xfd\xed\x7b\xc9\x7c\x15\x4d\xaf\xbd\x7e\xc4\x3e\x06\x00\x0d\xea\xc3\x01\x26\x02\x00\x00")
 
 func dbMigrations0005_refresh_token_createSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -172,12 +187,12 @@ func dbMigrations0005_refresh_token_createSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0005_refresh_token_create.sql", size: 506, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0005_refresh_token_create.sql", size: 550, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0006_user_email_uniqueSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xf0\xf7\xf3\x89\x54\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\xe2\x52\x00\x02\x47\x17\x17\x05\x67\x7f\xbf\xe0\x90\x20\x47\x4f\xbf\x10\x24\xd9\xf8\xd4\xdc\xc4\xcc\x9c\xf8\xec\xd4\x4a\x85\x50\x3f\xcf\xc0\x50\x57\x05\x0d\xb0\x88\xa6\x35\x17\x20\x00\x00\xff\xff\x18\x48\x0d\x30\x63\x00\x00\x00")
+var _dbMigrations0006_user_email_uniqueSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xf0\xf7\xf3\x89\x54\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\xe2\x52\x50\x50\x50\x70\x74\x71\x51\x70\xf6\xf7\x0b\x0e\x09\x72\xf4\xf4\x0b\x41\x92\x8d\x4f\xcd\x4d\xcc\xcc\x89\xcf\x4e\xad\x54\x08\xf5\xf3\x0c\x0c\x75\x55\xd0\x00\x8b\x68\x5a\x73\x71\x21\xdb\xe1\x92\x5f\x9e\x47\xd0\x16\x97\x20\xff\x00\x42\xd6\x58\x73\x01\x06\x00\xba\x82\xb3\x9e\xbb\x00\x00\x00")
 
 func dbMigrations0006_user_email_uniqueSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -192,12 +207,12 @@ func dbMigrations0006_user_email_uniqueSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0006_user_email_unique.sql", size: 99, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0006_user_email_unique.sql", size: 187, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0007_session_scopeSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x2a\x4e\xce\x2f\x48\x55\x52\x28\x49\xad\x28\xb1\xe6\x02\x04\x00\x00\xff\xff\x79\x7f\x20\x70\x3c\x00\x00\x00")
+var _dbMigrations0007_session_scopeSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x2a\x4e\xce\x2f\x48\x55\x52\x28\x49\xad\x28\xb1\xe6\xe2\x42\xd6\xec\x92\x5f\x9e\x87\x55\xbb\x4b\x90\x7f\x00\x9a\x7e\x6b\x2e\xc0\x00\xdc\x19\xe3\xfd\x77\x00\x00\x00")
 
 func dbMigrations0007_session_scopeSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -212,12 +227,12 @@ func dbMigrations0007_session_scopeSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0007_session_scope.sql", size: 60, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0007_session_scope.sql", size: 119, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0008_users_active_or_inactiveSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xc9\x2c\x4e\x4c\xca\x49\x4d\x51\x48\xca\xcf\xcf\x49\x4d\xcc\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x41\x51\x1f\xec\x1a\xa2\xa0\x04\x53\xa9\xa4\x60\xab\xe0\xe6\xe8\x13\xec\x6a\xcd\x05\x08\x00\x00\xff\xff\x06\x75\x98\x47\x6e\x00\x00\x00")
+var _dbMigrations0008_users_active_or_inactiveSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x48\xc9\x2c\x4e\x4c\xca\x49\x4d\x51\x48\xca\xcf\xcf\x49\x4d\xcc\xb3\xe6\xe2\x0a\x0d\x70\x71\x0c\x41\x51\x1f\xec\x1a\xa2\xa0\x04\x53\xa9\xa4\x60\xab\xe0\xe6\xe8\x13\xec\x6a\xcd\xc5\x85\x6c\x9d\x4b\x7e\x79\x1e\x2e\x0b\x5d\x82\xfc\x03\xd0\x6d\xb4\xe6\x02\x0c\x00\xcb\x96\xde\x69\xad\x00\x00\x00")
 
 func dbMigrations0008_users_active_or_inactiveSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -232,12 +247,12 @@ func dbMigrations0008_users_active_or_inactiveSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0008_users_active_or_inactive.sql", size: 110, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0008_users_active_or_inactive.sql", size: 173, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0009_key_not_primary_keySql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\xc8\x4e\xad\x54\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x28\xc9\x2d\x88\x2f\x4b\xcc\x29\x4d\x55\x48\xaa\x2c\x49\x4d\xb4\xe6\x0a\x0d\x70\x71\x0c\x71\x55\xf0\x76\x8d\x54\x08\x76\x0d\x41\x92\xb7\x55\x00\xd3\xd6\x18\xa6\xb9\x04\xf9\x07\xc0\x8c\xc3\xa1\x24\xc8\xd5\xcf\xd1\xd7\x15\xa6\x48\x09\x6e\xa8\x92\x42\x49\xbe\x82\x12\x84\x69\xcd\x05\x08\x00\x00\xff\xff\x58\x6f\x17\x19\xb6\x00\x00\x00")
+var _dbMigrations0009_key_not_primary_keySql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x74\xce\x4d\x4e\xc3\x30\x10\x05\xe0\x7d\x4e\xf1\x94\x2d\x94\x03\x10\xb1\x08\xc4\x2b\xd2\x1f\x85\x64\xc1\x0a\xb9\xf5\x50\x5b\x90\xd8\x1a\x4f\x31\xe6\xf4\x28\x41\x45\x95\x10\xab\x19\xcd\x7b\xfa\x34\xab\x15\xae\x46\x77\x64\x2d\x84\x21\x14\x75\xdb\xab\x0e\x7d\x7d\xdf\x2a\xbc\x51\x46\xdd\x34\x78\xd8\xb6\xc3\x7a\x03\x19\xc3\xcb\x87\x7e\x3f\x11\xf6\x59\x48\x57\xc5\xb0\x6b\xea\x5e\xe1\x51\x3d\xe3\x49\xf5\x17\xf9\x1d\x96\x59\xfd\xd1\x9a\x6e\xbb\x3b\x73\xff\x54\x3a\xb5\xa9\xd7\xea\x5c\x2a\x7f\xd1\x12\xe2\x51\xfe\xac\x55\x51\x5c\xbe\xdd\xf8\x34\xcd\x87\xde\x12\x02\xbb\x51\x73\x5e\x28\x17\x31\x79\x01\x53\x14\xcf\x64\x6e\x41\xd3\x81\x73\x10\x32\x4b\x1c\x49\x22\xfc\x49\x8e\xec\x13\xc4\x12\xa2\xfb\x22\xf8\xd7\x99\xd2\xd8\x0b\x13\xc1\x4d\x86\x3e\xc1\x3e\x5d\x23\x59\x77\xb0\xb3\x99\x6c\x86\x13\x24\x1d\x61\xd8\x87\x40\xe6\xa6\xf8\x1e\x00\xa6\x2e\xd6\x76\x46\x01\x00\x00")
 
 func dbMigrations0009_key_not_primary_keySqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -252,12 +267,12 @@ func dbMigrations0009_key_not_primary_keySql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0009_key_not_primary_key.sql", size: 182, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0009_key_not_primary_key.sql", size: 326, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
 
-var _dbMigrations0010_client_metadata_field_changedSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x0a\x0d\x70\x71\x0c\x71\x55\x48\xce\xc9\x4c\xcd\x2b\x89\xcf\x4c\x01\x92\x99\x25\x95\x5c\xc1\xae\x21\x0a\xb9\xa9\x25\x89\x29\x89\x25\x89\x0a\xb6\x0a\x25\xa9\x15\x25\x1a\x5c\x0a\x40\x90\x55\x9c\x9f\x17\x9f\x54\x9a\x99\x93\x12\x9f\x9f\x94\x95\x9a\x0c\x15\x06\x01\xf5\xa2\xd4\x94\xcc\x22\xa0\x50\x68\x90\x4f\xb1\xba\x0e\x58\xa9\x06\x98\x80\x99\xa4\xa9\x6b\x67\x87\xaa\x4a\x53\x07\x53\x7b\x7c\x69\x51\x26\xd1\xfa\xc1\xda\x81\xa4\x26\x57\xb8\x87\x6b\x90\xab\x02\x1e\x0d\x10\x73\x35\x15\x3c\x83\x15\xfc\x42\x7d\x7c\xac\xb9\x00\x01\x00\x00\xff\xff\xeb\xe6\x9a\x19\x0b\x01\x00\x00")
+var _dbMigrations0010_client_metadata_field_changedSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x8c\x90\xc1\x4a\xc3\x40\x10\x86\xef\xf3\x14\xff\xad\x5d\x4c\x7c\x01\xb1\x20\x34\xa0\x10\x44\xda\x04\x8f\x61\x9b\x1d\xcc\xd4\xed\x6e\xd9\x9d\x5a\x7d\x7b\x49\x54\x8c\x08\xe2\x1e\xf6\xf0\xf3\x7d\xff\x30\x53\x96\xb8\x38\xc8\x53\xb2\xca\x68\x8f\xd4\x3e\xac\x6f\x9a\x0a\xbd\x17\x0e\xda\x89\xe3\xa0\xa2\x6f\xb4\xad\x1a\x1c\x58\xad\xb3\x6a\x71\x0d\xe5\x57\x5d\x12\x00\xec\x73\x0c\xdd\xee\x24\xde\x75\x71\xb7\xe7\xfe\x33\x1e\xdf\x22\xb1\x93\xc4\xbd\xb6\x9b\x3a\x2f\x8a\x09\x5d\x4e\xdf\x57\x93\x29\x57\xab\x9f\x94\x29\x7e\xeb\xdd\x29\xc9\xbf\xfd\x49\x37\x04\x43\x8f\xb7\xd5\xa6\xc2\x1f\xc2\x47\xaf\xc1\xdd\x16\xf7\x6d\x5d\x5f\x11\xcd\x6f\xb1\x8e\xe7\x30\x06\xcd\xc0\xdf\x9b\x3f\x33\x1f\x33\x44\x33\xe6\x53\x0b\x9c\x07\xe9\x07\x48\x86\xf5\x1e\xd1\x3b\x4e\x78\xe1\x94\x25\x86\x91\xb4\xee\x92\xde\x07\x00\x8e\x2f\x6c\x88\x67\x01\x00\x00")
 
 func dbMigrations0010_client_metadata_field_changedSqlBytes() ([]byte, error) {
 	return bindataRead(
@@ -272,7 +287,307 @@ func dbMigrations0010_client_metadata_field_changedSql() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "db/migrations/0010_client_metadata_field_changed.sql", size: 267, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	info := bindataFileInfo{name: "db/migrations/0010_client_metadata_field_changed.sql", size: 359, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0011_client_secret_rotationSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x84\x52\x41\x8f\xaa\x30\x18\xbc\xf7\x57\xcc\x51\xf3\x90\xf8\xce\xc4\x03\xd2\xef\xe5\x91\x07\xd4\x94\x92\xb7\x9e\x08\x4a\x63\x9a\xb8\xac\xa1\x4d\x56\xff\xfd\x46\x44\x96\x75\xd5\xbd\x0e\x33\xc3\xcc\x37\x9d\xcd\xf0\xeb\xd5\xec\xda\xca\x69\x14\x07\x16\x49\x0a\x15\x41\x85\xcb\x84\xb0\xdd\x1b\xdd\xb8\xd2\xd4\xba\x71\xc6\x9d\x4a\xab\xb7\xad\x76\x98\x30\x00\x30\x35\x36\x66\x67\x75\x6b\xaa\x3d\x32\xa1\x90\x15\x49\x82\x95\x8c\xd3\x50\xae\xf1\x8f\xd6\x5e\x47\x1b\x3c\xe0\xf4\xd1\x0d\xc4\xcb\xc7\xde\x70\x73\x72\xba\xea\xe9\xad\xae\x9c\xae\xcb\xca\x9d\xdd\x4d\xe3\x2e\xb0\x3e\x1e\x4c\xab\xed\x27\xcc\xa6\x01\xbb\x86\x8d\x33\x4e\x2f\x0f\xc2\x96\x03\x5c\x9a\xfa\x08\x91\x3d\x2c\x35\xe0\x67\xe7\x38\xcb\x49\x2a\xc4\x99\x12\x3f\x0b\xbc\xbe\x87\x37\x4a\xef\x8d\x22\x4f\xbb\x0a\x39\x25\x14\x29\x8c\xe9\x73\x0f\x73\xfc\x91\x22\xbd\xfd\x47\xc0\x58\x98\x28\x92\xf7\x77\x00\x97\x62\x85\x48\x24\x45\x9a\xf5\x5e\x01\x63\xe3\x21\xf9\xdb\x7b\xf3\xd4\x21\xe4\xfc\xab\xc1\x65\x83\x80\xb1\x62\xc5\x43\xf5\x5d\x90\x93\xba\x32\x17\x98\x8c\x0b\x59\xbf\xc7\xef\x15\xb9\x1e\xcb\x76\x8a\xff\x7f\x49\x12\xac\x3f\x90\xb0\xb8\x15\xf8\xa6\xee\xa8\x42\x72\x92\x58\xae\x61\x7d\x53\x83\x53\x1e\x21\x89\xd3\x58\xe1\x77\xb7\x7c\x77\x81\x67\x8f\x34\x60\x1f\x03\x00\xe0\x82\xd4\x31\xd9\x02\x00\x00")
+
+func dbMigrations0011_client_secret_rotationSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0011_client_secret_rotationSql,
+		"db/migrations/0011_client_secret_rotation.sql",
+	)
+}
+
+func dbMigrations0011_client_secret_rotationSql() (*asset, error) {
+	bytes, err := dbMigrations0011_client_secret_rotationSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0011_client_secret_rotation.sql", size: 729, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0012_user_totpSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x5c\x8f\xb1\x4e\xc3\x30\x10\x86\x77\x3f\xc5\x3f\x82\xa0\x4f\xd0\x29\x50\x0f\x88\xd0\x56\x56\x3a\x74\xb2\x1c\xfb\xa8\x2c\xb5\xbe\xea\x7c\x01\xf2\xf6\x48\x71\x06\xc4\x78\xdf\x77\xfa\xa5\x6f\xb3\xc1\xd3\x2d\x5f\x24\x28\xe1\x74\x37\xaf\xce\x76\x83\xc5\xd0\xbd\xf4\x16\x53\x25\xf1\xca\x7a\xc7\x83\x01\xd0\xee\x9c\xa0\xf4\xa3\xd8\x1f\x06\xec\x4f\x7d\x8f\xa3\x7b\xfb\xe8\xdc\x19\xef\xf6\xfc\xbc\xbc\x55\x8a\x42\x8a\x71\x56\x0a\x8d\x44\x2e\x9f\x59\x6e\x94\x30\x32\x5f\x29\x94\x86\x85\x22\x7f\x91\xcc\x3e\x72\xa2\xba\xcc\x36\x71\x0d\x55\x7d\xe4\xa9\x28\x09\xc6\x7c\xc9\x65\x15\x51\x28\x28\x25\x1f\x74\xc5\xe6\x71\x6b\xcc\xdf\x86\x1d\x7f\x17\xb3\x73\x87\xe3\xff\x86\xad\xf9\x1d\x00\xa1\x50\xf1\x07\xeb\x00\x00\x00")
+
+func dbMigrations0012_user_totpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0012_user_totpSql,
+		"db/migrations/0012_user_totp.sql",
+	)
+}
+
+func dbMigrations0012_user_totpSql() (*asset, error) {
+	bytes, err := dbMigrations0012_user_totpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0012_user_totp.sql", size: 235, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0013_user_webauthnSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x84\x90\xc1\x4a\xc4\x30\x14\x45\xf7\xf9\x8a\xbb\x54\x74\xbe\x60\x56\xd5\x66\x31\x58\xd3\x21\x74\xc0\x59\x85\x24\x7d\xd4\xe0\x98\x96\xf4\x85\xda\xbf\x17\x5b\x0b\x22\xe8\x6c\xef\x3b\x5c\xce\xbb\xbb\x1d\xee\xde\x43\x97\x2c\x13\x4e\x83\x78\xd4\xb2\x68\x24\x9a\xe2\xa1\x92\xc8\x23\x25\x33\x91\xb3\x99\x5f\xa3\xf1\x89\x5a\x8a\x1c\xec\x05\x37\x02\x00\x42\x0b\xa6\x0f\x86\xaa\x1b\xa8\x53\x55\xe1\xa8\x0f\xcf\x85\x3e\xe3\x49\x9e\xef\x17\x62\x29\xf8\x8d\xad\xa7\x21\xbb\x4b\xf0\xe6\x8d\x66\xb8\x99\xc9\xae\xe9\x18\xba\x68\x7c\x9f\x23\xc3\x85\x2e\x44\x5e\x63\x9f\xc8\x32\xb5\xc6\x6e\xb1\xb8\xdd\x8b\xcd\xf5\xa0\x4a\xf9\xf2\xa7\xab\xd9\x1c\x6a\xf5\xcf\x3f\xdf\xd0\x57\xeb\xcf\x41\xca\x7e\x8a\xa2\xd4\xf5\xf1\xca\x20\x7b\xf1\x39\x00\x99\xee\xca\x99\x47\x01\x00\x00")
+
+func dbMigrations0013_user_webauthnSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0013_user_webauthnSql,
+		"db/migrations/0013_user_webauthn.sql",
+	)
+}
+
+func dbMigrations0013_user_webauthnSql() (*asset, error) {
+	bytes, err := dbMigrations0013_user_webauthnSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0013_user_webauthn.sql", size: 327, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0014_session_amrSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x4a\xcc\x2d\x52\x52\x28\x49\xad\x28\xb1\xe6\xe2\x42\xd6\xea\x92\x5f\x9e\x87\x55\xb3\x4b\x90\x7f\x00\x8a\x6e\x6b\x2e\xc0\x00\xcd\x85\xf3\x62\x73\x00\x00\x00")
+
+func dbMigrations0014_session_amrSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0014_session_amrSql,
+		"db/migrations/0014_session_amr.sql",
+	)
+}
+
+func dbMigrations0014_session_amrSql() (*asset, error) {
+	bytes, err := dbMigrations0014_session_amrSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0014_session_amr.sql", size: 115, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0015_login_attemptSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x6c\xce\xb1\x0e\x82\x30\x14\x85\xe1\xbd\x4f\x71\x46\x8d\xf2\x04\x4c\x28\x1d\x8c\x08\xa4\x81\x81\x89\x54\xb9\x36\x37\x96\x42\xe0\x12\x7d\x7c\x13\x65\x70\x70\x3d\xdf\x19\xfe\x28\xc2\xae\x67\x37\x59\x21\xd4\xa3\x3a\x1a\x9d\x54\x1a\x55\x72\xc8\x34\xfc\xe0\x38\xb4\x56\x84\xfa\x51\xb0\x51\x00\xc0\x1d\x84\x5e\x82\xbc\xa8\x90\xd7\x59\x86\xd2\x9c\x2e\x89\x69\x70\xd6\xcd\xfe\xf3\xb8\x5b\xf6\xcb\x44\x33\x38\x08\x39\x9a\xbe\xab\xb7\xb3\xb4\x2b\xe1\xca\x8e\x83\xac\x30\xdc\x1e\xd4\xb5\x4b\x10\xf6\x2b\xa8\x6d\xac\xd4\x6f\x58\x3a\x3c\x83\x4a\x4d\x51\xfe\x0b\x8b\xd5\x7b\x00\xf6\xd3\xfb\x80\xc4\x00\x00\x00")
+
+func dbMigrations0015_login_attemptSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0015_login_attemptSql,
+		"db/migrations/0015_login_attempt.sql",
+	)
+}
+
+func dbMigrations0015_login_attemptSql() (*asset, error) {
+	bytes, err := dbMigrations0015_login_attemptSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0015_login_attempt.sql", size: 196, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0016_password_historySql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x48\x2c\x2e\x2e\xcf\x2f\x4a\x89\xcf\xcc\x4b\xcb\x57\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\xc8\x2c\x2e\xc9\x2f\xaa\x54\x52\x28\x49\xad\x28\xb1\xe6\xe2\x0a\x0d\x70\x71\x0c\x41\xd7\x10\xec\x1a\x82\xa4\xd2\x56\x41\x5d\xdd\x9a\x8b\x0b\xd9\x3a\x97\xfc\xf2\x3c\x3c\x16\xba\x04\xf9\x07\x60\xd8\x68\xcd\x05\x18\x00\xf2\x10\xb4\x60\xb1\x00\x00\x00")
+
+func dbMigrations0016_password_historySqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0016_password_historySql,
+		"db/migrations/0016_password_history.sql",
+	)
+}
+
+func dbMigrations0016_password_historySql() (*asset, error) {
+	bytes, err := dbMigrations0016_password_historySqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0016_password_history.sql", size: 177, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0017_session_link_tokenSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x2d\x2e\xce\xcc\xcf\x53\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\xca\xc9\xcc\xcb\x8e\x2f\xc9\xcf\x4e\xcd\x53\x52\x28\x49\xad\x28\xb1\xe6\xe2\x42\x36\xc1\x25\xbf\x3c\x0f\xab\x19\x2e\x41\xfe\x01\xd8\x0c\xb1\xe6\x02\x0c\x00\x82\xfe\x18\xb8\x81\x00\x00\x00")
+
+func dbMigrations0017_session_link_tokenSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0017_session_link_tokenSql,
+		"db/migrations/0017_session_link_token.sql",
+	)
+}
+
+func dbMigrations0017_session_link_tokenSql() (*asset, error) {
+	bytes, err := dbMigrations0017_session_link_tokenSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0017_session_link_token.sql", size: 129, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0018_user_search_indexesSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x8c\x90\xcf\x8a\x83\x30\x10\x87\xef\x79\x8a\x39\x2a\x8b\x4f\xe0\x69\x59\x3d\xec\x45\x17\xd9\x42\x6f\x43\x68\x06\x3b\x60\xfe\x10\x47\xb4\x6f\x5f\x6a\x5b\x2a\x6d\x03\x3d\x7f\x5f\xbe\x30\xbf\xa2\x80\x2f\xcb\x7d\xd4\x42\xb0\x0b\xea\xa7\xab\xbf\xff\x6b\xf8\x6d\xaa\x7a\x0f\x7a\x92\xa3\xc1\x69\xa4\x88\x83\x9f\x29\x22\x59\xcd\x03\xb2\x59\xa0\x6d\x36\x14\xb2\x15\x67\x2b\xce\x41\x68\x11\x0c\x5a\x84\xa2\x43\x1f\xc6\xbc\x54\xc9\xee\x21\x92\x16\x32\xa8\xe5\x5d\xf6\x41\x5f\x1a\x91\xac\x17\x42\x36\xe4\x84\xe5\x84\x56\x87\xc0\xae\xbf\x56\xd9\xdc\x6b\x09\x0d\xb2\x9b\x77\xe9\x6e\x27\xa8\xfc\xec\x54\xd5\xb5\x7f\x9f\x7f\x53\xaa\xed\x83\xe4\x6d\x49\xed\x69\xda\x52\x9d\x07\x00\x7a\x1f\xc9\xb3\x93\x01\x00\x00")
+
+func dbMigrations0018_user_search_indexesSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0018_user_search_indexesSql,
+		"db/migrations/0018_user_search_indexes.sql",
+	)
+}
+
+func dbMigrations0018_user_search_indexesSql() (*asset, error) {
+	bytes, err := dbMigrations0018_user_search_indexesSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0018_user_search_indexes.sql", size: 403, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0019_user_groupSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x74\x90\x41\x6a\xc3\x30\x10\x45\xf7\x73\x8a\xbf\x4c\x68\x72\x02\xad\xdc\x5a\x0b\x53\x57\x4e\x85\x05\xcd\x4a\x28\xd5\x60\x04\x95\x63\x14\x85\xa6\xb7\x2f\xc4\x31\x98\xc4\xd9\x6a\xde\xfc\xff\x34\xdb\x2d\x5e\x62\xe8\x92\xcb\x0c\x33\xd0\x9b\x96\x45\x2b\xd1\x16\xaf\xb5\xc4\xf9\xc4\xc9\x76\xe9\x78\x1e\xb0\x22\x00\x08\x1e\x99\x2f\x19\xaa\x69\xa1\x4c\x5d\x63\xa7\xab\x8f\x42\xef\xf1\x2e\xf7\x9b\x2b\xe1\xc3\x69\xf8\x71\x7f\xb6\x77\x91\xef\x58\xa3\xaa\x4f\x23\x47\xec\x3b\xb1\xcb\xec\xad\xcb\x38\x84\x2e\xf4\x99\xd6\x82\x9e\x95\xdb\xc8\xf1\xc0\xe9\xe6\x30\x3e\xdd\x9b\x8c\xb1\xd7\x9d\xe5\xd1\xcc\x14\xab\x29\x63\x33\x6d\xac\xe7\xfd\x95\x2a\xe5\xd7\x63\xbf\xbd\xb1\x36\xf8\x0b\x1a\xb5\x24\x38\xa5\x09\xa2\xf9\x5d\xcb\xe3\x6f\x4f\xa5\x6e\x76\xcf\xbe\x26\x68\x79\x2c\xe8\x7f\x00\x17\x97\x8a\xd4\x9f\x01\x00\x00")
+
+func dbMigrations0019_user_groupSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0019_user_groupSql,
+		"db/migrations/0019_user_group.sql",
+	)
+}
+
+func dbMigrations0019_user_groupSql() (*asset, error) {
+	bytes, err := dbMigrations0019_user_groupSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0019_user_group.sql", size: 415, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0020_user_attributeSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x7c\x90\xcd\x4e\xc3\x30\x10\x84\xef\xfb\x14\xa3\x5c\xda\x8a\xf6\x09\x2c\x0e\xa6\xf6\x01\xe1\x26\x91\x71\x0e\x3d\x45\xa6\xb5\x2a\x4b\x89\x89\xc2\x86\x9f\xb7\x47\x98\x1c\x10\x84\x5e\x67\x34\xb3\xdf\xce\x6e\x87\x9b\x3e\x5e\x46\xcf\x01\xcd\x40\x7b\xab\xa5\xd3\x70\xf2\xce\x68\x4c\x2f\x61\x6c\x3d\xf3\x18\x9f\x26\x0e\x58\x13\x80\x6f\x31\x9e\xc1\xe1\x9d\x51\x56\x0e\x65\x63\xcc\x36\x5b\xc9\xf7\x61\x49\x7f\xf5\xdd\xb4\x68\xd4\xf6\xfe\x20\xed\x11\x0f\xfa\x88\xf5\x5c\xbc\xcd\x35\x1b\xda\x08\x22\x69\x9c\xb6\x33\xcc\xa9\x8b\x21\x71\x1b\xcf\x21\x71\xe4\x0f\x48\xa5\xb0\xaf\x4c\x73\x28\x51\x9c\x3a\x1f\xfb\xb6\xf7\xc3\x10\xd3\xa5\xc8\x97\x04\x51\x53\xab\xaf\x5f\x8a\x5f\xc9\x02\x8f\xda\xfd\xc9\xdc\x62\xb5\x12\x44\x3f\xe7\x50\xcf\x6f\xe9\x2a\x82\xb2\x55\xfd\x0f\x83\x20\xca\xee\xd2\x90\x82\x3e\x07\x00\x25\xda\x74\x3a\x75\x01\x00\x00")
+
+func dbMigrations0020_user_attributeSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0020_user_attributeSql,
+		"db/migrations/0020_user_attribute.sql",
+	)
+}
+
+func dbMigrations0020_user_attributeSql() (*asset, error) {
+	bytes, err := dbMigrations0020_user_attributeSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0020_user_attribute.sql", size: 373, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0021_user_profileSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xd2\xd5\x55\xd0\xce\xcd\x4c\x2f\x4a\x2c\x49\x55\x08\x2d\xe0\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x48\x2c\x2d\xc9\x48\x89\x2f\x2d\x4e\x2d\x52\x70\x74\x71\x51\x70\xf6\xf7\x09\xf5\xf5\x53\x50\x4a\xcf\x2c\x4b\xcd\x8b\xcf\x4b\xcc\x4d\x55\x52\x28\x49\xad\x28\xb1\x26\x46\x53\x5a\x62\x6e\x66\x4e\x25\xa9\xba\x0a\x32\x93\x4b\x4a\x8b\x48\xd1\x91\x93\x9f\x9c\x98\x03\xd7\xc0\x15\x1a\xe0\xe2\x18\x82\xa2\x38\xd8\x35\x04\xd5\x0b\xb6\x0a\xea\xea\x3a\x68\x0e\x84\x8a\xc1\xad\x87\xf2\x61\x86\x83\xb8\xd6\x5c\x5c\xc8\x41\xe7\x92\x5f\x9e\x87\xcb\x7d\x2e\x41\xfe\x01\xe8\x0e\xb4\x26\x4a\x31\xcc\x01\xc4\xa9\x46\xf6\x02\x71\x3a\x90\xc2\xc1\x9a\x0b\x30\x00\x93\xed\xc5\xd0\x0a\x02\x00\x00")
+
+func dbMigrations0021_user_profileSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0021_user_profileSql,
+		"db/migrations/0021_user_profile.sql",
+	)
+}
+
+func dbMigrations0021_user_profileSql() (*asset, error) {
+	bytes, err := dbMigrations0021_user_profileSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0021_user_profile.sql", size: 522, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0022_audit_eventSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x7c\x91\xcf\x4e\xc2\x40\x10\xc6\xef\xfb\x14\xdf\x51\xa2\x3c\x41\x4f\xd5\xee\x81\x58\x5b\xd2\x94\x44\x4e\x9b\x95\x99\x90\x49\xca\x96\x6c\x07\xc5\xb7\x37\xd4\x02\xb5\xa2\xd7\xef\x5f\xe6\x97\x99\xcf\x71\xbf\x93\x6d\xf4\xca\x58\xed\xcd\x53\x65\xd3\xda\xa2\x4e\x1f\x73\x0b\x7f\x20\x51\xc7\xef\x1c\x14\x77\x06\x00\x84\xf0\x26\xdb\x8e\xa3\xf8\x06\x45\x59\xa3\x58\xe5\x39\x96\xd5\xe2\x25\xad\xd6\x78\xb6\xeb\x87\x3e\xa6\x9f\x7b\x86\xf2\x51\x2f\x99\x6f\x7d\x13\xd9\x2b\x93\xf3\x7a\x9a\x91\x30\xf5\x0f\x1d\x47\x27\xd4\x57\x87\x46\x23\x1c\x74\xa2\xb5\x21\xf0\x46\xdb\x49\x34\xf2\xae\x55\x76\x9e\x28\x8e\x54\x62\xf5\xd2\x74\xbd\x62\x66\x89\x39\x13\x2e\x8a\xcc\xbe\x8e\x09\xdd\xf5\x38\x27\x74\x44\x59\xfc\xe4\xbf\xda\xb3\xe4\xef\x91\x81\xe0\xe6\xc2\xe0\xfd\x57\xbf\xe0\xde\x3e\xe1\xec\x9e\x38\xc6\x8f\xcb\xda\x8f\x60\xb2\xaa\x5c\xfe\x7e\x5c\x62\xbe\x06\x00\xcc\x9c\xaf\x0b\xe2\x01\x00\x00")
+
+func dbMigrations0022_audit_eventSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0022_audit_eventSql,
+		"db/migrations/0022_audit_event.sql",
+	)
+}
+
+func dbMigrations0022_audit_eventSql() (*asset, error) {
+	bytes, err := dbMigrations0022_audit_eventSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0022_audit_event.sql", size: 482, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrations0023_webhookSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x7c\x92\xc1\x6e\xab\x30\x10\x45\xf7\xfe\x8a\x59\xbe\xa7\x36\x5f\x90\x15\x2d\x2c\xa2\x52\x88\x10\x91\x9a\x95\x65\x60\x44\x47\x25\x36\x1a\x4f\x12\xf8\xfb\xaa\x49\xa5\xb8\xd4\xcd\xd6\xe7\xce\xe8\xce\x91\x57\x2b\x78\x38\x50\xcf\x46\x10\x76\xa3\x7a\xae\xb2\xa4\xce\xa0\x4e\x9e\xf2\x0c\xce\xd8\xbc\x3b\xf7\xa1\xfd\xb1\xf1\x2d\xd3\x28\xe4\x2c\xfc\x53\x00\x00\xd4\x81\xe0\x24\x50\x94\x35\x14\xbb\x3c\x87\x6d\xb5\x79\x4d\xaa\x3d\xbc\x64\xfb\xc7\x4b\xe2\xc8\xc3\xcf\xc8\xf5\xd9\x63\xcb\x28\xd0\xcc\x82\x66\x81\xf0\x84\x56\x7c\x6c\xa8\x65\x34\x82\x9d\x36\x02\x0d\xf5\x64\x6f\x5c\xfd\x5f\xab\x78\xe9\x0e\x07\x3a\x21\xcf\xb7\xc2\x0d\xf5\x1e\x99\xcc\x70\xa7\x75\x78\xaa\x5e\x1e\x19\xd4\xbc\xcb\x64\x1e\x31\x46\x47\x33\x0f\xce\x44\x07\x8d\x08\x1e\x46\xf1\x40\x56\xb0\x47\x5e\x60\x8b\x93\xe8\xef\xcc\x52\xc1\x35\x31\x18\x2f\x1a\x99\x1d\x5f\xd6\x87\x5e\x36\x45\x9a\xbd\xfd\xf2\xa2\xc3\x9d\x9a\xba\x09\xca\x22\x22\x2f\x4c\x7d\xed\x0c\xff\x4b\xea\xce\x56\xa5\x55\xb9\xfd\x43\xfd\x5a\xc5\x68\xa8\x78\xad\x3e\x07\x00\x98\x85\x74\x76\x80\x02\x00\x00")
+
+func dbMigrations0023_webhookSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrations0023_webhookSql,
+		"db/migrations/0023_webhook.sql",
+	)
+}
+
+func dbMigrations0023_webhookSql() (*asset, error) {
+	bytes, err := dbMigrations0023_webhookSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/0023_webhook.sql", size: 640, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrationsMysql0001_initialSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\xb4\x58\x41\xaf\xe2\x36\x10\xbe\xf3\x2b\x7c\x04\x95\xbd\x54\x5d\xa9\xd2\xd3\x1e\xd8\xf7\xd2\x16\xf5\x2d\x6f\x4b\xe1\xb0\x27\xaf\x93\x0c\xc1\xc2\xb1\x23\x7b\xc2\x83\x7f\x5f\x25\x71\xc0\x0e\x49\xf4\x12\xe8\x0d\x66\xec\xf1\xcc\x7c\xe3\x6f\xc6\xf9\xf4\x89\xfc\x92\xf2\x44\x33\x04\xb2\xcd\x26\xcf\xeb\x60\xb1\x09\xc8\x66\xf1\xf5\x35\x20\x2c\xc7\x7d\x4c\x73\x03\x9a\x4c\x27\x84\x10\xc2\x63\x72\x64\x3a\xda\x33\x3d\xfd\xf5\xf3\xe7\x19\x59\xbd\x6d\xc8\x6a\xfb\xfa\x4a\xbe\xaf\x97\xdf\x16\xeb\x1f\xe4\xef\xe0\xc7\xbc\x5c\x09\x29\xe3\xc2\x5b\xec\xc8\xe9\x11\x34\xdf\x71\x88\x49\xa8\x94\x00\x26\x2b\x5d\xcc\x4d\x26\xd8\x99\x4a\x96\x02\x41\x38\x61\x25\x66\x71\xca\xa5\xbf\x32\xd2\xc0\x10\x62\xca\x90\x84\x3c\xe1\x12\x2f\x06\x58\x28\x9a\x66\x13\x7e\x04\xd9\x34\xba\x63\x29\x17\x37\x47\x65\x3c\xc2\x5c\xbb\x12\xa1\x22\x26\x5c\xc1\x76\xb5\xfc\x67\x1b\x14\x81\x3a\xe9\xa1\x55\x58\x07\x38\x93\x69\xf9\xd3\x46\xdb\x58\x75\x75\x9b\xf2\xf8\x44\xa6\xd7\xff\xb3\xc9\x8c\x04\xab\x3f\x97\xab\xe0\xcb\x52\x4a\xf5\xf2\x95\xbc\x04\x7f\x2c\xb6\xaf\x1b\xf2\xfc\xd7\x62\xfd\x6f\xb0\xf9\x92\xe3\xee\xf7\x34\xfc\xed\x69\xe2\x43\x14\x09\x0e\xb2\x30\x07\x12\x39\x9e\x07\xe3\x94\x02\xb2\x98\x21\x73\x22\x8c\xe1\x44\xdb\x72\x2e\x18\x4f\x69\xca\xb2\x8c\xcb\xa4\x5c\xfe\x20\x9f\xa9\x81\x48\x03\x5e\x5d\xaf\x20\xbd\x3a\xbd\xd8\x6e\xde\xe8\x72\xf5\xbc\x0e\xbe\x05\xab\xcd\x6d\x0c\x17\x7b\xed\x41\x57\x8b\xec\x21\xa1\x50\x61\x6f\x0d\xc1\x29\xe3\x1a\x4c\x53\x5c\x20\xd9\xee\x37\xbd\x88\x2d\xa8\xf5\xdf\xf1\x98\x2a\x29\x21\x42\xa5\x69\xa4\xe4\x8e\x27\x83\x41\xc5\x73\xe6\x96\xac\xb5\x72\x0f\x64\x3f\x0f\x70\xfe\x69\xfd\x38\x32\x91\x43\x99\xc8\xb1\xd6\x32\x66\xcc\xbb\xd2\x31\xe5\x72\xa7\xac\xd5\xf2\x82\x7c\x3c\xc4\xda\x84\x7b\x79\x6b\xab\x16\x42\x0f\xbf\x3d\x37\xa8\xf4\xf9\xae\x24\x18\x30\x86\x2b\x39\x18\x0e\x83\x0c\x3d\x3c\x06\x55\xde\xb5\xba\x1d\x0b\x95\xac\x69\x58\x43\xcc\x35\x44\x48\x73\x2d\x1c\xf1\x85\x1c\xbc\x92\xb0\x25\xe6\xd9\x6d\x03\xa1\x36\x9d\x70\x83\xa0\x7d\x4e\x90\x4a\x46\xae\x03\x26\x52\x5e\xe5\xb1\x54\x3b\xff\x04\x97\x07\x8a\xea\x00\xf2\x11\x30\x54\x6c\x5b\x1a\xae\xaa\xf3\xa3\x68\xd8\xed\x5e\xe0\x1d\xb9\x37\x58\x90\xbf\x0d\x79\xac\xbf\x1a\x52\x85\x70\xa5\x8d\x9a\x42\xa7\xb7\x48\xf4\xf0\x57\x1f\x34\xd6\x7e\xdf\x76\x27\x0d\x64\xea\x9e\x39\xbf\xee\x77\x9a\x56\x87\xcf\xd4\x7a\x51\x31\x9d\xfd\x33\x1b\x9f\x98\x9d\x06\xb3\xb7\x25\x31\x9a\xfe\x33\x76\x16\x8a\xc5\x74\xcf\xcc\xde\xe1\xf7\xee\x84\xb5\xf7\x8b\xb1\x51\x94\xe7\xa0\xc2\x6c\x1c\x91\xdd\xf6\xa5\x82\xad\x75\xda\x9c\x62\x34\x44\xea\x08\xfa\x4c\x23\x15\x83\x71\x6f\x15\x33\x48\x23\x95\xcb\xf2\x76\xba\xc4\xd1\xa4\x99\xbb\x22\x7c\x87\xb0\x98\x64\x64\x31\xc4\x94\x55\xc1\xc4\x60\x22\xec\x4d\x8d\x05\x33\x0f\x05\x8f\xca\xbb\x7d\x4d\x89\xe1\x89\xac\x42\xec\x0f\xf0\x5a\xbf\x5d\x2e\xd7\x05\x7c\x7f\xf1\x0a\x95\x70\x49\x19\x22\xa4\x19\x0e\xce\xc4\x8e\x71\x91\x17\x3d\x8a\x4b\x84\x04\xb4\x03\xa5\x55\x79\x21\x09\x15\x1d\x20\xa6\xb9\x44\x2e\x1e\x01\x66\xa2\x55\x9e\x0d\x76\xda\x9b\xcf\xdb\xf7\x54\xd3\xf1\xff\x51\x7f\xa5\xcb\x34\x85\x34\xbc\xbc\x46\x2a\xd1\x70\xda\xec\xa3\xc6\xda\xe6\xbc\xde\x39\x6b\x14\x95\xeb\xc7\x63\xe9\xb0\xdc\xcf\x10\x35\x0f\x73\x84\x8f\xb0\x89\x6d\xc2\x9d\x70\xcc\x9d\x79\xad\x20\x8c\xbe\xc0\xed\x41\xf3\xd2\xdc\xe8\x18\x58\x1e\x73\xa4\x70\x04\x79\xc7\x3c\x7f\x19\x5f\x1b\xfe\xde\x14\xd4\x87\x5b\x64\x3b\xe3\xf7\x8e\x42\xb6\x03\xb2\x38\xd6\xde\xdb\x08\x19\x17\x2e\xfd\x56\x4f\xbc\x4b\xd4\x7d\x6f\xbc\xf6\x0d\xad\x35\xd4\x61\xfb\xd1\x2f\x8d\x77\x08\xf7\x4a\x1d\xa8\xc9\x43\x13\x69\x9e\xe1\x98\xf1\xb6\x1e\x36\xbb\x1f\x5c\x0d\x4d\x19\x8c\x19\x86\xf0\xbd\x11\xc6\x20\x78\xd1\x3e\xc7\x57\xa5\x9b\xa3\xba\x54\xda\x02\xeb\xd5\x75\x95\xb6\x9d\x61\xda\x54\xb6\xc5\x5c\x5a\x45\x43\x2d\xe1\x84\x97\x36\xd4\x7a\x2d\xca\xae\x02\x5a\x2b\xdd\x28\xdb\x66\x6e\xa8\x6b\xab\x2a\x31\x57\x32\xa4\xca\xdc\xaf\x4a\x2f\xea\x5d\x4e\x5e\xd6\x6f\xdf\x3b\x20\x79\x6a\x53\xba\xd9\xf6\x16\x38\xf7\xc1\x93\xfb\xec\x79\xab\x72\x79\xbb\x43\xeb\x89\xbd\xfe\x7e\xbb\xa1\x65\xb6\xb8\x5d\x54\x4c\x85\x9e\xd4\x9b\x78\x1b\x9a\xd6\x81\xdb\x5b\xe3\x3c\x7c\xda\xe4\x9e\xcc\x7b\x62\x7b\x9a\xf2\xb1\xe4\x49\x9a\xdf\x1b\x7c\x65\xeb\x47\x8f\xbe\x25\x0d\xb8\xea\xaf\x5f\x4f\x93\xff\x06\x00\x1b\xce\x2d\xb1\x6b\x14\x00\x00")
+
+func dbMigrationsMysql0001_initialSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrationsMysql0001_initialSql,
+		"db/migrations/mysql/0001_initial.sql",
+	)
+}
+
+func dbMigrationsMysql0001_initialSql() (*asset, error) {
+	bytes, err := dbMigrationsMysql0001_initialSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/mysql/0001_initial.sql", size: 5227, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
+var _dbMigrationsSqlite30001_initialSql = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x02\xff\x94\x58\xcd\x8e\xe2\x38\x10\xbe\xf3\x14\xd6\x9c\x40\xcb\x3c\xc1\x9c\x7a\xa7\x39\xb4\xb6\x87\x9e\x45\x20\xed\x9c\x2c\x27\x29\x82\x85\x63\x47\x76\x85\x9f\xb7\x5f\x25\x71\xc0\x8e\x6d\x08\x47\xaa\xca\xe5\xaf\xbe\xfa\x71\x85\xef\xdf\xc9\x5f\x15\x2f\x35\x43\x20\xbb\x7a\xf6\x73\xb3\x7a\xdb\xae\xc8\xf6\xed\xef\xcf\x15\x61\x0d\x1e\x0a\xda\x18\xd0\x64\x3e\x23\x84\x10\x5e\x10\x84\x0b\x92\xf5\xd7\x96\xac\x77\x9f\x9f\xe4\xf7\xe6\xe3\xd7\xdb\xe6\x0f\xf9\x67\xf5\x67\xd9\x59\x40\xc5\xb8\xe8\x8d\x76\xeb\x8f\x7f\x77\x2b\x47\x4c\x4f\xa0\xf9\x9e\x43\x41\x32\xa5\x04\x30\xd9\xeb\x0a\x6e\x6a\xc1\xae\x54\xb2\x0a\xba\x93\xbd\x98\x15\x15\x97\xbe\x65\xae\x81\x21\x14\x94\x21\xc9\x78\xc9\x25\xde\x1c\xb0\x4c\x8c\xdd\x96\xfc\x04\x72\xec\x74\xcf\x2a\x2e\x82\xab\x6a\x9e\x63\xa3\x5d\x89\x50\x39\x13\xbd\x60\xb6\xf8\x31\x1b\x68\xf9\x58\xbf\xaf\xfe\x73\x68\xa1\x42\x9d\x41\xd3\x3e\x3a\x5e\x5c\xc8\xd7\xda\x23\xad\x53\xcf\x3b\xf5\xe2\x91\x9b\x7b\x60\x31\x2f\x77\xad\xe3\xa3\xcf\x50\x2e\x38\xc8\xf6\x10\x48\xe4\x78\x9d\x9c\xa6\x0a\x90\x15\x0c\x99\x13\x72\x01\x17\x1a\xe3\x5c\x30\x5e\xd1\x8a\xd5\x35\x97\x65\x40\x48\x14\x05\x35\x90\x6b\xc0\x3b\x18\x2e\x11\x4a\xd0\x2e\x0c\xf2\xb6\xdb\x7e\x7d\xac\x7f\x6e\x56\xbf\x56\xeb\xed\x70\x95\x75\xe3\xa3\xef\x95\xd6\x67\x26\x54\xf6\xb0\x18\xe0\x52\x73\x0d\xe6\x2e\x0e\xf3\x17\x87\x4b\x6f\xe2\x21\x07\xa9\xb0\x6e\xf2\x90\x08\x25\x25\xe4\xa8\x34\xcd\x95\xdc\xf3\x72\x72\x3e\xf0\x5a\xbb\xe5\x67\x4f\xc7\xd9\xfe\x76\x84\xeb\x37\xeb\xf9\xc4\x44\x03\x1d\x29\xa1\x5d\xcd\x8c\x39\x2b\x5d\x50\x2e\xf7\xca\xda\x77\xd5\xf6\x1c\xce\x70\xd4\xed\x91\xc1\x9b\x25\xd8\x23\xfd\xc0\x0d\x2a\x7d\x4d\x00\x36\x60\x0c\x57\x72\x32\x19\x06\x19\x7a\x6c\x4c\xcc\x74\xac\x8a\x3c\xd9\xd8\xb1\x86\x82\x6b\xc8\x91\x36\x5a\x38\xe2\x5b\x37\x79\x09\xb1\x89\xf5\xfc\xba\x74\x0e\x2e\x4b\x6e\x10\xb4\xdf\x44\x52\xc9\xdc\xbd\xd8\xe4\xca\xcb\x37\xab\xb4\xf3\x4b\x70\x79\xa4\xa8\x8e\x20\x1f\x13\x4a\x8f\x30\x74\x7c\x5f\x13\xcf\x78\xb5\xc7\x3c\xc0\x09\x16\x0d\xb6\xf3\xcf\x06\x11\x22\xd0\x50\x29\x84\x7b\x6b\x0c\xf3\x61\x1e\x67\x6b\xd4\xcb\x31\xda\xac\xbf\x98\xb9\x13\x08\x99\xbb\xbe\x97\xf7\x73\x8b\xb0\xcd\x13\x18\xa9\xbd\x7d\xe8\xf2\x64\x28\xd6\x2e\x12\xfb\x5e\x83\x39\xd8\x0c\xbd\x3a\xe4\x6a\x76\x15\x8a\x15\xf4\xc0\xcc\xc1\x99\x66\x21\x25\x7e\x1d\x87\x29\xe8\x4e\xa0\xc2\xfa\xb5\xd6\x0e\xe7\x68\x3b\x6b\x74\x35\x7e\x3e\x35\xe4\xea\x04\xfa\x4a\x73\x55\x80\x71\xab\x93\x19\xa4\xb9\x6a\x64\x57\xe5\x6e\xe3\x8d\xdb\x34\x81\xf9\x0c\x59\xfb\xba\xc9\xf6\xd5\xeb\x48\x67\x62\xf2\x68\x88\x06\x69\x89\x6d\x32\xc1\xf3\xae\x27\xee\xc1\x19\x5e\xca\x1e\xec\x74\xa8\x7d\xf5\xa4\xa0\x0e\xe5\xd3\x96\x4e\x3a\x9c\x54\xed\x08\x55\x72\x49\x19\x22\x54\x35\x4e\x8e\x7a\xcf\xb8\x68\xda\x99\x6b\x6b\xcc\x49\x84\x55\x79\xd1\x09\x95\x1f\xa1\xa0\x8d\x44\x2e\x1e\xa7\xa2\xd4\xaa\xa9\x27\xc3\x08\x16\xb5\xbb\xad\xbb\xeb\x4d\xad\x83\xee\x72\x5a\x41\x95\xdd\xf6\xcb\x5e\xf4\x7c\x68\x3c\x1a\x10\x83\x8f\xe5\x70\x62\x91\x48\xae\x7b\xff\x78\x28\x44\x00\xa6\x52\xda\xc9\x19\xa2\xe6\x59\x83\x40\xe6\xcf\xc0\x86\xec\x2d\x9d\xa7\xfc\x59\x74\xd6\xf1\xb2\x73\xb3\x08\x99\x65\x4d\xc1\x91\xc2\x09\xe4\xeb\xfb\xd7\x6d\x09\x19\x01\x08\x12\xfa\x74\x9c\x47\xdf\xe0\xf8\x13\x6a\xc7\x2f\x2b\x0a\xed\x2d\xa3\xc8\xb8\x30\xc9\x05\xfc\x16\x64\x74\x75\x76\x28\xf0\x76\xe7\xa4\x93\x51\xf6\x3d\x0f\xf7\xbc\xa7\x31\x8c\x37\x47\x1f\x42\x72\x5d\x3c\x43\x76\x50\xea\x48\x4d\x93\x99\x5c\xf3\x1a\x5f\xd9\x92\x86\x9d\x25\xbd\x27\x8f\x34\x1d\x1c\xf3\x5a\x82\x67\x49\xcc\x05\x08\xde\xbe\x0f\x2f\x57\x99\x1b\x6c\xa2\x45\x7a\x56\x1f\xe9\x52\xa5\x6a\x5f\xd7\x98\xca\x0e\xdd\xdb\x10\x1d\xa9\x25\x5c\xf0\x36\x98\xa3\x65\xde\xcd\x5b\xd0\x5a\xe9\x44\x59\x8e\xa9\xa1\xae\xcf\xa1\x34\x42\xfe\x5c\xab\xd6\xa7\xfb\x45\xfe\xae\xce\x72\xf6\xbe\xf9\xfa\x9d\x60\xff\x47\x4c\xe9\x32\xec\x19\x38\x65\xe9\xc9\xfd\x11\x16\xaa\xdc\x41\x98\xd0\x7a\x62\xef\x95\x0b\x0f\x44\x5e\xcc\xd0\xa8\xdd\x6c\x3c\xa9\xb7\x76\x8d\x34\xd1\x0d\xce\xb3\x71\x56\xe6\x98\xdc\x93\x79\x1f\x4e\x9e\xa6\x5b\xb3\x3d\xc9\xf8\x8b\xcf\x57\x46\x3f\x20\x1f\x99\x8c\xd2\x35\xfc\x07\xf0\x63\xf6\xff\x00\xc6\xcc\x23\x31\xa7\x11\x00\x00")
+
+func dbMigrationsSqlite30001_initialSqlBytes() ([]byte, error) {
+	return bindataRead(
+		_dbMigrationsSqlite30001_initialSql,
+		"db/migrations/sqlite3/0001_initial.sql",
+	)
+}
+
+func dbMigrationsSqlite30001_initialSql() (*asset, error) {
+	bytes, err := dbMigrationsSqlite30001_initialSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "db/migrations/sqlite3/0001_initial.sql", size: 4519, mode: os.FileMode(436), modTime: time.Unix(1, 0)}
 	a := &asset{bytes: bytes, info: info}
 	return a, nil
 }
@@ -329,16 +644,31 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"db/migrations/0001_initial_migration.sql":             dbMigrations0001_initial_migrationSql,
-	"db/migrations/0002_dex_admin.sql":                     dbMigrations0002_dex_adminSql,
-	"db/migrations/0003_user_created_at.sql":               dbMigrations0003_user_created_atSql,
-	"db/migrations/0004_session_nonce.sql":                 dbMigrations0004_session_nonceSql,
-	"db/migrations/0005_refresh_token_create.sql":          dbMigrations0005_refresh_token_createSql,
-	"db/migrations/0006_user_email_unique.sql":             dbMigrations0006_user_email_uniqueSql,
-	"db/migrations/0007_session_scope.sql":                 dbMigrations0007_session_scopeSql,
-	"db/migrations/0008_users_active_or_inactive.sql":      dbMigrations0008_users_active_or_inactiveSql,
-	"db/migrations/0009_key_not_primary_key.sql":           dbMigrations0009_key_not_primary_keySql,
+	"db/migrations/0001_initial_migration.sql": dbMigrations0001_initial_migrationSql,
+	"db/migrations/0002_dex_admin.sql": dbMigrations0002_dex_adminSql,
+	"db/migrations/0003_user_created_at.sql": dbMigrations0003_user_created_atSql,
+	"db/migrations/0004_session_nonce.sql": dbMigrations0004_session_nonceSql,
+	"db/migrations/0005_refresh_token_create.sql": dbMigrations0005_refresh_token_createSql,
+	"db/migrations/0006_user_email_unique.sql": dbMigrations0006_user_email_uniqueSql,
+	"db/migrations/0007_session_scope.sql": dbMigrations0007_session_scopeSql,
+	"db/migrations/0008_users_active_or_inactive.sql": dbMigrations0008_users_active_or_inactiveSql,
+	"db/migrations/0009_key_not_primary_key.sql": dbMigrations0009_key_not_primary_keySql,
 	"db/migrations/0010_client_metadata_field_changed.sql": dbMigrations0010_client_metadata_field_changedSql,
+	"db/migrations/0011_client_secret_rotation.sql": dbMigrations0011_client_secret_rotationSql,
+	"db/migrations/0012_user_totp.sql": dbMigrations0012_user_totpSql,
+	"db/migrations/0013_user_webauthn.sql": dbMigrations0013_user_webauthnSql,
+	"db/migrations/0014_session_amr.sql": dbMigrations0014_session_amrSql,
+	"db/migrations/0015_login_attempt.sql": dbMigrations0015_login_attemptSql,
+	"db/migrations/0016_password_history.sql": dbMigrations0016_password_historySql,
+	"db/migrations/0017_session_link_token.sql": dbMigrations0017_session_link_tokenSql,
+	"db/migrations/0018_user_search_indexes.sql": dbMigrations0018_user_search_indexesSql,
+	"db/migrations/0019_user_group.sql": dbMigrations0019_user_groupSql,
+	"db/migrations/0020_user_attribute.sql": dbMigrations0020_user_attributeSql,
+	"db/migrations/0021_user_profile.sql": dbMigrations0021_user_profileSql,
+	"db/migrations/0022_audit_event.sql": dbMigrations0022_audit_eventSql,
+	"db/migrations/0023_webhook.sql": dbMigrations0023_webhookSql,
+	"db/migrations/mysql/0001_initial.sql": dbMigrationsMysql0001_initialSql,
+	"db/migrations/sqlite3/0001_initial.sql": dbMigrationsSqlite30001_initialSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -384,16 +714,35 @@ type bintree struct {
 var _bintree = &bintree{nil, map[string]*bintree{
 	"db": &bintree{nil, map[string]*bintree{
 		"migrations": &bintree{nil, map[string]*bintree{
-			"0001_initial_migration.sql":             &bintree{dbMigrations0001_initial_migrationSql, map[string]*bintree{}},
-			"0002_dex_admin.sql":                     &bintree{dbMigrations0002_dex_adminSql, map[string]*bintree{}},
-			"0003_user_created_at.sql":               &bintree{dbMigrations0003_user_created_atSql, map[string]*bintree{}},
-			"0004_session_nonce.sql":                 &bintree{dbMigrations0004_session_nonceSql, map[string]*bintree{}},
-			"0005_refresh_token_create.sql":          &bintree{dbMigrations0005_refresh_token_createSql, map[string]*bintree{}},
-			"0006_user_email_unique.sql":             &bintree{dbMigrations0006_user_email_uniqueSql, map[string]*bintree{}},
-			"0007_session_scope.sql":                 &bintree{dbMigrations0007_session_scopeSql, map[string]*bintree{}},
-			"0008_users_active_or_inactive.sql":      &bintree{dbMigrations0008_users_active_or_inactiveSql, map[string]*bintree{}},
-			"0009_key_not_primary_key.sql":           &bintree{dbMigrations0009_key_not_primary_keySql, map[string]*bintree{}},
+			"0001_initial_migration.sql": &bintree{dbMigrations0001_initial_migrationSql, map[string]*bintree{}},
+			"0002_dex_admin.sql": &bintree{dbMigrations0002_dex_adminSql, map[string]*bintree{}},
+			"0003_user_created_at.sql": &bintree{dbMigrations0003_user_created_atSql, map[string]*bintree{}},
+			"0004_session_nonce.sql": &bintree{dbMigrations0004_session_nonceSql, map[string]*bintree{}},
+			"0005_refresh_token_create.sql": &bintree{dbMigrations0005_refresh_token_createSql, map[string]*bintree{}},
+			"0006_user_email_unique.sql": &bintree{dbMigrations0006_user_email_uniqueSql, map[string]*bintree{}},
+			"0007_session_scope.sql": &bintree{dbMigrations0007_session_scopeSql, map[string]*bintree{}},
+			"0008_users_active_or_inactive.sql": &bintree{dbMigrations0008_users_active_or_inactiveSql, map[string]*bintree{}},
+			"0009_key_not_primary_key.sql": &bintree{dbMigrations0009_key_not_primary_keySql, map[string]*bintree{}},
 			"0010_client_metadata_field_changed.sql": &bintree{dbMigrations0010_client_metadata_field_changedSql, map[string]*bintree{}},
+			"0011_client_secret_rotation.sql": &bintree{dbMigrations0011_client_secret_rotationSql, map[string]*bintree{}},
+			"0012_user_totp.sql": &bintree{dbMigrations0012_user_totpSql, map[string]*bintree{}},
+			"0013_user_webauthn.sql": &bintree{dbMigrations0013_user_webauthnSql, map[string]*bintree{}},
+			"0014_session_amr.sql": &bintree{dbMigrations0014_session_amrSql, map[string]*bintree{}},
+			"0015_login_attempt.sql": &bintree{dbMigrations0015_login_attemptSql, map[string]*bintree{}},
+			"0016_password_history.sql": &bintree{dbMigrations0016_password_historySql, map[string]*bintree{}},
+			"0017_session_link_token.sql": &bintree{dbMigrations0017_session_link_tokenSql, map[string]*bintree{}},
+			"0018_user_search_indexes.sql": &bintree{dbMigrations0018_user_search_indexesSql, map[string]*bintree{}},
+			"0019_user_group.sql": &bintree{dbMigrations0019_user_groupSql, map[string]*bintree{}},
+			"0020_user_attribute.sql": &bintree{dbMigrations0020_user_attributeSql, map[string]*bintree{}},
+			"0021_user_profile.sql": &bintree{dbMigrations0021_user_profileSql, map[string]*bintree{}},
+			"0022_audit_event.sql": &bintree{dbMigrations0022_audit_eventSql, map[string]*bintree{}},
+			"0023_webhook.sql": &bintree{dbMigrations0023_webhookSql, map[string]*bintree{}},
+			"mysql": &bintree{nil, map[string]*bintree{
+				"0001_initial.sql": &bintree{dbMigrationsMysql0001_initialSql, map[string]*bintree{}},
+			}},
+			"sqlite3": &bintree{nil, map[string]*bintree{
+				"0001_initial.sql": &bintree{dbMigrationsSqlite30001_initialSql, map[string]*bintree{}},
+			}},
 		}},
 	}},
 }}
@@ -0,0 +1,189 @@
+-- +migrate Up
+CREATE TABLE authd_user (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    email varchar(255),
+    email_verified boolean,
+    display_name text,
+    admin boolean,
+    created_at bigint,
+    disabled boolean,
+    given_name text,
+    family_name text,
+    picture text,
+    locale text,
+    UNIQUE KEY authd_user_email_key (email),
+    KEY authd_user_created_at_idx (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE client_identity (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    metadata text,
+    dex_admin boolean,
+    claim_mapping text
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE client_identity_secret (
+    id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    client_id varchar(255) NOT NULL,
+    secret blob,
+    created_at bigint,
+    expires_at bigint,
+    KEY client_identity_secret_client_id_idx (client_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE connector_config (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    type text,
+    config text
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE `key` (
+    value blob
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE password_info (
+    user_id varchar(255) NOT NULL PRIMARY KEY,
+    password text,
+    password_expires bigint,
+    history text
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE session (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    state text,
+    created_at bigint,
+    expires_at bigint,
+    client_id text,
+    client_state text,
+    redirect_url text,
+    identity text,
+    connector_id text,
+    user_id varchar(255),
+    register boolean,
+    nonce text,
+    scope text,
+    amr text,
+    link_token text
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE session_key (
+    `key` varchar(255) NOT NULL PRIMARY KEY,
+    session_id text,
+    expires_at bigint,
+    stale boolean
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE remote_identity_mapping (
+    connector_id varchar(255) NOT NULL,
+    user_id varchar(255),
+    remote_id varchar(255) NOT NULL,
+    PRIMARY KEY (connector_id, remote_id),
+    KEY remote_identity_mapping_user_id_idx (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE refresh_token (
+    id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    payload_hash blob,
+    user_id varchar(255),
+    client_id varchar(255)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_totp (
+    user_id varchar(255) NOT NULL PRIMARY KEY,
+    secret blob,
+    confirmed boolean,
+    recovery_codes text,
+    last_counter bigint,
+    created_at bigint
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_webauthn_credential (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    user_id varchar(255) NOT NULL,
+    public_key blob,
+    sign_count bigint,
+    created_at bigint,
+    KEY user_webauthn_credential_user_id (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE login_attempt (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    failures integer,
+    last_failure bigint,
+    locked_until bigint
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_group (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    display_name varchar(255) NOT NULL UNIQUE,
+    created_at bigint
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_group_member (
+    group_id varchar(255) NOT NULL,
+    user_id varchar(255) NOT NULL,
+    PRIMARY KEY (group_id, user_id),
+    KEY user_group_member_user_id_idx (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE user_attribute (
+    user_id varchar(255) NOT NULL,
+    name varchar(255) NOT NULL,
+    value text NOT NULL,
+    PRIMARY KEY (user_id, name)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE audit_event (
+    id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    type text NOT NULL,
+    created_at bigint NOT NULL,
+    user_id varchar(255),
+    client_id varchar(255),
+    connector_id text,
+    remote_addr text,
+    details text,
+    KEY audit_event_created_at_idx (created_at),
+    KEY audit_event_user_id_idx (user_id),
+    KEY audit_event_client_id_idx (client_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE webhook_subscription (
+    id varchar(255) NOT NULL PRIMARY KEY,
+    url text NOT NULL,
+    secret blob NOT NULL,
+    events text NOT NULL,
+    created_at bigint NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE webhook_delivery (
+    id bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    subscription_id text NOT NULL,
+    event_id text NOT NULL,
+    event_type text NOT NULL,
+    payload text NOT NULL,
+    attempts integer NOT NULL,
+    next_attempt bigint NOT NULL,
+    last_error text,
+    KEY webhook_delivery_next_attempt_idx (next_attempt)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- +migrate Down
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_subscription;
+DROP TABLE audit_event;
+DROP TABLE user_attribute;
+DROP TABLE user_group_member;
+DROP TABLE user_group;
+DROP TABLE login_attempt;
+DROP TABLE user_webauthn_credential;
+DROP TABLE user_totp;
+DROP TABLE refresh_token;
+DROP TABLE remote_identity_mapping;
+DROP TABLE session_key;
+DROP TABLE session;
+DROP TABLE password_info;
+DROP TABLE `key`;
+DROP TABLE connector_config;
+DROP TABLE client_identity_secret;
+DROP TABLE client_identity;
+DROP TABLE authd_user;
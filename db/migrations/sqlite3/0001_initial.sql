@@ -0,0 +1,197 @@
+-- +migrate Up
+CREATE TABLE authd_user (
+    id text NOT NULL PRIMARY KEY,
+    email text UNIQUE,
+    email_verified boolean,
+    display_name text,
+    admin boolean,
+    created_at bigint,
+    disabled boolean,
+    given_name text,
+    family_name text,
+    picture text,
+    locale text
+);
+
+CREATE INDEX authd_user_lower_email_idx ON authd_user (lower(email));
+
+CREATE INDEX authd_user_created_at_idx ON authd_user (created_at);
+
+CREATE TABLE client_identity (
+    id text NOT NULL PRIMARY KEY,
+    metadata text,
+    dex_admin boolean,
+    claim_mapping text
+);
+
+CREATE TABLE client_identity_secret (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    client_id text NOT NULL,
+    secret blob,
+    created_at bigint,
+    expires_at bigint
+);
+
+CREATE INDEX client_identity_secret_client_id_idx ON client_identity_secret (client_id);
+
+CREATE TABLE connector_config (
+    id text NOT NULL PRIMARY KEY,
+    type text,
+    config text
+);
+
+CREATE TABLE "key" (
+    value blob
+);
+
+CREATE TABLE password_info (
+    user_id text NOT NULL PRIMARY KEY,
+    password text,
+    password_expires bigint,
+    history text
+);
+
+CREATE TABLE session (
+    id text NOT NULL PRIMARY KEY,
+    state text,
+    created_at bigint,
+    expires_at bigint,
+    client_id text,
+    client_state text,
+    redirect_url text,
+    identity text,
+    connector_id text,
+    user_id text,
+    register boolean,
+    nonce text,
+    scope text,
+    amr text,
+    link_token text
+);
+
+CREATE TABLE session_key (
+    "key" text NOT NULL PRIMARY KEY,
+    session_id text,
+    expires_at bigint,
+    stale boolean
+);
+
+CREATE TABLE remote_identity_mapping (
+    connector_id text NOT NULL,
+    user_id text,
+    remote_id text NOT NULL,
+    PRIMARY KEY (connector_id, remote_id)
+);
+
+CREATE INDEX remote_identity_mapping_user_id_idx ON remote_identity_mapping (user_id);
+
+CREATE TABLE refresh_token (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    payload_hash blob,
+    user_id text,
+    client_id text
+);
+
+CREATE TABLE user_totp (
+    user_id text NOT NULL PRIMARY KEY,
+    secret blob,
+    confirmed boolean,
+    recovery_codes text,
+    last_counter bigint,
+    created_at bigint
+);
+
+CREATE TABLE user_webauthn_credential (
+    id text NOT NULL PRIMARY KEY,
+    user_id text NOT NULL,
+    public_key blob,
+    sign_count bigint,
+    created_at bigint
+);
+
+CREATE INDEX user_webauthn_credential_user_id ON user_webauthn_credential (user_id);
+
+CREATE TABLE login_attempt (
+    id text NOT NULL PRIMARY KEY,
+    failures integer,
+    last_failure bigint,
+    locked_until bigint
+);
+
+CREATE TABLE user_group (
+    id text NOT NULL PRIMARY KEY,
+    display_name text NOT NULL UNIQUE,
+    created_at bigint
+);
+
+CREATE TABLE user_group_member (
+    group_id text NOT NULL,
+    user_id text NOT NULL,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX user_group_member_user_id_idx ON user_group_member (user_id);
+
+CREATE TABLE user_attribute (
+    user_id text NOT NULL,
+    name text NOT NULL,
+    value text NOT NULL,
+    PRIMARY KEY (user_id, name)
+);
+
+CREATE TABLE audit_event (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    type text NOT NULL,
+    created_at bigint NOT NULL,
+    user_id text,
+    client_id text,
+    connector_id text,
+    remote_addr text,
+    details text
+);
+
+CREATE INDEX audit_event_created_at_idx ON audit_event (created_at);
+CREATE INDEX audit_event_user_id_idx ON audit_event (user_id);
+CREATE INDEX audit_event_client_id_idx ON audit_event (client_id);
+
+CREATE TABLE webhook_subscription (
+    id text NOT NULL PRIMARY KEY,
+    url text NOT NULL,
+    secret blob NOT NULL,
+    events text NOT NULL,
+    created_at bigint NOT NULL
+);
+
+CREATE TABLE webhook_delivery (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    subscription_id text NOT NULL,
+    event_id text NOT NULL,
+    event_type text NOT NULL,
+    payload text NOT NULL,
+    attempts integer NOT NULL,
+    next_attempt bigint NOT NULL,
+    last_error text
+);
+
+CREATE INDEX webhook_delivery_next_attempt_idx ON webhook_delivery (next_attempt);
+
+-- +migrate Down
+DROP TABLE webhook_delivery;
+DROP TABLE webhook_subscription;
+DROP TABLE audit_event;
+DROP TABLE user_attribute;
+DROP TABLE user_group_member;
+DROP TABLE user_group;
+DROP TABLE login_attempt;
+DROP TABLE user_webauthn_credential;
+DROP TABLE user_totp;
+DROP TABLE refresh_token;
+DROP TABLE remote_identity_mapping;
+DROP TABLE session_key;
+DROP TABLE session;
+DROP TABLE password_info;
+DROP TABLE "key";
+DROP TABLE connector_config;
+DROP TABLE client_identity_secret;
+DROP TABLE client_identity;
+DROP TABLE authd_user;
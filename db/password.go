@@ -1,6 +1,7 @@
 package db
 
 import (
+	"encoding/json"
 	"errors"
 	"reflect"
 	"time"
@@ -29,6 +30,7 @@ type passwordInfoModel struct {
 	UserID          string `db:"user_id"`
 	Password        string `db:"password"`
 	PasswordExpires int64  `db:"password_expires"`
+	History         string `db:"history"`
 }
 
 func NewPasswordInfoRepo(dbm *gorp.DbMap) user.PasswordInfoRepo {
@@ -89,6 +91,21 @@ func (r *passwordInfoRepo) Update(tx repo.Transaction, pw user.PasswordInfo) err
 	return nil
 }
 
+func (r *passwordInfoRepo) Delete(tx repo.Transaction, userID string) error {
+	if userID == "" {
+		return user.ErrorInvalidID
+	}
+
+	n, err := r.executor(tx).Delete(&passwordInfoModel{UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return user.ErrorNotFound
+	}
+	return nil
+}
+
 func (r *passwordInfoRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
 	if tx == nil {
 		return r.dbMap
@@ -151,6 +168,16 @@ func (p *passwordInfoModel) passwordInfo() (user.PasswordInfo, error) {
 		pw.PasswordExpires = time.Unix(p.PasswordExpires, 0).UTC()
 	}
 
+	if p.History != "" {
+		var history []string
+		if err := json.Unmarshal([]byte(p.History), &history); err != nil {
+			return user.PasswordInfo{}, err
+		}
+		for _, h := range history {
+			pw.History = append(pw.History, user.Password(h))
+		}
+	}
+
 	return pw, nil
 }
 
@@ -164,5 +191,17 @@ func newPasswordInfoModel(p *user.PasswordInfo) (*passwordInfoModel, error) {
 		pw.PasswordExpires = p.PasswordExpires.Unix()
 	}
 
+	if len(p.History) > 0 {
+		history := make([]string, len(p.History))
+		for i, h := range p.History {
+			history[i] = string(h)
+		}
+		b, err := json.Marshal(history)
+		if err != nil {
+			return nil, err
+		}
+		pw.History = string(b)
+	}
+
 	return &pw, nil
 }
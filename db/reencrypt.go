@@ -0,0 +1,175 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/repo"
+)
+
+// keySetRecordID stands in for the ID of the signing keys, which are kept
+// in a single row without one.
+const keySetRecordID = "signing-keys"
+
+// encryptedColumn is a column of the database holding data encrypted with
+// the key secrets.
+type encryptedColumn struct {
+	table    string
+	idColumn string
+	column   string
+
+	// keySet is set for the signing keys, which may be in the deprecated
+	// format.
+	keySet bool
+}
+
+var encryptedColumns = []encryptedColumn{
+	{table: keyTableName, column: "value", keySet: true},
+	{table: totpInfoTableName, idColumn: "user_id", column: "secret"},
+	{table: webhookSubscriptionTableName, idColumn: "id", column: "secret"},
+}
+
+type encryptedRow struct {
+	ID    string `db:"id"`
+	Value []byte `db:"value"`
+}
+
+func NewReencrypter(dbm *gorp.DbMap, secrets ...[]byte) (*Reencrypter, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("must provide at least one key secret")
+	}
+	for i, secret := range secrets {
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("key secret %d: expected 32-byte secret", i)
+		}
+	}
+	return &Reencrypter{dbMap: dbm, secrets: secrets}, nil
+}
+
+// Reencrypter re-encrypts the signing keys, TOTP secrets and webhook
+// secrets with the first of its key secrets, in the current format.
+type Reencrypter struct {
+	dbMap   *gorp.DbMap
+	secrets [][]byte
+}
+
+// Reencrypt re-encrypts all stale rows in a single transaction, so that
+// either all of them or none are re-encrypted. Rows which none of the
+// secrets decrypts are reported and left as they are.
+func (r *Reencrypter) Reencrypt(dryRun bool) (repo.ReencryptionReport, error) {
+	tx, err := r.dbMap.Begin()
+	if err != nil {
+		return repo.ReencryptionReport{}, err
+	}
+
+	report, err := r.reencrypt(tx, dryRun)
+	if err != nil || dryRun {
+		rollback(tx)
+		return report, err
+	}
+	if err := tx.Commit(); err != nil {
+		return repo.ReencryptionReport{}, err
+	}
+	return report, nil
+}
+
+func (r *Reencrypter) reencrypt(tx *gorp.Transaction, dryRun bool) (repo.ReencryptionReport, error) {
+	var report repo.ReencryptionReport
+	for _, col := range encryptedColumns {
+		rows, err := r.selectRows(tx, col)
+		if err != nil {
+			return repo.ReencryptionReport{}, err
+		}
+
+		for _, row := range rows {
+			report.Records++
+			stale, plaintext := r.decrypt(col, row)
+			if stale == nil {
+				continue
+			}
+			report.Stale = append(report.Stale, *stale)
+			if dryRun || stale.Secret < 0 {
+				continue
+			}
+
+			v, err := pcrypto.Encrypt(plaintext, r.secrets[0])
+			if err != nil {
+				return repo.ReencryptionReport{}, err
+			}
+			if err := r.updateRow(tx, col, row, v); err != nil {
+				return repo.ReencryptionReport{}, err
+			}
+			report.Reencrypted++
+		}
+	}
+	return report, nil
+}
+
+// decrypt returns the plaintext of a row, along with a StaleRecord unless
+// the row is encrypted with the active secret in the current format.
+func (r *Reencrypter) decrypt(col encryptedColumn, row encryptedRow) (*repo.StaleRecord, []byte) {
+	formats := []bool{false}
+	if col.keySet {
+		formats = append(formats, true)
+	}
+
+	stale := &repo.StaleRecord{Table: col.table, ID: row.ID, Secret: -1}
+	for _, oldFormat := range formats {
+		for i, secret := range r.secrets {
+			var plaintext []byte
+			var err error
+			if col.keySet {
+				_, plaintext, err = decryptPrivateKeySet(row.Value, secret, oldFormat)
+			} else {
+				plaintext, err = pcrypto.Decrypt(row.Value, secret)
+			}
+			if err != nil {
+				continue
+			}
+
+			if i == 0 && !oldFormat {
+				return nil, plaintext
+			}
+			stale.Secret = i
+			stale.DeprecatedFormat = oldFormat
+			return stale, plaintext
+		}
+	}
+	return stale, nil
+}
+
+func (r *Reencrypter) selectRows(tx *gorp.Transaction, col encryptedColumn) ([]encryptedRow, error) {
+	id := "''"
+	if col.idColumn != "" {
+		id = pq.QuoteIdentifier(col.idColumn)
+	}
+	q := fmt.Sprintf("SELECT %s AS id, %s AS value FROM %s",
+		id, pq.QuoteIdentifier(col.column), pq.QuoteIdentifier(col.table))
+
+	var rows []encryptedRow
+	if _, err := tx.Select(&rows, q); err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if col.keySet {
+			rows[i].ID = keySetRecordID
+		}
+	}
+	return rows, nil
+}
+
+func (r *Reencrypter) updateRow(tx *gorp.Transaction, col encryptedColumn, row encryptedRow, value []byte) error {
+	// The signing keys are found by their old value, having no ID.
+	where, arg := col.column, interface{}(row.Value)
+	if col.idColumn != "" {
+		where, arg = col.idColumn, row.ID
+	}
+	q := fmt.Sprintf("UPDATE %s SET %s = $1 WHERE %s = $2", pq.QuoteIdentifier(col.table),
+		pq.QuoteIdentifier(col.column), pq.QuoteIdentifier(where))
+	_, err := tx.Exec(q, value, arg)
+	return err
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/key"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/webhook"
+)
+
+func TestReencrypt(t *testing.T) {
+	dbMap, err := NewConnection(Config{DSN: "sqlite3://:memory:"})
+	if err != nil {
+		t.Fatalf("unable to connect: %v", err)
+	}
+	if _, err := MigrateToLatest(dbMap); err != nil {
+		t.Fatalf("unable to migrate: %v", err)
+	}
+
+	active := bytes.Repeat([]byte{'1'}, 32)
+	old := bytes.Repeat([]byte{'2'}, 32)
+	lost := bytes.Repeat([]byte{'3'}, 32)
+
+	// The signing keys were written in the deprecated format with the old
+	// secret, a TOTP secret with the old secret and another with a secret
+	// that has since been lost. The webhook secret is up to date.
+	k, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	ks := key.NewPrivateKeySet([]*key.PrivateKey{k}, time.Now().Add(time.Hour))
+	kRepo, err := NewPrivateKeySetRepo(dbMap, true, old)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := kRepo.Set(ks); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, tt := range []struct {
+		userID string
+		secret []byte
+	}{
+		{"ID-1", old},
+		{"ID-2", lost},
+	} {
+		r, err := NewTOTPInfoRepo(dbMap, tt.secret)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := r.Create(nil, user.TOTPInfo{UserID: tt.userID, Secret: []byte("12345678901234567890")}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	whRepo, err := NewWebhookSubscriptionRepo(dbMap, active)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sub := webhook.Subscription{ID: "WH-1", URL: "https://example.com", Secret: []byte("secret"), Events: []webhook.EventType{webhook.EventUserCreated}}
+	if err := whRepo.Create(nil, sub); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r, err := NewReencrypter(dbMap, active, old)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stale := []repo.StaleRecord{
+		{Table: keyTableName, ID: keySetRecordID, Secret: 1, DeprecatedFormat: true},
+		{Table: totpInfoTableName, ID: "ID-1", Secret: 1},
+		{Table: totpInfoTableName, ID: "ID-2", Secret: -1},
+	}
+	tests := []struct {
+		dryRun bool
+		want   repo.ReencryptionReport
+	}{
+		{true, repo.ReencryptionReport{Records: 4, Stale: stale}},
+		{false, repo.ReencryptionReport{Records: 4, Stale: stale, Reencrypted: 2}},
+		{false, repo.ReencryptionReport{Records: 4, Stale: stale[2:]}},
+	}
+	for i, tt := range tests {
+		got, err := r.Reencrypt(tt.dryRun)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !reflect.DeepEqual(tt.want, got) {
+			t.Errorf("case %d: want=%#v, got=%#v", i, tt.want, got)
+		}
+	}
+
+	// The re-encrypted rows can be read with the active secret alone.
+	kRepo, err = NewPrivateKeySetRepo(dbMap, false, active)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := kRepo.Get()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.(*key.PrivateKeySet).Active().ID() != k.ID() {
+		t.Errorf("want key %s, got %s", k.ID(), got.(*key.PrivateKeySet).Active().ID())
+	}
+
+	// So can the keys by repos still writing the deprecated format.
+	kRepo, err = NewPrivateKeySetRepo(dbMap, true, active)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := kRepo.Get(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	totpRepo, err := NewTOTPInfoRepo(dbMap, active)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := totpRepo.Get(nil, "ID-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
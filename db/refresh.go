@@ -10,7 +10,9 @@ import (
 
 	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/dex/refresh"
+	"github.com/coreos/dex/repo"
 	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -167,14 +169,43 @@ func (r *refreshTokenRepo) Revoke(userID, token string) error {
 	return nil
 }
 
-func (r *refreshTokenRepo) executor(tx *gorp.Transaction) gorp.SqlExecutor {
+func (r *refreshTokenRepo) ClientsWithRefreshTokens(userID string) ([]string, error) {
+	qt := pq.QuoteIdentifier(refreshTokenTableName)
+	var clientIDs []string
+	_, err := r.dbMap.Select(&clientIDs,
+		fmt.Sprintf("SELECT DISTINCT client_id FROM %s WHERE user_id = $1 ORDER BY client_id", qt), userID)
+	if err != nil {
+		return nil, err
+	}
+	return clientIDs, nil
+}
+
+func (r *refreshTokenRepo) RevokeTokensForClient(userID, clientID string) error {
+	qt := pq.QuoteIdentifier(refreshTokenTableName)
+	_, err := r.dbMap.Exec(
+		fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND client_id = $2", qt), userID, clientID)
+	return err
+}
+
+func (r *refreshTokenRepo) RevokeTokensForUser(tx repo.Transaction, userID string) error {
+	qt := pq.QuoteIdentifier(refreshTokenTableName)
+	_, err := r.executor(tx).Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qt), userID)
+	return err
+}
+
+func (r *refreshTokenRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
 	if tx == nil {
 		return r.dbMap
 	}
-	return tx
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
 }
 
-func (r *refreshTokenRepo) get(tx *gorp.Transaction, tokenID int64) (*refreshTokenModel, error) {
+func (r *refreshTokenRepo) get(tx repo.Transaction, tokenID int64) (*refreshTokenModel, error) {
 	ex := r.executor(tx)
 	result, err := ex.Get(refreshTokenModel{}, tokenID)
 	if err != nil {
@@ -14,7 +14,9 @@ import (
 	"github.com/lib/pq"
 
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
 	"github.com/coreos/go-oidc/oidc"
 )
 
@@ -45,6 +47,15 @@ type sessionModel struct {
 	Register    bool   `db:"register"`
 	Nonce       string `db:"nonce"`
 	Scope       string `db:"scope"`
+	AMR         string `db:"amr"`
+	LinkToken   string `db:"link_token"`
+}
+
+// sessionIdentity is the remote identity of a session along with its
+// profile, kept together in the identity column.
+type sessionIdentity struct {
+	oidc.Identity
+	user.Profile
 }
 
 func (s *sessionModel) session() (*session.Session, error) {
@@ -53,7 +64,7 @@ func (s *sessionModel) session() (*session.Session, error) {
 		return nil, err
 	}
 
-	var ident oidc.Identity
+	var ident sessionIdentity
 	if err = json.Unmarshal([]byte(s.Identity), &ident); err != nil {
 		return nil, err
 	}
@@ -69,12 +80,15 @@ func (s *sessionModel) session() (*session.Session, error) {
 		ClientID:    s.ClientID,
 		ClientState: s.ClientState,
 		RedirectURL: *ru,
-		Identity:    ident,
+		Identity:    ident.Identity,
+		Profile:     ident.Profile,
 		ConnectorID: s.ConnectorID,
 		UserID:      s.UserID,
 		Register:    s.Register,
 		Nonce:       s.Nonce,
 		Scope:       strings.Fields(s.Scope),
+		AMR:         strings.Fields(s.AMR),
+		LinkToken:   s.LinkToken,
 	}
 
 	if s.CreatedAt != 0 {
@@ -89,7 +103,7 @@ func (s *sessionModel) session() (*session.Session, error) {
 }
 
 func newSessionModel(s *session.Session) (*sessionModel, error) {
-	b, err := json.Marshal(s.Identity)
+	b, err := json.Marshal(sessionIdentity{Identity: s.Identity, Profile: s.Profile})
 	if err != nil {
 		return nil, err
 	}
@@ -106,6 +120,8 @@ func newSessionModel(s *session.Session) (*sessionModel, error) {
 		Register:    s.Register,
 		Nonce:       s.Nonce,
 		Scope:       strings.Join(s.Scope, " "),
+		AMR:         strings.Join(s.AMR, " "),
+		LinkToken:   s.LinkToken,
 	}
 
 	if !s.CreatedAt.IsZero() {
@@ -182,6 +198,24 @@ func (r *SessionRepo) Update(s session.Session) error {
 	return nil
 }
 
+func (r *SessionRepo) DeleteByUserID(tx repo.Transaction, userID string) error {
+	qt := pq.QuoteIdentifier(sessionTableName)
+	_, err := r.executor(tx).Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qt), userID)
+	return err
+}
+
+func (r *SessionRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
 func (r *SessionRepo) purge() error {
 	qt := pq.QuoteIdentifier(sessionTableName)
 	q := fmt.Sprintf("DELETE FROM %s WHERE expires_at < $1 OR state = $2", qt)
@@ -196,6 +230,7 @@ func (r *SessionRepo) purge() error {
 			return nil
 		}
 		d = fmt.Sprintf("%d", n)
+		gcPurgedRows.Add(float64(n), sessionTableName)
 	}
 
 	log.Infof("Deleted %s stale row(s) from %s table", d, sessionTableName)
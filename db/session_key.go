@@ -78,7 +78,7 @@ func (r *SessionKeyRepo) Pop(key string) (string, error) {
 	}
 
 	qt := pq.QuoteIdentifier(sessionKeyTableName)
-	q := fmt.Sprintf("UPDATE %s SET stale=$1 WHERE key=$2 AND stale=$3", qt)
+	q := fmt.Sprintf(`UPDATE %s SET stale=$1 WHERE "key"=$2 AND stale=$3`, qt)
 	res, err := r.dbMap.Exec(q, true, key, false)
 	if err != nil {
 		return "", err
@@ -108,6 +108,7 @@ func (r *SessionKeyRepo) purge() error {
 			return nil
 		}
 		d = fmt.Sprintf("%d", n)
+		gcPurgedRows.Add(float64(n), sessionKeyTableName)
 	}
 
 	log.Infof("Deleted %s stale row(s) from %s table", d, sessionKeyTableName)
@@ -0,0 +1,207 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	totpInfoTableName = "user_totp"
+)
+
+var (
+	ErrorCannotDecryptTOTPSecret = errors.New("Cannot Decrypt TOTP Secret")
+)
+
+func init() {
+	register(table{
+		name:    totpInfoTableName,
+		model:   totpInfoModel{},
+		autoinc: false,
+		pkey:    []string{"user_id"},
+	})
+}
+
+// totpInfoModel is the stored form of a user.TOTPInfo. The secret is
+// encrypted with the active key secret.
+type totpInfoModel struct {
+	UserID        string `db:"user_id"`
+	Secret        []byte `db:"secret"`
+	Confirmed     bool   `db:"confirmed"`
+	RecoveryCodes string `db:"recovery_codes"`
+	LastCounter   int64  `db:"last_counter"`
+	CreatedAt     int64  `db:"created_at"`
+}
+
+func NewTOTPInfoRepo(dbm *gorp.DbMap, secrets ...[]byte) (user.TOTPInfoRepo, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("must provide at least one key secret")
+	}
+	for i, secret := range secrets {
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("key secret %d: expected 32-byte secret", i)
+		}
+	}
+
+	r := &totpInfoRepo{
+		dbMap:   dbm,
+		secrets: secrets,
+	}
+
+	return r, nil
+}
+
+type totpInfoRepo struct {
+	dbMap   *gorp.DbMap
+	secrets [][]byte
+}
+
+// Get returns the TOTPInfo of the given user. Within a transaction the row
+// is locked until the transaction ends, so that a code checked against it
+// cannot be accepted by another worker at the same time.
+func (r *totpInfoRepo) Get(tx repo.Transaction, userID string) (user.TOTPInfo, error) {
+	q := fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1", pq.QuoteIdentifier(totpInfoTableName))
+	if tx != nil {
+		q += forUpdate(r.dbMap)
+	}
+
+	var tm totpInfoModel
+	if err := r.executor(tx).SelectOne(&tm, q, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return user.TOTPInfo{}, user.ErrorNotFound
+		}
+		return user.TOTPInfo{}, err
+	}
+
+	return r.totpInfo(&tm)
+}
+
+func (r *totpInfoRepo) Create(tx repo.Transaction, info user.TOTPInfo) error {
+	if info.UserID == "" {
+		return user.ErrorInvalidID
+	}
+
+	_, err := r.Get(tx, info.UserID)
+	if err == nil {
+		return user.ErrorDuplicateID
+	}
+	if err != user.ErrorNotFound {
+		return err
+	}
+
+	tm, err := r.newTOTPInfoModel(info)
+	if err != nil {
+		return err
+	}
+	return r.executor(tx).Insert(tm)
+}
+
+func (r *totpInfoRepo) Update(tx repo.Transaction, info user.TOTPInfo) error {
+	if info.UserID == "" {
+		return user.ErrorInvalidID
+	}
+
+	// make sure this user is enrolled already
+	if _, err := r.Get(tx, info.UserID); err != nil {
+		return err
+	}
+
+	tm, err := r.newTOTPInfoModel(info)
+	if err != nil {
+		return err
+	}
+	_, err = r.executor(tx).Update(tm)
+	return err
+}
+
+func (r *totpInfoRepo) Delete(tx repo.Transaction, userID string) error {
+	n, err := r.executor(tx).Delete(&totpInfoModel{UserID: userID})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return user.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *totpInfoRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
+func (r *totpInfoRepo) newTOTPInfoModel(info user.TOTPInfo) (*totpInfoModel, error) {
+	secret, err := pcrypto.Encrypt(info.Secret, r.secrets[0])
+	if err != nil {
+		return nil, err
+	}
+
+	rc, err := json.Marshal(info.RecoveryCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	tm := totpInfoModel{
+		UserID:        info.UserID,
+		Secret:        secret,
+		Confirmed:     info.Confirmed,
+		RecoveryCodes: string(rc),
+		LastCounter:   info.LastCounter,
+	}
+
+	if !info.CreatedAt.IsZero() {
+		tm.CreatedAt = info.CreatedAt.Unix()
+	}
+
+	return &tm, nil
+}
+
+func (r *totpInfoRepo) totpInfo(tm *totpInfoModel) (user.TOTPInfo, error) {
+	info := user.TOTPInfo{
+		UserID:      tm.UserID,
+		Confirmed:   tm.Confirmed,
+		LastCounter: tm.LastCounter,
+	}
+
+	// Secrets written before a key rotation are still encrypted with one
+	// of the older key secrets.
+	var err error
+	for _, secret := range r.secrets {
+		info.Secret, err = pcrypto.Decrypt(tm.Secret, secret)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return user.TOTPInfo{}, ErrorCannotDecryptTOTPSecret
+	}
+
+	if tm.RecoveryCodes != "" {
+		if err := json.Unmarshal([]byte(tm.RecoveryCodes), &info.RecoveryCodes); err != nil {
+			return user.TOTPInfo{}, err
+		}
+	}
+
+	if tm.CreatedAt != 0 {
+		info.CreatedAt = time.Unix(tm.CreatedAt, 0).UTC()
+	}
+
+	return info, nil
+}
@@ -0,0 +1,61 @@
+package db
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coreos/dex/user"
+)
+
+func TestNewTOTPInfoRepoInvalidKey(t *testing.T) {
+	_, err := NewTOTPInfoRepo(nil, []byte("sharks"))
+	if err == nil {
+		t.Errorf("Expected non-nil error for key secret that was not 32 bytes")
+	}
+	_, err = NewTOTPInfoRepo(nil)
+	if err == nil {
+		t.Fatalf("Expected non-nil error when creating repo with no key secrets")
+	}
+}
+
+func TestTOTPInfoModelKeyRotation(t *testing.T) {
+	oldKey := []byte("abcdefghijklmnopqrstuvwxyz123456")
+	newKey := []byte("654321zyxwvutsrqponmlkjihgfedcba")
+	info := user.TOTPInfo{
+		UserID: "ID-1",
+		Secret: []byte("12345678901234567890"),
+	}
+
+	r, err := NewTOTPInfoRepo(nil, oldKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tm, err := r.(*totpInfoRepo).newTOTPInfoModel(info)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(tm.Secret, info.Secret) {
+		t.Errorf("secret stored in plaintext")
+	}
+
+	// A secret encrypted with the old key remains readable after rotation.
+	r, err = NewTOTPInfoRepo(nil, newKey, oldKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := r.(*totpInfoRepo).totpInfo(tm)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got.Secret, info.Secret) {
+		t.Errorf("want secret=%q, got %q", info.Secret, got.Secret)
+	}
+
+	r, err = NewTOTPInfoRepo(nil, newKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.(*totpInfoRepo).totpInfo(tm); err != ErrorCannotDecryptTOTPSecret {
+		t.Errorf("want=%v, got=%v", ErrorCannotDecryptTOTPSecret, err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/go-gorp/gorp"
@@ -125,6 +126,34 @@ func (r *userRepo) Disable(tx repo.Transaction, userID string, disable bool) err
 	return nil
 }
 
+func (r *userRepo) Delete(tx repo.Transaction, userID string) error {
+	if userID == "" {
+		return user.ErrorInvalidID
+	}
+
+	ex := r.executor(tx)
+	qt := pq.QuoteIdentifier(remoteIdentityMappingTableName)
+	if _, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qt), userID); err != nil {
+		return err
+	}
+
+	qt = pq.QuoteIdentifier(userTableName)
+	result, err := ex.Exec(fmt.Sprintf("DELETE FROM %s WHERE id = $1", qt), userID)
+	if err != nil {
+		return err
+	}
+
+	ct, err := result.RowsAffected()
+	switch {
+	case err != nil:
+		return err
+	case ct == 0:
+		return user.ErrorNotFound
+	}
+
+	return nil
+}
+
 func (r *userRepo) GetByEmail(tx repo.Transaction, email string) (user.User, error) {
 	return r.getByEmail(tx, email)
 }
@@ -291,11 +320,12 @@ func (r *userRepo) List(tx repo.Transaction, filter user.UserFilter, maxResults
 	ex := r.executor(tx)
 
 	qt := pq.QuoteIdentifier(userTableName)
+	where, args := userFilterWhere(filter, likeEscape(r.dbMap))
 
 	// Ask for one more than needed so we know if there's more results, and
 	// hence, whether a nextPageToken is necessary.
-	ums, err := ex.Select(&userModel{},
-		fmt.Sprintf("SELECT * FROM %s ORDER BY email LIMIT $1 OFFSET $2 ", qt), maxResults+1, offset)
+	q := fmt.Sprintf("SELECT * FROM %s%s ORDER BY email LIMIT $%d OFFSET $%d", qt, where, len(args)+1, len(args)+2)
+	ums, err := ex.Select(&userModel{}, q, append(args, maxResults+1, offset)...)
 	if err != nil {
 		return nil, "", err
 	}
@@ -338,6 +368,56 @@ func (r *userRepo) List(tx repo.Transaction, filter user.UserFilter, maxResults
 
 }
 
+// userFilterWhere returns the WHERE clause selecting the users matching
+// filter, if any, along with its arguments. escape is appended to LIKE
+// conditions.
+func userFilterWhere(filter user.UserFilter, escape string) (string, []interface{}) {
+	var conds []string
+	var args []interface{}
+	add := func(cond string, arg interface{}) {
+		args = append(args, arg)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.EmailPrefix != "" {
+		add("lower(email) LIKE $%d"+escape, escapeLike(strings.ToLower(filter.EmailPrefix))+"%")
+	}
+	if filter.DisplayName != "" {
+		add("lower(display_name) LIKE $%d"+escape, "%"+escapeLike(strings.ToLower(filter.DisplayName))+"%")
+	}
+	if filter.Admin != nil {
+		add("admin = $%d", *filter.Admin)
+	}
+	if filter.Disabled != nil {
+		add("disabled = $%d", *filter.Disabled)
+	}
+	if filter.EmailVerified != nil {
+		add("email_verified = $%d", *filter.EmailVerified)
+	}
+	if filter.ConnectorID != "" {
+		qt := pq.QuoteIdentifier(remoteIdentityMappingTableName)
+		add("id IN (SELECT user_id FROM "+qt+" WHERE connector_id = $%d)", filter.ConnectorID)
+	}
+	if !filter.CreatedAfter.IsZero() {
+		add("created_at >= $%d", filter.CreatedAfter.Unix())
+	}
+	if !filter.CreatedBefore.IsZero() {
+		add("created_at < $%d", filter.CreatedBefore.Unix())
+	}
+
+	if len(conds) == 0 {
+		return "", nil
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern in s.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
 func (r *userRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
 	if tx == nil {
 		return r.dbMap
@@ -442,6 +522,10 @@ type userModel struct {
 	Email         string `db:"email"`
 	EmailVerified bool   `db:"email_verified"`
 	DisplayName   string `db:"display_name"`
+	GivenName     string `db:"given_name"`
+	FamilyName    string `db:"family_name"`
+	Picture       string `db:"picture"`
+	Locale        string `db:"locale"`
 	Disabled      bool   `db:"disabled"`
 	Admin         bool   `db:"admin"`
 	CreatedAt     int64  `db:"created_at"`
@@ -451,6 +535,10 @@ func (u *userModel) user() (user.User, error) {
 	usr := user.User{
 		ID:            u.ID,
 		DisplayName:   u.DisplayName,
+		GivenName:     u.GivenName,
+		FamilyName:    u.FamilyName,
+		Picture:       u.Picture,
+		Locale:        u.Locale,
 		Email:         u.Email,
 		EmailVerified: u.EmailVerified,
 		Admin:         u.Admin,
@@ -468,6 +556,10 @@ func newUserModel(u *user.User) (*userModel, error) {
 	um := userModel{
 		ID:            u.ID,
 		DisplayName:   u.DisplayName,
+		GivenName:     u.GivenName,
+		FamilyName:    u.FamilyName,
+		Picture:       u.Picture,
+		Locale:        u.Locale,
 		Email:         u.Email,
 		EmailVerified: u.EmailVerified,
 		Admin:         u.Admin,
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	webAuthnCredentialTableName = "user_webauthn_credential"
+)
+
+func init() {
+	register(table{
+		name:    webAuthnCredentialTableName,
+		model:   webAuthnCredentialModel{},
+		autoinc: false,
+		pkey:    []string{"id"},
+	})
+}
+
+// webAuthnCredentialModel is the stored form of a user.WebAuthnCredential.
+// The credential ID is stored base64url encoded.
+type webAuthnCredentialModel struct {
+	ID        string `db:"id"`
+	UserID    string `db:"user_id"`
+	PublicKey []byte `db:"public_key"`
+	SignCount int64  `db:"sign_count"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+func NewWebAuthnCredentialRepo(dbm *gorp.DbMap) user.WebAuthnCredentialRepo {
+	return &webAuthnCredentialRepo{
+		dbMap: dbm,
+	}
+}
+
+type webAuthnCredentialRepo struct {
+	dbMap *gorp.DbMap
+}
+
+func (r *webAuthnCredentialRepo) Get(tx repo.Transaction, id []byte) (user.WebAuthnCredential, error) {
+	m, err := r.get(tx, id)
+	if err != nil {
+		return user.WebAuthnCredential{}, err
+	}
+	return m.credential()
+}
+
+func (r *webAuthnCredentialRepo) GetByUserID(tx repo.Transaction, userID string) ([]user.WebAuthnCredential, error) {
+	qt := pq.QuoteIdentifier(webAuthnCredentialTableName)
+	ms, err := r.executor(tx).Select(&webAuthnCredentialModel{},
+		fmt.Sprintf("SELECT * FROM %s WHERE user_id = $1 ORDER BY created_at, id", qt), userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var creds []user.WebAuthnCredential
+	for _, m := range ms {
+		wm, ok := m.(*webAuthnCredentialModel)
+		if !ok {
+			log.Errorf("expected webAuthnCredentialModel but found %v", reflect.TypeOf(m))
+			return nil, errors.New("unrecognized model")
+		}
+		cred, err := wm.credential()
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	return creds, nil
+}
+
+func (r *webAuthnCredentialRepo) Create(tx repo.Transaction, cred user.WebAuthnCredential) error {
+	if len(cred.ID) == 0 || cred.UserID == "" {
+		return user.ErrorInvalidID
+	}
+
+	_, err := r.get(tx, cred.ID)
+	if err == nil {
+		return user.ErrorDuplicateID
+	}
+	if err != user.ErrorNotFound {
+		return err
+	}
+
+	return r.executor(tx).Insert(newWebAuthnCredentialModel(cred))
+}
+
+func (r *webAuthnCredentialRepo) Update(tx repo.Transaction, cred user.WebAuthnCredential) error {
+	m, err := r.get(tx, cred.ID)
+	if err != nil {
+		return err
+	}
+
+	m.SignCount = int64(cred.SignCount)
+	_, err = r.executor(tx).Update(m)
+	return err
+}
+
+func (r *webAuthnCredentialRepo) DeleteByUserID(tx repo.Transaction, userID string) error {
+	qt := pq.QuoteIdentifier(webAuthnCredentialTableName)
+	_, err := r.executor(tx).Exec(fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", qt), userID)
+	return err
+}
+
+func (r *webAuthnCredentialRepo) get(tx repo.Transaction, id []byte) (*webAuthnCredentialModel, error) {
+	m, err := r.executor(tx).Get(webAuthnCredentialModel{}, encodeCredentialID(id))
+	if err != nil {
+		return nil, err
+	}
+
+	if m == nil {
+		return nil, user.ErrorNotFound
+	}
+
+	wm, ok := m.(*webAuthnCredentialModel)
+	if !ok {
+		log.Errorf("expected webAuthnCredentialModel but found %v", reflect.TypeOf(m))
+		return nil, errors.New("unrecognized model")
+	}
+	return wm, nil
+}
+
+func (r *webAuthnCredentialRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
+func newWebAuthnCredentialModel(cred user.WebAuthnCredential) *webAuthnCredentialModel {
+	m := webAuthnCredentialModel{
+		ID:        encodeCredentialID(cred.ID),
+		UserID:    cred.UserID,
+		PublicKey: cred.PublicKey,
+		SignCount: int64(cred.SignCount),
+	}
+
+	if !cred.CreatedAt.IsZero() {
+		m.CreatedAt = cred.CreatedAt.Unix()
+	}
+
+	return &m
+}
+
+func (m *webAuthnCredentialModel) credential() (user.WebAuthnCredential, error) {
+	id, err := base64.RawURLEncoding.DecodeString(m.ID)
+	if err != nil {
+		return user.WebAuthnCredential{}, err
+	}
+
+	cred := user.WebAuthnCredential{
+		ID:        id,
+		UserID:    m.UserID,
+		PublicKey: m.PublicKey,
+		SignCount: uint32(m.SignCount),
+	}
+
+	if m.CreatedAt != 0 {
+		cred.CreatedAt = time.Unix(m.CreatedAt, 0).UTC()
+	}
+
+	return cred, nil
+}
+
+func encodeCredentialID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
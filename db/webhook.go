@@ -0,0 +1,332 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-gorp/gorp"
+	"github.com/lib/pq"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/webhook"
+)
+
+const (
+	webhookSubscriptionTableName = "webhook_subscription"
+	webhookDeliveryTableName     = "webhook_delivery"
+)
+
+var (
+	ErrorCannotDecryptWebhookSecret = errors.New("Cannot Decrypt Webhook Secret")
+)
+
+func init() {
+	register(table{
+		name:    webhookSubscriptionTableName,
+		model:   webhookSubscriptionModel{},
+		autoinc: false,
+		pkey:    []string{"id"},
+	})
+
+	register(table{
+		name:    webhookDeliveryTableName,
+		model:   webhookDeliveryModel{},
+		autoinc: true,
+		pkey:    []string{"id"},
+	})
+}
+
+// webhookSubscriptionModel is the stored form of a webhook.Subscription. The
+// secret is encrypted with the active key secret.
+type webhookSubscriptionModel struct {
+	ID        string `db:"id"`
+	URL       string `db:"url"`
+	Secret    []byte `db:"secret"`
+	Events    string `db:"events"`
+	CreatedAt int64  `db:"created_at"`
+}
+
+type webhookDeliveryModel struct {
+	ID             int64  `db:"id"`
+	SubscriptionID string `db:"subscription_id"`
+	EventID        string `db:"event_id"`
+	EventType      string `db:"event_type"`
+	Payload        string `db:"payload"`
+	Attempts       int    `db:"attempts"`
+	NextAttempt    int64  `db:"next_attempt"`
+	LastError      string `db:"last_error"`
+}
+
+func NewWebhookSubscriptionRepo(dbm *gorp.DbMap, secrets ...[]byte) (webhook.SubscriptionRepo, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("must provide at least one key secret")
+	}
+	for i, secret := range secrets {
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("key secret %d: expected 32-byte secret", i)
+		}
+	}
+
+	return &webhookSubscriptionRepo{
+		dbMap:   dbm,
+		secrets: secrets,
+	}, nil
+}
+
+type webhookSubscriptionRepo struct {
+	dbMap   *gorp.DbMap
+	secrets [][]byte
+}
+
+func (r *webhookSubscriptionRepo) Create(tx repo.Transaction, s webhook.Subscription) error {
+	if err := s.Valid(); err != nil {
+		return err
+	}
+
+	_, err := r.Get(tx, s.ID)
+	if err == nil {
+		return webhook.ErrorDuplicateID
+	}
+	if err != webhook.ErrorNotFound {
+		return err
+	}
+
+	m, err := r.newModel(s)
+	if err != nil {
+		return err
+	}
+	return r.executor(tx).Insert(m)
+}
+
+func (r *webhookSubscriptionRepo) Get(tx repo.Transaction, id string) (webhook.Subscription, error) {
+	m, err := r.executor(tx).Get(webhookSubscriptionModel{}, id)
+	if err != nil {
+		return webhook.Subscription{}, err
+	}
+	if m == nil {
+		return webhook.Subscription{}, webhook.ErrorNotFound
+	}
+
+	sm, ok := m.(*webhookSubscriptionModel)
+	if !ok {
+		log.Errorf("expected webhookSubscriptionModel but found %v", reflect.TypeOf(m))
+		return webhook.Subscription{}, errors.New("unrecognized model")
+	}
+	return r.subscription(sm)
+}
+
+func (r *webhookSubscriptionRepo) Delete(tx repo.Transaction, id string) error {
+	n, err := r.executor(tx).Delete(&webhookSubscriptionModel{ID: id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return webhook.ErrorNotFound
+	}
+	return nil
+}
+
+func (r *webhookSubscriptionRepo) All(tx repo.Transaction) ([]webhook.Subscription, error) {
+	qt := pq.QuoteIdentifier(webhookSubscriptionTableName)
+	q := fmt.Sprintf("SELECT * FROM %s ORDER BY created_at, id", qt)
+	ms, err := r.executor(tx).Select(&webhookSubscriptionModel{}, q)
+	if err != nil {
+		return nil, err
+	}
+
+	subs := make([]webhook.Subscription, 0, len(ms))
+	for _, m := range ms {
+		sm, ok := m.(*webhookSubscriptionModel)
+		if !ok {
+			log.Errorf("expected webhookSubscriptionModel but found %v", reflect.TypeOf(m))
+			return nil, errors.New("unrecognized model")
+		}
+		s, err := r.subscription(sm)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, s)
+	}
+	return subs, nil
+}
+
+func (r *webhookSubscriptionRepo) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return r.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
+func (r *webhookSubscriptionRepo) newModel(s webhook.Subscription) (*webhookSubscriptionModel, error) {
+	secret, err := pcrypto.Encrypt(s.Secret, r.secrets[0])
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := json.Marshal(s.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhookSubscriptionModel{
+		ID:        s.ID,
+		URL:       s.URL,
+		Secret:    secret,
+		Events:    string(events),
+		CreatedAt: s.CreatedAt.Unix(),
+	}, nil
+}
+
+func (r *webhookSubscriptionRepo) subscription(m *webhookSubscriptionModel) (webhook.Subscription, error) {
+	s := webhook.Subscription{
+		ID:        m.ID,
+		URL:       m.URL,
+		CreatedAt: time.Unix(m.CreatedAt, 0).UTC(),
+	}
+
+	// Secrets written before a key rotation are still encrypted with one
+	// of the older key secrets.
+	var err error
+	for _, secret := range r.secrets {
+		s.Secret, err = pcrypto.Decrypt(m.Secret, secret)
+		if err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return webhook.Subscription{}, ErrorCannotDecryptWebhookSecret
+	}
+
+	if err := json.Unmarshal([]byte(m.Events), &s.Events); err != nil {
+		return webhook.Subscription{}, err
+	}
+	return s, nil
+}
+
+func NewWebhookDeliveryQueue(dbm *gorp.DbMap) webhook.DeliveryQueue {
+	return &webhookDeliveryQueue{
+		dbMap: dbm,
+	}
+}
+
+type webhookDeliveryQueue struct {
+	dbMap *gorp.DbMap
+}
+
+func (q *webhookDeliveryQueue) Enqueue(tx repo.Transaction, ds []webhook.Delivery) error {
+	ex := q.executor(tx)
+	for _, d := range ds {
+		if err := ex.Insert(newWebhookDeliveryModel(d)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (q *webhookDeliveryQueue) Claim(now time.Time, lease time.Duration, max int) ([]webhook.Delivery, error) {
+	tx, err := q.dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock the due rows, so that concurrent senders cannot claim them too.
+	qt := pq.QuoteIdentifier(webhookDeliveryTableName)
+	qs := fmt.Sprintf("SELECT * FROM %s WHERE next_attempt <= $1 ORDER BY id LIMIT $2%s", qt, forUpdate(q.dbMap))
+	ms, err := tx.Select(&webhookDeliveryModel{}, qs, now.Unix(), max)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	ds := make([]webhook.Delivery, 0, len(ms))
+	for _, m := range ms {
+		dm, ok := m.(*webhookDeliveryModel)
+		if !ok {
+			tx.Rollback()
+			log.Errorf("expected webhookDeliveryModel but found %v", reflect.TypeOf(m))
+			return nil, errors.New("unrecognized model")
+		}
+		ds = append(ds, dm.delivery())
+
+		dm.NextAttempt = now.Add(lease).Unix()
+		if _, err := tx.Update(dm); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+func (q *webhookDeliveryQueue) Retry(d webhook.Delivery) error {
+	n, err := q.dbMap.Update(newWebhookDeliveryModel(d))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return webhook.ErrorDeliveryNotFound
+	}
+	return nil
+}
+
+func (q *webhookDeliveryQueue) Remove(id int64) error {
+	n, err := q.dbMap.Delete(&webhookDeliveryModel{ID: id})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return webhook.ErrorDeliveryNotFound
+	}
+	return nil
+}
+
+func (q *webhookDeliveryQueue) executor(tx repo.Transaction) gorp.SqlExecutor {
+	if tx == nil {
+		return q.dbMap
+	}
+
+	gorpTx, ok := tx.(*gorp.Transaction)
+	if !ok {
+		panic("wrong kind of transaction passed to a DB repo")
+	}
+	return gorpTx
+}
+
+func newWebhookDeliveryModel(d webhook.Delivery) *webhookDeliveryModel {
+	return &webhookDeliveryModel{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Payload:        string(d.Payload),
+		Attempts:       d.Attempts,
+		NextAttempt:    d.NextAttempt.Unix(),
+		LastError:      d.LastError,
+	}
+}
+
+func (m *webhookDeliveryModel) delivery() webhook.Delivery {
+	return webhook.Delivery{
+		ID:             m.ID,
+		SubscriptionID: m.SubscriptionID,
+		EventID:        m.EventID,
+		EventType:      webhook.EventType(m.EventType),
+		Payload:        []byte(m.Payload),
+		Attempts:       m.Attempts,
+		NextAttempt:    time.Unix(m.NextAttempt, 0).UTC(),
+		LastError:      m.LastError,
+	}
+}
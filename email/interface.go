@@ -8,6 +8,8 @@ import (
 	"io"
 	"os"
 	"strings"
+
+	"github.com/coreos/dex/pkg/metrics"
 )
 
 const (
@@ -21,6 +23,10 @@ var (
 
 func init() {
 	RegisterEmailerConfigType(FakeEmailerType, func() EmailerConfig { return &FakeEmailerConfig{} })
+
+	metrics.NewCounterFunc("dex_email_send_failures_total", "Emails which could not be sent.", func() float64 {
+		return float64(counterEmailSendErr.Value())
+	})
 }
 
 // Emailer is an object that sends emails.
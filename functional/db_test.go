@@ -116,6 +116,37 @@ func TestDBSessionRepoCreateUpdate(t *testing.T) {
 	}
 }
 
+func TestDBSessionRepoDeleteByUserID(t *testing.T) {
+	r := db.NewSessionRepo(connect(t))
+
+	now := time.Now().Round(time.Second).UTC()
+	for _, ses := range []session.Session{
+		{ID: "AAA", UserID: "user-foo"},
+		{ID: "BBB", UserID: "user-foo"},
+		{ID: "CCC", UserID: "user-bar"},
+	} {
+		ses.State = session.SessionStateIdentified
+		ses.CreatedAt = now
+		ses.ExpiresAt = now.Add(time.Minute)
+		if err := r.Create(ses); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if err := r.DeleteByUserID(nil, "user-foo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for _, id := range []string{"AAA", "BBB"} {
+		if _, err := r.Get(id); err == nil {
+			t.Errorf("Session %s: expected non-nil error", id)
+		}
+	}
+	if _, err := r.Get("CCC"); err != nil {
+		t.Errorf("Session of other user: unexpected error: %v", err)
+	}
+}
+
 func TestDBPrivateKeySetRepoSetGet(t *testing.T) {
 	s1 := []byte("xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx")
 	s2 := []byte("oooooooooooooooooooooooooooooooo")
@@ -533,3 +564,79 @@ func TestDBRefreshRepoRevoke(t *testing.T) {
 		}
 	}
 }
+
+func TestDBRefreshRepoClientsWithRefreshTokens(t *testing.T) {
+	r := db.NewRefreshTokenRepo(connect(t))
+
+	for _, pair := range [][2]string{
+		{"user-foo", "client-foo"},
+		{"user-foo", "client-bar"},
+		{"user-foo", "client-foo"},
+		{"user-bar", "client-baz"},
+	} {
+		if _, err := r.Create(pair[0], pair[1]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	got, err := r.ClientsWithRefreshTokens("user-foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"client-bar", "client-foo"}, got); diff != "" {
+		t.Errorf("ClientsWithRefreshTokens: Compare(want, got): %v", diff)
+	}
+
+	if err := r.RevokeTokensForClient("user-foo", "client-foo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	got, err = r.ClientsWithRefreshTokens("user-foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"client-bar"}, got); diff != "" {
+		t.Errorf("ClientsWithRefreshTokens after revoke: Compare(want, got): %v", diff)
+	}
+
+	got, err = r.ClientsWithRefreshTokens("user-bar")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"client-baz"}, got); diff != "" {
+		t.Errorf("ClientsWithRefreshTokens of other user: Compare(want, got): %v", diff)
+	}
+}
+
+func TestDBRefreshRepoRevokeTokensForUser(t *testing.T) {
+	r := db.NewRefreshTokenRepo(connect(t))
+
+	for _, pair := range [][2]string{
+		{"user-foo", "client-foo"},
+		{"user-foo", "client-bar"},
+		{"user-bar", "client-baz"},
+	} {
+		if _, err := r.Create(pair[0], pair[1]); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if err := r.RevokeTokensForUser(nil, "user-foo"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	got, err := r.ClientsWithRefreshTokens("user-foo")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ClientsWithRefreshTokens after revoke: want none, got %v", got)
+	}
+
+	got, err = r.ClientsWithRefreshTokens("user-bar")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"client-baz"}, got); diff != "" {
+		t.Errorf("ClientsWithRefreshTokens of other user: Compare(want, got): %v", diff)
+	}
+}
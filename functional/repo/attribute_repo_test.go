@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"os"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+)
+
+var makeTestAttributeRepo func() user.AttributeRepo
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestAttributeRepo = user.NewAttributeRepo
+	} else {
+		makeTestAttributeRepo = func() user.AttributeRepo {
+			return db.NewAttributeRepo(initDB(dsn))
+		}
+	}
+}
+
+func TestGetSetAttributes(t *testing.T) {
+	repo := makeTestAttributeRepo()
+
+	got, err := repo.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("want no attributes, got %v", got)
+	}
+
+	tests := []user.Attributes{
+		{
+			"department": "Engineering",
+			"level":      float64(3),
+			"manager":    true,
+			"roles":      []string{"dev", "ops"},
+		},
+		{
+			"department": "Sales",
+		},
+		{},
+	}
+
+	for i, attrs := range tests {
+		if err := repo.Set(nil, "ID-1", attrs); err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+
+		got, err := repo.Get(nil, "ID-1")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(attrs, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+
+		other, err := repo.Get(nil, "ID-2")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if len(other) != 0 {
+			t.Errorf("case %d: want no attributes of other user, got %v", i, other)
+		}
+	}
+}
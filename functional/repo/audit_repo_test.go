@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/db"
+)
+
+var makeTestAuditEventRepo func() audit.EventRepo
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestAuditEventRepo = func() audit.EventRepo {
+			return audit.NewEventRepo()
+		}
+	} else {
+		makeTestAuditEventRepo = func() audit.EventRepo {
+			return db.NewAuditEventRepo(initDB(dsn))
+		}
+	}
+}
+
+func TestAuditEventRepoList(t *testing.T) {
+	repo := makeTestAuditEventRepo()
+
+	if err := repo.Record(audit.Event{Type: "bogus", Time: time.Unix(1234567890, 0).UTC()}); err != audit.ErrorInvalidEventType {
+		t.Fatalf("want=%v, got=%v", audit.ErrorInvalidEventType, err)
+	}
+
+	events := []audit.Event{
+		{
+			Type:        audit.EventLogin,
+			Time:        time.Unix(1234567890, 0).UTC(),
+			UserID:      "ID-1",
+			ClientID:    "client-1",
+			ConnectorID: "local",
+			RemoteAddr:  "192.0.2.1",
+		},
+		{
+			Type:       audit.EventLoginFailed,
+			Time:       time.Unix(1234567900, 0).UTC(),
+			UserID:     "ID-2",
+			RemoteAddr: "192.0.2.2",
+			Details:    "wrong password",
+		},
+		{
+			Type:     audit.EventTokenIssued,
+			Time:     time.Unix(1234567910, 0).UTC(),
+			UserID:   "ID-1",
+			ClientID: "client-1",
+		},
+		{
+			Type:     audit.EventLogin,
+			Time:     time.Unix(1234567920, 0).UTC(),
+			UserID:   "ID-2",
+			ClientID: "client-2",
+		},
+	}
+	for i, e := range events {
+		if err := repo.Record(e); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+	}
+
+	// IDs are assigned by the repo, so compare everything else.
+	strip := func(es []audit.Event) []audit.Event {
+		for i := range es {
+			es[i].ID = 0
+		}
+		return es
+	}
+
+	tests := []struct {
+		filter     audit.EventFilter
+		maxResults int
+		want       [][]audit.Event
+	}{
+		{
+			maxResults: 10,
+			want: [][]audit.Event{
+				{events[3], events[2], events[1], events[0]},
+			},
+		},
+		{
+			maxResults: 3,
+			want: [][]audit.Event{
+				{events[3], events[2], events[1]},
+				{events[0]},
+			},
+		},
+		{
+			filter:     audit.EventFilter{UserID: "ID-1"},
+			maxResults: 1,
+			want: [][]audit.Event{
+				{events[2]},
+				{events[0]},
+			},
+		},
+		{
+			filter:     audit.EventFilter{ClientID: "client-1", Type: audit.EventLogin},
+			maxResults: 10,
+			want: [][]audit.Event{
+				{events[0]},
+			},
+		},
+		{
+			filter: audit.EventFilter{
+				After:  time.Unix(1234567900, 0).UTC(),
+				Before: time.Unix(1234567920, 0).UTC(),
+			},
+			maxResults: 10,
+			want: [][]audit.Event{
+				{events[2], events[1]},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		var got [][]audit.Event
+		var tok string
+		for {
+			es, next, err := repo.List(tt.filter, tt.maxResults, tok)
+			if err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			got = append(got, strip(es))
+			if next == "" {
+				break
+			}
+			if len(got) > len(events) {
+				t.Fatalf("case %d: too many pages", i)
+			}
+			tok = next
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+
+	if _, _, err := repo.List(audit.EventFilter{}, 0, ""); err != audit.ErrorInvalidMaxResults {
+		t.Errorf("want=%v, got=%v", audit.ErrorInvalidMaxResults, err)
+	}
+	if _, _, err := repo.List(audit.EventFilter{}, 10, "garbage"); err != audit.ErrorInvalidNextPageToken {
+		t.Errorf("want=%v, got=%v", audit.ErrorInvalidNextPageToken, err)
+	}
+}
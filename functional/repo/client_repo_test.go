@@ -5,11 +5,14 @@ import (
 	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
 
 	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
 )
 
 var makeTestClientIdentityRepoFromClients func(clients []oidc.ClientIdentity) client.ClientIdentityRepo
@@ -25,7 +28,8 @@ var (
 				RedirectURIs: []url.URL{
 					url.URL{
 						Scheme: "https",
-						Host:   "client1.example.com/callback",
+						Host:   "client1.example.com",
+						Path:   "/callback",
 					},
 				},
 			},
@@ -39,7 +43,8 @@ var (
 				RedirectURIs: []url.URL{
 					url.URL{
 						Scheme: "https",
-						Host:   "client2.example.com/callback",
+						Host:   "client2.example.com",
+						Path:   "/callback",
 					},
 				},
 			},
@@ -49,7 +54,9 @@ var (
 
 func init() {
 	dsn := os.Getenv("DEX_TEST_DSN")
-	if dsn == "" {
+	if kubernetesEnabled() {
+		makeTestClientIdentityRepoFromClients = makeTestClientIdentityRepoKubernetes
+	} else if dsn == "" {
 		makeTestClientIdentityRepoFromClients = makeTestClientIdentityRepoMem
 	} else {
 		makeTestClientIdentityRepoFromClients = makeTestClientIdentityRepoDB(dsn)
@@ -73,6 +80,14 @@ func makeTestClientIdentityRepoDB(dsn string) func([]oidc.ClientIdentity) client
 
 }
 
+func makeTestClientIdentityRepoKubernetes(clients []oidc.ClientIdentity) client.ClientIdentityRepo {
+	repo, err := kubernetes.NewClientIdentityRepoFromClients(initKubernetes(), clients)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to add clients: %v", err))
+	}
+	return repo
+}
+
 func makeTestClientIdentityRepo() client.ClientIdentityRepo {
 	return makeTestClientIdentityRepoFromClients(testClients)
 }
@@ -151,3 +166,223 @@ func TestGetSetAdminClient(t *testing.T) {
 
 	}
 }
+
+func TestGetSetClaimMapping(t *testing.T) {
+	tests := []struct {
+		cid     string
+		set     client.ClaimMapping
+		want    client.ClaimMapping
+		wantErr error
+	}{
+		{
+			cid:  "client1",
+			set:  client.ClaimMapping{"department": "dept", "groups": "roles"},
+			want: client.ClaimMapping{"department": "dept", "groups": "roles"},
+		},
+		{
+			cid:  "client1",
+			set:  client.ClaimMapping{},
+			want: client.ClaimMapping{},
+		},
+		{
+			cid:     "client3",
+			set:     client.ClaimMapping{"department": "dept"},
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestClientIdentityRepo()
+		got, err := repo.ClaimMapping(tt.cid)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if len(got) != 0 {
+			t.Errorf("case %d: want empty claim mapping, got %v", i, got)
+		}
+
+		err = repo.SetClaimMapping(tt.cid, tt.set)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		got, err = repo.ClaimMapping(tt.cid)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	tests := []struct {
+		cid         string
+		gracePeriod time.Duration
+
+		wantOldValid bool
+		wantErr      error
+	}{
+		{
+			cid:          "client1",
+			gracePeriod:  time.Hour,
+			wantOldValid: true,
+		},
+		{
+			cid:          "client2",
+			gracePeriod:  0,
+			wantOldValid: false,
+		},
+		{
+			cid:     "client3",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestClientIdentityRepo()
+
+		var oldSecret string
+		for _, c := range testClients {
+			if c.Credentials.ID == tt.cid {
+				oldSecret = c.Credentials.Secret
+			}
+		}
+
+		creds, err := repo.RotateSecret(tt.cid, tt.gracePeriod)
+		if tt.wantErr != nil {
+			if err != tt.wantErr {
+				t.Errorf("case %d: want=%v, got=%v", i, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		ok, err := repo.Authenticate(*creds)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if !ok {
+			t.Errorf("case %d: new secret not accepted", i)
+		}
+
+		ok, err = repo.Authenticate(oidc.ClientCredentials{ID: tt.cid, Secret: oldSecret})
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if ok != tt.wantOldValid {
+			t.Errorf("case %d: old secret valid: want=%v, got=%v", i, tt.wantOldValid, ok)
+		}
+	}
+}
+
+func TestUpdateClient(t *testing.T) {
+	newMeta := oidc.ClientMetadata{
+		RedirectURIs: []url.URL{
+			url.URL{Scheme: "https", Host: "new.example.com", Path: "/callback"},
+			url.URL{Scheme: "https", Host: "new.example.com", Path: "/other"},
+		},
+	}
+
+	tests := []struct {
+		cid     string
+		wantErr error
+	}{
+		{
+			cid: "client1",
+		},
+		{
+			cid:     "client3",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestClientIdentityRepo()
+
+		err := repo.Update(tt.cid, newMeta)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		meta, err := repo.Metadata(tt.cid)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(newMeta, *meta); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+
+		// Other clients must not be affected.
+		meta, err = repo.Metadata("client2")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(testClients[1].Metadata, *meta); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestDeleteClient(t *testing.T) {
+	tests := []struct {
+		cid     string
+		wantErr error
+	}{
+		{
+			cid: "client1",
+		},
+		{
+			cid:     "client3",
+			wantErr: client.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestClientIdentityRepo()
+
+		err := repo.Delete(tt.cid)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		if _, err := repo.Metadata(tt.cid); err != client.ErrorNotFound {
+			t.Errorf("case %d: want=%v, got=%v", i, client.ErrorNotFound, err)
+		}
+
+		ok, err := repo.Authenticate(testClients[0].Credentials)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if ok {
+			t.Errorf("case %d: deleted client still authenticates", i)
+		}
+
+		if err := repo.SetDexAdmin(tt.cid, true); err != client.ErrorNotFound {
+			t.Errorf("case %d: want=%v, got=%v", i, client.ErrorNotFound, err)
+		}
+
+		cis, err := repo.All()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if len(cis) != 1 || cis[0].Credentials.ID != "client2" {
+			t.Errorf("case %d: unexpected remaining clients: %v", i, cis)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
 )
 
 type connectorConfigRepoFactory func(cfgs []connector.ConnectorConfig) connector.ConnectorConfigRepo
@@ -14,7 +15,9 @@ type connectorConfigRepoFactory func(cfgs []connector.ConnectorConfig) connector
 var makeTestConnectorConfigRepoFromConfigs connectorConfigRepoFactory
 
 func init() {
-	if dsn := os.Getenv("DEX_TEST_DSN"); dsn == "" {
+	if dsn := os.Getenv("DEX_TEST_DSN"); kubernetesEnabled() {
+		makeTestConnectorConfigRepoFromConfigs = makeTestConnectorConfigRepoKubernetes
+	} else if dsn == "" {
 		makeTestConnectorConfigRepoFromConfigs = connector.NewConnectorConfigRepoFromConfigs
 	} else {
 		makeTestConnectorConfigRepoFromConfigs = makeTestConnectorConfigRepoMem(dsn)
@@ -33,6 +36,14 @@ func makeTestConnectorConfigRepoMem(dsn string) connectorConfigRepoFactory {
 	}
 }
 
+func makeTestConnectorConfigRepoKubernetes(cfgs []connector.ConnectorConfig) connector.ConnectorConfigRepo {
+	repo := kubernetes.NewConnectorConfigRepo(initKubernetes())
+	if err := repo.Set(cfgs); err != nil {
+		panic(fmt.Sprintf("Unable to set connector configs: %v", err))
+	}
+	return repo
+}
+
 func TestConnectorConfigRepoGetByID(t *testing.T) {
 	tests := []struct {
 		cfgs []connector.ConnectorConfig
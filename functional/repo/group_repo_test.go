@@ -0,0 +1,262 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+)
+
+var makeTestGroupRepo func() user.GroupRepo
+
+var (
+	testGroups = []user.Group{
+		{
+			ID:          "group-1",
+			DisplayName: "Engineering",
+			Members:     []string{"ID-1", "ID-2"},
+			CreatedAt:   time.Unix(1234567890, 0).UTC(),
+		},
+		{
+			ID:          "group-2",
+			DisplayName: "Admins",
+			Members:     []string{"ID-1"},
+			CreatedAt:   time.Unix(1234567891, 0).UTC(),
+		},
+	}
+)
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestGroupRepo = makeTestGroupRepoMem
+	} else {
+		makeTestGroupRepo = makeTestGroupRepoDB(dsn)
+	}
+}
+
+func makeTestGroupRepoMem() user.GroupRepo {
+	repo := user.NewGroupRepo()
+	for _, g := range testGroups {
+		if err := repo.Create(nil, g); err != nil {
+			panic(fmt.Sprintf("Unable to add Groups: %v", err))
+		}
+	}
+	return repo
+}
+
+func makeTestGroupRepoDB(dsn string) func() user.GroupRepo {
+	return func() user.GroupRepo {
+		c := initDB(dsn)
+
+		repo := db.NewGroupRepo(c)
+		for _, g := range testGroups {
+			if err := repo.Create(nil, g); err != nil {
+				panic(fmt.Sprintf("Unable to add Groups: %v", err))
+			}
+		}
+		return repo
+	}
+}
+
+func TestCreateGroup(t *testing.T) {
+	tests := []struct {
+		group user.Group
+		want  user.Group
+		err   error
+	}{
+		{
+			group: user.Group{
+				ID:          "group-3",
+				DisplayName: "Sales",
+				Members:     []string{"ID-2", "ID-1", "ID-2"},
+				CreatedAt:   time.Unix(1234567892, 0).UTC(),
+			},
+			want: user.Group{
+				ID:          "group-3",
+				DisplayName: "Sales",
+				Members:     []string{"ID-1", "ID-2"},
+				CreatedAt:   time.Unix(1234567892, 0).UTC(),
+			},
+		},
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Sales"},
+			err:   user.ErrorDuplicateID,
+		},
+		{
+			group: user.Group{ID: "group-3", DisplayName: "Engineering"},
+			err:   user.ErrorDuplicateGroupName,
+		},
+		{
+			group: user.Group{DisplayName: "Sales"},
+			err:   user.ErrorInvalidID,
+		},
+		{
+			group: user.Group{ID: "group-3"},
+			err:   user.ErrorInvalidGroupName,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestGroupRepo()
+		err := repo.Create(nil, tt.group)
+		if err != tt.err {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.group.ID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	tests := []struct {
+		group user.Group
+		want  user.Group
+		err   error
+	}{
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Developers", Members: []string{"ID-3"}},
+			want: user.Group{
+				ID:          "group-1",
+				DisplayName: "Developers",
+				Members:     []string{"ID-3"},
+				CreatedAt:   time.Unix(1234567890, 0).UTC(),
+			},
+		},
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Engineering"},
+			want: user.Group{
+				ID:          "group-1",
+				DisplayName: "Engineering",
+				CreatedAt:   time.Unix(1234567890, 0).UTC(),
+			},
+		},
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Admins"},
+			err:   user.ErrorDuplicateGroupName,
+		},
+		{
+			group: user.Group{ID: "group-1"},
+			err:   user.ErrorInvalidGroupName,
+		},
+		{
+			group: user.Group{ID: "group-3", DisplayName: "Sales"},
+			err:   user.ErrorGroupNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestGroupRepo()
+		err := repo.Update(nil, tt.group)
+		if err != tt.err {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.group.ID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestListGroups(t *testing.T) {
+	repo := makeTestGroupRepo()
+	got, err := repo.List(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []user.Group{testGroups[1], testGroups[0]}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+}
+
+func TestGetGroupsByMember(t *testing.T) {
+	tests := []struct {
+		userID string
+		want   []string
+	}{
+		{userID: "ID-1", want: []string{"group-2", "group-1"}},
+		{userID: "ID-2", want: []string{"group-1"}},
+		{userID: "ID-3", want: []string{}},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestGroupRepo()
+		groups, err := repo.GetByMember(nil, tt.userID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		got := []string{}
+		for _, g := range groups {
+			got = append(got, g.ID)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestDeleteGroup(t *testing.T) {
+	repo := makeTestGroupRepo()
+	if err := repo.Delete(nil, "group-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := repo.Get(nil, "group-1"); err != user.ErrorGroupNotFound {
+		t.Errorf("want err=%v, got %v", user.ErrorGroupNotFound, err)
+	}
+	if err := repo.Delete(nil, "group-1"); err != user.ErrorGroupNotFound {
+		t.Errorf("want err=%v, got %v", user.ErrorGroupNotFound, err)
+	}
+
+	groups, err := repo.GetByMember(nil, "ID-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("want no groups of deleted group's members, got %v", groups)
+	}
+}
+
+func TestRemoveGroupMember(t *testing.T) {
+	repo := makeTestGroupRepo()
+	if err := repo.RemoveMember(nil, "ID-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, err := repo.List(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []user.Group{testGroups[1], testGroups[0]}
+	want[0].Members = nil
+	want[1].Members = []string{"ID-2"}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+}
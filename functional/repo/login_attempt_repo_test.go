@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+)
+
+var makeTestLoginAttemptRepo func() user.LoginAttemptRepo
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestLoginAttemptRepo = func() user.LoginAttemptRepo {
+			return user.NewLoginAttemptRepo()
+		}
+	} else {
+		makeTestLoginAttemptRepo = func() user.LoginAttemptRepo {
+			return db.NewLoginAttemptRepo(initDB(dsn))
+		}
+	}
+}
+
+func TestLoginAttemptRepo(t *testing.T) {
+	repo := makeTestLoginAttemptRepo()
+
+	if _, err := repo.Get(nil, "user:ID-1"); err != user.ErrorNotFound {
+		t.Fatalf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+	if err := repo.Put(nil, user.LoginAttempts{}); err != user.ErrorInvalidID {
+		t.Fatalf("want=%v, got=%v", user.ErrorInvalidID, err)
+	}
+
+	tests := []user.LoginAttempts{
+		{
+			Key:         "user:ID-1",
+			Failures:    1,
+			LastFailure: time.Unix(1234567890, 0).UTC(),
+		},
+		// Put replaces an existing entry.
+		{
+			Key:         "user:ID-1",
+			Failures:    5,
+			LastFailure: time.Unix(1234567900, 0).UTC(),
+			LockedUntil: time.Unix(1234567960, 0).UTC(),
+		},
+	}
+
+	for i, tt := range tests {
+		if err := repo.Put(nil, tt); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		got, err := repo.Get(nil, tt.Key)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(tt, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+
+	if err := repo.Delete(nil, "user:ID-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Delete(nil, "user:ID-1"); err != user.ErrorNotFound {
+		t.Fatalf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+}
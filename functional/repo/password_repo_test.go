@@ -9,6 +9,7 @@ import (
 	"github.com/kylelemons/godebug/pretty"
 
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
 	"github.com/coreos/dex/user"
 )
 
@@ -25,7 +26,9 @@ var (
 
 func init() {
 	dsn := os.Getenv("DEX_TEST_DSN")
-	if dsn == "" {
+	if kubernetesEnabled() {
+		makeTestPasswordInfoRepo = makeTestPasswordInfoRepoKubernetes
+	} else if dsn == "" {
 		makeTestPasswordInfoRepo = makeTestPasswordInfoRepoMem
 	} else {
 		makeTestPasswordInfoRepo = makeTestPasswordInfoRepoDB(dsn)
@@ -36,6 +39,14 @@ func makeTestPasswordInfoRepoMem() user.PasswordInfoRepo {
 	return user.NewPasswordInfoRepoFromPasswordInfos(testPWs)
 }
 
+func makeTestPasswordInfoRepoKubernetes() user.PasswordInfoRepo {
+	repo := kubernetes.NewPasswordInfoRepo(initKubernetes())
+	if err := user.LoadPasswordInfos(repo, testPWs); err != nil {
+		panic(fmt.Sprintf("Unable to add passwordInfos: %v", err))
+	}
+	return repo
+}
+
 func makeTestPasswordInfoRepoDB(dsn string) func() user.PasswordInfoRepo {
 	return func() user.PasswordInfoRepo {
 		c := initDB(dsn)
@@ -139,6 +150,14 @@ func TestUpdatePasswordInfo(t *testing.T) {
 			},
 			err: user.ErrorInvalidPassword,
 		},
+		{
+			pw: user.PasswordInfo{
+				UserID:   "ID-1",
+				Password: user.Password("new_pass"),
+				History:  []user.Password{user.Password("hi."), user.Password("older")},
+			},
+			err: nil,
+		},
 	}
 
 	for i, tt := range tests {
@@ -165,3 +184,31 @@ func TestUpdatePasswordInfo(t *testing.T) {
 		}
 	}
 }
+
+func TestDeletePasswordInfo(t *testing.T) {
+	tests := []struct {
+		id  string
+		err error
+	}{
+		{
+			id: "ID-1",
+		},
+		{
+			id:  "ID-2",
+			err: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestPasswordInfoRepo()
+		err := repo.Delete(nil, tt.id)
+		if err != tt.err {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.err, err)
+			continue
+		}
+
+		if _, err := repo.Get(nil, tt.id); err != user.ErrorNotFound {
+			t.Errorf("case %d: want user.ErrorNotFound, got %q", i, err)
+		}
+	}
+}
@@ -5,11 +5,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/go-oidc/oidc"
 	"github.com/jonboulle/clockwork"
 	"github.com/kylelemons/godebug/pretty"
 
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
+	"github.com/coreos/dex/redis"
 	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
 )
 
 var makeTestSessionRepo func() (session.SessionRepo, clockwork.FakeClock)
@@ -17,7 +21,13 @@ var makeTestSessionKeyRepo func() (session.SessionKeyRepo, clockwork.FakeClock)
 
 func init() {
 	dsn := os.Getenv("DEX_TEST_DSN")
-	if dsn == "" {
+	if redisEnabled() {
+		makeTestSessionRepo = makeTestSessionRepoRedis
+		makeTestSessionKeyRepo = makeTestSessionKeyRepoRedis
+	} else if kubernetesEnabled() {
+		makeTestSessionRepo = makeTestSessionRepoKubernetes
+		makeTestSessionKeyRepo = makeTestSessionKeyRepoKubernetes
+	} else if dsn == "" {
 		makeTestSessionRepo = makeTestSessionRepoMem
 		makeTestSessionKeyRepo = makeTestSessionKeyRepoMem
 	} else {
@@ -39,6 +49,16 @@ func makeTestSessionRepoDB(dsn string) func() (session.SessionRepo, clockwork.Fa
 	}
 }
 
+func makeTestSessionRepoKubernetes() (session.SessionRepo, clockwork.FakeClock) {
+	fc := clockwork.NewFakeClock()
+	return kubernetes.NewSessionRepoWithClock(initKubernetes(), fc), fc
+}
+
+func makeTestSessionRepoRedis() (session.SessionRepo, clockwork.FakeClock) {
+	fc := clockwork.NewFakeClock()
+	return redis.NewSessionRepoWithClock(initRedis(fc), fc), fc
+}
+
 func makeTestSessionKeyRepoMem() (session.SessionKeyRepo, clockwork.FakeClock) {
 	fc := clockwork.NewFakeClock()
 	return session.NewSessionKeyRepoWithClock(fc), fc
@@ -52,6 +72,16 @@ func makeTestSessionKeyRepoDB(dsn string) func() (session.SessionKeyRepo, clockw
 	}
 }
 
+func makeTestSessionKeyRepoKubernetes() (session.SessionKeyRepo, clockwork.FakeClock) {
+	fc := clockwork.NewFakeClock()
+	return kubernetes.NewSessionKeyRepoWithClock(initKubernetes(), fc), fc
+}
+
+func makeTestSessionKeyRepoRedis() (session.SessionKeyRepo, clockwork.FakeClock) {
+	fc := clockwork.NewFakeClock()
+	return redis.NewSessionKeyRepoWithClock(initRedis(fc), fc), fc
+}
+
 func TestSessionKeyRepoPopNoExist(t *testing.T) {
 	r, _ := makeTestSessionKeyRepo()
 
@@ -126,6 +156,13 @@ func TestSessionRepoCreateGet(t *testing.T) {
 			ExpiresAt:   time.Unix(789, 0).UTC(),
 			Nonce:       "oncenay",
 		},
+		session.Session{
+			ID:          "012",
+			ClientState: "blargh",
+			ExpiresAt:   time.Unix(12, 0).UTC(),
+			Identity:    oidc.Identity{ID: "YYY", Email: "elroy@example.com"},
+			Profile:     user.Profile{GivenName: "Elroy", Picture: "https://example.com/elroy.png"},
+		},
 	}
 
 	for i, tt := range tests {
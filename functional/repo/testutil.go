@@ -2,11 +2,53 @@ package repo
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
+	"github.com/coreos/dex/kubernetes/fake"
+	"github.com/coreos/dex/redis"
+	redisfake "github.com/coreos/dex/redis/fake"
 	"github.com/go-gorp/gorp"
+	goredis "github.com/go-redis/redis"
+	"github.com/jonboulle/clockwork"
 )
 
+// kubernetesEnabled reports whether the Kubernetes repos should be tested,
+// against a fake API server, instead of the in-memory or database ones.
+func kubernetesEnabled() bool {
+	return os.Getenv("DEX_TEST_KUBERNETES") != ""
+}
+
+func initKubernetes() *kubernetes.Client {
+	srv := fake.NewServer()
+	c, err := kubernetes.NewClient(kubernetes.Config{Server: srv.URL, Namespace: "dex"})
+	if err != nil {
+		panic(fmt.Sprintf("Unable to create Kubernetes client: %v", err))
+	}
+	return c
+}
+
+// redisEnabled reports whether the Redis session repos should be tested,
+// against a fake Redis server, instead of the in-memory or database ones.
+func redisEnabled() bool {
+	return os.Getenv("DEX_TEST_REDIS") != ""
+}
+
+// initRedis connects to a new fake Redis server, which expires keys
+// according to the given clock.
+func initRedis(clock clockwork.Clock) *goredis.Client {
+	srv, err := redisfake.NewServerWithClock(clock)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to start Redis server: %v", err))
+	}
+	c, err := redis.NewConnection(redis.Config{URL: srv.URL()})
+	if err != nil {
+		panic(fmt.Sprintf("Unable to connect to Redis: %v", err))
+	}
+	return c
+}
+
 func initDB(dsn string) *gorp.DbMap {
 	c, err := db.NewConnection(db.Config{DSN: dsn})
 	if err != nil {
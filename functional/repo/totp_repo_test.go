@@ -0,0 +1,189 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+)
+
+var makeTestTOTPInfoRepo func() user.TOTPInfoRepo
+
+var (
+	testTOTPInfos = []user.TOTPInfo{
+		{
+			UserID:        "ID-1",
+			Secret:        []byte("12345678901234567890"),
+			Confirmed:     true,
+			RecoveryCodes: [][]byte{[]byte("hash-1"), []byte("hash-2")},
+			LastCounter:   41152263,
+			CreatedAt:     time.Unix(1234567890, 0).UTC(),
+		},
+	}
+
+	testTOTPKeySecret = []byte("abcdefghijklmnopqrstuvwxyz123456")
+)
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestTOTPInfoRepo = makeTestTOTPInfoRepoMem
+	} else {
+		makeTestTOTPInfoRepo = makeTestTOTPInfoRepoDB(dsn)
+	}
+}
+
+func makeTestTOTPInfoRepoMem() user.TOTPInfoRepo {
+	repo := user.NewTOTPInfoRepo()
+	for _, info := range testTOTPInfos {
+		if err := repo.Create(nil, info); err != nil {
+			panic(fmt.Sprintf("Unable to add TOTPInfos: %v", err))
+		}
+	}
+	return repo
+}
+
+func makeTestTOTPInfoRepoDB(dsn string) func() user.TOTPInfoRepo {
+	return func() user.TOTPInfoRepo {
+		c := initDB(dsn)
+
+		repo, err := db.NewTOTPInfoRepo(c, testTOTPKeySecret)
+		if err != nil {
+			panic(fmt.Sprintf("Unable to create TOTPInfoRepo: %v", err))
+		}
+		for _, info := range testTOTPInfos {
+			if err := repo.Create(nil, info); err != nil {
+				panic(fmt.Sprintf("Unable to add TOTPInfos: %v", err))
+			}
+		}
+		return repo
+	}
+}
+
+func TestCreateTOTPInfo(t *testing.T) {
+	tests := []struct {
+		info user.TOTPInfo
+		err  error
+	}{
+		{
+			info: user.TOTPInfo{
+				UserID:    "ID-2",
+				Secret:    []byte("09876543210987654321"),
+				CreatedAt: time.Now().Round(time.Second).UTC(),
+			},
+		},
+		{
+			info: user.TOTPInfo{
+				UserID: "ID-1",
+				Secret: []byte("09876543210987654321"),
+			},
+			err: user.ErrorDuplicateID,
+		},
+		{
+			info: user.TOTPInfo{
+				Secret: []byte("09876543210987654321"),
+			},
+			err: user.ErrorInvalidID,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestTOTPInfoRepo()
+		err := repo.Create(nil, tt.info)
+		if err != tt.err {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.info.UserID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.info, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestUpdateTOTPInfo(t *testing.T) {
+	tests := []struct {
+		info user.TOTPInfo
+		err  error
+	}{
+		{
+			info: user.TOTPInfo{
+				UserID:        "ID-1",
+				Secret:        []byte("12345678901234567890"),
+				Confirmed:     true,
+				RecoveryCodes: [][]byte{[]byte("hash-2")},
+				LastCounter:   41152264,
+				CreatedAt:     time.Unix(1234567890, 0).UTC(),
+			},
+		},
+		{
+			info: user.TOTPInfo{
+				UserID: "ID-2",
+				Secret: []byte("09876543210987654321"),
+			},
+			err: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestTOTPInfoRepo()
+		err := repo.Update(nil, tt.info)
+		if err != tt.err {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.info.UserID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.info, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestDeleteTOTPInfo(t *testing.T) {
+	tests := []struct {
+		userID string
+		err    error
+	}{
+		{
+			userID: "ID-1",
+		},
+		{
+			userID: "ID-2",
+			err:    user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestTOTPInfoRepo()
+		err := repo.Delete(nil, tt.userID)
+		if err != tt.err {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.err, err)
+			continue
+		}
+
+		if _, err := repo.Get(nil, tt.userID); err != user.ErrorNotFound {
+			t.Errorf("case %d: want=%v, got=%v", i, user.ErrorNotFound, err)
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/kylelemons/godebug/pretty"
 
 	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/kubernetes"
 	"github.com/coreos/dex/user"
 )
 
@@ -49,7 +50,9 @@ var (
 
 func init() {
 	dsn := os.Getenv("DEX_TEST_DSN")
-	if dsn == "" {
+	if kubernetesEnabled() {
+		makeTestUserRepoFromUsers = makeTestUserRepoKubernetes
+	} else if dsn == "" {
 		makeTestUserRepoFromUsers = makeTestUserRepoMem
 	} else {
 		makeTestUserRepoFromUsers = makeTestUserRepoDB(dsn)
@@ -73,6 +76,14 @@ func makeTestUserRepoDB(dsn string) func([]user.UserWithRemoteIdentities) user.U
 
 }
 
+func makeTestUserRepoKubernetes(users []user.UserWithRemoteIdentities) user.UserRepo {
+	repo, err := kubernetes.NewUserRepoFromUsers(initKubernetes(), users)
+	if err != nil {
+		panic(fmt.Sprintf("Unable to add users: %v", err))
+	}
+	return repo
+}
+
 func makeTestUserRepo() user.UserRepo {
 	return makeTestUserRepoFromUsers(testUsers)
 }
@@ -182,6 +193,18 @@ func TestUpdateUser(t *testing.T) {
 			},
 			err: nil,
 		},
+		{
+			// Update the profile claims.
+			user: user.User{
+				ID:         "ID-1",
+				Email:      "Email-1@example.com",
+				GivenName:  "One",
+				FamilyName: "Example",
+				Picture:    "https://example.com/1.png",
+				Locale:     "en-US",
+			},
+			err: nil,
+		},
 		{
 			// No email.
 			user: user.User{
@@ -229,6 +252,21 @@ func TestUpdateUser(t *testing.T) {
 				t.Errorf("case %d: Compare(want, got) = %v", i,
 					diff)
 			}
+
+			gotUser, err = repo.GetByEmail(nil, tt.user.Email)
+			if err != nil || gotUser.ID != tt.user.ID {
+				t.Errorf("case %d: want user %s by email, got %#v, %v", i, tt.user.ID, gotUser, err)
+			}
+
+			// A changed email address is free again.
+			if tt.user.Email != "Email-1@example.com" {
+				if _, err := repo.GetByEmail(nil, "Email-1@example.com"); err != user.ErrorNotFound {
+					t.Errorf("case %d: want user.ErrorNotFound by old email, got %v", i, err)
+				}
+				if err := repo.Create(nil, user.User{ID: "ID-3", Email: "Email-1@example.com"}); err != nil {
+					t.Errorf("case %d: unexpected error creating user with old email: %v", i, err)
+				}
+			}
 		}
 	}
 }
@@ -288,6 +326,61 @@ func TestDisableUser(t *testing.T) {
 	}
 }
 
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		id  string
+		err error
+	}{
+		{
+			id: "ID-1",
+		},
+		{
+			id:  "NO SUCH ID",
+			err: user.ErrorNotFound,
+		},
+		{
+			id:  "",
+			err: user.ErrorInvalidID,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestUserRepo()
+		err := repo.Delete(nil, tt.id)
+		if err != tt.err {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		if _, err := repo.Get(nil, tt.id); err != user.ErrorNotFound {
+			t.Errorf("case %d: want user.ErrorNotFound, got %v", i, err)
+		}
+		if _, err := repo.GetByEmail(nil, "Email-1@example.com"); err != user.ErrorNotFound {
+			t.Errorf("case %d: want user.ErrorNotFound by email, got %v", i, err)
+		}
+		rid := user.RemoteIdentity{ConnectorID: "IDPC-1", ID: "RID-1"}
+		if _, err := repo.GetByRemoteIdentity(nil, rid); err != user.ErrorNotFound {
+			t.Errorf("case %d: want user.ErrorNotFound by remote identity, got %v", i, err)
+		}
+
+		// The remote identity and email address are free again.
+		usr := user.User{ID: "ID-3", Email: "Email-1@example.com"}
+		if err := repo.Create(nil, usr); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if err := repo.AddRemoteIdentity(nil, "ID-3", rid); err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+
+		if _, err := repo.Get(nil, "ID-2"); err != nil {
+			t.Errorf("case %d: other user: unexpected error: %v", i, err)
+		}
+	}
+}
+
 func TestAttachRemoteIdentity(t *testing.T) {
 	tests := []struct {
 		id  string
@@ -650,6 +743,117 @@ func TestList(t *testing.T) {
 	}
 }
 
+func TestListFilter(t *testing.T) {
+	date := func(month time.Month) time.Time {
+		return time.Date(2016, month, 1, 0, 0, 0, 0, time.UTC)
+	}
+	yes, no := true, false
+	repoUsers := []user.UserWithRemoteIdentities{
+		{
+			User: user.User{ID: "0", Email: "alice@example.com", DisplayName: "Alice Smith",
+				Admin: true, EmailVerified: true, CreatedAt: date(time.January)},
+			RemoteIdentities: []user.RemoteIdentity{{ConnectorID: "google", ID: "g0"}},
+		},
+		{
+			User: user.User{ID: "1", Email: "alan@example.com", DisplayName: "Alan Turing",
+				Disabled: true, CreatedAt: date(time.February)},
+			RemoteIdentities: []user.RemoteIdentity{{ConnectorID: "local", ID: "l1"}},
+		},
+		{
+			User: user.User{ID: "2", Email: "bob@example.com", DisplayName: "Bob_Smith",
+				EmailVerified: true, CreatedAt: date(time.March)},
+			RemoteIdentities: []user.RemoteIdentity{{ConnectorID: "google", ID: "g2"}, {ConnectorID: "local", ID: "l2"}},
+		},
+		{
+			User: user.User{ID: "3", Email: "dave@example.com", DisplayName: "Dave",
+				CreatedAt: date(time.April)},
+		},
+	}
+
+	tests := []struct {
+		filter  user.UserFilter
+		wantIDs []string
+	}{
+		{
+			filter:  user.UserFilter{EmailPrefix: "AL"},
+			wantIDs: []string{"1", "0"},
+		},
+		{
+			// Wildcards match themselves only.
+			filter: user.UserFilter{EmailPrefix: "a%"},
+		},
+		{
+			filter:  user.UserFilter{DisplayName: "smith"},
+			wantIDs: []string{"0", "2"},
+		},
+		{
+			filter:  user.UserFilter{DisplayName: "_"},
+			wantIDs: []string{"2"},
+		},
+		{
+			filter:  user.UserFilter{Admin: &yes},
+			wantIDs: []string{"0"},
+		},
+		{
+			filter:  user.UserFilter{Admin: &no},
+			wantIDs: []string{"1", "2", "3"},
+		},
+		{
+			filter:  user.UserFilter{Disabled: &yes},
+			wantIDs: []string{"1"},
+		},
+		{
+			filter:  user.UserFilter{EmailVerified: &yes},
+			wantIDs: []string{"0", "2"},
+		},
+		{
+			filter:  user.UserFilter{ConnectorID: "local"},
+			wantIDs: []string{"1", "2"},
+		},
+		{
+			filter:  user.UserFilter{CreatedAfter: date(time.February)},
+			wantIDs: []string{"1", "2", "3"},
+		},
+		{
+			filter:  user.UserFilter{CreatedBefore: date(time.February)},
+			wantIDs: []string{"0"},
+		},
+		{
+			filter:  user.UserFilter{ConnectorID: "local", EmailVerified: &yes},
+			wantIDs: []string{"2"},
+		},
+		{
+			filter: user.UserFilter{EmailPrefix: "zzz"},
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestUserRepoFromUsers(repoUsers)
+
+		// Pages of two users make sure the filter is kept by the token.
+		var tok string
+		var gotIDs []string
+		for {
+			users, next, err := repo.List(nil, tt.filter, 2, tok)
+			if err == user.ErrorNotFound && tok == "" {
+				break
+			}
+			if err != nil {
+				t.Fatalf("case %d: unexpected err: %v", i, err)
+			}
+			for _, usr := range users {
+				gotIDs = append(gotIDs, usr.ID)
+			}
+			if tok = next; tok == "" {
+				break
+			}
+		}
+		if diff := pretty.Compare(tt.wantIDs, gotIDs); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
 func TestListErrorNotFound(t *testing.T) {
 	repo := makeTestUserRepoFromUsers(nil)
 	_, _, err := repo.List(nil, user.UserFilter{}, 10, "")
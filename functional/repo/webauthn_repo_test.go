@@ -0,0 +1,233 @@
+package repo
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/user"
+)
+
+var makeTestWebAuthnCredentialRepo func() user.WebAuthnCredentialRepo
+
+var (
+	testWebAuthnCredentials = []user.WebAuthnCredential{
+		{
+			ID:        []byte("cred-1"),
+			UserID:    "ID-1",
+			PublicKey: []byte("public-key-1"),
+			SignCount: 10,
+			CreatedAt: time.Unix(1234567890, 0).UTC(),
+		},
+		{
+			ID:        []byte("cred-2"),
+			UserID:    "ID-1",
+			PublicKey: []byte("public-key-2"),
+			SignCount: 0,
+			CreatedAt: time.Unix(1234567891, 0).UTC(),
+		},
+		{
+			ID:        []byte("cred-3"),
+			UserID:    "ID-2",
+			PublicKey: []byte("public-key-3"),
+			SignCount: 5,
+			CreatedAt: time.Unix(1234567890, 0).UTC(),
+		},
+	}
+)
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestWebAuthnCredentialRepo = makeTestWebAuthnCredentialRepoMem
+	} else {
+		makeTestWebAuthnCredentialRepo = makeTestWebAuthnCredentialRepoDB(dsn)
+	}
+}
+
+func makeTestWebAuthnCredentialRepoMem() user.WebAuthnCredentialRepo {
+	repo := user.NewWebAuthnCredentialRepo()
+	for _, cred := range testWebAuthnCredentials {
+		if err := repo.Create(nil, cred); err != nil {
+			panic(fmt.Sprintf("Unable to add WebAuthnCredentials: %v", err))
+		}
+	}
+	return repo
+}
+
+func makeTestWebAuthnCredentialRepoDB(dsn string) func() user.WebAuthnCredentialRepo {
+	return func() user.WebAuthnCredentialRepo {
+		c := initDB(dsn)
+
+		repo := db.NewWebAuthnCredentialRepo(c)
+		for _, cred := range testWebAuthnCredentials {
+			if err := repo.Create(nil, cred); err != nil {
+				panic(fmt.Sprintf("Unable to add WebAuthnCredentials: %v", err))
+			}
+		}
+		return repo
+	}
+}
+
+func TestCreateWebAuthnCredential(t *testing.T) {
+	tests := []struct {
+		cred user.WebAuthnCredential
+		err  error
+	}{
+		{
+			cred: user.WebAuthnCredential{
+				ID:        []byte("cred-4"),
+				UserID:    "ID-2",
+				PublicKey: []byte("public-key-4"),
+				SignCount: 1,
+				CreatedAt: time.Now().Round(time.Second).UTC(),
+			},
+		},
+		{
+			cred: user.WebAuthnCredential{
+				ID:     []byte("cred-1"),
+				UserID: "ID-2",
+			},
+			err: user.ErrorDuplicateID,
+		},
+		{
+			cred: user.WebAuthnCredential{
+				UserID: "ID-2",
+			},
+			err: user.ErrorInvalidID,
+		},
+		{
+			cred: user.WebAuthnCredential{
+				ID: []byte("cred-5"),
+			},
+			err: user.ErrorInvalidID,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestWebAuthnCredentialRepo()
+		err := repo.Create(nil, tt.cred)
+		if err != tt.err {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.cred.ID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.cred, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestGetWebAuthnCredentialsByUserID(t *testing.T) {
+	tests := []struct {
+		userID string
+		want   []user.WebAuthnCredential
+	}{
+		{
+			userID: "ID-1",
+			want:   testWebAuthnCredentials[:2],
+		},
+		{
+			userID: "ID-2",
+			want:   testWebAuthnCredentials[2:],
+		},
+		{
+			userID: "ID-3",
+			want:   nil,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestWebAuthnCredentialRepo()
+		got, err := repo.GetByUserID(nil, tt.userID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestUpdateWebAuthnCredential(t *testing.T) {
+	tests := []struct {
+		cred user.WebAuthnCredential
+		want user.WebAuthnCredential
+		err  error
+	}{
+		// only the signature counter changes
+		{
+			cred: user.WebAuthnCredential{
+				ID:        []byte("cred-1"),
+				UserID:    "ID-2",
+				PublicKey: []byte("other-key"),
+				SignCount: 11,
+			},
+			want: user.WebAuthnCredential{
+				ID:        []byte("cred-1"),
+				UserID:    "ID-1",
+				PublicKey: []byte("public-key-1"),
+				SignCount: 11,
+				CreatedAt: time.Unix(1234567890, 0).UTC(),
+			},
+		},
+		{
+			cred: user.WebAuthnCredential{
+				ID:        []byte("cred-4"),
+				SignCount: 1,
+			},
+			err: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := makeTestWebAuthnCredentialRepo()
+		err := repo.Update(nil, tt.cred)
+		if err != tt.err {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+
+		got, err := repo.Get(nil, tt.cred.ID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestDeleteWebAuthnCredentialsByUserID(t *testing.T) {
+	repo := makeTestWebAuthnCredentialRepo()
+	if err := repo.DeleteByUserID(nil, "ID-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if creds, err := repo.GetByUserID(nil, "ID-1"); err != nil || len(creds) != 0 {
+		t.Errorf("want no credentials, got %d, err=%v", len(creds), err)
+	}
+	if _, err := repo.Get(nil, []byte("cred-1")); err != user.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+	if creds, err := repo.GetByUserID(nil, "ID-2"); err != nil || len(creds) != 1 {
+		t.Errorf("want one credential for other user, got %d, err=%v", len(creds), err)
+	}
+}
@@ -0,0 +1,182 @@
+package repo
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/db"
+	"github.com/coreos/dex/webhook"
+)
+
+var (
+	makeTestWebhookSubscriptionRepo func() webhook.SubscriptionRepo
+	makeTestWebhookDeliveryQueue    func() webhook.DeliveryQueue
+)
+
+func init() {
+	dsn := os.Getenv("DEX_TEST_DSN")
+	if dsn == "" {
+		makeTestWebhookSubscriptionRepo = func() webhook.SubscriptionRepo {
+			return webhook.NewSubscriptionRepo()
+		}
+		makeTestWebhookDeliveryQueue = func() webhook.DeliveryQueue {
+			return webhook.NewDeliveryQueue()
+		}
+	} else {
+		makeTestWebhookSubscriptionRepo = func() webhook.SubscriptionRepo {
+			repo, err := db.NewWebhookSubscriptionRepo(initDB(dsn), []byte("12345678901234567890123456789012"))
+			if err != nil {
+				panic(err)
+			}
+			return repo
+		}
+		makeTestWebhookDeliveryQueue = func() webhook.DeliveryQueue {
+			return db.NewWebhookDeliveryQueue(initDB(dsn))
+		}
+	}
+}
+
+func TestWebhookSubscriptionRepo(t *testing.T) {
+	repo := makeTestWebhookSubscriptionRepo()
+
+	subs := []webhook.Subscription{
+		{
+			ID:        "sub-2",
+			URL:       "https://two.example.com/hook",
+			Secret:    []byte("secret-2"),
+			Events:    []webhook.EventType{webhook.EventUserLogin},
+			CreatedAt: time.Unix(1234567900, 0).UTC(),
+		},
+		{
+			ID:        "sub-1",
+			URL:       "https://one.example.com/hook",
+			Secret:    []byte("secret-1"),
+			Events:    []webhook.EventType{webhook.EventUserCreated, webhook.EventUserVerified},
+			CreatedAt: time.Unix(1234567890, 0).UTC(),
+		},
+	}
+	for i, s := range subs {
+		if err := repo.Create(nil, s); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if err := repo.Create(nil, subs[0]); err != webhook.ErrorDuplicateID {
+		t.Errorf("want=%v, got=%v", webhook.ErrorDuplicateID, err)
+	}
+	if err := repo.Create(nil, webhook.Subscription{ID: "sub-3", URL: "https://example.com"}); err != webhook.ErrorNoEvents {
+		t.Errorf("want=%v, got=%v", webhook.ErrorNoEvents, err)
+	}
+
+	got, err := repo.Get(nil, "sub-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare(subs[1], got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	all, err := repo.All(nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]webhook.Subscription{subs[1], subs[0]}, all); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if err := repo.Delete(nil, "sub-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repo.Delete(nil, "sub-1"); err != webhook.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", webhook.ErrorNotFound, err)
+	}
+	if _, err := repo.Get(nil, "sub-1"); err != webhook.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", webhook.ErrorNotFound, err)
+	}
+}
+
+func TestWebhookDeliveryQueue(t *testing.T) {
+	q := makeTestWebhookDeliveryQueue()
+	now := time.Unix(1234567890, 0).UTC()
+
+	err := q.Enqueue(nil, []webhook.Delivery{
+		{
+			SubscriptionID: "sub-1",
+			EventID:        "event-1",
+			EventType:      webhook.EventUserCreated,
+			Payload:        []byte(`{"id":"event-1"}`),
+			NextAttempt:    now,
+		},
+		{
+			SubscriptionID: "sub-1",
+			EventID:        "event-2",
+			EventType:      webhook.EventUserLogin,
+			Payload:        []byte(`{"id":"event-2"}`),
+			NextAttempt:    now.Add(time.Minute),
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ds, err := q.Claim(now, time.Hour, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ds) != 1 {
+		t.Fatalf("want 1 delivery, got %#v", ds)
+	}
+	want := webhook.Delivery{
+		ID:             ds[0].ID,
+		SubscriptionID: "sub-1",
+		EventID:        "event-1",
+		EventType:      webhook.EventUserCreated,
+		Payload:        []byte(`{"id":"event-1"}`),
+		NextAttempt:    now,
+	}
+	if diff := pretty.Compare(want, ds[0]); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// The claimed delivery is leased, so it isn't due again until the lease
+	// runs out or it is retried.
+	if ds, err = q.Claim(now.Add(time.Minute), time.Hour, 10); err != nil || len(ds) != 1 || ds[0].EventID != "event-2" {
+		t.Fatalf("want event-2 only, got %#v, err=%v", ds, err)
+	}
+
+	retry := want
+	retry.Attempts = 1
+	retry.LastError = "unexpected response status 500"
+	retry.NextAttempt = now.Add(2 * time.Minute)
+	if err := q.Retry(retry); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ds, err = q.Claim(now.Add(2*time.Minute), time.Hour, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ds) != 1 {
+		t.Fatalf("want 1 delivery, got %#v", ds)
+	}
+	if diff := pretty.Compare(retry, ds[0]); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if err := q.Remove(retry.ID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.Remove(retry.ID); err != webhook.ErrorDeliveryNotFound {
+		t.Errorf("want=%v, got=%v", webhook.ErrorDeliveryNotFound, err)
+	}
+	if err := q.Retry(retry); err != webhook.ErrorDeliveryNotFound {
+		t.Errorf("want=%v, got=%v", webhook.ErrorDeliveryNotFound, err)
+	}
+
+	// Only the leased event-2 is left.
+	if ds, err = q.Claim(now.Add(2*time.Hour), time.Hour, 10); err != nil || len(ds) != 1 || ds[0].EventID != "event-2" {
+		t.Fatalf("want event-2 only, got %#v, err=%v", ds, err)
+	}
+}
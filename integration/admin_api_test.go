@@ -3,15 +3,21 @@ package integration
 import (
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 
+	"github.com/coreos/go-oidc/oidc"
 	"github.com/kylelemons/godebug/pretty"
 	"google.golang.org/api/googleapi"
 
 	"github.com/coreos/dex/admin"
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/client"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/schema/adminschema"
 	"github.com/coreos/dex/server"
 	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/webhook"
 )
 
 const (
@@ -21,6 +27,11 @@ const (
 type adminAPITestFixtures struct {
 	ur       user.UserRepo
 	pwr      user.PasswordInfoRepo
+	cir      client.ClientIdentityRepo
+	totpr    user.TOTPInfoRepo
+	war      user.WebAuthnCredentialRepo
+	aer      audit.EventRepo
+	whr      webhook.SubscriptionRepo
 	adAPI    *admin.AdminAPI
 	adSrv    *server.AdminServer
 	hSrv     *httptest.Server
@@ -77,7 +88,22 @@ func makeAdminAPITestFixtures() *adminAPITestFixtures {
 	ur, pwr, um := makeUserObjects(adminUsers, adminPasswords)
 	f.ur = ur
 	f.pwr = pwr
-	f.adAPI = admin.NewAdminAPI(um, f.ur, f.pwr, "local")
+	f.cir = client.NewClientIdentityRepo([]oidc.ClientIdentity{
+		{
+			Credentials: oidc.ClientCredentials{
+				ID:     testClientID,
+				Secret: testClientSecret,
+			},
+			Metadata: oidc.ClientMetadata{
+				RedirectURIs: []url.URL{testRedirectURL},
+			},
+		},
+	})
+	f.totpr = user.NewTOTPInfoRepo()
+	f.war = user.NewWebAuthnCredentialRepo()
+	f.aer = audit.NewEventRepo()
+	f.whr = webhook.NewSubscriptionRepo()
+	f.adAPI = admin.NewAdminAPI(um, f.ur, f.pwr, f.cir, f.totpr, f.war, user.NewLoginThrottler(user.NewLoginAttemptRepo(), repo.InMemTransactionFactory, user.DefaultLockoutPolicy), f.aer, f.whr, nil, "local")
 	f.adSrv = server.NewAdminServer(f.adAPI, nil, adminAPITestSecret)
 	f.hSrv = httptest.NewServer(f.adSrv.HTTPHandler())
 	f.hc = &http.Client{
@@ -301,3 +327,235 @@ func TestGetState(t *testing.T) {
 	}
 
 }
+
+func TestListAuditEvents(t *testing.T) {
+	f := makeAdminAPITestFixtures()
+	defer f.close()
+
+	for _, e := range []audit.Event{
+		{Type: audit.EventLogin, UserID: "ID-1"},
+		{Type: audit.EventLoginFailed, UserID: "ID-2"},
+		{Type: audit.EventLogin, UserID: "ID-2"},
+	} {
+		if err := f.aer.Record(e); err != nil {
+			t.Fatalf("err != nil: %v", err)
+		}
+	}
+
+	resp, err := f.adClient.AuditEvent.List().UserId("ID-2").MaxResults(1).Do()
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].Id != "3" || resp.NextPageToken == "" {
+		t.Fatalf("unexpected first page: %#v", resp)
+	}
+
+	resp, err = f.adClient.AuditEvent.List().NextPageToken(resp.NextPageToken).Do()
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if len(resp.Events) != 1 || resp.Events[0].Id != "2" || resp.Events[0].Type != "login_failed" || resp.NextPageToken != "" {
+		t.Fatalf("unexpected second page: %#v", resp)
+	}
+
+	_, err = f.adClient.AuditEvent.List().Type("bogus").Do()
+	gErr, ok := err.(*googleapi.Error)
+	if !ok || gErr.Code != http.StatusBadRequest {
+		t.Errorf("want bad request for invalid type, got %v", err)
+	}
+}
+
+func TestWebhookCRUD(t *testing.T) {
+	f := makeAdminAPITestFixtures()
+	defer f.close()
+
+	created, err := f.adClient.Webhook.Create(&adminschema.Webhook{
+		Url:    "https://example.com/hook",
+		Events: []string{"user_created"},
+	}).Do()
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if created.Id == "" || created.Secret == "" {
+		t.Fatalf("want ID and secret, got %#v", created)
+	}
+
+	got, err := f.adClient.Webhook.Get(created.Id).Do()
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if got.Url != created.Url || got.Secret != "" {
+		t.Errorf("unexpected webhook: %#v", got)
+	}
+
+	list, err := f.adClient.Webhook.List().Do()
+	if err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	if len(list.Webhooks) != 1 || list.Webhooks[0].Id != created.Id {
+		t.Errorf("unexpected webhooks: %#v", list.Webhooks)
+	}
+
+	if err := f.adClient.Webhook.Delete(created.Id).Do(); err != nil {
+		t.Fatalf("err != nil: %v", err)
+	}
+	_, err = f.adClient.Webhook.Get(created.Id).Do()
+	if gErr, ok := err.(*googleapi.Error); !ok || gErr.Code != http.StatusNotFound {
+		t.Errorf("want not found after delete, got %v", err)
+	}
+
+	_, err = f.adClient.Webhook.Create(&adminschema.Webhook{Url: "https://example.com/hook"}).Do()
+	if gErr, ok := err.(*googleapi.Error); !ok || gErr.Code != http.StatusBadRequest {
+		t.Errorf("want bad request without events, got %v", err)
+	}
+}
+
+func TestReencryptWithoutReencrypter(t *testing.T) {
+	f := makeAdminAPITestFixtures()
+	defer f.close()
+
+	_, err := f.adClient.KeySecrets.Reencrypt(&adminschema.ReencryptRequest{DryRun: true}).Do()
+	if gErr, ok := err.(*googleapi.Error); !ok || gErr.Code != http.StatusNotImplemented {
+		t.Errorf("want not implemented, got %v", err)
+	}
+}
+
+func TestRotateClientSecret(t *testing.T) {
+	tests := []struct {
+		id          string
+		gracePeriod int64
+
+		wantOldValid bool
+		errCode      int
+	}{
+		{
+			id:           testClientID,
+			gracePeriod:  3600,
+			wantOldValid: true,
+			errCode:      -1,
+		},
+		{
+			id:           testClientID,
+			wantOldValid: false,
+			errCode:      -1,
+		},
+		{
+			id:          testClientID,
+			gracePeriod: -1,
+			errCode:     http.StatusBadRequest,
+		},
+		{
+			id:      "no-such-client",
+			errCode: http.StatusNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		func() {
+			f := makeAdminAPITestFixtures()
+			defer f.close()
+
+			resp, err := f.adClient.Client.RotateSecret(tt.id, &adminschema.ClientRotateSecretRequest{
+				GracePeriodSeconds: tt.gracePeriod,
+			}).Do()
+			if tt.errCode != -1 {
+				if err == nil {
+					t.Errorf("case %d: err was nil", i)
+					return
+				}
+				gErr, ok := err.(*googleapi.Error)
+				if !ok {
+					t.Errorf("case %d: not a googleapi Error: %q", i, err)
+					return
+				}
+
+				if gErr.Code != tt.errCode {
+					t.Errorf("case %d: want=%d, got=%d", i, tt.errCode, gErr.Code)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("case %d: err != nil: %q", i, err)
+				return
+			}
+
+			ok, err := f.cir.Authenticate(oidc.ClientCredentials{ID: resp.Id, Secret: resp.Secret})
+			if err != nil || !ok {
+				t.Errorf("case %d: new secret not accepted: ok=%v err=%v", i, ok, err)
+			}
+
+			ok, err = f.cir.Authenticate(oidc.ClientCredentials{ID: tt.id, Secret: testClientSecret})
+			if err != nil {
+				t.Errorf("case %d: err != nil: %q", i, err)
+			}
+			if ok != tt.wantOldValid {
+				t.Errorf("case %d: old secret valid: want=%v, got=%v", i, tt.wantOldValid, ok)
+			}
+		}()
+	}
+}
+
+func TestClientCRUD(t *testing.T) {
+	f := makeAdminAPITestFixtures()
+	defer f.close()
+
+	checkCode := func(desc string, err error, code int) {
+		gErr, ok := err.(*googleapi.Error)
+		if !ok {
+			t.Fatalf("%s: not a googleapi Error: %q", desc, err)
+		}
+		if gErr.Code != code {
+			t.Errorf("%s: want=%d, got=%d", desc, code, gErr.Code)
+		}
+	}
+
+	list, err := f.adClient.Client.List().Do()
+	if err != nil {
+		t.Fatalf("list: err != nil: %q", err)
+	}
+	want := []*adminschema.Client{
+		{Id: testClientID, RedirectURIs: []string{testRedirectURL.String()}},
+	}
+	if diff := pretty.Compare(want, list.Clients); diff != "" {
+		t.Errorf("list: Compare(want, got) = %v", diff)
+	}
+
+	newRedirectURIs := []string{"https://new.example.com/callback"}
+	c, err := f.adClient.Client.Update(testClientID, &adminschema.Client{RedirectURIs: newRedirectURIs}).Do()
+	if err != nil {
+		t.Fatalf("update: err != nil: %q", err)
+	}
+	if diff := pretty.Compare(newRedirectURIs, c.RedirectURIs); diff != "" {
+		t.Errorf("update: Compare(want, got) = %v", diff)
+	}
+
+	_, err = f.adClient.Client.Update(testClientID, &adminschema.Client{}).Do()
+	checkCode("update without redirect URIs", err, http.StatusBadRequest)
+
+	err = f.adClient.Client.SetAdmin(testClientID, &adminschema.ClientSetAdminRequest{IsAdmin: true}).Do()
+	if err != nil {
+		t.Fatalf("set admin: err != nil: %q", err)
+	}
+
+	c, err = f.adClient.Client.Get(testClientID).Do()
+	if err != nil {
+		t.Fatalf("get: err != nil: %q", err)
+	}
+	wantClient := &adminschema.Client{Id: testClientID, IsAdmin: true, RedirectURIs: newRedirectURIs}
+	if diff := pretty.Compare(wantClient, c); diff != "" {
+		t.Errorf("get: Compare(want, got) = %v", diff)
+	}
+
+	if err := f.adClient.Client.Delete(testClientID).Do(); err != nil {
+		t.Fatalf("delete: err != nil: %q", err)
+	}
+
+	_, err = f.adClient.Client.Get(testClientID).Do()
+	checkCode("get after delete", err, http.StatusNotFound)
+
+	err = f.adClient.Client.Delete(testClientID).Do()
+	checkCode("delete after delete", err, http.StatusNotFound)
+
+	err = f.adClient.Client.SetAdmin(testClientID, &adminschema.ClientSetAdminRequest{IsAdmin: true}).Do()
+	checkCode("set admin after delete", err, http.StatusNotFound)
+}
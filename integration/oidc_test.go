@@ -200,7 +200,7 @@ func TestHTTPExchangeTokenRefreshToken(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
-	if _, err = sm.AttachRemoteIdentity(sessionID, passwordInfo.Identity()); err != nil {
+	if _, err = sm.AttachRemoteIdentity(sessionID, passwordInfo.Identity(), user.Profile{}); err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
@@ -243,6 +243,7 @@ func TestListUsers(t *testing.T) {
 	tests := []struct {
 		maxResults int64
 		pages      int
+		filter     func(*schema.UsersListCall)
 
 		token string
 
@@ -268,6 +269,27 @@ func TestListUsers(t *testing.T) {
 		{
 			pages: 1,
 
+			token: userGoodToken,
+
+			maxResults: 4,
+			filter: func(call *schema.UsersListCall) {
+				call.EmailPrefix("email-").Disabled(false)
+			},
+			wantIDs: [][]string{{"ID-1", "ID-2", "ID-3"}},
+		},
+		{
+			pages: 1,
+
+			token: userGoodToken,
+
+			filter: func(call *schema.UsersListCall) {
+				call.CreatedAfter("not a time")
+			},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			pages: 1,
+
 			token: userBadTokenDisabled,
 
 			maxResults: 1,
@@ -310,6 +332,9 @@ func TestListUsers(t *testing.T) {
 				if tt.maxResults != 0 {
 					call.MaxResults(tt.maxResults)
 				}
+				if tt.filter != nil {
+					tt.filter(call)
+				}
 				usersResponse, err := call.Do()
 
 				if tt.wantCode != 0 {
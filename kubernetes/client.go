@@ -0,0 +1,351 @@
+package kubernetes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// Group and Version are the API group and version of the custom
+	// resources holding the data of dex.
+	Group   = "dex.coreos.com"
+	Version = "v1"
+
+	// serviceAccountDir holds the credentials of the service account of a
+	// pod.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// maxConflictRetries is the number of times a modification is retried
+	// when the resource it modifies is changed concurrently.
+	maxConflictRetries = 5
+
+	// listPageSize is the number of resources requested at a time by list.
+	listPageSize = 500
+
+	// labelUser is the label of resources belonging to a user, set to the
+	// resource name of their ID.
+	labelUser = Group + "/user"
+)
+
+var (
+	ErrorNotFound      = errors.New("kubernetes: resource not found")
+	ErrorAlreadyExists = errors.New("kubernetes: resource already exists")
+
+	// ErrorConflict is returned when a resource was modified since it was
+	// read, and modifying it again still conflicted.
+	ErrorConflict = errors.New("kubernetes: resource modified concurrently")
+
+	nameEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567")
+)
+
+// Config configures the connection of a Client to an API server.
+type Config struct {
+	// Server is the URL of the API server.
+	Server string
+
+	// Namespace is the namespace of the resources.
+	Namespace string
+
+	// TokenFile, if set, is a file holding the bearer token with which
+	// requests are authenticated. It is read for every request, so that
+	// rotated tokens are picked up.
+	TokenFile string
+
+	// CAFile, if set, is a file of PEM-encoded certificates of the
+	// authorities trusted to sign the certificate of the API server.
+	CAFile string
+}
+
+// InClusterConfig returns the Config of a pod, which uses its service
+// account and the namespace it runs in.
+func InClusterConfig() (Config, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return Config{}, errors.New("not running in a Kubernetes cluster: KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT unset")
+	}
+
+	ns, err := ioutil.ReadFile(path.Join(serviceAccountDir, "namespace"))
+	if err != nil {
+		return Config{}, fmt.Errorf("unable to read namespace of service account: %v", err)
+	}
+
+	cfg := Config{
+		Server:    "https://" + net.JoinHostPort(host, port),
+		Namespace: strings.TrimSpace(string(ns)),
+		TokenFile: path.Join(serviceAccountDir, "token"),
+		CAFile:    path.Join(serviceAccountDir, "ca.crt"),
+	}
+	return cfg, nil
+}
+
+// Client makes requests for the custom resources of dex to an API server.
+type Client struct {
+	baseURL    string
+	tokenFile  string
+	httpClient *http.Client
+}
+
+func NewClient(cfg Config) (*Client, error) {
+	u, err := url.Parse(cfg.Server)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API server URL %q: %v", cfg.Server, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid API server URL %q: scheme must be http or https", cfg.Server)
+	}
+	if cfg.Namespace == "" {
+		return nil, errors.New("missing namespace")
+	}
+
+	tr := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if cfg.CAFile != "" {
+		pem, err := ioutil.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tr.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	c := &Client{
+		baseURL:    strings.TrimRight(cfg.Server, "/") + path.Join("/apis", Group, Version, "namespaces", cfg.Namespace),
+		tokenFile:  cfg.TokenFile,
+		httpClient: &http.Client{Transport: tr, Timeout: 30 * time.Second},
+	}
+	return c, nil
+}
+
+// typeMeta identifies the kind of a resource.
+type typeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+func newTypeMeta(kind string) typeMeta {
+	return typeMeta{APIVersion: Group + "/" + Version, Kind: kind}
+}
+
+// objectMeta is the metadata of a resource.
+type objectMeta struct {
+	Name            string            `json:"name"`
+	ResourceVersion string            `json:"resourceVersion,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+}
+
+// listMeta is the metadata of a list of resources.
+type listMeta struct {
+	Continue string `json:"continue,omitempty"`
+}
+
+// deleteOptions is the body of requests deleting a resource.
+type deleteOptions struct {
+	typeMeta
+	Preconditions preconditions `json:"preconditions"`
+}
+
+type preconditions struct {
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+// status is the body of failed responses.
+type status struct {
+	Message string `json:"message"`
+	Reason  string `json:"reason"`
+}
+
+// resourceName returns the name of the resource holding the entity with the
+// given key, made of one or more parts. Keys are hashed, as the IDs and
+// email addresses of entities need not be valid names. The name is also a
+// valid label value.
+func resourceName(key ...string) string {
+	h := sha256.Sum256([]byte(strings.Join(key, "\x00")))
+	return strings.TrimRight(nameEncoding.EncodeToString(h[:]), "=")
+}
+
+func (c *Client) get(resource, name string, v interface{}) error {
+	return c.do("GET", c.baseURL+"/"+resource+"/"+name, nil, v)
+}
+
+// list stores the resources matching the given labels in v, which must be
+// a pointer to a slice.
+func (c *Client) list(resource string, labels map[string]string, v interface{}) error {
+	q := url.Values{}
+	q.Set("limit", fmt.Sprint(listPageSize))
+	if len(labels) > 0 {
+		var sel []string
+		for k, l := range labels {
+			sel = append(sel, k+"="+l)
+		}
+		q.Set("labelSelector", strings.Join(sel, ","))
+	}
+
+	var items []json.RawMessage
+	for {
+		var page struct {
+			Metadata listMeta          `json:"metadata"`
+			Items    []json.RawMessage `json:"items"`
+		}
+		if err := c.do("GET", c.baseURL+"/"+resource+"?"+q.Encode(), nil, &page); err != nil {
+			return err
+		}
+		items = append(items, page.Items...)
+		if page.Metadata.Continue == "" {
+			break
+		}
+		q.Set("continue", page.Metadata.Continue)
+	}
+
+	if items == nil {
+		items = []json.RawMessage{}
+	}
+	b, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// create creates the resource v and updates it with the response.
+func (c *Client) create(resource string, v interface{}) error {
+	return c.do("POST", c.baseURL+"/"+resource, v, v)
+}
+
+// update replaces the resource with the given name by v, which must carry
+// the resource version it was read at, and updates v with the response.
+// ErrorConflict is returned if the resource has been modified since.
+func (c *Client) update(resource, name string, v interface{}) error {
+	return c.do("PUT", c.baseURL+"/"+resource+"/"+name, v, v)
+}
+
+// createOrConflict creates a resource, reporting that it already exists as
+// a conflict, so that retryOnConflict tries again to modify it.
+func (c *Client) createOrConflict(resource string, v interface{}) error {
+	err := c.create(resource, v)
+	if err == ErrorAlreadyExists {
+		err = ErrorConflict
+	}
+	return err
+}
+
+func (c *Client) delete(resource, name string) error {
+	return c.do("DELETE", c.baseURL+"/"+resource+"/"+name, nil, nil)
+}
+
+// deleteAt deletes the resource with the given name if it is still at the
+// given resource version, and returns ErrorConflict otherwise.
+func (c *Client) deleteAt(resource, name, resourceVersion string) error {
+	opts := deleteOptions{
+		typeMeta:      typeMeta{APIVersion: "v1", Kind: "DeleteOptions"},
+		Preconditions: preconditions{ResourceVersion: resourceVersion},
+	}
+	return c.do("DELETE", c.baseURL+"/"+resource+"/"+name, &opts, nil)
+}
+
+// deleteAll deletes the resources matching the given labels.
+func (c *Client) deleteAll(resource string, labels map[string]string) error {
+	var objs []struct {
+		Metadata objectMeta `json:"metadata"`
+	}
+	if err := c.list(resource, labels, &objs); err != nil {
+		return err
+	}
+	for _, o := range objs {
+		if err := c.delete(resource, o.Metadata.Name); err != nil && err != ErrorNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// retryOnConflict calls fn, which should read, modify and update a
+// resource, until it returns an error other than ErrorConflict.
+func retryOnConflict(fn func() error) error {
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		if err = fn(); err != ErrorConflict {
+			return err
+		}
+	}
+	return err
+}
+
+func (c *Client) do(method, u string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.tokenFile != "" {
+		tok, err := ioutil.ReadFile(c.tokenFile)
+		if err != nil {
+			return fmt.Errorf("unable to read token file: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(tok)))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		var st status
+		json.Unmarshal(b, &st)
+		switch {
+		case resp.StatusCode == http.StatusNotFound:
+			return ErrorNotFound
+		case resp.StatusCode == http.StatusConflict && st.Reason == "AlreadyExists":
+			return ErrorAlreadyExists
+		case resp.StatusCode == http.StatusConflict:
+			return ErrorConflict
+		}
+		if st.Message == "" {
+			st.Message = resp.Status
+		}
+		return fmt.Errorf("kubernetes: %s %s: %s", method, req.URL.Path, st.Message)
+	}
+
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
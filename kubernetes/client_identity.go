@@ -0,0 +1,381 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/coreos/dex/client"
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/pkg/log"
+)
+
+const (
+	clientResourceType = "clients"
+
+	bcryptHashCost = 10
+
+	// Blowfish, the algorithm underlying bcrypt, has a maximum password
+	// length of 72, beyond which the bcrypt library silently ignores
+	// passwords.
+	maxSecretLength = 72
+)
+
+// clientResource is a client along with the hashes of its secrets, named
+// after its ID.
+type clientResource struct {
+	typeMeta
+	Metadata objectMeta `json:"metadata"`
+	Spec     clientSpec `json:"spec"`
+}
+
+type clientSpec struct {
+	ID           string              `json:"id"`
+	Metadata     json.RawMessage     `json:"metadata"`
+	Secrets      []clientSecret      `json:"secrets"`
+	DexAdmin     bool                `json:"dexAdmin,omitempty"`
+	ClaimMapping client.ClaimMapping `json:"claimMapping,omitempty"`
+}
+
+// clientSecret is one of the secrets of a client. A client may have several
+// valid secrets during a rotation; ExpiresAt is zero for secrets which do
+// not expire.
+type clientSecret struct {
+	Hash      string    `json:"hash"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (s clientSecret) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !s.ExpiresAt.After(now)
+}
+
+func (r *clientResource) clientIdentity() (*oidc.ClientIdentity, error) {
+	ci := oidc.ClientIdentity{
+		Credentials: oidc.ClientCredentials{
+			ID: r.Spec.ID,
+		},
+	}
+	if err := json.Unmarshal(r.Spec.Metadata, &ci.Metadata); err != nil {
+		return nil, err
+	}
+	return &ci, nil
+}
+
+func NewClientIdentityRepo(c *Client) client.ClientIdentityRepo {
+	return NewClientIdentityRepoWithClock(c, clockwork.NewRealClock())
+}
+
+func NewClientIdentityRepoWithClock(c *Client, clock clockwork.Clock) client.ClientIdentityRepo {
+	return &clientIdentityRepo{client: c, clock: clock}
+}
+
+func NewClientIdentityRepoFromClients(c *Client, clients []oidc.ClientIdentity) (client.ClientIdentityRepo, error) {
+	r := NewClientIdentityRepo(c).(*clientIdentityRepo)
+	for _, ci := range clients {
+		dec, err := base64.URLEncoding.DecodeString(ci.Credentials.Secret)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := r.insert(ci.Credentials.ID, dec, ci.Metadata); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+type clientIdentityRepo struct {
+	client *Client
+	clock  clockwork.Clock
+}
+
+// insert adds a client along with its first secret.
+func (r *clientIdentityRepo) insert(id string, secret []byte, meta oidc.ClientMetadata) error {
+	bmeta, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+
+	cs, err := r.newSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	res := &clientResource{
+		typeMeta: newTypeMeta("Client"),
+		Metadata: objectMeta{Name: resourceName(id)},
+		Spec: clientSpec{
+			ID:       id,
+			Metadata: bmeta,
+			Secrets:  []clientSecret{cs},
+		},
+	}
+	return r.client.create(clientResourceType, res)
+}
+
+func (r *clientIdentityRepo) newSecret(secret []byte) (clientSecret, error) {
+	hashed, err := bcrypt.GenerateFromPassword(secret, bcryptHashCost)
+	if err != nil {
+		return clientSecret{}, err
+	}
+
+	cs := clientSecret{
+		Hash:      string(hashed),
+		CreatedAt: r.clock.Now().UTC(),
+	}
+	return cs, nil
+}
+
+func (r *clientIdentityRepo) Metadata(clientID string) (*oidc.ClientMetadata, error) {
+	res, err := r.get(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	ci, err := res.clientIdentity()
+	if err != nil {
+		return nil, err
+	}
+	return &ci.Metadata, nil
+}
+
+func (r *clientIdentityRepo) IsDexAdmin(clientID string) (bool, error) {
+	res, err := r.get(clientID)
+	if err == client.ErrorNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.Spec.DexAdmin, nil
+}
+
+func (r *clientIdentityRepo) SetDexAdmin(clientID string, isAdmin bool) error {
+	return r.update(clientID, func(res *clientResource) error {
+		res.Spec.DexAdmin = isAdmin
+		return nil
+	})
+}
+
+func (r *clientIdentityRepo) ClaimMapping(clientID string) (client.ClaimMapping, error) {
+	res, err := r.get(clientID)
+	if err == client.ErrorNotFound {
+		return client.ClaimMapping{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cm := client.ClaimMapping{}
+	for claim, attr := range res.Spec.ClaimMapping {
+		cm[claim] = attr
+	}
+	return cm, nil
+}
+
+func (r *clientIdentityRepo) SetClaimMapping(clientID string, cm client.ClaimMapping) error {
+	return r.update(clientID, func(res *clientResource) error {
+		res.Spec.ClaimMapping = cm
+		return nil
+	})
+}
+
+func (r *clientIdentityRepo) Update(clientID string, meta oidc.ClientMetadata) error {
+	bmeta, err := json.Marshal(&meta)
+	if err != nil {
+		return err
+	}
+
+	return r.update(clientID, func(res *clientResource) error {
+		res.Spec.Metadata = bmeta
+		return nil
+	})
+}
+
+// update applies fn to the resource of the given client and writes the
+// result back, starting over if the resource was modified concurrently.
+func (r *clientIdentityRepo) update(clientID string, fn func(*clientResource) error) error {
+	return retryOnConflict(func() error {
+		res, err := r.get(clientID)
+		if err != nil {
+			return err
+		}
+		if err := fn(res); err != nil {
+			return err
+		}
+		return r.client.update(clientResourceType, res.Metadata.Name, res)
+	})
+}
+
+func (r *clientIdentityRepo) Delete(clientID string) error {
+	err := r.client.delete(clientResourceType, resourceName(clientID))
+	if err == ErrorNotFound {
+		return client.ErrorNotFound
+	}
+	return err
+}
+
+func (r *clientIdentityRepo) Authenticate(creds oidc.ClientCredentials) (bool, error) {
+	res, err := r.get(creds.ID)
+	if err == client.ErrorNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	dec, err := base64.URLEncoding.DecodeString(creds.Secret)
+	if err != nil {
+		log.Errorf("error Decoding client creds: %v", err)
+		return false, nil
+	}
+
+	if len(dec) > maxSecretLength {
+		return false, nil
+	}
+
+	now := r.clock.Now()
+	for _, cs := range res.Spec.Secrets {
+		if cs.expired(now) {
+			continue
+		}
+		if bcrypt.CompareHashAndPassword([]byte(cs.Hash), dec) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *clientIdentityRepo) New(id string, meta oidc.ClientMetadata) (*oidc.ClientCredentials, error) {
+	secret, err := pcrypto.RandBytes(maxSecretLength)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.insert(id, secret, meta); err != nil {
+		if err == ErrorAlreadyExists {
+			err = errors.New("client ID already exists")
+		}
+		return nil, err
+	}
+
+	cc := oidc.ClientCredentials{
+		ID:     id,
+		Secret: base64.URLEncoding.EncodeToString(secret),
+	}
+	return &cc, nil
+}
+
+func (r *clientIdentityRepo) All() ([]oidc.ClientIdentity, error) {
+	var ress []clientResource
+	if err := r.client.list(clientResourceType, nil, &ress); err != nil {
+		return nil, err
+	}
+
+	cs := make([]oidc.ClientIdentity, len(ress))
+	for i, res := range ress {
+		ci, err := res.clientIdentity()
+		if err != nil {
+			return nil, err
+		}
+		cs[i] = *ci
+	}
+	return cs, nil
+}
+
+func (r *clientIdentityRepo) RotateSecret(clientID string, gracePeriod time.Duration) (*oidc.ClientCredentials, error) {
+	secret, err := pcrypto.RandBytes(maxSecretLength)
+	if err != nil {
+		return nil, err
+	}
+
+	cs, err := r.newSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.update(clientID, func(res *clientResource) error {
+		// No secret may outlive the grace period, including those already
+		// on their way out from previous rotations.
+		now := r.clock.Now()
+		expiresAt := now.Add(gracePeriod).UTC()
+		secrets := []clientSecret{cs}
+		for _, old := range res.Spec.Secrets {
+			if old.expired(now) {
+				continue
+			}
+			if old.ExpiresAt.IsZero() || old.ExpiresAt.After(expiresAt) {
+				old.ExpiresAt = expiresAt
+			}
+			secrets = append(secrets, old)
+		}
+		res.Spec.Secrets = secrets
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cc := oidc.ClientCredentials{
+		ID:     clientID,
+		Secret: base64.URLEncoding.EncodeToString(secret),
+	}
+	return &cc, nil
+}
+
+func (r *clientIdentityRepo) get(clientID string) (*clientResource, error) {
+	var res clientResource
+	err := r.client.get(clientResourceType, resourceName(clientID), &res)
+	if err == ErrorNotFound {
+		return nil, client.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// purge removes the expired secrets of all clients.
+func (r *clientIdentityRepo) purge() error {
+	var ress []clientResource
+	if err := r.client.list(clientResourceType, nil, &ress); err != nil {
+		return err
+	}
+
+	now := r.clock.Now()
+	var n int
+	for _, res := range ress {
+		var valid []clientSecret
+		for _, cs := range res.Spec.Secrets {
+			if !cs.expired(now) {
+				valid = append(valid, cs)
+			}
+		}
+		if len(valid) == len(res.Spec.Secrets) {
+			continue
+		}
+
+		purged := len(res.Spec.Secrets) - len(valid)
+		res.Spec.Secrets = valid
+		// A client modified since it was listed is left for the next run.
+		err := r.client.update(clientResourceType, res.Metadata.Name, &res)
+		if err == ErrorConflict || err == ErrorNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		n += purged
+	}
+
+	if n > 0 {
+		log.Infof("Deleted %d expired client secret(s)", n)
+	}
+	return nil
+}
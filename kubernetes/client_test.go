@@ -0,0 +1,251 @@
+package kubernetes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/coreos/dex/kubernetes/fake"
+)
+
+type testResource struct {
+	typeMeta
+	Metadata objectMeta `json:"metadata"`
+	Spec     string     `json:"spec"`
+}
+
+func newTestResource(name, spec string, labels map[string]string) *testResource {
+	return &testResource{
+		typeMeta: newTypeMeta("Test"),
+		Metadata: objectMeta{Name: name, Labels: labels},
+		Spec:     spec,
+	}
+}
+
+func newTestClient(t *testing.T) (*Client, *fake.Server) {
+	srv := fake.NewServer()
+	c, err := NewClient(Config{Server: srv.URL, Namespace: "dex"})
+	if err != nil {
+		srv.Close()
+		t.Fatalf("unable to create client: %v", err)
+	}
+	return c, srv
+}
+
+func TestNewClientInvalidConfig(t *testing.T) {
+	tests := []Config{
+		{Server: "", Namespace: "dex"},
+		{Server: "ftp://example.com", Namespace: "dex"},
+		{Server: "https://example.com", Namespace: ""},
+		{Server: "https://example.com", Namespace: "dex", CAFile: "/does/not/exist"},
+	}
+
+	for i, tt := range tests {
+		if _, err := NewClient(tt); err == nil {
+			t.Errorf("case %d: expected non-nil error", i)
+		}
+	}
+}
+
+func TestResourceName(t *testing.T) {
+	a := resourceName("a", "b")
+	if len(a) != 52 {
+		t.Errorf("want name of 52 characters, got %q", a)
+	}
+	for _, r := range a {
+		if !('a' <= r && r <= 'z' || '2' <= r && r <= '7') {
+			t.Fatalf("invalid character %q in name %q", r, a)
+		}
+	}
+
+	// Parts must not run into one another.
+	if b := resourceName("ab"); a == b {
+		t.Errorf("resourceName(a, b) = resourceName(ab) = %q", a)
+	}
+}
+
+func TestClientCreateGetUpdate(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+
+	res := newTestResource("foo", "one", nil)
+	if err := c.create("tests", res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Metadata.ResourceVersion == "" {
+		t.Fatalf("resource version not set on create")
+	}
+	if err := c.create("tests", newTestResource("foo", "two", nil)); err != ErrorAlreadyExists {
+		t.Errorf("want=%v, got=%v", ErrorAlreadyExists, err)
+	}
+
+	stale := *res
+	res.Spec = "three"
+	if err := c.update("tests", "foo", res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stale.Spec = "four"
+	if err := c.update("tests", "foo", &stale); err != ErrorConflict {
+		t.Errorf("want=%v, got=%v", ErrorConflict, err)
+	}
+
+	var got testResource
+	if err := c.get("tests", "foo", &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Spec != "three" {
+		t.Errorf("want=three, got=%s", got.Spec)
+	}
+
+	if err := c.get("tests", "bar", &got); err != ErrorNotFound {
+		t.Errorf("want=%v, got=%v", ErrorNotFound, err)
+	}
+	if err := c.update("tests", "bar", newTestResource("bar", "", nil)); err != ErrorNotFound {
+		t.Errorf("want=%v, got=%v", ErrorNotFound, err)
+	}
+}
+
+func TestClientDelete(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+
+	res := newTestResource("foo", "one", nil)
+	if err := c.create("tests", res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rv := res.Metadata.ResourceVersion
+	if err := c.update("tests", "foo", res); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := c.deleteAt("tests", "foo", rv); err != ErrorConflict {
+		t.Errorf("want=%v, got=%v", ErrorConflict, err)
+	}
+	if err := c.deleteAt("tests", "foo", res.Metadata.ResourceVersion); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := c.delete("tests", "foo"); err != ErrorNotFound {
+		t.Errorf("want=%v, got=%v", ErrorNotFound, err)
+	}
+}
+
+func TestClientList(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+
+	// More resources than fit in a page, half of them labeled.
+	n := listPageSize + 10
+	var labeled int
+	for i := 0; i < n; i++ {
+		labels := map[string]string{labelUser: "other"}
+		if i%2 == 0 {
+			labels[labelUser] = "someone"
+			labeled++
+		}
+		if err := c.create("tests", newTestResource(resourceName(string(rune(i))), "", labels)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var all []testResource
+	if err := c.list("tests", nil, &all); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != n {
+		t.Errorf("want %d resources, got %d", n, len(all))
+	}
+
+	var some []testResource
+	if err := c.list("tests", map[string]string{labelUser: "someone"}, &some); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(some) != labeled {
+		t.Errorf("want %d resources, got %d", labeled, len(some))
+	}
+
+	if err := c.deleteAll("tests", map[string]string{labelUser: "someone"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := c.list("tests", nil, &all); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(all) != n-labeled {
+		t.Errorf("want %d resources, got %d", n-labeled, len(all))
+	}
+
+	var none []testResource
+	if err := c.list("others", nil, &none); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if none == nil || len(none) != 0 {
+		t.Errorf("want empty list, got %v", none)
+	}
+}
+
+func TestClientTokenFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-kubernetes")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	tokenFile := filepath.Join(dir, "token")
+
+	srv := fake.NewServer()
+	defer srv.Close()
+	srv.Token = "secret"
+
+	c, err := NewClient(Config{Server: srv.URL, Namespace: "dex", TokenFile: tokenFile})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var res testResource
+	if err := c.get("tests", "foo", &res); err == nil || err == ErrorNotFound {
+		t.Errorf("want error reading token file, got %v", err)
+	}
+
+	// The token is read again for every request.
+	for i, tt := range []struct {
+		token string
+		want  error
+	}{
+		{token: "wrong\n", want: nil},
+		{token: "secret\n", want: ErrorNotFound},
+	} {
+		if err := ioutil.WriteFile(tokenFile, []byte(tt.token), 0600); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		err := c.get("tests", "foo", &res)
+		if tt.want == nil {
+			if err == nil || err == ErrorNotFound {
+				t.Errorf("case %d: want unauthorized error, got %v", i, err)
+			}
+		} else if err != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, err)
+		}
+	}
+}
+
+func TestRetryOnConflict(t *testing.T) {
+	var calls int
+	err := retryOnConflict(func() error {
+		calls++
+		if calls < 3 {
+			return ErrorConflict
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("want nil error after 3 calls, got %v after %d", err, calls)
+	}
+
+	calls = 0
+	err = retryOnConflict(func() error {
+		calls++
+		return ErrorConflict
+	})
+	if err != ErrorConflict || calls != maxConflictRetries {
+		t.Errorf("want %v after %d calls, got %v after %d", ErrorConflict, maxConflictRetries, err, calls)
+	}
+}
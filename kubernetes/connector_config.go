@@ -0,0 +1,125 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/repo"
+)
+
+const connectorConfigResourceType = "connectorconfigs"
+
+// connectorConfigResource is a connector config. Its spec is the config as
+// found in a connectors file, so that connectors can be added by creating
+// resources by hand. Their names are therefore free; connectors are found
+// by the ID in their spec.
+type connectorConfigResource struct {
+	typeMeta
+	Metadata objectMeta      `json:"metadata"`
+	Spec     json.RawMessage `json:"spec"`
+}
+
+func newConnectorConfigResource(cfg connector.ConnectorConfig) (*connectorConfigResource, error) {
+	b, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	m["type"] = cfg.ConnectorType()
+	spec, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &connectorConfigResource{
+		typeMeta: newTypeMeta("ConnectorConfig"),
+		Metadata: objectMeta{Name: resourceName(cfg.ConnectorID())},
+		Spec:     spec,
+	}
+	return res, nil
+}
+
+func (r *connectorConfigResource) connectorConfig() (connector.ConnectorConfig, error) {
+	var typ struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(r.Spec, &typ); err != nil {
+		return nil, err
+	}
+	if typ.Type == "" {
+		return nil, errors.New("connector config type not set")
+	}
+
+	cfg, err := connector.NewConnectorConfigFromType(typ.Type)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(r.Spec, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func NewConnectorConfigRepo(c *Client) *ConnectorConfigRepo {
+	return &ConnectorConfigRepo{client: c}
+}
+
+type ConnectorConfigRepo struct {
+	client *Client
+}
+
+func (r *ConnectorConfigRepo) All() ([]connector.ConnectorConfig, error) {
+	var ress []connectorConfigResource
+	if err := r.client.list(connectorConfigResourceType, nil, &ress); err != nil {
+		return nil, err
+	}
+
+	cfgs := make([]connector.ConnectorConfig, len(ress))
+	for i, res := range ress {
+		cfg, err := res.connectorConfig()
+		if err != nil {
+			return nil, err
+		}
+		cfgs[i] = cfg
+	}
+	return cfgs, nil
+}
+
+func (r *ConnectorConfigRepo) GetConnectorByID(_ repo.Transaction, id string) (connector.ConnectorConfig, error) {
+	cfgs, err := r.All()
+	if err != nil {
+		return nil, err
+	}
+	for _, cfg := range cfgs {
+		if cfg.ConnectorID() == id {
+			return cfg, nil
+		}
+	}
+	return nil, connector.ErrorNotFound
+}
+
+// Set replaces all connector configs with cfgs.
+func (r *ConnectorConfigRepo) Set(cfgs []connector.ConnectorConfig) error {
+	ress := make([]*connectorConfigResource, len(cfgs))
+	for i, cfg := range cfgs {
+		res, err := newConnectorConfigResource(cfg)
+		if err != nil {
+			return err
+		}
+		ress[i] = res
+	}
+
+	if err := r.client.deleteAll(connectorConfigResourceType, nil); err != nil {
+		return err
+	}
+	for _, res := range ress {
+		if err := r.client.create(connectorConfigResourceType, res); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,283 @@
+// Package fake provides an in-memory API server serving custom resources,
+// against which the Kubernetes storage backend can be tested.
+package fake
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// object is a resource as stored by the server.
+type object map[string]interface{}
+
+func (o object) metadata() map[string]interface{} {
+	md, _ := o["metadata"].(map[string]interface{})
+	if md == nil {
+		md = make(map[string]interface{})
+		o["metadata"] = md
+	}
+	return md
+}
+
+func (o object) name() string {
+	s, _ := o.metadata()["name"].(string)
+	return s
+}
+
+func (o object) resourceVersion() string {
+	s, _ := o.metadata()["resourceVersion"].(string)
+	return s
+}
+
+func (o object) matches(sel map[string]string) bool {
+	labels, _ := o.metadata()["labels"].(map[string]interface{})
+	for k, v := range sel {
+		if l, _ := labels[k].(string); l != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Server is an API server keeping custom resources in memory. It supports
+// creating, getting, listing, replacing and deleting resources of any type
+// in any namespace, with equality label selectors, paginated lists,
+// optimistic concurrency on resource versions and delete preconditions.
+type Server struct {
+	*httptest.Server
+
+	// Token, if set, is the bearer token requests must carry.
+	Token string
+
+	mu      sync.Mutex
+	version int
+	// objects holds resources by collection path, then name.
+	objects map[string]map[string]object
+}
+
+// NewServer starts and returns a new Server, which should be closed when
+// finished with.
+func NewServer() *Server {
+	s := &Server{objects: make(map[string]map[string]object)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Token != "" && r.Header.Get("Authorization") != "Bearer "+s.Token {
+		writeStatus(w, http.StatusUnauthorized, "Unauthorized", "Unauthorized")
+		return
+	}
+
+	// Paths are /apis/<group>/<version>/namespaces/<namespace>/<resource>,
+	// optionally followed by /<name>.
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) < 6 || len(parts) > 7 || parts[0] != "apis" || parts[3] != "namespaces" {
+		writeStatus(w, http.StatusNotFound, "NotFound", "the server could not find the requested resource")
+		return
+	}
+	coll := strings.Join(parts[:6], "/")
+	var name string
+	if len(parts) == 7 {
+		name = parts[6]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "GET" && name == "":
+		s.list(w, r, coll)
+	case r.Method == "GET":
+		s.get(w, coll, name)
+	case r.Method == "POST" && name == "":
+		s.create(w, r, coll)
+	case r.Method == "PUT" && name != "":
+		s.update(w, r, coll, name)
+	case r.Method == "DELETE" && name != "":
+		s.delete(w, r, coll, name)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "the server does not allow this method on the requested resource")
+	}
+}
+
+func (s *Server) get(w http.ResponseWriter, coll, name string) {
+	o, ok := s.objects[coll][name]
+	if !ok {
+		writeNotFound(w, name)
+		return
+	}
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, coll string) {
+	q := r.URL.Query()
+	sel := make(map[string]string)
+	if ls := q.Get("labelSelector"); ls != "" {
+		for _, req := range strings.Split(ls, ",") {
+			kv := strings.SplitN(req, "=", 2)
+			if len(kv) != 2 {
+				writeStatus(w, http.StatusBadRequest, "BadRequest", fmt.Sprintf("unsupported label selector %q", ls))
+				return
+			}
+			sel[kv[0]] = kv[1]
+		}
+	}
+	limit, _ := strconv.Atoi(q.Get("limit"))
+
+	// Resources are listed by name, continuing after the last one of the
+	// previous page.
+	var names []string
+	for name, o := range s.objects[coll] {
+		if name > q.Get("continue") && o.matches(sel) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var cont string
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+		cont = names[limit-1]
+	}
+
+	items := make([]object, len(names))
+	for i, name := range names {
+		items[i] = s.objects[coll][name]
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"metadata": map[string]interface{}{"continue": cont},
+		"items":    items,
+	})
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, coll string) {
+	o, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+	name := o.name()
+	if name == "" {
+		writeStatus(w, http.StatusUnprocessableEntity, "Invalid", "metadata.name: Required value")
+		return
+	}
+	if o.resourceVersion() != "" {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "resourceVersion should not be set on objects to be created")
+		return
+	}
+	if _, ok := s.objects[coll][name]; ok {
+		writeStatus(w, http.StatusConflict, "AlreadyExists", fmt.Sprintf("%q already exists", name))
+		return
+	}
+
+	s.store(coll, o)
+	writeJSON(w, http.StatusCreated, o)
+}
+
+func (s *Server) update(w http.ResponseWriter, r *http.Request, coll, name string) {
+	o, ok := readObject(w, r)
+	if !ok {
+		return
+	}
+	if o.name() != name {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "the name of the object does not match the name on the URL")
+		return
+	}
+	old, ok := s.objects[coll][name]
+	if !ok {
+		writeNotFound(w, name)
+		return
+	}
+	if o.resourceVersion() == "" {
+		writeStatus(w, http.StatusUnprocessableEntity, "Invalid", "metadata.resourceVersion: Invalid value: 0x0: must be specified for an update")
+		return
+	}
+	if o.resourceVersion() != old.resourceVersion() {
+		writeConflict(w, name)
+		return
+	}
+
+	s.store(coll, o)
+	writeJSON(w, http.StatusOK, o)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, coll, name string) {
+	var opts struct {
+		Preconditions struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"preconditions"`
+	}
+	if b, err := ioutil.ReadAll(r.Body); err != nil || (len(b) > 0 && json.Unmarshal(b, &opts) != nil) {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "invalid delete options")
+		return
+	}
+
+	o, ok := s.objects[coll][name]
+	if !ok {
+		writeNotFound(w, name)
+		return
+	}
+	if rv := opts.Preconditions.ResourceVersion; rv != "" && rv != o.resourceVersion() {
+		writeConflict(w, name)
+		return
+	}
+
+	delete(s.objects[coll], name)
+	writeStatus(w, http.StatusOK, "", "")
+}
+
+// store saves o at a new resource version.
+func (s *Server) store(coll string, o object) {
+	s.version++
+	o.metadata()["resourceVersion"] = strconv.Itoa(s.version)
+	if s.objects[coll] == nil {
+		s.objects[coll] = make(map[string]object)
+	}
+	s.objects[coll][o.name()] = o
+}
+
+func readObject(w http.ResponseWriter, r *http.Request) (object, bool) {
+	var o object
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil || o == nil {
+		writeStatus(w, http.StatusBadRequest, "BadRequest", "invalid object")
+		return nil, false
+	}
+	return o, true
+}
+
+func writeNotFound(w http.ResponseWriter, name string) {
+	writeStatus(w, http.StatusNotFound, "NotFound", fmt.Sprintf("%q not found", name))
+}
+
+func writeConflict(w http.ResponseWriter, name string) {
+	writeStatus(w, http.StatusConflict, "Conflict", fmt.Sprintf("Operation cannot be fulfilled on %q: the object has been modified; please apply your changes to the latest version and try again", name))
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason, message string) {
+	st := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Status",
+		"code":       code,
+		"reason":     reason,
+		"message":    message,
+	}
+	if code/100 == 2 {
+		st["status"] = "Success"
+	} else {
+		st["status"] = "Failure"
+	}
+	writeJSON(w, code, st)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
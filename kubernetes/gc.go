@@ -0,0 +1,95 @@
+package kubernetes
+
+import (
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/pkg/log"
+	ptime "github.com/coreos/dex/pkg/time"
+)
+
+type purger interface {
+	purge() error
+}
+
+type namedPurger struct {
+	name string
+	purger
+}
+
+// NewGarbageCollector returns a GarbageCollector deleting expired sessions,
+// session keys and client secrets every ival. Purging the same resources
+// from several workers at once is safe, so every worker may run one.
+func NewGarbageCollector(c *Client, ival time.Duration) *GarbageCollector {
+	purgers := []namedPurger{
+		namedPurger{
+			name:   "session",
+			purger: NewSessionRepo(c),
+		},
+		namedPurger{
+			name:   "session_key",
+			purger: NewSessionKeyRepo(c),
+		},
+		namedPurger{
+			name:   "client_identity_secret",
+			purger: NewClientIdentityRepo(c).(*clientIdentityRepo),
+		},
+	}
+
+	gc := GarbageCollector{
+		purgers:  purgers,
+		interval: ival,
+		clock:    clockwork.NewRealClock(),
+	}
+
+	return &gc
+}
+
+type GarbageCollector struct {
+	purgers  []namedPurger
+	interval time.Duration
+	clock    clockwork.Clock
+}
+
+func (gc *GarbageCollector) Run() chan struct{} {
+	stop := make(chan struct{})
+
+	go func() {
+		var failing bool
+		next := gc.interval
+		for {
+			select {
+			case <-gc.clock.After(next):
+				if gc.purgeAll() {
+					if !failing {
+						failing = true
+						next = time.Second
+					} else {
+						next = ptime.ExpBackoff(next, time.Minute)
+					}
+					log.Errorf("Failed garbage collection, retrying in %v", next)
+					break
+				}
+				failing = false
+				next = gc.interval
+				log.Infof("Garbage collection complete, running again in %v", next)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
+
+// purgeAll runs every purger, and reports whether any of them failed.
+func (gc *GarbageCollector) purgeAll() (failed bool) {
+	for _, p := range gc.purgers {
+		if err := p.purge(); err != nil {
+			log.Errorf("Failed purging %s: %v", p.name, err)
+			failed = true
+		}
+	}
+	return
+}
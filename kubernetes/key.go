@@ -0,0 +1,198 @@
+package kubernetes
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/key"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+)
+
+const (
+	signingKeysResourceType = "signingkeys"
+
+	// signingKeysName is the name of the only signing keys resource.
+	signingKeysName = "signing-keys"
+)
+
+var (
+	ErrorCannotDecryptKeys = errors.New("Cannot Decrypt Keys")
+)
+
+// signingKeysResource holds the encrypted private key set.
+type signingKeysResource struct {
+	typeMeta
+	Metadata objectMeta      `json:"metadata"`
+	Spec     signingKeysSpec `json:"spec"`
+}
+
+type signingKeysSpec struct {
+	Value []byte `json:"value"`
+}
+
+type privateKeyModel struct {
+	ID    string `json:"id"`
+	PKCS1 []byte `json:"pkcs1"`
+}
+
+type privateKeySetModel struct {
+	Keys      []privateKeyModel `json:"keys"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+func newPrivateKeySetModel(pks *key.PrivateKeySet) *privateKeySetModel {
+	pkeys := pks.Keys()
+	keys := make([]privateKeyModel, len(pkeys))
+	for i, pkey := range pkeys {
+		keys[i] = privateKeyModel{
+			ID:    pkey.ID(),
+			PKCS1: x509.MarshalPKCS1PrivateKey(pkey.PrivateKey),
+		}
+	}
+
+	return &privateKeySetModel{
+		Keys:      keys,
+		ExpiresAt: pks.ExpiresAt(),
+	}
+}
+
+func (m *privateKeySetModel) PrivateKeySet() (*key.PrivateKeySet, error) {
+	keys := make([]*key.PrivateKey, len(m.Keys))
+	for i, pkm := range m.Keys {
+		d, err := x509.ParsePKCS1PrivateKey(pkm.PKCS1)
+		if err != nil {
+			return nil, err
+		}
+		keys[i] = &key.PrivateKey{
+			KeyID:      pkm.ID,
+			PrivateKey: d,
+		}
+	}
+	return key.NewPrivateKeySet(keys, m.ExpiresAt), nil
+}
+
+func NewPrivateKeySetRepo(c *Client, secrets ...[]byte) (*PrivateKeySetRepo, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("must provide at least one key secret")
+	}
+	for i, secret := range secrets {
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("key secret %d: expected 32-byte secret", i)
+		}
+	}
+
+	r := &PrivateKeySetRepo{
+		client:  c,
+		secrets: secrets,
+	}
+	return r, nil
+}
+
+// PrivateKeySetRepo stores the private key set in a single resource,
+// encrypted with the first of its secrets.
+//
+// Set only succeeds if the key set has not changed since it was last read
+// by Get, so that of several workers rotating the keys at once, only one
+// does. Since it remembers what it read, each user of the key set, such as
+// a rotator, should have its own PrivateKeySetRepo.
+type PrivateKeySetRepo struct {
+	client  *Client
+	secrets [][]byte
+
+	mu              sync.Mutex
+	resourceVersion string
+}
+
+func (r *PrivateKeySetRepo) Set(ks key.KeySet) error {
+	pks, ok := ks.(*key.PrivateKeySet)
+	if !ok {
+		return errors.New("unable to cast to PrivateKeySet")
+	}
+
+	j, err := json.Marshal(newPrivateKeySetModel(pks))
+	if err != nil {
+		return err
+	}
+
+	v, err := pcrypto.Encrypt(j, r.active())
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	res := &signingKeysResource{
+		typeMeta: newTypeMeta("SigningKeys"),
+		Metadata: objectMeta{
+			Name:            signingKeysName,
+			ResourceVersion: r.resourceVersion,
+		},
+		Spec: signingKeysSpec{Value: v},
+	}
+
+	if r.resourceVersion == "" {
+		err = r.client.createOrConflict(signingKeysResourceType, res)
+	} else {
+		err = r.client.update(signingKeysResourceType, signingKeysName, res)
+	}
+	if err != nil {
+		return err
+	}
+
+	r.resourceVersion = res.Metadata.ResourceVersion
+	return nil
+}
+
+func (r *PrivateKeySetRepo) Get() (key.KeySet, error) {
+	var res signingKeysResource
+	err := r.client.get(signingKeysResourceType, signingKeysName, &res)
+	if err == ErrorNotFound {
+		r.setResourceVersion("")
+		return nil, key.ErrorNoKeys
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.setResourceVersion(res.Metadata.ResourceVersion)
+
+	var pks *key.PrivateKeySet
+	for _, secret := range r.secrets {
+		var j []byte
+		j, err = pcrypto.Decrypt(res.Spec.Value, secret)
+		if err != nil {
+			continue
+		}
+
+		var m privateKeySetModel
+		if err = json.Unmarshal(j, &m); err != nil {
+			continue
+		}
+
+		pks, err = m.PrivateKeySet()
+		if err != nil {
+			continue
+		}
+		break
+	}
+
+	if err != nil {
+		return nil, ErrorCannotDecryptKeys
+	}
+	return key.KeySet(pks), nil
+}
+
+func (r *PrivateKeySetRepo) setResourceVersion(rv string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resourceVersion = rv
+}
+
+func (r *PrivateKeySetRepo) active() []byte {
+	return r.secrets[0]
+}
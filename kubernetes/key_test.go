@@ -0,0 +1,112 @@
+package kubernetes
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/key"
+)
+
+func TestNewPrivateKeySetRepoInvalidKey(t *testing.T) {
+	_, err := NewPrivateKeySetRepo(nil, []byte("sharks"))
+	if err == nil {
+		t.Errorf("Expected non-nil error for key secret that was not 32 bytes")
+	}
+	_, err = NewPrivateKeySetRepo(nil)
+	if err == nil {
+		t.Fatalf("Expected non-nil error when creating repo with no key secrets")
+	}
+}
+
+func newTestPrivateKeySet(t *testing.T) *key.PrivateKeySet {
+	k, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("unable to generate key: %v", err)
+	}
+	return key.NewPrivateKeySet([]*key.PrivateKey{k}, time.Now().Add(time.Hour).UTC().Round(time.Second))
+}
+
+func TestPrivateKeySetRepoSetGet(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+
+	s1 := bytes.Repeat([]byte{'1'}, 32)
+	s2 := bytes.Repeat([]byte{'2'}, 32)
+
+	r, err := NewPrivateKeySetRepo(c, s1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Get(); err != key.ErrorNoKeys {
+		t.Fatalf("want=%v, got=%v", key.ErrorNoKeys, err)
+	}
+
+	want := newTestPrivateKeySet(t)
+	if err := r.Set(want); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Keys remain readable after a new secret is added in front.
+	rotated, err := NewPrivateKeySetRepo(c, s2, s1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ks, err := rotated.Get()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := ks.(*key.PrivateKeySet)
+	if got.Active().ID() != want.Active().ID() || !got.ExpiresAt().Equal(want.ExpiresAt()) {
+		t.Errorf("want key %s expiring at %v, got key %s expiring at %v",
+			want.Active().ID(), want.ExpiresAt(), got.Active().ID(), got.ExpiresAt())
+	}
+
+	other, err := NewPrivateKeySetRepo(c, s2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := other.Get(); err != ErrorCannotDecryptKeys {
+		t.Errorf("want=%v, got=%v", ErrorCannotDecryptKeys, err)
+	}
+}
+
+func TestPrivateKeySetRepoConcurrentSet(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+
+	secret := bytes.Repeat([]byte{'1'}, 32)
+	var repos []*PrivateKeySetRepo
+	for i := 0; i < 2; i++ {
+		r, err := NewPrivateKeySetRepo(c, secret)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		repos = append(repos, r)
+	}
+
+	// Both repos find no keys, and only the first to set them succeeds.
+	for _, r := range repos {
+		if _, err := r.Get(); err != key.ErrorNoKeys {
+			t.Fatalf("want=%v, got=%v", key.ErrorNoKeys, err)
+		}
+	}
+	if err := repos[0].Set(newTestPrivateKeySet(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repos[1].Set(newTestPrivateKeySet(t)); err != ErrorConflict {
+		t.Fatalf("want=%v, got=%v", ErrorConflict, err)
+	}
+
+	// Having read the new keys, the second repo may replace them, after
+	// which the first one is out of date.
+	if _, err := repos[1].Get(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repos[1].Set(newTestPrivateKeySet(t)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repos[0].Set(newTestPrivateKeySet(t)); err != ErrorConflict {
+		t.Fatalf("want=%v, got=%v", ErrorConflict, err)
+	}
+}
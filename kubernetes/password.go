@@ -0,0 +1,130 @@
+package kubernetes
+
+import (
+	"time"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const passwordInfoResourceType = "passwordinfos"
+
+// passwordInfoResource is the PasswordInfo of a user, named after their ID.
+type passwordInfoResource struct {
+	typeMeta
+	Metadata objectMeta       `json:"metadata"`
+	Spec     passwordInfoSpec `json:"spec"`
+}
+
+type passwordInfoSpec struct {
+	UserID          string    `json:"userID"`
+	Password        string    `json:"password"`
+	PasswordExpires time.Time `json:"passwordExpires"`
+	History         []string  `json:"history,omitempty"`
+}
+
+func newPasswordInfoResource(pw user.PasswordInfo) *passwordInfoResource {
+	res := &passwordInfoResource{
+		typeMeta: newTypeMeta("PasswordInfo"),
+		Metadata: objectMeta{
+			Name:   resourceName(pw.UserID),
+			Labels: map[string]string{labelUser: resourceName(pw.UserID)},
+		},
+		Spec: passwordInfoSpec{
+			UserID:          pw.UserID,
+			Password:        string(pw.Password),
+			PasswordExpires: pw.PasswordExpires,
+		},
+	}
+	for _, h := range pw.History {
+		res.Spec.History = append(res.Spec.History, string(h))
+	}
+	return res
+}
+
+func (r *passwordInfoResource) passwordInfo() user.PasswordInfo {
+	pw := user.PasswordInfo{
+		UserID:   r.Spec.UserID,
+		Password: user.Password(r.Spec.Password),
+	}
+	if !r.Spec.PasswordExpires.IsZero() {
+		pw.PasswordExpires = r.Spec.PasswordExpires.UTC()
+	}
+	for _, h := range r.Spec.History {
+		pw.History = append(pw.History, user.Password(h))
+	}
+	return pw
+}
+
+func NewPasswordInfoRepo(c *Client) user.PasswordInfoRepo {
+	return &passwordInfoRepo{client: c}
+}
+
+type passwordInfoRepo struct {
+	client *Client
+}
+
+func (r *passwordInfoRepo) Get(_ repo.Transaction, userID string) (user.PasswordInfo, error) {
+	res, err := r.get(userID)
+	if err != nil {
+		return user.PasswordInfo{}, err
+	}
+	return res.passwordInfo(), nil
+}
+
+func (r *passwordInfoRepo) Create(_ repo.Transaction, pw user.PasswordInfo) error {
+	if pw.UserID == "" {
+		return user.ErrorInvalidID
+	}
+
+	err := r.client.create(passwordInfoResourceType, newPasswordInfoResource(pw))
+	if err == ErrorAlreadyExists {
+		return user.ErrorDuplicateID
+	}
+	return err
+}
+
+func (r *passwordInfoRepo) Update(_ repo.Transaction, pw user.PasswordInfo) error {
+	if pw.UserID == "" {
+		return user.ErrorInvalidID
+	}
+
+	if len(pw.Password) == 0 {
+		return user.ErrorInvalidPassword
+	}
+
+	return retryOnConflict(func() error {
+		res, err := r.get(pw.UserID)
+		if err != nil {
+			return err
+		}
+
+		upd := newPasswordInfoResource(pw)
+		upd.Metadata.ResourceVersion = res.Metadata.ResourceVersion
+		return r.client.update(passwordInfoResourceType, upd.Metadata.Name, upd)
+	})
+}
+
+func (r *passwordInfoRepo) Delete(_ repo.Transaction, userID string) error {
+	if userID == "" {
+		return user.ErrorInvalidID
+	}
+
+	err := r.client.delete(passwordInfoResourceType, resourceName(userID))
+	if err == ErrorNotFound {
+		return user.ErrorNotFound
+	}
+	return err
+}
+
+func (r *passwordInfoRepo) get(userID string) (*passwordInfoResource, error) {
+	var res passwordInfoResource
+	err := r.client.get(passwordInfoResourceType, resourceName(userID), &res)
+	if err == ErrorNotFound {
+		return nil, user.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,254 @@
+package kubernetes
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/refresh"
+	"github.com/coreos/dex/repo"
+)
+
+const (
+	refreshTokenResourceType = "refreshtokens"
+
+	// refreshTokenIDLength is the number of random bytes of the IDs of
+	// refresh tokens, which are encoded in hex to make their resource
+	// names.
+	refreshTokenIDLength = 16
+)
+
+// refreshTokenResource is a refresh token, named after its ID. It holds a
+// hash of the token's payload rather than the payload itself.
+type refreshTokenResource struct {
+	typeMeta
+	Metadata objectMeta       `json:"metadata"`
+	Spec     refreshTokenSpec `json:"spec"`
+}
+
+type refreshTokenSpec struct {
+	PayloadHash string `json:"payloadHash"`
+	UserID      string `json:"userID"`
+	ClientID    string `json:"clientID"`
+}
+
+// buildToken combines the token ID and token payload to create a new token.
+func buildToken(tokenID string, tokenPayload []byte) string {
+	return tokenID + refresh.TokenDelimer + base64.URLEncoding.EncodeToString(tokenPayload)
+}
+
+// parseToken parses a token and returns the token ID and token payload.
+func parseToken(token string) (string, []byte, error) {
+	parts := strings.SplitN(token, refresh.TokenDelimer, 2)
+	if len(parts) != 2 {
+		return "", nil, refresh.ErrorInvalidToken
+	}
+	if _, err := hex.DecodeString(parts[0]); err != nil || len(parts[0]) != 2*refreshTokenIDLength {
+		return "", nil, refresh.ErrorInvalidToken
+	}
+	tokenPayload, err := base64.URLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, refresh.ErrorInvalidToken
+	}
+	return parts[0], tokenPayload, nil
+}
+
+func checkTokenPayload(payloadHash string, payload []byte) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(payloadHash), payload); err != nil {
+		switch err {
+		case bcrypt.ErrMismatchedHashAndPassword:
+			return refresh.ErrorInvalidToken
+		default:
+			return err
+		}
+	}
+	return nil
+}
+
+func NewRefreshTokenRepo(c *Client) refresh.RefreshTokenRepo {
+	return NewRefreshTokenRepoWithTokenGenerator(c, refresh.DefaultRefreshTokenGenerator)
+}
+
+func NewRefreshTokenRepoWithTokenGenerator(c *Client, tokenGenerator refresh.RefreshTokenGenerator) refresh.RefreshTokenRepo {
+	return &refreshTokenRepo{
+		client:         c,
+		tokenGenerator: tokenGenerator,
+	}
+}
+
+type refreshTokenRepo struct {
+	client         *Client
+	tokenGenerator refresh.RefreshTokenGenerator
+}
+
+func (r *refreshTokenRepo) Create(userID, clientID string) (string, error) {
+	if userID == "" {
+		return "", refresh.ErrorInvalidUserID
+	}
+	if clientID == "" {
+		return "", refresh.ErrorInvalidClientID
+	}
+
+	tokenPayload, err := r.tokenGenerator.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	payloadHash, err := bcrypt.GenerateFromPassword(tokenPayload, bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := pcrypto.RandBytes(refreshTokenIDLength)
+	if err != nil {
+		return "", err
+	}
+	tokenID := hex.EncodeToString(b)
+
+	res := &refreshTokenResource{
+		typeMeta: newTypeMeta("RefreshToken"),
+		Metadata: objectMeta{
+			Name:   tokenID,
+			Labels: map[string]string{labelUser: resourceName(userID)},
+		},
+		Spec: refreshTokenSpec{
+			PayloadHash: string(payloadHash),
+			UserID:      userID,
+			ClientID:    clientID,
+		},
+	}
+	if err := r.client.create(refreshTokenResourceType, res); err != nil {
+		return "", err
+	}
+
+	return buildToken(tokenID, tokenPayload), nil
+}
+
+func (r *refreshTokenRepo) Verify(clientID, token string) (string, error) {
+	tokenID, tokenPayload, err := parseToken(token)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := r.get(tokenID)
+	if err != nil {
+		return "", err
+	}
+
+	if res.Spec.ClientID != clientID {
+		return "", refresh.ErrorInvalidClientID
+	}
+
+	if err := checkTokenPayload(res.Spec.PayloadHash, tokenPayload); err != nil {
+		return "", err
+	}
+
+	return res.Spec.UserID, nil
+}
+
+func (r *refreshTokenRepo) Revoke(userID, token string) error {
+	tokenID, tokenPayload, err := parseToken(token)
+	if err != nil {
+		return err
+	}
+
+	res, err := r.get(tokenID)
+	if err != nil {
+		return err
+	}
+
+	if res.Spec.UserID != userID {
+		return refresh.ErrorInvalidUserID
+	}
+
+	if err := checkTokenPayload(res.Spec.PayloadHash, tokenPayload); err != nil {
+		return err
+	}
+
+	err = r.client.delete(refreshTokenResourceType, tokenID)
+	if err == ErrorNotFound {
+		return refresh.ErrorInvalidToken
+	}
+	return err
+}
+
+func (r *refreshTokenRepo) ClientsWithRefreshTokens(userID string) ([]string, error) {
+	ress, err := r.tokensForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var clientIDs []string
+	for _, res := range ress {
+		if !seen[res.Spec.ClientID] {
+			seen[res.Spec.ClientID] = true
+			clientIDs = append(clientIDs, res.Spec.ClientID)
+		}
+	}
+	sort.Strings(clientIDs)
+	return clientIDs, nil
+}
+
+func (r *refreshTokenRepo) RevokeTokensForClient(userID, clientID string) error {
+	ress, err := r.tokensForUser(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range ress {
+		if res.Spec.ClientID != clientID {
+			continue
+		}
+		if err := r.client.delete(refreshTokenResourceType, res.Metadata.Name); err != nil && err != ErrorNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *refreshTokenRepo) RevokeTokensForUser(_ repo.Transaction, userID string) error {
+	ress, err := r.tokensForUser(userID)
+	if err != nil {
+		return err
+	}
+
+	for _, res := range ress {
+		if err := r.client.delete(refreshTokenResourceType, res.Metadata.Name); err != nil && err != ErrorNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *refreshTokenRepo) get(tokenID string) (*refreshTokenResource, error) {
+	var res refreshTokenResource
+	err := r.client.get(refreshTokenResourceType, tokenID, &res)
+	if err == ErrorNotFound {
+		return nil, refresh.ErrorInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// tokensForUser returns the refresh tokens of the given user.
+func (r *refreshTokenRepo) tokensForUser(userID string) ([]refreshTokenResource, error) {
+	var ress []refreshTokenResource
+	if err := r.client.list(refreshTokenResourceType, map[string]string{labelUser: resourceName(userID)}, &ress); err != nil {
+		return nil, err
+	}
+
+	var tokens []refreshTokenResource
+	for _, res := range ress {
+		if res.Spec.UserID == userID {
+			tokens = append(tokens, res)
+		}
+	}
+	return tokens, nil
+}
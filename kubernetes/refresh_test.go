@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/coreos/dex/refresh"
+)
+
+func TestBuildAndParseToken(t *testing.T) {
+	tests := []struct {
+		id      string
+		payload []byte
+	}{
+		{"0123456789abcdef0123456789abcdef", []byte("may the force be with you")},
+		{"ffffffffffffffffffffffffffffffff", []byte{0xd3, 0x22, 0xa8, 0x44, 0x34, 0x94, 0xd8}},
+	}
+
+	for i, tt := range tests {
+		id, payload, err := parseToken(buildToken(tt.id, tt.payload))
+		if err != nil {
+			t.Errorf("case %d: failed to parse token: %v", i, err)
+			continue
+		}
+		if tt.id != id {
+			t.Errorf("case %d: want id=%s, got id=%s", i, tt.id, id)
+		}
+		if bytes.Compare(tt.payload, payload) != 0 {
+			t.Errorf("case %d: want payload=%x, got payload=%x", i, tt.payload, payload)
+		}
+	}
+
+	for i, tok := range []string{"", "abc", "1/Zm9v", "0123456789abcdef0123456789abcdeg/Zm9v", "0123456789abcdef0123456789abcdef/!"} {
+		if _, _, err := parseToken(tok); err != refresh.ErrorInvalidToken {
+			t.Errorf("case %d: want=%v, got=%v", i, refresh.ErrorInvalidToken, err)
+		}
+	}
+}
+
+func TestRefreshTokenRepo(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+	r := NewRefreshTokenRepo(c)
+
+	tok1, err := r.Create("user1", "client1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok2, err := r.Create("user1", "client2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok3, err := r.Create("user2", "client1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if userID, err := r.Verify("client1", tok1); err != nil || userID != "user1" {
+		t.Errorf("want user1, got %q, %v", userID, err)
+	}
+	if _, err := r.Verify("client2", tok1); err != refresh.ErrorInvalidClientID {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidClientID, err)
+	}
+	id, _, _ := parseToken(tok1)
+	if _, err := r.Verify("client1", buildToken(id, []byte("forged"))); err != refresh.ErrorInvalidToken {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidToken, err)
+	}
+
+	clientIDs, err := r.ClientsWithRefreshTokens("user1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"client1", "client2"}; !reflect.DeepEqual(want, clientIDs) {
+		t.Errorf("want=%v, got=%v", want, clientIDs)
+	}
+
+	if err := r.Revoke("user2", tok1); err != refresh.ErrorInvalidUserID {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidUserID, err)
+	}
+	if err := r.RevokeTokensForClient("user1", "client1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Verify("client1", tok1); err != refresh.ErrorInvalidToken {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidToken, err)
+	}
+	if _, err := r.Verify("client2", tok2); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := r.RevokeTokensForUser(nil, "user1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Verify("client2", tok2); err != refresh.ErrorInvalidToken {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidToken, err)
+	}
+
+	// Tokens of other users are left alone.
+	if err := r.Revoke("user2", tok3); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := r.Revoke("user2", tok3); err != refresh.ErrorInvalidToken {
+		t.Errorf("want=%v, got=%v", refresh.ErrorInvalidToken, err)
+	}
+}
@@ -0,0 +1,292 @@
+package kubernetes
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	sessionResourceType    = "sessions"
+	sessionKeyResourceType = "sessionkeys"
+)
+
+// sessionResource is a Session, named after its ID.
+type sessionResource struct {
+	typeMeta
+	Metadata objectMeta  `json:"metadata"`
+	Spec     sessionSpec `json:"spec"`
+}
+
+type sessionSpec struct {
+	ID          string        `json:"id"`
+	State       string        `json:"state"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	ExpiresAt   time.Time     `json:"expiresAt"`
+	ClientID    string        `json:"clientID"`
+	ClientState string        `json:"clientState,omitempty"`
+	RedirectURL string        `json:"redirectURL"`
+	Identity    oidc.Identity `json:"identity"`
+	Profile     user.Profile  `json:"profile"`
+	ConnectorID string        `json:"connectorID"`
+	UserID      string        `json:"userID,omitempty"`
+	Register    bool          `json:"register,omitempty"`
+	Nonce       string        `json:"nonce,omitempty"`
+	Scope       []string      `json:"scope,omitempty"`
+	AMR         []string      `json:"amr,omitempty"`
+	LinkToken   string        `json:"linkToken,omitempty"`
+}
+
+// sessionKeyResource is a SessionKey, named after the key.
+type sessionKeyResource struct {
+	typeMeta
+	Metadata objectMeta     `json:"metadata"`
+	Spec     sessionKeySpec `json:"spec"`
+}
+
+type sessionKeySpec struct {
+	Key       string    `json:"key"`
+	SessionID string    `json:"sessionID"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func newSessionResource(s session.Session) *sessionResource {
+	return &sessionResource{
+		typeMeta: newTypeMeta("Session"),
+		Metadata: objectMeta{
+			Name:   resourceName(s.ID),
+			Labels: map[string]string{labelUser: resourceName(s.UserID)},
+		},
+		Spec: sessionSpec{
+			ID:          s.ID,
+			State:       string(s.State),
+			CreatedAt:   s.CreatedAt,
+			ExpiresAt:   s.ExpiresAt,
+			ClientID:    s.ClientID,
+			ClientState: s.ClientState,
+			RedirectURL: s.RedirectURL.String(),
+			Identity:    s.Identity,
+			Profile:     s.Profile,
+			ConnectorID: s.ConnectorID,
+			UserID:      s.UserID,
+			Register:    s.Register,
+			Nonce:       s.Nonce,
+			Scope:       s.Scope,
+			AMR:         s.AMR,
+			LinkToken:   s.LinkToken,
+		},
+	}
+}
+
+func (r *sessionResource) session() (*session.Session, error) {
+	ru, err := url.Parse(r.Spec.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	s := session.Session{
+		ID:          r.Spec.ID,
+		State:       session.SessionState(r.Spec.State),
+		ClientID:    r.Spec.ClientID,
+		ClientState: r.Spec.ClientState,
+		RedirectURL: *ru,
+		Identity:    r.Spec.Identity,
+		Profile:     r.Spec.Profile,
+		ConnectorID: r.Spec.ConnectorID,
+		UserID:      r.Spec.UserID,
+		Register:    r.Spec.Register,
+		Nonce:       r.Spec.Nonce,
+		Scope:       r.Spec.Scope,
+		AMR:         r.Spec.AMR,
+		LinkToken:   r.Spec.LinkToken,
+	}
+
+	// Zero times are decoded in UTC rather than without a location.
+	if s.Identity.ExpiresAt.IsZero() {
+		s.Identity.ExpiresAt = time.Time{}
+	}
+	if !r.Spec.CreatedAt.IsZero() {
+		s.CreatedAt = r.Spec.CreatedAt.UTC()
+	}
+	if !r.Spec.ExpiresAt.IsZero() {
+		s.ExpiresAt = r.Spec.ExpiresAt.UTC()
+	}
+	return &s, nil
+}
+
+func NewSessionRepo(c *Client) *SessionRepo {
+	return NewSessionRepoWithClock(c, clockwork.NewRealClock())
+}
+
+func NewSessionRepoWithClock(c *Client, clock clockwork.Clock) *SessionRepo {
+	return &SessionRepo{client: c, clock: clock}
+}
+
+type SessionRepo struct {
+	client *Client
+	clock  clockwork.Clock
+}
+
+func (r *SessionRepo) Get(sessionID string) (*session.Session, error) {
+	var res sessionResource
+	err := r.client.get(sessionResourceType, resourceName(sessionID), &res)
+	if err == ErrorNotFound {
+		return nil, errors.New("session does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := res.session()
+	if err != nil {
+		return nil, err
+	}
+	if s.ExpiresAt.Before(r.clock.Now()) {
+		return nil, errors.New("session does not exist")
+	}
+	return s, nil
+}
+
+func (r *SessionRepo) Create(s session.Session) error {
+	return r.client.create(sessionResourceType, newSessionResource(s))
+}
+
+func (r *SessionRepo) Update(s session.Session) error {
+	upd := newSessionResource(s)
+	return retryOnConflict(func() error {
+		var res sessionResource
+		err := r.client.get(sessionResourceType, upd.Metadata.Name, &res)
+		if err == ErrorNotFound {
+			return errors.New("session does not exist")
+		}
+		if err != nil {
+			return err
+		}
+
+		upd.Metadata.ResourceVersion = res.Metadata.ResourceVersion
+		return r.client.update(sessionResourceType, upd.Metadata.Name, upd)
+	})
+}
+
+func (r *SessionRepo) DeleteByUserID(_ repo.Transaction, userID string) error {
+	return r.client.deleteAll(sessionResourceType, map[string]string{labelUser: resourceName(userID)})
+}
+
+// purge removes expired sessions and those whose code has been exchanged.
+func (r *SessionRepo) purge() error {
+	var ress []sessionResource
+	if err := r.client.list(sessionResourceType, nil, &ress); err != nil {
+		return err
+	}
+
+	now := r.clock.Now()
+	var n int
+	for _, res := range ress {
+		if !res.Spec.ExpiresAt.Before(now) && res.Spec.State != string(session.SessionStateDead) {
+			continue
+		}
+		err := r.client.delete(sessionResourceType, res.Metadata.Name)
+		if err == ErrorNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		n++
+	}
+
+	if n > 0 {
+		log.Infof("Deleted %d stale session(s)", n)
+	}
+	return nil
+}
+
+func NewSessionKeyRepo(c *Client) *SessionKeyRepo {
+	return NewSessionKeyRepoWithClock(c, clockwork.NewRealClock())
+}
+
+func NewSessionKeyRepoWithClock(c *Client, clock clockwork.Clock) *SessionKeyRepo {
+	return &SessionKeyRepo{client: c, clock: clock}
+}
+
+type SessionKeyRepo struct {
+	client *Client
+	clock  clockwork.Clock
+}
+
+func (r *SessionKeyRepo) Push(sk session.SessionKey, exp time.Duration) error {
+	res := &sessionKeyResource{
+		typeMeta: newTypeMeta("SessionKey"),
+		Metadata: objectMeta{Name: resourceName(sk.Key)},
+		Spec: sessionKeySpec{
+			Key:       sk.Key,
+			SessionID: sk.SessionID,
+			ExpiresAt: r.clock.Now().Add(exp).UTC(),
+		},
+	}
+	return r.client.create(sessionKeyResourceType, res)
+}
+
+// Pop returns the session ID of a key and deletes it. Of several
+// concurrent calls for the same key, only the one which deletes it
+// succeeds.
+func (r *SessionKeyRepo) Pop(key string) (string, error) {
+	var res sessionKeyResource
+	err := r.client.get(sessionKeyResourceType, resourceName(key), &res)
+	if err == ErrorNotFound {
+		return "", errors.New("session key does not exist")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = r.client.deleteAt(sessionKeyResourceType, res.Metadata.Name, res.Metadata.ResourceVersion)
+	if err == ErrorNotFound || err == ErrorConflict {
+		return "", errors.New("failed to pop entity")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if res.Spec.ExpiresAt.Before(r.clock.Now()) {
+		return "", errors.New("invalid session key")
+	}
+	return res.Spec.SessionID, nil
+}
+
+// purge removes expired session keys.
+func (r *SessionKeyRepo) purge() error {
+	var ress []sessionKeyResource
+	if err := r.client.list(sessionKeyResourceType, nil, &ress); err != nil {
+		return err
+	}
+
+	now := r.clock.Now()
+	var n int
+	for _, res := range ress {
+		if !res.Spec.ExpiresAt.Before(now) {
+			continue
+		}
+		err := r.client.delete(sessionKeyResourceType, res.Metadata.Name)
+		if err == ErrorNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		n++
+	}
+
+	if n > 0 {
+		log.Infof("Deleted %d expired session key(s)", n)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/session"
+)
+
+func TestSessionRepoPurge(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+	fc := clockwork.NewFakeClock()
+	r := NewSessionRepoWithClock(c, fc)
+
+	now := fc.Now().UTC()
+	for _, s := range []session.Session{
+		{ID: "live", State: session.SessionStateNew, ExpiresAt: now.Add(time.Hour)},
+		{ID: "expired", State: session.SessionStateNew, ExpiresAt: now.Add(-time.Minute)},
+		{ID: "dead", State: session.SessionStateDead, ExpiresAt: now.Add(time.Hour)},
+	} {
+		s.RedirectURL = url.URL{Scheme: "https", Host: "example.com"}
+		if err := r.Create(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := r.purge(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var ress []sessionResource
+	if err := c.list(sessionResourceType, nil, &ress); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ress) != 1 || ress[0].Spec.ID != "live" {
+		t.Errorf("want only the live session left, got %v", ress)
+	}
+}
+
+func TestSessionKeyRepoPopOnce(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+	fc := clockwork.NewFakeClock()
+	r := NewSessionKeyRepoWithClock(c, fc)
+
+	if err := r.Push(session.SessionKey{Key: "key", SessionID: "session"}, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Push(session.SessionKey{Key: "expired", SessionID: "session"}, time.Second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fc.Advance(2 * time.Second)
+
+	if err := r.purge(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Pop("expired"); err == nil {
+		t.Errorf("want error popping purged key")
+	}
+
+	if id, err := r.Pop("key"); err != nil || id != "session" {
+		t.Fatalf("want session, got %q, %v", id, err)
+	}
+	if _, err := r.Pop("key"); err == nil {
+		t.Errorf("want error popping key twice")
+	}
+}
@@ -0,0 +1,542 @@
+package kubernetes
+
+import (
+	"sort"
+	"time"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	userResourceType           = "users"
+	userEmailResourceType      = "useremails"
+	remoteIdentityResourceType = "remoteidentities"
+)
+
+// userResource is a User, named after their ID.
+type userResource struct {
+	typeMeta
+	Metadata objectMeta `json:"metadata"`
+	Spec     userSpec   `json:"spec"`
+}
+
+type userSpec struct {
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	EmailVerified bool      `json:"emailVerified,omitempty"`
+	DisplayName   string    `json:"displayName,omitempty"`
+	GivenName     string    `json:"givenName,omitempty"`
+	FamilyName    string    `json:"familyName,omitempty"`
+	Picture       string    `json:"picture,omitempty"`
+	Locale        string    `json:"locale,omitempty"`
+	Admin         bool      `json:"admin,omitempty"`
+	Disabled      bool      `json:"disabled,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// userEmailResource records the user an email address belongs to. It is
+// named after the address, so that no two users can claim the same one.
+type userEmailResource struct {
+	typeMeta
+	Metadata objectMeta    `json:"metadata"`
+	Spec     userEmailSpec `json:"spec"`
+}
+
+type userEmailSpec struct {
+	Email  string `json:"email"`
+	UserID string `json:"userID"`
+}
+
+// remoteIdentityResource links a remote identity to a user. It is named
+// after the remote identity, so that it can only be linked to one user.
+type remoteIdentityResource struct {
+	typeMeta
+	Metadata objectMeta         `json:"metadata"`
+	Spec     remoteIdentitySpec `json:"spec"`
+}
+
+type remoteIdentitySpec struct {
+	ConnectorID string `json:"connectorID"`
+	RemoteID    string `json:"remoteID"`
+	UserID      string `json:"userID"`
+}
+
+func newUserResource(u user.User) *userResource {
+	return &userResource{
+		typeMeta: newTypeMeta("User"),
+		Metadata: objectMeta{Name: resourceName(u.ID)},
+		Spec: userSpec{
+			ID:            u.ID,
+			Email:         u.Email,
+			EmailVerified: u.EmailVerified,
+			DisplayName:   u.DisplayName,
+			GivenName:     u.GivenName,
+			FamilyName:    u.FamilyName,
+			Picture:       u.Picture,
+			Locale:        u.Locale,
+			Admin:         u.Admin,
+			Disabled:      u.Disabled,
+			CreatedAt:     u.CreatedAt,
+		},
+	}
+}
+
+func (r *userResource) user() user.User {
+	u := user.User{
+		ID:            r.Spec.ID,
+		Email:         r.Spec.Email,
+		EmailVerified: r.Spec.EmailVerified,
+		DisplayName:   r.Spec.DisplayName,
+		GivenName:     r.Spec.GivenName,
+		FamilyName:    r.Spec.FamilyName,
+		Picture:       r.Spec.Picture,
+		Locale:        r.Spec.Locale,
+		Admin:         r.Spec.Admin,
+		Disabled:      r.Spec.Disabled,
+	}
+	if !r.Spec.CreatedAt.IsZero() {
+		u.CreatedAt = r.Spec.CreatedAt.UTC()
+	}
+	return u
+}
+
+func NewUserRepo(c *Client) user.UserRepo {
+	return &userRepo{client: c}
+}
+
+func NewUserRepoFromUsers(c *Client, us []user.UserWithRemoteIdentities) (user.UserRepo, error) {
+	r := &userRepo{client: c}
+	for _, u := range us {
+		if err := r.Create(nil, u.User); err != nil {
+			return nil, err
+		}
+		for _, ri := range u.RemoteIdentities {
+			if err := r.AddRemoteIdentity(nil, u.User.ID, ri); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// userRepo keeps users in custom resources. The resources of a user, their
+// email address and remote identities are written one by one rather than
+// in a transaction, so an email address or remote identity may be left
+// claimed by a user which no longer exists. Such claims are ignored and
+// replaced as they are found.
+type userRepo struct {
+	client *Client
+}
+
+func (r *userRepo) Get(_ repo.Transaction, id string) (user.User, error) {
+	res, err := r.get(id)
+	if err != nil {
+		return user.User{}, err
+	}
+	return res.user(), nil
+}
+
+func (r *userRepo) Create(_ repo.Transaction, usr user.User) error {
+	if usr.ID == "" {
+		return user.ErrorInvalidID
+	}
+
+	_, err := r.get(usr.ID)
+	if err == nil {
+		return user.ErrorDuplicateID
+	}
+	if err != user.ErrorNotFound {
+		return err
+	}
+
+	if !user.ValidEmail(usr.Email) {
+		return user.ErrorInvalidEmail
+	}
+
+	if err := r.claimEmail(usr.Email, usr.ID); err != nil {
+		return err
+	}
+
+	err = r.client.create(userResourceType, newUserResource(usr))
+	if err == ErrorAlreadyExists {
+		err = user.ErrorDuplicateID
+	}
+	if err != nil {
+		r.releaseEmail(usr.Email, usr.ID)
+	}
+	return err
+}
+
+func (r *userRepo) GetByEmail(_ repo.Transaction, email string) (user.User, error) {
+	res, err := r.getByEmail(email)
+	if err != nil {
+		return user.User{}, err
+	}
+	return res.user(), nil
+}
+
+func (r *userRepo) Disable(_ repo.Transaction, id string, disabled bool) error {
+	if id == "" {
+		return user.ErrorInvalidID
+	}
+
+	return retryOnConflict(func() error {
+		res, err := r.get(id)
+		if err != nil {
+			return err
+		}
+		res.Spec.Disabled = disabled
+		return r.client.update(userResourceType, res.Metadata.Name, res)
+	})
+}
+
+func (r *userRepo) Delete(_ repo.Transaction, id string) error {
+	if id == "" {
+		return user.ErrorInvalidID
+	}
+
+	res, err := r.get(id)
+	if err != nil {
+		return err
+	}
+
+	labels := map[string]string{labelUser: resourceName(id)}
+	if err := r.client.deleteAll(remoteIdentityResourceType, labels); err != nil {
+		return err
+	}
+
+	err = r.client.delete(userResourceType, res.Metadata.Name)
+	if err == ErrorNotFound {
+		return user.ErrorNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	r.releaseEmail(res.Spec.Email, id)
+	return nil
+}
+
+func (r *userRepo) Update(_ repo.Transaction, usr user.User) error {
+	if usr.ID == "" {
+		return user.ErrorInvalidID
+	}
+
+	if !user.ValidEmail(usr.Email) {
+		return user.ErrorInvalidEmail
+	}
+
+	var oldEmail string
+	var claimed bool
+	err := retryOnConflict(func() error {
+		res, err := r.get(usr.ID)
+		if err != nil {
+			return err
+		}
+		oldEmail = res.Spec.Email
+		if oldEmail != usr.Email && !claimed {
+			if err := r.claimEmail(usr.Email, usr.ID); err != nil {
+				return err
+			}
+			claimed = true
+		}
+
+		upd := newUserResource(usr)
+		upd.Metadata = res.Metadata
+		return r.client.update(userResourceType, upd.Metadata.Name, upd)
+	})
+	if err != nil {
+		if claimed {
+			r.releaseEmail(usr.Email, usr.ID)
+		}
+		return err
+	}
+
+	if oldEmail != usr.Email {
+		r.releaseEmail(oldEmail, usr.ID)
+	}
+	return nil
+}
+
+func (r *userRepo) GetByRemoteIdentity(_ repo.Transaction, ri user.RemoteIdentity) (user.User, error) {
+	var res remoteIdentityResource
+	err := r.client.get(remoteIdentityResourceType, resourceName(ri.ConnectorID, ri.ID), &res)
+	if err == ErrorNotFound {
+		return user.User{}, user.ErrorNotFound
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+
+	return r.Get(nil, res.Spec.UserID)
+}
+
+func (r *userRepo) AddRemoteIdentity(_ repo.Transaction, userID string, ri user.RemoteIdentity) error {
+	if _, err := r.get(userID); err != nil {
+		return err
+	}
+
+	res := &remoteIdentityResource{
+		typeMeta: newTypeMeta("RemoteIdentity"),
+		Metadata: objectMeta{
+			Name:   resourceName(ri.ConnectorID, ri.ID),
+			Labels: map[string]string{labelUser: resourceName(userID)},
+		},
+		Spec: remoteIdentitySpec{
+			ConnectorID: ri.ConnectorID,
+			RemoteID:    ri.ID,
+			UserID:      userID,
+		},
+	}
+
+	err := r.client.create(remoteIdentityResourceType, res)
+	if err != ErrorAlreadyExists {
+		return err
+	}
+
+	// The remote identity may be linked to a user which no longer exists,
+	// in which case the link is taken over.
+	return retryOnConflict(func() error {
+		var other remoteIdentityResource
+		err := r.client.get(remoteIdentityResourceType, res.Metadata.Name, &other)
+		if err == ErrorNotFound {
+			res.Metadata.ResourceVersion = ""
+			return r.client.createOrConflict(remoteIdentityResourceType, res)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := r.get(other.Spec.UserID); err != user.ErrorNotFound {
+			if err == nil {
+				err = user.ErrorDuplicateRemoteIdentity
+			}
+			return err
+		}
+
+		res.Metadata.ResourceVersion = other.Metadata.ResourceVersion
+		return r.client.update(remoteIdentityResourceType, res.Metadata.Name, res)
+	})
+}
+
+func (r *userRepo) RemoveRemoteIdentity(_ repo.Transaction, userID string, ri user.RemoteIdentity) error {
+	if userID == "" || ri.ID == "" || ri.ConnectorID == "" {
+		return user.ErrorInvalidID
+	}
+
+	name := resourceName(ri.ConnectorID, ri.ID)
+	var res remoteIdentityResource
+	err := r.client.get(remoteIdentityResourceType, name, &res)
+	if err == ErrorNotFound {
+		return user.ErrorNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if res.Spec.UserID != userID {
+		return user.ErrorNotFound
+	}
+
+	err = r.client.delete(remoteIdentityResourceType, name)
+	if err == ErrorNotFound {
+		return user.ErrorNotFound
+	}
+	return err
+}
+
+func (r *userRepo) GetRemoteIdentities(_ repo.Transaction, userID string) ([]user.RemoteIdentity, error) {
+	if userID == "" {
+		return nil, user.ErrorInvalidID
+	}
+
+	ress, err := r.remoteIdentities(map[string]string{labelUser: resourceName(userID)})
+	if err != nil {
+		return nil, err
+	}
+
+	var ris []user.RemoteIdentity
+	for _, res := range ress {
+		if res.Spec.UserID == userID {
+			ris = append(ris, user.RemoteIdentity{
+				ConnectorID: res.Spec.ConnectorID,
+				ID:          res.Spec.RemoteID,
+			})
+		}
+	}
+	return ris, nil
+}
+
+func (r *userRepo) GetAdminCount(_ repo.Transaction) (int, error) {
+	var ress []userResource
+	if err := r.client.list(userResourceType, nil, &ress); err != nil {
+		return 0, err
+	}
+
+	var n int
+	for _, res := range ress {
+		if res.Spec.Admin {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *userRepo) List(_ repo.Transaction, filter user.UserFilter, maxResults int, nextPageToken string) ([]user.User, string, error) {
+	var offset int
+	var err error
+	if nextPageToken != "" {
+		filter, maxResults, offset, err = user.DecodeNextPageToken(nextPageToken)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	var ress []userResource
+	if err := r.client.list(userResourceType, nil, &ress); err != nil {
+		return nil, "", err
+	}
+
+	// Remote identities are only needed to filter by connector.
+	ridsByUserID := make(map[string][]user.RemoteIdentity)
+	if filter.ConnectorID != "" {
+		riRess, err := r.remoteIdentities(nil)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, res := range riRess {
+			ridsByUserID[res.Spec.UserID] = append(ridsByUserID[res.Spec.UserID], user.RemoteIdentity{
+				ConnectorID: res.Spec.ConnectorID,
+				ID:          res.Spec.RemoteID,
+			})
+		}
+	}
+
+	var users []user.User
+	for _, res := range ress {
+		u := res.user()
+		if filter.Matches(u, ridsByUserID[u.ID]) {
+			users = append(users, u)
+		}
+	}
+	sort.Sort(usersByEmail(users))
+
+	if offset >= len(users) {
+		return nil, "", user.ErrorNotFound
+	}
+
+	high := offset + maxResults
+	var tok string
+	if high < len(users) {
+		if tok, err = user.EncodeNextPageToken(filter, maxResults, high); err != nil {
+			return nil, "", err
+		}
+	} else {
+		high = len(users)
+	}
+	return users[offset:high], tok, nil
+}
+
+func (r *userRepo) get(id string) (*userResource, error) {
+	var res userResource
+	err := r.client.get(userResourceType, resourceName(id), &res)
+	if err == ErrorNotFound {
+		return nil, user.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+func (r *userRepo) getByEmail(email string) (*userResource, error) {
+	var claim userEmailResource
+	err := r.client.get(userEmailResourceType, resourceName(email), &claim)
+	if err == ErrorNotFound {
+		return nil, user.ErrorNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := r.get(claim.Spec.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if res.Spec.Email != email {
+		return nil, user.ErrorNotFound
+	}
+	return res, nil
+}
+
+// claimEmail records that the email address belongs to the user with the
+// given ID. ErrorDuplicateEmail is returned if it belongs to another user.
+func (r *userRepo) claimEmail(email, userID string) error {
+	res := &userEmailResource{
+		typeMeta: newTypeMeta("UserEmail"),
+		Metadata: objectMeta{Name: resourceName(email)},
+		Spec: userEmailSpec{
+			Email:  email,
+			UserID: userID,
+		},
+	}
+
+	err := r.client.create(userEmailResourceType, res)
+	if err != ErrorAlreadyExists {
+		return err
+	}
+
+	// The address may be recorded for a user which no longer exists, or
+	// has since changed theirs, in which case the record is taken over.
+	return retryOnConflict(func() error {
+		var other userEmailResource
+		err := r.client.get(userEmailResourceType, res.Metadata.Name, &other)
+		if err == ErrorNotFound {
+			res.Metadata.ResourceVersion = ""
+			return r.client.createOrConflict(userEmailResourceType, res)
+		}
+		if err != nil {
+			return err
+		}
+
+		owner, err := r.get(other.Spec.UserID)
+		if err == nil && owner.Spec.Email == email {
+			return user.ErrorDuplicateEmail
+		}
+		if err != nil && err != user.ErrorNotFound {
+			return err
+		}
+
+		res.Metadata.ResourceVersion = other.Metadata.ResourceVersion
+		return r.client.update(userEmailResourceType, res.Metadata.Name, res)
+	})
+}
+
+// releaseEmail removes the record of an email address belonging to a user,
+// unless it has been taken over by another user since. Failures are
+// ignored, as records left behind are taken over when the address is
+// claimed again.
+func (r *userRepo) releaseEmail(email, userID string) {
+	var claim userEmailResource
+	if err := r.client.get(userEmailResourceType, resourceName(email), &claim); err != nil {
+		return
+	}
+	if claim.Spec.UserID != userID {
+		return
+	}
+	r.client.deleteAt(userEmailResourceType, claim.Metadata.Name, claim.Metadata.ResourceVersion)
+}
+
+func (r *userRepo) remoteIdentities(labels map[string]string) ([]remoteIdentityResource, error) {
+	var ress []remoteIdentityResource
+	if err := r.client.list(remoteIdentityResourceType, labels, &ress); err != nil {
+		return nil, err
+	}
+	return ress, nil
+}
+
+type usersByEmail []user.User
+
+func (s usersByEmail) Len() int           { return len(s) }
+func (s usersByEmail) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s usersByEmail) Less(i, j int) bool { return s[i].Email < s[j].Email }
@@ -0,0 +1,96 @@
+package kubernetes
+
+import (
+	"testing"
+
+	"github.com/coreos/dex/user"
+)
+
+func TestUserRepoTakesOverStaleClaims(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+	r := NewUserRepo(c)
+
+	ri := user.RemoteIdentity{ConnectorID: "local", ID: "remote"}
+	if err := r.Create(nil, user.User{ID: "ID-1", Email: "one@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.AddRemoteIdentity(nil, "ID-1", ri); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Leave the claims of the user behind, as a worker failing half way
+	// through a deletion would.
+	if err := c.delete(userResourceType, resourceName("ID-1")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := r.GetByEmail(nil, "one@example.com"); err != user.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+	if _, err := r.GetByRemoteIdentity(nil, ri); err != user.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+
+	if err := r.Create(nil, user.User{ID: "ID-2", Email: "one@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.AddRemoteIdentity(nil, "ID-2", ri); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if u, err := r.GetByEmail(nil, "one@example.com"); err != nil || u.ID != "ID-2" {
+		t.Errorf("want ID-2, got %q, %v", u.ID, err)
+	}
+	if u, err := r.GetByRemoteIdentity(nil, ri); err != nil || u.ID != "ID-2" {
+		t.Errorf("want ID-2, got %q, %v", u.ID, err)
+	}
+
+	// Live claims are not taken over.
+	if err := r.Create(nil, user.User{ID: "ID-3", Email: "one@example.com"}); err != user.ErrorDuplicateEmail {
+		t.Errorf("want=%v, got=%v", user.ErrorDuplicateEmail, err)
+	}
+	if err := r.Create(nil, user.User{ID: "ID-3", Email: "three@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.AddRemoteIdentity(nil, "ID-3", ri); err != user.ErrorDuplicateRemoteIdentity {
+		t.Errorf("want=%v, got=%v", user.ErrorDuplicateRemoteIdentity, err)
+	}
+}
+
+func TestUserRepoUpdateEmail(t *testing.T) {
+	c, srv := newTestClient(t)
+	defer srv.Close()
+	r := NewUserRepo(c)
+
+	for _, u := range []user.User{
+		{ID: "ID-1", Email: "one@example.com"},
+		{ID: "ID-2", Email: "two@example.com"},
+	} {
+		if err := r.Create(nil, u); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := r.Update(nil, user.User{ID: "ID-1", Email: "two@example.com"}); err != user.ErrorDuplicateEmail {
+		t.Errorf("want=%v, got=%v", user.ErrorDuplicateEmail, err)
+	}
+	if err := r.Update(nil, user.User{ID: "ID-1", Email: "new@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The old address is released, and may be taken by another user.
+	var claim userEmailResource
+	if err := c.get(userEmailResourceType, resourceName("one@example.com"), &claim); err != ErrorNotFound {
+		t.Errorf("want=%v, got=%v", ErrorNotFound, err)
+	}
+	if err := r.Update(nil, user.User{ID: "ID-2", Email: "one@example.com"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if u, err := r.GetByEmail(nil, "one@example.com"); err != nil || u.ID != "ID-2" {
+		t.Errorf("want ID-2, got %q, %v", u.ID, err)
+	}
+	if _, err := r.GetByEmail(nil, "two@example.com"); err != user.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+}
@@ -143,6 +143,9 @@ func Decrypt(ciphertext, key []byte) (plaintext []byte, err error) {
 		return nil, err
 	}
 
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
 	return gcm.Open(nil, ciphertext[:gcm.NonceSize()],
 		ciphertext[gcm.NonceSize():], nil)
 }
@@ -3,6 +3,7 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"net"
 	"net/http"
 	"net/url"
 	"path"
@@ -128,3 +129,61 @@ func CopyRequest(r *http.Request) *http.Request {
 	}
 	return &r2
 }
+
+// RemoteIP returns the address of the client which sent r, without the port.
+func RemoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// TrustedProxyHandler sets the remote address of requests sent by one of the
+// given proxies to the client address they forwarded in X-Forwarded-For, so
+// that RemoteIP returns the client rather than the proxy. The header is read
+// from the right, skipping the addresses of further trusted proxies, as its
+// leftmost entries are set by the client and may be forged. Requests from
+// other addresses are passed on unchanged.
+func TrustedProxyHandler(h http.Handler, proxies []*net.IPNet) http.Handler {
+	trusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+		for _, n := range proxies {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !trusted(RemoteIP(r)) {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		var client string
+		hops := strings.Split(strings.Join(r.Header["X-Forwarded-For"], ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr := strings.TrimSpace(hops[i])
+			if addr == "" {
+				continue
+			}
+			client = addr
+			if !trusted(addr) {
+				break
+			}
+		}
+		if net.ParseIP(client) == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		r2 := *r
+		r2.RemoteAddr = client
+		h.ServeHTTP(w, &r2)
+	})
+}
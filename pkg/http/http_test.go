@@ -1,6 +1,7 @@
 package http
 
 import (
+	"net"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -378,3 +379,62 @@ func TestCopyRequest(t *testing.T) {
 		t.Fatalf("Result of CopyRequest incorrect: %#v != %#v", r1, r2)
 	}
 }
+
+func TestRemoteIP(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{addr: "192.0.2.1:40000", want: "192.0.2.1"},
+		{addr: "[2001:db8::1]:40000", want: "2001:db8::1"},
+		{addr: "192.0.2.1", want: "192.0.2.1"},
+	}
+
+	for i, tt := range tests {
+		r := &http.Request{RemoteAddr: tt.addr}
+		if got := RemoteIP(r); got != tt.want {
+			t.Errorf("case %d: want=%s, got=%s", i, tt.want, got)
+		}
+	}
+}
+
+func TestTrustedProxyHandler(t *testing.T) {
+	_, proxies, err := net.ParseCIDR("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		xff  []string
+		want string
+	}{
+		// Not sent by a proxy: the header is ignored.
+		{addr: "192.0.2.1:40000", xff: []string{"198.51.100.1"}, want: "192.0.2.1"},
+		{addr: "10.0.0.1:40000", want: "10.0.0.1"},
+		{addr: "10.0.0.1:40000", xff: []string{"198.51.100.1"}, want: "198.51.100.1"},
+		// Entries added by the client are skipped.
+		{addr: "10.0.0.1:40000", xff: []string{"203.0.113.1, 198.51.100.1"}, want: "198.51.100.1"},
+		// So are further trusted proxies.
+		{addr: "10.0.0.1:40000", xff: []string{"198.51.100.1, 10.0.0.2"}, want: "198.51.100.1"},
+		{addr: "10.0.0.1:40000", xff: []string{"198.51.100.1", "10.0.0.2"}, want: "198.51.100.1"},
+		{addr: "10.0.0.1:40000", xff: []string{"10.0.0.3, 10.0.0.2"}, want: "10.0.0.3"},
+		{addr: "10.0.0.1:40000", xff: []string{"garbage"}, want: "10.0.0.1"},
+	}
+
+	for i, tt := range tests {
+		var got string
+		h := TrustedProxyHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = RemoteIP(r)
+		}), []*net.IPNet{proxies})
+
+		r := &http.Request{RemoteAddr: tt.addr, Header: http.Header{}}
+		for _, v := range tt.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		h.ServeHTTP(nil, r)
+		if got != tt.want {
+			t.Errorf("case %d: want=%s, got=%s", i, tt.want, got)
+		}
+	}
+}
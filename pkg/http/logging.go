@@ -0,0 +1,132 @@
+package http
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coreos/dex/pkg/log"
+)
+
+const (
+	// RequestIDHeader carries the ID correlating the log lines of a request.
+	// IDs set by clients or proxies are kept; requests without one are given
+	// a new one. The ID is echoed in the response.
+	RequestIDHeader = "X-Request-Id"
+
+	maxRequestIDLength = 128
+)
+
+// RequestIDHandler gives every request an ID and a logger carrying it as the
+// request_id field, which handlers obtain with Logger.
+func RequestIDHandler(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		l := log.FromContext(r.Context()).WithField("request_id", id)
+		h.ServeHTTP(w, r.WithContext(log.NewContext(r.Context(), l)))
+	})
+}
+
+// Logger returns the logger of a request, carrying its ID if it passed
+// through RequestIDHandler.
+func Logger(r *http.Request) *log.Logger {
+	return log.FromContext(r.Context())
+}
+
+// LoggingHandler assigns request IDs like RequestIDHandler and logs every
+// request once it has been handled. Query parameters which may hold secrets
+// are redacted.
+func LoggingHandler(h http.Handler) http.Handler {
+	return RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+
+		Logger(r).With(log.Fields{
+			"method":      r.Method,
+			"path":        r.URL.Path,
+			"query":       RedactQuery(r.URL.Query()).Encode(),
+			"status":      sw.status,
+			"duration_ms": time.Since(start).Nanoseconds() / int64(time.Millisecond),
+			"remote_addr": RemoteIP(r),
+		}).Infof("%s %s %d", r.Method, r.URL.Path, sw.status)
+	}))
+}
+
+// redactedParams are query parameters which carry secrets.
+var redactedParams = []string{
+	"client_secret",
+	"code",
+	"link_token",
+	"password",
+	"refresh_token",
+	"session_key",
+	"state",
+	"token",
+}
+
+// RedactQuery returns a copy of q in which the values of parameters carrying
+// secrets, such as authorization codes and tokens, are replaced by
+// log.Redacted.
+func RedactQuery(q url.Values) url.Values {
+	r := make(url.Values, len(q))
+	for k, v := range q {
+		r[k] = v
+	}
+	for _, k := range redactedParams {
+		if _, ok := r[k]; ok {
+			r[k] = []string{log.Redacted}
+		}
+	}
+	return r
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-' || c == '_' || c == '.' || c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
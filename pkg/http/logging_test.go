@@ -0,0 +1,96 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/coreos/dex/pkg/log"
+)
+
+func TestRequestIDHandler(t *testing.T) {
+	tests := []struct {
+		header  string
+		wantNew bool
+	}{
+		{header: "abc-123", wantNew: false},
+		{header: "", wantNew: true},
+		{header: "bad id\n", wantNew: true},
+	}
+
+	for i, tt := range tests {
+		var got *log.Logger
+		h := RequestIDHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = Logger(r)
+		}))
+
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.header != "" {
+			r.Header.Set(RequestIDHeader, tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		id := w.Header().Get(RequestIDHeader)
+		if tt.wantNew {
+			if id == tt.header || !validRequestID(id) {
+				t.Errorf("case %d: want new request ID, got %q", i, id)
+			}
+		} else if id != tt.header {
+			t.Errorf("case %d: want request ID %q, got %q", i, tt.header, id)
+		}
+		if got == nil || got == log.FromContext(context.Background()) {
+			t.Errorf("case %d: handler not given a request logger", i)
+		}
+	}
+}
+
+func TestRedactQuery(t *testing.T) {
+	q := url.Values{
+		"code":        []string{"secret-code"},
+		"state":       []string{"session-key"},
+		"session_key": []string{"session-key"},
+		"link_token":  []string{"link-token"},
+		"client_id":   []string{"XXX"},
+	}
+	want := url.Values{
+		"code":        []string{log.Redacted},
+		"state":       []string{log.Redacted},
+		"session_key": []string{log.Redacted},
+		"link_token":  []string{log.Redacted},
+		"client_id":   []string{"XXX"},
+	}
+
+	if got := RedactQuery(q); !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if q.Get("code") != "secret-code" {
+		t.Errorf("RedactQuery modified its argument")
+	}
+}
+
+func TestLoggingHandlerRedactsQuery(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	h := LoggingHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest("GET", "/auth/local/login?session_key=live-key&link_token=link-jwt&connector_id=local", nil)
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	got := buf.String()
+	for _, secret := range []string{"live-key", "link-jwt"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("want %q redacted, got %q", secret, got)
+		}
+	}
+	if !strings.Contains(got, "connector_id=local") {
+		t.Errorf("want other parameters logged, got %q", got)
+	}
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying l, so that functions further
+// down the call chain log with its fields.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger carried by ctx, or the package-level logger
+// if there is none.
+func FromContext(ctx context.Context) *Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(contextKey{}).(*Logger); ok {
+			return l
+		}
+	}
+	return std
+}
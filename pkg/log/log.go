@@ -1,86 +1,370 @@
 package log
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	calldepth = 2
+	calldepth = 3
+
+	// Redacted replaces the values of fields holding secrets.
+	Redacted = "REDACTED"
+)
+
+// Level is the severity of a log line. Lines below the level set with
+// SetLevel are dropped.
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarning
+	LevelError
+	LevelFatal
+)
+
+var levelNames = map[Level]string{
+	LevelDebug:   "DEBUG",
+	LevelInfo:    "INFO",
+	LevelWarning: "WARN",
+	LevelError:   "ERROR",
+	LevelFatal:   "FATAL",
+}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel parses the name of a level, such as "debug" or "warning".
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return LevelDebug, nil
+	case "info":
+		return LevelInfo, nil
+	case "warn", "warning":
+		return LevelWarning, nil
+	case "error":
+		return LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", s)
+}
+
+// Format is the encoding of log lines.
+type Format string
+
+const (
+	// FormatText writes lines like "INFO: message key=value".
+	FormatText Format = "text"
+	// FormatJSON writes each line as a JSON object.
+	FormatJSON Format = "json"
+	// FormatLogfmt writes lines of key=value pairs.
+	FormatLogfmt Format = "logfmt"
 )
 
+// ParseFormat parses the name of a format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatText, FormatJSON, FormatLogfmt:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown log format %q", s)
+}
+
+// Fields are key/value pairs attached to log lines. Well known keys are
+// client_id, connector_id, user_id, session_id and request_id.
+type Fields map[string]interface{}
+
 var (
-	logger = log.New(os.Stderr, "", 0)
-	debug  = false
+	logger     = log.New(os.Stderr, "", 0)
+	level      = LevelInfo
+	format     = FormatText
+	timestamps = false
+	mu         sync.RWMutex
+
+	// redactedKeys are the field keys whose values are never logged.
+	redactedKeys = map[string]struct{}{
+		"access_token":  struct{}{},
+		"client_secret": struct{}{},
+		"code":          struct{}{},
+		"id_token":      struct{}{},
+		"link_token":    struct{}{},
+		"password":      struct{}{},
+		"refresh_token": struct{}{},
+		"secret":        struct{}{},
+		"session_key":   struct{}{},
+		"state":         struct{}{},
+		"token":         struct{}{},
+	}
+
+	std = &Logger{}
 )
 
 func EnableTimestamps() {
-	logger.SetFlags(logger.Flags() | log.Ldate | log.Ltime)
+	mu.Lock()
+	defer mu.Unlock()
+	timestamps = true
+	if format == FormatText {
+		logger.SetFlags(logger.Flags() | log.Ldate | log.Ltime)
+	}
 }
 
 func EnableDebug() {
-	debug = true
+	SetLevel(LevelDebug)
 }
 
-func Debug(v ...interface{}) {
-	if debug {
-		logger.Output(calldepth, header("DEBUG", fmt.Sprint(v...)))
+func SetLevel(l Level) {
+	mu.Lock()
+	defer mu.Unlock()
+	level = l
+}
+
+// SetFormat sets the encoding of log lines. Structured formats always
+// include a timestamp field once EnableTimestamps has been called.
+func SetFormat(f Format) {
+	mu.Lock()
+	defer mu.Unlock()
+	format = f
+	if f == FormatText && timestamps {
+		logger.SetFlags(log.Ldate | log.Ltime)
+	} else {
+		logger.SetFlags(0)
 	}
 }
 
-func Debugf(format string, v ...interface{}) {
-	if debug {
-		logger.Output(calldepth, header("DEBUG", fmt.Sprintf(format, v...)))
+// SetOutput sets the destination of log lines.
+func SetOutput(w io.Writer) {
+	logger.SetOutput(w)
+}
+
+// RedactField adds a field key whose values are replaced by Redacted.
+func RedactField(key string) {
+	mu.Lock()
+	defer mu.Unlock()
+	redactedKeys[key] = struct{}{}
+}
+
+// Logger writes log lines carrying a set of fields.
+type Logger struct {
+	fields Fields
+}
+
+// With returns a logger adding fields to those of the package-level logger.
+func With(fields Fields) *Logger {
+	return std.With(fields)
+}
+
+// WithField returns a logger adding key to the fields of the package-level
+// logger.
+func WithField(key string, value interface{}) *Logger {
+	return std.WithField(key, value)
+}
+
+// With returns a logger with the fields of l and fields. Fields of the same
+// key replace those of l.
+func (l *Logger) With(fields Fields) *Logger {
+	f := make(Fields, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		f[k] = v
+	}
+	for k, v := range fields {
+		f[k] = v
+	}
+	return &Logger{fields: f}
+}
+
+// WithField returns a logger with the fields of l and key set to value.
+func (l *Logger) WithField(key string, value interface{}) *Logger {
+	return l.With(Fields{key: value})
+}
+
+func (l *Logger) Debug(v ...interface{}) {
+	l.output(LevelDebug, fmt.Sprint(v...))
+}
+
+func (l *Logger) Debugf(format string, v ...interface{}) {
+	l.output(LevelDebug, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Info(v ...interface{}) {
+	l.output(LevelInfo, fmt.Sprint(v...))
+}
+
+func (l *Logger) Infof(format string, v ...interface{}) {
+	l.output(LevelInfo, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Warning(v ...interface{}) {
+	l.output(LevelWarning, fmt.Sprint(v...))
+}
+
+func (l *Logger) Warningf(format string, v ...interface{}) {
+	l.output(LevelWarning, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Error(v ...interface{}) {
+	l.output(LevelError, fmt.Sprint(v...))
+}
+
+func (l *Logger) Errorf(format string, v ...interface{}) {
+	l.output(LevelError, fmt.Sprintf(format, v...))
+}
+
+func (l *Logger) Fatal(v ...interface{}) {
+	l.output(LevelFatal, fmt.Sprint(v...))
+	os.Exit(1)
+}
+
+func (l *Logger) Fatalf(format string, v ...interface{}) {
+	l.output(LevelFatal, fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+func (l *Logger) output(lvl Level, msg string) {
+	mu.RLock()
+	if lvl < level {
+		mu.RUnlock()
+		return
 	}
+	line := encode(format, timestamps, time.Now(), lvl, msg, l.fields)
+	mu.RUnlock()
+	logger.Output(calldepth, line)
+}
+
+func Debug(v ...interface{}) {
+	std.output(LevelDebug, fmt.Sprint(v...))
+}
+
+func Debugf(format string, v ...interface{}) {
+	std.output(LevelDebug, fmt.Sprintf(format, v...))
 }
 
 func Info(v ...interface{}) {
-	logger.Output(calldepth, header("INFO", fmt.Sprint(v...)))
+	std.output(LevelInfo, fmt.Sprint(v...))
 }
 
 func Infof(format string, v ...interface{}) {
-	logger.Output(calldepth, header("INFO", fmt.Sprintf(format, v...)))
+	std.output(LevelInfo, fmt.Sprintf(format, v...))
 }
 
 func Error(v ...interface{}) {
-	logger.Output(calldepth, header("ERROR", fmt.Sprint(v...)))
+	std.output(LevelError, fmt.Sprint(v...))
 }
 
 func Errorf(format string, v ...interface{}) {
-	logger.Output(calldepth, header("ERROR", fmt.Sprintf(format, v...)))
+	std.output(LevelError, fmt.Sprintf(format, v...))
 }
 
 func Warning(v ...interface{}) {
-	logger.Output(calldepth, header("WARN", fmt.Sprint(v...)))
+	std.output(LevelWarning, fmt.Sprint(v...))
 }
 
 func Warningf(format string, v ...interface{}) {
-	logger.Output(calldepth, header("WARN", fmt.Sprintf(format, v...)))
+	std.output(LevelWarning, fmt.Sprintf(format, v...))
 }
 
 func Fatal(v ...interface{}) {
-	logger.Output(calldepth, header("FATAL", fmt.Sprint(v...)))
+	std.output(LevelFatal, fmt.Sprint(v...))
 	os.Exit(1)
 }
 
 func Fatalf(format string, v ...interface{}) {
-	logger.Output(calldepth, header("FATAL", fmt.Sprintf(format, v...)))
+	std.output(LevelFatal, fmt.Sprintf(format, v...))
 	os.Exit(1)
 }
 
-func header(lvl, msg string) string {
-	return fmt.Sprintf("%s: %s", lvl, msg)
+// encode formats a log line. Callers must hold mu.
+func encode(f Format, withTime bool, t time.Time, lvl Level, msg string, fields Fields) string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	switch f {
+	case FormatJSON:
+		m := make(map[string]interface{}, len(fields)+3)
+		for _, k := range keys {
+			m[k] = fieldValue(k, fields[k])
+		}
+		if withTime {
+			m["time"] = t.UTC().Format(time.RFC3339Nano)
+		}
+		m["level"] = strings.ToLower(lvl.String())
+		m["msg"] = msg
+		b, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Sprintf(`{"level":"error","msg":%q}`, "unable to encode log line: "+err.Error())
+		}
+		return string(b)
+	case FormatLogfmt:
+		var buf bytes.Buffer
+		if withTime {
+			writePair(&buf, "time", t.UTC().Format(time.RFC3339Nano))
+		}
+		writePair(&buf, "level", strings.ToLower(lvl.String()))
+		writePair(&buf, "msg", msg)
+		for _, k := range keys {
+			writePair(&buf, k, fieldValue(k, fields[k]))
+		}
+		return buf.String()
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(lvl.String())
+	buf.WriteString(": ")
+	buf.WriteString(msg)
+	for _, k := range keys {
+		writePair(&buf, k, fieldValue(k, fields[k]))
+	}
+	return buf.String()
+}
+
+// fieldValue returns the value to log for a field, which is Redacted for
+// secrets and the message of errors.
+func fieldValue(k string, v interface{}) interface{} {
+	if _, ok := redactedKeys[k]; ok {
+		return Redacted
+	}
+	if err, ok := v.(error); ok {
+		return err.Error()
+	}
+	return v
+}
+
+// writePair writes key=value to buf, separated from previous content by a
+// space and quoting the value where necessary.
+func writePair(buf *bytes.Buffer, k string, v interface{}) {
+	if buf.Len() > 0 {
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(k)
+	buf.WriteByte('=')
+
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " =\"\\\t\r\n") {
+		s = strconv.Quote(s)
+	}
+	buf.WriteString(s)
 }
 
-type logWriter string
+type logWriter Level
 
 func (l logWriter) Write(p []byte) (n int, err error) {
-	logger.Output(calldepth, header(string(l), string(p)))
+	std.output(Level(l), strings.TrimSuffix(string(p), "\n"))
 	return len(p), nil
 }
 
 func InfoWriter() io.Writer {
-	return logWriter("INFO")
+	return logWriter(LevelInfo)
 }
@@ -0,0 +1,99 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	tm := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+	fields := Fields{
+		"client_id": "XXX",
+		"code":      "abc",
+		"error":     errors.New("bad thing"),
+	}
+
+	tests := []struct {
+		format   Format
+		withTime bool
+		want     string
+	}{
+		{
+			format: FormatText,
+			want:   `INFO: hello world client_id=XXX code=REDACTED error="bad thing"`,
+		},
+		{
+			format:   FormatLogfmt,
+			withTime: true,
+			want:     `time=2016-01-02T03:04:05Z level=info msg="hello world" client_id=XXX code=REDACTED error="bad thing"`,
+		},
+		{
+			format: FormatJSON,
+			want:   `{"client_id":"XXX","code":"REDACTED","error":"bad thing","level":"info","msg":"hello world"}`,
+		},
+	}
+
+	for i, tt := range tests {
+		got := encode(tt.format, tt.withTime, tm, LevelInfo, "hello world", fields)
+		if got != tt.want {
+			t.Errorf("case %d: want %q, got %q", i, tt.want, got)
+		}
+	}
+}
+
+func TestLevel(t *testing.T) {
+	var buf bytes.Buffer
+	SetOutput(&buf)
+	defer SetOutput(os.Stderr)
+	SetLevel(LevelWarning)
+	defer SetLevel(LevelInfo)
+
+	Infof("dropped")
+	With(Fields{"user_id": "u1"}).Warningf("kept")
+
+	want := "WARN: kept user_id=u1\n"
+	if got := buf.String(); got != want {
+		t.Errorf("want %q, got %q", want, got)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    Level
+		wantErr bool
+	}{
+		{s: "debug", want: LevelDebug},
+		{s: "INFO", want: LevelInfo},
+		{s: "warn", want: LevelWarning},
+		{s: "warning", want: LevelWarning},
+		{s: "error", want: LevelError},
+		{s: "verbose", wantErr: true},
+	}
+
+	for i, tt := range tests {
+		got, err := ParseLevel(tt.s)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("case %d: want error %t, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("case %d: want %v, got %v", i, tt.want, got)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if l := FromContext(context.Background()); l != std {
+		t.Errorf("want package-level logger for context without logger")
+	}
+
+	l := With(Fields{"request_id": "r1"})
+	if got := FromContext(NewContext(context.Background(), l)); got != l {
+		t.Errorf("want logger stored in context")
+	}
+}
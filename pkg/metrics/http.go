@@ -0,0 +1,56 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = NewCounterVec("dex_http_requests_total",
+		"HTTP requests handled, by handler, method and response status.", "handler", "method", "code")
+	httpRequestDuration = NewHistogramVec("dex_http_request_duration_seconds",
+		"Time taken to handle HTTP requests, by handler.", DefaultBuckets, "handler")
+)
+
+// InstrumentHandler returns h, counting its requests and timing them. The
+// handler label of each request is returned by name, for example the route
+// pattern which matched the request.
+func InstrumentHandler(h http.Handler, name func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		h.ServeHTTP(sw, r)
+
+		n := name(r)
+		httpRequests.Inc(n, r.Method, strconv.Itoa(sw.status))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), n)
+	})
+}
+
+// statusWriter remembers the status code written through it.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush passes flushes on to the underlying writer, if it supports them.
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
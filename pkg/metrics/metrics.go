@@ -0,0 +1,367 @@
+// Package metrics keeps counters, gauges and histograms and serves them in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// ContentType is the content type of the Prometheus text format.
+	ContentType = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+var (
+	// DefaultBuckets are the upper bounds of histogram buckets suited to
+	// request latencies, in seconds.
+	DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// DefaultRegistry holds the metrics created by the package-level
+	// constructors.
+	DefaultRegistry = NewRegistry()
+)
+
+// metric is a family of samples sharing a name.
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics and serves them over HTTP.
+type Registry struct {
+	mu      sync.Mutex
+	metrics map[string]metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{metrics: make(map[string]metric)}
+}
+
+// register adds m to the registry. Like expvar, it panics if a metric of the
+// same name has already been registered.
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[m.name()]; ok {
+		panic("metrics: duplicate metric " + m.name())
+	}
+	r.metrics[m.name()] = m
+}
+
+// Write writes every metric in the registry to w, sorted by name.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	ms := make([]metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		ms = append(ms, m)
+	}
+	r.mu.Unlock()
+	sort.Sort(byName(ms))
+
+	bw := bufio.NewWriter(w)
+	for _, m := range ms {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	r.Write(w)
+}
+
+// Handler returns a handler serving the metrics of DefaultRegistry.
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+type byName []metric
+
+func (m byName) Len() int           { return len(m) }
+func (m byName) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byName) Less(i, j int) bool { return m[i].name() < m[j].name() }
+
+type desc struct {
+	n      string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) name() string {
+	return d.n
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.n, helpEscaper.Replace(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.n, d.typ)
+}
+
+// key joins label values into a map key.
+func (d *desc) key(lvs []string) string {
+	if len(lvs) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.n, len(d.labels), len(lvs)))
+	}
+	return strings.Join(lvs, "\xff")
+}
+
+// labelPairs formats label values, followed by any extra label, for a
+// sample line.
+func (d *desc) labelPairs(lvs []string, extra ...string) string {
+	if len(lvs) == 0 && len(extra) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(lvs)+1)
+	for i, v := range lvs {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", d.labels[i], labelEscaper.Replace(v)))
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", extra[i], labelEscaper.Replace(extra[i+1])))
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// series is the value of a metric for one set of label values.
+type series struct {
+	lvs   []string
+	value float64
+}
+
+// vec holds the series of a counter or gauge.
+type vec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func newVec(name, help, typ string, labels []string) *vec {
+	return &vec{
+		desc:   desc{n: name, help: help, typ: typ, labels: labels},
+		series: make(map[string]*series),
+	}
+}
+
+func (v *vec) get(lvs []string) *series {
+	k := v.key(lvs)
+	s, ok := v.series[k]
+	if !ok {
+		s = &series{lvs: append([]string(nil), lvs...)}
+		v.series[k] = s
+	}
+	return s
+}
+
+func (v *vec) write(w *bufio.Writer) {
+	v.writeHeader(w)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, k := range sortedKeys(v.series) {
+		s := v.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", v.n, v.labelPairs(s.lvs), formatFloat(s.value))
+	}
+}
+
+func sortedKeys(m map[string]*series) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CounterVec is a counter partitioned by label values.
+type CounterVec struct {
+	*vec
+}
+
+// NewCounterVec returns a counter registered with DefaultRegistry.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labels...)
+}
+
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{newVec(name, help, "counter", labels)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter with the given label values.
+func (c *CounterVec) Inc(lvs ...string) {
+	c.Add(1, lvs...)
+}
+
+// Add adds v, which must not be negative, to the counter with the given
+// label values.
+func (c *CounterVec) Add(v float64, lvs ...string) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.get(lvs).value += v
+}
+
+// GaugeVec is a gauge partitioned by label values.
+type GaugeVec struct {
+	*vec
+}
+
+// NewGaugeVec returns a gauge registered with DefaultRegistry.
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labels...)
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{newVec(name, help, "gauge", labels)}
+	r.register(g)
+	return g
+}
+
+// Set sets the gauge with the given label values to v.
+func (g *GaugeVec) Set(v float64, lvs ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.get(lvs).value = v
+}
+
+// funcMetric is a metric without labels whose value is read when it is
+// written.
+type funcMetric struct {
+	desc
+	f func() float64
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	m.writeHeader(w)
+	fmt.Fprintf(w, "%s %s\n", m.n, formatFloat(m.f()))
+}
+
+// NewGaugeFunc registers a gauge with DefaultRegistry whose value is
+// returned by f.
+func NewGaugeFunc(name, help string, f func() float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, f)
+}
+
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.register(&funcMetric{desc: desc{n: name, help: help, typ: "gauge"}, f: f})
+}
+
+// NewCounterFunc registers a counter with DefaultRegistry whose value is
+// returned by f, which must never decrease.
+func NewCounterFunc(name, help string, f func() float64) {
+	DefaultRegistry.NewCounterFunc(name, help, f)
+}
+
+func (r *Registry) NewCounterFunc(name, help string, f func() float64) {
+	r.register(&funcMetric{desc: desc{n: name, help: help, typ: "counter"}, f: f})
+}
+
+// HistogramVec counts observations in buckets, partitioned by label values.
+type HistogramVec struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+type histogramSeries struct {
+	lvs    []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec returns a histogram registered with DefaultRegistry. The
+// buckets are the sorted upper bounds of the buckets; a +Inf bucket is
+// always added.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labels...)
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	for _, l := range labels {
+		if l == "le" {
+			panic("metrics: histograms cannot have an le label")
+		}
+	}
+	h := &HistogramVec{
+		desc:    desc{n: name, help: help, typ: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe adds v to the histogram with the given label values.
+func (h *HistogramVec) Observe(v float64, lvs ...string) {
+	k := h.key(lvs)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{
+			lvs:    append([]string(nil), lvs...),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.series[k] = s
+	}
+	for i, b := range h.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.sum += v
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := h.series[k]
+		for i, b := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, h.labelPairs(s.lvs, "le", formatFloat(b)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.n, h.labelPairs(s.lvs, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.n, h.labelPairs(s.lvs), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.n, h.labelPairs(s.lvs), s.count)
+	}
+}
@@ -0,0 +1,106 @@
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Requests.\nBy path.", "path")
+	c.Inc("/b")
+	c.Add(2, "/a")
+	c.Inc("/a")
+	g := r.NewGaugeVec("test_temperature", "Temperature.")
+	g.Set(math.Inf(1))
+	r.NewGaugeFunc("test_answer", "The answer.", func() float64 { return 42 })
+	q := r.NewCounterVec("test_quoted_total", "Quoted.", "v")
+	q.Inc("a\"b\\c\nd")
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP test_answer The answer.
+# TYPE test_answer gauge
+test_answer 42
+# HELP test_quoted_total Quoted.
+# TYPE test_quoted_total counter
+test_quoted_total{v="a\"b\\c\nd"} 1
+# HELP test_requests_total Requests.\nBy path.
+# TYPE test_requests_total counter
+test_requests_total{path="/a"} 3
+test_requests_total{path="/b"} 1
+# HELP test_temperature Temperature.
+# TYPE test_temperature gauge
+test_temperature +Inf
+`
+	if diff := pretty.Compare(want, buf.String()); diff != "" {
+		t.Errorf("output differs from expected: %s", diff)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{0.1, 1}, "op")
+	for _, v := range []float64{0.05, 0.5, 5} {
+		h.Observe(v, "get")
+	}
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `# HELP test_duration_seconds Duration.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{op="get",le="0.1"} 1
+test_duration_seconds_bucket{op="get",le="1"} 2
+test_duration_seconds_bucket{op="get",le="+Inf"} 3
+test_duration_seconds_sum{op="get"} 5.55
+test_duration_seconds_count{op="get"} 3
+`
+	if diff := pretty.Compare(want, buf.String()); diff != "" {
+		t.Errorf("output differs from expected: %s", diff)
+	}
+}
+
+func TestDuplicateRegistration(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_total", "Test.")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic registering a duplicate metric")
+		}
+	}()
+	r.NewGaugeVec("test_total", "Test.")
+}
+
+func TestInstrumentHandler(t *testing.T) {
+	h := InstrumentHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.WriteHeader(http.StatusOK)
+	}), func(r *http.Request) string { return "/teapot" })
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/teapot", nil))
+
+	w := httptest.NewRecorder()
+	Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("want Content-Type %q, got %q", ContentType, ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		`dex_http_requests_total{handler="/teapot",method="POST",code="418"} 1`,
+		`dex_http_request_duration_seconds_count{handler="/teapot"} 1`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("metrics output missing %q:\n%s", line, body)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+)
+
+// SoftwareAuthenticator is an in-memory authenticator which performs the
+// client side of the ceremonies the way a browser and a security key would.
+// It is meant for tests.
+type SoftwareAuthenticator struct {
+	RelyingParty RelyingParty
+
+	keys      map[string]*ecdsa.PrivateKey
+	signCount uint32
+}
+
+func NewSoftwareAuthenticator(rp RelyingParty) *SoftwareAuthenticator {
+	return &SoftwareAuthenticator{
+		RelyingParty: rp,
+		keys:         make(map[string]*ecdsa.PrivateKey),
+	}
+}
+
+// Create generates a new credential in response to challenge, returning its
+// ID along with the clientDataJSON and attestationObject a browser would
+// hand to the relying party.
+func (a *SoftwareAuthenticator) Create(challenge []byte) (id, clientDataJSON, attestationObject []byte, err error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	id = make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, nil, nil, err
+	}
+	publicKey, err := marshalPublicKey(&priv.PublicKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	clientDataJSON, err = a.clientData(ceremonyCreate, challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	authData := a.authData(flagUserPresent | flagAttested)
+	authData = append(authData, make([]byte, 16)...) // aaguid
+	authData = append(authData, byte(len(id)>>8), byte(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, publicKey...)
+
+	attestationObject, err = encodeCBOR(map[interface{}]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[interface{}]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	a.keys[string(id)] = priv
+	return id, clientDataJSON, attestationObject, nil
+}
+
+// Get signs challenge with the credential identified by id, returning the
+// clientDataJSON, authenticatorData and signature a browser would hand to
+// the relying party.
+func (a *SoftwareAuthenticator) Get(challenge, id []byte) (clientDataJSON, authData, signature []byte, err error) {
+	priv, ok := a.keys[string(id)]
+	if !ok {
+		return nil, nil, nil, errors.New("unknown credential")
+	}
+
+	clientDataJSON, err = a.clientData(ceremonyGet, challenge)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	authData = a.authData(flagUserPresent)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	signature, err = ecdsa.SignASN1(rand.Reader, priv, signed[:])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return clientDataJSON, authData, signature, nil
+}
+
+func (a *SoftwareAuthenticator) clientData(ceremony string, challenge []byte) ([]byte, error) {
+	return json.Marshal(clientData{
+		Type:      ceremony,
+		Challenge: EncodeID(challenge),
+		Origin:    a.RelyingParty.Origin,
+	})
+}
+
+func (a *SoftwareAuthenticator) authData(flags byte) []byte {
+	a.signCount++
+	rpIDHash := sha256.Sum256([]byte(a.RelyingParty.ID))
+	b := make([]byte, authDataMinLength)
+	copy(b, rpIDHash[:])
+	b[32] = flags
+	binary.BigEndian.PutUint32(b[33:], a.signCount)
+	return b
+}
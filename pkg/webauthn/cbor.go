@@ -0,0 +1,266 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// This file implements the small subset of CBOR (RFC 7049) needed to read
+// attestation objects and COSE keys. Decoded values are represented as:
+//
+//   unsigned and negative integers: int64
+//   byte strings:                    []byte
+//   text strings:                    string
+//   arrays:                          []interface{}
+//   maps:                            map[interface{}]interface{}
+//   true, false:                     bool
+//   null, undefined:                 nil
+//
+// Indefinite-length items, tags and floating point numbers are not supported
+// as they never appear in the structures WebAuthn authenticators produce.
+
+const (
+	cborUnsigned = 0
+	cborNegative = 1
+	cborBytes    = 2
+	cborText     = 3
+	cborArray    = 4
+	cborMap      = 5
+	cborSimple   = 7
+
+	// cborMaxDepth bounds nesting so that hostile input cannot exhaust the
+	// stack.
+	cborMaxDepth = 16
+)
+
+var errCBORTruncated = errors.New("cbor: unexpected end of data")
+
+// decodeCBOR decodes the first CBOR item in b, returning it along with the
+// remaining bytes.
+func decodeCBOR(b []byte) (interface{}, []byte, error) {
+	return decodeCBORItem(b, 0)
+}
+
+func decodeCBORItem(b []byte, depth int) (interface{}, []byte, error) {
+	if depth > cborMaxDepth {
+		return nil, nil, errors.New("cbor: nesting too deep")
+	}
+
+	major, arg, b, err := decodeCBORHead(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch major {
+	case cborUnsigned:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return int64(arg), b, nil
+	case cborNegative:
+		if arg > 1<<63-1 {
+			return nil, nil, errors.New("cbor: integer overflow")
+		}
+		return -1 - int64(arg), b, nil
+	case cborBytes, cborText:
+		if uint64(len(b)) < arg {
+			return nil, nil, errCBORTruncated
+		}
+		s := b[:arg]
+		if major == cborText {
+			return string(s), b[arg:], nil
+		}
+		return append([]byte(nil), s...), b[arg:], nil
+	case cborArray:
+		// Every item takes at least one byte, which bounds the allocation.
+		if uint64(len(b)) < arg {
+			return nil, nil, errCBORTruncated
+		}
+		a := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			var v interface{}
+			if v, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			a = append(a, v)
+		}
+		return a, b, nil
+	case cborMap:
+		if uint64(len(b)) < 2*arg {
+			return nil, nil, errCBORTruncated
+		}
+		m := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			var k, v interface{}
+			if k, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, fmt.Errorf("cbor: unsupported map key type %T", k)
+			}
+			if v, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			if _, ok := m[k]; ok {
+				return nil, nil, fmt.Errorf("cbor: duplicate map key %v", k)
+			}
+			m[k] = v
+		}
+		return m, b, nil
+	case cborSimple:
+		switch arg {
+		case 20:
+			return false, b, nil
+		case 21:
+			return true, b, nil
+		case 22, 23:
+			return nil, b, nil
+		}
+		return nil, nil, fmt.Errorf("cbor: unsupported simple value %d", arg)
+	}
+	return nil, nil, fmt.Errorf("cbor: unsupported major type %d", major)
+}
+
+func decodeCBORHead(b []byte) (major byte, arg uint64, rest []byte, err error) {
+	if len(b) < 1 {
+		return 0, 0, nil, errCBORTruncated
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+
+	if major == cborSimple && info >= 25 && info <= 27 {
+		return 0, 0, nil, errors.New("cbor: floating point numbers are not supported")
+	}
+
+	switch {
+	case info < 24:
+		return major, uint64(info), b, nil
+	case info == 24:
+		if len(b) < 1 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(b[0]), b[1:], nil
+	case info == 25:
+		if len(b) < 2 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case info == 26:
+		if len(b) < 4 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case info == 27:
+		if len(b) < 8 {
+			return 0, 0, nil, errCBORTruncated
+		}
+		return major, binary.BigEndian.Uint64(b), b[8:], nil
+	}
+	return 0, 0, nil, errors.New("cbor: indefinite-length items are not supported")
+}
+
+// encodeCBOR encodes v, which must be built from the types produced by
+// decodeCBOR (plain ints are accepted as well). Map keys are written in
+// canonical order.
+func encodeCBOR(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeCBORItem(&buf, v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeCBORItem(buf *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case int:
+		return encodeCBORItem(buf, int64(v))
+	case int64:
+		if v < 0 {
+			encodeCBORHead(buf, cborNegative, uint64(-1-v))
+		} else {
+			encodeCBORHead(buf, cborUnsigned, uint64(v))
+		}
+	case []byte:
+		encodeCBORHead(buf, cborBytes, uint64(len(v)))
+		buf.Write(v)
+	case string:
+		encodeCBORHead(buf, cborText, uint64(len(v)))
+		buf.WriteString(v)
+	case []interface{}:
+		encodeCBORHead(buf, cborArray, uint64(len(v)))
+		for _, item := range v {
+			if err := encodeCBORItem(buf, item); err != nil {
+				return err
+			}
+		}
+	case map[interface{}]interface{}:
+		type entry struct {
+			key, value []byte
+		}
+		entries := make([]entry, 0, len(v))
+		for k, item := range v {
+			kb, err := encodeCBOR(k)
+			if err != nil {
+				return err
+			}
+			vb, err := encodeCBOR(item)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry{kb, vb})
+		}
+		// Canonical CBOR sorts keys by length first, then bytewise.
+		sort.Slice(entries, func(i, j int) bool {
+			if len(entries[i].key) != len(entries[j].key) {
+				return len(entries[i].key) < len(entries[j].key)
+			}
+			return bytes.Compare(entries[i].key, entries[j].key) < 0
+		})
+		encodeCBORHead(buf, cborMap, uint64(len(v)))
+		for _, e := range entries {
+			buf.Write(e.key)
+			buf.Write(e.value)
+		}
+	case bool:
+		if v {
+			buf.WriteByte(cborSimple<<5 | 21)
+		} else {
+			buf.WriteByte(cborSimple<<5 | 20)
+		}
+	case nil:
+		buf.WriteByte(cborSimple<<5 | 22)
+	default:
+		return fmt.Errorf("cbor: cannot encode %T", v)
+	}
+	return nil
+}
+
+func encodeCBORHead(buf *bytes.Buffer, major byte, arg uint64) {
+	var b [9]byte
+	switch {
+	case arg < 24:
+		buf.WriteByte(major<<5 | byte(arg))
+		return
+	case arg <= 0xff:
+		b[0] = major<<5 | 24
+		b[1] = byte(arg)
+		buf.Write(b[:2])
+	case arg <= 0xffff:
+		b[0] = major<<5 | 25
+		binary.BigEndian.PutUint16(b[1:], uint16(arg))
+		buf.Write(b[:3])
+	case arg <= 0xffffffff:
+		b[0] = major<<5 | 26
+		binary.BigEndian.PutUint32(b[1:], uint32(arg))
+		buf.Write(b[:5])
+	default:
+		b[0] = major<<5 | 27
+		binary.BigEndian.PutUint64(b[1:], arg)
+		buf.Write(b[:9])
+	}
+}
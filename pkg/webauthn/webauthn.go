@@ -0,0 +1,287 @@
+// Package webauthn implements the server side of the WebAuthn registration
+// and authentication ceremonies for security keys.
+//
+// Only ES256 credentials are supported, and attestation statements are not
+// verified: credentials are accepted as if "none" attestation was requested.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+)
+
+const (
+	// AlgorithmES256 is the COSE identifier of ECDSA with P-256 and SHA-256,
+	// the only algorithm supported for credentials.
+	AlgorithmES256 = -7
+
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+
+	flagUserPresent = 0x01
+	flagAttested    = 0x40
+	flagExtensions  = 0x80
+
+	// authenticator data is at least rpIdHash, flags and signCount.
+	authDataMinLength = 32 + 1 + 4
+
+	coseKeyType     = 1
+	coseAlgorithm   = 3
+	coseCurve       = -1
+	coseX           = -2
+	coseY           = -3
+	coseKeyTypeEC2  = 2
+	coseCurveP256   = 1
+	p256CoordLength = 32
+)
+
+var (
+	ErrorInvalidClientData       = errors.New("invalid client data")
+	ErrorCeremonyMismatch        = errors.New("client data is for another ceremony")
+	ErrorChallengeMismatch       = errors.New("challenge does not match")
+	ErrorOriginMismatch          = errors.New("origin does not match")
+	ErrorInvalidAuthData         = errors.New("invalid authenticator data")
+	ErrorRPIDMismatch            = errors.New("relying party ID does not match")
+	ErrorUserNotPresent          = errors.New("user presence was not asserted")
+	ErrorInvalidAttestation      = errors.New("invalid attestation object")
+	ErrorUnsupportedAlgorithm    = errors.New("unsupported credential algorithm")
+	ErrorInvalidPublicKey        = errors.New("invalid credential public key")
+	ErrorInvalidSignature        = errors.New("invalid assertion signature")
+	ErrorSignCountNotIncremented = errors.New("signature counter did not increase; the credential may have been cloned")
+)
+
+// RelyingParty identifies the site credentials are scoped to.
+type RelyingParty struct {
+	// ID is the relying party identifier, a registrable domain name.
+	ID string
+
+	// Origin is the origin (scheme, host and port) the ceremonies are
+	// performed on.
+	Origin string
+}
+
+// NewRelyingParty returns the RelyingParty for a site served at u.
+func NewRelyingParty(u url.URL) RelyingParty {
+	return RelyingParty{
+		ID:     u.Hostname(),
+		Origin: u.Scheme + "://" + u.Host,
+	}
+}
+
+// Credential is a public key credential created by an authenticator.
+type Credential struct {
+	ID []byte
+
+	// PublicKey is the credential public key, in COSE_Key format.
+	PublicKey []byte
+
+	SignCount uint32
+}
+
+// EncodeID returns the base64url encoding of a credential ID, as used by the
+// browser API.
+func EncodeID(id []byte) string {
+	return base64.RawURLEncoding.EncodeToString(id)
+}
+
+// DecodeID decodes a credential ID, or any other binary value, from its
+// base64url encoding. Padding is optional.
+func DecodeID(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// VerifyRegistration checks the response of an authenticator to a
+// navigator.credentials.create() call for challenge, and returns the newly
+// created credential.
+func (rp RelyingParty) VerifyRegistration(challenge, clientDataJSON, attestationObject []byte) (*Credential, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	v, rest, err := decodeCBOR(attestationObject)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrorInvalidAttestation
+	}
+	att, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrorInvalidAttestation
+	}
+	if _, ok := att["fmt"].(string); !ok {
+		return nil, ErrorInvalidAttestation
+	}
+	authData, ok := att["authData"].([]byte)
+	if !ok {
+		return nil, ErrorInvalidAttestation
+	}
+
+	flags, signCount, err := rp.verifyAuthData(authData)
+	if err != nil {
+		return nil, err
+	}
+	if flags&flagAttested == 0 {
+		return nil, ErrorInvalidAuthData
+	}
+
+	// attested credential data: aaguid, credential ID length, credential ID
+	// and the credential public key.
+	b := authData[authDataMinLength:]
+	if len(b) < 16+2 {
+		return nil, ErrorInvalidAuthData
+	}
+	b = b[16:]
+	idLen := int(binary.BigEndian.Uint16(b))
+	b = b[2:]
+	if idLen == 0 || len(b) < idLen {
+		return nil, ErrorInvalidAuthData
+	}
+	id := append([]byte(nil), b[:idLen]...)
+	b = b[idLen:]
+
+	_, rest, err = decodeCBOR(b)
+	if err != nil {
+		return nil, ErrorInvalidPublicKey
+	}
+	if len(rest) != 0 && flags&flagExtensions == 0 {
+		return nil, ErrorInvalidAuthData
+	}
+	publicKey := append([]byte(nil), b[:len(b)-len(rest)]...)
+	if _, err := parsePublicKey(publicKey); err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:        id,
+		PublicKey: publicKey,
+		SignCount: signCount,
+	}, nil
+}
+
+// VerifyAssertion checks the response of an authenticator to a
+// navigator.credentials.get() call for challenge against the stored
+// credential cred, and returns the new value of its signature counter.
+func (rp RelyingParty) VerifyAssertion(challenge []byte, cred Credential, clientDataJSON, authData, signature []byte) (uint32, error) {
+	if err := rp.verifyClientData(clientDataJSON, ceremonyGet, challenge); err != nil {
+		return 0, err
+	}
+
+	_, signCount, err := rp.verifyAuthData(authData)
+	if err != nil {
+		return 0, err
+	}
+
+	pub, err := parsePublicKey(cred.PublicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := sha256.Sum256(append(append([]byte(nil), authData...), clientDataHash[:]...))
+	if !ecdsa.VerifyASN1(pub, signed[:], signature) {
+		return 0, ErrorInvalidSignature
+	}
+
+	// Authenticators which do not implement a counter always report zero.
+	if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+		return 0, ErrorSignCountNotIncremented
+	}
+
+	return signCount, nil
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+func (rp RelyingParty) verifyClientData(clientDataJSON []byte, ceremony string, challenge []byte) error {
+	var cd clientData
+	if err := json.Unmarshal(clientDataJSON, &cd); err != nil {
+		return ErrorInvalidClientData
+	}
+	if cd.Type != ceremony {
+		return ErrorCeremonyMismatch
+	}
+	got, err := DecodeID(cd.Challenge)
+	if err != nil || !bytes.Equal(got, challenge) {
+		return ErrorChallengeMismatch
+	}
+	if cd.Origin != rp.Origin {
+		return ErrorOriginMismatch
+	}
+	return nil
+}
+
+func (rp RelyingParty) verifyAuthData(authData []byte) (flags byte, signCount uint32, err error) {
+	if len(authData) < authDataMinLength {
+		return 0, 0, ErrorInvalidAuthData
+	}
+	rpIDHash := sha256.Sum256([]byte(rp.ID))
+	if !bytes.Equal(authData[:32], rpIDHash[:]) {
+		return 0, 0, ErrorRPIDMismatch
+	}
+	flags = authData[32]
+	if flags&flagUserPresent == 0 {
+		return 0, 0, ErrorUserNotPresent
+	}
+	return flags, binary.BigEndian.Uint32(authData[33:37]), nil
+}
+
+func parsePublicKey(coseKey []byte) (*ecdsa.PublicKey, error) {
+	v, rest, err := decodeCBOR(coseKey)
+	if err != nil || len(rest) != 0 {
+		return nil, ErrorInvalidPublicKey
+	}
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrorInvalidPublicKey
+	}
+
+	if alg, _ := m[int64(coseAlgorithm)].(int64); alg != AlgorithmES256 {
+		return nil, ErrorUnsupportedAlgorithm
+	}
+	kty, _ := m[int64(coseKeyType)].(int64)
+	crv, _ := m[int64(coseCurve)].(int64)
+	x, _ := m[int64(coseX)].([]byte)
+	y, _ := m[int64(coseY)].([]byte)
+	if kty != coseKeyTypeEC2 || crv != coseCurveP256 || len(x) != p256CoordLength || len(y) != p256CoordLength {
+		return nil, ErrorInvalidPublicKey
+	}
+
+	pub := &ecdsa.PublicKey{
+		Curve: elliptic.P256(),
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrorInvalidPublicKey
+	}
+	return pub, nil
+}
+
+func marshalPublicKey(pub *ecdsa.PublicKey) ([]byte, error) {
+	if pub.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("unsupported curve %s", pub.Curve.Params().Name)
+	}
+	x := make([]byte, p256CoordLength)
+	y := make([]byte, p256CoordLength)
+	pub.X.FillBytes(x)
+	pub.Y.FillBytes(y)
+	return encodeCBOR(map[interface{}]interface{}{
+		int64(coseKeyType):   int64(coseKeyTypeEC2),
+		int64(coseAlgorithm): int64(AlgorithmES256),
+		int64(coseCurve):     int64(coseCurveP256),
+		int64(coseX):         x,
+		int64(coseY):         y,
+	})
+}
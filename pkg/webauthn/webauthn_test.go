@@ -0,0 +1,236 @@
+package webauthn
+
+import (
+	"bytes"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+var testRP = RelyingParty{ID: "dex.example.com", Origin: "https://dex.example.com"}
+
+func TestNewRelyingParty(t *testing.T) {
+	tests := []struct {
+		url  url.URL
+		want RelyingParty
+	}{
+		{
+			url:  url.URL{Scheme: "https", Host: "dex.example.com", Path: "/dex"},
+			want: RelyingParty{ID: "dex.example.com", Origin: "https://dex.example.com"},
+		},
+		{
+			url:  url.URL{Scheme: "http", Host: "127.0.0.1:5556"},
+			want: RelyingParty{ID: "127.0.0.1", Origin: "http://127.0.0.1:5556"},
+		},
+	}
+
+	for i, tt := range tests {
+		if got := NewRelyingParty(tt.url); got != tt.want {
+			t.Errorf("case %d: want=%#v, got=%#v", i, tt.want, got)
+		}
+	}
+}
+
+func TestCBORRoundTrip(t *testing.T) {
+	tests := []interface{}{
+		int64(0),
+		int64(23),
+		int64(24),
+		int64(-1),
+		int64(-7),
+		int64(1 << 40),
+		[]byte("bytes"),
+		"text",
+		true,
+		nil,
+		[]interface{}{int64(1), "two", []byte{3}},
+		map[interface{}]interface{}{
+			int64(1):  int64(2),
+			int64(-1): []byte{1, 2, 3},
+			"fmt":     "none",
+		},
+	}
+
+	for i, tt := range tests {
+		b, err := encodeCBOR(tt)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		got, rest, err := decodeCBOR(b)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if len(rest) != 0 {
+			t.Errorf("case %d: %d trailing bytes", i, len(rest))
+		}
+		if !reflect.DeepEqual(tt, got) {
+			t.Errorf("case %d: want=%#v, got=%#v", i, tt, got)
+		}
+	}
+}
+
+func TestDecodeCBORInvalid(t *testing.T) {
+	tests := [][]byte{
+		// empty
+		{},
+		// byte string longer than the input
+		{0x45, 0x01},
+		// array longer than the input
+		{0x9a, 0xff, 0xff, 0xff, 0xff},
+		// indefinite-length byte string
+		{0x5f, 0x41, 0x01, 0xff},
+		// float
+		{0xf9, 0x3c, 0x00},
+		// map with an array key
+		{0xa1, 0x80, 0x01},
+		// map with a duplicate key
+		{0xa2, 0x01, 0x01, 0x01, 0x02},
+	}
+
+	for i, tt := range tests {
+		if _, _, err := decodeCBOR(tt); err == nil {
+			t.Errorf("case %d: expected non-nil error", i)
+		}
+	}
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	challenge := []byte("registration-challenge")
+	a := NewSoftwareAuthenticator(testRP)
+	id, clientData, attestation, err := a.Create(challenge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cred, err := testRP.VerifyRegistration(challenge, clientData, attestation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(cred.ID, id) {
+		t.Errorf("want ID=%x, got %x", id, cred.ID)
+	}
+	if cred.SignCount != 1 {
+		t.Errorf("want SignCount=1, got %d", cred.SignCount)
+	}
+
+	tests := []struct {
+		rp        RelyingParty
+		challenge []byte
+		want      error
+	}{
+		{
+			rp:        testRP,
+			challenge: []byte("another-challenge"),
+			want:      ErrorChallengeMismatch,
+		},
+		{
+			rp:        RelyingParty{ID: "dex.example.com", Origin: "https://evil.example.com"},
+			challenge: challenge,
+			want:      ErrorOriginMismatch,
+		},
+		{
+			rp:        RelyingParty{ID: "example.com", Origin: "https://dex.example.com"},
+			challenge: challenge,
+			want:      ErrorRPIDMismatch,
+		},
+	}
+
+	for i, tt := range tests {
+		if _, err := tt.rp.VerifyRegistration(tt.challenge, clientData, attestation); err != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, err)
+		}
+	}
+
+	// An assertion response can not be used to register.
+	clientData, _, _, err = a.Get(challenge, id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := testRP.VerifyRegistration(challenge, clientData, attestation); err != ErrorCeremonyMismatch {
+		t.Errorf("want=%v, got=%v", ErrorCeremonyMismatch, err)
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	a := NewSoftwareAuthenticator(testRP)
+	_, clientData, attestation, err := a.Create([]byte("registration-challenge"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cred, err := testRP.VerifyRegistration([]byte("registration-challenge"), clientData, attestation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	challenge := []byte("assertion-challenge")
+	clientData, authData, sig, err := a.Get(challenge, cred.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	signCount, err := testRP.VerifyAssertion(challenge, *cred, clientData, authData, sig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if signCount != 2 {
+		t.Errorf("want signCount=2, got %d", signCount)
+	}
+
+	tampered := append([]byte(nil), sig...)
+	tampered[len(tampered)-1] ^= 0xff
+
+	other := NewSoftwareAuthenticator(testRP)
+	_, otherClientData, otherAttestation, err := other.Create([]byte("c"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	otherCred, err := testRP.VerifyRegistration([]byte("c"), otherClientData, otherAttestation)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	replayed := *cred
+	replayed.SignCount = signCount
+
+	tests := []struct {
+		challenge []byte
+		cred      Credential
+		sig       []byte
+		want      error
+	}{
+		{
+			challenge: []byte("another-challenge"),
+			cred:      *cred,
+			sig:       sig,
+			want:      ErrorChallengeMismatch,
+		},
+		{
+			challenge: challenge,
+			cred:      *cred,
+			sig:       tampered,
+			want:      ErrorInvalidSignature,
+		},
+		// signed by another credential
+		{
+			challenge: challenge,
+			cred:      *otherCred,
+			sig:       sig,
+			want:      ErrorInvalidSignature,
+		},
+		// the counter must increase
+		{
+			challenge: challenge,
+			cred:      replayed,
+			sig:       sig,
+			want:      ErrorSignCountNotIncremented,
+		},
+	}
+
+	for i, tt := range tests {
+		if _, err := testRP.VerifyAssertion(tt.challenge, tt.cred, clientData, authData, tt.sig); err != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, err)
+		}
+	}
+}
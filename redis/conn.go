@@ -0,0 +1,32 @@
+// Package redis stores sessions and session keys in Redis, where they
+// expire by themselves rather than being purged by the garbage collector.
+package redis
+
+import (
+	"fmt"
+
+	goredis "github.com/go-redis/redis"
+)
+
+// Config configures the connection to a Redis server.
+type Config struct {
+	// URL is the URL of the server, of the form
+	// redis://[:password@]host[:port][/db].
+	URL string
+}
+
+// NewConnection returns a client of the configured Redis server, after
+// checking that it can be reached.
+func NewConnection(cfg Config) (*goredis.Client, error) {
+	opts, err := goredis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %v", err)
+	}
+
+	c := goredis.NewClient(opts)
+	if err := c.Ping().Err(); err != nil {
+		c.Close()
+		return nil, fmt.Errorf("unable to reach Redis server: %v", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,473 @@
+// Package fake provides an in-process stand-in for a Redis server, against
+// which the Redis session store can be tested.
+package fake
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+// status, errorReply, int64, []byte, nil, []interface{} and nullArray are
+// the replies of commands, written as simple strings, errors, integers, bulk
+// strings, null bulk strings, arrays and null arrays.
+type status string
+
+type errorReply string
+
+type nullArray struct{}
+
+var (
+	replyOK     = status("OK")
+	replyQueued = status("QUEUED")
+
+	errSyntax    = errorReply("ERR syntax error")
+	errWrongType = errorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	errNotInt    = errorReply("ERR value is not an integer or out of range")
+)
+
+type entry struct {
+	str       []byte
+	set       map[string]bool
+	expiresAt time.Time
+}
+
+// Server serves a subset of the Redis protocol from memory: strings, sets,
+// key expiry and transactions, including WATCH. Keys expire according to its
+// clock.
+type Server struct {
+	listener net.Listener
+	clock    clockwork.Clock
+
+	mu   sync.Mutex
+	data map[string]*entry
+
+	// rev counts writes, and modified holds the rev of the last write to
+	// each key, so that EXEC can tell whether a watched key was modified.
+	rev      uint64
+	modified map[string]uint64
+}
+
+// NewServer starts and returns a new Server, which should be closed when
+// finished with.
+func NewServer() (*Server, error) {
+	return NewServerWithClock(clockwork.NewRealClock())
+}
+
+func NewServerWithClock(clock clockwork.Clock) (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		listener: l,
+		clock:    clock,
+		data:     make(map[string]*entry),
+		modified: make(map[string]uint64),
+	}
+	go s.serve()
+	return s, nil
+}
+
+// URL returns the URL to connect to the server with.
+func (s *Server) URL() string {
+	return "redis://" + s.listener.Addr().String()
+}
+
+func (s *Server) Close() error {
+	return s.listener.Close()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	// queued holds the commands of a transaction, from MULTI until EXEC,
+	// and watched the revs of the keys watched before it.
+	var queued [][]string
+	var inMulti bool
+	var watched map[string]uint64
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+
+		var rep interface{}
+		switch name := strings.ToUpper(args[0]); {
+		case name == "MULTI" && !inMulti:
+			inMulti = true
+			rep = replyOK
+		case name == "MULTI":
+			rep = errorReply("ERR MULTI calls can not be nested")
+		case name == "WATCH" && inMulti:
+			rep = errorReply("ERR WATCH inside MULTI is not allowed")
+		case name == "WATCH":
+			if len(args) < 2 {
+				rep = wrongArgs(name)
+				break
+			}
+			if watched == nil {
+				watched = make(map[string]uint64)
+			}
+			s.mu.Lock()
+			for _, k := range args[1:] {
+				if _, ok := watched[k]; !ok {
+					watched[k] = s.modified[k]
+				}
+			}
+			s.mu.Unlock()
+			rep = replyOK
+		case name == "UNWATCH":
+			watched = nil
+			rep = replyOK
+		case name == "EXEC" && inMulti:
+			s.mu.Lock()
+			if s.modifiedSince(watched) {
+				rep = nullArray{}
+			} else {
+				reps := make([]interface{}, len(queued))
+				for i, cmd := range queued {
+					reps[i] = s.do(cmd)
+				}
+				rep = reps
+			}
+			s.mu.Unlock()
+			queued, inMulti, watched = nil, false, nil
+		case name == "DISCARD" && inMulti:
+			queued, inMulti, watched = nil, false, nil
+			rep = replyOK
+		case name == "EXEC" || name == "DISCARD":
+			rep = errorReply("ERR " + name + " without MULTI")
+		case inMulti:
+			queued = append(queued, args)
+			rep = replyQueued
+		default:
+			s.mu.Lock()
+			rep = s.do(args)
+			s.mu.Unlock()
+		}
+
+		writeReply(w, rep)
+		if r.Buffered() == 0 {
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// do runs a command, with s.mu held.
+func (s *Server) do(args []string) interface{} {
+	name, args := strings.ToUpper(args[0]), args[1:]
+	switch name {
+	case "PING":
+		return status("PONG")
+	case "AUTH", "SELECT":
+		return replyOK
+	case "GET":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return nil
+		}
+		if e.set != nil {
+			return errWrongType
+		}
+		return e.str
+	case "SET":
+		return s.set(args)
+	case "SETNX":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		if s.get(args[0]) != nil {
+			return int64(0)
+		}
+		s.data[args[0]] = &entry{str: []byte(args[1])}
+		s.touch(args[0])
+		return int64(1)
+	case "DEL", "EXISTS":
+		if len(args) == 0 {
+			return wrongArgs(name)
+		}
+		var n int64
+		for _, k := range args {
+			if s.get(k) != nil {
+				n++
+				if name == "DEL" {
+					delete(s.data, k)
+					s.touch(k)
+				}
+			}
+		}
+		return n
+	case "EXPIRE", "PEXPIRE":
+		if len(args) != 2 {
+			return wrongArgs(name)
+		}
+		d, err := parseDuration(args[1], name == "EXPIRE")
+		if err != nil {
+			return errNotInt
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return int64(0)
+		}
+		e.expiresAt = s.clock.Now().Add(d)
+		s.touch(args[0])
+		return int64(1)
+	case "TTL", "PTTL":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		e := s.get(args[0])
+		switch {
+		case e == nil:
+			return int64(-2)
+		case e.expiresAt.IsZero():
+			return int64(-1)
+		}
+		unit := time.Millisecond
+		if name == "TTL" {
+			unit = time.Second
+		}
+		return int64(e.expiresAt.Sub(s.clock.Now()) / unit)
+	case "SADD", "SREM":
+		if len(args) < 2 {
+			return wrongArgs(name)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			if name == "SREM" {
+				return int64(0)
+			}
+			e = &entry{set: make(map[string]bool)}
+			s.data[args[0]] = e
+		}
+		if e.set == nil {
+			return errWrongType
+		}
+		var n int64
+		for _, m := range args[1:] {
+			if e.set[m] != (name == "SADD") {
+				n++
+			}
+			if name == "SADD" {
+				e.set[m] = true
+			} else {
+				delete(e.set, m)
+			}
+		}
+		if len(e.set) == 0 {
+			delete(s.data, args[0])
+		}
+		if n > 0 {
+			s.touch(args[0])
+		}
+		return n
+	case "SMEMBERS":
+		if len(args) != 1 {
+			return wrongArgs(name)
+		}
+		e := s.get(args[0])
+		if e == nil {
+			return []interface{}{}
+		}
+		if e.set == nil {
+			return errWrongType
+		}
+		ms := make([]interface{}, 0, len(e.set))
+		for m := range e.set {
+			ms = append(ms, []byte(m))
+		}
+		return ms
+	case "FLUSHDB", "FLUSHALL":
+		for k := range s.data {
+			s.touch(k)
+		}
+		s.data = make(map[string]*entry)
+		return replyOK
+	}
+	return errorReply(fmt.Sprintf("ERR unknown command '%s'", strings.ToLower(name)))
+}
+
+// get returns the entry of a key, unless it has expired.
+func (s *Server) get(key string) *entry {
+	e, ok := s.data[key]
+	if !ok {
+		return nil
+	}
+	if !e.expiresAt.IsZero() && !e.expiresAt.After(s.clock.Now()) {
+		delete(s.data, key)
+		return nil
+	}
+	return e
+}
+
+// touch records a write to a key, with s.mu held.
+func (s *Server) touch(key string) {
+	s.rev++
+	s.modified[key] = s.rev
+}
+
+// modifiedSince reports whether any of the watched keys has been written
+// since it was watched, with s.mu held.
+func (s *Server) modifiedSince(watched map[string]uint64) bool {
+	for k, rev := range watched {
+		if s.modified[k] != rev {
+			return true
+		}
+	}
+	return false
+}
+
+// set runs SET key value [EX seconds|PX milliseconds] [NX|XX].
+func (s *Server) set(args []string) interface{} {
+	if len(args) < 2 {
+		return wrongArgs("SET")
+	}
+	var ttl time.Duration
+	var nx, xx bool
+	for i := 2; i < len(args); i++ {
+		switch opt := strings.ToUpper(args[i]); opt {
+		case "EX", "PX":
+			if i+1 == len(args) {
+				return errSyntax
+			}
+			i++
+			d, err := parseDuration(args[i], opt == "EX")
+			if err != nil {
+				return errNotInt
+			}
+			if d <= 0 {
+				return errorReply("ERR invalid expire time in set")
+			}
+			ttl = d
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			return errSyntax
+		}
+	}
+	if nx && xx {
+		return errSyntax
+	}
+
+	exists := s.get(args[0]) != nil
+	if (nx && exists) || (xx && !exists) {
+		return nil
+	}
+	e := &entry{str: []byte(args[1])}
+	if ttl > 0 {
+		e.expiresAt = s.clock.Now().Add(ttl)
+	}
+	s.data[args[0]] = e
+	s.touch(args[0])
+	return replyOK
+}
+
+func wrongArgs(name string) errorReply {
+	return errorReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+func parseDuration(s string, seconds bool) (time.Duration, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	if seconds {
+		return time.Duration(n) * time.Second, nil
+	}
+	return time.Duration(n) * time.Millisecond, nil
+}
+
+// readCommand reads a command, sent as an array of bulk strings.
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return nil, errors.New("expected array")
+	}
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 1 {
+		return nil, errors.New("invalid array length")
+	}
+
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errors.New("expected bulk string")
+		}
+		l, err := strconv.Atoi(line[1:])
+		if err != nil || l < 0 {
+			return nil, errors.New("invalid bulk string length")
+		}
+		b := make([]byte, l+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:l])
+	}
+	return args, nil
+}
+
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func writeReply(w *bufio.Writer, rep interface{}) {
+	switch rep := rep.(type) {
+	case status:
+		fmt.Fprintf(w, "+%s\r\n", rep)
+	case errorReply:
+		fmt.Fprintf(w, "-%s\r\n", rep)
+	case int64:
+		fmt.Fprintf(w, ":%d\r\n", rep)
+	case []byte:
+		fmt.Fprintf(w, "$%d\r\n%s\r\n", len(rep), rep)
+	case []interface{}:
+		fmt.Fprintf(w, "*%d\r\n", len(rep))
+		for _, r := range rep {
+			writeReply(w, r)
+		}
+	case nullArray:
+		w.WriteString("*-1\r\n")
+	case nil:
+		w.WriteString("$-1\r\n")
+	}
+}
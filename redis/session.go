@@ -0,0 +1,292 @@
+package redis
+
+import (
+	"encoding/json"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/oidc"
+	goredis "github.com/go-redis/redis"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	sessionKeyPrefix    = "dex:session:"
+	userSessionsPrefix  = "dex:user-sessions:"
+	sessionKeyKeyPrefix = "dex:session-key:"
+	minTTL              = time.Millisecond
+
+	// maxIndexAttempts bounds the retries of a user's sessions index update
+	// lost to a concurrent one.
+	maxIndexAttempts = 10
+)
+
+type sessionModel struct {
+	ID          string        `json:"id"`
+	State       string        `json:"state"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	ExpiresAt   time.Time     `json:"expiresAt"`
+	ClientID    string        `json:"clientID"`
+	ClientState string        `json:"clientState,omitempty"`
+	RedirectURL string        `json:"redirectURL"`
+	Identity    oidc.Identity `json:"identity"`
+	Profile     user.Profile  `json:"profile"`
+	ConnectorID string        `json:"connectorID"`
+	UserID      string        `json:"userID,omitempty"`
+	Register    bool          `json:"register,omitempty"`
+	Nonce       string        `json:"nonce,omitempty"`
+	Scope       []string      `json:"scope,omitempty"`
+	AMR         []string      `json:"amr,omitempty"`
+	LinkToken   string        `json:"linkToken,omitempty"`
+}
+
+func newSessionModel(s session.Session) *sessionModel {
+	return &sessionModel{
+		ID:          s.ID,
+		State:       string(s.State),
+		CreatedAt:   s.CreatedAt,
+		ExpiresAt:   s.ExpiresAt,
+		ClientID:    s.ClientID,
+		ClientState: s.ClientState,
+		RedirectURL: s.RedirectURL.String(),
+		Identity:    s.Identity,
+		Profile:     s.Profile,
+		ConnectorID: s.ConnectorID,
+		UserID:      s.UserID,
+		Register:    s.Register,
+		Nonce:       s.Nonce,
+		Scope:       s.Scope,
+		AMR:         s.AMR,
+		LinkToken:   s.LinkToken,
+	}
+}
+
+func (m *sessionModel) session() (*session.Session, error) {
+	ru, err := url.Parse(m.RedirectURL)
+	if err != nil {
+		return nil, err
+	}
+
+	s := session.Session{
+		ID:          m.ID,
+		State:       session.SessionState(m.State),
+		ClientID:    m.ClientID,
+		ClientState: m.ClientState,
+		RedirectURL: *ru,
+		Identity:    m.Identity,
+		Profile:     m.Profile,
+		ConnectorID: m.ConnectorID,
+		UserID:      m.UserID,
+		Register:    m.Register,
+		Nonce:       m.Nonce,
+		Scope:       m.Scope,
+		AMR:         m.AMR,
+		LinkToken:   m.LinkToken,
+	}
+
+	// Zero times are decoded in UTC rather than without a location.
+	if s.Identity.ExpiresAt.IsZero() {
+		s.Identity.ExpiresAt = time.Time{}
+	}
+	if !m.CreatedAt.IsZero() {
+		s.CreatedAt = m.CreatedAt.UTC()
+	}
+	if !m.ExpiresAt.IsZero() {
+		s.ExpiresAt = m.ExpiresAt.UTC()
+	}
+	return &s, nil
+}
+
+// ttl returns the time for which Redis should keep an entity expiring at
+// expiresAt. Entities which have already expired are kept briefly rather
+// than forever, which is what no expiry would mean.
+func ttl(clock clockwork.Clock, expiresAt time.Time) time.Duration {
+	d := expiresAt.Sub(clock.Now())
+	if d < minTTL {
+		d = minTTL
+	}
+	return d
+}
+
+func NewSessionRepo(c *goredis.Client) session.SessionRepo {
+	return NewSessionRepoWithClock(c, clockwork.NewRealClock())
+}
+
+func NewSessionRepoWithClock(c *goredis.Client, clock clockwork.Clock) session.SessionRepo {
+	return &sessionRepo{client: c, clock: clock}
+}
+
+// sessionRepo keeps every session under its own key, which expires along
+// with the session. The IDs of the sessions of a user are kept in a set,
+// so that they can be deleted together; the set expires along with the
+// user's latest session.
+type sessionRepo struct {
+	client *goredis.Client
+	clock  clockwork.Clock
+}
+
+func (r *sessionRepo) Get(sessionID string) (*session.Session, error) {
+	b, err := r.client.Get(sessionKeyPrefix + sessionID).Bytes()
+	if err == goredis.Nil {
+		return nil, errors.New("session does not exist")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var m sessionModel
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	s, err := m.session()
+	if err != nil {
+		return nil, err
+	}
+	if s.ExpiresAt.Before(r.clock.Now()) {
+		return nil, errors.New("session does not exist")
+	}
+	return s, nil
+}
+
+func (r *sessionRepo) Create(s session.Session) error {
+	b, err := json.Marshal(newSessionModel(s))
+	if err != nil {
+		return err
+	}
+
+	d := ttl(r.clock, s.ExpiresAt)
+	ok, err := r.client.SetNX(sessionKeyPrefix+s.ID, b, d).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("ID exists")
+	}
+	return r.index(s, d)
+}
+
+func (r *sessionRepo) Update(s session.Session) error {
+	b, err := json.Marshal(newSessionModel(s))
+	if err != nil {
+		return err
+	}
+
+	d := ttl(r.clock, s.ExpiresAt)
+	ok, err := r.client.SetXX(sessionKeyPrefix+s.ID, b, d).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("session does not exist")
+	}
+	return r.index(s, d)
+}
+
+// index adds a session to the set of sessions of its user, if it has one.
+// The set must outlive every session in it, so its TTL is only ever
+// extended: the TTL is compared under WATCH, retrying if the set changes.
+func (r *sessionRepo) index(s session.Session, d time.Duration) error {
+	if s.UserID == "" {
+		return nil
+	}
+
+	key := userSessionsPrefix + s.UserID
+	for i := 0; i < maxIndexAttempts; i++ {
+		err := r.client.Watch(func(tx *goredis.Tx) error {
+			// PTTL is negative if the set does not exist or has no TTL.
+			cur, err := tx.PTTL(key).Result()
+			if err != nil {
+				return err
+			}
+			_, err = tx.Pipelined(func(pipe goredis.Pipeliner) error {
+				pipe.SAdd(key, s.ID)
+				if cur < d {
+					pipe.PExpire(key, d)
+				}
+				return nil
+			})
+			return err
+		}, key)
+		if err != goredis.TxFailedErr {
+			return err
+		}
+	}
+	return errors.New("too many concurrent updates of user sessions")
+}
+
+func (r *sessionRepo) DeleteByUserID(_ repo.Transaction, userID string) error {
+	key := userSessionsPrefix + userID
+	ids, err := r.client.SMembers(key).Result()
+	if err != nil {
+		return err
+	}
+
+	keys := []string{key}
+	for _, id := range ids {
+		keys = append(keys, sessionKeyPrefix+id)
+	}
+	return r.client.Del(keys...).Err()
+}
+
+type sessionKeyModel struct {
+	SessionID string    `json:"sessionID"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func NewSessionKeyRepo(c *goredis.Client) session.SessionKeyRepo {
+	return NewSessionKeyRepoWithClock(c, clockwork.NewRealClock())
+}
+
+func NewSessionKeyRepoWithClock(c *goredis.Client, clock clockwork.Clock) session.SessionKeyRepo {
+	return &sessionKeyRepo{client: c, clock: clock}
+}
+
+type sessionKeyRepo struct {
+	client *goredis.Client
+	clock  clockwork.Clock
+}
+
+func (r *sessionKeyRepo) Push(sk session.SessionKey, exp time.Duration) error {
+	expiresAt := r.clock.Now().Add(exp)
+	b, err := json.Marshal(sessionKeyModel{
+		SessionID: sk.SessionID,
+		ExpiresAt: expiresAt.UTC(),
+	})
+	if err != nil {
+		return err
+	}
+	return r.client.Set(sessionKeyKeyPrefix+sk.Key, b, ttl(r.clock, expiresAt)).Err()
+}
+
+// Pop gets and deletes a session key in a single transaction, so that of
+// several concurrent calls for the same key, only one gets its session ID.
+func (r *sessionKeyRepo) Pop(key string) (string, error) {
+	pipe := r.client.TxPipeline()
+	get := pipe.Get(sessionKeyKeyPrefix + key)
+	pipe.Del(sessionKeyKeyPrefix + key)
+	_, err := pipe.Exec()
+	if err == goredis.Nil {
+		return "", errors.New("session key does not exist")
+	}
+	if err != nil {
+		return "", err
+	}
+
+	b, err := get.Bytes()
+	if err != nil {
+		return "", err
+	}
+	var m sessionKeyModel
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", err
+	}
+	if m.ExpiresAt.Before(r.clock.Now()) {
+		return "", errors.New("invalid session key")
+	}
+	return m.SessionID, nil
+}
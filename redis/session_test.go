@@ -0,0 +1,240 @@
+package redis
+
+import (
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/redis/fake"
+	"github.com/coreos/dex/session"
+)
+
+func newTestClient(t *testing.T, clock clockwork.Clock) (*goredis.Client, *fake.Server) {
+	srv, err := fake.NewServerWithClock(clock)
+	if err != nil {
+		t.Fatalf("unable to start server: %v", err)
+	}
+	c, err := NewConnection(Config{URL: srv.URL()})
+	if err != nil {
+		srv.Close()
+		t.Fatalf("unable to connect: %v", err)
+	}
+	return c, srv
+}
+
+func TestSessionRepoExpiry(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	c, srv := newTestClient(t, fc)
+	defer srv.Close()
+	r := NewSessionRepoWithClock(c, fc)
+
+	s := session.Session{
+		ID:          "session",
+		State:       session.SessionStateNew,
+		ExpiresAt:   fc.Now().UTC().Add(time.Minute),
+		RedirectURL: url.URL{Scheme: "https", Host: "example.com"},
+	}
+	if err := r.Create(s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ttl, err := c.PTTL(sessionKeyPrefix + s.ID).Result()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl != time.Minute {
+		t.Errorf("want TTL of %v, got %v", time.Minute, ttl)
+	}
+
+	fc.Advance(2 * time.Minute)
+	if n, err := c.Exists(sessionKeyPrefix + s.ID).Result(); err != nil || n != 0 {
+		t.Errorf("want session expired by Redis, got %d, %v", n, err)
+	}
+}
+
+func TestSessionRepoDeleteByUserID(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	c, srv := newTestClient(t, fc)
+	defer srv.Close()
+	r := NewSessionRepoWithClock(c, fc)
+
+	now := fc.Now().UTC()
+	for _, s := range []session.Session{
+		{ID: "a", UserID: "elroy", ExpiresAt: now.Add(time.Minute)},
+		{ID: "b", UserID: "elroy", ExpiresAt: now.Add(time.Hour)},
+		{ID: "c", UserID: "judy", ExpiresAt: now.Add(time.Hour)},
+	} {
+		s.RedirectURL = url.URL{Scheme: "https", Host: "example.com"}
+		if err := r.Create(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// The index of a user's sessions outlives the first of them.
+	ttl, err := c.PTTL(userSessionsPrefix + "elroy").Result()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl != time.Hour {
+		t.Errorf("want TTL of %v, got %v", time.Hour, ttl)
+	}
+
+	if err := r.DeleteByUserID(nil, "elroy"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"a", false},
+		{"b", false},
+		{"c", true},
+	}
+	for i, tt := range tests {
+		_, err := r.Get(tt.id)
+		if got := err == nil; got != tt.want {
+			t.Errorf("case %d: want found=%t, got error %v", i, tt.want, err)
+		}
+	}
+}
+
+func TestSessionRepoIndexTTL(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	c, srv := newTestClient(t, fc)
+	defer srv.Close()
+	r := NewSessionRepoWithClock(c, fc)
+
+	now := fc.Now().UTC()
+	tests := []struct {
+		id        string
+		expiresAt time.Time
+		wantTTL   time.Duration
+	}{
+		{"a", now.Add(time.Hour), time.Hour},
+		// A shorter lived session must not shorten the index's TTL.
+		{"b", now.Add(time.Minute), time.Hour},
+		{"c", now.Add(2 * time.Hour), 2 * time.Hour},
+	}
+	for i, tt := range tests {
+		s := session.Session{
+			ID:          tt.id,
+			UserID:      "elroy",
+			ExpiresAt:   tt.expiresAt,
+			RedirectURL: url.URL{Scheme: "https", Host: "example.com"},
+		}
+		if err := r.Create(s); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		ttl, err := c.PTTL(userSessionsPrefix + "elroy").Result()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if ttl != tt.wantTTL {
+			t.Errorf("case %d: want TTL of %v, got %v", i, tt.wantTTL, ttl)
+		}
+	}
+
+	// Updating the longest lived session to expire sooner does not shorten
+	// it either, as the index still outlives the session.
+	s, err := r.Get("c")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s.ExpiresAt = now.Add(time.Minute)
+	if err := r.Update(*s); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ttl, err := c.PTTL(userSessionsPrefix + "elroy").Result()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if ttl != 2*time.Hour {
+		t.Errorf("want TTL of %v, got %v", 2*time.Hour, ttl)
+	}
+}
+
+func TestSessionRepoIndexConcurrent(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	c, srv := newTestClient(t, fc)
+	defer srv.Close()
+	r := NewSessionRepoWithClock(c, fc)
+
+	// Concurrently created sessions of a user are all indexed, and the
+	// index takes the TTL of the longest lived of them.
+	now := fc.Now().UTC()
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < cap(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs <- r.Create(session.Session{
+				ID:          string(rune('a' + i)),
+				UserID:      "elroy",
+				ExpiresAt:   now.Add(time.Duration(i+1) * time.Minute),
+				RedirectURL: url.URL{Scheme: "https", Host: "example.com"},
+			})
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ids, err := c.SMembers(userSessionsPrefix + "elroy").Result()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ids) != cap(errs) {
+		t.Errorf("want %d sessions indexed, got %v", cap(errs), ids)
+	}
+	ttl, err := c.PTTL(userSessionsPrefix + "elroy").Result()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := time.Duration(cap(errs)) * time.Minute; ttl != want {
+		t.Errorf("want TTL of %v, got %v", want, ttl)
+	}
+}
+
+func TestSessionKeyRepoPopOnce(t *testing.T) {
+	fc := clockwork.NewFakeClock()
+	c, srv := newTestClient(t, fc)
+	defer srv.Close()
+	r := NewSessionKeyRepoWithClock(c, fc)
+
+	if err := r.Push(session.SessionKey{Key: "key", SessionID: "session"}, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Of many concurrent pops, exactly one gets the session ID.
+	var wg sync.WaitGroup
+	ids := make(chan string, 10)
+	for i := 0; i < cap(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if id, err := r.Pop("key"); err == nil {
+				ids <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+
+	var got []string
+	for id := range ids {
+		got = append(got, id)
+	}
+	if len(got) != 1 || got[0] != "session" {
+		t.Errorf("want exactly one pop of session, got %v", got)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+
+	"github.com/coreos/dex/repo"
 )
 
 const (
@@ -52,6 +57,17 @@ type RefreshTokenRepo interface {
 
 	// Revoke deletes the refresh token if the token belongs to the given userID.
 	Revoke(userID, token string) error
+
+	// ClientsWithRefreshTokens returns the IDs of the clients holding refresh
+	// tokens for the given user, sorted.
+	ClientsWithRefreshTokens(userID string) ([]string, error)
+
+	// RevokeTokensForClient deletes all refresh tokens of the given user held
+	// by the given client.
+	RevokeTokensForClient(userID, clientID string) error
+
+	// RevokeTokensForUser deletes all refresh tokens of the given user.
+	RevokeTokensForUser(tx repo.Transaction, userID string) error
 }
 
 type refreshToken struct {
@@ -61,7 +77,9 @@ type refreshToken struct {
 }
 
 type memRefreshTokenRepo struct {
+	mu             sync.Mutex
 	store          map[int]refreshToken
+	nextID         int
 	tokenGenerator RefreshTokenGenerator
 }
 
@@ -116,7 +134,10 @@ func (r *memRefreshTokenRepo) Create(userID, clientID string) (string, error) {
 		return "", err
 	}
 
-	tokenID := len(r.store) // Should only be used in single threaded tests.
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tokenID := r.nextID
+	r.nextID++
 
 	// No limits on the number of tokens per user/client for this in-memory repo.
 	r.store[tokenID] = refreshToken{
@@ -128,6 +149,8 @@ func (r *memRefreshTokenRepo) Create(userID, clientID string) (string, error) {
 }
 
 func (r *memRefreshTokenRepo) Verify(clientID, token string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	tokenID, tokenPayload, err := parseToken(token)
 	if err != nil {
 		return "", err
@@ -150,6 +173,8 @@ func (r *memRefreshTokenRepo) Verify(clientID, token string) (string, error) {
 }
 
 func (r *memRefreshTokenRepo) Revoke(userID, token string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	tokenID, tokenPayload, err := parseToken(token)
 	if err != nil {
 		return err
@@ -171,3 +196,90 @@ func (r *memRefreshTokenRepo) Revoke(userID, token string) error {
 	delete(r.store, tokenID)
 	return nil
 }
+
+func (r *memRefreshTokenRepo) ClientsWithRefreshTokens(userID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[string]struct{})
+	var clientIDs []string
+	for _, record := range r.store {
+		if record.userID != userID {
+			continue
+		}
+		if _, ok := seen[record.clientID]; !ok {
+			seen[record.clientID] = struct{}{}
+			clientIDs = append(clientIDs, record.clientID)
+		}
+	}
+	sort.Strings(clientIDs)
+	return clientIDs, nil
+}
+
+func (r *memRefreshTokenRepo) RevokeTokensForClient(userID, clientID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for tokenID, record := range r.store {
+		if record.userID == userID && record.clientID == clientID {
+			delete(r.store, tokenID)
+		}
+	}
+	return nil
+}
+
+func (r *memRefreshTokenRepo) RevokeTokensForUser(_ repo.Transaction, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for tokenID, record := range r.store {
+		if record.userID == userID {
+			delete(r.store, tokenID)
+		}
+	}
+	return nil
+}
+
+// memRefreshTokenSnapshot is a token in a snapshot of a memRefreshTokenRepo.
+type memRefreshTokenSnapshot struct {
+	ID       int
+	Payload  []byte
+	UserID   string
+	ClientID string
+}
+
+func (r *memRefreshTokenRepo) Snapshot() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	ts := make([]memRefreshTokenSnapshot, 0, len(r.store))
+	for id, t := range r.store {
+		ts = append(ts, memRefreshTokenSnapshot{
+			ID:       id,
+			Payload:  t.payload,
+			UserID:   t.userID,
+			ClientID: t.clientID,
+		})
+	}
+	sort.Slice(ts, func(i, j int) bool { return ts[i].ID < ts[j].ID })
+	return json.Marshal(ts)
+}
+
+func (r *memRefreshTokenRepo) Restore(b []byte) error {
+	var ts []memRefreshTokenSnapshot
+	if err := json.Unmarshal(b, &ts); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.store = make(map[int]refreshToken, len(ts))
+	r.nextID = 0
+	for _, t := range ts {
+		r.store[t.ID] = refreshToken{
+			payload:  t.Payload,
+			userID:   t.UserID,
+			clientID: t.ClientID,
+		}
+		if t.ID >= r.nextID {
+			r.nextID = t.ID + 1
+		}
+	}
+	return nil
+}
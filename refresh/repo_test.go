@@ -0,0 +1,107 @@
+package refresh
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestMemRefreshTokenRepoCreateAfterRevoke(t *testing.T) {
+	r := NewRefreshTokenRepo()
+
+	tok1, err := r.Create("user", "client")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok2, err := r.Create("user", "client")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := r.Revoke("user", tok1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A new token must not take the place of a live one.
+	if _, err := r.Create("user", "client"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := r.Verify("client", tok2); err != nil {
+		t.Errorf("token invalidated by a later one: %v", err)
+	}
+}
+
+func TestMemRefreshTokenRepoConcurrentCreate(t *testing.T) {
+	r := NewRefreshTokenRepo()
+
+	const n = 20
+	toks := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tok, err := r.Create("user", "client")
+			if err != nil {
+				t.Errorf("case %d: unexpected error: %v", i, err)
+			}
+			toks[i] = tok
+		}(i)
+	}
+	wg.Wait()
+
+	for i, tok := range toks {
+		if _, err := r.Verify("client", tok); err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestMemRefreshTokenRepoSnapshot(t *testing.T) {
+	r := NewRefreshTokenRepo().(*memRefreshTokenRepo)
+	tok1, err := r.Create("user1", "client1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tok2, err := r.Create("user2", "client2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewRefreshTokenRepo().(*memRefreshTokenRepo)
+	if err := restored.Restore(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		clientID string
+		token    string
+		wantUser string
+	}{
+		{clientID: "client1", token: tok1, wantUser: "user1"},
+		{clientID: "client2", token: tok2, wantUser: "user2"},
+	}
+	for i, tt := range tests {
+		userID, err := restored.Verify(tt.clientID, tt.token)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if userID != tt.wantUser {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.wantUser, userID)
+		}
+	}
+
+	// New tokens continue after the restored ones.
+	tok3, err := restored.Create("user3", "client3")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, tt := range tests {
+		if _, err := restored.Verify(tt.clientID, tt.token); err != nil {
+			t.Errorf("case %d: token invalidated by %s: %v", i, tok3, err)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package repo
+
+// Reencrypter re-encrypts the records a storage backend keeps encrypted
+// with key secrets, so that they can all be read with the first, active,
+// secret alone, and older secrets can be retired.
+type Reencrypter interface {
+	// Reencrypt re-encrypts every stale record which one of the secrets
+	// decrypts, unless dryRun is set, and reports the stale records found.
+	Reencrypt(dryRun bool) (ReencryptionReport, error)
+}
+
+// StaleRecord is a record encrypted other than with the active key secret
+// in the current format.
+type StaleRecord struct {
+	// Table names the kind of record, and ID the record within it.
+	Table string
+	ID    string
+
+	// Secret is the index of the key secret decrypting the record, or -1
+	// if none does.
+	Secret int
+
+	// DeprecatedFormat is set for records encrypted with AES-CBC, as
+	// written with --use-deprecated-secret-format.
+	DeprecatedFormat bool
+}
+
+type ReencryptionReport struct {
+	// Records is the number of encrypted records found.
+	Records int
+
+	Stale []StaleRecord
+
+	// Reencrypted is the number of stale records re-encrypted, which is
+	// zero for dry runs.
+	Reencrypted int
+}
+
+// Undecryptable returns the stale records which none of the key secrets
+// decrypts, and which could thus not be re-encrypted.
+func (r ReencryptionReport) Undecryptable() []StaleRecord {
+	var recs []StaleRecord
+	for _, rec := range r.Stale {
+		if rec.Secret < 0 {
+			recs = append(recs, rec)
+		}
+	}
+	return recs
+}
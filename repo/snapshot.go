@@ -0,0 +1,196 @@
+package repo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/pkg/log"
+	ptime "github.com/coreos/dex/pkg/time"
+)
+
+// Snapshotter is implemented by in-memory repos whose contents can be saved
+// and restored.
+type Snapshotter interface {
+	// Snapshot returns the contents of the repo encoded as JSON.
+	Snapshot() ([]byte, error)
+
+	// Restore replaces the contents of the repo with those of a snapshot.
+	Restore([]byte) error
+}
+
+// SnapshotFile saves the contents of a set of in-memory repos to a file, so
+// that they can be restored after a restart. The repos hold secrets such as
+// TOTP keys and refresh tokens, so the file is encrypted with the first of
+// its key secrets, and may be decrypted with any of them.
+type SnapshotFile struct {
+	path     string
+	interval time.Duration
+	secrets  [][]byte
+
+	mu    sync.Mutex
+	names []string
+	repos map[string]Snapshotter
+	last  []byte
+}
+
+type snapshotFileContents struct {
+	Repos map[string]json.RawMessage `json:"repos"`
+}
+
+// NewSnapshotFile returns a SnapshotFile stored at path, which Run saves
+// every interval.
+func NewSnapshotFile(path string, interval time.Duration, secrets ...[]byte) (*SnapshotFile, error) {
+	if len(secrets) == 0 {
+		return nil, errors.New("must provide at least one key secret")
+	}
+	for i, secret := range secrets {
+		if len(secret) != 32 {
+			return nil, fmt.Errorf("key secret %d: expected 32-byte secret", i)
+		}
+	}
+
+	return &SnapshotFile{
+		path:     path,
+		interval: interval,
+		secrets:  secrets,
+		repos:    make(map[string]Snapshotter),
+	}, nil
+}
+
+// Register adds a repo to the snapshot under the given name.
+func (f *SnapshotFile) Register(name string, s Snapshotter) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.repos[name]; !ok {
+		f.names = append(f.names, name)
+	}
+	f.repos[name] = s
+}
+
+// Load restores the registered repos from the file. Repos missing from the
+// file are left as they are, as are all repos if the file does not exist.
+func (f *SnapshotFile) Load() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ciphertext, err := ioutil.ReadFile(f.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	var b []byte
+	for _, secret := range f.secrets {
+		if b, err = pcrypto.Decrypt(ciphertext, secret); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("unable to decrypt snapshot %s with any of the key secrets", f.path)
+	}
+
+	var c snapshotFileContents
+	if err := json.Unmarshal(b, &c); err != nil {
+		return fmt.Errorf("unable to decode snapshot %s: %v", f.path, err)
+	}
+	for _, name := range f.names {
+		raw, ok := c.Repos[name]
+		if !ok {
+			continue
+		}
+		if err := f.repos[name].Restore(raw); err != nil {
+			return fmt.Errorf("unable to restore %s from snapshot %s: %v", name, f.path, err)
+		}
+	}
+	f.last = b
+	return nil
+}
+
+// Save writes the contents of the registered repos to the file, unless they
+// have not changed since it was last loaded or saved. The file is replaced
+// atomically, so that it is never left partially written.
+func (f *SnapshotFile) Save() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	c := snapshotFileContents{Repos: make(map[string]json.RawMessage, len(f.names))}
+	for _, name := range f.names {
+		b, err := f.repos[name].Snapshot()
+		if err != nil {
+			return fmt.Errorf("unable to snapshot %s: %v", name, err)
+		}
+		c.Repos[name] = b
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(b, f.last) {
+		return nil
+	}
+	ciphertext, err := pcrypto.Encrypt(b, f.secrets[0])
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(f.path), filepath.Base(f.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(ciphertext); err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return err
+	}
+
+	f.last = b
+	return nil
+}
+
+// Run saves the file every interval until the returned channel is closed.
+func (f *SnapshotFile) Run() chan struct{} {
+	stop := make(chan struct{})
+
+	go func() {
+		var failing bool
+		next := f.interval
+		for {
+			select {
+			case <-time.After(next):
+				if err := f.Save(); err != nil {
+					if !failing {
+						failing = true
+						next = time.Second
+					} else {
+						next = ptime.ExpBackoff(next, f.interval)
+					}
+					log.Errorf("Failed saving snapshot, retrying in %v: %v", next, err)
+					break
+				}
+				failing = false
+				next = f.interval
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
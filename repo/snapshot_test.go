@@ -0,0 +1,204 @@
+package repo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+)
+
+type staticSnapshotter struct {
+	data     []byte
+	restored []byte
+}
+
+func (s *staticSnapshotter) Snapshot() ([]byte, error) {
+	return s.data, nil
+}
+
+func (s *staticSnapshotter) Restore(b []byte) error {
+	s.restored = b
+	return nil
+}
+
+var (
+	testSnapshotSecret    = bytes.Repeat([]byte{'1'}, 32)
+	testSnapshotOldSecret = bytes.Repeat([]byte{'2'}, 32)
+)
+
+func newTestSnapshotFile(t *testing.T, path string, secrets ...[]byte) *SnapshotFile {
+	f, err := NewSnapshotFile(path, time.Minute, secrets...)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return f
+}
+
+func TestSnapshotFileSaveLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	foo := &staticSnapshotter{data: []byte(`{"a":1}`)}
+	bar := &staticSnapshotter{data: []byte(`[1,2]`)}
+	f := newTestSnapshotFile(t, path, testSnapshotSecret)
+	f.Register("foo", foo)
+	f.Register("bar", bar)
+
+	// Loading a missing file leaves the repos alone.
+	if err := f.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if foo.restored != nil || bar.restored != nil {
+		t.Fatalf("repos restored from missing file")
+	}
+
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "foo", want: `{"a":1}`},
+		{name: "bar", want: `[1,2]`},
+		{name: "baz"},
+	}
+
+	g := newTestSnapshotFile(t, path, testSnapshotSecret)
+	restored := make(map[string]*staticSnapshotter)
+	for _, tt := range tests {
+		restored[tt.name] = &staticSnapshotter{}
+		g.Register(tt.name, restored[tt.name])
+	}
+	if err := g.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, tt := range tests {
+		if got := string(restored[tt.name].restored); got != tt.want {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.want, got)
+		}
+	}
+}
+
+func TestSnapshotFileSaveUnchanged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	s := &staticSnapshotter{data: []byte(`"x"`)}
+	f := newTestSnapshotFile(t, path, testSnapshotSecret)
+	f.Register("s", s)
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// An unchanged snapshot is not written again.
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unchanged snapshot written again: %v", err)
+	}
+
+	s.data = []byte(`"y"`)
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ciphertext, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(ciphertext, []byte(`"y"`)) {
+		t.Errorf("snapshot written in plaintext")
+	}
+	b, err := pcrypto.Decrypt(ciphertext, testSnapshotSecret)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := `{"repos":{"s":"y"}}`; string(b) != want {
+		t.Errorf("want=%s, got=%s", want, b)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("want 1 file in snapshot directory, got %d", len(files))
+	}
+}
+
+func TestSnapshotFileLoadInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+	if err := ioutil.WriteFile(path, []byte("not json"), 0600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := newTestSnapshotFile(t, path, testSnapshotSecret)
+	f.Register("s", &staticSnapshotter{})
+	if err := f.Load(); err == nil {
+		t.Errorf("want error loading invalid snapshot")
+	}
+}
+
+func TestSnapshotFileKeyRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dex-snapshot")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "snapshot.json")
+
+	f := newTestSnapshotFile(t, path, testSnapshotOldSecret)
+	f.Register("s", &staticSnapshotter{data: []byte(`"x"`)})
+	if err := f.Save(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A snapshot written with an older secret is still read.
+	s := &staticSnapshotter{}
+	g := newTestSnapshotFile(t, path, testSnapshotSecret, testSnapshotOldSecret)
+	g.Register("s", s)
+	if err := g.Load(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(s.restored) != `"x"` {
+		t.Errorf("want=%q, got=%q", `"x"`, s.restored)
+	}
+
+	// Without it, the snapshot cannot be read.
+	h := newTestSnapshotFile(t, path, testSnapshotSecret)
+	h.Register("s", &staticSnapshotter{})
+	if err := h.Load(); err == nil {
+		t.Errorf("want error loading snapshot without its secret")
+	}
+}
+
+func TestNewSnapshotFileInvalidKey(t *testing.T) {
+	if _, err := NewSnapshotFile("snapshot.json", time.Minute); err == nil {
+		t.Errorf("want error without key secrets")
+	}
+	if _, err := NewSnapshotFile("snapshot.json", time.Minute, []byte("sharks")); err == nil {
+		t.Errorf("want error for key secret that is not 32 bytes")
+	}
+}
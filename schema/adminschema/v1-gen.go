@@ -46,7 +46,12 @@ func New(client *http.Client) (*Service, error) {
 	}
 	s := &Service{client: client, BasePath: basePath}
 	s.Admin = NewAdminService(s)
+	s.AuditEvent = NewAuditEventService(s)
+	s.Client = NewClientService(s)
+	s.KeySecrets = NewKeySecretsService(s)
 	s.State = NewStateService(s)
+	s.User = NewUserService(s)
+	s.Webhook = NewWebhookService(s)
 	return s, nil
 }
 
@@ -56,7 +61,17 @@ type Service struct {
 
 	Admin *AdminService
 
+	AuditEvent *AuditEventService
+
+	Client *ClientService
+
+	KeySecrets *KeySecretsService
+
 	State *StateService
+
+	User *UserService
+
+	Webhook *WebhookService
 }
 
 func NewAdminService(s *Service) *AdminService {
@@ -68,6 +83,33 @@ type AdminService struct {
 	s *Service
 }
 
+func NewAuditEventService(s *Service) *AuditEventService {
+	rs := &AuditEventService{s: s}
+	return rs
+}
+
+type AuditEventService struct {
+	s *Service
+}
+
+func NewClientService(s *Service) *ClientService {
+	rs := &ClientService{s: s}
+	return rs
+}
+
+type ClientService struct {
+	s *Service
+}
+
+func NewKeySecretsService(s *Service) *KeySecretsService {
+	rs := &KeySecretsService{s: s}
+	return rs
+}
+
+type KeySecretsService struct {
+	s *Service
+}
+
 func NewStateService(s *Service) *StateService {
 	rs := &StateService{s: s}
 	return rs
@@ -77,6 +119,24 @@ type StateService struct {
 	s *Service
 }
 
+func NewUserService(s *Service) *UserService {
+	rs := &UserService{s: s}
+	return rs
+}
+
+type UserService struct {
+	s *Service
+}
+
+func NewWebhookService(s *Service) *WebhookService {
+	rs := &WebhookService{s: s}
+	return rs
+}
+
+type WebhookService struct {
+	s *Service
+}
+
 type Admin struct {
 	Email string `json:"email,omitempty"`
 
@@ -85,10 +145,119 @@ type Admin struct {
 	Password string `json:"password,omitempty"`
 }
 
+type AuditEvent struct {
+	ClientId string `json:"clientId,omitempty"`
+
+	ConnectorId string `json:"connectorId,omitempty"`
+
+	Details string `json:"details,omitempty"`
+
+	Id string `json:"id,omitempty"`
+
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+
+	Time string `json:"time,omitempty"`
+
+	Type string `json:"type,omitempty"`
+
+	UserId string `json:"userId,omitempty"`
+}
+
+type AuditEventsResponse struct {
+	Events []*AuditEvent `json:"events,omitempty"`
+
+	NextPageToken string `json:"nextPageToken,omitempty"`
+}
+
+type Client struct {
+	Id string `json:"id,omitempty"`
+
+	IsAdmin bool `json:"isAdmin,omitempty"`
+
+	RedirectURIs []string `json:"redirectURIs,omitempty"`
+}
+
+type ClientClaimMapping struct {
+	// Claims: The custom attributes of users added to the client's ID
+	// tokens, keyed by the name of the claim holding each.
+	Claims map[string]string `json:"claims,omitempty"`
+}
+
+type ClientRotateSecretRequest struct {
+	// GracePeriodSeconds: Number of seconds for which the previous secrets
+	// of the client remain valid. If zero, they are revoked immediately.
+	GracePeriodSeconds int64 `json:"gracePeriodSeconds,omitempty"`
+}
+
+type ClientRotateSecretResponse struct {
+	Id string `json:"id,omitempty"`
+
+	Secret string `json:"secret,omitempty"`
+}
+
+type ClientSetAdminRequest struct {
+	IsAdmin bool `json:"isAdmin,omitempty"`
+}
+
+type ClientsResponse struct {
+	Clients []*Client `json:"clients,omitempty"`
+}
+
+type ReencryptRequest struct {
+	// DryRun: If true, only the stale records are reported.
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+type ReencryptResponse struct {
+	// Records: The number of encrypted records found.
+	Records int64 `json:"records,omitempty"`
+
+	// Reencrypted: The number of stale records re-encrypted.
+	Reencrypted int64 `json:"reencrypted,omitempty"`
+
+	StaleRecords []*StaleRecord `json:"staleRecords,omitempty"`
+}
+
+type StaleRecord struct {
+	// DeprecatedFormat: If true, the record is encrypted in the deprecated
+	// AES-CBC format.
+	DeprecatedFormat bool `json:"deprecatedFormat,omitempty"`
+
+	Id string `json:"id,omitempty"`
+
+	// Secret: The index of the key secret decrypting the record, or -1 if
+	// none does.
+	Secret int64 `json:"secret,omitempty"`
+
+	Table string `json:"table,omitempty"`
+}
+
 type State struct {
 	AdminUserCreated bool `json:"AdminUserCreated,omitempty"`
 }
 
+type UserAttributes struct {
+	// Attributes: An object holding the custom attributes of a user by
+	// name. Values are strings, numbers, booleans or lists of strings.
+	Attributes interface{} `json:"attributes,omitempty"`
+}
+
+type Webhook struct {
+	CreatedAt string `json:"createdAt,omitempty"`
+
+	Events []string `json:"events,omitempty"`
+
+	Id string `json:"id,omitempty"`
+
+	Secret string `json:"secret,omitempty"`
+
+	Url string `json:"url,omitempty"`
+}
+
+type WebhooksResponse struct {
+	Webhooks []*Webhook `json:"webhooks,omitempty"`
+}
+
 // method id "dex.admin.Admin.Create":
 
 type AdminCreateCall struct {
@@ -230,35 +399,103 @@ func (c *AdminGetCall) Do() (*Admin, error) {
 
 }
 
-// method id "dex.admin.State.Get":
+// method id "dex.admin.AuditEvent.List":
 
-type StateGetCall struct {
+type AuditEventListCall struct {
 	s    *Service
 	opt_ map[string]interface{}
 }
 
-// Get: Get the state of the Dex DB
-func (r *StateService) Get() *StateGetCall {
-	c := &StateGetCall{s: r.s, opt_: make(map[string]interface{})}
+// List: Retrieve a page of audit events, newest first. Events must
+// match every filter given. The filters are ignored when nextPageToken
+// is given.
+func (r *AuditEventService) List() *AuditEventListCall {
+	c := &AuditEventListCall{s: r.s, opt_: make(map[string]interface{})}
+	return c
+}
+
+// After sets the optional parameter "after": Only events at or after
+// this time.
+func (c *AuditEventListCall) After(after string) *AuditEventListCall {
+	c.opt_["after"] = after
+	return c
+}
+
+// Before sets the optional parameter "before": Only events before this
+// time.
+func (c *AuditEventListCall) Before(before string) *AuditEventListCall {
+	c.opt_["before"] = before
+	return c
+}
+
+// ClientId sets the optional parameter "clientId":
+func (c *AuditEventListCall) ClientId(clientId string) *AuditEventListCall {
+	c.opt_["clientId"] = clientId
+	return c
+}
+
+// MaxResults sets the optional parameter "maxResults":
+func (c *AuditEventListCall) MaxResults(maxResults int64) *AuditEventListCall {
+	c.opt_["maxResults"] = maxResults
+	return c
+}
+
+// NextPageToken sets the optional parameter "nextPageToken":
+func (c *AuditEventListCall) NextPageToken(nextPageToken string) *AuditEventListCall {
+	c.opt_["nextPageToken"] = nextPageToken
+	return c
+}
+
+// Type sets the optional parameter "type": Only events of this type,
+// such as login or login_failed.
+func (c *AuditEventListCall) Type(type_ string) *AuditEventListCall {
+	c.opt_["type"] = type_
+	return c
+}
+
+// UserId sets the optional parameter "userId":
+func (c *AuditEventListCall) UserId(userId string) *AuditEventListCall {
+	c.opt_["userId"] = userId
 	return c
 }
 
 // Fields allows partial responses to be retrieved.
 // See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *StateGetCall) Fields(s ...googleapi.Field) *StateGetCall {
+func (c *AuditEventListCall) Fields(s ...googleapi.Field) *AuditEventListCall {
 	c.opt_["fields"] = googleapi.CombineFields(s)
 	return c
 }
 
-func (c *StateGetCall) Do() (*State, error) {
+func (c *AuditEventListCall) Do() (*AuditEventsResponse, error) {
 	var body io.Reader = nil
 	params := make(url.Values)
 	params.Set("alt", "json")
+	if v, ok := c.opt_["after"]; ok {
+		params.Set("after", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["before"]; ok {
+		params.Set("before", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["clientId"]; ok {
+		params.Set("clientId", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["maxResults"]; ok {
+		params.Set("maxResults", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["nextPageToken"]; ok {
+		params.Set("nextPageToken", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["type"]; ok {
+		params.Set("type", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["userId"]; ok {
+		params.Set("userId", fmt.Sprintf("%v", v))
+	}
 	if v, ok := c.opt_["fields"]; ok {
 		params.Set("fields", fmt.Sprintf("%v", v))
 	}
-	urls := googleapi.ResolveRelative(c.s.BasePath, "state")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "audit-events")
 	urls += "?" + params.Encode()
 	req, _ := http.NewRequest("GET", urls, body)
 	googleapi.SetOpaque(req.URL)
@@ -271,18 +508,1397 @@ func (c *StateGetCall) Do() (*State, error) {
 	if err := googleapi.CheckResponse(res); err != nil {
 		return nil, err
 	}
-	var ret *State
+	var ret *AuditEventsResponse
 	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
 	// {
-	//   "description": "Get the state of the Dex DB",
+	//   "description": "Retrieve a page of audit events, newest first. Events must match every filter given. The filters are ignored when nextPageToken is given.",
 	//   "httpMethod": "GET",
-	//   "id": "dex.admin.State.Get",
-	//   "path": "state",
+	//   "id": "dex.admin.AuditEvent.List",
+	//   "parameters": {
+	//     "after": {
+	//       "description": "Only events at or after this time.",
+	//       "format": "date-time",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "before": {
+	//       "description": "Only events before this time.",
+	//       "format": "date-time",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "clientId": {
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "maxResults": {
+	//       "location": "query",
+	//       "type": "integer"
+	//     },
+	//     "nextPageToken": {
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "type": {
+	//       "description": "Only events of this type, such as login or login_failed.",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "userId": {
+	//       "location": "query",
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "audit-events",
 	//   "response": {
-	//     "$ref": "State"
+	//     "$ref": "AuditEventsResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.Delete":
+
+type ClientDeleteCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Delete: Delete a client and all of its secrets.
+func (r *ClientService) Delete(id string) *ClientDeleteCall {
+	c := &ClientDeleteCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientDeleteCall) Fields(s ...googleapi.Field) *ClientDeleteCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientDeleteCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Delete a client and all of its secrets.",
+	//   "httpMethod": "DELETE",
+	//   "id": "dex.admin.Client.Delete",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}"
+	// }
+
+}
+
+// method id "dex.admin.Client.Get":
+
+type ClientGetCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Get: Retrieve a single client by id.
+func (r *ClientService) Get(id string) *ClientGetCall {
+	c := &ClientGetCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientGetCall) Fields(s ...googleapi.Field) *ClientGetCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientGetCall) Do() (*Client, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *Client
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve a single client by id.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.Client.Get",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}",
+	//   "response": {
+	//     "$ref": "Client"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.GetClaimMapping":
+
+type ClientGetClaimMappingCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// GetClaimMapping: Get the claim mapping of a client.
+func (r *ClientService) GetClaimMapping(id string) *ClientGetClaimMappingCall {
+	c := &ClientGetClaimMappingCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientGetClaimMappingCall) Fields(s ...googleapi.Field) *ClientGetClaimMappingCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientGetClaimMappingCall) Do() (*ClientClaimMapping, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/claim-mapping")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *ClientClaimMapping
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Get the claim mapping of a client.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.Client.GetClaimMapping",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}/claim-mapping",
+	//   "response": {
+	//     "$ref": "ClientClaimMapping"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.List":
+
+type ClientListCall struct {
+	s    *Service
+	opt_ map[string]interface{}
+}
+
+// List: Retrieve all clients.
+func (r *ClientService) List() *ClientListCall {
+	c := &ClientListCall{s: r.s, opt_: make(map[string]interface{})}
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientListCall) Fields(s ...googleapi.Field) *ClientListCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientListCall) Do() (*ClientsResponse, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *ClientsResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve all clients.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.Client.List",
+	//   "path": "clients",
+	//   "response": {
+	//     "$ref": "ClientsResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.RotateSecret":
+
+type ClientRotateSecretCall struct {
+	s                         *Service
+	id                        string
+	clientrotatesecretrequest *ClientRotateSecretRequest
+	opt_                      map[string]interface{}
+}
+
+// RotateSecret: Issue a new secret for a client.
+func (r *ClientService) RotateSecret(id string, clientrotatesecretrequest *ClientRotateSecretRequest) *ClientRotateSecretCall {
+	c := &ClientRotateSecretCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.clientrotatesecretrequest = clientrotatesecretrequest
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientRotateSecretCall) Fields(s ...googleapi.Field) *ClientRotateSecretCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientRotateSecretCall) Do() (*ClientRotateSecretResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.clientrotatesecretrequest)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/rotate-secret")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *ClientRotateSecretResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Issue a new secret for a client.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.admin.Client.RotateSecret",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}/rotate-secret",
+	//   "request": {
+	//     "$ref": "ClientRotateSecretRequest"
+	//   },
+	//   "response": {
+	//     "$ref": "ClientRotateSecretResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.SetAdmin":
+
+type ClientSetAdminCall struct {
+	s                     *Service
+	id                    string
+	clientsetadminrequest *ClientSetAdminRequest
+	opt_                  map[string]interface{}
+}
+
+// SetAdmin: Grant or revoke the admin status of a client.
+func (r *ClientService) SetAdmin(id string, clientsetadminrequest *ClientSetAdminRequest) *ClientSetAdminCall {
+	c := &ClientSetAdminCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.clientsetadminrequest = clientsetadminrequest
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientSetAdminCall) Fields(s ...googleapi.Field) *ClientSetAdminCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientSetAdminCall) Do() error {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.clientsetadminrequest)
+	if err != nil {
+		return err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/admin")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("PUT", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Grant or revoke the admin status of a client.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.admin.Client.SetAdmin",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}/admin",
+	//   "request": {
+	//     "$ref": "ClientSetAdminRequest"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.SetClaimMapping":
+
+type ClientSetClaimMappingCall struct {
+	s                  *Service
+	id                 string
+	clientclaimmapping *ClientClaimMapping
+	opt_               map[string]interface{}
+}
+
+// SetClaimMapping: Replace the claim mapping of a client, which selects
+// the custom attributes of users added to its ID tokens.
+func (r *ClientService) SetClaimMapping(id string, clientclaimmapping *ClientClaimMapping) *ClientSetClaimMappingCall {
+	c := &ClientSetClaimMappingCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.clientclaimmapping = clientclaimmapping
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientSetClaimMappingCall) Fields(s ...googleapi.Field) *ClientSetClaimMappingCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientSetClaimMappingCall) Do() (*ClientClaimMapping, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.clientclaimmapping)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/claim-mapping")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("PUT", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *ClientClaimMapping
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Replace the claim mapping of a client, which selects the custom attributes of users added to its ID tokens.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.admin.Client.SetClaimMapping",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}/claim-mapping",
+	//   "request": {
+	//     "$ref": "ClientClaimMapping"
+	//   },
+	//   "response": {
+	//     "$ref": "ClientClaimMapping"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Client.Update":
+
+type ClientUpdateCall struct {
+	s      *Service
+	id     string
+	client *Client
+	opt_   map[string]interface{}
+}
+
+// Update: Replace the redirect URIs of a client.
+func (r *ClientService) Update(id string, client *Client) *ClientUpdateCall {
+	c := &ClientUpdateCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.client = client
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientUpdateCall) Fields(s ...googleapi.Field) *ClientUpdateCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientUpdateCall) Do() (*Client, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.client)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("PUT", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *Client
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Replace the redirect URIs of a client.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.admin.Client.Update",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}",
+	//   "request": {
+	//     "$ref": "Client"
+	//   },
+	//   "response": {
+	//     "$ref": "Client"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.KeySecrets.Reencrypt":
+
+type KeySecretsReencryptCall struct {
+	s                *Service
+	reencryptrequest *ReencryptRequest
+	opt_             map[string]interface{}
+}
+
+// Reencrypt: Re-encrypt the signing keys and other encrypted data with
+// the active key secret, and report the records which were not
+// encrypted with it.
+func (r *KeySecretsService) Reencrypt(reencryptrequest *ReencryptRequest) *KeySecretsReencryptCall {
+	c := &KeySecretsReencryptCall{s: r.s, opt_: make(map[string]interface{})}
+	c.reencryptrequest = reencryptrequest
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *KeySecretsReencryptCall) Fields(s ...googleapi.Field) *KeySecretsReencryptCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *KeySecretsReencryptCall) Do() (*ReencryptResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.reencryptrequest)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "key-secrets/reencrypt")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *ReencryptResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Re-encrypt the signing keys and other encrypted data with the active key secret, and report the records which were not encrypted with it.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.admin.KeySecrets.Reencrypt",
+	//   "path": "key-secrets/reencrypt",
+	//   "request": {
+	//     "$ref": "ReencryptRequest"
+	//   },
+	//   "response": {
+	//     "$ref": "ReencryptResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.State.Get":
+
+type StateGetCall struct {
+	s    *Service
+	opt_ map[string]interface{}
+}
+
+// Get: Get the state of the Dex DB
+func (r *StateService) Get() *StateGetCall {
+	c := &StateGetCall{s: r.s, opt_: make(map[string]interface{})}
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *StateGetCall) Fields(s ...googleapi.Field) *StateGetCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *StateGetCall) Do() (*State, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "state")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *State
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Get the state of the Dex DB",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.State.Get",
+	//   "path": "state",
+	//   "response": {
+	//     "$ref": "State"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.User.Delete":
+
+type UserDeleteCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Delete: Delete a user along with their password, remote identities,
+// second factors, refresh tokens and sessions.
+func (r *UserService) Delete(id string) *UserDeleteCall {
+	c := &UserDeleteCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UserDeleteCall) Fields(s ...googleapi.Field) *UserDeleteCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UserDeleteCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Delete a user along with their password, remote identities, second factors, refresh tokens and sessions.",
+	//   "httpMethod": "DELETE",
+	//   "id": "dex.admin.User.Delete",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}"
+	// }
+
+}
+
+// method id "dex.admin.User.GetAttributes":
+
+type UserGetAttributesCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// GetAttributes: Get the custom attributes of a user.
+func (r *UserService) GetAttributes(id string) *UserGetAttributesCall {
+	c := &UserGetAttributesCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UserGetAttributesCall) Fields(s ...googleapi.Field) *UserGetAttributesCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UserGetAttributesCall) Do() (*UserAttributes, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/attributes")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserAttributes
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Get the custom attributes of a user.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.User.GetAttributes",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/attributes",
+	//   "response": {
+	//     "$ref": "UserAttributes"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.User.ResetSecondFactor":
+
+type UserResetSecondFactorCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// ResetSecondFactor: Remove the second factors of a user, their TOTP
+// secret and any security keys, so that they can log in with their
+// first factor alone and enroll again.
+func (r *UserService) ResetSecondFactor(id string) *UserResetSecondFactorCall {
+	c := &UserResetSecondFactorCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UserResetSecondFactorCall) Fields(s ...googleapi.Field) *UserResetSecondFactorCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UserResetSecondFactorCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/reset-second-factor")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Remove the second factors of a user, their TOTP secret and any security keys, so that they can log in with their first factor alone and enroll again.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.admin.User.ResetSecondFactor",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/reset-second-factor"
+	// }
+
+}
+
+// method id "dex.admin.User.SetAttributes":
+
+type UserSetAttributesCall struct {
+	s              *Service
+	id             string
+	userattributes *UserAttributes
+	opt_           map[string]interface{}
+}
+
+// SetAttributes: Replace the custom attributes of a user.
+func (r *UserService) SetAttributes(id string, userattributes *UserAttributes) *UserSetAttributesCall {
+	c := &UserSetAttributesCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.userattributes = userattributes
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UserSetAttributesCall) Fields(s ...googleapi.Field) *UserSetAttributesCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UserSetAttributesCall) Do() (*UserAttributes, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.userattributes)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/attributes")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("PUT", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserAttributes
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Replace the custom attributes of a user.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.admin.User.SetAttributes",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/attributes",
+	//   "request": {
+	//     "$ref": "UserAttributes"
+	//   },
+	//   "response": {
+	//     "$ref": "UserAttributes"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.User.Unlock":
+
+type UserUnlockCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Unlock: Lift the lockout of a local user's account after too many
+// failed logins, and forget their failed logins.
+func (r *UserService) Unlock(id string) *UserUnlockCall {
+	c := &UserUnlockCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UserUnlockCall) Fields(s ...googleapi.Field) *UserUnlockCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UserUnlockCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/unlock")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Lift the lockout of a local user's account after too many failed logins, and forget their failed logins.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.admin.User.Unlock",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/unlock"
+	// }
+
+}
+
+// method id "dex.admin.Webhook.Create":
+
+type WebhookCreateCall struct {
+	s       *Service
+	webhook *Webhook
+	opt_    map[string]interface{}
+}
+
+// Create: Subscribe a URL to user lifecycle events. The response holds
+// the secret with which payloads are signed; it cannot be retrieved
+// again.
+func (r *WebhookService) Create(webhook *Webhook) *WebhookCreateCall {
+	c := &WebhookCreateCall{s: r.s, opt_: make(map[string]interface{})}
+	c.webhook = webhook
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *WebhookCreateCall) Fields(s ...googleapi.Field) *WebhookCreateCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *WebhookCreateCall) Do() (*Webhook, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.webhook)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "webhooks")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *Webhook
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Subscribe a URL to user lifecycle events. The response holds the secret with which payloads are signed; it cannot be retrieved again.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.admin.Webhook.Create",
+	//   "path": "webhooks",
+	//   "request": {
+	//     "$ref": "Webhook"
+	//   },
+	//   "response": {
+	//     "$ref": "Webhook"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Webhook.Delete":
+
+type WebhookDeleteCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Delete: Delete a webhook subscription. Events queued for it are
+// dropped.
+func (r *WebhookService) Delete(id string) *WebhookDeleteCall {
+	c := &WebhookDeleteCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *WebhookDeleteCall) Fields(s ...googleapi.Field) *WebhookDeleteCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *WebhookDeleteCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "webhooks/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Delete a webhook subscription. Events queued for it are dropped.",
+	//   "httpMethod": "DELETE",
+	//   "id": "dex.admin.Webhook.Delete",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "webhooks/{id}"
+	// }
+
+}
+
+// method id "dex.admin.Webhook.Get":
+
+type WebhookGetCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Get: Retrieve a single webhook subscription by id. Its secret is left
+// out.
+func (r *WebhookService) Get(id string) *WebhookGetCall {
+	c := &WebhookGetCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *WebhookGetCall) Fields(s ...googleapi.Field) *WebhookGetCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *WebhookGetCall) Do() (*Webhook, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "webhooks/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *Webhook
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve a single webhook subscription by id. Its secret is left out.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.Webhook.Get",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "webhooks/{id}",
+	//   "response": {
+	//     "$ref": "Webhook"
+	//   }
+	// }
+
+}
+
+// method id "dex.admin.Webhook.List":
+
+type WebhookListCall struct {
+	s    *Service
+	opt_ map[string]interface{}
+}
+
+// List: Retrieve all webhook subscriptions. Their secrets are left out.
+func (r *WebhookService) List() *WebhookListCall {
+	c := &WebhookListCall{s: r.s, opt_: make(map[string]interface{})}
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *WebhookListCall) Fields(s ...googleapi.Field) *WebhookListCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *WebhookListCall) Do() (*WebhooksResponse, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "webhooks")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *WebhooksResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve all webhook subscriptions. Their secrets are left out.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.admin.Webhook.List",
+	//   "path": "webhooks",
+	//   "response": {
+	//     "$ref": "WebhooksResponse"
 	//   }
 	// }
 
@@ -50,6 +50,224 @@ const DiscoveryJSON = `{
                   "type": "boolean"
               }
           }
+      },
+      "Client": {
+          "id": "Client",
+          "type": "object",
+          "properties": {
+              "id": {
+                  "type": "string"
+              },
+              "redirectURIs": {
+                  "required": true,
+                  "type": "array",
+                  "items": {
+                      "type": "string"
+                  }
+              },
+              "isAdmin": {
+                  "type": "boolean"
+              }
+          }
+      },
+      "ClientsResponse": {
+          "id": "ClientsResponse",
+          "type": "object",
+          "properties": {
+              "clients": {
+                  "type": "array",
+                  "items": {
+                      "$ref": "Client"
+                  }
+              }
+          }
+      },
+      "ClientSetAdminRequest": {
+          "id": "ClientSetAdminRequest",
+          "type": "object",
+          "properties": {
+              "isAdmin": {
+                  "type": "boolean"
+              }
+          }
+      },
+      "ClientClaimMapping": {
+          "id": "ClientClaimMapping",
+          "type": "object",
+          "properties": {
+              "claims": {
+                  "type": "object",
+                  "description": "The custom attributes of users added to the client's ID tokens, keyed by the name of the claim holding each.",
+                  "additionalProperties": {
+                      "type": "string"
+                  }
+              }
+          }
+      },
+      "UserAttributes": {
+          "id": "UserAttributes",
+          "type": "object",
+          "properties": {
+              "attributes": {
+                  "type": "any",
+                  "description": "An object holding the custom attributes of a user by name. Values are strings, numbers, booleans or lists of strings."
+              }
+          }
+      },
+      "ClientRotateSecretRequest": {
+          "id": "ClientRotateSecretRequest",
+          "type": "object",
+          "properties": {
+              "gracePeriodSeconds": {
+                  "type": "integer",
+                  "description": "Number of seconds for which the previous secrets of the client remain valid. If zero, they are revoked immediately."
+              }
+          }
+      },
+      "AuditEvent": {
+          "id": "AuditEvent",
+          "type": "object",
+          "properties": {
+              "id": {
+                  "type": "string"
+              },
+              "type": {
+                  "type": "string"
+              },
+              "time": {
+                  "type": "string",
+                  "format": "date-time"
+              },
+              "userId": {
+                  "type": "string"
+              },
+              "clientId": {
+                  "type": "string"
+              },
+              "connectorId": {
+                  "type": "string"
+              },
+              "remoteAddr": {
+                  "type": "string"
+              },
+              "details": {
+                  "type": "string"
+              }
+          }
+      },
+      "AuditEventsResponse": {
+          "id": "AuditEventsResponse",
+          "type": "object",
+          "properties": {
+              "events": {
+                  "type": "array",
+                  "items": {
+                      "$ref": "AuditEvent"
+                  }
+              },
+              "nextPageToken": {
+                  "type": "string"
+              }
+          }
+      },
+      "Webhook": {
+          "id": "Webhook",
+          "type": "object",
+          "properties": {
+              "id": {
+                  "type": "string"
+              },
+              "url": {
+                  "type": "string"
+              },
+              "events": {
+                  "type": "array",
+                  "items": {
+                      "type": "string"
+                  }
+              },
+              "secret": {
+                  "type": "string"
+              },
+              "createdAt": {
+                  "type": "string",
+                  "format": "date-time"
+              }
+          }
+      },
+      "WebhooksResponse": {
+          "id": "WebhooksResponse",
+          "type": "object",
+          "properties": {
+              "webhooks": {
+                  "type": "array",
+                  "items": {
+                      "$ref": "Webhook"
+                  }
+              }
+          }
+      },
+      "ClientRotateSecretResponse": {
+          "id": "ClientRotateSecretResponse",
+          "type": "object",
+          "properties": {
+              "id": {
+                  "type": "string"
+              },
+              "secret": {
+                  "type": "string"
+              }
+          }
+      },
+      "ReencryptRequest": {
+          "id": "ReencryptRequest",
+          "type": "object",
+          "properties": {
+              "dryRun": {
+                  "type": "boolean",
+                  "description": "If true, only the stale records are reported."
+              }
+          }
+      },
+      "ReencryptResponse": {
+          "id": "ReencryptResponse",
+          "type": "object",
+          "properties": {
+              "records": {
+                  "type": "integer",
+                  "description": "The number of encrypted records found."
+              },
+              "reencrypted": {
+                  "type": "integer",
+                  "description": "The number of stale records re-encrypted."
+              },
+              "staleRecords": {
+                  "type": "array",
+                  "items": {
+                      "$ref": "StaleRecord"
+                  }
+              }
+          }
+      },
+      "StaleRecord": {
+          "id": "StaleRecord",
+          "type": "object",
+          "properties": {
+              "table": {
+                  "type": "string"
+              },
+              "id": {
+                  "type": "string"
+              },
+              "secret": {
+                  "type": "integer",
+                  "description": "The index of the key secret decrypting the record, or -1 if none does."
+              },
+              "deprecatedFormat": {
+                  "type": "boolean",
+                  "description": "If true, the record is encrypted in the deprecated AES-CBC format."
+              }
+          }
       }
   },
   "resources": {
@@ -101,6 +319,375 @@ const DiscoveryJSON = `{
                   }
               }
           }
+      },
+      "Client": {
+          "methods": {
+              "List": {
+                  "id": "dex.admin.Client.List",
+                  "description": "Retrieve all clients.",
+                  "httpMethod": "GET",
+                  "path": "clients",
+                  "response": {
+                      "$ref": "ClientsResponse"
+                  }
+              },
+              "Get": {
+                  "id": "dex.admin.Client.Get",
+                  "description": "Retrieve a single client by id.",
+                  "httpMethod": "GET",
+                  "path": "clients/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "response": {
+                      "$ref": "Client"
+                  }
+              },
+              "Update": {
+                  "id": "dex.admin.Client.Update",
+                  "description": "Replace the redirect URIs of a client.",
+                  "httpMethod": "PUT",
+                  "path": "clients/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "request": {
+                      "$ref": "Client"
+                  },
+                  "response": {
+                      "$ref": "Client"
+                  }
+              },
+              "Delete": {
+                  "id": "dex.admin.Client.Delete",
+                  "description": "Delete a client and all of its secrets.",
+                  "httpMethod": "DELETE",
+                  "path": "clients/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ]
+              },
+              "SetAdmin": {
+                  "id": "dex.admin.Client.SetAdmin",
+                  "description": "Grant or revoke the admin status of a client.",
+                  "httpMethod": "PUT",
+                  "path": "clients/{id}/admin",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "request": {
+                      "$ref": "ClientSetAdminRequest"
+                  }
+              },
+              "GetClaimMapping": {
+                  "id": "dex.admin.Client.GetClaimMapping",
+                  "description": "Get the claim mapping of a client.",
+                  "httpMethod": "GET",
+                  "path": "clients/{id}/claim-mapping",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "response": {
+                      "$ref": "ClientClaimMapping"
+                  }
+              },
+              "SetClaimMapping": {
+                  "id": "dex.admin.Client.SetClaimMapping",
+                  "description": "Replace the claim mapping of a client, which selects the custom attributes of users added to its ID tokens.",
+                  "httpMethod": "PUT",
+                  "path": "clients/{id}/claim-mapping",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "request": {
+                      "$ref": "ClientClaimMapping"
+                  },
+                  "response": {
+                      "$ref": "ClientClaimMapping"
+                  }
+              },
+              "RotateSecret": {
+                  "id": "dex.admin.Client.RotateSecret",
+                  "description": "Issue a new secret for a client.",
+                  "httpMethod": "POST",
+                  "path": "clients/{id}/rotate-secret",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "request": {
+                      "$ref": "ClientRotateSecretRequest"
+                  },
+                  "response": {
+                      "$ref": "ClientRotateSecretResponse"
+                  }
+              }
+          }
+      },
+      "User": {
+          "methods": {
+              "ResetSecondFactor": {
+                  "id": "dex.admin.User.ResetSecondFactor",
+                  "description": "Remove the second factors of a user, their TOTP secret and any security keys, so that they can log in with their first factor alone and enroll again.",
+                  "httpMethod": "POST",
+                  "path": "users/{id}/reset-second-factor",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ]
+              },
+              "Unlock": {
+                  "id": "dex.admin.User.Unlock",
+                  "description": "Lift the lockout of a local user's account after too many failed logins, and forget their failed logins.",
+                  "httpMethod": "POST",
+                  "path": "users/{id}/unlock",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ]
+              },
+              "GetAttributes": {
+                  "id": "dex.admin.User.GetAttributes",
+                  "description": "Get the custom attributes of a user.",
+                  "httpMethod": "GET",
+                  "path": "users/{id}/attributes",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "response": {
+                      "$ref": "UserAttributes"
+                  }
+              },
+              "SetAttributes": {
+                  "id": "dex.admin.User.SetAttributes",
+                  "description": "Replace the custom attributes of a user.",
+                  "httpMethod": "PUT",
+                  "path": "users/{id}/attributes",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "request": {
+                      "$ref": "UserAttributes"
+                  },
+                  "response": {
+                      "$ref": "UserAttributes"
+                  }
+              },
+              "Delete": {
+                  "id": "dex.admin.User.Delete",
+                  "description": "Delete a user along with their password, remote identities, second factors, refresh tokens and sessions.",
+                  "httpMethod": "DELETE",
+                  "path": "users/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ]
+              }
+          }
+      },
+      "AuditEvent": {
+          "methods": {
+              "List": {
+                  "id": "dex.admin.AuditEvent.List",
+                  "description": "Retrieve a page of audit events, newest first. Events must match every filter given. The filters are ignored when nextPageToken is given.",
+                  "httpMethod": "GET",
+                  "path": "audit-events",
+                  "parameters": {
+                      "nextPageToken": {
+                          "type": "string",
+                          "location": "query"
+                      },
+                      "maxResults": {
+                          "type": "integer",
+                          "location": "query"
+                      },
+                      "userId": {
+                          "type": "string",
+                          "location": "query"
+                      },
+                      "clientId": {
+                          "type": "string",
+                          "location": "query"
+                      },
+                      "type": {
+                          "type": "string",
+                          "description": "Only events of this type, such as login or login_failed.",
+                          "location": "query"
+                      },
+                      "after": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Only events at or after this time.",
+                          "location": "query"
+                      },
+                      "before": {
+                          "type": "string",
+                          "format": "date-time",
+                          "description": "Only events before this time.",
+                          "location": "query"
+                      }
+                  },
+                  "response": {
+                      "$ref": "AuditEventsResponse"
+                  }
+              }
+          }
+      },
+      "Webhook": {
+          "methods": {
+              "List": {
+                  "id": "dex.admin.Webhook.List",
+                  "description": "Retrieve all webhook subscriptions. Their secrets are left out.",
+                  "httpMethod": "GET",
+                  "path": "webhooks",
+                  "response": {
+                      "$ref": "WebhooksResponse"
+                  }
+              },
+              "Get": {
+                  "id": "dex.admin.Webhook.Get",
+                  "description": "Retrieve a single webhook subscription by id. Its secret is left out.",
+                  "httpMethod": "GET",
+                  "path": "webhooks/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ],
+                  "response": {
+                      "$ref": "Webhook"
+                  }
+              },
+              "Create": {
+                  "id": "dex.admin.Webhook.Create",
+                  "description": "Subscribe a URL to user lifecycle events. The response holds the secret with which payloads are signed; it cannot be retrieved again.",
+                  "httpMethod": "POST",
+                  "path": "webhooks",
+                  "request": {
+                      "$ref": "Webhook"
+                  },
+                  "response": {
+                      "$ref": "Webhook"
+                  }
+              },
+              "Delete": {
+                  "id": "dex.admin.Webhook.Delete",
+                  "description": "Delete a webhook subscription. Events queued for it are dropped.",
+                  "httpMethod": "DELETE",
+                  "path": "webhooks/{id}",
+                  "parameters": {
+                      "id": {
+                          "type": "string",
+                          "required": true,
+                          "location": "path"
+                      }
+                  },
+                  "parameterOrder": [
+                      "id"
+                  ]
+              }
+          }
+      },
+      "KeySecrets": {
+          "methods": {
+              "Reencrypt": {
+                  "id": "dex.admin.KeySecrets.Reencrypt",
+                  "description": "Re-encrypt the signing keys and other encrypted data with the active key secret, and report the records which were not encrypted with it.",
+                  "httpMethod": "POST",
+                  "path": "key-secrets/reencrypt",
+                  "request": {
+                      "$ref": "ReencryptRequest"
+                  },
+                  "response": {
+                      "$ref": "ReencryptResponse"
+                  }
+              }
+          }
       }
   }
 }
@@ -89,6 +89,22 @@ type ClientPage struct {
 	NextPageToken string `json:"nextPageToken,omitempty"`
 }
 
+type ClientRotateSecretRequest struct {
+	// GracePeriodSeconds: Number of seconds for which the previous secrets
+	// of the client remain valid. If zero, they are revoked immediately.
+	GracePeriodSeconds int64 `json:"gracePeriodSeconds,omitempty"`
+}
+
+type ClientRotateSecretResponse struct {
+	Id string `json:"id,omitempty"`
+
+	Secret string `json:"secret,omitempty"`
+}
+
+type ClientSetAdminRequest struct {
+	IsAdmin bool `json:"isAdmin,omitempty"`
+}
+
 type ClientWithSecret struct {
 	Id string `json:"id,omitempty"`
 
@@ -103,6 +119,17 @@ type Error struct {
 	Error_description string `json:"error_description,omitempty"`
 }
 
+type RemoteIdentitiesResponse struct {
+	RemoteIdentities []*RemoteIdentity `json:"remoteIdentities,omitempty"`
+}
+
+type RemoteIdentity struct {
+	ConnectorID string `json:"connectorID,omitempty"`
+
+	// Id: The ID of the user at the connector.
+	Id string `json:"id,omitempty"`
+}
+
 type User struct {
 	Admin bool `json:"admin,omitempty"`
 
@@ -119,6 +146,12 @@ type User struct {
 	Id string `json:"id,omitempty"`
 }
 
+type UserAttributes struct {
+	// Attributes: An object holding the custom attributes of a user by
+	// name. Values are strings, numbers, booleans or lists of strings.
+	Attributes interface{} `json:"attributes,omitempty"`
+}
+
 type UserCreateRequest struct {
 	RedirectURL string `json:"redirectURL,omitempty"`
 
@@ -136,6 +169,10 @@ type UserCreateResponse struct {
 type UserCreateResponseUser struct {
 }
 
+type UserDeleteResponse struct {
+	Ok bool `json:"ok,omitempty"`
+}
+
 type UserDisableRequest struct {
 	// Disable: If true, disable this user, if false, enable them. No error
 	// is signaled if the user state doesn't change.
@@ -225,6 +262,145 @@ func (c *ClientsCreateCall) Do() (*ClientWithSecret, error) {
 
 }
 
+// method id "dex.Client.Delete":
+
+type ClientsDeleteCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Delete: Delete a Client and all of its secrets. Clients may only
+// delete themselves unless they are admin clients.
+func (r *ClientsService) Delete(id string) *ClientsDeleteCall {
+	c := &ClientsDeleteCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientsDeleteCall) Fields(s ...googleapi.Field) *ClientsDeleteCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientsDeleteCall) Do() error {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return err
+	}
+	return nil
+	// {
+	//   "description": "Delete a Client and all of its secrets. Clients may only delete themselves unless they are admin clients.",
+	//   "httpMethod": "DELETE",
+	//   "id": "dex.Client.Delete",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}"
+	// }
+
+}
+
+// method id "dex.Client.Get":
+
+type ClientsGetCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Get: Retrieve a single Client by id. Clients may only retrieve
+// themselves unless they are admin clients.
+func (r *ClientsService) Get(id string) *ClientsGetCall {
+	c := &ClientsGetCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *ClientsGetCall) Fields(s ...googleapi.Field) *ClientsGetCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *ClientsGetCall) Do() (*Client, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *Client
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Retrieve a single Client by id. Clients may only retrieve themselves unless they are admin clients.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.Client.Get",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}",
+	//   "response": {
+	//     "$ref": "Client"
+	//   }
+	// }
+
+}
+
 // method id "dex.Client.List":
 
 type ClientsListCall struct {
@@ -298,32 +474,35 @@ func (c *ClientsListCall) Do() (*ClientPage, error) {
 
 }
 
-// method id "dex.User.Create":
+// method id "dex.Client.RotateSecret":
 
-type UsersCreateCall struct {
-	s                 *Service
-	usercreaterequest *UserCreateRequest
-	opt_              map[string]interface{}
+type ClientsRotateSecretCall struct {
+	s                         *Service
+	id                        string
+	clientrotatesecretrequest *ClientRotateSecretRequest
+	opt_                      map[string]interface{}
 }
 
-// Create: Create a new User.
-func (r *UsersService) Create(usercreaterequest *UserCreateRequest) *UsersCreateCall {
-	c := &UsersCreateCall{s: r.s, opt_: make(map[string]interface{})}
-	c.usercreaterequest = usercreaterequest
+// RotateSecret: Issue a new secret for a Client. Clients may only
+// rotate their own secret unless they are admin clients.
+func (r *ClientsService) RotateSecret(id string, clientrotatesecretrequest *ClientRotateSecretRequest) *ClientsRotateSecretCall {
+	c := &ClientsRotateSecretCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.clientrotatesecretrequest = clientrotatesecretrequest
 	return c
 }
 
 // Fields allows partial responses to be retrieved.
 // See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *UsersCreateCall) Fields(s ...googleapi.Field) *UsersCreateCall {
+func (c *ClientsRotateSecretCall) Fields(s ...googleapi.Field) *ClientsRotateSecretCall {
 	c.opt_["fields"] = googleapi.CombineFields(s)
 	return c
 }
 
-func (c *UsersCreateCall) Do() (*UserCreateResponse, error) {
+func (c *ClientsRotateSecretCall) Do() (*ClientRotateSecretResponse, error) {
 	var body io.Reader = nil
-	body, err := googleapi.WithoutDataWrapper.JSONReader(c.usercreaterequest)
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.clientrotatesecretrequest)
 	if err != nil {
 		return nil, err
 	}
@@ -333,10 +512,12 @@ func (c *UsersCreateCall) Do() (*UserCreateResponse, error) {
 	if v, ok := c.opt_["fields"]; ok {
 		params.Set("fields", fmt.Sprintf("%v", v))
 	}
-	urls := googleapi.ResolveRelative(c.s.BasePath, "users")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/rotate-secret")
 	urls += "?" + params.Encode()
 	req, _ := http.NewRequest("POST", urls, body)
-	googleapi.SetOpaque(req.URL)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
 	req.Header.Set("Content-Type", ctype)
 	req.Header.Set("User-Agent", "google-api-go-client/0.5")
 	res, err := c.s.client.Do(req)
@@ -347,56 +528,67 @@ func (c *UsersCreateCall) Do() (*UserCreateResponse, error) {
 	if err := googleapi.CheckResponse(res); err != nil {
 		return nil, err
 	}
-	var ret *UserCreateResponse
+	var ret *ClientRotateSecretResponse
 	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
 	// {
-	//   "description": "Create a new User.",
+	//   "description": "Issue a new secret for a Client. Clients may only rotate their own secret unless they are admin clients.",
 	//   "httpMethod": "POST",
-	//   "id": "dex.User.Create",
-	//   "path": "users",
+	//   "id": "dex.Client.RotateSecret",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "clients/{id}/rotate-secret",
 	//   "request": {
-	//     "$ref": "UserCreateRequest"
+	//     "$ref": "ClientRotateSecretRequest"
 	//   },
 	//   "response": {
-	//     "$ref": "UserCreateResponse"
+	//     "$ref": "ClientRotateSecretResponse"
 	//   }
 	// }
 
 }
 
-// method id "dex.User.Disable":
+// method id "dex.Client.SetAdmin":
 
-type UsersDisableCall struct {
-	s                  *Service
-	id                 string
-	userdisablerequest *UserDisableRequest
-	opt_               map[string]interface{}
+type ClientsSetAdminCall struct {
+	s                     *Service
+	id                    string
+	clientsetadminrequest *ClientSetAdminRequest
+	opt_                  map[string]interface{}
 }
 
-// Disable: Enable or disable a user.
-func (r *UsersService) Disable(id string, userdisablerequest *UserDisableRequest) *UsersDisableCall {
-	c := &UsersDisableCall{s: r.s, opt_: make(map[string]interface{})}
+// SetAdmin: Grant or revoke the admin status of a Client. Only admin
+// clients may do this.
+func (r *ClientsService) SetAdmin(id string, clientsetadminrequest *ClientSetAdminRequest) *ClientsSetAdminCall {
+	c := &ClientsSetAdminCall{s: r.s, opt_: make(map[string]interface{})}
 	c.id = id
-	c.userdisablerequest = userdisablerequest
+	c.clientsetadminrequest = clientsetadminrequest
 	return c
 }
 
 // Fields allows partial responses to be retrieved.
 // See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *UsersDisableCall) Fields(s ...googleapi.Field) *UsersDisableCall {
+func (c *ClientsSetAdminCall) Fields(s ...googleapi.Field) *ClientsSetAdminCall {
 	c.opt_["fields"] = googleapi.CombineFields(s)
 	return c
 }
 
-func (c *UsersDisableCall) Do() (*UserDisableResponse, error) {
+func (c *ClientsSetAdminCall) Do() error {
 	var body io.Reader = nil
-	body, err := googleapi.WithoutDataWrapper.JSONReader(c.userdisablerequest)
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.clientsetadminrequest)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	ctype := "application/json"
 	params := make(url.Values)
@@ -404,9 +596,9 @@ func (c *UsersDisableCall) Do() (*UserDisableResponse, error) {
 	if v, ok := c.opt_["fields"]; ok {
 		params.Set("fields", fmt.Sprintf("%v", v))
 	}
-	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/disable")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}/admin")
 	urls += "?" + params.Encode()
-	req, _ := http.NewRequest("POST", urls, body)
+	req, _ := http.NewRequest("PUT", urls, body)
 	googleapi.Expand(req.URL, map[string]string{
 		"id": c.id,
 	})
@@ -414,21 +606,17 @@ func (c *UsersDisableCall) Do() (*UserDisableResponse, error) {
 	req.Header.Set("User-Agent", "google-api-go-client/0.5")
 	res, err := c.s.client.Do(req)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer googleapi.CloseBody(res)
 	if err := googleapi.CheckResponse(res); err != nil {
-		return nil, err
-	}
-	var ret *UserDisableResponse
-	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
-		return nil, err
+		return err
 	}
-	return ret, nil
+	return nil
 	// {
-	//   "description": "Enable or disable a user.",
-	//   "httpMethod": "POST",
-	//   "id": "dex.User.Disable",
+	//   "description": "Grant or revoke the admin status of a Client. Only admin clients may do this.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.Client.SetAdmin",
 	//   "parameterOrder": [
 	//     "id"
 	//   ],
@@ -439,53 +627,59 @@ func (c *UsersDisableCall) Do() (*UserDisableResponse, error) {
 	//       "type": "string"
 	//     }
 	//   },
-	//   "path": "users/{id}/disable",
+	//   "path": "clients/{id}/admin",
 	//   "request": {
-	//     "$ref": "UserDisableRequest"
-	//   },
-	//   "response": {
-	//     "$ref": "UserDisableResponse"
+	//     "$ref": "ClientSetAdminRequest"
 	//   }
 	// }
 
 }
 
-// method id "dex.User.Get":
+// method id "dex.Client.Update":
 
-type UsersGetCall struct {
-	s    *Service
-	id   string
-	opt_ map[string]interface{}
+type ClientsUpdateCall struct {
+	s      *Service
+	id     string
+	client *Client
+	opt_   map[string]interface{}
 }
 
-// Get: Get a single User object by id.
-func (r *UsersService) Get(id string) *UsersGetCall {
-	c := &UsersGetCall{s: r.s, opt_: make(map[string]interface{})}
+// Update: Replace the redirect URIs of a Client. Clients may only
+// update themselves unless they are admin clients.
+func (r *ClientsService) Update(id string, client *Client) *ClientsUpdateCall {
+	c := &ClientsUpdateCall{s: r.s, opt_: make(map[string]interface{})}
 	c.id = id
+	c.client = client
 	return c
 }
 
 // Fields allows partial responses to be retrieved.
 // See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *UsersGetCall) Fields(s ...googleapi.Field) *UsersGetCall {
+func (c *ClientsUpdateCall) Fields(s ...googleapi.Field) *ClientsUpdateCall {
 	c.opt_["fields"] = googleapi.CombineFields(s)
 	return c
 }
 
-func (c *UsersGetCall) Do() (*UserResponse, error) {
+func (c *ClientsUpdateCall) Do() (*Client, error) {
 	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.client)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
 	params := make(url.Values)
 	params.Set("alt", "json")
 	if v, ok := c.opt_["fields"]; ok {
 		params.Set("fields", fmt.Sprintf("%v", v))
 	}
-	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}")
+	urls := googleapi.ResolveRelative(c.s.BasePath, "clients/{id}")
 	urls += "?" + params.Encode()
-	req, _ := http.NewRequest("GET", urls, body)
+	req, _ := http.NewRequest("PUT", urls, body)
 	googleapi.Expand(req.URL, map[string]string{
 		"id": c.id,
 	})
+	req.Header.Set("Content-Type", ctype)
 	req.Header.Set("User-Agent", "google-api-go-client/0.5")
 	res, err := c.s.client.Do(req)
 	if err != nil {
@@ -495,15 +689,15 @@ func (c *UsersGetCall) Do() (*UserResponse, error) {
 	if err := googleapi.CheckResponse(res); err != nil {
 		return nil, err
 	}
-	var ret *UserResponse
+	var ret *Client
 	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
 		return nil, err
 	}
 	return ret, nil
 	// {
-	//   "description": "Get a single User object by id.",
-	//   "httpMethod": "GET",
-	//   "id": "dex.User.Get",
+	//   "description": "Replace the redirect URIs of a Client. Clients may only update themselves unless they are admin clients.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.Client.Update",
 	//   "parameterOrder": [
 	//     "id"
 	//   ],
@@ -514,51 +708,586 @@ func (c *UsersGetCall) Do() (*UserResponse, error) {
 	//       "type": "string"
 	//     }
 	//   },
-	//   "path": "users/{id}",
+	//   "path": "clients/{id}",
+	//   "request": {
+	//     "$ref": "Client"
+	//   },
 	//   "response": {
-	//     "$ref": "UserResponse"
+	//     "$ref": "Client"
 	//   }
 	// }
 
 }
 
-// method id "dex.User.List":
-
-type UsersListCall struct {
-	s    *Service
-	opt_ map[string]interface{}
-}
-
-// List: Retrieve a page of User objects.
-func (r *UsersService) List() *UsersListCall {
-	c := &UsersListCall{s: r.s, opt_: make(map[string]interface{})}
-	return c
-}
+// method id "dex.User.Create":
 
-// MaxResults sets the optional parameter "maxResults":
-func (c *UsersListCall) MaxResults(maxResults int64) *UsersListCall {
-	c.opt_["maxResults"] = maxResults
-	return c
+type UsersCreateCall struct {
+	s                 *Service
+	usercreaterequest *UserCreateRequest
+	opt_              map[string]interface{}
 }
 
-// NextPageToken sets the optional parameter "nextPageToken":
-func (c *UsersListCall) NextPageToken(nextPageToken string) *UsersListCall {
-	c.opt_["nextPageToken"] = nextPageToken
+// Create: Create a new User.
+func (r *UsersService) Create(usercreaterequest *UserCreateRequest) *UsersCreateCall {
+	c := &UsersCreateCall{s: r.s, opt_: make(map[string]interface{})}
+	c.usercreaterequest = usercreaterequest
 	return c
 }
 
 // Fields allows partial responses to be retrieved.
 // See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
 // for more information.
-func (c *UsersListCall) Fields(s ...googleapi.Field) *UsersListCall {
+func (c *UsersCreateCall) Fields(s ...googleapi.Field) *UsersCreateCall {
 	c.opt_["fields"] = googleapi.CombineFields(s)
 	return c
 }
 
-func (c *UsersListCall) Do() (*UsersResponse, error) {
+func (c *UsersCreateCall) Do() (*UserCreateResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.usercreaterequest)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.SetOpaque(req.URL)
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserCreateResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Create a new User.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.User.Create",
+	//   "path": "users",
+	//   "request": {
+	//     "$ref": "UserCreateRequest"
+	//   },
+	//   "response": {
+	//     "$ref": "UserCreateResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.Delete":
+
+type UsersDeleteCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Delete: Delete a user along with their password, remote identities,
+// refresh tokens and sessions.
+func (r *UsersService) Delete(id string) *UsersDeleteCall {
+	c := &UsersDeleteCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersDeleteCall) Fields(s ...googleapi.Field) *UsersDeleteCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersDeleteCall) Do() (*UserDeleteResponse, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("DELETE", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserDeleteResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Delete a user along with their password, remote identities, refresh tokens and sessions.",
+	//   "httpMethod": "DELETE",
+	//   "id": "dex.User.Delete",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}",
+	//   "response": {
+	//     "$ref": "UserDeleteResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.Disable":
+
+type UsersDisableCall struct {
+	s                  *Service
+	id                 string
+	userdisablerequest *UserDisableRequest
+	opt_               map[string]interface{}
+}
+
+// Disable: Enable or disable a user.
+func (r *UsersService) Disable(id string, userdisablerequest *UserDisableRequest) *UsersDisableCall {
+	c := &UsersDisableCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.userdisablerequest = userdisablerequest
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersDisableCall) Fields(s ...googleapi.Field) *UsersDisableCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersDisableCall) Do() (*UserDisableResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.userdisablerequest)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/disable")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserDisableResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Enable or disable a user.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.User.Disable",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/disable",
+	//   "request": {
+	//     "$ref": "UserDisableRequest"
+	//   },
+	//   "response": {
+	//     "$ref": "UserDisableResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.Get":
+
+type UsersGetCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// Get: Get a single User object by id.
+func (r *UsersService) Get(id string) *UsersGetCall {
+	c := &UsersGetCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersGetCall) Fields(s ...googleapi.Field) *UsersGetCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersGetCall) Do() (*UserResponse, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Get a single User object by id.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.User.Get",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}",
+	//   "response": {
+	//     "$ref": "UserResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.GetAttributes":
+
+type UsersGetAttributesCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// GetAttributes: Get the custom attributes of a user.
+func (r *UsersService) GetAttributes(id string) *UsersGetAttributesCall {
+	c := &UsersGetAttributesCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersGetAttributesCall) Fields(s ...googleapi.Field) *UsersGetAttributesCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersGetAttributesCall) Do() (*UserAttributes, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/attributes")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserAttributes
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Get the custom attributes of a user.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.User.GetAttributes",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/attributes",
+	//   "response": {
+	//     "$ref": "UserAttributes"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.Link":
+
+type UsersLinkCall struct {
+	s              *Service
+	id             string
+	remoteidentity *RemoteIdentity
+	opt_           map[string]interface{}
+}
+
+// Link: Link a remote identity to a user. The remote identity must not
+// be linked to another user.
+func (r *UsersService) Link(id string, remoteidentity *RemoteIdentity) *UsersLinkCall {
+	c := &UsersLinkCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.remoteidentity = remoteidentity
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersLinkCall) Fields(s ...googleapi.Field) *UsersLinkCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersLinkCall) Do() (*RemoteIdentitiesResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.remoteidentity)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/link")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *RemoteIdentitiesResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Link a remote identity to a user. The remote identity must not be linked to another user.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.User.Link",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/link",
+	//   "request": {
+	//     "$ref": "RemoteIdentity"
+	//   },
+	//   "response": {
+	//     "$ref": "RemoteIdentitiesResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.List":
+
+type UsersListCall struct {
+	s    *Service
+	opt_ map[string]interface{}
+}
+
+// List: Retrieve a page of User objects, sorted by email address. Users
+// must match every filter given. The filters are ignored when
+// nextPageToken is given.
+func (r *UsersService) List() *UsersListCall {
+	c := &UsersListCall{s: r.s, opt_: make(map[string]interface{})}
+	return c
+}
+
+// Admin sets the optional parameter "admin":
+func (c *UsersListCall) Admin(admin bool) *UsersListCall {
+	c.opt_["admin"] = admin
+	return c
+}
+
+// ConnectorID sets the optional parameter "connectorID": Only users
+// with a remote identity at this connector.
+func (c *UsersListCall) ConnectorID(connectorID string) *UsersListCall {
+	c.opt_["connectorID"] = connectorID
+	return c
+}
+
+// CreatedAfter sets the optional parameter "createdAfter": Only users
+// created at or after this time.
+func (c *UsersListCall) CreatedAfter(createdAfter string) *UsersListCall {
+	c.opt_["createdAfter"] = createdAfter
+	return c
+}
+
+// CreatedBefore sets the optional parameter "createdBefore": Only users
+// created before this time.
+func (c *UsersListCall) CreatedBefore(createdBefore string) *UsersListCall {
+	c.opt_["createdBefore"] = createdBefore
+	return c
+}
+
+// Disabled sets the optional parameter "disabled":
+func (c *UsersListCall) Disabled(disabled bool) *UsersListCall {
+	c.opt_["disabled"] = disabled
+	return c
+}
+
+// DisplayName sets the optional parameter "displayName": Only users
+// whose display name contains this, ignoring case.
+func (c *UsersListCall) DisplayName(displayName string) *UsersListCall {
+	c.opt_["displayName"] = displayName
+	return c
+}
+
+// EmailPrefix sets the optional parameter "emailPrefix": Only users
+// whose email address starts with this, ignoring case.
+func (c *UsersListCall) EmailPrefix(emailPrefix string) *UsersListCall {
+	c.opt_["emailPrefix"] = emailPrefix
+	return c
+}
+
+// EmailVerified sets the optional parameter "emailVerified":
+func (c *UsersListCall) EmailVerified(emailVerified bool) *UsersListCall {
+	c.opt_["emailVerified"] = emailVerified
+	return c
+}
+
+// MaxResults sets the optional parameter "maxResults":
+func (c *UsersListCall) MaxResults(maxResults int64) *UsersListCall {
+	c.opt_["maxResults"] = maxResults
+	return c
+}
+
+// NextPageToken sets the optional parameter "nextPageToken":
+func (c *UsersListCall) NextPageToken(nextPageToken string) *UsersListCall {
+	c.opt_["nextPageToken"] = nextPageToken
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersListCall) Fields(s ...googleapi.Field) *UsersListCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersListCall) Do() (*UsersResponse, error) {
 	var body io.Reader = nil
 	params := make(url.Values)
 	params.Set("alt", "json")
+	if v, ok := c.opt_["admin"]; ok {
+		params.Set("admin", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["connectorID"]; ok {
+		params.Set("connectorID", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["createdAfter"]; ok {
+		params.Set("createdAfter", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["createdBefore"]; ok {
+		params.Set("createdBefore", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["disabled"]; ok {
+		params.Set("disabled", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["displayName"]; ok {
+		params.Set("displayName", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["emailPrefix"]; ok {
+		params.Set("emailPrefix", fmt.Sprintf("%v", v))
+	}
+	if v, ok := c.opt_["emailVerified"]; ok {
+		params.Set("emailVerified", fmt.Sprintf("%v", v))
+	}
 	if v, ok := c.opt_["maxResults"]; ok {
 		params.Set("maxResults", fmt.Sprintf("%v", v))
 	}
@@ -587,10 +1316,49 @@ func (c *UsersListCall) Do() (*UsersResponse, error) {
 	}
 	return ret, nil
 	// {
-	//   "description": "Retrieve a page of User objects.",
+	//   "description": "Retrieve a page of User objects, sorted by email address. Users must match every filter given. The filters are ignored when nextPageToken is given.",
 	//   "httpMethod": "GET",
 	//   "id": "dex.User.List",
 	//   "parameters": {
+	//     "admin": {
+	//       "location": "query",
+	//       "type": "boolean"
+	//     },
+	//     "connectorID": {
+	//       "description": "Only users with a remote identity at this connector.",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "createdAfter": {
+	//       "description": "Only users created at or after this time.",
+	//       "format": "date-time",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "createdBefore": {
+	//       "description": "Only users created before this time.",
+	//       "format": "date-time",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "disabled": {
+	//       "location": "query",
+	//       "type": "boolean"
+	//     },
+	//     "displayName": {
+	//       "description": "Only users whose display name contains this, ignoring case.",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "emailPrefix": {
+	//       "description": "Only users whose email address starts with this, ignoring case.",
+	//       "location": "query",
+	//       "type": "string"
+	//     },
+	//     "emailVerified": {
+	//       "location": "query",
+	//       "type": "boolean"
+	//     },
 	//     "maxResults": {
 	//       "location": "query",
 	//       "type": "integer"
@@ -607,3 +1375,242 @@ func (c *UsersListCall) Do() (*UsersResponse, error) {
 	// }
 
 }
+
+// method id "dex.User.ListRemoteIdentities":
+
+type UsersListRemoteIdentitiesCall struct {
+	s    *Service
+	id   string
+	opt_ map[string]interface{}
+}
+
+// ListRemoteIdentities: List the remote identities linked to a user.
+func (r *UsersService) ListRemoteIdentities(id string) *UsersListRemoteIdentitiesCall {
+	c := &UsersListRemoteIdentitiesCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersListRemoteIdentitiesCall) Fields(s ...googleapi.Field) *UsersListRemoteIdentitiesCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersListRemoteIdentitiesCall) Do() (*RemoteIdentitiesResponse, error) {
+	var body io.Reader = nil
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/remote-identities")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("GET", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *RemoteIdentitiesResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "List the remote identities linked to a user.",
+	//   "httpMethod": "GET",
+	//   "id": "dex.User.ListRemoteIdentities",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/remote-identities",
+	//   "response": {
+	//     "$ref": "RemoteIdentitiesResponse"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.SetAttributes":
+
+type UsersSetAttributesCall struct {
+	s              *Service
+	id             string
+	userattributes *UserAttributes
+	opt_           map[string]interface{}
+}
+
+// SetAttributes: Replace the custom attributes of a user.
+func (r *UsersService) SetAttributes(id string, userattributes *UserAttributes) *UsersSetAttributesCall {
+	c := &UsersSetAttributesCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.userattributes = userattributes
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersSetAttributesCall) Fields(s ...googleapi.Field) *UsersSetAttributesCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersSetAttributesCall) Do() (*UserAttributes, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.userattributes)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/attributes")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("PUT", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *UserAttributes
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Replace the custom attributes of a user.",
+	//   "httpMethod": "PUT",
+	//   "id": "dex.User.SetAttributes",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/attributes",
+	//   "request": {
+	//     "$ref": "UserAttributes"
+	//   },
+	//   "response": {
+	//     "$ref": "UserAttributes"
+	//   }
+	// }
+
+}
+
+// method id "dex.User.Unlink":
+
+type UsersUnlinkCall struct {
+	s              *Service
+	id             string
+	remoteidentity *RemoteIdentity
+	opt_           map[string]interface{}
+}
+
+// Unlink: Unlink a remote identity from a user. The last remote
+// identity of a user cannot be unlinked.
+func (r *UsersService) Unlink(id string, remoteidentity *RemoteIdentity) *UsersUnlinkCall {
+	c := &UsersUnlinkCall{s: r.s, opt_: make(map[string]interface{})}
+	c.id = id
+	c.remoteidentity = remoteidentity
+	return c
+}
+
+// Fields allows partial responses to be retrieved.
+// See https://developers.google.com/gdata/docs/2.0/basics#PartialResponse
+// for more information.
+func (c *UsersUnlinkCall) Fields(s ...googleapi.Field) *UsersUnlinkCall {
+	c.opt_["fields"] = googleapi.CombineFields(s)
+	return c
+}
+
+func (c *UsersUnlinkCall) Do() (*RemoteIdentitiesResponse, error) {
+	var body io.Reader = nil
+	body, err := googleapi.WithoutDataWrapper.JSONReader(c.remoteidentity)
+	if err != nil {
+		return nil, err
+	}
+	ctype := "application/json"
+	params := make(url.Values)
+	params.Set("alt", "json")
+	if v, ok := c.opt_["fields"]; ok {
+		params.Set("fields", fmt.Sprintf("%v", v))
+	}
+	urls := googleapi.ResolveRelative(c.s.BasePath, "users/{id}/unlink")
+	urls += "?" + params.Encode()
+	req, _ := http.NewRequest("POST", urls, body)
+	googleapi.Expand(req.URL, map[string]string{
+		"id": c.id,
+	})
+	req.Header.Set("Content-Type", ctype)
+	req.Header.Set("User-Agent", "google-api-go-client/0.5")
+	res, err := c.s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer googleapi.CloseBody(res)
+	if err := googleapi.CheckResponse(res); err != nil {
+		return nil, err
+	}
+	var ret *RemoteIdentitiesResponse
+	if err := json.NewDecoder(res.Body).Decode(&ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+	// {
+	//   "description": "Unlink a remote identity from a user. The last remote identity of a user cannot be unlinked.",
+	//   "httpMethod": "POST",
+	//   "id": "dex.User.Unlink",
+	//   "parameterOrder": [
+	//     "id"
+	//   ],
+	//   "parameters": {
+	//     "id": {
+	//       "location": "path",
+	//       "required": true,
+	//       "type": "string"
+	//     }
+	//   },
+	//   "path": "users/{id}/unlink",
+	//   "request": {
+	//     "$ref": "RemoteIdentity"
+	//   },
+	//   "response": {
+	//     "$ref": "RemoteIdentitiesResponse"
+	//   }
+	// }
+
+}
@@ -89,6 +89,37 @@ const DiscoveryJSON = `{
         }
       }
     },
+    "ClientRotateSecretRequest": {
+      "id": "ClientRotateSecretRequest",
+      "type": "object",
+      "properties": {
+        "gracePeriodSeconds": {
+          "type": "integer",
+          "description": "Number of seconds for which the previous secrets of the client remain valid. If zero, they are revoked immediately."
+        }
+      }
+    },
+    "ClientRotateSecretResponse": {
+      "id": "ClientRotateSecretResponse",
+      "type": "object",
+      "properties": {
+        "id": {
+          "type": "string"
+        },
+        "secret": {
+          "type": "string"
+        }
+      }
+    },
+    "ClientSetAdminRequest": {
+      "id": "ClientSetAdminRequest",
+      "type": "object",
+      "properties": {
+        "isAdmin": {
+          "type": "boolean"
+        }
+      }
+    },
     "User": {
       "id": "User",
       "type": "object",
@@ -188,6 +219,50 @@ const DiscoveryJSON = `{
           "type": "boolean"
         }
       }
+    },
+    "UserDeleteResponse": {
+      "id": "UserDeleteResponse",
+      "type": "object",
+      "properties": {
+        "ok": {
+          "type": "boolean"
+        }
+      }
+    },
+    "RemoteIdentity": {
+      "id": "RemoteIdentity",
+      "type": "object",
+      "properties": {
+        "connectorID": {
+          "type": "string"
+        },
+        "id": {
+          "type": "string",
+          "description": "The ID of the user at the connector."
+        }
+      }
+    },
+    "RemoteIdentitiesResponse": {
+      "id": "RemoteIdentitiesResponse",
+      "type": "object",
+      "properties": {
+        "remoteIdentities": {
+          "type": "array",
+          "items": {
+            "$ref": "RemoteIdentity"
+          }
+        }
+      }
+    },
+    "UserAttributes": {
+      "id": "UserAttributes",
+      "type": "object",
+      "properties": {
+        "attributes": {
+          "type": "any",
+          "description": "An object holding the custom attributes of a user by name. Values are strings, numbers, booleans or lists of strings."
+        }
+      }
     }
   },
   "resources": {
@@ -219,6 +294,104 @@ const DiscoveryJSON = `{
           "response": {
             "$ref": "ClientWithSecret"
           }
+        },
+        "Get": {
+          "id": "dex.Client.Get",
+          "description": "Retrieve a single Client by id. Clients may only retrieve themselves unless they are admin clients.",
+          "httpMethod": "GET",
+          "path": "clients/{id}",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "response": {
+            "$ref": "Client"
+          }
+        },
+        "Update": {
+          "id": "dex.Client.Update",
+          "description": "Replace the redirect URIs of a Client. Clients may only update themselves unless they are admin clients.",
+          "httpMethod": "PUT",
+          "path": "clients/{id}",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "Client"
+          },
+          "response": {
+            "$ref": "Client"
+          }
+        },
+        "Delete": {
+          "id": "dex.Client.Delete",
+          "description": "Delete a Client and all of its secrets. Clients may only delete themselves unless they are admin clients.",
+          "httpMethod": "DELETE",
+          "path": "clients/{id}",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ]
+        },
+        "SetAdmin": {
+          "id": "dex.Client.SetAdmin",
+          "description": "Grant or revoke the admin status of a Client. Only admin clients may do this.",
+          "httpMethod": "PUT",
+          "path": "clients/{id}/admin",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "ClientSetAdminRequest"
+          }
+        },
+        "RotateSecret": {
+          "id": "dex.Client.RotateSecret",
+          "description": "Issue a new secret for a Client. Clients may only rotate their own secret unless they are admin clients.",
+          "httpMethod": "POST",
+          "path": "clients/{id}/rotate-secret",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "ClientRotateSecretRequest"
+          },
+          "response": {
+            "$ref": "ClientRotateSecretResponse"
+          }
         }
       }
     },
@@ -226,7 +399,7 @@ const DiscoveryJSON = `{
       "methods": {
         "List": {
           "id": "dex.User.List",
-          "description": "Retrieve a page of User objects.",
+          "description": "Retrieve a page of User objects, sorted by email address. Users must match every filter given. The filters are ignored when nextPageToken is given.",
           "httpMethod": "GET",
           "path": "users",
           "parameters": {
@@ -237,6 +410,45 @@ const DiscoveryJSON = `{
             "maxResults": {
               "type": "integer",
               "location": "query"
+            },
+            "emailPrefix": {
+              "type": "string",
+              "description": "Only users whose email address starts with this, ignoring case.",
+              "location": "query"
+            },
+            "displayName": {
+              "type": "string",
+              "description": "Only users whose display name contains this, ignoring case.",
+              "location": "query"
+            },
+            "admin": {
+              "type": "boolean",
+              "location": "query"
+            },
+            "disabled": {
+              "type": "boolean",
+              "location": "query"
+            },
+            "emailVerified": {
+              "type": "boolean",
+              "location": "query"
+            },
+            "connectorID": {
+              "type": "string",
+              "description": "Only users with a remote identity at this connector.",
+              "location": "query"
+            },
+            "createdAfter": {
+              "type": "string",
+              "format": "date-time",
+              "description": "Only users created at or after this time.",
+              "location": "query"
+            },
+            "createdBefore": {
+              "type": "string",
+              "format": "date-time",
+              "description": "Only users created before this time.",
+              "location": "query"
             }
           },
           "response": {
@@ -295,6 +507,129 @@ const DiscoveryJSON = `{
           "response": {
             "$ref": "UserDisableResponse"
           }
+        },
+        "Delete": {
+          "id": "dex.User.Delete",
+          "description": "Delete a user along with their password, remote identities, refresh tokens and sessions.",
+          "httpMethod": "DELETE",
+          "path": "users/{id}",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "response": {
+            "$ref": "UserDeleteResponse"
+          }
+        },
+        "ListRemoteIdentities": {
+          "id": "dex.User.ListRemoteIdentities",
+          "description": "List the remote identities linked to a user.",
+          "httpMethod": "GET",
+          "path": "users/{id}/remote-identities",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "response": {
+            "$ref": "RemoteIdentitiesResponse"
+          }
+        },
+        "Link": {
+          "id": "dex.User.Link",
+          "description": "Link a remote identity to a user. The remote identity must not be linked to another user.",
+          "httpMethod": "POST",
+          "path": "users/{id}/link",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "RemoteIdentity"
+          },
+          "response": {
+            "$ref": "RemoteIdentitiesResponse"
+          }
+        },
+        "Unlink": {
+          "id": "dex.User.Unlink",
+          "description": "Unlink a remote identity from a user. The last remote identity of a user cannot be unlinked.",
+          "httpMethod": "POST",
+          "path": "users/{id}/unlink",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "RemoteIdentity"
+          },
+          "response": {
+            "$ref": "RemoteIdentitiesResponse"
+          }
+        },
+        "GetAttributes": {
+          "id": "dex.User.GetAttributes",
+          "description": "Get the custom attributes of a user.",
+          "httpMethod": "GET",
+          "path": "users/{id}/attributes",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "response": {
+            "$ref": "UserAttributes"
+          }
+        },
+        "SetAttributes": {
+          "id": "dex.User.SetAttributes",
+          "description": "Replace the custom attributes of a user.",
+          "httpMethod": "PUT",
+          "path": "users/{id}/attributes",
+          "parameters": {
+            "id": {
+              "type": "string",
+              "required": true,
+              "location": "path"
+            }
+          },
+          "parameterOrder": [
+            "id"
+          ],
+          "request": {
+            "$ref": "UserAttributes"
+          },
+          "response": {
+            "$ref": "UserAttributes"
+          }
         }
       }
     }
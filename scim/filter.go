@@ -0,0 +1,334 @@
+package scim
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Comparison operators of filters.
+const (
+	OpEqual          = "eq"
+	OpNotEqual       = "ne"
+	OpContains       = "co"
+	OpStartsWith     = "sw"
+	OpEndsWith       = "ew"
+	OpGreaterThan    = "gt"
+	OpGreaterOrEqual = "ge"
+	OpLessThan       = "lt"
+	OpLessOrEqual    = "le"
+	OpPresent        = "pr"
+)
+
+var compareOps = map[string]bool{
+	OpEqual:          true,
+	OpNotEqual:       true,
+	OpContains:       true,
+	OpStartsWith:     true,
+	OpEndsWith:       true,
+	OpGreaterThan:    true,
+	OpGreaterOrEqual: true,
+	OpLessThan:       true,
+	OpLessOrEqual:    true,
+}
+
+// Filter is a parsed SCIM filter expression, as described in RFC 7644
+// section 3.4.2.2. Complex attribute filters such as emails[type eq "work"]
+// are not supported.
+type Filter interface {
+	// Match reports whether a resource matches the filter. values returns
+	// the values of an attribute of the resource, which are matched ignoring
+	// case; booleans are given as "true" and "false".
+	Match(values func(attr string) []string) bool
+}
+
+// Comparison compares the values of an attribute to Value using Op.
+type Comparison struct {
+	// Attr is the attribute path, in lower case and without schema URN.
+	Attr  string
+	Op    string
+	Value string
+}
+
+type and struct{ left, right Filter }
+type or struct{ left, right Filter }
+type not struct{ f Filter }
+
+func (f and) Match(values func(string) []string) bool {
+	return f.left.Match(values) && f.right.Match(values)
+}
+
+func (f or) Match(values func(string) []string) bool {
+	return f.left.Match(values) || f.right.Match(values)
+}
+
+func (f not) Match(values func(string) []string) bool {
+	return !f.f.Match(values)
+}
+
+func (c Comparison) Match(values func(string) []string) bool {
+	vs := values(c.Attr)
+	if c.Op == OpPresent {
+		for _, v := range vs {
+			if v != "" {
+				return true
+			}
+		}
+		return false
+	}
+	if c.Op == OpNotEqual {
+		return !Comparison{Attr: c.Attr, Op: OpEqual, Value: c.Value}.Match(values)
+	}
+
+	want := strings.ToLower(c.Value)
+	for _, v := range vs {
+		v = strings.ToLower(v)
+		var ok bool
+		switch c.Op {
+		case OpEqual:
+			ok = v == want
+		case OpContains:
+			ok = strings.Contains(v, want)
+		case OpStartsWith:
+			ok = strings.HasPrefix(v, want)
+		case OpEndsWith:
+			ok = strings.HasSuffix(v, want)
+		case OpGreaterThan:
+			ok = v > want
+		case OpGreaterOrEqual:
+			ok = v >= want
+		case OpLessThan:
+			ok = v < want
+		case OpLessOrEqual:
+			ok = v <= want
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Conjuncts returns the comparisons which must all hold for f to match, so
+// that callers can narrow down the resources to match f against.
+func Conjuncts(f Filter) []Comparison {
+	switch f := f.(type) {
+	case Comparison:
+		return []Comparison{f}
+	case and:
+		return append(Conjuncts(f.left), Conjuncts(f.right)...)
+	}
+	return nil
+}
+
+// ParseFilter parses a SCIM filter expression. Only the given attribute
+// paths, such as "userName" or "emails.value", may be used. Errors are of
+// type *Error.
+func ParseFilter(s string, attrs ...string) (Filter, error) {
+	known := make(map[string]bool)
+	for _, a := range attrs {
+		known[strings.ToLower(a)] = true
+	}
+
+	toks, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &filterParser{toks: toks, known: known}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos != len(p.toks) {
+		return nil, BadRequest(ErrorTypeInvalidFilter, "unexpected %q in filter", p.toks[p.pos].text)
+	}
+	return f, nil
+}
+
+type token struct {
+	text string
+	// quoted is set for string literals, whose text is unquoted.
+	quoted bool
+}
+
+func tokenize(s string) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			toks = append(toks, token{text: string(c)})
+			i++
+		case c == '"':
+			var b []byte
+			j := i + 1
+			for ; j < len(s) && s[j] != '"'; j++ {
+				if s[j] == '\\' && j+1 < len(s) {
+					j++
+				}
+				b = append(b, s[j])
+			}
+			if j == len(s) {
+				return nil, BadRequest(ErrorTypeInvalidFilter, "unterminated string in filter")
+			}
+			toks = append(toks, token{text: string(b), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(s) && s[j] != ' ' && s[j] != '\t' && s[j] != '(' && s[j] != ')' && s[j] != '"' {
+				j++
+			}
+			if s[i:j] == "" || strings.ContainsAny(s[i:j], "[]") {
+				return nil, BadRequest(ErrorTypeInvalidFilter, "unsupported filter %q", s)
+			}
+			toks = append(toks, token{text: s[i:j]})
+			i = j
+		}
+	}
+	return toks, nil
+}
+
+type filterParser struct {
+	toks  []token
+	pos   int
+	known map[string]bool
+}
+
+// keyword reports whether the next token is the given keyword, consuming it
+// if so.
+func (p *filterParser) keyword(kw string) bool {
+	if p.pos < len(p.toks) && !p.toks[p.pos].quoted && strings.EqualFold(p.toks[p.pos].text, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) next() (token, error) {
+	if p.pos == len(p.toks) {
+		return token{}, BadRequest(ErrorTypeInvalidFilter, "unexpected end of filter")
+	}
+	t := p.toks[p.pos]
+	p.pos++
+	return t, nil
+}
+
+func (p *filterParser) parseOr() (Filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("or") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		f = or{f, right}
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseAnd() (Filter, error) {
+	f, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.keyword("and") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		f = and{f, right}
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseUnary() (Filter, error) {
+	if p.keyword("not") {
+		if !p.keyword("(") {
+			return nil, BadRequest(ErrorTypeInvalidFilter, "expected ( after not")
+		}
+		f, err := p.parseGroup()
+		if err != nil {
+			return nil, err
+		}
+		return not{f}, nil
+	}
+	if p.keyword("(") {
+		return p.parseGroup()
+	}
+	return p.parseComparison()
+}
+
+// parseGroup parses the rest of a parenthesized filter.
+func (p *filterParser) parseGroup() (Filter, error) {
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.keyword(")") {
+		return nil, BadRequest(ErrorTypeInvalidFilter, "expected ) in filter")
+	}
+	return f, nil
+}
+
+func (p *filterParser) parseComparison() (Filter, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.quoted {
+		return nil, BadRequest(ErrorTypeInvalidFilter, "expected attribute, found %q", t.text)
+	}
+	attr := attrPath(t.text)
+	if !p.known[attr] {
+		return nil, BadRequest(ErrorTypeInvalidFilter, "unsupported attribute %q in filter", t.text)
+	}
+
+	t, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	op := strings.ToLower(t.text)
+	if op == OpPresent && !t.quoted {
+		return Comparison{Attr: attr, Op: op}, nil
+	}
+	if !compareOps[op] || t.quoted {
+		return nil, BadRequest(ErrorTypeInvalidFilter, "unknown operator %q in filter", t.text)
+	}
+
+	t, err = p.next()
+	if err != nil {
+		return nil, err
+	}
+	value := t.text
+	if !t.quoted {
+		value = strings.ToLower(value)
+		if value != "true" && value != "false" && value != "null" && !isNumber(value) {
+			return nil, BadRequest(ErrorTypeInvalidFilter, "invalid value %q in filter", t.text)
+		}
+	}
+	return Comparison{Attr: attr, Op: op, Value: value}, nil
+}
+
+// attrPath returns the attribute path p in lower case and without its
+// schema URN, if any.
+func attrPath(p string) string {
+	p = strings.ToLower(p)
+	for _, urn := range []string{SchemaUser, SchemaGroup} {
+		if prefix := strings.ToLower(urn) + ":"; strings.HasPrefix(p, prefix) {
+			return p[len(prefix):]
+		}
+	}
+	return p
+}
+
+func isNumber(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && r != '.' && r != '-' {
+			return false
+		}
+	}
+	return s != ""
+}
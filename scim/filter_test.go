@@ -0,0 +1,129 @@
+package scim
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var testAttrs = []string{"userName", "displayName", "emails.value", "active", "meta.created"}
+
+func testValues(attr string) []string {
+	switch attr {
+	case "username", "emails.value":
+		return []string{"Jane.Doe@example.com"}
+	case "displayname":
+		return []string{"Jane Doe"}
+	case "active":
+		return []string{"true"}
+	case "meta.created":
+		return []string{"2016-01-02T15:04:05Z"}
+	}
+	return nil
+}
+
+func TestFilterMatch(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   bool
+	}{
+		{`userName eq "jane.doe@example.com"`, true},
+		{`userName eq "jane@example.com"`, false},
+		{`USERNAME EQ "Jane.Doe@Example.com"`, true},
+		{`urn:ietf:params:scim:schemas:core:2.0:User:userName eq "jane.doe@example.com"`, true},
+		{`userName ne "jane@example.com"`, true},
+		{`userName sw "jane."`, true},
+		{`userName ew "@example.com"`, true},
+		{`displayName co "doe"`, true},
+		{`displayName co "smith"`, false},
+		{`active eq true`, true},
+		{`active eq false`, false},
+		{`displayName pr`, true},
+		{`meta.created gt "2016-01-01T00:00:00Z"`, true},
+		{`meta.created lt "2016-01-01T00:00:00Z"`, false},
+		{`userName sw "jane" and active eq false`, false},
+		{`userName sw "john" or displayName co "jane"`, true},
+		{`userName sw "john" or displayName co "jane" and active eq false`, false},
+		{`(userName sw "john" or displayName co "jane") and active eq true`, true},
+		{`not (active eq true)`, false},
+		{`displayName eq "Jane \"JD\" Doe"`, false},
+	}
+
+	for i, tt := range tests {
+		f, err := ParseFilter(tt.filter, testAttrs...)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if got := f.Match(testValues); got != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, got)
+		}
+	}
+}
+
+func TestParseFilterInvalid(t *testing.T) {
+	tests := []string{
+		`externalId eq "abc"`,
+		`userName`,
+		`userName eq`,
+		`userName is "jane"`,
+		`userName eq jane`,
+		`userName eq "jane`,
+		`(userName eq "jane"`,
+		`userName eq "jane")`,
+		`not userName eq "jane"`,
+		`emails[type eq "work"]`,
+		`userName eq "jane" and`,
+		`"userName" eq "jane"`,
+	}
+
+	for i, filter := range tests {
+		_, err := ParseFilter(filter, testAttrs...)
+		serr, ok := err.(*Error)
+		if !ok {
+			t.Errorf("case %d: want *Error, got %v", i, err)
+			continue
+		}
+		if serr.ScimType != ErrorTypeInvalidFilter || serr.Status != "400" {
+			t.Errorf("case %d: want 400 %s, got %s %s", i, ErrorTypeInvalidFilter, serr.Status, serr.ScimType)
+		}
+	}
+}
+
+func TestConjuncts(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []Comparison
+	}{
+		{
+			filter: `userName eq "Jane@example.com"`,
+			want:   []Comparison{{Attr: "username", Op: OpEqual, Value: "Jane@example.com"}},
+		},
+		{
+			filter: `userName sw "jane" and (active eq TRUE and displayName pr)`,
+			want: []Comparison{
+				{Attr: "username", Op: OpStartsWith, Value: "jane"},
+				{Attr: "active", Op: OpEqual, Value: "true"},
+				{Attr: "displayname", Op: OpPresent},
+			},
+		},
+		{
+			filter: `userName sw "jane" and (active eq true or displayName pr)`,
+			want:   []Comparison{{Attr: "username", Op: OpStartsWith, Value: "jane"}},
+		},
+		{
+			filter: `not (userName sw "jane")`,
+		},
+	}
+
+	for i, tt := range tests {
+		f, err := ParseFilter(tt.filter, testAttrs...)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, Conjuncts(f)); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
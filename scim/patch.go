@@ -0,0 +1,315 @@
+package scim
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// PatchRequest modifies a resource, as described in RFC 7644 section 3.5.2.
+type PatchRequest struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []PatchOperation `json:"Operations"`
+}
+
+type PatchOperation struct {
+	// Op is "add", "remove" or "replace", in any case.
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+const (
+	patchAdd     = "add"
+	patchRemove  = "remove"
+	patchReplace = "replace"
+)
+
+// ignoredUserAttrs are attributes of the core User schema which dex does not
+// store. Changes to them are accepted and dropped, so that clients which
+// always send them can still provision users.
+var ignoredUserAttrs = map[string]bool{
+	"externalid":           true,
+	"name.givenname":       true,
+	"name.familyname":      true,
+	"name.middlename":      true,
+	"name.honorificprefix": true,
+	"name.honorificsuffix": true,
+	"nickname":             true,
+	"profileurl":           true,
+	"title":                true,
+	"usertype":             true,
+	"preferredlanguage":    true,
+	"locale":               true,
+	"timezone":             true,
+	"phonenumbers":         true,
+	"ims":                  true,
+	"photos":               true,
+	"addresses":            true,
+	"entitlements":         true,
+	"roles":                true,
+	"x509certificates":     true,
+}
+
+// Apply applies the operations of a PatchRequest to u. Errors are of type
+// *Error.
+func (u *User) Apply(ops []PatchOperation) error {
+	for _, op := range ops {
+		if err := u.apply(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *User) apply(op PatchOperation) error {
+	kind, err := patchOp(op)
+	if err != nil {
+		return err
+	}
+
+	path := attrPath(op.Path)
+	if path == "" {
+		if kind == patchRemove {
+			return BadRequest(ErrorTypeNoTarget, "remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return BadRequest(ErrorTypeInvalidValue, "value must be an object if no path is given")
+		}
+		for name, value := range attrs {
+			if name == "schemas" || name == "id" {
+				continue
+			}
+			if err := u.set(attrPath(name), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// emails[type eq "work"].value refers to the sole email address of a
+	// dex user.
+	if strings.HasPrefix(path, "emails[") {
+		if !strings.HasSuffix(path, "].value") && !strings.HasSuffix(path, "]") {
+			return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", op.Path)
+		}
+		path = "emails.value"
+	}
+
+	if kind == patchRemove {
+		switch {
+		case ignoredUserAttrs[path] || ignoredUserAttrs[rootAttr(path)]:
+			return nil
+		case path == "displayname" || path == "name" || path == "name.formatted":
+			u.DisplayName = ""
+			u.Name = nil
+			return nil
+		case path == "username" || path == "emails" || path == "emails.value":
+			return BadRequest(ErrorTypeMutability, "%s is required", op.Path)
+		}
+		return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", op.Path)
+	}
+	return u.set(path, op.Value)
+}
+
+// set sets the user attribute with the given path to value.
+func (u *User) set(path string, value json.RawMessage) error {
+	switch path {
+	case "username":
+		return unmarshalValue(path, value, &u.UserName)
+	case "displayname":
+		return unmarshalValue(path, value, &u.DisplayName)
+	case "name":
+		var name Name
+		if err := unmarshalValue(path, value, &name); err != nil {
+			return err
+		}
+		if name.Formatted != "" {
+			u.Name = &name
+			u.DisplayName = name.Formatted
+		}
+		return nil
+	case "name.formatted":
+		var formatted string
+		if err := unmarshalValue(path, value, &formatted); err != nil {
+			return err
+		}
+		u.Name = &Name{Formatted: formatted}
+		u.DisplayName = formatted
+		return nil
+	case "emails":
+		var emails []Email
+		if err := unmarshalValue(path, value, &emails); err != nil {
+			return err
+		}
+		u.Emails = emails
+		u.UserName = u.Email()
+		return nil
+	case "emails.value":
+		var email string
+		if err := unmarshalValue(path, value, &email); err != nil {
+			return err
+		}
+		u.Emails = []Email{{Value: email, Primary: true}}
+		u.UserName = email
+		return nil
+	case "active":
+		active, err := boolValue(value)
+		if err != nil {
+			return err
+		}
+		u.Active = &active
+		return nil
+	}
+
+	if ignoredUserAttrs[path] || ignoredUserAttrs[rootAttr(path)] || strings.HasPrefix(path, "urn:") {
+		return nil
+	}
+	return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", path)
+}
+
+// Apply applies the operations of a PatchRequest to g. Errors are of type
+// *Error.
+func (g *Group) Apply(ops []PatchOperation) error {
+	for _, op := range ops {
+		if err := g.apply(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Group) apply(op PatchOperation) error {
+	kind, err := patchOp(op)
+	if err != nil {
+		return err
+	}
+
+	path := attrPath(op.Path)
+	switch {
+	case path == "":
+		if kind == patchRemove {
+			return BadRequest(ErrorTypeNoTarget, "remove requires a path")
+		}
+		var attrs map[string]json.RawMessage
+		if err := json.Unmarshal(op.Value, &attrs); err != nil {
+			return BadRequest(ErrorTypeInvalidValue, "value must be an object if no path is given")
+		}
+		for name, value := range attrs {
+			if err := g.applyAttr(kind, attrPath(name), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case strings.HasPrefix(path, "members["):
+		if kind != patchRemove || !strings.HasSuffix(path, "]") {
+			return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", op.Path)
+		}
+		// Parse the filter from op.Path, as attrPath loses its case.
+		inner := op.Path[strings.Index(op.Path, "[")+1 : len(op.Path)-1]
+		f, err := ParseFilter(inner, "value")
+		if err != nil {
+			return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", op.Path)
+		}
+		var members []Reference
+		for _, m := range g.Members {
+			m := m
+			if !f.Match(func(string) []string { return []string{m.Value} }) {
+				members = append(members, m)
+			}
+		}
+		g.Members = members
+		return nil
+	}
+	return g.applyAttr(kind, path, op.Value)
+}
+
+func (g *Group) applyAttr(kind, path string, value json.RawMessage) error {
+	switch path {
+	case "schemas", "id", "externalid":
+		return nil
+	case "displayname":
+		if kind == patchRemove {
+			return BadRequest(ErrorTypeMutability, "displayName is required")
+		}
+		return unmarshalValue(path, value, &g.DisplayName)
+	case "members":
+		var members []Reference
+		if len(value) > 0 {
+			if err := unmarshalValue(path, value, &members); err != nil {
+				return err
+			}
+		}
+		switch kind {
+		case patchAdd:
+			g.Members = append(g.Members, members...)
+		case patchReplace:
+			g.Members = members
+		case patchRemove:
+			if len(value) == 0 {
+				g.Members = nil
+				return nil
+			}
+			remove := make(map[string]bool)
+			for _, m := range members {
+				remove[m.Value] = true
+			}
+			var kept []Reference
+			for _, m := range g.Members {
+				if !remove[m.Value] {
+					kept = append(kept, m)
+				}
+			}
+			g.Members = kept
+		}
+		return nil
+	}
+	return BadRequest(ErrorTypeInvalidPath, "unsupported path %q", path)
+}
+
+// patchOp returns the kind of op in lower case.
+func patchOp(op PatchOperation) (string, error) {
+	kind := strings.ToLower(op.Op)
+	switch kind {
+	case patchAdd, patchReplace:
+		if len(op.Value) == 0 {
+			return "", BadRequest(ErrorTypeInvalidValue, "%s requires a value", op.Op)
+		}
+		return kind, nil
+	case patchRemove:
+		return kind, nil
+	}
+	return "", BadRequest(ErrorTypeInvalidSyntax, "unknown operation %q", op.Op)
+}
+
+func unmarshalValue(path string, value json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(value, v); err != nil {
+		return BadRequest(ErrorTypeInvalidValue, "invalid value for %s", path)
+	}
+	return nil
+}
+
+// boolValue parses a boolean value, which some clients send as a string such
+// as "False".
+func boolValue(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b, nil
+		}
+	}
+	return false, BadRequest(ErrorTypeInvalidValue, "invalid value for active")
+}
+
+// rootAttr returns the top level attribute of a path such as "name.givenname".
+func rootAttr(path string) string {
+	if i := strings.IndexAny(path, ".["); i >= 0 {
+		return path[:i]
+	}
+	return path
+}
@@ -0,0 +1,192 @@
+package scim
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func mustParsePatch(t *testing.T, s string) []PatchOperation {
+	var req PatchRequest
+	if err := json.Unmarshal([]byte(s), &req); err != nil {
+		t.Fatalf("unable to parse patch request %s: %v", s, err)
+	}
+	return req.Operations
+}
+
+func TestUserApply(t *testing.T) {
+	tests := []struct {
+		ops      string
+		want     User
+		wantType string
+	}{
+		{
+			ops: `{"Operations": [{"op": "replace", "path": "active", "value": false}]}`,
+			want: User{
+				UserName:    "jane@example.com",
+				DisplayName: "Jane",
+				Active:      boolPtr(false),
+			},
+		},
+		{
+			// As sent by Azure AD.
+			ops: `{"Operations": [
+				{"op": "Replace", "path": "active", "value": "False"},
+				{"op": "Replace", "path": "emails[type eq \"work\"].value", "value": "jd@example.com"},
+				{"op": "Add", "path": "name.givenName", "value": "Jane"},
+				{"op": "Add", "path": "externalId", "value": "1234"}
+			]}`,
+			want: User{
+				UserName:    "jd@example.com",
+				DisplayName: "Jane",
+				Emails:      []Email{{Value: "jd@example.com", Primary: true}},
+				Active:      boolPtr(false),
+			},
+		},
+		{
+			// As sent by Okta.
+			ops: `{"Operations": [{"op": "replace", "value": {"active": true, "displayName": "Jane Doe"}}]}`,
+			want: User{
+				UserName:    "jane@example.com",
+				DisplayName: "Jane Doe",
+				Active:      boolPtr(true),
+			},
+		},
+		{
+			ops: `{"Operations": [{"op": "replace", "path": "name", "value": {"formatted": "J. Doe", "givenName": "J."}}]}`,
+			want: User{
+				UserName:    "jane@example.com",
+				DisplayName: "J. Doe",
+				Name:        &Name{Formatted: "J. Doe"},
+			},
+		},
+		{
+			ops: `{"Operations": [{"op": "remove", "path": "displayName"}]}`,
+			want: User{
+				UserName: "jane@example.com",
+			},
+		},
+		{
+			ops:      `{"Operations": [{"op": "remove", "path": "userName"}]}`,
+			wantType: ErrorTypeMutability,
+		},
+		{
+			ops:      `{"Operations": [{"op": "replace", "path": "password", "value": "secret"}]}`,
+			wantType: ErrorTypeInvalidPath,
+		},
+		{
+			ops:      `{"Operations": [{"op": "replace", "path": "active", "value": "maybe"}]}`,
+			wantType: ErrorTypeInvalidValue,
+		},
+		{
+			ops:      `{"Operations": [{"op": "replace", "path": "active"}]}`,
+			wantType: ErrorTypeInvalidValue,
+		},
+		{
+			ops:      `{"Operations": [{"op": "move", "path": "active", "value": true}]}`,
+			wantType: ErrorTypeInvalidSyntax,
+		},
+	}
+
+	for i, tt := range tests {
+		u := User{UserName: "jane@example.com", DisplayName: "Jane"}
+		err := u.Apply(mustParsePatch(t, tt.ops))
+		if tt.wantType != "" {
+			serr, ok := err.(*Error)
+			if !ok || serr.ScimType != tt.wantType {
+				t.Errorf("case %d: want error of type %s, got %v", i, tt.wantType, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, u); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestGroupApply(t *testing.T) {
+	tests := []struct {
+		ops      string
+		want     []string
+		wantName string
+		wantType string
+	}{
+		{
+			ops:      `{"Operations": [{"op": "add", "path": "members", "value": [{"value": "ID-3"}]}]}`,
+			want:     []string{"ID-1", "ID-2", "ID-3"},
+			wantName: "Engineering",
+		},
+		{
+			ops:      `{"Operations": [{"op": "remove", "path": "members[value eq \"ID-1\"]"}]}`,
+			want:     []string{"ID-2"},
+			wantName: "Engineering",
+		},
+		{
+			// As sent by Azure AD.
+			ops:      `{"Operations": [{"op": "Remove", "path": "members", "value": [{"value": "ID-2"}]}]}`,
+			want:     []string{"ID-1"},
+			wantName: "Engineering",
+		},
+		{
+			ops:      `{"Operations": [{"op": "remove", "path": "members"}]}`,
+			wantName: "Engineering",
+		},
+		{
+			ops:      `{"Operations": [{"op": "replace", "path": "members", "value": [{"value": "ID-3"}]}]}`,
+			want:     []string{"ID-3"},
+			wantName: "Engineering",
+		},
+		{
+			// As sent by Okta.
+			ops:      `{"Operations": [{"op": "replace", "value": {"id": "group-1", "displayName": "Developers"}}]}`,
+			want:     []string{"ID-1", "ID-2"},
+			wantName: "Developers",
+		},
+		{
+			ops:      `{"Operations": [{"op": "replace", "value": {"owner": "jane"}}]}`,
+			wantType: ErrorTypeInvalidPath,
+		},
+		{
+			ops:      `{"Operations": [{"op": "remove", "path": "displayName"}]}`,
+			wantType: ErrorTypeMutability,
+		},
+		{
+			ops:      `{"Operations": [{"op": "add", "path": "members[value eq \"ID-3\"]", "value": [{"value": "ID-3"}]}]}`,
+			wantType: ErrorTypeInvalidPath,
+		},
+	}
+
+	for i, tt := range tests {
+		g := Group{
+			DisplayName: "Engineering",
+			Members:     []Reference{{Value: "ID-1"}, {Value: "ID-2"}},
+		}
+		err := g.Apply(mustParsePatch(t, tt.ops))
+		if tt.wantType != "" {
+			serr, ok := err.(*Error)
+			if !ok || serr.ScimType != tt.wantType {
+				t.Errorf("case %d: want error of type %s, got %v", i, tt.wantType, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, g.MemberIDs()); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+		if g.DisplayName != tt.wantName {
+			t.Errorf("case %d: want displayName=%q, got %q", i, tt.wantName, g.DisplayName)
+		}
+	}
+}
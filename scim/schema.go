@@ -0,0 +1,182 @@
+package scim
+
+// MaxResults is the maximum number of resources returned by a query.
+const MaxResults = 1000
+
+type Supported struct {
+	Supported bool `json:"supported"`
+}
+
+type FilterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+type BulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+type AuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Primary     bool   `json:"primary,omitempty"`
+}
+
+// ServiceProviderConfig describes the SCIM features supported by dex.
+type ServiceProviderConfig struct {
+	Schemas               []string               `json:"schemas"`
+	Patch                 Supported              `json:"patch"`
+	Bulk                  BulkSupport            `json:"bulk"`
+	Filter                FilterSupport          `json:"filter"`
+	ChangePassword        Supported              `json:"changePassword"`
+	Sort                  Supported              `json:"sort"`
+	ETag                  Supported              `json:"etag"`
+	AuthenticationSchemes []AuthenticationScheme `json:"authenticationSchemes"`
+	Meta                  *Meta                  `json:"meta,omitempty"`
+}
+
+func NewServiceProviderConfig(baseURL string) ServiceProviderConfig {
+	return ServiceProviderConfig{
+		Schemas: []string{SchemaServiceProviderConfig},
+		Patch:   Supported{true},
+		Filter:  FilterSupport{Supported: true, MaxResults: MaxResults},
+		AuthenticationSchemes: []AuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "OAuth Bearer Token",
+			Description: "Client credentials token of a dex admin client",
+			Primary:     true,
+		}},
+		Meta: &Meta{
+			ResourceType: "ServiceProviderConfig",
+			Location:     baseURL + "/ServiceProviderConfig",
+		},
+	}
+}
+
+type ResourceType struct {
+	Schemas     []string `json:"schemas"`
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Endpoint    string   `json:"endpoint"`
+	Description string   `json:"description"`
+	Schema      string   `json:"schema"`
+	Meta        *Meta    `json:"meta,omitempty"`
+}
+
+// NewResourceTypes returns the resource types served by dex.
+func NewResourceTypes(baseURL string) []ResourceType {
+	return []ResourceType{
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          "User",
+			Name:        "User",
+			Endpoint:    "/Users",
+			Description: "User Account",
+			Schema:      SchemaUser,
+			Meta:        &Meta{ResourceType: "ResourceType", Location: baseURL + "/ResourceTypes/User"},
+		},
+		{
+			Schemas:     []string{SchemaResourceType},
+			ID:          "Group",
+			Name:        "Group",
+			Endpoint:    "/Groups",
+			Description: "Group",
+			Schema:      SchemaGroup,
+			Meta:        &Meta{ResourceType: "ResourceType", Location: baseURL + "/ResourceTypes/Group"},
+		},
+	}
+}
+
+type Attribute struct {
+	Name          string      `json:"name"`
+	Type          string      `json:"type"`
+	MultiValued   bool        `json:"multiValued"`
+	Required      bool        `json:"required"`
+	CaseExact     bool        `json:"caseExact"`
+	Mutability    string      `json:"mutability"`
+	Returned      string      `json:"returned"`
+	Uniqueness    string      `json:"uniqueness"`
+	SubAttributes []Attribute `json:"subAttributes,omitempty"`
+}
+
+type Schema struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Attributes  []Attribute `json:"attributes"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+func attribute(name, typ string, required bool, mutability, uniqueness string) Attribute {
+	return Attribute{
+		Name:       name,
+		Type:       typ,
+		Required:   required,
+		Mutability: mutability,
+		Returned:   "default",
+		Uniqueness: uniqueness,
+	}
+}
+
+// NewSchemas returns the schemas of the resources served by dex, reduced to
+// the attributes dex stores.
+func NewSchemas(baseURL string) []Schema {
+	name := attribute("name", "complex", false, "readWrite", "none")
+	name.SubAttributes = []Attribute{attribute("formatted", "string", false, "readWrite", "none")}
+
+	emails := attribute("emails", "complex", false, "readWrite", "none")
+	emails.MultiValued = true
+	emails.SubAttributes = []Attribute{
+		attribute("value", "string", false, "readWrite", "none"),
+		attribute("type", "string", false, "readWrite", "none"),
+		attribute("primary", "boolean", false, "readWrite", "none"),
+	}
+
+	groups := attribute("groups", "complex", false, "readOnly", "none")
+	groups.MultiValued = true
+	groups.SubAttributes = []Attribute{
+		attribute("value", "string", false, "readOnly", "none"),
+		attribute("$ref", "reference", false, "readOnly", "none"),
+		attribute("display", "string", false, "readOnly", "none"),
+	}
+
+	members := attribute("members", "complex", false, "readWrite", "none")
+	members.MultiValued = true
+	members.SubAttributes = []Attribute{
+		attribute("value", "string", false, "immutable", "none"),
+		attribute("$ref", "reference", false, "immutable", "none"),
+	}
+
+	return []Schema{
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaUser,
+			Name:        "User",
+			Description: "User Account",
+			Attributes: []Attribute{
+				attribute("userName", "string", true, "readWrite", "server"),
+				name,
+				attribute("displayName", "string", false, "readWrite", "none"),
+				emails,
+				attribute("active", "boolean", false, "readWrite", "none"),
+				groups,
+			},
+			Meta: &Meta{ResourceType: "Schema", Location: baseURL + "/Schemas/" + SchemaUser},
+		},
+		{
+			Schemas:     []string{SchemaSchema},
+			ID:          SchemaGroup,
+			Name:        "Group",
+			Description: "Group",
+			Attributes: []Attribute{
+				attribute("displayName", "string", true, "readWrite", "server"),
+				members,
+			},
+			Meta: &Meta{ResourceType: "Schema", Location: baseURL + "/Schemas/" + SchemaGroup},
+		},
+	}
+}
@@ -0,0 +1,231 @@
+// Package scim implements the resources and protocol messages of SCIM 2.0
+// (RFC 7643 and RFC 7644) needed to provision dex users and groups.
+package scim
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/coreos/dex/user"
+)
+
+const (
+	SchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SchemaResourceType          = "urn:ietf:params:scim:schemas:core:2.0:ResourceType"
+	SchemaSchema                = "urn:ietf:params:scim:schemas:core:2.0:Schema"
+
+	MessageListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	MessagePatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	MessageError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+
+	// ContentType is the media type of SCIM requests and responses.
+	ContentType = "application/scim+json"
+)
+
+// scimType values of errors, from RFC 7644 section 3.12.
+const (
+	ErrorTypeInvalidFilter = "invalidFilter"
+	ErrorTypeUniqueness    = "uniqueness"
+	ErrorTypeInvalidSyntax = "invalidSyntax"
+	ErrorTypeInvalidPath   = "invalidPath"
+	ErrorTypeNoTarget      = "noTarget"
+	ErrorTypeInvalidValue  = "invalidValue"
+	ErrorTypeMutability    = "mutability"
+)
+
+// Error is a SCIM error response.
+type Error struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func NewError(status int, scimType, detail string) *Error {
+	return &Error{
+		Schemas:  []string{MessageError},
+		Status:   fmt.Sprint(status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// BadRequest returns an Error with status 400 and the given scimType.
+func BadRequest(scimType, format string, args ...interface{}) *Error {
+	return NewError(http.StatusBadRequest, scimType, fmt.Sprintf(format, args...))
+}
+
+func (e *Error) Error() string {
+	if e.ScimType == "" {
+		return fmt.Sprintf("scim: %s: %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("scim: %s %s: %s", e.Status, e.ScimType, e.Detail)
+}
+
+type Meta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type Name struct {
+	Formatted string `json:"formatted,omitempty"`
+}
+
+type Email struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+// Reference refers to another resource, such as a member of a group or a
+// group of a user.
+type Reference struct {
+	Value   string `json:"value"`
+	Ref     string `json:"$ref,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+// User is the SCIM representation of a user.User. The userName of a user is
+// its email address.
+type User struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	UserName    string      `json:"userName"`
+	Name        *Name       `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	Emails      []Email     `json:"emails,omitempty"`
+	Active      *bool       `json:"active,omitempty"`
+	Groups      []Reference `json:"groups,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// NewUser returns the SCIM representation of usr, a member of groups.
+// Resource locations are relative to baseURL, the SCIM base URL.
+func NewUser(usr user.User, groups []user.Group, baseURL string) User {
+	active := !usr.Disabled
+	u := User{
+		Schemas:     []string{SchemaUser},
+		ID:          usr.ID,
+		UserName:    usr.Email,
+		DisplayName: usr.DisplayName,
+		Emails: []Email{{
+			Value:   usr.Email,
+			Type:    "work",
+			Primary: true,
+		}},
+		Active: &active,
+		Meta: &Meta{
+			ResourceType: "User",
+			Location:     baseURL + "/Users/" + usr.ID,
+		},
+	}
+	if usr.DisplayName != "" {
+		u.Name = &Name{Formatted: usr.DisplayName}
+	}
+	if !usr.CreatedAt.IsZero() {
+		u.Meta.Created = usr.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	for _, g := range groups {
+		u.Groups = append(u.Groups, Reference{
+			Value:   g.ID,
+			Ref:     baseURL + "/Groups/" + g.ID,
+			Display: g.DisplayName,
+		})
+	}
+	return u
+}
+
+// Email returns the email address of u: its userName if that is an email
+// address, otherwise its primary email.
+func (u User) Email() string {
+	if user.ValidEmail(u.UserName) || len(u.Emails) == 0 {
+		return u.UserName
+	}
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	return u.Emails[0].Value
+}
+
+// FullName returns the displayName of u, falling back to its formatted name.
+func (u User) FullName() string {
+	if u.DisplayName == "" && u.Name != nil {
+		return u.Name.Formatted
+	}
+	return u.DisplayName
+}
+
+// IsActive returns the active attribute of u, which defaults to true.
+func (u User) IsActive() bool {
+	return u.Active == nil || *u.Active
+}
+
+// Group is the SCIM representation of a user.Group.
+type Group struct {
+	Schemas     []string    `json:"schemas"`
+	ID          string      `json:"id,omitempty"`
+	DisplayName string      `json:"displayName"`
+	Members     []Reference `json:"members,omitempty"`
+	Meta        *Meta       `json:"meta,omitempty"`
+}
+
+// NewGroup returns the SCIM representation of g. Resource locations are
+// relative to baseURL, the SCIM base URL.
+func NewGroup(g user.Group, baseURL string) Group {
+	sg := Group{
+		Schemas:     []string{SchemaGroup},
+		ID:          g.ID,
+		DisplayName: g.DisplayName,
+		Meta: &Meta{
+			ResourceType: "Group",
+			Location:     baseURL + "/Groups/" + g.ID,
+		},
+	}
+	if !g.CreatedAt.IsZero() {
+		sg.Meta.Created = g.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	for _, id := range g.Members {
+		sg.Members = append(sg.Members, Reference{
+			Value: id,
+			Ref:   baseURL + "/Users/" + id,
+		})
+	}
+	return sg
+}
+
+// MemberIDs returns the IDs of the members of g.
+func (g Group) MemberIDs() []string {
+	var ids []string
+	for _, m := range g.Members {
+		ids = append(ids, m.Value)
+	}
+	return ids
+}
+
+// ListResponse is a page of the resources matching a query.
+type ListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+// NewListResponse returns a ListResponse holding resources, a page starting
+// at the 1-based startIndex of totalResults matching resources.
+func NewListResponse(resources interface{}, itemsPerPage, totalResults, startIndex int) ListResponse {
+	return ListResponse{
+		Schemas:      []string{MessageListResponse},
+		TotalResults: totalResults,
+		StartIndex:   startIndex,
+		ItemsPerPage: itemsPerPage,
+		Resources:    resources,
+	}
+}
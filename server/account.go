@@ -0,0 +1,674 @@
+package server
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/coreos/dex/audit"
+	"github.com/coreos/dex/client"
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	phttp "github.com/coreos/dex/pkg/http"
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
+)
+
+const (
+	// accountClientID is the client ID under which the account pages log
+	// users in through the usual authorization flow. It is reserved for
+	// them; a registered client with the same ID is ignored.
+	accountClientID = "dex-account"
+
+	accountSessionValidity = 30 * time.Minute
+	accountStateValidity   = 10 * time.Minute
+
+	cookieAccountSession = "AccountSession"
+	cookieAccountState   = "AccountState"
+)
+
+var errAccountSessionInvalid = errors.New("no valid account session")
+
+type accountTemplateData struct {
+	Error   bool
+	Message string
+	Success string
+
+	CSRFToken string
+
+	Email         string
+	EmailVerified bool
+	DisplayName   string
+	HasPassword   bool
+
+	PasswordViolations []string
+
+	Identities     []accountIdentity
+	LinkConnectors []accountConnector
+	Grants         []accountGrant
+
+	ProfileURL  string
+	PasswordURL string
+	LinkURL     string
+	UnlinkURL   string
+	RevokeURL   string
+	LogoutURL   string
+}
+
+type accountIdentity struct {
+	ConnectorID   string
+	ConnectorName string
+	ID            string
+}
+
+type accountConnector struct {
+	ID   string
+	Name string
+}
+
+type accountGrant struct {
+	ClientID   string
+	ClientName string
+}
+
+// accountPasswordChange lets the account pages change a password through
+// UserManager.ChangePassword once the user has given their current one.
+type accountPasswordChange struct {
+	userID   string
+	password user.Password
+	callback url.URL
+}
+
+func (c accountPasswordChange) UserID() string {
+	return c.userID
+}
+
+func (c accountPasswordChange) Password() user.Password {
+	return c.password
+}
+
+func (c accountPasswordChange) Callback() *url.URL {
+	return &c.callback
+}
+
+// accountHandler serves the pages on which logged in users manage their own
+// account. Users log in to them like to any client, under accountClientID;
+// the account session is then kept in a signed cookie.
+type accountHandler struct {
+	s   *Server
+	tpl Template
+}
+
+func (h *accountHandler) errPage(w http.ResponseWriter, msg string, status int) {
+	execTemplateWithStatus(w, h.tpl, accountTemplateData{
+		Error:   true,
+		Message: msg,
+	}, status)
+}
+
+func (h *accountHandler) internalError(w http.ResponseWriter, r *http.Request, err error) {
+	phttp.Logger(r).Errorf("Internal Error during account management: %v", err)
+	h.errPage(w, "There was a problem processing your request.", http.StatusInternalServerError)
+}
+
+// handleAccount shows the account page, sending users who are not logged in
+// to the login page first.
+func (h *accountHandler) handleAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		h.errPage(w, "GET only acceptable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ses, usr, err := h.session(r)
+	if err == errAccountSessionInvalid {
+		h.startLogin(w, r, url.Values{}, http.StatusFound)
+		return
+	}
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	h.render(w, r, ses, usr, accountTemplateData{}, http.StatusOK)
+}
+
+// startLogin sends the user to log in to the account pages, adding q to the
+// authorization request.
+func (h *accountHandler) startLogin(w http.ResponseWriter, r *http.Request, q url.Values, status int) {
+	b, err := pcrypto.RandBytes(16)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	state := base64.RawURLEncoding.EncodeToString(b)
+	http.SetCookie(w, h.cookie(cookieAccountState, state, accountStateValidity))
+
+	callbackURL := h.s.absURL(httpPathAccountCallback)
+	u := h.s.absURL(httpPathAuth)
+	q.Set("client_id", accountClientID)
+	q.Set("redirect_uri", callbackURL.String())
+	q.Set("response_type", "code")
+	q.Set("scope", "openid")
+	q.Set("state", state)
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), status)
+}
+
+// handleCallback completes a login to the account pages, exchanging the code
+// for an account session.
+func (h *accountHandler) handleCallback(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		h.errPage(w, "GET only acceptable method", http.StatusMethodNotAllowed)
+		return
+	}
+
+	q := r.URL.Query()
+	if q.Get("error") != "" {
+		h.errPage(w, "Your login failed. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	state, err := r.Cookie(cookieAccountState)
+	if err != nil || state.Value == "" || state.Value != q.Get("state") {
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, h.cookie(cookieAccountState, "", -1))
+
+	sessionID, err := h.s.SessionManager.ExchangeKey(q.Get("code"))
+	if err != nil {
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+	oses, err := h.s.SessionManager.Kill(sessionID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if oses.ClientID != accountClientID {
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+	if oses.LinkToken != "" {
+		h.completeLink(w, r, oses)
+		return
+	}
+	if oses.UserID == "" {
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	required, err := h.s.webAuthnRequired(oses, oses.UserID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if required && !containsString(oses.AMR, amrHardwareKey) {
+		sessionLogger(r.Context(), oses).Errorf("Session was not completed with a security key")
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	ses, err := user.NewAccountSession(oses.UserID, accountClientID, h.s.IssuerURL, accountSessionValidity)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	signer, err := h.s.KeyManager.Signer()
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	token, err := ses.Token(signer)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	sessionLogger(r.Context(), oses).Infof("Account session started")
+
+	http.SetCookie(w, h.cookie(cookieAccountSession, token, accountSessionValidity))
+	accountURL := h.s.absURL(httpPathAccount)
+	http.Redirect(w, r, accountURL.String(), http.StatusSeeOther)
+}
+
+// handleProfile changes the display name and email address of the user.
+// Users with a password have to give it to change their email address, as
+// it lets them reset their password.
+func (h *accountHandler) handleProfile(w http.ResponseWriter, r *http.Request) {
+	ses, usr, ok := h.post(w, r)
+	if !ok {
+		return
+	}
+
+	email := strings.TrimSpace(r.PostForm.Get("email"))
+	displayName := strings.TrimSpace(r.PostForm.Get("display_name"))
+
+	if email != usr.Email {
+		msg, err := h.checkPassword(usr, r.PostForm.Get("current_password"))
+		if err != nil {
+			h.internalError(w, r, err)
+			return
+		}
+		if msg != "" {
+			h.render(w, r, ses, usr, accountTemplateData{Message: msg}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	updated, err := h.s.UserManager.UpdateProfile(usr.ID, displayName, email)
+	switch err {
+	case nil:
+	case user.ErrorInvalidEmail:
+		h.render(w, r, ses, usr, accountTemplateData{Message: "Please enter a valid email address."}, http.StatusBadRequest)
+		return
+	case user.ErrorDuplicateEmail:
+		h.render(w, r, ses, usr, accountTemplateData{Message: "That email address is already in use."}, http.StatusBadRequest)
+		return
+	default:
+		h.internalError(w, r, err)
+		return
+	}
+
+	data := accountTemplateData{Success: "Your profile has been updated."}
+	if updated.Email != usr.Email {
+		phttp.Logger(r).WithField("user_id", usr.ID).Infof("User changed their email address")
+		if h.s.UserEmailer != nil {
+			if _, err := h.s.UserEmailer.SendEmailVerification(usr.ID, accountClientID, h.s.absURL(httpPathAccount)); err != nil {
+				phttp.Logger(r).WithField("user_id", usr.ID).Errorf("Failed to send email verification email: %v", err)
+			}
+			data.Success = "Your profile has been updated. Please check your email to verify your new address."
+		}
+	}
+	h.render(w, r, ses, updated, data, http.StatusOK)
+}
+
+// handlePassword changes the password of the user after checking their
+// current one.
+func (h *accountHandler) handlePassword(w http.ResponseWriter, r *http.Request) {
+	ses, usr, ok := h.post(w, r)
+	if !ok {
+		return
+	}
+
+	pwi, err := h.s.PasswordInfoRepo.Get(nil, usr.ID)
+	if err == user.ErrorNotFound {
+		h.render(w, r, ses, usr, accountTemplateData{Message: "Your account has no password."}, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	msg, err := h.checkPassword(usr, r.PostForm.Get("current_password"))
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	if msg != "" {
+		h.render(w, r, ses, usr, accountTemplateData{Message: msg}, http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.s.UserManager.ChangePassword(accountPasswordChange{
+		userID:   usr.ID,
+		password: pwi.Password,
+		callback: h.s.absURL(httpPathAccount),
+	}, r.PostForm.Get("password"))
+	switch e := err.(type) {
+	case nil:
+	case user.PasswordPolicyError:
+		data := accountTemplateData{Message: "Please choose another password."}
+		for _, v := range e.Violations {
+			data.PasswordViolations = append(data.PasswordViolations, v.Message)
+		}
+		h.render(w, r, ses, usr, data, http.StatusBadRequest)
+		return
+	default:
+		switch err {
+		case user.ErrorInvalidPassword:
+			h.render(w, r, ses, usr, accountTemplateData{Message: "Please enter a new password."}, http.StatusBadRequest)
+		case manager.ErrorPasswordAlreadyChanged:
+			h.render(w, r, ses, usr, accountTemplateData{Message: "Your password has been changed in the meantime. Please try again."}, http.StatusConflict)
+		default:
+			h.internalError(w, r, err)
+		}
+		return
+	}
+
+	phttp.Logger(r).WithField("user_id", usr.ID).Infof("User changed their password")
+	audit.Record(h.s.AuditSink, audit.Event{
+		Type:       audit.EventPasswordChanged,
+		UserID:     usr.ID,
+		RemoteAddr: phttp.RemoteIP(r),
+	})
+	h.render(w, r, ses, usr, accountTemplateData{Success: "Your password has been changed."}, http.StatusOK)
+}
+
+// handleLink sends the user to log in with another connector, whose
+// remote identity is then linked to them by completeLink.
+func (h *accountHandler) handleLink(w http.ResponseWriter, r *http.Request) {
+	ses, usr, ok := h.post(w, r)
+	if !ok {
+		return
+	}
+
+	connectorID := r.PostForm.Get("connector_id")
+	if !h.linkable(connectorID) {
+		h.render(w, r, ses, usr, accountTemplateData{Message: "Logins of that kind cannot be linked."}, http.StatusBadRequest)
+		return
+	}
+
+	token, err := h.s.linkToken(usr.ID, user.RemoteIdentity{ConnectorID: connectorID})
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	q := url.Values{}
+	q.Set("connector_id", connectorID)
+	q.Set("link_token", token)
+	h.startLogin(w, r, q, http.StatusSeeOther)
+}
+
+// completeLink links the remote identity the user logged in with to the user
+// of the account session, as requested by handleLink.
+func (h *accountHandler) completeLink(w http.ResponseWriter, r *http.Request, oses *session.Session) {
+	ses, usr, err := h.session(r)
+	if err == errAccountSessionInvalid {
+		h.errPage(w, "Your session has expired. Please log in and try again.", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+
+	link, err := h.s.parseLinkToken(oses.LinkToken)
+	if err != nil || link.UserID() != usr.ID || link.RemoteIdentity().ConnectorID != oses.ConnectorID {
+		h.errPage(w, "Your login has expired. Please try again.", http.StatusBadRequest)
+		return
+	}
+
+	rid := user.RemoteIdentity{
+		ConnectorID: oses.ConnectorID,
+		ID:          oses.Identity.ID,
+	}
+	if oses.UserID == usr.ID {
+		h.render(w, r, ses, usr, accountTemplateData{Success: "That login is already linked to your account."}, http.StatusOK)
+		return
+	}
+	// A session with a user logged in with an identity of another user.
+	err = user.ErrorDuplicateRemoteIdentity
+	if oses.UserID == "" {
+		err = h.s.UserManager.AddRemoteIdentity(usr.ID, rid)
+	}
+	switch err {
+	case nil:
+	case user.ErrorDuplicateRemoteIdentity:
+		h.render(w, r, ses, usr, accountTemplateData{Message: "That login is already linked to another account."}, http.StatusConflict)
+		return
+	default:
+		h.internalError(w, r, err)
+		return
+	}
+
+	phttp.Logger(r).WithField("user_id", usr.ID).Infof("User linked a remote identity: connectorID=%s", rid.ConnectorID)
+	h.render(w, r, ses, usr, accountTemplateData{Success: "Your account has been linked."}, http.StatusOK)
+}
+
+// handleUnlink unlinks a remote identity from the user, who must keep at
+// least one.
+func (h *accountHandler) handleUnlink(w http.ResponseWriter, r *http.Request) {
+	ses, usr, ok := h.post(w, r)
+	if !ok {
+		return
+	}
+
+	rid := user.RemoteIdentity{
+		ConnectorID: r.PostForm.Get("connector_id"),
+		ID:          r.PostForm.Get("remote_id"),
+	}
+	switch err := h.s.UserManager.RemoveRemoteIdentity(usr.ID, rid); err {
+	case nil:
+	case user.ErrorNotFound:
+		h.render(w, r, ses, usr, accountTemplateData{Message: "That login is not linked to your account."}, http.StatusBadRequest)
+		return
+	case manager.ErrorLastRemoteIdentity:
+		h.render(w, r, ses, usr, accountTemplateData{Message: "You cannot unlink your only login."}, http.StatusBadRequest)
+		return
+	default:
+		h.internalError(w, r, err)
+		return
+	}
+
+	phttp.Logger(r).WithField("user_id", usr.ID).Infof("User unlinked a remote identity: connectorID=%s", rid.ConnectorID)
+	h.render(w, r, ses, usr, accountTemplateData{Success: "The login has been unlinked."}, http.StatusOK)
+}
+
+// linkable reports whether remote identities of the given connector can be
+// linked from the account pages. Local identities belong to the user whose
+// password they check, so they cannot be linked to anyone else.
+func (h *accountHandler) linkable(connectorID string) bool {
+	_, ok := h.s.connector(connectorID)
+	return ok && connectorID != h.s.localConnectorID
+}
+
+// handleRevoke revokes the refresh tokens of the user held by a client.
+func (h *accountHandler) handleRevoke(w http.ResponseWriter, r *http.Request) {
+	ses, usr, ok := h.post(w, r)
+	if !ok {
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if err := h.s.RefreshTokenRepo.RevokeTokensForClient(usr.ID, clientID); err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	phttp.Logger(r).With(log.Fields{"user_id": usr.ID, "client_id": clientID}).Infof("User revoked the refresh tokens of client")
+	h.render(w, r, ses, usr, accountTemplateData{Success: "Access has been revoked."}, http.StatusOK)
+}
+
+// handleLogout ends the account session.
+func (h *accountHandler) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if _, _, ok := h.post(w, r); !ok {
+		return
+	}
+
+	http.SetCookie(w, h.cookie(cookieAccountSession, "", -1))
+	accountURL := h.s.absURL(httpPathAccount)
+	http.Redirect(w, r, accountURL.String(), http.StatusSeeOther)
+}
+
+// post checks that r is a POST request of a logged in user carrying the CSRF
+// token of their session. Otherwise it responds to the request itself and
+// returns false.
+func (h *accountHandler) post(w http.ResponseWriter, r *http.Request) (user.AccountSession, user.User, bool) {
+	if r.Method != "POST" {
+		w.Header().Set("Allow", "POST")
+		h.errPage(w, "POST only acceptable method", http.StatusMethodNotAllowed)
+		return user.AccountSession{}, user.User{}, false
+	}
+	if err := r.ParseForm(); err != nil {
+		h.errPage(w, "Invalid request.", http.StatusBadRequest)
+		return user.AccountSession{}, user.User{}, false
+	}
+
+	ses, usr, err := h.session(r)
+	if err == errAccountSessionInvalid {
+		accountURL := h.s.absURL(httpPathAccount)
+		http.Redirect(w, r, accountURL.String(), http.StatusSeeOther)
+		return user.AccountSession{}, user.User{}, false
+	}
+	if err != nil {
+		h.internalError(w, r, err)
+		return user.AccountSession{}, user.User{}, false
+	}
+
+	if r.PostForm.Get("csrf_token") != ses.CSRFToken() {
+		h.errPage(w, "Your request could not be verified. Please go back and try again.", http.StatusForbidden)
+		return user.AccountSession{}, user.User{}, false
+	}
+	return ses, usr, true
+}
+
+// session returns the account session of the request and its user, or
+// errAccountSessionInvalid if there is none.
+func (h *accountHandler) session(r *http.Request) (user.AccountSession, user.User, error) {
+	c, err := r.Cookie(cookieAccountSession)
+	if err != nil || c.Value == "" {
+		return user.AccountSession{}, user.User{}, errAccountSessionInvalid
+	}
+
+	keys, err := h.s.KeyManager.PublicKeys()
+	if err != nil {
+		return user.AccountSession{}, user.User{}, err
+	}
+	ses, err := user.ParseAndVerifyAccountSessionToken(c.Value, h.s.IssuerURL, keys)
+	if err != nil || ses.ClientID() != accountClientID {
+		return user.AccountSession{}, user.User{}, errAccountSessionInvalid
+	}
+
+	usr, err := h.s.UserRepo.Get(nil, ses.UserID())
+	if err == user.ErrorNotFound || (err == nil && usr.Disabled) {
+		return user.AccountSession{}, user.User{}, errAccountSessionInvalid
+	}
+	if err != nil {
+		return user.AccountSession{}, user.User{}, err
+	}
+	return ses, usr, nil
+}
+
+// checkPassword checks the current password the user has given, counting
+// failures against the lockout policy. An empty error and message mean the
+// password is right; otherwise the message is shown to the user.
+func (h *accountHandler) checkPassword(usr user.User, plaintext string) (string, error) {
+	pwi, err := h.s.PasswordInfoRepo.Get(nil, usr.ID)
+	if err == user.ErrorNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if h.s.LoginThrottler != nil {
+		if err := h.s.LoginThrottler.Locked(usr.ID, ""); err == user.ErrorLockedOut {
+			return "Too many failed attempts. Please try again later.", nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+
+	if user.ComparePassword(pwi.Password, plaintext) == nil {
+		return "", nil
+	}
+	if h.s.LoginThrottler != nil {
+		if _, err := h.s.LoginThrottler.RecordFailure(usr.ID, ""); err != nil {
+			return "", err
+		}
+	}
+	return "Your current password is incorrect.", nil
+}
+
+func (h *accountHandler) render(w http.ResponseWriter, r *http.Request, ses user.AccountSession, usr user.User, data accountTemplateData, status int) {
+	_, err := h.s.PasswordInfoRepo.Get(nil, usr.ID)
+	if err != nil && err != user.ErrorNotFound {
+		h.internalError(w, r, err)
+		return
+	}
+	data.HasPassword = err == nil
+
+	rids, err := h.s.UserManager.GetRemoteIdentities(usr.ID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	for _, rid := range rids {
+		data.Identities = append(data.Identities, accountIdentity{
+			ConnectorID:   rid.ConnectorID,
+			ConnectorName: connectorDisplayName(rid.ConnectorID),
+			ID:            rid.ID,
+		})
+	}
+	for _, idpc := range h.s.Connectors {
+		if h.linkable(idpc.ID()) {
+			data.LinkConnectors = append(data.LinkConnectors, accountConnector{
+				ID:   idpc.ID(),
+				Name: connectorDisplayName(idpc.ID()),
+			})
+		}
+	}
+
+	clientIDs, err := h.s.RefreshTokenRepo.ClientsWithRefreshTokens(usr.ID)
+	if err != nil {
+		h.internalError(w, r, err)
+		return
+	}
+	for _, clientID := range clientIDs {
+		grant := accountGrant{ClientID: clientID, ClientName: clientID}
+		cm, err := h.s.ClientIdentityRepo.Metadata(clientID)
+		if err != nil && err != client.ErrorNotFound {
+			h.internalError(w, r, err)
+			return
+		}
+		if cm != nil && cm.ClientName != "" {
+			grant.ClientName = cm.ClientName
+		}
+		data.Grants = append(data.Grants, grant)
+	}
+
+	data.CSRFToken = ses.CSRFToken()
+	data.Email = usr.Email
+	data.EmailVerified = usr.EmailVerified
+	data.DisplayName = usr.DisplayName
+	for _, u := range []struct {
+		dst  *string
+		path string
+	}{
+		{&data.ProfileURL, httpPathAccountProfile},
+		{&data.PasswordURL, httpPathAccountPassword},
+		{&data.LinkURL, httpPathAccountLink},
+		{&data.UnlinkURL, httpPathAccountUnlink},
+		{&data.RevokeURL, httpPathAccountRevoke},
+		{&data.LogoutURL, httpPathAccountLogout},
+	} {
+		abs := h.s.absURL(u.path)
+		*u.dst = abs.String()
+	}
+
+	// The account pages must not be framed by other sites, which could trick
+	// users into submitting them.
+	w.Header().Set("X-Frame-Options", "DENY")
+	execTemplateWithStatus(w, h.tpl, data, status)
+}
+
+func (h *accountHandler) cookie(name, value string, maxAge time.Duration) *http.Cookie {
+	accountURL := h.s.absURL(httpPathAccount)
+	c := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     accountURL.Path,
+		HttpOnly: true,
+		Secure:   h.s.IssuerURL.Scheme == "https",
+	}
+	if maxAge < 0 {
+		c.MaxAge = -1
+	} else {
+		c.MaxAge = int(maxAge.Seconds())
+		c.Expires = time.Now().Add(maxAge)
+	}
+	return c
+}
+
+func connectorDisplayName(connectorID string) string {
+	if name, ok := connectorDisplayNameMap[connectorID]; ok {
+		return name
+	}
+	return connectorID
+}
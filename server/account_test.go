@@ -0,0 +1,413 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/refresh"
+	"github.com/coreos/dex/user"
+)
+
+type accountTestTemplate struct {
+	tpl Template
+
+	data accountTemplateData
+}
+
+func (t *accountTestTemplate) Execute(w io.Writer, data interface{}) error {
+	t.data = data.(accountTemplateData)
+	return t.tpl.Execute(w, data)
+}
+
+type accountTestFixtures struct {
+	*testFixtures
+	tpl *accountTestTemplate
+	mux *http.ServeMux
+}
+
+func makeAccountTestFixtures(t *testing.T) *accountTestFixtures {
+	f, err := makeTestFixtures()
+	if err != nil {
+		t.Fatalf("error making test fixtures: %v", err)
+	}
+	f.srv.RefreshTokenRepo = refresh.NewRefreshTokenRepo()
+
+	pw, err := user.NewPasswordFromPlaintext("password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.srv.PasswordInfoRepo.Update(nil, user.PasswordInfo{UserID: "ID-1", Password: pw}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tpl := &accountTestTemplate{tpl: f.srv.AccountTemplate}
+	h := &accountHandler{s: f.srv, tpl: tpl}
+	mux := http.NewServeMux()
+	mux.HandleFunc(httpPathAccount, h.handleAccount)
+	mux.HandleFunc(httpPathAccountCallback, h.handleCallback)
+	mux.HandleFunc(httpPathAccountProfile, h.handleProfile)
+	mux.HandleFunc(httpPathAccountPassword, h.handlePassword)
+	mux.HandleFunc(httpPathAccountLink, h.handleLink)
+	mux.HandleFunc(httpPathAccountUnlink, h.handleUnlink)
+	mux.HandleFunc(httpPathAccountRevoke, h.handleRevoke)
+	mux.HandleFunc(httpPathAccountLogout, h.handleLogout)
+
+	return &accountTestFixtures{
+		testFixtures: f,
+		tpl:          tpl,
+		mux:          mux,
+	}
+}
+
+func (f *accountTestFixtures) serve(method, target string, form url.Values, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form == nil {
+		r, _ = http.NewRequest(method, target, nil)
+	} else {
+		r, _ = http.NewRequest(method, target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for _, c := range cookies {
+		r.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	f.mux.ServeHTTP(w, r)
+	return w
+}
+
+func responseCookie(w *httptest.ResponseRecorder, name string) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// login logs the user with remote identity RID-1 in to the account pages,
+// returning the session cookie.
+func (f *accountTestFixtures) login(t *testing.T) *http.Cookie {
+	w := f.serve("GET", "/account", nil)
+	if w.Code != http.StatusFound {
+		t.Fatalf("want=%d, got=%d", http.StatusFound, w.Code)
+	}
+	state := responseCookie(w, cookieAccountState)
+	if state == nil {
+		t.Fatalf("no state cookie set")
+	}
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := loc.Query()
+	if loc.Path != httpPathAuth || q.Get("client_id") != accountClientID || q.Get("state") != state.Value {
+		t.Fatalf("unexpected login redirect: %v", loc)
+	}
+
+	redirectURL, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sessionID, err := f.sessionManager.NewSession("IDPC-1", accountClientID, q.Get("state"), *redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	callback, err := f.srv.Login(context.Background(), oidc.Identity{ID: "RID-1"}, user.Profile{}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w = f.serve("GET", callback, nil, state)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("want=%d, got=%d: %s", http.StatusSeeOther, w.Code, w.Body.String())
+	}
+	ses := responseCookie(w, cookieAccountSession)
+	if ses == nil || ses.Value == "" {
+		t.Fatalf("no session cookie set")
+	}
+	return ses
+}
+
+func TestAccountLogin(t *testing.T) {
+	f := makeAccountTestFixtures(t)
+	ses := f.login(t)
+
+	w := f.serve("GET", "/account", nil, ses)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want=%d, got=%d", http.StatusOK, w.Code)
+	}
+	if f.tpl.data.Email != "Email-1@example.com" || !f.tpl.data.HasPassword || f.tpl.data.CSRFToken == "" {
+		t.Fatalf("unexpected template data: %#v", f.tpl.data)
+	}
+	wantIdentities := []accountIdentity{
+		{ConnectorID: "IDPC-1", ConnectorName: "IDPC-1", ID: "RID-1"},
+	}
+	if diff := pretty.Compare(wantIdentities, f.tpl.data.Identities); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+	csrf := f.tpl.data.CSRFToken
+
+	// A callback without the state cookie is rejected.
+	w = f.serve("GET", "/account/callback?code=code-1&state=bogus", nil)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want=%d, got=%d", http.StatusBadRequest, w.Code)
+	}
+
+	w = f.serve("POST", "/account/logout", url.Values{"csrf_token": {csrf}}, ses)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("want=%d, got=%d", http.StatusSeeOther, w.Code)
+	}
+	if c := responseCookie(w, cookieAccountSession); c == nil || c.MaxAge >= 0 {
+		t.Errorf("want session cookie deleted, got %v", c)
+	}
+}
+
+func TestAccountChangePassword(t *testing.T) {
+	tests := []struct {
+		current   string
+		password  string
+		badCSRF   bool
+		wantCode  int
+		wantNewPW bool
+	}{
+		{
+			current:   "password",
+			password:  "n3w-Passw0rd",
+			wantCode:  http.StatusOK,
+			wantNewPW: true,
+		},
+		{
+			current:  "wrong",
+			password: "n3w-Passw0rd",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			current:  "password",
+			password: "",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			current:  "password",
+			password: "n3w-Passw0rd",
+			badCSRF:  true,
+			wantCode: http.StatusForbidden,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeAccountTestFixtures(t)
+		ses := f.login(t)
+		f.serve("GET", "/account", nil, ses)
+
+		csrf := f.tpl.data.CSRFToken
+		if tt.badCSRF {
+			csrf = "bogus"
+		}
+		w := f.serve("POST", "/account/password", url.Values{
+			"csrf_token":       {csrf},
+			"current_password": {tt.current},
+			"password":         {tt.password},
+		}, ses)
+		if w.Code != tt.wantCode {
+			t.Errorf("case %d: want=%d, got=%d", i, tt.wantCode, w.Code)
+			continue
+		}
+
+		pwi, err := f.srv.PasswordInfoRepo.Get(nil, "ID-1")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		changed := user.ComparePassword(pwi.Password, tt.password) == nil
+		if changed != tt.wantNewPW {
+			t.Errorf("case %d: want password changed=%v, got %v", i, tt.wantNewPW, changed)
+		}
+	}
+}
+
+func TestAccountUpdateProfile(t *testing.T) {
+	tests := []struct {
+		displayName string
+		email       string
+		current     string
+		wantCode    int
+		wantEmail   string
+	}{
+		{
+			displayName: "Jane",
+			email:       "Email-1@example.com",
+			wantCode:    http.StatusOK,
+			wantEmail:   "Email-1@example.com",
+		},
+		{
+			// Changing the email address requires the current password.
+			email:     "new@example.com",
+			wantCode:  http.StatusBadRequest,
+			wantEmail: "Email-1@example.com",
+		},
+		{
+			email:     "new@example.com",
+			current:   "password",
+			wantCode:  http.StatusOK,
+			wantEmail: "new@example.com",
+		},
+		{
+			email:     "Email-Verified@example.com",
+			current:   "password",
+			wantCode:  http.StatusBadRequest,
+			wantEmail: "Email-1@example.com",
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeAccountTestFixtures(t)
+		ses := f.login(t)
+		f.serve("GET", "/account", nil, ses)
+
+		w := f.serve("POST", "/account/profile", url.Values{
+			"csrf_token":       {f.tpl.data.CSRFToken},
+			"display_name":     {tt.displayName},
+			"email":            {tt.email},
+			"current_password": {tt.current},
+		}, ses)
+		if w.Code != tt.wantCode {
+			t.Errorf("case %d: want=%d, got=%d", i, tt.wantCode, w.Code)
+			continue
+		}
+
+		usr, err := f.userRepo.Get(nil, "ID-1")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if usr.Email != tt.wantEmail {
+			t.Errorf("case %d: want email=%q, got=%q", i, tt.wantEmail, usr.Email)
+		}
+		if tt.wantCode == http.StatusOK && usr.DisplayName != tt.displayName {
+			t.Errorf("case %d: want display name=%q, got=%q", i, tt.displayName, usr.DisplayName)
+		}
+	}
+}
+
+func TestAccountRevoke(t *testing.T) {
+	f := makeAccountTestFixtures(t)
+	if _, err := f.srv.RefreshTokenRepo.Create("ID-1", testClientID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.srv.RefreshTokenRepo.Create("ID-1", "YYY"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ses := f.login(t)
+	f.serve("GET", "/account", nil, ses)
+	wantGrants := []accountGrant{
+		{ClientID: testClientID, ClientName: testClientID},
+		{ClientID: "YYY", ClientName: "YYY"},
+	}
+	if diff := pretty.Compare(wantGrants, f.tpl.data.Grants); diff != "" {
+		t.Fatalf("Compare(want, got) = %v", diff)
+	}
+
+	w := f.serve("POST", "/account/revoke", url.Values{
+		"csrf_token": {f.tpl.data.CSRFToken},
+		"client_id":  {testClientID},
+	}, ses)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want=%d, got=%d", http.StatusOK, w.Code)
+	}
+	if diff := pretty.Compare(wantGrants[1:], f.tpl.data.Grants); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+func TestAccountLinkUnlink(t *testing.T) {
+	f := makeAccountTestFixtures(t)
+	ses := f.login(t)
+	f.serve("GET", "/account", nil, ses)
+	csrf := f.tpl.data.CSRFToken
+
+	wantConnectors := []accountConnector{
+		{ID: "oidc", Name: "oidc"},
+		{ID: "oidc-trusted", Name: "oidc-trusted"},
+	}
+	if diff := pretty.Compare(wantConnectors, f.tpl.data.LinkConnectors); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// Local identities cannot be linked.
+	w := f.serve("POST", "/account/link", url.Values{"csrf_token": {csrf}, "connector_id": {"local"}}, ses)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want=%d, got=%d", http.StatusBadRequest, w.Code)
+	}
+
+	w = f.serve("POST", "/account/link", url.Values{"csrf_token": {csrf}, "connector_id": {"oidc"}}, ses)
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("want=%d, got=%d", http.StatusSeeOther, w.Code)
+	}
+	state := responseCookie(w, cookieAccountState)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	q := loc.Query()
+	if state == nil || q.Get("connector_id") != "oidc" || q.Get("link_token") == "" {
+		t.Fatalf("unexpected link redirect: %v", loc)
+	}
+
+	// The user logs in with the connector as an identity nobody has yet.
+	redirectURL, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	sessionID, err := f.sessionManager.NewSession("oidc", accountClientID, q.Get("state"), *redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key, err = f.srv.SetLinkToken(key, q.Get("link_token")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	callback, err := f.srv.Login(context.Background(), oidc.Identity{ID: "new", Email: "someone@example.com"}, user.Profile{}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	w = f.serve("GET", callback, nil, state, ses)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want=%d, got=%d: %s", http.StatusOK, w.Code, w.Body.String())
+	}
+	wantIdentities := []accountIdentity{
+		{ConnectorID: "IDPC-1", ConnectorName: "IDPC-1", ID: "RID-1"},
+		{ConnectorID: "oidc", ConnectorName: "oidc", ID: "new"},
+	}
+	if diff := pretty.Compare(wantIdentities, f.tpl.data.Identities); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	w = f.serve("POST", "/account/unlink", url.Values{"csrf_token": {csrf}, "connector_id": {"IDPC-1"}, "remote_id": {"RID-1"}}, ses)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want=%d, got=%d", http.StatusOK, w.Code)
+	}
+	if diff := pretty.Compare(wantIdentities[1:], f.tpl.data.Identities); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// The last identity cannot be unlinked.
+	w = f.serve("POST", "/account/unlink", url.Values{"csrf_token": {csrf}, "connector_id": {"oidc"}, "remote_id": {"new"}}, ses)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("want=%d, got=%d", http.StatusBadRequest, w.Code)
+	}
+}
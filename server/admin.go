@@ -2,14 +2,19 @@ package server
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"net/url"
 	"path"
+	"time"
 
 	"github.com/coreos/pkg/health"
 	"github.com/julienschmidt/httprouter"
 
 	"github.com/coreos/dex/admin"
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/pkg/metrics"
 	"github.com/coreos/dex/schema/adminschema"
 	"github.com/coreos/go-oidc/key"
 )
@@ -23,6 +28,24 @@ var (
 	AdminGetEndpoint      = addBasePath("/admin/:id")
 	AdminCreateEndpoint   = addBasePath("/admin")
 	AdminGetStateEndpoint = addBasePath("/state")
+
+	AdminClientListEndpoint         = addBasePath("/clients")
+	AdminClientEndpoint             = addBasePath("/clients/:id")
+	AdminClientRotateSecretEndpoint = addBasePath("/clients/:id/rotate-secret")
+	AdminClientSetAdminEndpoint     = addBasePath("/clients/:id/admin")
+	AdminClientClaimMappingEndpoint = addBasePath("/clients/:id/claim-mapping")
+
+	AdminUserResetSecondFactorEndpoint = addBasePath("/users/:id/reset-second-factor")
+	AdminUserUnlockEndpoint            = addBasePath("/users/:id/unlock")
+	AdminUserAttributesEndpoint        = addBasePath("/users/:id/attributes")
+	AdminUserEndpoint                  = addBasePath("/users/:id")
+
+	AdminAuditEventsEndpoint = addBasePath("/audit-events")
+
+	AdminWebhookListEndpoint = addBasePath("/webhooks")
+	AdminWebhookEndpoint     = addBasePath("/webhooks/:id")
+
+	AdminKeySecretsReencryptEndpoint = addBasePath("/key-secrets/reencrypt")
 )
 
 // AdminServer serves the admin API.
@@ -45,14 +68,65 @@ func NewAdminServer(adminAPI *admin.AdminAPI, rotator *key.PrivateKeyRotator, se
 }
 
 func (s *AdminServer) HTTPHandler() http.Handler {
-	r := httprouter.New()
+	r := instrumentedRouter{httprouter.New()}
 	r.GET(AdminGetEndpoint, s.getAdmin)
 	r.POST(AdminCreateEndpoint, s.createAdmin)
 	r.GET(AdminGetStateEndpoint, s.getState)
+	r.GET(AdminClientListEndpoint, s.listClients)
+	r.GET(AdminClientEndpoint, s.getClient)
+	r.PUT(AdminClientEndpoint, s.updateClient)
+	r.DELETE(AdminClientEndpoint, s.deleteClient)
+	r.POST(AdminClientRotateSecretEndpoint, s.rotateClientSecret)
+	r.PUT(AdminClientSetAdminEndpoint, s.setClientAdmin)
+	r.GET(AdminClientClaimMappingEndpoint, s.getClientClaimMapping)
+	r.PUT(AdminClientClaimMappingEndpoint, s.setClientClaimMapping)
+	r.POST(AdminUserResetSecondFactorEndpoint, s.resetUserSecondFactor)
+	r.POST(AdminUserUnlockEndpoint, s.unlockUser)
+	r.GET(AdminUserAttributesEndpoint, s.getUserAttributes)
+	r.PUT(AdminUserAttributesEndpoint, s.setUserAttributes)
+	r.DELETE(AdminUserEndpoint, s.deleteUser)
+	r.GET(AdminAuditEventsEndpoint, s.listAuditEvents)
+	r.GET(AdminWebhookListEndpoint, s.listWebhooks)
+	r.POST(AdminWebhookListEndpoint, s.createWebhook)
+	r.GET(AdminWebhookEndpoint, s.getWebhook)
+	r.DELETE(AdminWebhookEndpoint, s.deleteWebhook)
+	r.POST(AdminKeySecretsReencryptEndpoint, s.reencrypt)
 	r.Handler("GET", httpPathHealth, s.checker)
 	r.HandlerFunc("GET", httpPathDebugVars, health.ExpvarHandler)
+	r.Handler("GET", httpPathMetrics, metrics.Handler())
+
+	return authorizer(r, s.secret, httpPathHealth, httpPathDebugVars, httpPathMetrics)
+}
+
+// instrumentedRouter records metrics for the handles registered with it,
+// labelled by their route.
+type instrumentedRouter struct {
+	*httprouter.Router
+}
+
+func (r instrumentedRouter) GET(path string, h httprouter.Handle) {
+	r.Handle("GET", path, h)
+}
+
+func (r instrumentedRouter) POST(path string, h httprouter.Handle) {
+	r.Handle("POST", path, h)
+}
+
+func (r instrumentedRouter) PUT(path string, h httprouter.Handle) {
+	r.Handle("PUT", path, h)
+}
+
+func (r instrumentedRouter) DELETE(path string, h httprouter.Handle) {
+	r.Handle("DELETE", path, h)
+}
 
-	return authorizer(r, s.secret, httpPathHealth, httpPathDebugVars)
+func (r instrumentedRouter) Handle(method, path string, h httprouter.Handle) {
+	label := func(*http.Request) string { return path }
+	r.Router.Handle(method, path, func(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+		metrics.InstrumentHandler(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			h(w, req, ps)
+		}), label).ServeHTTP(w, req)
+	})
 }
 
 func authorizer(h http.Handler, secret string, public ...string) http.Handler {
@@ -113,6 +187,307 @@ func (s *AdminServer) getState(w http.ResponseWriter, r *http.Request, ps httpro
 	writeResponseWithBody(w, http.StatusOK, state)
 }
 
+func (s *AdminServer) rotateClientSecret(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	req := adminschema.ClientRotateSecretRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	resp, err := s.adminAPI.RotateClientSecret(id, req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *AdminServer) listClients(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	resp, err := s.adminAPI.ListClients()
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *AdminServer) getClient(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	c, err := s.adminAPI.GetClient(id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, c)
+}
+
+func (s *AdminServer) updateClient(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	c := adminschema.Client{}
+	err := json.NewDecoder(r.Body).Decode(&c)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	c, err = s.adminAPI.UpdateClient(id, c)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, c)
+}
+
+func (s *AdminServer) deleteClient(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if err := s.adminAPI.DeleteClient(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) setClientAdmin(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	req := adminschema.ClientSetAdminRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	if err := s.adminAPI.SetClientAdmin(id, req); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) getClientClaimMapping(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	cm, err := s.adminAPI.GetClientClaimMapping(id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, cm)
+}
+
+func (s *AdminServer) setClientClaimMapping(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	req := adminschema.ClientClaimMapping{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	cm, err := s.adminAPI.SetClientClaimMapping(id, req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, cm)
+}
+
+func (s *AdminServer) resetUserSecondFactor(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if err := s.adminAPI.ResetSecondFactor(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) unlockUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if err := s.adminAPI.UnlockUser(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) getUserAttributes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	attrs, err := s.adminAPI.GetUserAttributes(id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, attrs)
+}
+
+func (s *AdminServer) setUserAttributes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	req := adminschema.UserAttributes{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	attrs, err := s.adminAPI.SetUserAttributes(id, req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, attrs)
+}
+
+func (s *AdminServer) deleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if err := s.adminAPI.DeleteUser(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) listAuditEvents(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	q := r.URL.Query()
+	filter, err := auditEventFilterFromQuery(q)
+	if err != nil {
+		writeInvalidRequest(w, err.Error())
+		return
+	}
+	maxResults, err := intFromQuery(q, "maxResults", defaultMaxResults)
+	if err != nil {
+		writeInvalidRequest(w, "maxResults must be an integer")
+		return
+	}
+
+	resp, err := s.adminAPI.ListAuditEvents(filter, maxResults, q.Get("nextPageToken"))
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func auditEventFilterFromQuery(q url.Values) (audit.EventFilter, error) {
+	filter := audit.EventFilter{
+		UserID:   q.Get("userId"),
+		ClientID: q.Get("clientId"),
+		Type:     audit.EventType(q.Get("type")),
+	}
+
+	times := []struct {
+		name  string
+		field *time.Time
+	}{
+		{"after", &filter.After},
+		{"before", &filter.Before},
+	}
+	for _, p := range times {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return audit.EventFilter{}, fmt.Errorf("%s must be an RFC 3339 time", p.name)
+			}
+			*p.field = t
+		}
+	}
+
+	return filter, nil
+}
+
+func (s *AdminServer) listWebhooks(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	resp, err := s.adminAPI.ListWebhooks()
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *AdminServer) getWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	wh, err := s.adminAPI.GetWebhook(id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, wh)
+}
+
+func (s *AdminServer) createWebhook(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	wh := adminschema.Webhook{}
+	err := json.NewDecoder(r.Body).Decode(&wh)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	wh, err = s.adminAPI.CreateWebhook(wh)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", AdminWebhookListEndpoint+"/"+wh.Id)
+	writeResponseWithBody(w, http.StatusCreated, wh)
+}
+
+func (s *AdminServer) deleteWebhook(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	id := ps.ByName("id")
+
+	if err := s.adminAPI.DeleteWebhook(id); err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *AdminServer) reencrypt(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	req := adminschema.ReencryptRequest{}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	resp, err := s.adminAPI.Reencrypt(req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
 func (s *AdminServer) writeError(w http.ResponseWriter, err error) {
 	log.Errorf("Error calling admin API: %v: ", err)
 	if adminErr, ok := err.(admin.Error); ok {
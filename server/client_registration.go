@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 
+	"github.com/coreos/dex/audit"
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/go-oidc/oauth2"
 	"github.com/coreos/go-oidc/oidc"
@@ -48,6 +50,12 @@ func (s *Server) handleClientRegistrationRequest(r *http.Request) (*oidc.ClientR
 		log.Errorf("Failed to create new client identity: %v", err)
 		return nil, newAPIError(oauth2.ErrorServerError, "unable to save client metadata")
 	}
+	audit.Record(s.AuditSink, audit.Event{
+		Type:       audit.EventClientRegistered,
+		ClientID:   creds.ID,
+		RemoteAddr: phttp.RemoteIP(r),
+		Details:    "dynamic registration",
+	})
 
 	return &oidc.ClientRegistrationResponse{
 		ClientID:       creds.ID,
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"strings"
+	"time"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/client"
 	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
@@ -15,20 +18,35 @@ import (
 
 type clientResource struct {
 	repo client.ClientIdentityRepo
+
+	// auditSink, if set, records the clients created.
+	auditSink audit.AuditSink
+
+	// path is the absolute path of the collection; individual clients
+	// live below it.
+	path string
 }
 
-func registerClientResource(prefix string, repo client.ClientIdentityRepo) (string, http.Handler) {
+func registerClientResource(prefix string, repo client.ClientIdentityRepo, auditSink audit.AuditSink) (string, http.Handler) {
 	mux := http.NewServeMux()
-	c := &clientResource{
-		repo: repo,
-	}
 	relPath := "clients"
 	absPath := path.Join(prefix, relPath)
+	c := &clientResource{
+		repo:      repo,
+		auditSink: auditSink,
+		path:      absPath,
+	}
 	mux.Handle(absPath, c)
+	mux.Handle(absPath+"/", c)
 	return relPath, mux
 }
 
 func (c *clientResource) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c.path != "" && strings.HasPrefix(r.URL.Path, c.path+"/") {
+		c.serveClient(w, r, strings.TrimPrefix(r.URL.Path, c.path+"/"))
+		return
+	}
+
 	switch r.Method {
 	case "GET":
 		c.list(w, r)
@@ -103,8 +121,243 @@ func (c *clientResource) create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	ci.Credentials = *creds
+	audit.Record(c.auditSink, audit.Event{Type: audit.EventClientRegistered, ClientID: creds.ID})
 
 	ssc := schema.MapClientIdentityToSchemaClientWithSecret(ci)
 	w.Header().Add("Location", phttp.NewResourceLocation(r.URL, ci.Credentials.ID))
 	writeResponseWithBody(w, http.StatusCreated, ssc)
 }
+
+// serveClient handles requests addressed to a single client, where subPath is
+// the portion of the request path following the collection path.
+func (c *clientResource) serveClient(w http.ResponseWriter, r *http.Request, subPath string) {
+	parts := strings.Split(subPath, "/")
+	if parts[0] == "" || len(parts) > 2 {
+		writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "resource not found"))
+		return
+	}
+	clientID := parts[0]
+
+	if len(parts) == 1 {
+		switch r.Method {
+		case "GET":
+			c.get(w, r, clientID)
+		case "PUT":
+			c.update(w, r, clientID)
+		case "DELETE":
+			c.delete(w, r, clientID)
+		default:
+			msg := fmt.Sprintf("HTTP %s method not supported for this resource", r.Method)
+			writeAPIError(w, http.StatusMethodNotAllowed, newAPIError(errorInvalidRequest, msg))
+		}
+		return
+	}
+
+	var handle func(http.ResponseWriter, *http.Request, string)
+	var method string
+	switch parts[1] {
+	case "rotate-secret":
+		handle, method = c.rotateSecret, "POST"
+	case "admin":
+		handle, method = c.setAdmin, "PUT"
+	default:
+		writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "resource not found"))
+		return
+	}
+
+	if r.Method != method {
+		msg := fmt.Sprintf("HTTP %s method not supported for this resource", r.Method)
+		writeAPIError(w, http.StatusMethodNotAllowed, newAPIError(errorInvalidRequest, msg))
+		return
+	}
+
+	handle(w, r, clientID)
+}
+
+// authorize checks that the caller of r may act upon the client with the
+// given ID. Admin clients may act upon any client; others may only act upon
+// themselves, and only if allowSelf is set. If the caller is not authorized
+// an error response is written and ok is false.
+func (c *clientResource) authorize(w http.ResponseWriter, r *http.Request, clientID string, allowSelf bool) (callerID string, ok bool) {
+	callerID, err := getClientIDFromAuthorizedRequest(r)
+	if err != nil {
+		log.Errorf("Failed to extract client ID from request: %v", err)
+		writeAPIError(w, http.StatusUnauthorized, newAPIError(errorAccessDenied, "missing or invalid token"))
+		return "", false
+	}
+
+	if allowSelf && callerID == clientID {
+		return callerID, true
+	}
+
+	isAdmin, err := c.repo.IsDexAdmin(callerID)
+	if err != nil {
+		log.Errorf("Failed checking admin status of client %s: %v", callerID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, ""))
+		return "", false
+	}
+	if !isAdmin {
+		msg := "clients may only act upon themselves"
+		if !allowSelf {
+			msg = "only admin clients may do this"
+		}
+		writeAPIError(w, http.StatusForbidden, newAPIError(errorAccessDenied, msg))
+		return "", false
+	}
+
+	return callerID, true
+}
+
+func (c *clientResource) get(w http.ResponseWriter, r *http.Request, clientID string) {
+	if _, ok := c.authorize(w, r, clientID, true); !ok {
+		return
+	}
+
+	meta, err := c.repo.Metadata(clientID)
+	if err != nil {
+		if err == client.ErrorNotFound {
+			writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "client not found"))
+			return
+		}
+		log.Errorf("Failed fetching client %s: %v", clientID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, "unable to fetch client"))
+		return
+	}
+
+	sc := schema.MapClientIdentityToSchemaClient(oidc.ClientIdentity{
+		Credentials: oidc.ClientCredentials{ID: clientID},
+		Metadata:    *meta,
+	})
+	writeResponseWithBody(w, http.StatusOK, sc)
+}
+
+func (c *clientResource) update(w http.ResponseWriter, r *http.Request, clientID string) {
+	callerID, ok := c.authorize(w, r, clientID, true)
+	if !ok {
+		return
+	}
+
+	ct := r.Header.Get("content-type")
+	if ct != "application/json" {
+		log.Debugf("Unsupported request content-type: %v", ct)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest, "unsupported content-type"))
+		return
+	}
+
+	var sc schema.Client
+	if err := json.NewDecoder(r.Body).Decode(&sc); err != nil {
+		log.Debugf("Error decoding request body: %v", err)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest, "unable to decode request body"))
+		return
+	}
+
+	ci, err := schema.MapSchemaClientToClientIdentity(sc)
+	if err != nil {
+		log.Debugf("Invalid request data: %v", err)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidClientMetadata, "missing or invalid field: redirectURIs"))
+		return
+	}
+
+	if err := ci.Metadata.Valid(); err != nil {
+		log.Debugf("ClientMetadata invalid: %v", err)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidClientMetadata, err.Error()))
+		return
+	}
+
+	if err := c.repo.Update(clientID, ci.Metadata); err != nil {
+		if err == client.ErrorNotFound {
+			writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "client not found"))
+			return
+		}
+		log.Errorf("Failed updating client %s: %v", clientID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, "unable to update client"))
+		return
+	}
+
+	log.Infof("Client %s updated by client %s", clientID, callerID)
+	ci.Credentials = oidc.ClientCredentials{ID: clientID}
+	writeResponseWithBody(w, http.StatusOK, schema.MapClientIdentityToSchemaClient(ci))
+}
+
+func (c *clientResource) delete(w http.ResponseWriter, r *http.Request, clientID string) {
+	callerID, ok := c.authorize(w, r, clientID, true)
+	if !ok {
+		return
+	}
+
+	if err := c.repo.Delete(clientID); err != nil {
+		if err == client.ErrorNotFound {
+			writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "client not found"))
+			return
+		}
+		log.Errorf("Failed deleting client %s: %v", clientID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, "unable to delete client"))
+		return
+	}
+
+	log.Infof("Client %s deleted by client %s", clientID, callerID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *clientResource) setAdmin(w http.ResponseWriter, r *http.Request, clientID string) {
+	callerID, ok := c.authorize(w, r, clientID, false)
+	if !ok {
+		return
+	}
+
+	var req schema.ClientSetAdminRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debugf("Error decoding request body: %v", err)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest, "unable to decode request body"))
+		return
+	}
+
+	if err := c.repo.SetDexAdmin(clientID, req.IsAdmin); err != nil {
+		if err == client.ErrorNotFound {
+			writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "client not found"))
+			return
+		}
+		log.Errorf("Failed setting admin status of client %s: %v", clientID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, "unable to set client admin status"))
+		return
+	}
+
+	log.Infof("Admin status of client %s set to %t by client %s", clientID, req.IsAdmin, callerID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *clientResource) rotateSecret(w http.ResponseWriter, r *http.Request, clientID string) {
+	callerID, ok := c.authorize(w, r, clientID, true)
+	if !ok {
+		return
+	}
+
+	var req schema.ClientRotateSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Debugf("Error decoding request body: %v", err)
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest, "unable to decode request body"))
+		return
+	}
+
+	if req.GracePeriodSeconds < 0 {
+		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest, "gracePeriodSeconds must not be negative"))
+		return
+	}
+
+	creds, err := c.repo.RotateSecret(clientID, time.Duration(req.GracePeriodSeconds)*time.Second)
+	if err != nil {
+		if err == client.ErrorNotFound {
+			writeAPIError(w, http.StatusNotFound, newAPIError(errorInvalidRequest, "client not found"))
+			return
+		}
+		log.Errorf("Failed rotating secret of client %s: %v", clientID, err)
+		writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError, "unable to rotate client secret"))
+		return
+	}
+
+	log.Infof("Secret of client %s rotated by client %s", clientID, callerID)
+	writeResponseWithBody(w, http.StatusOK, schema.ClientRotateSecretResponse{
+		Id:     creds.ID,
+		Secret: creds.Secret,
+	})
+}
@@ -11,10 +11,14 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/coreos/dex/client"
 	schema "github.com/coreos/dex/schema/workerschema"
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
 	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
 )
 
 func makeBody(s string) io.ReadCloser {
@@ -254,3 +258,344 @@ func TestList(t *testing.T) {
 		}
 	}
 }
+
+func TestRotateSecret(t *testing.T) {
+	privKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate private key, error=%v", err)
+	}
+
+	makeToken := func(sub string) string {
+		now := time.Now()
+		claims := oidc.NewClaims("iss", sub, sub, now, now.Add(time.Hour))
+		jwt, err := jose.NewSignedJWT(claims, privKey.Signer())
+		if err != nil {
+			t.Fatalf("Failed to generate JWT, error=%v", err)
+		}
+		return jwt.Encode()
+	}
+
+	cs := []oidc.ClientIdentity{
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "foo", Secret: "foo-secret"},
+		},
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "bar", Secret: "bar-secret"},
+		},
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "admin", Secret: "admin-secret"},
+		},
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		caller string
+		body   string
+
+		wantCode int
+	}{
+		// rotating own secret
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/foo/rotate-secret",
+			caller:   "foo",
+			body:     `{"gracePeriodSeconds":60}`,
+			wantCode: http.StatusOK,
+		},
+		// rotating another client's secret
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/bar/rotate-secret",
+			caller:   "foo",
+			body:     `{}`,
+			wantCode: http.StatusForbidden,
+		},
+		// admin clients may rotate any secret
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/bar/rotate-secret",
+			caller:   "admin",
+			body:     `{}`,
+			wantCode: http.StatusOK,
+		},
+		// unknown client
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/baz/rotate-secret",
+			caller:   "admin",
+			body:     `{}`,
+			wantCode: http.StatusNotFound,
+		},
+		// negative grace period
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/foo/rotate-secret",
+			caller:   "foo",
+			body:     `{"gracePeriodSeconds":-1}`,
+			wantCode: http.StatusBadRequest,
+		},
+		// invalid method
+		{
+			method:   "GET",
+			path:     "/api/v1/clients/foo/rotate-secret",
+			caller:   "foo",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+		// unknown subresource
+		{
+			method:   "POST",
+			path:     "/api/v1/clients/foo/bar",
+			caller:   "foo",
+			body:     `{}`,
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := client.NewClientIdentityRepo(cs)
+		if err := repo.SetDexAdmin("admin", true); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		_, h := registerClientResource("/api/v1", repo, nil)
+
+		r, err := http.NewRequest(tt.method, "http://example.com"+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("Failed creating http.Request: %v", err)
+		}
+		r.Header.Set("Authorization", "Bearer "+makeToken(tt.caller))
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("case %d: invalid response code, want=%d, got=%d: %s", i, tt.wantCode, w.Code, w.Body.String())
+			continue
+		}
+		if tt.wantCode != http.StatusOK {
+			continue
+		}
+
+		var resp schema.ClientRotateSecretResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Errorf("case %d: unexpected error=%v", i, err)
+			continue
+		}
+
+		ok, err := repo.Authenticate(oidc.ClientCredentials{ID: resp.Id, Secret: resp.Secret})
+		if err != nil || !ok {
+			t.Errorf("case %d: new secret not accepted: ok=%v err=%v", i, ok, err)
+		}
+	}
+}
+
+func TestServeClient(t *testing.T) {
+	privKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate private key, error=%v", err)
+	}
+
+	makeToken := func(sub string) string {
+		now := time.Now()
+		claims := oidc.NewClaims("iss", sub, sub, now, now.Add(time.Hour))
+		jwt, err := jose.NewSignedJWT(claims, privKey.Signer())
+		if err != nil {
+			t.Fatalf("Failed to generate JWT, error=%v", err)
+		}
+		return jwt.Encode()
+	}
+
+	fooURL := url.URL{Scheme: "https", Host: "foo.example.com", Path: "/callback"}
+	cs := []oidc.ClientIdentity{
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "foo", Secret: "foo-secret"},
+			Metadata:    oidc.ClientMetadata{RedirectURIs: []url.URL{fooURL}},
+		},
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "bar", Secret: "bar-secret"},
+			Metadata:    oidc.ClientMetadata{RedirectURIs: []url.URL{fooURL}},
+		},
+		oidc.ClientIdentity{
+			Credentials: oidc.ClientCredentials{ID: "admin", Secret: "admin-secret"},
+			Metadata:    oidc.ClientMetadata{RedirectURIs: []url.URL{fooURL}},
+		},
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		caller string
+		body   string
+
+		wantCode      int
+		wantClient    *schema.Client
+		wantFooExists bool
+		wantFooAdmin  bool
+	}{
+		// client gets itself
+		{
+			method:        "GET",
+			path:          "/api/v1/clients/foo",
+			caller:        "foo",
+			wantCode:      http.StatusOK,
+			wantClient:    &schema.Client{Id: "foo", RedirectURIs: []string{"https://foo.example.com/callback"}},
+			wantFooExists: true,
+		},
+		// admin gets another client
+		{
+			method:        "GET",
+			path:          "/api/v1/clients/foo",
+			caller:        "admin",
+			wantCode:      http.StatusOK,
+			wantClient:    &schema.Client{Id: "foo", RedirectURIs: []string{"https://foo.example.com/callback"}},
+			wantFooExists: true,
+		},
+		// non-admin gets another client
+		{
+			method:        "GET",
+			path:          "/api/v1/clients/foo",
+			caller:        "bar",
+			wantCode:      http.StatusForbidden,
+			wantFooExists: true,
+		},
+		// unknown client
+		{
+			method:        "GET",
+			path:          "/api/v1/clients/baz",
+			caller:        "admin",
+			wantCode:      http.StatusNotFound,
+			wantFooExists: true,
+		},
+		// client updates itself
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/foo",
+			caller:        "foo",
+			body:          `{"redirectURIs":["https://new.example.com/callback"]}`,
+			wantCode:      http.StatusOK,
+			wantClient:    &schema.Client{Id: "foo", RedirectURIs: []string{"https://new.example.com/callback"}},
+			wantFooExists: true,
+		},
+		// update without redirect URIs
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/foo",
+			caller:        "foo",
+			body:          `{"redirectURIs":[]}`,
+			wantCode:      http.StatusBadRequest,
+			wantFooExists: true,
+		},
+		// non-admin updates another client
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/foo",
+			caller:        "bar",
+			body:          `{"redirectURIs":["https://new.example.com/callback"]}`,
+			wantCode:      http.StatusForbidden,
+			wantFooExists: true,
+		},
+		// client deletes itself
+		{
+			method:   "DELETE",
+			path:     "/api/v1/clients/foo",
+			caller:   "foo",
+			wantCode: http.StatusNoContent,
+		},
+		// admin deletes another client
+		{
+			method:   "DELETE",
+			path:     "/api/v1/clients/foo",
+			caller:   "admin",
+			wantCode: http.StatusNoContent,
+		},
+		// non-admin deletes another client
+		{
+			method:        "DELETE",
+			path:          "/api/v1/clients/foo",
+			caller:        "bar",
+			wantCode:      http.StatusForbidden,
+			wantFooExists: true,
+		},
+		// admin grants admin status
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/foo/admin",
+			caller:        "admin",
+			body:          `{"isAdmin":true}`,
+			wantCode:      http.StatusNoContent,
+			wantFooExists: true,
+			wantFooAdmin:  true,
+		},
+		// clients may not make themselves admins
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/foo/admin",
+			caller:        "foo",
+			body:          `{"isAdmin":true}`,
+			wantCode:      http.StatusForbidden,
+			wantFooExists: true,
+		},
+		// admin status of unknown client
+		{
+			method:        "PUT",
+			path:          "/api/v1/clients/baz/admin",
+			caller:        "admin",
+			body:          `{"isAdmin":true}`,
+			wantCode:      http.StatusNotFound,
+			wantFooExists: true,
+		},
+		// unsupported method
+		{
+			method:        "POST",
+			path:          "/api/v1/clients/foo",
+			caller:        "foo",
+			wantCode:      http.StatusMethodNotAllowed,
+			wantFooExists: true,
+		},
+	}
+
+	for i, tt := range tests {
+		repo := client.NewClientIdentityRepo(cs)
+		if err := repo.SetDexAdmin("admin", true); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		_, h := registerClientResource("/api/v1", repo, nil)
+
+		r, err := http.NewRequest(tt.method, "http://example.com"+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("Failed creating http.Request: %v", err)
+		}
+		r.Header.Set("Authorization", "Bearer "+makeToken(tt.caller))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("case %d: invalid response code, want=%d, got=%d: %s", i, tt.wantCode, w.Code, w.Body.String())
+			continue
+		}
+
+		if tt.wantClient != nil {
+			var sc schema.Client
+			if err := json.Unmarshal(w.Body.Bytes(), &sc); err != nil {
+				t.Errorf("case %d: unexpected error=%v", i, err)
+				continue
+			}
+			if diff := pretty.Compare(*tt.wantClient, sc); diff != "" {
+				t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+			}
+		}
+
+		_, err = repo.Metadata("foo")
+		if gotExists := err == nil; gotExists != tt.wantFooExists {
+			t.Errorf("case %d: client foo exists: want=%v, got=%v", i, tt.wantFooExists, gotExists)
+		}
+
+		isAdmin, err := repo.IsDexAdmin("foo")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if isAdmin != tt.wantFooAdmin {
+			t.Errorf("case %d: client foo admin: want=%v, got=%v", i, tt.wantFooAdmin, isAdmin)
+		}
+	}
+}
@@ -13,16 +13,20 @@ import (
 	"github.com/coreos/go-oidc/key"
 	"github.com/coreos/pkg/health"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/db"
 	"github.com/coreos/dex/email"
+	"github.com/coreos/dex/kubernetes"
+	"github.com/coreos/dex/redis"
 	"github.com/coreos/dex/refresh"
 	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
 	useremail "github.com/coreos/dex/user/email"
 	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
 )
 
 type ServerConfig struct {
@@ -36,6 +40,24 @@ type ServerConfig struct {
 	StateConfig              StateConfigurer
 	EnableRegistration       bool
 	EnableClientRegistration bool
+
+	WebAuthnRequiredClients    []string
+	WebAuthnRequiredConnectors []string
+
+	// LockoutPolicy limits failed logins to the local connector.
+	LockoutPolicy user.LockoutPolicy
+
+	// PasswordPolicy is enforced on new passwords of local users. If nil,
+	// user.DefaultPasswordPolicy is used.
+	PasswordPolicy user.PasswordPolicy
+
+	// AuditLogFile, if set, is a file to which audit events are appended as
+	// lines of JSON, in addition to being stored by the StateConfigurer.
+	AuditLogFile string
+
+	// SessionRedisURL, if set, is the URL of a Redis server in which to
+	// keep sessions and session keys, whatever the StateConfigurer.
+	SessionRedisURL string
 }
 
 type StateConfigurer interface {
@@ -46,6 +68,15 @@ type SingleServerConfig struct {
 	ClientsFile    string
 	ConnectorsFile string
 	UsersFile      string
+
+	// SnapshotFile, if set, is a file to which the in-memory repos are
+	// saved every SnapshotInterval, and from which they are restored on
+	// startup. Once it exists, its clients and users replace those of
+	// ClientsFile and UsersFile. It is encrypted with SnapshotKeySecrets,
+	// which are used like the key secrets of a database.
+	SnapshotFile       string
+	SnapshotInterval   time.Duration
+	SnapshotKeySecrets [][]byte
 }
 
 type MultiServerConfig struct {
@@ -54,6 +85,18 @@ type MultiServerConfig struct {
 	UseOldFormat   bool
 }
 
+// KubernetesServerConfig keeps the state of the server in custom resources
+// of a Kubernetes cluster. As there is no overlord, the server rotates the
+// signing keys every KeyPeriod and deletes expired data every GCInterval
+// itself. Second factors, groups, attributes, stored audit events and
+// webhooks are not supported.
+type KubernetesServerConfig struct {
+	KeySecrets [][]byte
+	Config     kubernetes.Config
+	KeyPeriod  time.Duration
+	GCInterval time.Duration
+}
+
 func (cfg *ServerConfig) Server() (*Server, error) {
 	iu, err := url.Parse(cfg.IssuerURL)
 	if err != nil {
@@ -76,6 +119,28 @@ func (cfg *ServerConfig) Server() (*Server, error) {
 
 		EnableRegistration:       cfg.EnableRegistration,
 		EnableClientRegistration: cfg.EnableClientRegistration,
+
+		WebAuthnRequiredClients:    cfg.WebAuthnRequiredClients,
+		WebAuthnRequiredConnectors: cfg.WebAuthnRequiredConnectors,
+		LockoutPolicy:              cfg.LockoutPolicy,
+		PasswordPolicy:             cfg.PasswordPolicy,
+	}
+
+	if cfg.AuditLogFile != "" {
+		f, err := os.OpenFile(cfg.AuditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open audit log file %s: %v", cfg.AuditLogFile, err)
+		}
+		srv.AuditSink = audit.NewFileSink(f)
+	}
+
+	if cfg.SessionRedisURL != "" {
+		rc, err := redis.NewConnection(redis.Config{URL: cfg.SessionRedisURL})
+		if err != nil {
+			return nil, err
+		}
+		srv.SessionRepo = redis.NewSessionRepo(rc)
+		srv.SessionKeyRepo = redis.NewSessionKeyRepo(rc)
 	}
 
 	err = cfg.StateConfig.Configure(&srv)
@@ -83,6 +148,10 @@ func (cfg *ServerConfig) Server() (*Server, error) {
 		return nil, err
 	}
 
+	if (len(srv.WebAuthnRequiredClients) > 0 || len(srv.WebAuthnRequiredConnectors) > 0) && srv.WebAuthnCredentialRepo == nil {
+		return nil, errors.New("security keys cannot be required without a WebAuthnCredentialRepo")
+	}
+
 	err = setTemplates(&srv, tpl)
 	if err != nil {
 		return nil, err
@@ -128,8 +197,7 @@ func (cfg *SingleServerConfig) Configure(srv *Server) error {
 	}
 	cfgRepo := connector.NewConnectorConfigRepoFromConfigs(cfgs)
 
-	sRepo := session.NewSessionRepo()
-	skRepo := session.NewSessionKeyRepo()
+	sRepo, skRepo := withSessionRepos(srv, session.NewSessionRepo(), session.NewSessionKeyRepo())
 	sm := session.NewSessionManager(sRepo, skRepo)
 
 	userRepo, err := user.NewUserRepoFromFile(cfg.UsersFile)
@@ -138,17 +206,73 @@ func (cfg *SingleServerConfig) Configure(srv *Server) error {
 	}
 
 	pwiRepo := user.NewPasswordInfoRepo()
+	totpRepo := user.NewTOTPInfoRepo()
+	webAuthnRepo := user.NewWebAuthnCredentialRepo()
 
 	refTokRepo := refresh.NewRefreshTokenRepo()
+	loginAttemptRepo := user.NewLoginAttemptRepo()
+	groupRepo := user.NewGroupRepo()
+	attributeRepo := user.NewAttributeRepo()
+	eventRepo := audit.NewEventRepo()
+	auditSink := withAuditRepo(srv.AuditSink, eventRepo)
+
+	if cfg.SnapshotFile != "" {
+		snap, err := repo.NewSnapshotFile(cfg.SnapshotFile, cfg.SnapshotInterval, cfg.SnapshotKeySecrets...)
+		if err != nil {
+			return fmt.Errorf("unable to create snapshot file: %v", err)
+		}
+		for name, r := range map[string]interface{}{
+			"clients":              ciRepo,
+			"users":                userRepo,
+			"password_infos":       pwiRepo,
+			"totp_infos":           totpRepo,
+			"webauthn_credentials": webAuthnRepo,
+			"refresh_tokens":       refTokRepo,
+			"login_attempts":       loginAttemptRepo,
+			"groups":               groupRepo,
+			"attributes":           attributeRepo,
+			"audit_events":         eventRepo,
+		} {
+			snap.Register(name, r.(repo.Snapshotter))
+		}
+		// Sessions kept in Redis outlive the server by themselves.
+		for name, r := range map[string]interface{}{
+			"sessions":     sRepo,
+			"session_keys": skRepo,
+		} {
+			if s, ok := r.(repo.Snapshotter); ok {
+				snap.Register(name, s)
+			}
+		}
+		if err := snap.Load(); err != nil {
+			return err
+		}
+		srv.Snapshots = snap
+	}
 
 	txnFactory := repo.InMemTransactionFactory
-	userManager := manager.NewUserManager(userRepo, pwiRepo, cfgRepo, txnFactory, manager.ManagerOptions{})
+	userManager := manager.NewUserManager(userRepo, pwiRepo, cfgRepo, txnFactory, manager.ManagerOptions{
+		PasswordPolicy:         srv.PasswordPolicy,
+		RefreshTokenRepo:       refTokRepo,
+		SessionRepo:            sRepo,
+		TOTPInfoRepo:           totpRepo,
+		WebAuthnCredentialRepo: webAuthnRepo,
+		LoginAttemptRepo:       loginAttemptRepo,
+		GroupRepo:              groupRepo,
+		AttributeRepo:          attributeRepo,
+		AuditSink:              auditSink,
+	})
 	srv.ClientIdentityRepo = ciRepo
 	srv.KeySetRepo = kRepo
 	srv.ConnectorConfigRepo = cfgRepo
 	srv.UserRepo = userRepo
 	srv.UserManager = userManager
 	srv.PasswordInfoRepo = pwiRepo
+	srv.TOTPInfoRepo = totpRepo
+	srv.WebAuthnCredentialRepo = webAuthnRepo
+	srv.AttributeRepo = attributeRepo
+	srv.AuditSink = auditSink
+	srv.LoginThrottler = user.NewLoginThrottler(loginAttemptRepo, txnFactory, srv.LockoutPolicy)
 	srv.SessionManager = sm
 	srv.RefreshTokenRepo = refTokRepo
 	return nil
@@ -175,13 +299,37 @@ func (cfg *MultiServerConfig) Configure(srv *Server) error {
 	}
 
 	ciRepo := db.NewClientIdentityRepo(dbc)
-	sRepo := db.NewSessionRepo(dbc)
-	skRepo := db.NewSessionKeyRepo(dbc)
+	sRepo, skRepo := withSessionRepos(srv, db.NewSessionRepo(dbc), db.NewSessionKeyRepo(dbc))
 	cfgRepo := db.NewConnectorConfigRepo(dbc)
 	userRepo := db.NewUserRepo(dbc)
 	pwiRepo := db.NewPasswordInfoRepo(dbc)
-	userManager := manager.NewUserManager(userRepo, pwiRepo, cfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{})
+	totpRepo, err := db.NewTOTPInfoRepo(dbc, cfg.KeySecrets...)
+	if err != nil {
+		return fmt.Errorf("unable to create TOTPInfoRepo: %v", err)
+	}
+	webAuthnRepo := db.NewWebAuthnCredentialRepo(dbc)
+	loginAttemptRepo := db.NewLoginAttemptRepo(dbc)
+	groupRepo := db.NewGroupRepo(dbc)
+	attributeRepo := db.NewAttributeRepo(dbc)
+	auditSink := withAuditRepo(srv.AuditSink, db.NewAuditEventRepo(dbc))
+	webhookRepo, err := db.NewWebhookSubscriptionRepo(dbc, cfg.KeySecrets...)
+	if err != nil {
+		return fmt.Errorf("unable to create WebhookSubscriptionRepo: %v", err)
+	}
+	notifier := webhook.NewNotifier(webhookRepo, db.NewWebhookDeliveryQueue(dbc))
 	refreshTokenRepo := db.NewRefreshTokenRepo(dbc)
+	userManager := manager.NewUserManager(userRepo, pwiRepo, cfgRepo, db.TransactionFactory(dbc), manager.ManagerOptions{
+		PasswordPolicy:         srv.PasswordPolicy,
+		RefreshTokenRepo:       refreshTokenRepo,
+		SessionRepo:            sRepo,
+		TOTPInfoRepo:           totpRepo,
+		WebAuthnCredentialRepo: webAuthnRepo,
+		LoginAttemptRepo:       loginAttemptRepo,
+		GroupRepo:              groupRepo,
+		AttributeRepo:          attributeRepo,
+		AuditSink:              auditSink,
+		WebhookNotifier:        notifier,
+	})
 
 	sm := session.NewSessionManager(sRepo, skRepo)
 
@@ -191,11 +339,100 @@ func (cfg *MultiServerConfig) Configure(srv *Server) error {
 	srv.UserRepo = userRepo
 	srv.UserManager = userManager
 	srv.PasswordInfoRepo = pwiRepo
+	srv.TOTPInfoRepo = totpRepo
+	srv.TOTPTxnFactory = db.TransactionFactory(dbc)
+	srv.WebAuthnCredentialRepo = webAuthnRepo
+	srv.AttributeRepo = attributeRepo
+	srv.AuditSink = auditSink
+	srv.WebhookNotifier = notifier
+	srv.LoginThrottler = user.NewLoginThrottler(loginAttemptRepo, db.TransactionFactory(dbc), srv.LockoutPolicy)
 	srv.SessionManager = sm
 	srv.RefreshTokenRepo = refreshTokenRepo
 	return nil
 }
 
+func (cfg *KubernetesServerConfig) Configure(srv *Server) error {
+	if len(cfg.KeySecrets) == 0 {
+		return errors.New("missing key secret")
+	}
+
+	// There are no resources for security keys, so requiring them could
+	// never be satisfied.
+	if len(srv.WebAuthnRequiredClients) > 0 || len(srv.WebAuthnRequiredConnectors) > 0 {
+		return errors.New("security keys cannot be required with Kubernetes storage")
+	}
+
+	c, err := kubernetes.NewClient(cfg.Config)
+	if err != nil {
+		return fmt.Errorf("unable to create Kubernetes client: %v", err)
+	}
+
+	// The rotator has a key set repo of its own, as the repo remembers the
+	// version of the keys it last read.
+	kRepo, err := kubernetes.NewPrivateKeySetRepo(c, cfg.KeySecrets...)
+	if err != nil {
+		return fmt.Errorf("unable to create PrivateKeySetRepo: %v", err)
+	}
+	rotRepo, err := kubernetes.NewPrivateKeySetRepo(c, cfg.KeySecrets...)
+	if err != nil {
+		return fmt.Errorf("unable to create PrivateKeySetRepo: %v", err)
+	}
+
+	ciRepo := kubernetes.NewClientIdentityRepo(c)
+	sRepo, skRepo := withSessionRepos(srv, kubernetes.NewSessionRepo(c), kubernetes.NewSessionKeyRepo(c))
+	cfgRepo := kubernetes.NewConnectorConfigRepo(c)
+	userRepo := kubernetes.NewUserRepo(c)
+	pwiRepo := kubernetes.NewPasswordInfoRepo(c)
+	refreshTokenRepo := kubernetes.NewRefreshTokenRepo(c)
+
+	// The API server has no transactions. Each resource is written
+	// atomically, but the UserManager operations which touch several of
+	// them, such as creating a user along with its password, can be left
+	// half done if a write fails.
+	userManager := manager.NewUserManager(userRepo, pwiRepo, cfgRepo, repo.InMemTransactionFactory, manager.ManagerOptions{
+		PasswordPolicy:   srv.PasswordPolicy,
+		RefreshTokenRepo: refreshTokenRepo,
+		SessionRepo:      sRepo,
+		AuditSink:        srv.AuditSink,
+	})
+
+	sm := session.NewSessionManager(sRepo, skRepo)
+
+	srv.ClientIdentityRepo = ciRepo
+	srv.KeySetRepo = kRepo
+	srv.KeyRotator = key.NewPrivateKeyRotator(rotRepo, cfg.KeyPeriod)
+	srv.GarbageCollector = kubernetes.NewGarbageCollector(c, cfg.GCInterval)
+	srv.ConnectorConfigRepo = cfgRepo
+	srv.UserRepo = userRepo
+	srv.UserManager = userManager
+	srv.PasswordInfoRepo = pwiRepo
+	srv.SessionManager = sm
+	srv.RefreshTokenRepo = refreshTokenRepo
+
+	// Failed logins are counted by each worker on its own, so a client
+	// spreading its attempts over n workers gets n times as many.
+	srv.LoginThrottler = user.NewLoginThrottler(user.NewLoginAttemptRepo(), repo.InMemTransactionFactory, srv.LockoutPolicy)
+	return nil
+}
+
+// withAuditRepo returns an AuditSink recording events to repo as well as to
+// sink, if it is set.
+func withAuditRepo(sink audit.AuditSink, repo audit.EventRepo) audit.AuditSink {
+	if sink == nil {
+		return repo
+	}
+	return audit.MultiSink(repo, sink)
+}
+
+// withSessionRepos returns the session repos of srv, if it has them, and
+// otherwise sRepo and skRepo.
+func withSessionRepos(srv *Server, sRepo session.SessionRepo, skRepo session.SessionKeyRepo) (session.SessionRepo, session.SessionKeyRepo) {
+	if srv.SessionRepo == nil {
+		return sRepo, skRepo
+	}
+	return srv.SessionRepo, srv.SessionKeyRepo
+}
+
 func getTemplates(issuerName, issuerLogoURL string,
 	enableRegister bool, dir string) (*template.Template, error) {
 	tpl := template.New("").Funcs(map[string]interface{}{
@@ -244,6 +481,18 @@ func setTemplates(srv *Server, tpls *template.Template) error {
 	}
 	srv.ResetPasswordTemplate = rpwtpl
 
+	watpl, err := findTemplate(WebAuthnTemplateName, tpls)
+	if err != nil {
+		return err
+	}
+	srv.WebAuthnTemplate = watpl
+
+	atpl, err := findTemplate(AccountTemplateName, tpls)
+	if err != nil {
+		return err
+	}
+	srv.AccountTemplate = atpl
+
 	return nil
 }
 
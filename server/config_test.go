@@ -0,0 +1,19 @@
+package server
+
+import (
+	"testing"
+)
+
+func TestKubernetesServerConfigWebAuthnRequired(t *testing.T) {
+	tests := []*Server{
+		{WebAuthnRequiredClients: []string{"XXX"}},
+		{WebAuthnRequiredConnectors: []string{"local"}},
+	}
+
+	for i, srv := range tests {
+		cfg := &KubernetesServerConfig{KeySecrets: [][]byte{make([]byte, 32)}}
+		if err := cfg.Configure(srv); err == nil {
+			t.Errorf("case %d: want error, got nil", i)
+		}
+	}
+}
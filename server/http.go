@@ -42,7 +42,18 @@ var (
 	httpPathResetPassword      = "/reset-password"
 	httpPathAcceptInvitation   = "/accept-invitation"
 	httpPathDebugVars          = "/debug/vars"
+	httpPathMetrics            = "/metrics"
 	httpPathClientRegistration = "/registration"
+	httpPathWebAuthn           = "/webauthn"
+	httpPathAccount            = "/account"
+	httpPathAccountCallback    = "/account/callback"
+	httpPathAccountProfile     = "/account/profile"
+	httpPathAccountPassword    = "/account/password"
+	httpPathAccountLink        = "/account/link"
+	httpPathAccountUnlink      = "/account/unlink"
+	httpPathAccountRevoke      = "/account/revoke"
+	httpPathAccountLogout      = "/account/logout"
+	httpPathSCIM               = "/scim/v2"
 
 	cookieLastSeen                 = "LastSeen"
 	cookieShowEmailVerifiedMessage = "ShowEmailVerifiedMessage"
@@ -189,7 +200,7 @@ func renderLoginPage(w http.ResponseWriter, r *http.Request, srv OIDCServer, idp
 	clientID := q.Get("client_id")
 	cm, err := srv.ClientMetadata(clientID)
 	if err != nil {
-		log.Errorf("Failed fetching client %q from repo: %v", clientID, err)
+		phttp.Logger(r).Errorf("Failed fetching client %q from repo: %v", clientID, err)
 		td.Error = true
 		td.Message = "Server Error"
 		execTemplate(w, tpl, td)
@@ -221,6 +232,7 @@ func renderLoginPage(w http.ResponseWriter, r *http.Request, srv OIDCServer, idp
 	}
 	linkParams.Del("msg_code")
 	linkParams.Del("show_connectors")
+	linkParams.Del("link_token")
 	link.RawQuery = linkParams.Encode()
 	td.RegisterOrLoginURL = link.String()
 
@@ -272,13 +284,14 @@ func handleAuthFunc(srv OIDCServer, idpcs []connector.Connector, tpl *template.T
 			return
 		}
 
+		l := phttp.Logger(r)
 		q := r.URL.Query()
 		register := q.Get("register") == "1" && registrationEnabled
 		e := q.Get("error")
 		if e != "" {
 			sessionKey := q.Get("state")
 			if err := srv.KillSession(sessionKey); err != nil {
-				log.Errorf("Failed killing sessionKey %q: %v", sessionKey, err)
+				l.Errorf("Failed killing session: %v", err)
 			}
 			renderLoginPage(w, r, srv, idpcs, register, tpl)
 			return
@@ -291,27 +304,29 @@ func handleAuthFunc(srv OIDCServer, idpcs []connector.Connector, tpl *template.T
 			return
 		}
 
+		l = l.WithField("connector_id", connectorID)
 		acr, err := oauth2.ParseAuthCodeRequest(q)
 		if err != nil {
-			log.Errorf("Invalid auth request: %v", err)
+			l.Errorf("Invalid auth request: %v", err)
 			writeAuthError(w, err, acr.State)
 			return
 		}
 
+		l = l.WithField("client_id", acr.ClientID)
 		cm, err := srv.ClientMetadata(acr.ClientID)
 		if err != nil {
-			log.Errorf("Failed fetching client %q from repo: %v", acr.ClientID, err)
+			l.Errorf("Failed fetching client %q from repo: %v", acr.ClientID, err)
 			writeAuthError(w, oauth2.NewError(oauth2.ErrorServerError), acr.State)
 			return
 		}
 		if cm == nil {
-			log.Errorf("Client %q not found", acr.ClientID)
+			l.Errorf("Client %q not found", acr.ClientID)
 			writeAuthError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), acr.State)
 			return
 		}
 
 		if len(cm.RedirectURIs) == 0 {
-			log.Errorf("Client %q has no redirect URLs", acr.ClientID)
+			l.Errorf("Client %q has no redirect URLs", acr.ClientID)
 			writeAuthError(w, oauth2.NewError(oauth2.ErrorServerError), acr.State)
 			return
 		}
@@ -320,22 +335,22 @@ func handleAuthFunc(srv OIDCServer, idpcs []connector.Connector, tpl *template.T
 		if err != nil {
 			switch err {
 			case (client.ErrorCantChooseRedirectURL):
-				log.Errorf("Request must provide redirect URL as client %q has registered many", acr.ClientID)
+				l.Errorf("Request must provide redirect URL as client %q has registered many", acr.ClientID)
 				writeAuthError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), acr.State)
 				return
 			case (client.ErrorInvalidRedirectURL):
-				log.Errorf("Request provided unregistered redirect URL: %s", acr.RedirectURL)
+				l.Errorf("Request provided unregistered redirect URL: %s", acr.RedirectURL)
 				writeAuthError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), acr.State)
 				return
 			case (client.ErrorNoValidRedirectURLs):
-				log.Errorf("There are no registered URLs for the requested client: %s", acr.RedirectURL)
+				l.Errorf("There are no registered URLs for the requested client: %s", acr.RedirectURL)
 				writeAuthError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), acr.State)
 				return
 			}
 		}
 
 		if acr.ResponseType != oauth2.ResponseTypeCode {
-			log.Errorf("unexpected ResponseType: %v: ", acr.ResponseType)
+			l.Errorf("unexpected ResponseType: %v: ", acr.ResponseType)
 			redirectAuthError(w, oauth2.NewError(oauth2.ErrorUnsupportedResponseType), acr.State, redirectURL)
 			return
 		}
@@ -363,20 +378,28 @@ func handleAuthFunc(srv OIDCServer, idpcs []connector.Connector, tpl *template.T
 		}
 
 		if !foundOpenIDScope {
-			log.Errorf("Invalid auth request: missing 'openid' in 'scope'")
+			l.Errorf("Invalid auth request: missing 'openid' in 'scope'")
 			writeAuthError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), acr.State)
 			return
 		}
 
 		nonce := q.Get("nonce")
 
-		key, err := srv.NewSession(connectorID, acr.ClientID, acr.State, redirectURL, nonce, register, acr.Scope)
+		key, err := srv.NewSession(r.Context(), connectorID, acr.ClientID, acr.State, redirectURL, nonce, register, acr.Scope)
 		if err != nil {
-			log.Errorf("Error creating new session: %v: ", err)
+			l.Errorf("Error creating new session: %v: ", err)
 			redirectAuthError(w, err, acr.State, redirectURL)
 			return
 		}
 
+		if lt := q.Get("link_token"); lt != "" {
+			if key, err = srv.SetLinkToken(key, lt); err != nil {
+				l.Errorf("Error setting link token: %v: ", err)
+				redirectAuthError(w, err, acr.State, redirectURL)
+				return
+			}
+		}
+
 		if register {
 			_, ok := idpc.(*connector.LocalConnector)
 			if ok {
@@ -398,7 +421,7 @@ func handleAuthFunc(srv OIDCServer, idpcs []connector.Connector, tpl *template.T
 		}
 		lu, err := idpc.LoginURL(key, p)
 		if err != nil {
-			log.Errorf("Connector.LoginURL failed: %v", err)
+			l.Errorf("Connector.LoginURL failed: %v", err)
 			redirectAuthError(w, err, acr.State, redirectURL)
 			return
 		}
@@ -418,9 +441,10 @@ func handleTokenFunc(srv OIDCServer) http.HandlerFunc {
 			return
 		}
 
+		l := phttp.Logger(r)
 		err := r.ParseForm()
 		if err != nil {
-			log.Errorf("error parsing request: %v", err)
+			l.Errorf("error parsing request: %v", err)
 			writeTokenError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), "")
 			return
 		}
@@ -429,12 +453,13 @@ func handleTokenFunc(srv OIDCServer) http.HandlerFunc {
 
 		user, password, ok := r.BasicAuth()
 		if !ok {
-			log.Errorf("error parsing basic auth")
+			l.Errorf("error parsing basic auth")
 			writeTokenError(w, oauth2.NewError(oauth2.ErrorInvalidClient), state)
 			return
 		}
 
 		creds := oidc.ClientCredentials{ID: user, Secret: password}
+		l = l.WithField("client_id", creds.ID)
 
 		var jwt *jose.JWT
 		var refreshToken string
@@ -444,20 +469,20 @@ func handleTokenFunc(srv OIDCServer) http.HandlerFunc {
 		case oauth2.GrantTypeAuthCode:
 			code := r.PostForm.Get("code")
 			if code == "" {
-				log.Errorf("missing code param")
+				l.Errorf("missing code param")
 				writeTokenError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), state)
 				return
 			}
-			jwt, refreshToken, err = srv.CodeToken(creds, code)
+			jwt, refreshToken, err = srv.CodeToken(r.Context(), creds, code)
 			if err != nil {
-				log.Errorf("couldn't exchange code for token: %v", err)
+				l.Errorf("couldn't exchange code for token: %v", err)
 				writeTokenError(w, err, state)
 				return
 			}
 		case oauth2.GrantTypeClientCreds:
-			jwt, err = srv.ClientCredsToken(creds)
+			jwt, err = srv.ClientCredsToken(r.Context(), creds)
 			if err != nil {
-				log.Errorf("couldn't creds for token: %v", err)
+				l.Errorf("couldn't creds for token: %v", err)
 				writeTokenError(w, err, state)
 				return
 			}
@@ -467,13 +492,18 @@ func handleTokenFunc(srv OIDCServer) http.HandlerFunc {
 				writeTokenError(w, oauth2.NewError(oauth2.ErrorInvalidRequest), state)
 				return
 			}
-			jwt, err = srv.RefreshToken(creds, token)
+			var scope []string
+			if qs := r.PostForm.Get("scope"); qs != "" {
+				scope = strings.Split(qs, " ")
+			}
+			jwt, err = srv.RefreshToken(r.Context(), creds, scope, token)
 			if err != nil {
+				l.Errorf("couldn't refresh token: %v", err)
 				writeTokenError(w, err, state)
 				return
 			}
 		default:
-			log.Errorf("unsupported grant: %v", grantType)
+			l.Errorf("unsupported grant: %v", grantType)
 			writeTokenError(w, oauth2.NewError(oauth2.ErrorUnsupportedGrantType), state)
 			return
 		}
@@ -487,7 +517,7 @@ func handleTokenFunc(srv OIDCServer) http.HandlerFunc {
 
 		b, err := json.Marshal(t)
 		if err != nil {
-			log.Errorf("Failed marshaling %#v to JSON: %v", t, err)
+			l.Errorf("Failed marshaling token response to JSON: %v", err)
 			writeTokenError(w, oauth2.NewError(oauth2.ErrorServerError), state)
 			return
 		}
@@ -1,6 +1,8 @@
 package server
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,6 +10,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"reflect"
 	"strings"
 	"testing"
@@ -17,6 +20,8 @@ import (
 
 	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/connector"
+	phttp "github.com/coreos/dex/pkg/http"
+	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/oauth2"
@@ -342,6 +347,66 @@ func TestHandleTokenFuncState(t *testing.T) {
 	}
 }
 
+func TestHandleTokenFuncRequestLogger(t *testing.T) {
+	ci := oidc.ClientIdentity{
+		Credentials: oidc.ClientCredentials{ID: "XXX", Secret: base64.URLEncoding.EncodeToString([]byte("secrete"))},
+		Metadata: oidc.ClientMetadata{
+			RedirectURIs: []url.URL{{Scheme: "http", Host: "client.example.com", Path: "/callback"}},
+		},
+	}
+	srv := &Server{
+		IssuerURL:          url.URL{Scheme: "http", Host: "server.example.com"},
+		ClientIdentityRepo: client.NewClientIdentityRepo([]oidc.ClientIdentity{ci}),
+	}
+	hdlr := phttp.RequestIDHandler(handleTokenFunc(srv))
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	log.SetFormat(log.FormatJSON)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFormat(log.FormatText)
+	}()
+
+	// Lines logged by the server for the request carry its ID and client.
+	for i, grantType := range []string{oauth2.GrantTypeAuthCode, oauth2.GrantTypeRefreshToken} {
+		buf.Reset()
+		v := url.Values{
+			"grant_type":    {grantType},
+			"code":          {"code"},
+			"refresh_token": {"token"},
+		}
+		req, err := http.NewRequest("POST", "http://example.com", strings.NewReader(v.Encode()))
+		if err != nil {
+			t.Fatalf("case %d: unable to create HTTP request: %v", i, err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set(phttp.RequestIDHeader, "abc-123")
+		req.SetBasicAuth(ci.Credentials.ID, "wrong")
+
+		w := httptest.NewRecorder()
+		hdlr.ServeHTTP(w, req)
+
+		var found bool
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var m map[string]interface{}
+			if err := json.Unmarshal([]byte(line), &m); err != nil {
+				t.Fatalf("case %d: invalid log line %q: %v", i, line, err)
+			}
+			if m["msg"] != "Failed to authenticate client" {
+				continue
+			}
+			found = true
+			if m["request_id"] != "abc-123" || m["client_id"] != ci.Credentials.ID {
+				t.Errorf("case %d: want request and client IDs logged, got %v", i, m)
+			}
+		}
+		if !found {
+			t.Errorf("case %d: failed authentication not logged: %s", i, buf.String())
+		}
+	}
+}
+
 func TestHandleDiscoveryFuncMethodNotAllowed(t *testing.T) {
 	for _, m := range []string{"POST", "PUT", "DELETE"} {
 		hdlr := handleDiscoveryFunc(oidc.ProviderConfig{})
@@ -538,3 +603,37 @@ func (c checkable) Healthy() (err error) {
 	}
 	return
 }
+
+func TestHandleMetrics(t *testing.T) {
+	f, err := makeTestFixtures()
+	if err != nil {
+		t.Fatalf("error making test fixtures: %v", err)
+	}
+	h := f.srv.HTTPHandler()
+
+	for i, p := range []string{httpPathDiscovery, "/no/such/path"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", p, nil))
+		if i == 0 && w.Code != http.StatusOK {
+			t.Fatalf("GET %s: want status %d, got %d", p, http.StatusOK, w.Code)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", httpPathMetrics, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("want status %d, got %d", http.StatusOK, w.Code)
+	}
+
+	body := w.Body.String()
+	for i, want := range []string{
+		`dex_http_requests_total{handler="` + httpPathDiscovery + `",method="GET",code="200"}`,
+		`dex_http_requests_total{handler="unmatched",method="GET",code="404"}`,
+		`# TYPE dex_tokens_issued_total counter`,
+		`# TYPE dex_logins_total counter`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("case %d: metrics missing %q:\n%s", i, want, body)
+		}
+	}
+}
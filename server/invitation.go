@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"time"
 
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/dex/user"
 	"github.com/coreos/dex/user/manager"
@@ -49,7 +50,7 @@ func (h *InvitationHandler) handleGET(w http.ResponseWriter, r *http.Request) {
 
 	invite, err := user.ParseAndVerifyInvitationToken(token, h.issuerURL, keys)
 	if err != nil {
-		log.Debugf("invalid invitation token: %v (%v)", err, token)
+		phttp.Logger(r).Debugf("invalid invitation token: %v", err)
 		writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest,
 			"Your invitation could not be verified"))
 		return
@@ -66,6 +67,11 @@ func (h *InvitationHandler) handleGET(w http.ResponseWriter, r *http.Request) {
 			writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest,
 				"Your email does not match the email address on file"))
 			return
+		case user.ErrorNotFound:
+			// The user has been deleted since they were invited.
+			writeAPIError(w, http.StatusBadRequest, newAPIError(errorInvalidRequest,
+				"Your invitation could not be verified"))
+			return
 		default:
 			log.Errorf("internal error verifying email: %v", err)
 			writeAPIError(w, http.StatusInternalServerError, newAPIError(errorServerError,
@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
+)
+
+const linkTokenValidity = 10 * time.Minute
+
+var errLinkTokenAudience = errors.New("link token not addressed to the account pages")
+
+func (s *Server) SetLinkToken(sessionKey, token string) (string, error) {
+	sessionID, err := s.SessionManager.ExchangeKey(sessionKey)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := s.SessionManager.SetLinkToken(sessionID, token); err != nil {
+		return "", err
+	}
+	return s.SessionManager.NewSessionKey(sessionID)
+}
+
+// loginUnknownIdentity handles a login with a remote identity which belongs
+// to no user. If a user with the same email address exists, the identity is
+// linked to them right away when the connector vouches for the address and
+// they have verified it. Otherwise they are asked to log in with their
+// existing account to link it. Either the linked user or the URL to send the
+// user to is returned.
+func (s *Server) loginUnknownIdentity(ctx context.Context, ses *session.Session) (user.User, string, error) {
+	if ses.ClientID == accountClientID && ses.LinkToken != "" {
+		// The account pages link the identity to the user logged in to them
+		// once the login completes.
+		code, err := s.SessionManager.NewSessionKey(ses.ID)
+		if err != nil {
+			return user.User{}, "", err
+		}
+		return user.User{}, makeClientRedirectURL(ses.RedirectURL, code, ses.ClientState).String(), nil
+	}
+
+	// Does the user have an existing account with a different connector?
+	if ses.Identity.Email != "" {
+		existing, err := s.UserRepo.GetByEmail(nil, ses.Identity.Email)
+		switch {
+		case err == user.ErrorNotFound:
+		case err != nil:
+			return user.User{}, "", err
+		case s.trustedEmailProvider(ses.ConnectorID) && existing.EmailVerified && !existing.Disabled:
+			rid := user.RemoteIdentity{
+				ConnectorID: ses.ConnectorID,
+				ID:          ses.Identity.ID,
+			}
+			if err := s.UserManager.AddRemoteIdentity(existing.ID, rid); err != nil {
+				return user.User{}, "", err
+			}
+			sessionLogger(ctx, ses).WithField("user_id", existing.ID).Infof("Remote identity linked by trusted email")
+			return existing, "", nil
+		default:
+			u, err := s.linkLoginURL(ses, existing.ID)
+			if err != nil {
+				return user.User{}, "", err
+			}
+			if u != nil {
+				return user.User{}, u.String(), nil
+			}
+		}
+	}
+
+	// User doesn't have an existing account. Ask them to register.
+	u := newLoginURLFromSession(s.IssuerURL, ses, true, []string{ses.ConnectorID}, "register-maybe")
+	return user.User{}, u.String(), nil
+}
+
+// linkLoginURL returns the URL of the login page asking the user to log in
+// with the connectors of an existing account, carrying a request to link the
+// remote identity of the session to it. nil is returned if the account has no
+// connectors.
+func (s *Server) linkLoginURL(ses *session.Session, userID string) (*url.URL, error) {
+	rids, err := s.UserRepo.GetRemoteIdentities(nil, userID)
+	if err != nil {
+		return nil, err
+	}
+	var connIDs []string
+	seen := make(map[string]struct{})
+	for _, rid := range rids {
+		if _, ok := seen[rid.ConnectorID]; !ok {
+			seen[rid.ConnectorID] = struct{}{}
+			connIDs = append(connIDs, rid.ConnectorID)
+		}
+	}
+	if len(connIDs) == 0 {
+		return nil, nil
+	}
+
+	token, err := s.linkToken(userID, user.RemoteIdentity{
+		ConnectorID: ses.ConnectorID,
+		ID:          ses.Identity.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u := newLoginURLFromSession(s.IssuerURL, ses, false, connIDs, "link-account")
+	q := u.Query()
+	q.Set("link_token", token)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// linkToken returns a signed request to link rid to the given user. Link
+// tokens are addressed to the account pages' client ID, whatever the client
+// of the login, so that no client accepts them as ID tokens.
+func (s *Server) linkToken(userID string, rid user.RemoteIdentity) (string, error) {
+	signer, err := s.KeyManager.Signer()
+	if err != nil {
+		return "", err
+	}
+	link := user.NewRemoteIdentityLink(userID, rid, accountClientID, s.IssuerURL, linkTokenValidity)
+	return link.Token(signer)
+}
+
+func (s *Server) parseLinkToken(token string) (user.RemoteIdentityLink, error) {
+	keys, err := s.KeyManager.PublicKeys()
+	if err != nil {
+		return user.RemoteIdentityLink{}, err
+	}
+	link, err := user.ParseAndVerifyRemoteIdentityLinkToken(token, s.IssuerURL, keys)
+	if err != nil {
+		return user.RemoteIdentityLink{}, err
+	}
+	if link.ClientID() != accountClientID {
+		return user.RemoteIdentityLink{}, errLinkTokenAudience
+	}
+	return link, nil
+}
+
+// linkPendingIdentity links the remote identity requested by the link token
+// of a session to its user, once they have logged in. Invalid requests are
+// logged and ignored, so that they do not stand in the way of the login.
+func (s *Server) linkPendingIdentity(ctx context.Context, ses *session.Session, userID string) error {
+	if ses.LinkToken == "" {
+		return nil
+	}
+	l := sessionLogger(ctx, ses).WithField("user_id", userID)
+
+	link, err := s.parseLinkToken(ses.LinkToken)
+	if err != nil {
+		l.Errorf("Session has an invalid link token: %v", err)
+		return nil
+	}
+	rid := link.RemoteIdentity()
+	if rid.ID == "" {
+		return nil
+	}
+	if link.UserID() != userID {
+		l.Errorf("Session has a link token for another user")
+		return nil
+	}
+
+	err = s.UserManager.AddRemoteIdentity(userID, rid)
+	if err == user.ErrorDuplicateRemoteIdentity {
+		l.Infof("Remote identity already linked: connectorID=%s", rid.ConnectorID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	l.Infof("Remote identity linked: connectorID=%s", rid.ConnectorID)
+	return nil
+}
+
+func (s *Server) trustedEmailProvider(connectorID string) bool {
+	idpc, ok := s.connector(connectorID)
+	return ok && idpc.TrustedEmailProvider()
+}
+
+func (s *Server) connector(connectorID string) (connector.Connector, bool) {
+	for _, idpc := range s.Connectors {
+		if idpc.ID() == connectorID {
+			return idpc, true
+		}
+	}
+	return nil, false
+}
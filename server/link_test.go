@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
+)
+
+// startLogin creates a session for testClientID logging in with the given
+// connector, returning its key.
+func startLogin(t *testing.T, f *testFixtures, connectorID string) string {
+	sessionID, err := f.sessionManager.NewSession(connectorID, testClientID, "bogus", f.redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return key
+}
+
+func TestServerLoginLinkByEmail(t *testing.T) {
+	tests := []struct {
+		connectorID string
+		ident       oidc.Identity
+
+		// wantUserID is the user the identity is linked to right away, if any.
+		wantUserID string
+		// wantLinkTo lists the connectors the user is asked to log in with
+		// otherwise.
+		wantLinkTo string
+	}{
+		{
+			// A trusted connector and a verified email address.
+			connectorID: "oidc-trusted",
+			ident:       oidc.Identity{ID: "new", Email: "Email-Verified@example.com"},
+			wantUserID:  "ID-Verified",
+		},
+		{
+			connectorID: "oidc",
+			ident:       oidc.Identity{ID: "new", Email: "Email-Verified@example.com"},
+			wantLinkTo:  "IDPC-1",
+		},
+		{
+			// The existing user has not verified their email address.
+			connectorID: "oidc-trusted",
+			ident:       oidc.Identity{ID: "new", Email: "Email-1@example.com"},
+			wantLinkTo:  "IDPC-1",
+		},
+	}
+
+	for i, tt := range tests {
+		f, err := makeTestFixtures()
+		if err != nil {
+			t.Fatalf("case %d: error making test fixtures: %v", i, err)
+		}
+
+		redirectURL, err := f.srv.Login(context.Background(), tt.ident, user.Profile{}, startLogin(t, f, tt.connectorID))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		u, err := url.Parse(redirectURL)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		rid := user.RemoteIdentity{ConnectorID: tt.connectorID, ID: tt.ident.ID}
+		usr, err := f.userRepo.GetByRemoteIdentity(nil, rid)
+		if tt.wantUserID != "" {
+			if err != nil || usr.ID != tt.wantUserID {
+				t.Errorf("case %d: want identity linked to %q, got user=%q err=%v", i, tt.wantUserID, usr.ID, err)
+			}
+			if u.Host != f.redirectURL.Host {
+				t.Errorf("case %d: want redirect to client, got %v", i, u)
+			}
+			continue
+		}
+
+		if err != user.ErrorNotFound {
+			t.Errorf("case %d: want identity not linked, got user=%q err=%v", i, usr.ID, err)
+		}
+		q := u.Query()
+		if u.Path != httpPathAuth || q.Get("msg_code") != "link-account" || q.Get("show_connectors") != tt.wantLinkTo || q.Get("link_token") == "" {
+			t.Errorf("case %d: unexpected redirect: %v", i, u)
+		}
+	}
+}
+
+func TestServerLoginLinkAfterLogin(t *testing.T) {
+	tests := []struct {
+		// ident is the identity the user logs in with to link the new one.
+		ident      oidc.Identity
+		wantLinked bool
+	}{
+		{
+			ident:      oidc.Identity{ID: "RID-1"},
+			wantLinked: true,
+		},
+		{
+			// The link was requested for another user.
+			ident: oidc.Identity{ID: "RID-2"},
+		},
+	}
+
+	for i, tt := range tests {
+		f, err := makeTestFixtures()
+		if err != nil {
+			t.Fatalf("case %d: error making test fixtures: %v", i, err)
+		}
+
+		newIdent := oidc.Identity{ID: "new", Email: "Email-1@example.com"}
+		redirectURL, err := f.srv.Login(context.Background(), newIdent, user.Profile{}, startLogin(t, f, "oidc"))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		u, err := url.Parse(redirectURL)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		key, err := f.srv.SetLinkToken(startLogin(t, f, "IDPC-1"), u.Query().Get("link_token"))
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		redirectURL, err = f.srv.Login(context.Background(), tt.ident, user.Profile{}, key)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if u, err = url.Parse(redirectURL); err != nil || u.Host != f.redirectURL.Host {
+			t.Errorf("case %d: want redirect to client, got %q", i, redirectURL)
+		}
+
+		usr, err := f.userRepo.GetByRemoteIdentity(nil, user.RemoteIdentity{ConnectorID: "oidc", ID: "new"})
+		linked := err == nil && usr.ID == "ID-1"
+		if linked != tt.wantLinked {
+			t.Errorf("case %d: want linked=%v, got user=%q err=%v", i, tt.wantLinked, usr.ID, err)
+		}
+	}
+}
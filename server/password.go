@@ -7,7 +7,9 @@ import (
 
 	"github.com/coreos/go-oidc/key"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/client"
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/pkg/log"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
@@ -177,6 +179,10 @@ type resetPasswordTemplateData struct {
 	Token        string
 	DontShowForm bool
 	Success      bool
+
+	// Violations lists the password policy rules the submitted password
+	// failed.
+	Violations []string
 }
 
 type ResetPasswordHandler struct {
@@ -184,6 +190,7 @@ type ResetPasswordHandler struct {
 	issuerURL url.URL
 	um        *manager.UserManager
 	keysFunc  func() ([]key.PublicKey, error)
+	auditSink audit.AuditSink
 }
 
 type resetPasswordRequest struct {
@@ -236,6 +243,15 @@ func (r *resetPasswordRequest) handlePOST() {
 
 	plaintext := r.r.FormValue("password")
 	cbURL, err := r.h.um.ChangePassword(r.pwReset, plaintext)
+	if perr, ok := err.(user.PasswordPolicyError); ok {
+		r.data.Error = "Invalid Password"
+		r.data.Message = "Please choose a password which meets the following requirements."
+		for _, v := range perr.Violations {
+			r.data.Violations = append(r.data.Violations, v.Message)
+		}
+		execTemplateWithStatus(r.w, r.h.tpl, r.data, http.StatusBadRequest)
+		return
+	}
 	if err != nil {
 		switch err {
 		case manager.ErrorPasswordAlreadyChanged:
@@ -246,7 +262,7 @@ func (r *resetPasswordRequest) handlePOST() {
 			return
 		case user.ErrorInvalidPassword:
 			r.data.Error = "Invalid Password"
-			r.data.Message = "Please choose a password which is at least six characters."
+			r.data.Message = "Please choose a password."
 			execTemplateWithStatus(r.w, r.h.tpl, r.data, http.StatusBadRequest)
 			return
 		default:
@@ -256,6 +272,12 @@ func (r *resetPasswordRequest) handlePOST() {
 			return
 		}
 	}
+	audit.Record(r.h.auditSink, audit.Event{
+		Type:       audit.EventPasswordReset,
+		UserID:     r.pwReset.UserID(),
+		RemoteAddr: phttp.RemoteIP(r.r),
+	})
+
 	if cbURL == nil {
 		r.data.Success = true
 		execTemplate(r.w, r.h.tpl, r.data)
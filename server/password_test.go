@@ -2,6 +2,7 @@ package server
 
 import (
 	"bytes"
+	"context"
 	htmltemplate "html/template"
 	"net/http"
 	"net/http/httptest"
@@ -253,7 +254,7 @@ func TestSendResetPasswordEmailHandler(t *testing.T) {
 			t.Fatalf("case %d: could not make test fixtures: %v", i, err)
 		}
 
-		_, err = f.srv.NewSession("local", "XXX", "", f.redirectURL, "", true, []string{"openid"})
+		_, err = f.srv.NewSession(context.Background(), "local", "XXX", "", f.redirectURL, "", true, []string{"openid"})
 		if err != nil {
 			t.Fatalf("case %d: could not create new session: %v", i, err)
 		}
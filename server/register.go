@@ -8,7 +8,7 @@ import (
 	"strings"
 
 	"github.com/coreos/dex/connector"
-	"github.com/coreos/dex/pkg/log"
+	phttp "github.com/coreos/dex/pkg/http"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
 	"github.com/coreos/dex/user/manager"
@@ -65,8 +65,8 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 		execTemplateWithStatus(w, tpl, data, status)
 	}
 
-	internalError := func(w http.ResponseWriter, err error) {
-		log.Errorf("Internal Error during registration: %v", err)
+	internalError := func(w http.ResponseWriter, r *http.Request, err error) {
+		phttp.Logger(r).Errorf("Internal Error during registration: %v", err)
 		errPage(w, "There was a problem processing your request.", "", http.StatusInternalServerError)
 	}
 
@@ -75,7 +75,7 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		err := r.ParseForm()
 		if err != nil {
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 
@@ -90,7 +90,7 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 		// create a new code for them to use next time they hit the server.
 		code, err := s.SessionManager.NewSessionKey(sessionID)
 		if err != nil {
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 		ses, err := s.SessionManager.Get(sessionID)
@@ -101,27 +101,27 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 		var exists bool
 		exists, err = remoteIdentityExists(s.UserRepo, ses.ConnectorID, ses.Identity.ID)
 		if err != nil {
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 
 		if exists {
 			// we have to create a new session to be able to run the server.Login function
-			newSessionKey, err := s.NewSession(ses.ConnectorID, ses.ClientID,
+			newSessionKey, err := s.NewSession(r.Context(), ses.ConnectorID, ses.ClientID,
 				ses.ClientState, ses.RedirectURL, ses.Nonce, false, ses.Scope)
 			if err != nil {
-				internalError(w, err)
+				internalError(w, r, err)
 				return
 			}
 			// make sure to clean up the old session
 			if err = s.KillSession(code); err != nil {
-				internalError(w, err)
+				internalError(w, r, err)
 			}
 
 			// finally, we can create a valid redirect URL for them.
-			redirURL, err := s.Login(ses.Identity, newSessionKey)
+			redirURL, err := s.Login(r.Context(), ses.Identity, ses.Profile, newSessionKey)
 			if err != nil {
-				internalError(w, err)
+				internalError(w, r, err)
 				return
 			}
 
@@ -142,7 +142,7 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 		// to be registered.
 		idpc, ok := idx[ses.ConnectorID]
 		if !ok {
-			internalError(w, fmt.Errorf("no such IDPC: %v", ses.ConnectorID))
+			internalError(w, r, fmt.Errorf("no such IDPC: %v", ses.ConnectorID))
 			return
 		}
 		_, local := idpc.(*connector.LocalConnector)
@@ -213,12 +213,12 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 			// In this case, the user probably just forgot that they registered.
 			connID, err := getConnectorForUserByEmail(s.UserRepo, email)
 			if err != nil {
-				internalError(w, err)
+				internalError(w, r, err)
 			}
 			loginURL := newLoginURLFromSession(
 				s.IssuerURL, ses, false, []string{connID}, "login-maybe")
 			if err = s.KillSession(code); err != nil {
-				log.Errorf("Error killing session: %v", err)
+				sessionLogger(r.Context(), ses).Errorf("Error killing session: %v", err)
 			}
 			http.Redirect(w, r, loginURL.String(), http.StatusSeeOther)
 			return
@@ -235,18 +235,18 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 				errPage(w, "You already registered an account with this identity", "", http.StatusConflict)
 				return
 			}
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 		ses, err = s.SessionManager.AttachUser(sessionID, userID)
 		if err != nil {
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 
 		usr, err := s.UserRepo.Get(nil, userID)
 		if err != nil {
-			internalError(w, err)
+			internalError(w, r, err)
 			return
 		}
 
@@ -254,10 +254,29 @@ func handleRegisterFunc(s *Server, tpl Template) http.HandlerFunc {
 			_, err = s.UserEmailer.SendEmailVerification(usr.ID, ses.ClientID, ses.RedirectURL)
 
 			if err != nil {
-				log.Errorf("Error sending email verification: %v", err)
+				sessionLogger(r.Context(), ses).Errorf("Error sending email verification: %v", err)
 			}
 		}
 
+		if local {
+			if ses, err = s.SessionManager.AddAuthMethods(sessionID, amrPassword); err != nil {
+				internalError(w, r, err)
+				return
+			}
+		}
+
+		required, err := s.webAuthnRequired(ses, usr.ID)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		if required {
+			u := s.webAuthnURL(code)
+			w.Header().Set("Location", u.String())
+			w.WriteHeader(http.StatusSeeOther)
+			return
+		}
+
 		w.Header().Set("Location", makeClientRedirectURL(
 			ses.RedirectURL, code, ses.ClientState).String())
 		w.WriteHeader(http.StatusSeeOther)
@@ -282,7 +301,7 @@ func registerFromLocalConnector(userManager *manager.UserManager, sessionManager
 
 	ses, err = sessionManager.AttachRemoteIdentity(ses.ID, oidc.Identity{
 		ID: userID,
-	})
+	}, user.Profile{})
 	if err != nil {
 		return "", err
 	}
@@ -301,12 +320,21 @@ func registerFromRemoteConnector(userManager *manager.UserManager, ses *session.
 	if err != nil {
 		return "", err
 	}
+	if _, err := userManager.RefreshProfile(userID, ses.Profile); err != nil {
+		return "", err
+	}
 
 	return userID, nil
 }
 
 func errToFormErrors(err error) []formError {
 	fes := []formError{}
+	if perr, ok := err.(user.PasswordPolicyError); ok {
+		for _, v := range perr.Violations {
+			fes = append(fes, formError{Field: "password", Error: v.Message})
+		}
+		return fes
+	}
 	fe, ok := errToFormErrorMap[err]
 	if ok {
 		fes = append(fes, fe)
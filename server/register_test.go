@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -295,7 +296,7 @@ func TestHandleRegister(t *testing.T) {
 				})
 		}
 
-		key, err := f.srv.NewSession(tt.connID, "XXX", "", f.redirectURL, "", true, []string{"openid"})
+		key, err := f.srv.NewSession(context.Background(), tt.connID, "XXX", "", f.redirectURL, "", true, []string{"openid"})
 		t.Logf("case %d: key for NewSession: %v", i, key)
 
 		if tt.attachRemote {
@@ -311,7 +312,7 @@ func TestHandleRegister(t *testing.T) {
 			_, err = f.sessionManager.AttachRemoteIdentity(ses.ID, oidc.Identity{
 				ID:    "remoteID",
 				Email: tt.remoteIdentityEmail,
-			})
+			}, user.Profile{})
 
 			key, err := f.sessionManager.NewSessionKey(sesID)
 			if err != nil {
@@ -382,3 +383,35 @@ func newURLWithParams(u url.URL, values url.Values) *url.URL {
 	newU.RawQuery = values.Encode()
 	return &newU
 }
+
+func TestErrToFormErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		want []formError
+	}{
+		{
+			err:  user.ErrorInvalidEmail,
+			want: []formError{errToFormErrorMap[user.ErrorInvalidEmail]},
+		},
+		{
+			err: user.PasswordPolicyError{Violations: []user.PasswordViolation{
+				{Rule: "min_length", Message: "too short"},
+				{Rule: "dictionary", Message: "too common"},
+			}},
+			want: []formError{
+				{Field: "password", Error: "too short"},
+				{Field: "password", Error: "too common"},
+			},
+		},
+		{
+			err:  errors.New("other"),
+			want: []formError{},
+		},
+	}
+
+	for i, tt := range tests {
+		if diff := pretty.Compare(tt.want, errToFormErrors(tt.err)); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
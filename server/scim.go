@@ -0,0 +1,601 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+
+	"github.com/coreos/dex/client"
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/scim"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
+)
+
+const (
+	// scimUserPageSize is the number of users read from the UserManager at
+	// a time when answering queries.
+	scimUserPageSize = 100
+)
+
+var (
+	scimUserAttrs  = []string{"id", "userName", "displayName", "name.formatted", "emails", "emails.value", "active", "meta.created", "groups", "groups.value"}
+	scimGroupAttrs = []string{"id", "displayName", "members", "members.value", "meta.created"}
+)
+
+// scimServer serves the SCIM 2.0 API through which identity management
+// systems provision users and groups. Callers authenticate as admin clients.
+type scimServer struct {
+	// baseURL is the absolute URL of the SCIM API, used to refer to
+	// resources.
+	baseURL string
+
+	um               *manager.UserManager
+	cir              client.ClientIdentityRepo
+	localConnectorID string
+}
+
+func (s *scimServer) HTTPHandler() http.Handler {
+	r := httprouter.New()
+	r.RedirectTrailingSlash = false
+	r.RedirectFixedPath = false
+	r.GET(path.Join(httpPathSCIM, "ServiceProviderConfig"), s.authorize(s.getServiceProviderConfig))
+	r.GET(path.Join(httpPathSCIM, "ResourceTypes"), s.authorize(s.listResourceTypes))
+	r.GET(path.Join(httpPathSCIM, "ResourceTypes/:id"), s.authorize(s.getResourceType))
+	r.GET(path.Join(httpPathSCIM, "Schemas"), s.authorize(s.listSchemas))
+	r.GET(path.Join(httpPathSCIM, "Schemas/:id"), s.authorize(s.getSchema))
+	r.GET(path.Join(httpPathSCIM, "Users"), s.authorize(s.listUsers))
+	r.POST(path.Join(httpPathSCIM, "Users"), s.authorize(s.createUser))
+	r.GET(path.Join(httpPathSCIM, "Users/:id"), s.authorize(s.getUser))
+	r.PUT(path.Join(httpPathSCIM, "Users/:id"), s.authorize(s.replaceUser))
+	r.PATCH(path.Join(httpPathSCIM, "Users/:id"), s.authorize(s.patchUser))
+	r.DELETE(path.Join(httpPathSCIM, "Users/:id"), s.authorize(s.deleteUser))
+	r.GET(path.Join(httpPathSCIM, "Groups"), s.authorize(s.listGroups))
+	r.POST(path.Join(httpPathSCIM, "Groups"), s.authorize(s.createGroup))
+	r.GET(path.Join(httpPathSCIM, "Groups/:id"), s.authorize(s.getGroup))
+	r.PUT(path.Join(httpPathSCIM, "Groups/:id"), s.authorize(s.replaceGroup))
+	r.PATCH(path.Join(httpPathSCIM, "Groups/:id"), s.authorize(s.patchGroup))
+	r.DELETE(path.Join(httpPathSCIM, "Groups/:id"), s.authorize(s.deleteGroup))
+	r.NotFound = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeSCIMError(w, scim.NewError(http.StatusNotFound, "", "no such endpoint"))
+	})
+	return r
+}
+
+// authorize wraps handle so that it is only called for requests of admin
+// clients. The bearer token of the request must have been verified by a
+// clientTokenMiddleware already.
+func (s *scimServer) authorize(handle httprouter.Handle) httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		clientID, err := getClientIDFromAuthorizedRequest(r)
+		if err != nil {
+			log.Errorf("Failed to extract client ID from request: %v", err)
+			writeSCIMError(w, scim.NewError(http.StatusUnauthorized, "", "missing or invalid token"))
+			return
+		}
+
+		isAdmin, err := s.cir.IsDexAdmin(clientID)
+		if err != nil {
+			log.Errorf("Failed checking admin status of client %s: %v", clientID, err)
+			writeSCIMError(w, scim.NewError(http.StatusInternalServerError, "", ""))
+			return
+		}
+		if !isAdmin {
+			writeSCIMError(w, scim.NewError(http.StatusForbidden, "", "only admin clients may provision users"))
+			return
+		}
+
+		handle(w, r, ps)
+	}
+}
+
+func (s *scimServer) getServiceProviderConfig(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	writeSCIMResponse(w, http.StatusOK, scim.NewServiceProviderConfig(s.baseURL))
+}
+
+func (s *scimServer) listResourceTypes(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	rts := scim.NewResourceTypes(s.baseURL)
+	writeSCIMResponse(w, http.StatusOK, scim.NewListResponse(rts, len(rts), len(rts), 1))
+}
+
+func (s *scimServer) getResourceType(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	for _, rt := range scim.NewResourceTypes(s.baseURL) {
+		if rt.ID == ps.ByName("id") {
+			writeSCIMResponse(w, http.StatusOK, rt)
+			return
+		}
+	}
+	writeSCIMError(w, scim.NewError(http.StatusNotFound, "", "resource type not found"))
+}
+
+func (s *scimServer) listSchemas(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	schemas := scim.NewSchemas(s.baseURL)
+	writeSCIMResponse(w, http.StatusOK, scim.NewListResponse(schemas, len(schemas), len(schemas), 1))
+}
+
+func (s *scimServer) getSchema(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	for _, schema := range scim.NewSchemas(s.baseURL) {
+		if schema.ID == ps.ByName("id") {
+			writeSCIMResponse(w, http.StatusOK, schema)
+			return
+		}
+	}
+	writeSCIMError(w, scim.NewError(http.StatusNotFound, "", "schema not found"))
+}
+
+func (s *scimServer) listUsers(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q, err := parseSCIMQuery(r, scimUserAttrs)
+	if err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+
+	usrs, err := s.matchingUsers(q.filter)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+
+	resources := []scim.User{}
+	for _, i := range q.page(len(usrs)) {
+		u, err := s.scimUser(usrs[i])
+		if err != nil {
+			s.writeUserError(w, err)
+			return
+		}
+		resources = append(resources, u)
+	}
+	writeSCIMResponse(w, http.StatusOK, scim.NewListResponse(resources, len(resources), len(usrs), q.startIndex))
+}
+
+// matchingUsers returns all users matching f, which may be nil.
+func (s *scimServer) matchingUsers(f scim.Filter) ([]user.User, error) {
+	filter := userFilterFromSCIM(f)
+
+	var usrs []user.User
+	var tok string
+	for {
+		page, next, err := s.um.List(filter, scimUserPageSize, tok)
+		if err == user.ErrorNotFound {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, usr := range page {
+			if f == nil || f.Match(s.userValues(usr)) {
+				usrs = append(usrs, usr)
+			}
+		}
+		if next == "" {
+			break
+		}
+		tok = next
+	}
+	return usrs, nil
+}
+
+// userFilterFromSCIM returns a user.UserFilter selecting a superset of the
+// users matching f, so that fewer users have to be matched against f.
+func userFilterFromSCIM(f scim.Filter) user.UserFilter {
+	var filter user.UserFilter
+	if f == nil {
+		return filter
+	}
+	for _, c := range scim.Conjuncts(f) {
+		switch {
+		case (c.Attr == "username" || c.Attr == "emails" || c.Attr == "emails.value") &&
+			(c.Op == scim.OpEqual || c.Op == scim.OpStartsWith):
+			filter.EmailPrefix = c.Value
+		case (c.Attr == "displayname" || c.Attr == "name.formatted") &&
+			(c.Op == scim.OpEqual || c.Op == scim.OpStartsWith || c.Op == scim.OpContains):
+			filter.DisplayName = c.Value
+		case c.Attr == "active" && c.Op == scim.OpEqual && (c.Value == "true" || c.Value == "false"):
+			disabled := c.Value == "false"
+			filter.Disabled = &disabled
+		}
+	}
+	return filter
+}
+
+// userValues returns the SCIM attribute values of usr, for matching filters.
+func (s *scimServer) userValues(usr user.User) func(string) []string {
+	return func(attr string) []string {
+		switch attr {
+		case "id":
+			return []string{usr.ID}
+		case "username", "emails", "emails.value":
+			return []string{usr.Email}
+		case "displayname", "name.formatted":
+			return []string{usr.DisplayName}
+		case "active":
+			return []string{strconv.FormatBool(!usr.Disabled)}
+		case "meta.created":
+			return []string{usr.CreatedAt.UTC().Format(time.RFC3339)}
+		case "groups", "groups.value":
+			groups, err := s.um.GetGroupsOfUser(usr.ID)
+			if err != nil {
+				log.Errorf("Failed getting groups of user %s: %v", usr.ID, err)
+				return nil
+			}
+			var ids []string
+			for _, g := range groups {
+				ids = append(ids, g.ID)
+			}
+			return ids
+		}
+		return nil
+	}
+}
+
+func (s *scimServer) getUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	usr, err := s.um.Get(ps.ByName("id"))
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.writeUser(w, http.StatusOK, usr)
+}
+
+func (s *scimServer) createUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var u scim.User
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+	if u.Email() == "" {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidValue, "userName is required"))
+		return
+	}
+
+	// Provisioned users have no usable password; they set one through the
+	// password reset flow or log in through another connector.
+	b, err := pcrypto.RandBytes(32)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	usr := user.User{
+		Email:       u.Email(),
+		DisplayName: u.FullName(),
+		Disabled:    !u.IsActive(),
+	}
+	id, err := s.um.CreateUser(usr, user.Password(base64.URLEncoding.EncodeToString(b)), s.localConnectorID)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+
+	usr, err = s.um.Get(id)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	w.Header().Set("Location", s.baseURL+"/Users/"+id)
+	s.writeUser(w, http.StatusCreated, usr)
+}
+
+func (s *scimServer) replaceUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var u scim.User
+	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+
+	usr, err := s.um.Get(ps.ByName("id"))
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	s.saveUser(w, usr, u)
+}
+
+func (s *scimServer) patchUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req scim.PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+
+	usr, err := s.um.Get(ps.ByName("id"))
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+
+	u := scim.NewUser(usr, nil, s.baseURL)
+	if err := u.Apply(req.Operations); err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+	s.saveUser(w, usr, u)
+}
+
+// saveUser stores the changes made to usr in u and writes the updated user.
+func (s *scimServer) saveUser(w http.ResponseWriter, usr user.User, u scim.User) {
+	if u.Email() == "" {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidValue, "userName is required"))
+		return
+	}
+
+	var err error
+	if u.Email() != usr.Email || u.FullName() != usr.DisplayName {
+		usr, err = s.um.UpdateProfile(usr.ID, u.FullName(), u.Email())
+		if err != nil {
+			s.writeUserError(w, err)
+			return
+		}
+	}
+	if disabled := !u.IsActive(); disabled != usr.Disabled {
+		if err := s.um.Disable(usr.ID, disabled); err != nil {
+			s.writeUserError(w, err)
+			return
+		}
+		usr.Disabled = disabled
+	}
+	s.writeUser(w, http.StatusOK, usr)
+}
+
+func (s *scimServer) deleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := s.um.Delete(ps.ByName("id")); err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *scimServer) scimUser(usr user.User) (scim.User, error) {
+	groups, err := s.um.GetGroupsOfUser(usr.ID)
+	if err != nil && err != manager.ErrorGroupsUnavailable {
+		return scim.User{}, err
+	}
+	return scim.NewUser(usr, groups, s.baseURL), nil
+}
+
+func (s *scimServer) writeUser(w http.ResponseWriter, code int, usr user.User) {
+	u, err := s.scimUser(usr)
+	if err != nil {
+		s.writeUserError(w, err)
+		return
+	}
+	writeSCIMResponse(w, code, u)
+}
+
+func (s *scimServer) writeUserError(w http.ResponseWriter, err error) {
+	switch err {
+	case user.ErrorNotFound:
+		writeSCIMError(w, scim.NewError(http.StatusNotFound, "", "user not found"))
+	case user.ErrorDuplicateEmail:
+		writeSCIMError(w, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "userName not available"))
+	case user.ErrorInvalidEmail:
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidValue, "userName must be an email address"))
+	default:
+		log.Errorf("SCIM user request failed: %v", err)
+		writeSCIMError(w, scim.NewError(http.StatusInternalServerError, "", ""))
+	}
+}
+
+func (s *scimServer) listGroups(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	q, err := parseSCIMQuery(r, scimGroupAttrs)
+	if err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+
+	all, err := s.um.ListGroups()
+	if err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+	var groups []user.Group
+	for _, g := range all {
+		if q.filter == nil || q.filter.Match(groupValues(g)) {
+			groups = append(groups, g)
+		}
+	}
+
+	resources := []scim.Group{}
+	for _, i := range q.page(len(groups)) {
+		resources = append(resources, scim.NewGroup(groups[i], s.baseURL))
+	}
+	writeSCIMResponse(w, http.StatusOK, scim.NewListResponse(resources, len(resources), len(groups), q.startIndex))
+}
+
+// groupValues returns the SCIM attribute values of g, for matching filters.
+func groupValues(g user.Group) func(string) []string {
+	return func(attr string) []string {
+		switch attr {
+		case "id":
+			return []string{g.ID}
+		case "displayname":
+			return []string{g.DisplayName}
+		case "members", "members.value":
+			return g.Members
+		case "meta.created":
+			return []string{g.CreatedAt.UTC().Format(time.RFC3339)}
+		}
+		return nil
+	}
+}
+
+func (s *scimServer) getGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	g, err := s.um.GetGroup(ps.ByName("id"))
+	if err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+	writeSCIMResponse(w, http.StatusOK, scim.NewGroup(g, s.baseURL))
+}
+
+func (s *scimServer) createGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var sg scim.Group
+	if err := json.NewDecoder(r.Body).Decode(&sg); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+
+	g, err := s.um.CreateGroup(sg.DisplayName, sg.MemberIDs())
+	if err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+	w.Header().Set("Location", s.baseURL+"/Groups/"+g.ID)
+	writeSCIMResponse(w, http.StatusCreated, scim.NewGroup(g, s.baseURL))
+}
+
+func (s *scimServer) replaceGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var sg scim.Group
+	if err := json.NewDecoder(r.Body).Decode(&sg); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+	s.saveGroup(w, ps.ByName("id"), sg)
+}
+
+func (s *scimServer) patchGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	var req scim.PatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidSyntax, "cannot parse JSON body"))
+		return
+	}
+
+	g, err := s.um.GetGroup(ps.ByName("id"))
+	if err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+
+	sg := scim.NewGroup(g, s.baseURL)
+	if err := sg.Apply(req.Operations); err != nil {
+		writeSCIMError(w, err)
+		return
+	}
+	s.saveGroup(w, g.ID, sg)
+}
+
+func (s *scimServer) saveGroup(w http.ResponseWriter, id string, sg scim.Group) {
+	g, err := s.um.UpdateGroup(user.Group{
+		ID:          id,
+		DisplayName: sg.DisplayName,
+		Members:     sg.MemberIDs(),
+	})
+	if err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+	writeSCIMResponse(w, http.StatusOK, scim.NewGroup(g, s.baseURL))
+}
+
+func (s *scimServer) deleteGroup(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+	if err := s.um.DeleteGroup(ps.ByName("id")); err != nil {
+		s.writeGroupError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *scimServer) writeGroupError(w http.ResponseWriter, err error) {
+	switch err {
+	case user.ErrorGroupNotFound:
+		writeSCIMError(w, scim.NewError(http.StatusNotFound, "", "group not found"))
+	case user.ErrorNotFound:
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidValue, "member not found"))
+	case user.ErrorDuplicateGroupName:
+		writeSCIMError(w, scim.NewError(http.StatusConflict, scim.ErrorTypeUniqueness, "displayName not available"))
+	case user.ErrorInvalidGroupName:
+		writeSCIMError(w, scim.BadRequest(scim.ErrorTypeInvalidValue, "displayName is required"))
+	default:
+		log.Errorf("SCIM group request failed: %v", err)
+		writeSCIMError(w, scim.NewError(http.StatusInternalServerError, "", ""))
+	}
+}
+
+// scimQuery holds the filter and pagination parameters of a SCIM query.
+type scimQuery struct {
+	filter scim.Filter
+
+	// startIndex is the 1-based index of the first result to return.
+	startIndex int
+	count      int
+}
+
+func parseSCIMQuery(r *http.Request, attrs []string) (scimQuery, error) {
+	q := scimQuery{startIndex: 1, count: scim.MaxResults}
+	v := r.URL.Query()
+
+	if f := strings.TrimSpace(v.Get("filter")); f != "" {
+		filter, err := scim.ParseFilter(f, attrs...)
+		if err != nil {
+			return scimQuery{}, err
+		}
+		q.filter = filter
+	}
+
+	if si := v.Get("startIndex"); si != "" {
+		i, err := strconv.Atoi(si)
+		if err != nil {
+			return scimQuery{}, scim.BadRequest(scim.ErrorTypeInvalidValue, "startIndex must be an integer")
+		}
+		if i > 1 {
+			q.startIndex = i
+		}
+	}
+
+	if c := v.Get("count"); c != "" {
+		i, err := strconv.Atoi(c)
+		if err != nil {
+			return scimQuery{}, scim.BadRequest(scim.ErrorTypeInvalidValue, "count must be an integer")
+		}
+		switch {
+		case i < 0:
+			q.count = 0
+		case i < scim.MaxResults:
+			q.count = i
+		}
+	}
+	return q, nil
+}
+
+// page returns the indices of the results to return out of total results.
+func (q scimQuery) page(total int) []int {
+	var indices []int
+	for i := q.startIndex - 1; i < total && len(indices) < q.count; i++ {
+		indices = append(indices, i)
+	}
+	return indices
+}
+
+func writeSCIMResponse(w http.ResponseWriter, code int, resp interface{}) {
+	enc, err := json.Marshal(resp)
+	if err != nil {
+		log.Errorf("Failed JSON-encoding SCIM response: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", scim.ContentType)
+	w.WriteHeader(code)
+	if _, err = w.Write(enc); err != nil {
+		log.Errorf("Failed writing SCIM response: %v", err)
+	}
+}
+
+// writeSCIMError writes err, which should be a *scim.Error.
+func writeSCIMError(w http.ResponseWriter, err error) {
+	serr, ok := err.(*scim.Error)
+	if !ok {
+		log.Errorf("SCIM request failed: %v", err)
+		serr = scim.NewError(http.StatusInternalServerError, "", "")
+	}
+	code, convErr := strconv.Atoi(serr.Status)
+	if convErr != nil {
+		code = http.StatusInternalServerError
+	}
+	writeSCIMResponse(w, code, serr)
+}
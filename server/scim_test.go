@@ -0,0 +1,430 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/client"
+	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/scim"
+	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
+)
+
+const scimTestBaseURL = "https://dex.example.com/scim/v2"
+
+func makeSCIMTestFixtures(t *testing.T) (*scimServer, *manager.UserManager) {
+	created := time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC)
+	ur := user.NewUserRepoFromUsers([]user.UserWithRemoteIdentities{
+		{
+			User: user.User{ID: "ID-1", Email: "jane@example.com", DisplayName: "Jane Doe", CreatedAt: created},
+			RemoteIdentities: []user.RemoteIdentity{
+				{ConnectorID: "local", ID: "ID-1"},
+			},
+		},
+		{
+			User: user.User{ID: "ID-2", Email: "john@example.com", Disabled: true, CreatedAt: created},
+			RemoteIdentities: []user.RemoteIdentity{
+				{ConnectorID: "local", ID: "ID-2"},
+			},
+		},
+	})
+	pwr := user.NewPasswordInfoRepo()
+	ccr := connector.NewConnectorConfigRepoFromConfigs([]connector.ConnectorConfig{
+		&connector.LocalConnectorConfig{ID: "local"},
+	})
+	gr := user.NewGroupRepo()
+	if err := gr.Create(nil, user.Group{ID: "group-1", DisplayName: "Engineering", Members: []string{"ID-1"}, CreatedAt: created}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	um := manager.NewUserManager(ur, pwr, ccr, repo.InMemTransactionFactory, manager.ManagerOptions{
+		GroupRepo: gr,
+	})
+
+	fooURL := url.URL{Scheme: "https", Host: "foo.example.com", Path: "/callback"}
+	cir := client.NewClientIdentityRepo([]oidc.ClientIdentity{
+		{
+			Credentials: oidc.ClientCredentials{ID: "foo", Secret: "foo-secret"},
+			Metadata:    oidc.ClientMetadata{RedirectURIs: []url.URL{fooURL}},
+		},
+		{
+			Credentials: oidc.ClientCredentials{ID: "admin", Secret: "admin-secret"},
+			Metadata:    oidc.ClientMetadata{RedirectURIs: []url.URL{fooURL}},
+		},
+	})
+	if err := cir.SetDexAdmin("admin", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return &scimServer{
+		baseURL:          scimTestBaseURL,
+		um:               um,
+		cir:              cir,
+		localConnectorID: "local",
+	}, um
+}
+
+func TestSCIMServer(t *testing.T) {
+	makeToken := makeSCIMTokenFunc(t)
+
+	jane := scim.NewUser(user.User{
+		ID:          "ID-1",
+		Email:       "jane@example.com",
+		DisplayName: "Jane Doe",
+		CreatedAt:   time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC),
+	}, []user.Group{{ID: "group-1", DisplayName: "Engineering"}}, scimTestBaseURL)
+
+	tests := []struct {
+		method string
+		path   string
+		caller string
+		body   string
+
+		wantCode int
+		// wantIDs are the IDs of the resources listed in the response.
+		wantIDs   []string
+		wantTotal int
+		wantUser  *scim.User
+		// wantDisabled is the disabled state of user ID-1 after the request.
+		wantDisabled bool
+		wantMembers  []string
+	}{
+		// only admin clients are authorized
+		{
+			method:      "GET",
+			path:        "/scim/v2/Users",
+			caller:      "foo",
+			wantCode:    http.StatusForbidden,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Users",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"ID-1", "ID-2"},
+			wantTotal:   2,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        `/scim/v2/Users?filter=` + url.QueryEscape(`userName eq "JANE@example.com"`),
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"ID-1"},
+			wantTotal:   1,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        `/scim/v2/Users?filter=` + url.QueryEscape(`active eq false`),
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"ID-2"},
+			wantTotal:   1,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        `/scim/v2/Users?filter=` + url.QueryEscape(`groups.value eq "group-1"`),
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"ID-1"},
+			wantTotal:   1,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Users?startIndex=2&count=5",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"ID-2"},
+			wantTotal:   2,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        `/scim/v2/Users?filter=` + url.QueryEscape(`externalId eq "1"`),
+			caller:      "admin",
+			wantCode:    http.StatusBadRequest,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Users/ID-1",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantUser:    &jane,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Users/ID-3",
+			caller:      "admin",
+			wantCode:    http.StatusNotFound,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "POST",
+			path:        "/scim/v2/Users",
+			caller:      "admin",
+			body:        `{"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"], "userName": "jane@example.com"}`,
+			wantCode:    http.StatusConflict,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "POST",
+			path:        "/scim/v2/Users",
+			caller:      "admin",
+			body:        `{"userName": "jane"}`,
+			wantCode:    http.StatusBadRequest,
+			wantMembers: []string{"ID-1"},
+		},
+		// deprovisioning
+		{
+			method:       "PATCH",
+			path:         "/scim/v2/Users/ID-1",
+			caller:       "admin",
+			body:         `{"schemas": ["urn:ietf:params:scim:api:messages:2.0:PatchOp"], "Operations": [{"op": "replace", "path": "active", "value": false}]}`,
+			wantCode:     http.StatusOK,
+			wantDisabled: true,
+			wantMembers:  []string{"ID-1"},
+		},
+		{
+			method:      "PATCH",
+			path:        "/scim/v2/Users/ID-1",
+			caller:      "admin",
+			body:        `{"Operations": [{"op": "replace", "path": "userName", "value": "john@example.com"}]}`,
+			wantCode:    http.StatusConflict,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:       "PUT",
+			path:         "/scim/v2/Users/ID-1",
+			caller:       "admin",
+			body:         `{"userName": "jane@example.com", "displayName": "Jane Doe", "active": false}`,
+			wantCode:     http.StatusOK,
+			wantDisabled: true,
+			wantMembers:  []string{"ID-1"},
+		},
+		{
+			method:   "DELETE",
+			path:     "/scim/v2/Users/ID-1",
+			caller:   "admin",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Groups",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{"group-1"},
+			wantTotal:   1,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        `/scim/v2/Groups?filter=` + url.QueryEscape(`displayName eq "Sales"`),
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantIDs:     []string{},
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "PATCH",
+			path:        "/scim/v2/Groups/group-1",
+			caller:      "admin",
+			body:        `{"Operations": [{"op": "add", "path": "members", "value": [{"value": "ID-2"}]}]}`,
+			wantCode:    http.StatusOK,
+			wantMembers: []string{"ID-1", "ID-2"},
+		},
+		{
+			method:      "PATCH",
+			path:        "/scim/v2/Groups/group-1",
+			caller:      "admin",
+			body:        `{"Operations": [{"op": "add", "path": "members", "value": [{"value": "ID-3"}]}]}`,
+			wantCode:    http.StatusBadRequest,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "POST",
+			path:        "/scim/v2/Groups",
+			caller:      "admin",
+			body:        `{"displayName": "Engineering"}`,
+			wantCode:    http.StatusConflict,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:   "DELETE",
+			path:     "/scim/v2/Groups/group-1",
+			caller:   "admin",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/ServiceProviderConfig",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Schemas/urn:ietf:params:scim:schemas:core:2.0:Group",
+			caller:      "admin",
+			wantCode:    http.StatusOK,
+			wantMembers: []string{"ID-1"},
+		},
+		{
+			method:      "GET",
+			path:        "/scim/v2/Bulk",
+			caller:      "admin",
+			wantCode:    http.StatusNotFound,
+			wantMembers: []string{"ID-1"},
+		},
+	}
+
+	for i, tt := range tests {
+		s, um := makeSCIMTestFixtures(t)
+		h := s.HTTPHandler()
+
+		r, err := http.NewRequest(tt.method, "http://example.com"+tt.path, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatalf("Failed creating http.Request: %v", err)
+		}
+		r.Header.Set("Authorization", "Bearer "+makeToken(tt.caller))
+		r.Header.Set("Content-Type", scim.ContentType)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+
+		if w.Code != tt.wantCode {
+			t.Errorf("case %d: invalid response code, want=%d, got=%d: %s", i, tt.wantCode, w.Code, w.Body.String())
+			continue
+		}
+		if w.Code != http.StatusNoContent && w.HeaderMap.Get("Content-Type") != scim.ContentType {
+			t.Errorf("case %d: want Content-Type %s, got %s", i, scim.ContentType, w.HeaderMap.Get("Content-Type"))
+		}
+
+		if tt.wantIDs != nil {
+			var resp struct {
+				TotalResults int
+				Resources    []struct{ ID string }
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Errorf("case %d: unexpected error=%v", i, err)
+				continue
+			}
+			gotIDs := []string{}
+			for _, r := range resp.Resources {
+				gotIDs = append(gotIDs, r.ID)
+			}
+			if diff := pretty.Compare(tt.wantIDs, gotIDs); diff != "" {
+				t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+			}
+			if resp.TotalResults != tt.wantTotal {
+				t.Errorf("case %d: want totalResults=%d, got %d", i, tt.wantTotal, resp.TotalResults)
+			}
+		}
+
+		if tt.wantUser != nil {
+			var u scim.User
+			if err := json.Unmarshal(w.Body.Bytes(), &u); err != nil {
+				t.Errorf("case %d: unexpected error=%v", i, err)
+				continue
+			}
+			if diff := pretty.Compare(*tt.wantUser, u); diff != "" {
+				t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+			}
+		}
+
+		if usr, err := um.Get("ID-1"); err == nil && usr.Disabled != tt.wantDisabled {
+			t.Errorf("case %d: want disabled=%v, got %v", i, tt.wantDisabled, usr.Disabled)
+		}
+
+		var gotMembers []string
+		if g, err := um.GetGroup("group-1"); err == nil {
+			gotMembers = g.Members
+		}
+		if diff := pretty.Compare(tt.wantMembers, gotMembers); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestSCIMCreateUser(t *testing.T) {
+	s, um := makeSCIMTestFixtures(t)
+	makeToken := makeSCIMTokenFunc(t)
+
+	body := `{
+		"schemas": ["urn:ietf:params:scim:schemas:core:2.0:User"],
+		"userName": "alice",
+		"name": {"formatted": "Alice Smith", "givenName": "Alice"},
+		"emails": [{"value": "alice@example.com", "type": "work", "primary": true}],
+		"active": false
+	}`
+	r, err := http.NewRequest("POST", "http://example.com/scim/v2/Users", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Failed creating http.Request: %v", err)
+	}
+	r.Header.Set("Authorization", "Bearer "+makeToken("admin"))
+	w := httptest.NewRecorder()
+	s.HTTPHandler().ServeHTTP(w, r)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("invalid response code, want=%d, got=%d: %s", http.StatusCreated, w.Code, w.Body.String())
+	}
+	var got scim.User
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc := w.HeaderMap.Get("Location"); loc != scimTestBaseURL+"/Users/"+got.ID {
+		t.Errorf("want Location of created user, got %q", loc)
+	}
+
+	usr, err := um.Get(got.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := user.User{
+		ID:          got.ID,
+		Email:       "alice@example.com",
+		DisplayName: "Alice Smith",
+		Disabled:    true,
+		CreatedAt:   usr.CreatedAt,
+	}
+	if diff := pretty.Compare(want, usr); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+	if diff := pretty.Compare(scim.NewUser(usr, nil, scimTestBaseURL), got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+// makeSCIMTokenFunc returns a function making client tokens for the client
+// with the given ID.
+func makeSCIMTokenFunc(t *testing.T) func(clientID string) string {
+	privKey, err := key.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate private key, error=%v", err)
+	}
+
+	return func(clientID string) string {
+		now := time.Now()
+		claims := oidc.NewClaims("iss", clientID, clientID, now, now.Add(time.Hour))
+		jwt, err := jose.NewSignedJWT(claims, privKey.Signer())
+		if err != nil {
+			t.Fatalf("Failed to generate JWT, error=%v", err)
+		}
+		return jwt.Encode()
+	}
+}
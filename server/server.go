@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"html/template"
@@ -17,15 +18,25 @@ import (
 	"github.com/coreos/pkg/health"
 	"github.com/jonboulle/clockwork"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/client"
 	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/kubernetes"
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/pkg/metrics"
 	"github.com/coreos/dex/refresh"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
 	usersapi "github.com/coreos/dex/user/api"
 	useremail "github.com/coreos/dex/user/email"
 	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
+)
+
+var (
+	tokensIssued = metrics.NewCounterVec("dex_tokens_issued_total",
+		"ID tokens issued, by grant type and client.", "grant_type", "client_id")
 )
 
 const (
@@ -34,20 +45,28 @@ const (
 	VerifyEmailTemplateName            = "verify-email.html"
 	SendResetPasswordEmailTemplateName = "send-reset-password.html"
 	ResetPasswordTemplateName          = "reset-password.html"
+	WebAuthnTemplateName               = "webauthn.html"
+	AccountTemplateName                = "account.html"
 
 	APIVersion = "v1"
 )
 
+// OIDCServer is the part of Server the OIDC endpoints use. The contexts taken
+// are those of the requests being served, whose loggers are used.
 type OIDCServer interface {
 	ClientMetadata(string) (*oidc.ClientMetadata, error)
-	NewSession(connectorID, clientID, clientState string, redirectURL url.URL, nonce string, register bool, scope []string) (string, error)
-	Login(oidc.Identity, string) (string, error)
+	NewSession(ctx context.Context, connectorID, clientID, clientState string, redirectURL url.URL, nonce string, register bool, scope []string) (string, error)
+	Login(context.Context, oidc.Identity, user.Profile, string) (string, error)
+	// SetLinkToken records a request to link a remote identity in the session of the given key,
+	// returning a new key for it.
+	SetLinkToken(sessionKey, token string) (string, error)
 	// CodeToken exchanges a code for an ID token and a refresh token string on success.
-	CodeToken(creds oidc.ClientCredentials, sessionKey string) (*jose.JWT, string, error)
-	ClientCredsToken(creds oidc.ClientCredentials) (*jose.JWT, error)
+	CodeToken(ctx context.Context, creds oidc.ClientCredentials, sessionKey string) (*jose.JWT, string, error)
+	ClientCredsToken(ctx context.Context, creds oidc.ClientCredentials) (*jose.JWT, error)
 	// RefreshToken takes a previously generated refresh token and returns a new ID token
-	// if the token is valid.
-	RefreshToken(creds oidc.ClientCredentials, token string) (*jose.JWT, error)
+	// if the token is valid. The scope is that of the refresh request, which may
+	// ask for profile claims.
+	RefreshToken(ctx context.Context, creds oidc.ClientCredentials, scope []string, token string) (*jose.JWT, error)
 	KillSession(string) error
 }
 
@@ -66,16 +85,59 @@ type Server struct {
 	VerifyEmailTemplate            *template.Template
 	SendResetPasswordEmailTemplate *template.Template
 	ResetPasswordTemplate          *template.Template
+	WebAuthnTemplate               *template.Template
+	AccountTemplate                *template.Template
 	HealthChecks                   []health.Checkable
 	Connectors                     []connector.Connector
 	UserRepo                       user.UserRepo
 	UserManager                    *manager.UserManager
 	PasswordInfoRepo               user.PasswordInfoRepo
+	TOTPInfoRepo                   user.TOTPInfoRepo
+	TOTPTxnFactory                 repo.TransactionFactory
+	WebAuthnCredentialRepo         user.WebAuthnCredentialRepo
+	AttributeRepo                  user.AttributeRepo
+	LoginThrottler                 *user.LoginThrottler
 	RefreshTokenRepo               refresh.RefreshTokenRepo
 	UserEmailer                    *useremail.UserEmailer
 	EnableRegistration             bool
 	EnableClientRegistration       bool
 
+	// AuditSink, if set, records logins, issued tokens and other
+	// security-relevant events.
+	AuditSink audit.AuditSink
+
+	// WebhookNotifier, if set, queues a webhook event for every login.
+	WebhookNotifier webhook.Notifier
+
+	// WebAuthnRequiredClients and WebAuthnRequiredConnectors list the
+	// clients and connectors for which users must present a security key,
+	// registering one first if need be.
+	WebAuthnRequiredClients    []string
+	WebAuthnRequiredConnectors []string
+
+	// LockoutPolicy is the policy of the LoginThrottler created by the
+	// StateConfigurer.
+	LockoutPolicy user.LockoutPolicy
+
+	// PasswordPolicy is the policy of the UserManager created by the
+	// StateConfigurer.
+	PasswordPolicy user.PasswordPolicy
+
+	// SessionRepo and SessionKeyRepo, if set, keep the sessions and session
+	// keys instead of the repos of the StateConfigurer.
+	SessionRepo    session.SessionRepo
+	SessionKeyRepo session.SessionKeyRepo
+
+	// Snapshots, if set, saves the in-memory repos of a single server
+	// while it runs.
+	Snapshots *repo.SnapshotFile
+
+	// KeyRotator and GarbageCollector, if set, rotate the signing keys and
+	// delete expired sessions and client secrets while the server runs,
+	// for storage backends without an overlord to do so.
+	KeyRotator       *key.PrivateKeyRotator
+	GarbageCollector *kubernetes.GarbageCollector
+
 	localConnectorID string
 }
 
@@ -89,6 +151,15 @@ func (s *Server) Run() chan struct{} {
 	for _, idpc := range s.Connectors {
 		chans = append(chans, idpc.Sync())
 	}
+	if s.Snapshots != nil {
+		chans = append(chans, s.Snapshots.Run())
+	}
+	if s.KeyRotator != nil {
+		chans = append(chans, s.KeyRotator.Run())
+	}
+	if s.GarbageCollector != nil {
+		chans = append(chans, s.GarbageCollector.Run())
+	}
 
 	go func() {
 		<-stop
@@ -173,10 +244,24 @@ func (s *Server) AddConnector(cfg connector.ConnectorConfig) error {
 			return errors.New("PasswordInfoRepo cannot be nil")
 		}
 
-		localConn.SetLocalIdentityProvider(&connector.LocalIdentityProvider{
+		idp := &connector.LocalIdentityProvider{
+			ConnectorID:      connectorID,
 			UserRepo:         s.UserRepo,
 			PasswordInfoRepo: s.PasswordInfoRepo,
-		})
+		}
+		if s.TOTPInfoRepo != nil {
+			idp.TOTPInfoRepo = s.TOTPInfoRepo
+			idp.TOTPTxnFactory = s.TOTPTxnFactory
+			idp.IssuerURL = s.IssuerURL
+			idp.SignerFunc = s.KeyManager.Signer
+			idp.KeysFunc = s.KeyManager.PublicKeys
+		}
+		if s.LoginThrottler != nil {
+			idp.Throttler = s.LoginThrottler
+			idp.LockoutNotifier = s.notifyLockout
+		}
+		idp.AuditSink = s.AuditSink
+		localConn.SetLocalIdentityProvider(idp)
 
 		localCfg, ok := cfg.(*connector.LocalConnectorConfig)
 		if !ok {
@@ -237,6 +322,7 @@ func (s *Server) HTTPHandler() http.Handler {
 		issuerURL: s.IssuerURL,
 		um:        s.UserManager,
 		keysFunc:  s.KeyManager.PublicKeys,
+		auditSink: s.AuditSink,
 	})
 
 	mux.Handle(httpPathAcceptInvitation, &InvitationHandler{
@@ -248,11 +334,26 @@ func (s *Server) HTTPHandler() http.Handler {
 		redirectValidityWindow: s.SessionManager.ValidityWindow,
 	})
 
+	if s.WebAuthnCredentialRepo != nil {
+		mux.HandleFunc(httpPathWebAuthn, handleWebAuthnFunc(s, s.WebAuthnTemplate))
+	}
+
+	account := &accountHandler{s: s, tpl: s.AccountTemplate}
+	mux.HandleFunc(httpPathAccount, account.handleAccount)
+	mux.HandleFunc(httpPathAccountCallback, account.handleCallback)
+	mux.HandleFunc(httpPathAccountProfile, account.handleProfile)
+	mux.HandleFunc(httpPathAccountPassword, account.handlePassword)
+	mux.HandleFunc(httpPathAccountLink, account.handleLink)
+	mux.HandleFunc(httpPathAccountUnlink, account.handleUnlink)
+	mux.HandleFunc(httpPathAccountRevoke, account.handleRevoke)
+	mux.HandleFunc(httpPathAccountLogout, account.handleLogout)
+
 	if s.EnableClientRegistration {
 		mux.HandleFunc(httpPathClientRegistration, s.handleClientRegistration)
 	}
 
 	mux.HandleFunc(httpPathDebugVars, health.ExpvarHandler)
+	mux.Handle(httpPathMetrics, metrics.Handler())
 
 	pcfg := s.ProviderConfig()
 	for _, idpc := range s.Connectors {
@@ -266,8 +367,9 @@ func (s *Server) HTTPHandler() http.Handler {
 	apiBasePath := path.Join(httpPathAPI, APIVersion)
 	registerDiscoveryResource(apiBasePath, mux)
 
-	clientPath, clientHandler := registerClientResource(apiBasePath, s.ClientIdentityRepo)
+	clientPath, clientHandler := registerClientResource(apiBasePath, s.ClientIdentityRepo, s.AuditSink)
 	mux.Handle(path.Join(apiBasePath, clientPath), s.NewClientTokenAuthHandler(clientHandler))
+	mux.Handle(path.Join(apiBasePath, clientPath)+"/", s.NewClientTokenAuthHandler(clientHandler))
 
 	usersAPI := usersapi.NewUsersAPI(s.UserManager, s.ClientIdentityRepo, s.UserEmailer, s.localConnectorID)
 	handler := NewUserMgmtServer(usersAPI, s.JWTVerifierFactory(), s.UserManager, s.ClientIdentityRepo).HTTPHandler()
@@ -276,7 +378,28 @@ func (s *Server) HTTPHandler() http.Handler {
 	mux.Handle(path, handler)
 	mux.Handle(path+"/", handler)
 
-	return http.Handler(mux)
+	scimURL := s.absURL(httpPathSCIM)
+	scimHandler := (&scimServer{
+		baseURL:          scimURL.String(),
+		um:               s.UserManager,
+		cir:              s.ClientIdentityRepo,
+		localConnectorID: s.localConnectorID,
+	}).HTTPHandler()
+	mux.Handle(httpPathSCIM+"/", s.NewClientTokenAuthHandler(scimHandler))
+
+	return metrics.InstrumentHandler(mux, muxPattern(mux))
+}
+
+// muxPattern returns a function labelling requests with the pattern they
+// match in mux, so that paths containing IDs do not each get their own
+// metrics.
+func muxPattern(mux *http.ServeMux) func(*http.Request) string {
+	return func(r *http.Request) string {
+		if _, pattern := mux.Handler(r); pattern != "" {
+			return pattern
+		}
+		return "unmatched"
+	}
 }
 
 // NewClientTokenAuthHandler returns the given handler wrapped in middleware which requires a Client Bearer token.
@@ -290,30 +413,40 @@ func (s *Server) NewClientTokenAuthHandler(handler http.Handler) http.Handler {
 }
 
 func (s *Server) ClientMetadata(clientID string) (*oidc.ClientMetadata, error) {
+	if clientID == accountClientID {
+		return &oidc.ClientMetadata{
+			RedirectURIs: []url.URL{s.absURL(httpPathAccountCallback)},
+			ClientName:   "Account",
+		}, nil
+	}
 	return s.ClientIdentityRepo.Metadata(clientID)
 }
 
-func (s *Server) NewSession(ipdcID, clientID, clientState string, redirectURL url.URL, nonce string, register bool, scope []string) (string, error) {
+func (s *Server) NewSession(ctx context.Context, ipdcID, clientID, clientState string, redirectURL url.URL, nonce string, register bool, scope []string) (string, error) {
 	sessionID, err := s.SessionManager.NewSession(ipdcID, clientID, clientState, redirectURL, nonce, register, scope)
 	if err != nil {
 		return "", err
 	}
 
-	log.Infof("Session %s created: clientID=%s clientState=%s", sessionID, clientID, clientState)
+	log.FromContext(ctx).With(log.Fields{
+		"session_id":   sessionID,
+		"client_id":    clientID,
+		"connector_id": ipdcID,
+	}).Infof("Session created: clientState=%s", clientState)
 	return s.SessionManager.NewSessionKey(sessionID)
 }
 
-func (s *Server) Login(ident oidc.Identity, key string) (string, error) {
+func (s *Server) Login(ctx context.Context, ident oidc.Identity, profile user.Profile, key string) (string, error) {
 	sessionID, err := s.SessionManager.ExchangeKey(key)
 	if err != nil {
 		return "", err
 	}
 
-	ses, err := s.SessionManager.AttachRemoteIdentity(sessionID, ident)
+	ses, err := s.SessionManager.AttachRemoteIdentity(sessionID, ident, profile)
 	if err != nil {
 		return "", err
 	}
-	log.Infof("Session %s remote identity attached: clientID=%s identity=%#v", sessionID, ses.ClientID, ident)
+	sessionLogger(ctx, ses).Infof("Remote identity %s attached to session", ident.ID)
 
 	if ses.Register {
 		code, err := s.SessionManager.NewSessionKey(sessionID)
@@ -334,33 +467,85 @@ func (s *Server) Login(ident oidc.Identity, key string) (string, error) {
 		ID:          ses.Identity.ID,
 	})
 	if err == user.ErrorNotFound {
-		// Does the user have an existing account with a different connector?
-		if ses.Identity.Email != "" {
-			connID, err := getConnectorForUserByEmail(s.UserRepo, ses.Identity.Email)
-			if err == nil {
-				// Ask user to sign in through existing account.
-				u := newLoginURLFromSession(s.IssuerURL, ses, false, []string{connID}, "wrong-connector")
-				return u.String(), nil
-			}
+		var redirectURL string
+		usr, redirectURL, err = s.loginUnknownIdentity(ctx, ses)
+		if err != nil {
+			return "", err
 		}
+		if redirectURL != "" {
+			return redirectURL, nil
+		}
+	} else if err != nil {
+		return "", err
+	}
 
-		// User doesn't have an existing account. Ask them to register.
-		u := newLoginURLFromSession(s.IssuerURL, ses, true, []string{ses.ConnectorID}, "register-maybe")
-		return u.String(), nil
+	if usr.Disabled {
+		audit.Record(s.AuditSink, audit.Event{
+			Type:        audit.EventLoginFailed,
+			UserID:      usr.ID,
+			ClientID:    ses.ClientID,
+			ConnectorID: ses.ConnectorID,
+			Details:     "user disabled",
+		})
+		connector.Logins.Inc(ses.ConnectorID, connector.LoginFailure)
+		return "", user.ErrorNotFound
+	}
+
+	if usr, err = s.UserManager.RefreshProfile(usr.ID, ses.Profile); err != nil {
+		return "", err
+	}
+
+	amr, err := s.connectorAuthMethods(ses.ConnectorID, usr.ID)
+	if err != nil {
+		return "", err
 	}
+	if ses, err = s.SessionManager.AddAuthMethods(sessionID, amr...); err != nil {
+		return "", err
+	}
+
+	required, err := s.webAuthnRequired(ses, usr.ID)
 	if err != nil {
 		return "", err
 	}
+	if required {
+		code, err := s.SessionManager.NewSessionKey(sessionID)
+		if err != nil {
+			return "", err
+		}
+		sessionLogger(ctx, ses).WithField("user_id", usr.ID).Infof("Session requires a security key")
 
-	if usr.Disabled {
-		return "", user.ErrorNotFound
+		u := s.webAuthnURL(code)
+		return u.String(), nil
+	}
+
+	if err := s.linkPendingIdentity(ctx, ses, usr.ID); err != nil {
+		return "", err
 	}
 
 	ses, err = s.SessionManager.AttachUser(sessionID, usr.ID)
 	if err != nil {
 		return "", err
 	}
-	log.Infof("Session %s user identified: clientID=%s user=%#v", sessionID, ses.ClientID, usr)
+	sessionLogger(ctx, ses).Infof("Session user identified")
+	audit.Record(s.AuditSink, audit.Event{
+		Type:        audit.EventLogin,
+		UserID:      usr.ID,
+		ClientID:    ses.ClientID,
+		ConnectorID: ses.ConnectorID,
+	})
+	connector.Logins.Inc(ses.ConnectorID, connector.LoginSuccess)
+	if s.WebhookNotifier != nil {
+		err := s.WebhookNotifier.Notify(nil, webhook.Event{
+			Type:        webhook.EventUserLogin,
+			UserID:      usr.ID,
+			Email:       usr.Email,
+			ClientID:    ses.ClientID,
+			ConnectorID: ses.ConnectorID,
+		})
+		if err != nil {
+			sessionLogger(ctx, ses).Errorf("Failed to queue login webhook: %v", err)
+		}
+	}
 
 	code, err := s.SessionManager.NewSessionKey(sessionID)
 	if err != nil {
@@ -376,10 +561,25 @@ func (s *Server) Login(ident oidc.Identity, key string) (string, error) {
 	return ru.String(), nil
 }
 
-func (s *Server) ClientCredsToken(creds oidc.ClientCredentials) (*jose.JWT, error) {
+// sessionLogger returns the logger of ctx carrying the session, client,
+// connector and user of ses as fields.
+func sessionLogger(ctx context.Context, ses *session.Session) *log.Logger {
+	f := log.Fields{
+		"session_id":   ses.ID,
+		"client_id":    ses.ClientID,
+		"connector_id": ses.ConnectorID,
+	}
+	if ses.UserID != "" {
+		f["user_id"] = ses.UserID
+	}
+	return log.FromContext(ctx).With(f)
+}
+
+func (s *Server) ClientCredsToken(ctx context.Context, creds oidc.ClientCredentials) (*jose.JWT, error) {
+	l := log.FromContext(ctx).WithField("client_id", creds.ID)
 	ok, err := s.ClientIdentityRepo.Authenticate(creds)
 	if err != nil {
-		log.Errorf("Failed fetching client %s from repo: %v", creds.ID, err)
+		l.Errorf("Failed fetching client from repo: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 	if !ok {
@@ -388,7 +588,7 @@ func (s *Server) ClientCredsToken(creds oidc.ClientCredentials) (*jose.JWT, erro
 
 	signer, err := s.KeyManager.Signer()
 	if err != nil {
-		log.Errorf("Failed to generate ID token: %v", err)
+		l.Errorf("Failed to generate ID token: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 
@@ -399,23 +599,30 @@ func (s *Server) ClientCredsToken(creds oidc.ClientCredentials) (*jose.JWT, erro
 
 	jwt, err := jose.NewSignedJWT(claims, signer)
 	if err != nil {
-		log.Errorf("Failed to generate ID token: %v", err)
+		l.Errorf("Failed to generate ID token: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 
-	log.Infof("Client token sent: clientID=%s", creds.ID)
+	l.Infof("Client token sent")
+	audit.Record(s.AuditSink, audit.Event{
+		Type:     audit.EventTokenIssued,
+		ClientID: creds.ID,
+		Details:  "client credentials",
+	})
+	tokensIssued.Inc(oauth2.GrantTypeClientCreds, creds.ID)
 
 	return jwt, nil
 }
 
-func (s *Server) CodeToken(creds oidc.ClientCredentials, sessionKey string) (*jose.JWT, string, error) {
+func (s *Server) CodeToken(ctx context.Context, creds oidc.ClientCredentials, sessionKey string) (*jose.JWT, string, error) {
+	l := log.FromContext(ctx).WithField("client_id", creds.ID)
 	ok, err := s.ClientIdentityRepo.Authenticate(creds)
 	if err != nil {
-		log.Errorf("Failed fetching client %s from repo: %v", creds.ID, err)
+		l.Errorf("Failed fetching client from repo: %v", err)
 		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
 	}
 	if !ok {
-		log.Errorf("Failed to Authenticate client %s", creds.ID)
+		l.Errorf("Failed to authenticate client")
 		return nil, "", oauth2.NewError(oauth2.ErrorInvalidClient)
 	}
 
@@ -432,25 +639,43 @@ func (s *Server) CodeToken(creds oidc.ClientCredentials, sessionKey string) (*jo
 	if ses.ClientID != creds.ID {
 		return nil, "", oauth2.NewError(oauth2.ErrorInvalidGrant)
 	}
+	l = sessionLogger(ctx, ses)
+
+	required, err := s.webAuthnRequired(ses, ses.UserID)
+	if err != nil {
+		l.Errorf("Failed to check security key requirement: %v", err)
+		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
+	}
+	if required && !containsString(ses.AMR, amrHardwareKey) {
+		l.Errorf("Session was not completed with a security key")
+		return nil, "", oauth2.NewError(oauth2.ErrorInvalidGrant)
+	}
 
 	signer, err := s.KeyManager.Signer()
 	if err != nil {
-		log.Errorf("Failed to generate ID token: %v", err)
+		l.Errorf("Failed to generate ID token: %v", err)
 		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
 	}
 
 	user, err := s.UserRepo.Get(nil, ses.UserID)
 	if err != nil {
-		log.Errorf("Failed to fetch user %q from repo: %v: ", ses.UserID, err)
+		l.Errorf("Failed to fetch user from repo: %v", err)
 		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
 	}
 
 	claims := ses.Claims(s.IssuerURL.String())
 	user.AddToClaims(claims)
+	if containsString(ses.Scope, "profile") {
+		user.AddProfileClaims(claims)
+	}
+	if err := s.addAttributeClaims(claims, creds.ID, ses.UserID); err != nil {
+		l.Errorf("Failed to add attributes of user to claims: %v", err)
+		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
+	}
 
 	jwt, err := jose.NewSignedJWT(claims, signer)
 	if err != nil {
-		log.Errorf("Failed to generate ID token: %v", err)
+		l.Errorf("Failed to generate ID token: %v", err)
 		return nil, "", oauth2.NewError(oauth2.ErrorServerError)
 	}
 
@@ -459,32 +684,44 @@ func (s *Server) CodeToken(creds oidc.ClientCredentials, sessionKey string) (*jo
 
 	for _, scope := range ses.Scope {
 		if scope == "offline_access" {
-			log.Infof("Session %s requests offline access, will generate refresh token", sessionID)
+			l.Infof("Session requests offline access, will generate refresh token")
 
 			refreshToken, err = s.RefreshTokenRepo.Create(ses.UserID, creds.ID)
 			switch err {
 			case nil:
 				break
 			default:
-				log.Errorf("Failed to generate refresh token: %v", err)
+				l.Errorf("Failed to generate refresh token: %v", err)
 				return nil, "", oauth2.NewError(oauth2.ErrorServerError)
 			}
 			break
 		}
 	}
 
-	log.Infof("Session %s token sent: clientID=%s", sessionID, creds.ID)
+	l.Infof("Token sent")
+	e := audit.Event{
+		Type:        audit.EventTokenIssued,
+		UserID:      ses.UserID,
+		ClientID:    creds.ID,
+		ConnectorID: ses.ConnectorID,
+	}
+	if refreshToken != "" {
+		e.Details = "with refresh token"
+	}
+	audit.Record(s.AuditSink, e)
+	tokensIssued.Inc(oauth2.GrantTypeAuthCode, creds.ID)
 	return jwt, refreshToken, nil
 }
 
-func (s *Server) RefreshToken(creds oidc.ClientCredentials, token string) (*jose.JWT, error) {
+func (s *Server) RefreshToken(ctx context.Context, creds oidc.ClientCredentials, scope []string, token string) (*jose.JWT, error) {
+	l := log.FromContext(ctx).WithField("client_id", creds.ID)
 	ok, err := s.ClientIdentityRepo.Authenticate(creds)
 	if err != nil {
-		log.Errorf("Failed fetching client %s from repo: %v", creds.ID, err)
+		l.Errorf("Failed fetching client from repo: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 	if !ok {
-		log.Errorf("Failed to Authenticate client %s", creds.ID)
+		l.Errorf("Failed to authenticate client")
 		return nil, oauth2.NewError(oauth2.ErrorInvalidClient)
 	}
 
@@ -497,40 +734,81 @@ func (s *Server) RefreshToken(creds oidc.ClientCredentials, token string) (*jose
 	case refresh.ErrorInvalidClientID:
 		return nil, oauth2.NewError(oauth2.ErrorInvalidClient)
 	default:
+		l.Errorf("Failed to verify refresh token: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
+	l = l.WithField("user_id", userID)
 
-	user, err := s.UserRepo.Get(nil, userID)
-	if err != nil {
-		// The error can be user.ErrorNotFound, but we are not deleting
-		// user at this moment, so this shouldn't happen.
-		log.Errorf("Failed to fetch user %q from repo: %v: ", userID, err)
+	usr, err := s.UserRepo.Get(nil, userID)
+	switch err {
+	case nil:
+		break
+	case user.ErrorNotFound:
+		// Deleting a user revokes their refresh tokens, but one may have
+		// been verified just before.
+		l.Errorf("Refresh token of deleted user used")
+		return nil, oauth2.NewError(oauth2.ErrorInvalidRequest)
+	default:
+		l.Errorf("Failed to fetch user from repo: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 
 	signer, err := s.KeyManager.Signer()
 	if err != nil {
-		log.Errorf("Failed to refresh ID token: %v", err)
+		l.Errorf("Failed to refresh ID token: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 
 	now := time.Now()
 	expireAt := now.Add(session.DefaultSessionValidityWindow)
 
-	claims := oidc.NewClaims(s.IssuerURL.String(), user.ID, creds.ID, now, expireAt)
-	user.AddToClaims(claims)
+	claims := oidc.NewClaims(s.IssuerURL.String(), usr.ID, creds.ID, now, expireAt)
+	usr.AddToClaims(claims)
+	if containsString(scope, "profile") {
+		usr.AddProfileClaims(claims)
+	}
+	if err := s.addAttributeClaims(claims, creds.ID, usr.ID); err != nil {
+		l.Errorf("Failed to add attributes of user to claims: %v", err)
+		return nil, oauth2.NewError(oauth2.ErrorServerError)
+	}
 
 	jwt, err := jose.NewSignedJWT(claims, signer)
 	if err != nil {
-		log.Errorf("Failed to generate ID token: %v", err)
+		l.Errorf("Failed to generate ID token: %v", err)
 		return nil, oauth2.NewError(oauth2.ErrorServerError)
 	}
 
-	log.Infof("New token sent: clientID=%s", creds.ID)
+	l.Infof("New token sent")
+	audit.Record(s.AuditSink, audit.Event{
+		Type:     audit.EventTokenRefreshed,
+		UserID:   usr.ID,
+		ClientID: creds.ID,
+	})
+	tokensIssued.Inc(oauth2.GrantTypeRefreshToken, creds.ID)
 
 	return jwt, nil
 }
 
+// addAttributeClaims adds the custom attributes of the user selected by the
+// claim mapping of the client to the claims of an ID token.
+func (s *Server) addAttributeClaims(claims jose.Claims, clientID, userID string) error {
+	if s.AttributeRepo == nil {
+		return nil
+	}
+
+	cm, err := s.ClientIdentityRepo.ClaimMapping(clientID)
+	if err != nil || len(cm) == 0 {
+		return err
+	}
+
+	attrs, err := s.AttributeRepo.Get(nil, userID)
+	if err != nil {
+		return err
+	}
+	cm.AddToClaims(claims, attrs)
+	return nil
+}
+
 func (s *Server) JWTVerifierFactory() JWTVerifierFactory {
 	noop := func() error { return nil }
 
@@ -563,3 +841,14 @@ func (s sortableIDPCs) Swap(i, j int) {
 	idpcs := []connector.Connector(s)
 	idpcs[i], idpcs[j] = idpcs[j], idpcs[i]
 }
+
+// notifyLockout emails a local user whose account got locked after too many
+// failed logins.
+func (s *Server) notifyLockout(usr user.User, lockedUntil time.Time) {
+	if s.UserEmailer == nil || usr.ID == "" {
+		return
+	}
+	if err := s.UserEmailer.SendLockoutNotification(usr.ID, lockedUntil); err != nil {
+		log.Errorf("Unable to send lockout notification to user %s: %v", usr.ID, err)
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"context"
 	"encoding/base64"
 	"errors"
 	"fmt"
@@ -9,10 +10,15 @@ import (
 	"testing"
 	"time"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/client"
+	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/refresh/refreshtest"
+	"github.com/coreos/dex/repo"
 	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/user/manager"
+	"github.com/coreos/dex/webhook"
 	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/key"
 	"github.com/coreos/go-oidc/oauth2"
@@ -143,7 +149,7 @@ func TestServerNewSession(t *testing.T) {
 		},
 	}
 
-	key, err := srv.NewSession("bogus_idpc", ci.Credentials.ID, state, ci.Metadata.RedirectURIs[0], nonce, false, []string{"openid"})
+	key, err := srv.NewSession(context.Background(), "bogus_idpc", ci.Credentials.ID, state, ci.Metadata.RedirectURIs[0], nonce, false, []string{"openid"})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
@@ -153,7 +159,7 @@ func TestServerNewSession(t *testing.T) {
 		t.Fatalf("Session not retreivable: %v", err)
 	}
 
-	ses, err := sm.AttachRemoteIdentity(sessionID, oidc.Identity{})
+	ses, err := sm.AttachRemoteIdentity(sessionID, oidc.Identity{}, user.Profile{})
 	if err != nil {
 		t.Fatalf("Unable to add Identity to Session: %v", err)
 	}
@@ -209,21 +215,28 @@ func TestServerLogin(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
+	connCfgRepo := connector.NewConnectorConfigRepoFromConfigs(nil)
+	auditRepo := audit.NewEventRepo()
+	notifier := &recordingNotifier{}
 	srv := &Server{
 		IssuerURL:          url.URL{Scheme: "http", Host: "server.example.com"},
 		KeyManager:         km,
 		SessionManager:     sm,
 		ClientIdentityRepo: ciRepo,
 		UserRepo:           userRepo,
+		UserManager:        manager.NewUserManager(userRepo, user.NewPasswordInfoRepo(), connCfgRepo, repo.InMemTransactionFactory, manager.ManagerOptions{}),
+		AuditSink:          auditRepo,
+		WebhookNotifier:    notifier,
 	}
 
 	ident := oidc.Identity{ID: "YYY", Name: "elroy", Email: "elroy@example.com"}
+	profile := user.Profile{Picture: "https://example.com/elroy.png"}
 	key, err := sm.NewSessionKey(sessionID)
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
-	redirectURL, err := srv.Login(ident, key)
+	redirectURL, err := srv.Login(context.Background(), ident, profile, key)
 	if err != nil {
 		t.Fatalf("Unexpected err from Server.Login: %v", err)
 	}
@@ -232,6 +245,42 @@ func TestServerLogin(t *testing.T) {
 	if wantRedirectURL != redirectURL {
 		t.Fatalf("Unexpected redirectURL: want=%q, got=%q", wantRedirectURL, redirectURL)
 	}
+
+	usr, err := userRepo.Get(nil, "testid-1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if usr.Picture != profile.Picture {
+		t.Errorf("Unexpected picture: want=%q, got=%q", profile.Picture, usr.Picture)
+	}
+
+	events, _, err := auditRepo.List(audit.EventFilter{}, 10, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("Unexpected audit events: %#v", events)
+	}
+	e := events[0]
+	if e.Type != audit.EventLogin || e.UserID != "testid-1" || e.ClientID != "XXX" || e.ConnectorID != "test_connector_id" {
+		t.Errorf("Unexpected audit event: %#v", e)
+	}
+
+	wantEvents := []webhook.Event{
+		{Type: webhook.EventUserLogin, UserID: "testid-1", Email: usr.Email, ClientID: "XXX", ConnectorID: "test_connector_id"},
+	}
+	if diff := pretty.Compare(wantEvents, notifier.events); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+type recordingNotifier struct {
+	events []webhook.Event
+}
+
+func (n *recordingNotifier) Notify(_ repo.Transaction, e webhook.Event) error {
+	n.events = append(n.events, e)
+	return nil
 }
 
 func TestServerLoginUnrecognizedSessionKey(t *testing.T) {
@@ -254,7 +303,7 @@ func TestServerLoginUnrecognizedSessionKey(t *testing.T) {
 	}
 
 	ident := oidc.Identity{ID: "YYY", Name: "elroy", Email: "elroy@example.com"}
-	code, err := srv.Login(ident, "XXX")
+	code, err := srv.Login(context.Background(), ident, user.Profile{}, "XXX")
 	if err == nil {
 		t.Fatalf("Expected non-nil error")
 	}
@@ -312,12 +361,14 @@ func TestServerLoginDisabledUser(t *testing.T) {
 		ID:          "disabled-connector-id",
 	})
 
+	auditRepo := audit.NewEventRepo()
 	srv := &Server{
 		IssuerURL:          url.URL{Scheme: "http", Host: "server.example.com"},
 		KeyManager:         km,
 		SessionManager:     sm,
 		ClientIdentityRepo: ciRepo,
 		UserRepo:           userRepo,
+		AuditSink:          auditRepo,
 	}
 
 	ident := oidc.Identity{ID: "disabled-connector-id", Name: "elroy", Email: "elroy@example.com"}
@@ -326,10 +377,18 @@ func TestServerLoginDisabledUser(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
-	_, err = srv.Login(ident, key)
+	_, err = srv.Login(context.Background(), ident, user.Profile{}, key)
 	if err == nil {
 		t.Errorf("disabled user was allowed to log in")
 	}
+
+	events, _, err := auditRepo.List(audit.EventFilter{}, 10, "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Type != audit.EventLoginFailed || events[0].UserID != "disabled-1" {
+		t.Errorf("Unexpected audit events: %#v", events)
+	}
 }
 
 func TestServerCodeToken(t *testing.T) {
@@ -385,7 +444,7 @@ func TestServerCodeToken(t *testing.T) {
 		if err != nil {
 			t.Fatalf("case %d: unexpected error: %v", i, err)
 		}
-		_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{})
+		_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{}, user.Profile{})
 		if err != nil {
 			t.Fatalf("case %d: unexpected error: %v", i, err)
 		}
@@ -400,7 +459,7 @@ func TestServerCodeToken(t *testing.T) {
 			t.Fatalf("case %d: unexpected error: %v", i, err)
 		}
 
-		jwt, token, err := srv.CodeToken(ci.Credentials, key)
+		jwt, token, err := srv.CodeToken(context.Background(), ci.Credentials, key)
 		if err != nil {
 			t.Fatalf("case %d: unexpected error: %v", i, err)
 		}
@@ -438,12 +497,12 @@ func TestServerTokenUnrecognizedKey(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
-	_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{})
+	_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{}, user.Profile{})
 	if err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
-	jwt, token, err := srv.CodeToken(ci.Credentials, "foo")
+	jwt, token, err := srv.CodeToken(context.Background(), ci.Credentials, "foo")
 	if err == nil {
 		t.Fatalf("Expected non-nil error")
 	}
@@ -526,7 +585,7 @@ func TestServerTokenFail(t *testing.T) {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 
-		_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{})
+		_, err = sm.AttachRemoteIdentity(sessionID, oidc.Identity{}, user.Profile{})
 		if err != nil {
 			t.Errorf("case %d: unexpected error: %v", i, err)
 			continue
@@ -567,7 +626,7 @@ func TestServerTokenFail(t *testing.T) {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 
-		jwt, token, err := srv.CodeToken(tt.argCC, tt.argKey)
+		jwt, token, err := srv.CodeToken(context.Background(), tt.argCC, tt.argKey)
 		if token != tt.refreshToken {
 			fmt.Printf("case %d: expect refresh token %q, got %q\n", i, tt.refreshToken, token)
 			t.Fatalf("case %d: expect refresh token %q, got %q", i, tt.refreshToken, token)
@@ -720,7 +779,7 @@ func TestServerRefreshToken(t *testing.T) {
 			t.Fatalf("Unexpected error: %v", err)
 		}
 
-		jwt, err := srv.RefreshToken(tt.creds, tt.token)
+		jwt, err := srv.RefreshToken(context.Background(), tt.creds, nil, tt.token)
 		if !reflect.DeepEqual(err, tt.err) {
 			t.Errorf("Case %d: expect: %v, got: %v", i, tt.err, err)
 		}
@@ -740,7 +799,7 @@ func TestServerRefreshToken(t *testing.T) {
 	}
 
 	// Test that we should return error when user cannot be found after
-	// verifying the token.
+	// verifying the token, as they have been deleted.
 	km := &StaticKeyManager{
 		signer: signerFixture,
 	}
@@ -787,8 +846,146 @@ func TestServerRefreshToken(t *testing.T) {
 	}
 	srv.UserRepo = userRepo
 
-	_, err = srv.RefreshToken(credXXX, fmt.Sprintf("0/%s", base64.URLEncoding.EncodeToString([]byte("refresh-1"))))
-	if !reflect.DeepEqual(err, oauth2.NewError(oauth2.ErrorServerError)) {
-		t.Errorf("Expect: %v, got: %v", oauth2.NewError(oauth2.ErrorServerError), err)
+	_, err = srv.RefreshToken(context.Background(), credXXX, nil, fmt.Sprintf("0/%s", base64.URLEncoding.EncodeToString([]byte("refresh-1"))))
+	if !reflect.DeepEqual(err, oauth2.NewError(oauth2.ErrorInvalidRequest)) {
+		t.Errorf("Expect: %v, got: %v", oauth2.NewError(oauth2.ErrorInvalidRequest), err)
+	}
+}
+
+func TestServerRefreshTokenAttributeClaims(t *testing.T) {
+	creds := oidc.ClientCredentials{ID: "XXX", Secret: "secret"}
+	ciRepo := client.NewClientIdentityRepo([]oidc.ClientIdentity{
+		oidc.ClientIdentity{Credentials: creds},
+	})
+	if err := ciRepo.SetClaimMapping(creds.ID, client.ClaimMapping{
+		"department": "dept",
+		"roles":      "roles",
+		"missing":    "unset",
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	userRepo, err := makeNewUserRepo()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	attrRepo := user.NewAttributeRepo()
+	if err := attrRepo.Set(nil, "testid-1", user.Attributes{
+		"dept":   "Engineering",
+		"roles":  []string{"dev", "ops"},
+		"salary": float64(100),
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshTokenRepo, err := refreshtest.NewTestRefreshTokenRepo()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	token, err := refreshTokenRepo.Create("testid-1", creds.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	srv := &Server{
+		IssuerURL:          url.URL{Scheme: "http", Host: "server.example.com"},
+		KeyManager:         &StaticKeyManager{signer: &StaticSigner{sig: []byte("beer")}},
+		ClientIdentityRepo: ciRepo,
+		UserRepo:           userRepo,
+		AttributeRepo:      attrRepo,
+		RefreshTokenRepo:   refreshTokenRepo,
+	}
+
+	jwt, err := srv.RefreshToken(context.Background(), creds, nil, token)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	claims, err := jwt.Claims()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if claims["department"] != "Engineering" {
+		t.Errorf("want department=Engineering, got %v", claims["department"])
+	}
+	if diff := pretty.Compare([]interface{}{"dev", "ops"}, claims["roles"]); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+	for _, c := range []string{"missing", "salary", "dept"} {
+		if _, ok := claims[c]; ok {
+			t.Errorf("unexpected claim %q", c)
+		}
+	}
+}
+
+func TestServerRefreshTokenProfileClaims(t *testing.T) {
+	creds := oidc.ClientCredentials{ID: "XXX", Secret: "secret"}
+	ciRepo := client.NewClientIdentityRepo([]oidc.ClientIdentity{
+		oidc.ClientIdentity{Credentials: creds},
+	})
+
+	userRepo := user.NewUserRepo()
+	if err := userRepo.Create(nil, user.User{
+		ID:        "testid-1",
+		Email:     "testname@example.com",
+		GivenName: "Test",
+		Picture:   "https://example.com/test.png",
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	refreshTokenRepo, err := refreshtest.NewTestRefreshTokenRepo()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	srv := &Server{
+		IssuerURL:          url.URL{Scheme: "http", Host: "server.example.com"},
+		KeyManager:         &StaticKeyManager{signer: &StaticSigner{sig: []byte("beer")}},
+		ClientIdentityRepo: ciRepo,
+		UserRepo:           userRepo,
+		RefreshTokenRepo:   refreshTokenRepo,
+	}
+
+	tests := []struct {
+		scope []string
+		want  map[string]interface{}
+	}{
+		{
+			scope: nil,
+			want:  map[string]interface{}{},
+		},
+		{
+			scope: []string{"openid", "profile"},
+			want: map[string]interface{}{
+				"given_name": "Test",
+				"picture":    "https://example.com/test.png",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		token, err := refreshTokenRepo.Create("testid-1", creds.ID)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		jwt, err := srv.RefreshToken(context.Background(), creds, tt.scope, token)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		claims, err := jwt.Claims()
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		got := map[string]interface{}{}
+		for _, c := range []string{"given_name", "family_name", "picture", "locale"} {
+			if v, ok := claims[c]; ok {
+				got[c] = v
+			}
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
 	}
 }
@@ -3,9 +3,11 @@ package server
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/oidc"
@@ -28,7 +30,13 @@ var (
 	UsersListEndpoint    = addBasePath(UsersSubTree)
 	UsersCreateEndpoint  = addBasePath(UsersSubTree)
 	UsersGetEndpoint     = addBasePath(UsersSubTree + "/:id")
+	UsersDeleteEndpoint  = addBasePath(UsersSubTree + "/:id")
 	UsersDisableEndpoint = addBasePath(UsersSubTree + "/:id/disable")
+
+	UsersRemoteIdentitiesEndpoint = addBasePath(UsersSubTree + "/:id/remote-identities")
+	UsersLinkEndpoint             = addBasePath(UsersSubTree + "/:id/link")
+	UsersUnlinkEndpoint           = addBasePath(UsersSubTree + "/:id/unlink")
+	UsersAttributesEndpoint       = addBasePath(UsersSubTree + "/:id/attributes")
 )
 
 type UserMgmtServer struct {
@@ -55,6 +63,12 @@ func (s *UserMgmtServer) HTTPHandler() http.Handler {
 	r.POST(UsersCreateEndpoint, s.authAPIHandle(s.createUser))
 	r.POST(UsersDisableEndpoint, s.authAPIHandle(s.disableUser))
 	r.GET(UsersGetEndpoint, s.authAPIHandle(s.getUser))
+	r.DELETE(UsersDeleteEndpoint, s.authAPIHandle(s.deleteUser))
+	r.GET(UsersRemoteIdentitiesEndpoint, s.authAPIHandle(s.listRemoteIdentities))
+	r.POST(UsersLinkEndpoint, s.authAPIHandle(s.linkRemoteIdentity))
+	r.POST(UsersUnlinkEndpoint, s.authAPIHandle(s.unlinkRemoteIdentity))
+	r.GET(UsersAttributesEndpoint, s.authAPIHandle(s.getAttributes))
+	r.PUT(UsersAttributesEndpoint, s.authAPIHandle(s.setAttributes))
 	return r
 }
 
@@ -84,7 +98,14 @@ func (s *UserMgmtServer) listUsers(w http.ResponseWriter, r *http.Request, ps ht
 		return
 	}
 
-	users, nextPageToken, err := s.api.ListUsers(creds, maxResults, nextPageToken)
+	filter, err := userFilterFromQuery(r.URL.Query())
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, err.Error()))
+		return
+	}
+
+	users, nextPageToken, err := s.api.ListUsers(creds, filter, maxResults, nextPageToken)
 	if err != nil {
 		s.writeError(w, err)
 		return
@@ -161,6 +182,133 @@ func (s *UserMgmtServer) disableUser(w http.ResponseWriter, r *http.Request, ps
 	writeResponseWithBody(w, http.StatusOK, resp)
 }
 
+func (s *UserMgmtServer) deleteUser(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id := ps.ByName("id")
+	if id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "id is required"))
+		return
+	}
+
+	resp, err := s.api.DeleteUser(creds, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *UserMgmtServer) listRemoteIdentities(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id := ps.ByName("id")
+	if id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "id is required"))
+		return
+	}
+
+	resp, err := s.api.ListRemoteIdentities(creds, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *UserMgmtServer) linkRemoteIdentity(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id, rid, ok := s.remoteIdentityRequest(w, r, ps)
+	if !ok {
+		return
+	}
+
+	resp, err := s.api.LinkRemoteIdentity(creds, id, rid)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *UserMgmtServer) unlinkRemoteIdentity(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id, rid, ok := s.remoteIdentityRequest(w, r, ps)
+	if !ok {
+		return
+	}
+
+	resp, err := s.api.UnlinkRemoteIdentity(creds, id, rid)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *UserMgmtServer) getAttributes(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id := ps.ByName("id")
+	if id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "id is required"))
+		return
+	}
+
+	resp, err := s.api.GetAttributes(creds, id)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+func (s *UserMgmtServer) setAttributes(w http.ResponseWriter, r *http.Request, ps httprouter.Params, creds api.Creds) {
+	id := ps.ByName("id")
+	if id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "id is required"))
+		return
+	}
+
+	req := schema.UserAttributes{}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return
+	}
+
+	resp, err := s.api.SetAttributes(creds, id, req)
+	if err != nil {
+		s.writeError(w, err)
+		return
+	}
+
+	writeResponseWithBody(w, http.StatusOK, resp)
+}
+
+// remoteIdentityRequest reads the user ID and remote identity of a link or
+// unlink request, writing an error if they are missing.
+func (s *UserMgmtServer) remoteIdentityRequest(w http.ResponseWriter, r *http.Request, ps httprouter.Params) (string, schema.RemoteIdentity, bool) {
+	id := ps.ByName("id")
+	if id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "id is required"))
+		return "", schema.RemoteIdentity{}, false
+	}
+
+	rid := schema.RemoteIdentity{}
+	if err := json.NewDecoder(r.Body).Decode(&rid); err != nil {
+		writeInvalidRequest(w, "cannot parse JSON body")
+		return "", schema.RemoteIdentity{}, false
+	}
+	if rid.ConnectorID == "" || rid.Id == "" {
+		writeAPIError(w, http.StatusBadRequest,
+			newAPIError(errorInvalidRequest, "connectorID and id are required"))
+		return "", schema.RemoteIdentity{}, false
+	}
+	return id, rid, true
+}
+
 func (s *UserMgmtServer) writeError(w http.ResponseWriter, err error) {
 	log.Errorf("Error calling user management API: %v: ", err)
 	if apiErr, ok := err.(api.Error); ok {
@@ -238,6 +386,53 @@ func (s *UserMgmtServer) getCreds(r *http.Request) (api.Creds, error) {
 	}, nil
 }
 
+// userFilterFromQuery reads the filter of a user list request from its query
+// parameters.
+func userFilterFromQuery(q url.Values) (user.UserFilter, error) {
+	filter := user.UserFilter{
+		EmailPrefix: q.Get("emailPrefix"),
+		DisplayName: q.Get("displayName"),
+		ConnectorID: q.Get("connectorID"),
+	}
+
+	bools := []struct {
+		name  string
+		field **bool
+	}{
+		{"admin", &filter.Admin},
+		{"disabled", &filter.Disabled},
+		{"emailVerified", &filter.EmailVerified},
+	}
+	for _, p := range bools {
+		if v := q.Get(p.name); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return user.UserFilter{}, fmt.Errorf("%s must be a boolean", p.name)
+			}
+			*p.field = &b
+		}
+	}
+
+	times := []struct {
+		name  string
+		field *time.Time
+	}{
+		{"createdAfter", &filter.CreatedAfter},
+		{"createdBefore", &filter.CreatedBefore},
+	}
+	for _, p := range times {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return user.UserFilter{}, fmt.Errorf("%s must be an RFC 3339 time", p.name)
+			}
+			*p.field = t
+		}
+	}
+
+	return filter, nil
+}
+
 func intFromQuery(ps url.Values, name string, defaultVal int) (int, error) {
 	s := ps.Get(name)
 	if s == "" {
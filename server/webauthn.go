@@ -0,0 +1,374 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	phttp "github.com/coreos/dex/pkg/http"
+	"github.com/coreos/dex/pkg/webauthn"
+	"github.com/coreos/dex/session"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	// Authentication method references, as defined by RFC 8176, reported in
+	// the "amr" claim.
+	amrPassword    = "pwd"
+	amrOTP         = "otp"
+	amrHardwareKey = "hwk"
+	amrMultiFactor = "mfa"
+
+	webAuthnChallengeValidity = 5 * time.Minute
+)
+
+var (
+	errWebAuthnFailed      = errors.New("security key verification failed")
+	errWebAuthnUnavailable = errors.New("security key required but no WebAuthnCredentialRepo configured")
+)
+
+type webAuthnTemplateData struct {
+	Error   bool
+	Message string
+
+	PostURL   string
+	Code      string
+	Challenge string
+	Register  bool
+
+	// The following are handed to the browser's WebAuthn API; binary values
+	// are base64url encoded.
+	PublicKeyChallenge string
+	RPID               string
+	UserHandle         string
+	UserName           string
+	CredentialIDs      []string
+}
+
+// handleWebAuthnFunc serves the page users are sent to after their connector
+// login when a security key is required. Users who have not registered a key
+// yet are asked to register one; everyone else must use one of theirs.
+func handleWebAuthnFunc(s *Server, tpl Template) http.HandlerFunc {
+	errPage := func(w http.ResponseWriter, msg string, status int) {
+		data := webAuthnTemplateData{
+			Error:   true,
+			Message: msg,
+		}
+		execTemplateWithStatus(w, tpl, data, status)
+	}
+
+	internalError := func(w http.ResponseWriter, r *http.Request, err error) {
+		phttp.Logger(r).Errorf("Internal Error during security key verification: %v", err)
+		errPage(w, "There was a problem processing your request.", http.StatusInternalServerError)
+	}
+
+	rp := webauthn.NewRelyingParty(s.IssuerURL)
+	postURL := s.absURL(httpPathWebAuthn)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" && r.Method != "POST" {
+			w.Header().Set("Allow", "GET, POST")
+			errPage(w, "GET and POST only acceptable methods", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			internalError(w, r, err)
+			return
+		}
+
+		// verify the user has a valid code.
+		sessionID, err := s.SessionManager.ExchangeKey(r.Form.Get("code"))
+		if err != nil {
+			errPage(w, "Please authenticate before continuing.", http.StatusUnauthorized)
+			return
+		}
+
+		// create a new code for them to use next time they hit the server.
+		code, err := s.SessionManager.NewSessionKey(sessionID)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+
+		ses, err := s.SessionManager.Get(sessionID)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+
+		usr, err := s.sessionUser(ses)
+		if err != nil {
+			if err == user.ErrorNotFound {
+				errPage(w, "Please authenticate before continuing.", http.StatusUnauthorized)
+				return
+			}
+			internalError(w, r, err)
+			return
+		}
+
+		creds, err := s.WebAuthnCredentialRepo.GetByUserID(nil, usr.ID)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+
+		register := len(creds) == 0
+		purpose := user.SecondFactorPurposeWebAuthn
+		if register {
+			purpose = user.SecondFactorPurposeWebAuthnRegister
+		}
+
+		data := webAuthnTemplateData{
+			PostURL:  postURL.String(),
+			Code:     code,
+			Register: register,
+			RPID:     rp.ID,
+		}
+
+		if r.Method == "POST" {
+			err := s.verifyWebAuthn(r, rp, sessionID, usr.ID, purpose)
+			switch err {
+			case nil:
+				if _, err := s.SessionManager.AddAuthMethods(sessionID, amrHardwareKey, amrMultiFactor); err != nil {
+					internalError(w, r, err)
+					return
+				}
+				if ses.State == session.SessionStateRemoteAttached {
+					if err := s.linkPendingIdentity(r.Context(), ses, usr.ID); err != nil {
+						internalError(w, r, err)
+						return
+					}
+					if ses, err = s.SessionManager.AttachUser(sessionID, usr.ID); err != nil {
+						internalError(w, r, err)
+						return
+					}
+				}
+				sessionLogger(r.Context(), ses).WithField("user_id", usr.ID).Infof("Session security key verified")
+
+				http.Redirect(w, r, makeClientRedirectURL(ses.RedirectURL, code, ses.ClientState).String(), http.StatusSeeOther)
+				return
+			case errWebAuthnFailed:
+				data.Message = "Your security key could not be verified. Please try again."
+			default:
+				internalError(w, r, err)
+				return
+			}
+		}
+
+		token, err := s.newWebAuthnChallenge(sessionID, usr.ID, purpose)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+		data.Challenge = token
+		data.PublicKeyChallenge = webauthn.EncodeID(webAuthnChallengeBytes(token))
+
+		if register {
+			data.UserHandle = webauthn.EncodeID([]byte(usr.ID))
+			data.UserName = usr.Email
+			if data.UserName == "" {
+				data.UserName = usr.ID
+			}
+		}
+		for _, cred := range creds {
+			data.CredentialIDs = append(data.CredentialIDs, webauthn.EncodeID(cred.ID))
+		}
+
+		status := http.StatusOK
+		if data.Message != "" {
+			status = http.StatusBadRequest
+		}
+		execTemplateWithStatus(w, tpl, data, status)
+	}
+}
+
+// verifyWebAuthn checks the response of the browser to a challenge handed
+// out by the WebAuthn page, registering the new credential or recording the
+// use of an existing one. errWebAuthnFailed is returned for any response
+// which does not check out.
+func (s *Server) verifyWebAuthn(r *http.Request, rp webauthn.RelyingParty, sessionID, userID, purpose string) error {
+	keys, err := s.KeyManager.PublicKeys()
+	if err != nil {
+		return err
+	}
+
+	token := r.PostForm.Get("challenge")
+	ch, err := user.ParseAndVerifySecondFactorChallengeToken(token, s.IssuerURL, keys)
+	if err != nil {
+		phttp.Logger(r).WithField("user_id", userID).Debugf("Invalid security key challenge: %v", err)
+		return errWebAuthnFailed
+	}
+	if ch.SessionKey() != sessionID || ch.UserID() != userID || ch.Purpose() != purpose {
+		return errWebAuthnFailed
+	}
+	challenge := webAuthnChallengeBytes(token)
+
+	var fields [5][]byte
+	for i, name := range []string{"credential_id", "client_data", "attestation_object", "authenticator_data", "signature"} {
+		if fields[i], err = webauthn.DecodeID(r.PostForm.Get(name)); err != nil {
+			return errWebAuthnFailed
+		}
+	}
+	credID, clientData, attestation, authData, signature := fields[0], fields[1], fields[2], fields[3], fields[4]
+
+	if purpose == user.SecondFactorPurposeWebAuthnRegister {
+		cred, err := rp.VerifyRegistration(challenge, clientData, attestation)
+		if err != nil {
+			phttp.Logger(r).WithField("user_id", userID).Debugf("Security key registration failed: %v", err)
+			return errWebAuthnFailed
+		}
+
+		err = s.WebAuthnCredentialRepo.Create(nil, user.WebAuthnCredential{
+			ID:        cred.ID,
+			UserID:    userID,
+			PublicKey: cred.PublicKey,
+			SignCount: cred.SignCount,
+			CreatedAt: time.Now(),
+		})
+		if err == user.ErrorDuplicateID {
+			return errWebAuthnFailed
+		}
+		return err
+	}
+
+	stored, err := s.WebAuthnCredentialRepo.Get(nil, credID)
+	if err == user.ErrorNotFound || (err == nil && stored.UserID != userID) {
+		return errWebAuthnFailed
+	}
+	if err != nil {
+		return err
+	}
+
+	signCount, err := rp.VerifyAssertion(challenge, webauthn.Credential{
+		ID:        stored.ID,
+		PublicKey: stored.PublicKey,
+		SignCount: stored.SignCount,
+	}, clientData, authData, signature)
+	if err != nil {
+		phttp.Logger(r).WithField("user_id", userID).Debugf("Security key assertion failed: %v", err)
+		return errWebAuthnFailed
+	}
+
+	stored.SignCount = signCount
+	return s.WebAuthnCredentialRepo.Update(nil, stored)
+}
+
+// webAuthnURL returns the URL of the WebAuthn page for the session identified
+// by the session key code.
+func (s *Server) webAuthnURL(code string) url.URL {
+	u := s.absURL(httpPathWebAuthn)
+	q := u.Query()
+	q.Set("code", code)
+	u.RawQuery = q.Encode()
+	return u
+}
+
+// newWebAuthnChallenge returns a signed token binding a WebAuthn ceremony to
+// a session and user. The WebAuthn challenge itself is derived from the
+// token, so that nothing needs to be stored until the ceremony completes.
+func (s *Server) newWebAuthnChallenge(sessionID, userID, purpose string) (string, error) {
+	nonce, err := pcrypto.RandBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	signer, err := s.KeyManager.Signer()
+	if err != nil {
+		return "", err
+	}
+
+	ch := user.NewSecondFactorChallenge(userID, sessionID, purpose, s.IssuerURL, webAuthnChallengeValidity)
+	ch.Claims.Add("jti", base64.RawURLEncoding.EncodeToString(nonce))
+	return ch.Token(signer)
+}
+
+func webAuthnChallengeBytes(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
+
+// webAuthnRequired reports whether a session must be completed with a
+// security key before a token may be issued for it: either because its
+// client or connector demands one, or because the user has registered one.
+// Sessions whose client or connector demands a key fail without a
+// WebAuthnCredentialRepo to check it against.
+func (s *Server) webAuthnRequired(ses *session.Session, userID string) (bool, error) {
+	demanded := containsString(s.WebAuthnRequiredClients, ses.ClientID) || containsString(s.WebAuthnRequiredConnectors, ses.ConnectorID)
+	if s.WebAuthnCredentialRepo == nil {
+		if demanded {
+			return false, errWebAuthnUnavailable
+		}
+		return false, nil
+	}
+	if demanded {
+		return true, nil
+	}
+
+	creds, err := s.WebAuthnCredentialRepo.GetByUserID(nil, userID)
+	if err != nil {
+		return false, err
+	}
+	return len(creds) > 0, nil
+}
+
+// connectorAuthMethods returns the authentication methods a user went
+// through when logging in with the given connector. Remote connectors do
+// not tell us how they authenticated the user.
+func (s *Server) connectorAuthMethods(connectorID, userID string) ([]string, error) {
+	if connectorID != s.localConnectorID {
+		return nil, nil
+	}
+
+	amr := []string{amrPassword}
+	if s.TOTPInfoRepo != nil {
+		info, err := s.TOTPInfoRepo.Get(nil, userID)
+		if err != nil && err != user.ErrorNotFound {
+			return nil, err
+		}
+		if err == nil && info.Confirmed {
+			amr = append(amr, amrOTP, amrMultiFactor)
+		}
+	}
+	return amr, nil
+}
+
+// sessionUser returns the user a session belongs to, whether or not it has
+// been attached to the session yet.
+func (s *Server) sessionUser(ses *session.Session) (user.User, error) {
+	var (
+		usr user.User
+		err error
+	)
+	switch ses.State {
+	case session.SessionStateIdentified:
+		usr, err = s.UserRepo.Get(nil, ses.UserID)
+	case session.SessionStateRemoteAttached:
+		usr, err = s.UserRepo.GetByRemoteIdentity(nil, user.RemoteIdentity{
+			ConnectorID: ses.ConnectorID,
+			ID:          ses.Identity.ID,
+		})
+	default:
+		return user.User{}, user.ErrorNotFound
+	}
+	if err != nil {
+		return user.User{}, err
+	}
+	if usr.Disabled {
+		return user.User{}, user.ErrorNotFound
+	}
+	return usr, nil
+}
+
+func containsString(ss []string, s string) bool {
+	for _, x := range ss {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/pkg/webauthn"
+	"github.com/coreos/dex/user"
+)
+
+type webAuthnTestTemplate struct {
+	tpl Template
+
+	data webAuthnTemplateData
+}
+
+func (t *webAuthnTestTemplate) Execute(w io.Writer, data interface{}) error {
+	t.data = data.(webAuthnTemplateData)
+	return t.tpl.Execute(w, data)
+}
+
+type webAuthnTestFixtures struct {
+	*testFixtures
+	tpl     *webAuthnTestTemplate
+	handler http.HandlerFunc
+	auth    *webauthn.SoftwareAuthenticator
+}
+
+func makeWebAuthnTestFixtures(t *testing.T) *webAuthnTestFixtures {
+	f, err := makeTestFixtures()
+	if err != nil {
+		t.Fatalf("error making test fixtures: %v", err)
+	}
+	f.srv.WebAuthnCredentialRepo = user.NewWebAuthnCredentialRepo()
+	f.srv.WebAuthnRequiredClients = []string{testClientID}
+
+	tpl := &webAuthnTestTemplate{tpl: f.srv.WebAuthnTemplate}
+	return &webAuthnTestFixtures{
+		testFixtures: f,
+		tpl:          tpl,
+		handler:      handleWebAuthnFunc(f.srv, tpl),
+		auth:         webauthn.NewSoftwareAuthenticator(webauthn.NewRelyingParty(testIssuerURL)),
+	}
+}
+
+// login runs a connector login for the user with remote identity RID-1,
+// returning the URL the user is sent to.
+func (f *webAuthnTestFixtures) login(t *testing.T) string {
+	sessionID, err := f.sessionManager.NewSession("IDPC-1", testClientID, "bogus", f.redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	redirectURL, err := f.srv.Login(context.Background(), oidc.Identity{ID: "RID-1"}, user.Profile{}, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return redirectURL
+}
+
+func (f *webAuthnTestFixtures) serve(method, target string, form url.Values) *httptest.ResponseRecorder {
+	var r *http.Request
+	if form == nil {
+		r, _ = http.NewRequest(method, target, nil)
+	} else {
+		r, _ = http.NewRequest(method, target, strings.NewReader(form.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	w := httptest.NewRecorder()
+	f.handler(w, r)
+	return w
+}
+
+func (f *webAuthnTestFixtures) challenge(t *testing.T) []byte {
+	b, err := webauthn.DecodeID(f.tpl.data.PublicKeyChallenge)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return b
+}
+
+func TestWebAuthnLogin(t *testing.T) {
+	f := makeWebAuthnTestFixtures(t)
+
+	// First login: the client requires a security key, so the user has to
+	// register one.
+	u := f.login(t)
+	if !strings.HasPrefix(u, "http://server.example.com/webauthn?code=") {
+		t.Fatalf("want redirect to WebAuthn page, got %q", u)
+	}
+
+	w := f.serve("GET", u, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("want=%d, got=%d", http.StatusOK, w.Code)
+	}
+	if !f.tpl.data.Register || f.tpl.data.RPID != "server.example.com" || f.tpl.data.UserName != "Email-1@example.com" {
+		t.Fatalf("unexpected template data: %#v", f.tpl.data)
+	}
+
+	id, clientData, attestation, err := f.auth.Create(f.challenge(t))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w = f.serve("POST", "/webauthn", url.Values{
+		"code":               {f.tpl.data.Code},
+		"challenge":          {f.tpl.data.Challenge},
+		"credential_id":      {webauthn.EncodeID(id)},
+		"client_data":        {webauthn.EncodeID(clientData)},
+		"attestation_object": {webauthn.EncodeID(attestation)},
+	})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("want=%d, got=%d: %s", http.StatusSeeOther, w.Code, w.Body.String())
+	}
+
+	creds, err := f.srv.WebAuthnCredentialRepo.GetByUserID(nil, "ID-1")
+	if err != nil || len(creds) != 1 {
+		t.Fatalf("want one registered credential, got %d, err=%v", len(creds), err)
+	}
+
+	loc, err := url.Parse(w.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loc.Host != f.redirectURL.Host || loc.Query().Get("state") != "bogus" {
+		t.Fatalf("want redirect to client, got %v", loc)
+	}
+
+	jwt, _, err := f.srv.CodeToken(context.Background(), oidc.ClientCredentials{ID: testClientID, Secret: testClientSecret}, loc.Query().Get("code"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	claims, err := jwt.Claims()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]interface{}{"hwk", "mfa"}, claims["amr"]); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// Second login: the registered key must be used.
+	f.serve("GET", f.login(t), nil)
+	if f.tpl.data.Register || len(f.tpl.data.CredentialIDs) != 1 {
+		t.Fatalf("unexpected template data: %#v", f.tpl.data)
+	}
+
+	// A response to another challenge is rejected.
+	clientData, authData, sig, err := f.auth.Get([]byte("some other challenge"), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w = f.serve("POST", "/webauthn", url.Values{
+		"code":               {f.tpl.data.Code},
+		"challenge":          {f.tpl.data.Challenge},
+		"credential_id":      {webauthn.EncodeID(id)},
+		"client_data":        {webauthn.EncodeID(clientData)},
+		"authenticator_data": {webauthn.EncodeID(authData)},
+		"signature":          {webauthn.EncodeID(sig)},
+	})
+	if w.Code != http.StatusBadRequest || f.tpl.data.Message == "" {
+		t.Fatalf("want=%d with message, got=%d", http.StatusBadRequest, w.Code)
+	}
+
+	clientData, authData, sig, err = f.auth.Get(f.challenge(t), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	w = f.serve("POST", "/webauthn", url.Values{
+		"code":               {f.tpl.data.Code},
+		"challenge":          {f.tpl.data.Challenge},
+		"credential_id":      {webauthn.EncodeID(id)},
+		"client_data":        {webauthn.EncodeID(clientData)},
+		"authenticator_data": {webauthn.EncodeID(authData)},
+		"signature":          {webauthn.EncodeID(sig)},
+	})
+	if w.Code != http.StatusSeeOther {
+		t.Fatalf("want=%d, got=%d: %s", http.StatusSeeOther, w.Code, w.Body.String())
+	}
+
+	// The code was spent by the redirect; replaying the form fails.
+	w = f.serve("POST", "/webauthn", url.Values{"code": {f.tpl.data.Code}})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("want=%d, got=%d", http.StatusUnauthorized, w.Code)
+	}
+}
+
+func TestWebAuthnNotRequired(t *testing.T) {
+	f := makeWebAuthnTestFixtures(t)
+	f.srv.WebAuthnRequiredClients = nil
+
+	u := f.login(t)
+	if !strings.HasPrefix(u, f.redirectURL.String()) {
+		t.Fatalf("want redirect to client, got %q", u)
+	}
+
+	// Once a user has a security key, it is always required.
+	if err := f.srv.WebAuthnCredentialRepo.Create(nil, user.WebAuthnCredential{ID: []byte("cred-1"), UserID: "ID-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	u = f.login(t)
+	if !strings.HasPrefix(u, "http://server.example.com/webauthn?code=") {
+		t.Fatalf("want redirect to WebAuthn page, got %q", u)
+	}
+}
+
+func TestWebAuthnCodeTokenRequiresKey(t *testing.T) {
+	f := makeWebAuthnTestFixtures(t)
+	f.srv.WebAuthnRequiredClients = nil
+	f.srv.WebAuthnRequiredConnectors = []string{"IDPC-1"}
+
+	// A session which skipped the security key must not yield a token.
+	sessionID, err := f.sessionManager.NewSession("IDPC-1", testClientID, "bogus", f.redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.sessionManager.AttachRemoteIdentity(sessionID, oidc.Identity{ID: "RID-1"}, user.Profile{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := f.sessionManager.AttachUser(sessionID, "ID-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, _, err := f.srv.CodeToken(context.Background(), oidc.ClientCredentials{ID: testClientID, Secret: testClientSecret}, key); err == nil {
+		t.Fatalf("want non-nil error")
+	}
+}
+
+func TestWebAuthnRequiredWithoutRepo(t *testing.T) {
+	f := makeWebAuthnTestFixtures(t)
+	f.srv.WebAuthnCredentialRepo = nil
+
+	sessionID, err := f.sessionManager.NewSession("IDPC-1", testClientID, "bogus", f.redirectURL, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	key, err := f.sessionManager.NewSessionKey(sessionID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without a repo to check security keys against, the login must fail
+	// rather than skip the key.
+	if _, err := f.srv.Login(context.Background(), oidc.Identity{ID: "RID-1"}, user.Profile{}, key); err != errWebAuthnUnavailable {
+		t.Fatalf("want %v, got %v", errWebAuthnUnavailable, err)
+	}
+}
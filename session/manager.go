@@ -11,6 +11,8 @@ import (
 	"github.com/jonboulle/clockwork"
 
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 type GenerateCodeFunc func() (string, error)
@@ -110,13 +112,14 @@ func (m *SessionManager) getSessionInState(sessionID string, state SessionState)
 	return s, nil
 }
 
-func (m *SessionManager) AttachRemoteIdentity(sessionID string, ident oidc.Identity) (*Session, error) {
+func (m *SessionManager) AttachRemoteIdentity(sessionID string, ident oidc.Identity, profile user.Profile) (*Session, error) {
 	s, err := m.getSessionInState(sessionID, SessionStateNew)
 	if err != nil {
 		return nil, err
 	}
 
 	s.Identity = ident
+	s.Profile = profile
 	s.State = SessionStateRemoteAttached
 
 	if err = m.sessions.Update(*s); err != nil {
@@ -142,6 +145,59 @@ func (m *SessionManager) AttachUser(sessionID string, userID string) (*Session,
 	return s, nil
 }
 
+// AddAuthMethods records that the given authentication methods, such as
+// "pwd" or "hwk", were used during a session. Methods already recorded are
+// not added again.
+func (m *SessionManager) AddAuthMethods(sessionID string, methods ...string) (*Session, error) {
+	s, err := m.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.State == SessionStateDead {
+		return nil, fmt.Errorf("session state %s", s.State)
+	}
+
+	for _, method := range methods {
+		found := false
+		for _, existing := range s.AMR {
+			if existing == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			s.AMR = append(s.AMR, method)
+		}
+	}
+
+	if err = m.sessions.Update(*s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// SetLinkToken records a signed request to link a remote identity to the
+// user of a new session, to be acted upon once the user has logged in.
+func (m *SessionManager) SetLinkToken(sessionID, token string) (*Session, error) {
+	s, err := m.sessions.Get(sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.State != SessionStateNew {
+		return nil, fmt.Errorf("session state %s", s.State)
+	}
+
+	s.LinkToken = token
+	if err = m.sessions.Update(*s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
 func (m *SessionManager) Kill(sessionID string) (*Session, error) {
 	s, err := m.sessions.Get(sessionID)
 	if err != nil {
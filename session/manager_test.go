@@ -5,6 +5,9 @@ import (
 	"testing"
 
 	"github.com/coreos/go-oidc/oidc"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/user"
 )
 
 func staticGenerateCodeFunc(code string) GenerateCodeFunc {
@@ -33,11 +36,11 @@ func TestSessionAttachRemoteIdentityTwice(t *testing.T) {
 	}
 
 	ident := oidc.Identity{ID: "YYY", Name: "elroy", Email: "elroy@example.com"}
-	if _, err := sm.AttachRemoteIdentity(sessionID, ident); err != nil {
+	if _, err := sm.AttachRemoteIdentity(sessionID, ident, user.Profile{}); err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
-	if _, err := sm.AttachRemoteIdentity(sessionID, ident); err == nil {
+	if _, err := sm.AttachRemoteIdentity(sessionID, ident, user.Profile{}); err == nil {
 		t.Fatalf("Expected non-nil error")
 	}
 }
@@ -101,7 +104,7 @@ func TestSessionManagerKill(t *testing.T) {
 	}
 
 	ident := oidc.Identity{ID: "YYY", Name: "elroy", Email: "elroy@example.com"}
-	if _, err := sm.AttachRemoteIdentity(sessionID, ident); err != nil {
+	if _, err := sm.AttachRemoteIdentity(sessionID, ident, user.Profile{}); err != nil {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 
@@ -117,3 +120,52 @@ func TestSessionManagerKill(t *testing.T) {
 		t.Errorf("Unexpected Session: %#v", ses)
 	}
 }
+
+func TestSessionManagerAddAuthMethods(t *testing.T) {
+	sm := NewSessionManager(NewSessionRepo(), NewSessionKeyRepo())
+	sessionID, err := sm.NewSession("connector_id", "XXX", "bogus", url.URL{}, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := sm.AddAuthMethods(sessionID, "pwd"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ses, err := sm.AddAuthMethods(sessionID, "pwd", "hwk")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"pwd", "hwk"}, ses.AMR); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if _, err := sm.Kill(sessionID); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := sm.AddAuthMethods(sessionID, "otp"); err == nil {
+		t.Errorf("Expected non-nil error for dead session")
+	}
+}
+
+func TestSessionManagerSetLinkToken(t *testing.T) {
+	sm := NewSessionManager(NewSessionRepo(), NewSessionKeyRepo())
+	sessionID, err := sm.NewSession("connector_id", "XXX", "bogus", url.URL{}, "", false, []string{"openid"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if _, err := sm.SetLinkToken(sessionID, "token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	ses, err := sm.AttachRemoteIdentity(sessionID, oidc.Identity{ID: "YYY"}, user.Profile{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if ses.LinkToken != "token" {
+		t.Errorf("Incorrect LinkToken: want=%q got=%q", "token", ses.LinkToken)
+	}
+
+	if _, err := sm.SetLinkToken(sessionID, "other"); err == nil {
+		t.Errorf("Expected non-nil error for session with remote identity")
+	}
+}
@@ -1,16 +1,24 @@
 package session
 
 import (
+	"encoding/json"
 	"errors"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/repo"
 )
 
 type SessionRepo interface {
 	Get(string) (*Session, error)
 	Create(Session) error
 	Update(Session) error
+
+	// DeleteByUserID removes all sessions of the given user.
+	DeleteByUserID(tx repo.Transaction, userID string) error
 }
 
 type SessionKeyRepo interface {
@@ -30,11 +38,14 @@ func NewSessionRepoWithClock(clock clockwork.Clock) SessionRepo {
 }
 
 type memSessionRepo struct {
+	mu    sync.Mutex
 	store map[string]Session
 	clock clockwork.Clock
 }
 
 func (m *memSessionRepo) Get(sessionID string) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	s, ok := m.store[sessionID]
 	if !ok || s.ExpiresAt.Before(m.clock.Now()) {
 		return nil, errors.New("unrecognized ID")
@@ -43,6 +54,8 @@ func (m *memSessionRepo) Get(sessionID string) (*Session, error) {
 }
 
 func (m *memSessionRepo) Create(s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, ok := m.store[s.ID]; ok {
 		return errors.New("ID exists")
 	}
@@ -52,6 +65,8 @@ func (m *memSessionRepo) Create(s Session) error {
 }
 
 func (m *memSessionRepo) Update(s Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if _, ok := m.store[s.ID]; !ok {
 		return errors.New("unrecognized ID")
 	}
@@ -59,6 +74,43 @@ func (m *memSessionRepo) Update(s Session) error {
 	return nil
 }
 
+func (m *memSessionRepo) DeleteByUserID(_ repo.Transaction, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, s := range m.store {
+		if s.UserID == userID {
+			delete(m.store, id)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the sessions which have not expired yet.
+func (m *memSessionRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	sessions := make(map[string]Session, len(m.store))
+	for id, s := range m.store {
+		if !s.ExpiresAt.Before(now) {
+			sessions[id] = s
+		}
+	}
+	return json.Marshal(sessions)
+}
+
+func (m *memSessionRepo) Restore(b []byte) error {
+	sessions := make(map[string]Session)
+	if err := json.Unmarshal(b, &sessions); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = sessions
+	return nil
+}
+
 type expiringSessionKey struct {
 	SessionKey
 	expiresAt time.Time
@@ -76,11 +128,14 @@ func NewSessionKeyRepoWithClock(clock clockwork.Clock) SessionKeyRepo {
 }
 
 type memSessionKeyRepo struct {
+	mu    sync.Mutex
 	store map[string]expiringSessionKey
 	clock clockwork.Clock
 }
 
 func (m *memSessionKeyRepo) Pop(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	esk, ok := m.store[key]
 	if !ok {
 		return "", errors.New("unrecognized key")
@@ -95,9 +150,51 @@ func (m *memSessionKeyRepo) Pop(key string) (string, error) {
 }
 
 func (m *memSessionKeyRepo) Push(sk SessionKey, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.store[sk.Key] = expiringSessionKey{
 		SessionKey: sk,
 		expiresAt:  m.clock.Now().Add(ttl),
 	}
 	return nil
 }
+
+// memSessionKeySnapshot is a session key in a snapshot of a
+// memSessionKeyRepo.
+type memSessionKeySnapshot struct {
+	SessionKey
+	ExpiresAt time.Time
+}
+
+// Snapshot returns the session keys which have not expired yet.
+func (m *memSessionKeyRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	now := m.clock.Now()
+	var keys []memSessionKeySnapshot
+	for _, esk := range m.store {
+		if !esk.expiresAt.Before(now) {
+			keys = append(keys, memSessionKeySnapshot{SessionKey: esk.SessionKey, ExpiresAt: esk.expiresAt})
+		}
+	}
+	// Sorted, so that an unchanged repo gives an unchanged snapshot.
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return json.Marshal(keys)
+}
+
+func (m *memSessionKeyRepo) Restore(b []byte) error {
+	var keys []memSessionKeySnapshot
+	if err := json.Unmarshal(b, &keys); err != nil {
+		return err
+	}
+
+	store := make(map[string]expiringSessionKey, len(keys))
+	for _, k := range keys {
+		store[k.Key] = expiringSessionKey{SessionKey: k.SessionKey, expiresAt: k.ExpiresAt}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	return nil
+}
@@ -0,0 +1,76 @@
+package session
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/oidc"
+	"github.com/jonboulle/clockwork"
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/repo"
+)
+
+func TestMemSessionRepoSnapshot(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	r := NewSessionRepoWithClock(clock)
+	active := Session{
+		ID:          "active",
+		ConnectorID: "local",
+		State:       SessionStateIdentified,
+		CreatedAt:   clock.Now().UTC(),
+		ExpiresAt:   clock.Now().Add(time.Hour).UTC(),
+		ClientID:    "XXX",
+		RedirectURL: url.URL{Scheme: "https", Host: "client.example.com", Path: "/callback"},
+		Identity:    oidc.Identity{ID: "RID-1", Email: "elroy@example.com"},
+		Scope:       []string{"openid"},
+	}
+	expired := Session{ID: "expired", ExpiresAt: clock.Now().Add(-time.Minute).UTC()}
+	for _, s := range []Session{active, expired} {
+		if err := r.Create(s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	kr := NewSessionKeyRepoWithClock(clock)
+	if err := kr.Push(SessionKey{Key: "key-1", SessionID: "active"}, time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := kr.Push(SessionKey{Key: "key-2", SessionID: "expired"}, -time.Minute); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restoredRepo := NewSessionRepoWithClock(clock)
+	restoredKeyRepo := NewSessionKeyRepoWithClock(clock)
+	for _, rr := range []struct{ from, to repo.Snapshotter }{
+		{r.(repo.Snapshotter), restoredRepo.(repo.Snapshotter)},
+		{kr.(repo.Snapshotter), restoredKeyRepo.(repo.Snapshotter)},
+	} {
+		b, err := rr.from.Snapshot()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := rr.to.Restore(b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	got, err := restoredRepo.Get("active")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare(active, *got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+	if n := len(restoredRepo.(*memSessionRepo).store); n != 1 {
+		t.Errorf("want 1 restored session, got %d", n)
+	}
+
+	if id, err := restoredKeyRepo.Pop("key-1"); err != nil || id != "active" {
+		t.Errorf("want session key of active session, got %q, %v", id, err)
+	}
+	if _, err := restoredKeyRepo.Pop("key-2"); err == nil {
+		t.Errorf("expired session key restored")
+	}
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/oidc"
+
+	"github.com/coreos/dex/user"
 )
 
 const (
@@ -43,6 +45,9 @@ type Session struct {
 	Identity    oidc.Identity
 	UserID      string
 
+	// Profile holds the profile claims the connector found for the remote identity.
+	Profile user.Profile
+
 	// Regsiter indicates that this session is a registration flow.
 	Register bool
 
@@ -51,6 +56,12 @@ type Session struct {
 
 	// Scope is the 'scope' field in the authentication request. Example scopes are 'openid', 'email', 'offline', etc.
 	Scope []string
+
+	// AMR lists the authentication methods used during this session, and is propagated to the "amr" claim.
+	AMR []string
+
+	// LinkToken is a signed request to link a remote identity to the user once they have logged in.
+	LinkToken string
 }
 
 // Claims returns a new set of Claims for the current session.
@@ -61,5 +72,8 @@ func (s *Session) Claims(issuerURL string) jose.Claims {
 	if s.Nonce != "" {
 		claims["nonce"] = s.Nonce
 	}
+	if len(s.AMR) > 0 {
+		claims["amr"] = s.AMR
+	}
 	return claims
 }
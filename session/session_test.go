@@ -84,6 +84,29 @@ func TestSessionClaims(t *testing.T) {
 				"nonce": "oncenay",
 			},
 		},
+		// AMR gets propagated.
+		{
+			ses: Session{
+				CreatedAt: now,
+				ExpiresAt: now.Add(time.Hour),
+				ClientID:  "XXX",
+				Identity: oidc.Identity{
+					ID:    "YYY",
+					Name:  "elroy",
+					Email: "elroy@example.com",
+				},
+				UserID: "elroy-id",
+				AMR:    []string{"pwd", "hwk", "mfa"},
+			},
+			want: jose.Claims{
+				"iss": issuerURL,
+				"sub": "elroy-id",
+				"aud": "XXX",
+				"iat": float64(now.Unix()),
+				"exp": float64(now.Add(time.Hour).Unix()),
+				"amr": []string{"pwd", "hwk", "mfa"},
+			},
+		},
 	}
 
 	for i, tt := range tests {
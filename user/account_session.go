@@ -0,0 +1,83 @@
+package user
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
+	"github.com/coreos/go-oidc/oidc"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+)
+
+const (
+	// ClaimAccountSessionCSRF holds the token which forms of the account
+	// pages must echo back, so that other sites cannot submit them on the
+	// user's behalf.
+	ClaimAccountSessionCSRF = "http://coreos.com/account/csrf"
+
+	accountSessionCSRFLength = 32
+)
+
+// NewAccountSession creates an object which is kept in a cookie by users
+// who have logged in to the account pages. clientID is the audience of the
+// session, and a new CSRF token is generated for it.
+func NewAccountSession(userID, clientID string, issuer url.URL, expires time.Duration) (AccountSession, error) {
+	b, err := pcrypto.RandBytes(accountSessionCSRFLength)
+	if err != nil {
+		return AccountSession{}, err
+	}
+
+	claims := oidc.NewClaims(issuer.String(), userID, clientID, clock.Now(), clock.Now().Add(expires))
+	claims.Add(ClaimAccountSessionCSRF, base64.RawURLEncoding.EncodeToString(b))
+	return AccountSession{claims}, nil
+}
+
+type AccountSession struct {
+	Claims jose.Claims
+}
+
+// ParseAndVerifyAccountSessionToken parses a string into an AccountSession,
+// verifies the signature, and ensures that required claims are present. In
+// addition to the usual claims required by the OIDC spec, "aud" and "sub"
+// must be present as well as ClaimAccountSessionCSRF.
+func ParseAndVerifyAccountSessionToken(token string, issuer url.URL, keys []key.PublicKey) (AccountSession, error) {
+	tokenClaims, err := parseAndVerifyTokenClaims(token, issuer, keys)
+	if err != nil {
+		return AccountSession{}, err
+	}
+
+	csrf, ok, err := tokenClaims.Claims.StringClaim(ClaimAccountSessionCSRF)
+	if err != nil {
+		return AccountSession{}, err
+	}
+	if !ok || csrf == "" {
+		return AccountSession{}, fmt.Errorf("no %q claim", ClaimAccountSessionCSRF)
+	}
+
+	return AccountSession{tokenClaims.Claims}, nil
+}
+
+// Token serializes the session into a signed JWT.
+func (s AccountSession) Token(signer jose.Signer) (string, error) {
+	jwt, err := jose.NewSignedJWT(s.Claims, signer)
+	if err != nil {
+		return "", err
+	}
+	return jwt.Encode(), nil
+}
+
+func (s AccountSession) UserID() string {
+	return assertStringClaim(s.Claims, "sub")
+}
+
+func (s AccountSession) ClientID() string {
+	return assertStringClaim(s.Claims, "aud")
+}
+
+func (s AccountSession) CSRFToken() string {
+	return assertStringClaim(s.Claims, ClaimAccountSessionCSRF)
+}
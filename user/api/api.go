@@ -7,9 +7,11 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/coreos/dex/client"
+	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/pkg/log"
 	schema "github.com/coreos/dex/schema/workerschema"
 	"github.com/coreos/dex/user"
@@ -22,6 +24,14 @@ var (
 		user.ErrorDuplicateEmail: ErrorDuplicateEmail,
 		user.ErrorInvalidEmail:   ErrorInvalidEmail,
 		client.ErrorNotFound:     ErrorInvalidClient,
+
+		user.ErrorDuplicateRemoteIdentity: ErrorDuplicateRemoteIdentity,
+		manager.ErrorLastRemoteIdentity:   ErrorLastRemoteIdentity,
+		connector.ErrorNotFound:           ErrorInvalidConnector,
+
+		user.ErrorInvalidAttributeName:  ErrorInvalidAttributes,
+		user.ErrorInvalidAttributeValue: ErrorInvalidAttributes,
+		user.ErrorTooManyAttributes:     ErrorInvalidAttributes,
 	}
 
 	ErrorInvalidEmail = newError("invalid_email", "invalid email.", http.StatusBadRequest)
@@ -37,6 +47,12 @@ var (
 	ErrorMaxResultsTooHigh = newError("max_results_too_high", fmt.Sprintf("The max number of results per page is %d", maxUsersPerPage), http.StatusBadRequest)
 
 	ErrorInvalidRedirectURL = newError("invalid_redirect_url", "The provided redirect URL is invalid for the given client", http.StatusBadRequest)
+
+	ErrorInvalidConnector        = newError("invalid_connector", "No connector with the given ID.", http.StatusBadRequest)
+	ErrorDuplicateRemoteIdentity = newError("duplicate_remote_identity", "Remote identity already linked to a user.", http.StatusBadRequest)
+	ErrorLastRemoteIdentity      = newError("last_remote_identity", "The last remote identity of a user cannot be unlinked.", http.StatusBadRequest)
+
+	ErrorInvalidAttributes = newError("invalid_attributes", fmt.Sprintf("Attributes must be an object of at most %d attributes with names of letters, digits, '_', '.' and '-', and values which are strings, numbers, booleans or lists of strings.", user.MaxAttributes), http.StatusBadRequest)
 )
 
 const (
@@ -138,6 +154,21 @@ func (u *UsersAPI) DisableUser(creds Creds, userID string, disable bool) (schema
 	}, nil
 }
 
+func (u *UsersAPI) DeleteUser(creds Creds, userID string) (schema.UserDeleteResponse, error) {
+	log.Infof("userAPI: DeleteUser")
+	if !u.Authorize(creds) {
+		return schema.UserDeleteResponse{}, ErrorUnauthorized
+	}
+
+	if err := u.manager.Delete(userID); err != nil {
+		return schema.UserDeleteResponse{}, mapError(err)
+	}
+
+	return schema.UserDeleteResponse{
+		Ok: true,
+	}, nil
+}
+
 func (u *UsersAPI) CreateUser(creds Creds, usr schema.User, redirURL url.URL) (schema.UserCreateResponse, error) {
 	log.Infof("userAPI: CreateUser")
 	if !u.Authorize(creds) {
@@ -188,7 +219,9 @@ func (u *UsersAPI) CreateUser(creds Creds, usr schema.User, redirURL url.URL) (s
 	}, nil
 }
 
-func (u *UsersAPI) ListUsers(creds Creds, maxResults int, nextPageToken string) ([]*schema.User, string, error) {
+// ListUsers returns a page of the users matching filter. The filter is
+// ignored when nextPageToken is given, as it is carried by the token.
+func (u *UsersAPI) ListUsers(creds Creds, filter user.UserFilter, maxResults int, nextPageToken string) ([]*schema.User, string, error) {
 	log.Infof("userAPI: ListUsers")
 
 	if !u.Authorize(creds) {
@@ -199,12 +232,16 @@ func (u *UsersAPI) ListUsers(creds Creds, maxResults int, nextPageToken string)
 		return nil, "", ErrorMaxResultsTooHigh
 	}
 
-	users, tok, err := u.manager.List(user.UserFilter{}, maxResults, nextPageToken)
+	list := []*schema.User{}
+	users, tok, err := u.manager.List(filter, maxResults, nextPageToken)
+	if err == user.ErrorNotFound {
+		// No user matches the filter.
+		return list, "", nil
+	}
 	if err != nil {
 		return nil, "", mapError(err)
 	}
 
-	list := []*schema.User{}
 	for _, usr := range users {
 		schemaUsr := userToSchemaUser(usr)
 		list = append(list, &schemaUsr)
@@ -213,6 +250,93 @@ func (u *UsersAPI) ListUsers(creds Creds, maxResults int, nextPageToken string)
 	return list, tok, nil
 }
 
+func (u *UsersAPI) ListRemoteIdentities(creds Creds, userID string) (schema.RemoteIdentitiesResponse, error) {
+	log.Infof("userAPI: ListRemoteIdentities")
+	if !u.Authorize(creds) {
+		return schema.RemoteIdentitiesResponse{}, ErrorUnauthorized
+	}
+
+	return u.remoteIdentities(userID)
+}
+
+func (u *UsersAPI) LinkRemoteIdentity(creds Creds, userID string, rid schema.RemoteIdentity) (schema.RemoteIdentitiesResponse, error) {
+	log.Infof("userAPI: LinkRemoteIdentity")
+	if !u.Authorize(creds) {
+		return schema.RemoteIdentitiesResponse{}, ErrorUnauthorized
+	}
+
+	if err := u.manager.AddRemoteIdentity(userID, schemaRemoteIdentityToRemoteIdentity(rid)); err != nil {
+		return schema.RemoteIdentitiesResponse{}, mapError(err)
+	}
+	return u.remoteIdentities(userID)
+}
+
+func (u *UsersAPI) UnlinkRemoteIdentity(creds Creds, userID string, rid schema.RemoteIdentity) (schema.RemoteIdentitiesResponse, error) {
+	log.Infof("userAPI: UnlinkRemoteIdentity")
+	if !u.Authorize(creds) {
+		return schema.RemoteIdentitiesResponse{}, ErrorUnauthorized
+	}
+
+	if err := u.manager.RemoveRemoteIdentity(userID, schemaRemoteIdentityToRemoteIdentity(rid)); err != nil {
+		return schema.RemoteIdentitiesResponse{}, mapError(err)
+	}
+	return u.remoteIdentities(userID)
+}
+
+func (u *UsersAPI) remoteIdentities(userID string) (schema.RemoteIdentitiesResponse, error) {
+	rids, err := u.manager.GetRemoteIdentities(userID)
+	if err != nil {
+		return schema.RemoteIdentitiesResponse{}, mapError(err)
+	}
+
+	resp := schema.RemoteIdentitiesResponse{
+		RemoteIdentities: []*schema.RemoteIdentity{},
+	}
+	for _, rid := range rids {
+		resp.RemoteIdentities = append(resp.RemoteIdentities, &schema.RemoteIdentity{
+			ConnectorID: rid.ConnectorID,
+			Id:          rid.ID,
+		})
+	}
+	return resp, nil
+}
+
+func (u *UsersAPI) GetAttributes(creds Creds, userID string) (schema.UserAttributes, error) {
+	log.Infof("userAPI: GetAttributes")
+	if !u.Authorize(creds) {
+		return schema.UserAttributes{}, ErrorUnauthorized
+	}
+
+	attrs, err := u.manager.GetAttributes(userID)
+	if err != nil {
+		return schema.UserAttributes{}, mapError(err)
+	}
+	return schema.UserAttributes{Attributes: map[string]interface{}(attrs)}, nil
+}
+
+// SetAttributes replaces the custom attributes of a user.
+func (u *UsersAPI) SetAttributes(creds Creds, userID string, req schema.UserAttributes) (schema.UserAttributes, error) {
+	log.Infof("userAPI: SetAttributes")
+	if !u.Authorize(creds) {
+		return schema.UserAttributes{}, ErrorUnauthorized
+	}
+
+	attrs := user.Attributes{}
+	if req.Attributes != nil {
+		m, ok := req.Attributes.(map[string]interface{})
+		if !ok {
+			return schema.UserAttributes{}, ErrorInvalidAttributes
+		}
+		attrs = user.Attributes(m)
+	}
+
+	attrs, err := u.manager.SetAttributes(userID, attrs)
+	if err != nil {
+		return schema.UserAttributes{}, mapError(err)
+	}
+	return schema.UserAttributes{Attributes: map[string]interface{}(attrs)}, nil
+}
+
 func (u *UsersAPI) Authorize(creds Creds) bool {
 	return creds.User.Admin && !creds.User.Disabled
 }
@@ -240,13 +364,33 @@ func schemaUserToUser(usr schema.User) user.User {
 	}
 }
 
+func schemaRemoteIdentityToRemoteIdentity(rid schema.RemoteIdentity) user.RemoteIdentity {
+	return user.RemoteIdentity{
+		ConnectorID: rid.ConnectorID,
+		ID:          rid.Id,
+	}
+}
+
 func mapError(e error) error {
 	if mapped, ok := errorMap[e]; ok {
 		return mapped
 	}
+	if perr, ok := e.(user.PasswordPolicyError); ok {
+		return passwordPolicyError(perr)
+	}
 	return internalError(e)
 }
 
+// passwordPolicyError lists the violated rules in the description, one
+// sentence each.
+func passwordPolicyError(perr user.PasswordPolicyError) Error {
+	msgs := make([]string, len(perr.Violations))
+	for i, v := range perr.Violations {
+		msgs[i] = v.Message
+	}
+	return newError("invalid_password", strings.Join(msgs, " "), http.StatusBadRequest)
+}
+
 func generateTempHash() (string, error) {
 	b := make([]byte, 32)
 	n, err := rand.Read(b)
@@ -191,6 +191,7 @@ func TestGetUser(t *testing.T) {
 }
 
 func TestListUsers(t *testing.T) {
+	disabled := true
 	tests := []struct {
 		creds      Creds
 		filter     user.UserFilter
@@ -211,6 +212,21 @@ func TestListUsers(t *testing.T) {
 			maxResults: 3,
 			wantIDs:    [][]string{{"ID-1", "ID-2", "ID-3"}},
 		},
+		{
+			creds:      goodCreds,
+			filter:     user.UserFilter{Disabled: &disabled},
+			pages:      1,
+			maxResults: 10,
+			wantIDs:    [][]string{{"ID-4"}},
+		},
+		{
+			// No user matches.
+			creds:      goodCreds,
+			filter:     user.UserFilter{EmailPrefix: "nobody"},
+			pages:      1,
+			maxResults: 10,
+			wantIDs:    [][]string{nil},
+		},
 		{
 			creds:      badCreds,
 			pages:      3,
@@ -233,7 +249,7 @@ func TestListUsers(t *testing.T) {
 		var err error
 		var users []*schema.User
 		for x := 0; x < tt.pages; x++ {
-			users, next, err = api.ListUsers(tt.creds, tt.maxResults, next)
+			users, next, err = api.ListUsers(tt.creds, tt.filter, tt.maxResults, next)
 			if tt.wantErr != nil {
 				if err != tt.wantErr {
 					t.Errorf("case %d: want=%q, got=%q", i, tt.wantErr, err)
@@ -347,7 +363,7 @@ func TestCreateUser(t *testing.T) {
 
 			tok := ""
 			for {
-				list, tok, err := api.ListUsers(goodCreds, 100, tok)
+				list, tok, err := api.ListUsers(goodCreds, user.UserFilter{}, 100, tok)
 				if err != nil {
 					t.Fatalf("case %d: unexpected error: %v", i, err)
 					break
@@ -433,3 +449,126 @@ func TestDisableUsers(t *testing.T) {
 		}
 	}
 }
+
+func TestLinkRemoteIdentity(t *testing.T) {
+	api, _ := makeTestFixtures()
+
+	for _, id := range []string{"b", "a"} {
+		if _, err := api.LinkRemoteIdentity(goodCreds, "ID-2", schema.RemoteIdentity{ConnectorID: "local", Id: id}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	resp, err := api.ListRemoteIdentities(goodCreds, "ID-2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []*schema.RemoteIdentity{
+		{ConnectorID: "local", Id: "a"},
+		{ConnectorID: "local", Id: "b"},
+	}
+	if diff := pretty.Compare(want, resp.RemoteIdentities); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	tests := []struct {
+		creds   Creds
+		userID  string
+		rid     schema.RemoteIdentity
+		wantErr error
+	}{
+		{
+			creds:   goodCreds,
+			userID:  "ID-3",
+			rid:     schema.RemoteIdentity{ConnectorID: "local", Id: "a"},
+			wantErr: ErrorDuplicateRemoteIdentity,
+		},
+		{
+			creds:   goodCreds,
+			userID:  "ID-3",
+			rid:     schema.RemoteIdentity{ConnectorID: "nope", Id: "c"},
+			wantErr: ErrorInvalidConnector,
+		},
+		{
+			creds:   goodCreds,
+			userID:  "ID-5",
+			rid:     schema.RemoteIdentity{ConnectorID: "local", Id: "c"},
+			wantErr: ErrorResourceNotFound,
+		},
+		{
+			creds:   badCreds,
+			userID:  "ID-3",
+			rid:     schema.RemoteIdentity{ConnectorID: "local", Id: "c"},
+			wantErr: ErrorUnauthorized,
+		},
+	}
+	for i, tt := range tests {
+		_, err := api.LinkRemoteIdentity(tt.creds, tt.userID, tt.rid)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+		}
+	}
+}
+
+func TestUnlinkRemoteIdentity(t *testing.T) {
+	api, _ := makeTestFixtures()
+
+	a := schema.RemoteIdentity{ConnectorID: "local", Id: "a"}
+	b := schema.RemoteIdentity{ConnectorID: "local", Id: "b"}
+	for _, rid := range []schema.RemoteIdentity{a, b} {
+		if _, err := api.LinkRemoteIdentity(goodCreds, "ID-2", rid); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	resp, err := api.UnlinkRemoteIdentity(goodCreds, "ID-2", a)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]*schema.RemoteIdentity{&b}, resp.RemoteIdentities); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if _, err := api.UnlinkRemoteIdentity(goodCreds, "ID-2", a); err != ErrorResourceNotFound {
+		t.Errorf("want err=%v, got %v", ErrorResourceNotFound, err)
+	}
+	if _, err := api.UnlinkRemoteIdentity(goodCreds, "ID-2", b); err != ErrorLastRemoteIdentity {
+		t.Errorf("want err=%v, got %v", ErrorLastRemoteIdentity, err)
+	}
+}
+
+func TestDeleteUser(t *testing.T) {
+	tests := []struct {
+		creds   Creds
+		id      string
+		wantErr error
+	}{
+		{
+			creds: goodCreds,
+			id:    "ID-1",
+		},
+		{
+			creds:   goodCreds,
+			id:      "NO_ID",
+			wantErr: ErrorResourceNotFound,
+		},
+		{
+			creds:   badCreds,
+			id:      "ID-1",
+			wantErr: ErrorUnauthorized,
+		},
+	}
+
+	for i, tt := range tests {
+		api, _ := makeTestFixtures()
+		_, err := api.DeleteUser(tt.creds, tt.id)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+
+		_, err = api.GetUser(goodCreds, "ID-1")
+		if deleted := err == ErrorResourceNotFound; deleted != (tt.wantErr == nil) {
+			t.Errorf("case %d: want deleted=%v, got err=%v", i, tt.wantErr == nil, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sync"
+
+	"github.com/coreos/dex/repo"
+)
+
+const (
+	// MaxAttributes is the number of custom attributes a user may have.
+	MaxAttributes = 64
+
+	// MaxAttributeValueLength limits the JSON encoding of each attribute
+	// value.
+	MaxAttributeValueLength = 1024
+)
+
+var (
+	ErrorInvalidAttributeName  = errors.New("invalid attribute name")
+	ErrorInvalidAttributeValue = errors.New("attribute value must be a string, number, boolean or list of strings")
+	ErrorTooManyAttributes     = errors.New("too many attributes")
+
+	attributeNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.-]{0,63}$`)
+)
+
+// Attributes are the custom attributes of a user, by name. Values are
+// strings, numbers, booleans or lists of strings, as decoded from JSON by
+// Normalize.
+type Attributes map[string]interface{}
+
+// Normalize checks the names and values of the attributes, and returns them
+// with numbers as float64 and lists as []string.
+func (a Attributes) Normalize() (Attributes, error) {
+	if len(a) > MaxAttributes {
+		return nil, ErrorTooManyAttributes
+	}
+
+	norm := make(Attributes, len(a))
+	for name, value := range a {
+		if !attributeNameRegexp.MatchString(name) {
+			return nil, ErrorInvalidAttributeName
+		}
+
+		b, err := json.Marshal(value)
+		if err != nil || len(b) > MaxAttributeValueLength {
+			return nil, ErrorInvalidAttributeValue
+		}
+		v, err := decodeAttributeValue(b)
+		if err != nil {
+			return nil, err
+		}
+		norm[name] = v
+	}
+	return norm, nil
+}
+
+func decodeAttributeValue(b []byte) (interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, ErrorInvalidAttributeValue
+	}
+
+	switch v := v.(type) {
+	case string, float64, bool:
+		return v, nil
+	case []interface{}:
+		list := make([]string, len(v))
+		for i, e := range v {
+			s, ok := e.(string)
+			if !ok {
+				return nil, ErrorInvalidAttributeValue
+			}
+			list[i] = s
+		}
+		return list, nil
+	}
+	return nil, ErrorInvalidAttributeValue
+}
+
+// EncodeAttributeValue returns the JSON encoding of a normalized attribute
+// value, as stored by AttributeRepos.
+func EncodeAttributeValue(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// DecodeAttributeValue reverses EncodeAttributeValue.
+func DecodeAttributeValue(s string) (interface{}, error) {
+	return decodeAttributeValue([]byte(s))
+}
+
+type AttributeRepo interface {
+	// Get returns the attributes of the user with the given ID, which are
+	// empty if none were set.
+	Get(tx repo.Transaction, userID string) (Attributes, error)
+
+	// Set replaces the attributes of the user with the given ID, which
+	// must be normalized.
+	Set(tx repo.Transaction, userID string, attrs Attributes) error
+}
+
+func NewAttributeRepo() AttributeRepo {
+	return &memAttributeRepo{
+		attrs: make(map[string]Attributes),
+	}
+}
+
+type memAttributeRepo struct {
+	mu    sync.Mutex
+	attrs map[string]Attributes
+}
+
+func (r *memAttributeRepo) Get(_ repo.Transaction, userID string) (Attributes, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return copyAttributes(r.attrs[userID]), nil
+}
+
+func (r *memAttributeRepo) Set(_ repo.Transaction, userID string, attrs Attributes) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(attrs) == 0 {
+		delete(r.attrs, userID)
+		return nil
+	}
+	r.attrs[userID] = copyAttributes(attrs)
+	return nil
+}
+
+func (r *memAttributeRepo) Snapshot() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.Marshal(r.attrs)
+}
+
+func (r *memAttributeRepo) Restore(b []byte) error {
+	var attrs map[string]Attributes
+	if err := json.Unmarshal(b, &attrs); err != nil {
+		return err
+	}
+	for userID, a := range attrs {
+		norm, err := a.Normalize()
+		if err != nil {
+			return err
+		}
+		attrs[userID] = norm
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attrs = make(map[string]Attributes, len(attrs))
+	for userID, a := range attrs {
+		r.attrs[userID] = a
+	}
+	return nil
+}
+
+func copyAttributes(a Attributes) Attributes {
+	c := make(Attributes, len(a))
+	for name, value := range a {
+		if list, ok := value.([]string); ok {
+			value = append([]string(nil), list...)
+		}
+		c[name] = value
+	}
+	return c
+}
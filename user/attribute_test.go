@@ -0,0 +1,81 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestAttributesNormalize(t *testing.T) {
+	tooMany := Attributes{}
+	for i := 0; i <= MaxAttributes; i++ {
+		tooMany[strings.Repeat("a", i+1)] = true
+	}
+
+	tests := []struct {
+		attrs Attributes
+		want  Attributes
+		err   error
+	}{
+		{
+			attrs: Attributes{
+				"department":  "Engineering",
+				"employee_id": 1234,
+				"manager":     false,
+				"roles":       []interface{}{"dev", "ops"},
+				"cost.center": []string{},
+			},
+			want: Attributes{
+				"department":  "Engineering",
+				"employee_id": float64(1234),
+				"manager":     false,
+				"roles":       []string{"dev", "ops"},
+				"cost.center": []string{},
+			},
+		},
+		{
+			attrs: Attributes{},
+			want:  Attributes{},
+		},
+		{
+			attrs: Attributes{"1st": "a"},
+			err:   ErrorInvalidAttributeName,
+		},
+		{
+			attrs: Attributes{"first name": "a"},
+			err:   ErrorInvalidAttributeName,
+		},
+		{
+			attrs: Attributes{"address": map[string]interface{}{"city": "Berlin"}},
+			err:   ErrorInvalidAttributeValue,
+		},
+		{
+			attrs: Attributes{"roles": []interface{}{"dev", 1}},
+			err:   ErrorInvalidAttributeValue,
+		},
+		{
+			attrs: Attributes{"manager": nil},
+			err:   ErrorInvalidAttributeValue,
+		},
+		{
+			attrs: Attributes{"bio": strings.Repeat("a", MaxAttributeValueLength)},
+			err:   ErrorInvalidAttributeValue,
+		},
+		{
+			attrs: tooMany,
+			err:   ErrorTooManyAttributes,
+		},
+	}
+
+	for i, tt := range tests {
+		got, err := tt.attrs.Normalize()
+		if err != tt.err {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.err, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
@@ -205,6 +205,31 @@ func (u *UserEmailer) SendEmailVerification(userID, clientID string, redirectURL
 	return &verifyURL, nil
 }
 
+// SendLockoutNotification tells the user with the given userID that their
+// account has been locked until the given time after too many failed logins.
+// Nothing is sent if no emailer is configured.
+func (u *UserEmailer) SendLockoutNotification(userID string, lockedUntil time.Time) error {
+	usr, err := u.ur.Get(nil, userID)
+	if err != nil {
+		log.Errorf("Error getting user: %q", err)
+		return err
+	}
+
+	if u.emailer == nil || usr.Email == "" {
+		return nil
+	}
+
+	err = u.emailer.SendMail(u.fromAddress, "Your account has been locked", "lockout",
+		map[string]interface{}{
+			"email":       usr.Email,
+			"lockedUntil": lockedUntil.UTC().Format(time.RFC1123),
+		}, usr.Email)
+	if err != nil {
+		log.Errorf("error sending lockout notification email %v: ", err)
+	}
+	return err
+}
+
 func (u *UserEmailer) SetEmailer(emailer *email.TemplatizedEmailer) {
 	u.emailer = emailer
 }
@@ -0,0 +1,222 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/dex/repo"
+)
+
+var (
+	ErrorGroupNotFound      = errors.New("group not found in repository")
+	ErrorInvalidGroupName   = errors.New("invalid group display name")
+	ErrorDuplicateGroupName = errors.New("group display name not available")
+)
+
+// Group is a named set of users, as provisioned by SCIM clients.
+type Group struct {
+	// ID is the machine-generated, stable, unique identifier for this Group.
+	ID string
+
+	// DisplayName is the unique, human readable name of the group.
+	DisplayName string
+
+	// Members holds the IDs of the users in the group, in ascending order.
+	Members []string
+
+	CreatedAt time.Time
+}
+
+type GroupRepo interface {
+	// Get returns the group with the given ID, or ErrorGroupNotFound.
+	Get(tx repo.Transaction, id string) (Group, error)
+
+	// List returns all groups, ordered by display name.
+	List(tx repo.Transaction) ([]Group, error)
+
+	// GetByMember returns the groups the user is a member of, ordered by
+	// display name.
+	GetByMember(tx repo.Transaction, userID string) ([]Group, error)
+
+	Create(repo.Transaction, Group) error
+
+	// Update stores the display name and members of a group.
+	Update(repo.Transaction, Group) error
+
+	Delete(tx repo.Transaction, id string) error
+
+	// RemoveMember removes the user from all groups.
+	RemoveMember(tx repo.Transaction, userID string) error
+}
+
+func NewGroupRepo() GroupRepo {
+	return &memGroupRepo{
+		groups: make(map[string]Group),
+	}
+}
+
+type memGroupRepo struct {
+	mu     sync.Mutex
+	groups map[string]Group
+}
+
+func (r *memGroupRepo) Get(_ repo.Transaction, id string) (Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	g, ok := r.groups[id]
+	if !ok {
+		return Group{}, ErrorGroupNotFound
+	}
+	return copyGroup(g), nil
+}
+
+func (r *memGroupRepo) List(_ repo.Transaction) ([]Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := []Group{}
+	for _, g := range r.groups {
+		groups = append(groups, copyGroup(g))
+	}
+	sort.Sort(groupsByDisplayName(groups))
+	return groups, nil
+}
+
+func (r *memGroupRepo) GetByMember(_ repo.Transaction, userID string) ([]Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	groups := []Group{}
+	for _, g := range r.groups {
+		if hasMember(g, userID) {
+			groups = append(groups, copyGroup(g))
+		}
+	}
+	sort.Sort(groupsByDisplayName(groups))
+	return groups, nil
+}
+
+func (r *memGroupRepo) Create(_ repo.Transaction, g Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g.ID == "" {
+		return ErrorInvalidID
+	}
+	if g.DisplayName == "" {
+		return ErrorInvalidGroupName
+	}
+	if _, ok := r.groups[g.ID]; ok {
+		return ErrorDuplicateID
+	}
+	if r.nameTaken(g) {
+		return ErrorDuplicateGroupName
+	}
+
+	r.groups[g.ID] = copyGroup(g)
+	return nil
+}
+
+func (r *memGroupRepo) Update(_ repo.Transaction, g Group) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g.DisplayName == "" {
+		return ErrorInvalidGroupName
+	}
+	existing, ok := r.groups[g.ID]
+	if !ok {
+		return ErrorGroupNotFound
+	}
+	if r.nameTaken(g) {
+		return ErrorDuplicateGroupName
+	}
+
+	g.CreatedAt = existing.CreatedAt
+	r.groups[g.ID] = copyGroup(g)
+	return nil
+}
+
+func (r *memGroupRepo) Delete(_ repo.Transaction, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.groups[id]; !ok {
+		return ErrorGroupNotFound
+	}
+	delete(r.groups, id)
+	return nil
+}
+
+func (r *memGroupRepo) RemoveMember(_ repo.Transaction, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, g := range r.groups {
+		var members []string
+		for _, m := range g.Members {
+			if m != userID {
+				members = append(members, m)
+			}
+		}
+		g.Members = members
+		r.groups[id] = g
+	}
+	return nil
+}
+
+func (r *memGroupRepo) Snapshot() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return json.Marshal(r.groups)
+}
+
+func (r *memGroupRepo) Restore(b []byte) error {
+	groups := make(map[string]Group)
+	if err := json.Unmarshal(b, &groups); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.groups = groups
+	return nil
+}
+
+// nameTaken reports whether another group than g has its display name.
+func (r *memGroupRepo) nameTaken(g Group) bool {
+	for id, other := range r.groups {
+		if id != g.ID && other.DisplayName == g.DisplayName {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMember(g Group, userID string) bool {
+	for _, m := range g.Members {
+		if m == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// copyGroup returns a copy of g with its members deduplicated and sorted, so
+// that callers never share the slice with the repo.
+func copyGroup(g Group) Group {
+	seen := make(map[string]bool)
+	var members []string
+	for _, m := range g.Members {
+		if !seen[m] {
+			seen[m] = true
+			members = append(members, m)
+		}
+	}
+	sort.Strings(members)
+	g.Members = members
+	return g
+}
+
+type groupsByDisplayName []Group
+
+func (s groupsByDisplayName) Len() int           { return len(s) }
+func (s groupsByDisplayName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s groupsByDisplayName) Less(i, j int) bool { return s[i].DisplayName < s[j].DisplayName }
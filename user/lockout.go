@@ -0,0 +1,287 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/repo"
+)
+
+var (
+	ErrorLockedOut = errors.New("too many failed login attempts")
+
+	// DefaultLockoutPolicy locks an account for a minute after five failed
+	// logins in a row, doubling the lockout with every further failure up to
+	// an hour.
+	DefaultLockoutPolicy = LockoutPolicy{
+		MaxFailures:      5,
+		MaxFailuresPerIP: 100,
+		Duration:         time.Minute,
+		MaxDuration:      time.Hour,
+		ResetAfter:       24 * time.Hour,
+	}
+)
+
+// LoginAttempts counts the consecutive failed logins for an account or a
+// remote address.
+type LoginAttempts struct {
+	// Key identifies what is being counted; see accountKey and ipKey.
+	Key string
+
+	Failures    int
+	LastFailure time.Time
+
+	// LockedUntil is the time until which no login is allowed. It is zero
+	// if the key has never been locked.
+	LockedUntil time.Time
+}
+
+// LockoutPolicy determines when accounts and remote addresses are locked.
+type LockoutPolicy struct {
+	// MaxFailures is the number of consecutive failed logins after which
+	// an account is locked. Zero disables account lockout.
+	MaxFailures int
+
+	// MaxFailuresPerIP is the number of consecutive failed logins from a
+	// single remote address, whatever the account, after which the address
+	// is locked. Zero disables address lockout.
+	MaxFailuresPerIP int
+
+	// Duration is how long a key is locked when it first reaches its limit.
+	// Every further failure doubles the lockout, up to MaxDuration.
+	Duration    time.Duration
+	MaxDuration time.Duration
+
+	// ResetAfter is the period after which earlier failures are forgotten.
+	ResetAfter time.Duration
+}
+
+// lockDuration returns how long a key is locked after the given number of
+// failures, if the limit is max.
+func (p LockoutPolicy) lockDuration(failures, max int) time.Duration {
+	if max <= 0 || failures < max {
+		return 0
+	}
+
+	d := p.Duration
+	for i := max; i < failures && d < p.MaxDuration; i++ {
+		d *= 2
+	}
+	if p.MaxDuration > 0 && d > p.MaxDuration {
+		d = p.MaxDuration
+	}
+	return d
+}
+
+type LoginAttemptRepo interface {
+	Get(tx repo.Transaction, key string) (LoginAttempts, error)
+
+	// Put creates or replaces the LoginAttempts with the given key.
+	Put(repo.Transaction, LoginAttempts) error
+
+	Delete(tx repo.Transaction, key string) error
+}
+
+func NewLoginAttemptRepo() LoginAttemptRepo {
+	return &memLoginAttemptRepo{
+		attempts: make(map[string]LoginAttempts),
+	}
+}
+
+type memLoginAttemptRepo struct {
+	mu       sync.Mutex
+	attempts map[string]LoginAttempts
+}
+
+func (m *memLoginAttemptRepo) Get(_ repo.Transaction, key string) (LoginAttempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	la, ok := m.attempts[key]
+	if !ok {
+		return LoginAttempts{}, ErrorNotFound
+	}
+	return la, nil
+}
+
+func (m *memLoginAttemptRepo) Put(_ repo.Transaction, la LoginAttempts) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if la.Key == "" {
+		return ErrorInvalidID
+	}
+
+	m.attempts[la.Key] = la
+	return nil
+}
+
+func (m *memLoginAttemptRepo) Delete(_ repo.Transaction, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.attempts[key]; !ok {
+		return ErrorNotFound
+	}
+
+	delete(m.attempts, key)
+	return nil
+}
+
+func (m *memLoginAttemptRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(m.attempts)
+}
+
+func (m *memLoginAttemptRepo) Restore(b []byte) error {
+	attempts := make(map[string]LoginAttempts)
+	if err := json.Unmarshal(b, &attempts); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.attempts = attempts
+	return nil
+}
+
+// LoginThrottler tracks failed logins per account and per remote address,
+// and locks either once they exceed the limits of its Policy. Its state is
+// kept in a LoginAttemptRepo so that it is shared by all workers using the
+// same repo.
+type LoginThrottler struct {
+	Policy LockoutPolicy
+	Clock  clockwork.Clock
+
+	repo  LoginAttemptRepo
+	begin repo.TransactionFactory
+}
+
+func NewLoginThrottler(r LoginAttemptRepo, txnFactory repo.TransactionFactory, policy LockoutPolicy) *LoginThrottler {
+	return &LoginThrottler{
+		Policy: policy,
+		Clock:  clockwork.NewRealClock(),
+		repo:   r,
+		begin:  txnFactory,
+	}
+}
+
+// Locked returns ErrorLockedOut if logins to the given account, or from the
+// given remote address, are not allowed at the moment. Either may be empty.
+func (t *LoginThrottler) Locked(userID, ip string) error {
+	now := t.Clock.Now()
+	for _, key := range t.keys(userID, ip) {
+		la, err := t.repo.Get(nil, key)
+		if err == ErrorNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if now.Before(la.LockedUntil) {
+			return ErrorLockedOut
+		}
+	}
+	return nil
+}
+
+// RecordFailure records a failed login to the given account from the given
+// remote address. If this failure locks the account, the time until which it
+// is locked is returned; otherwise the returned time is zero.
+func (t *LoginThrottler) RecordFailure(userID, ip string) (time.Time, error) {
+	var lockedUntil time.Time
+	if ip != "" {
+		if _, err := t.recordFailure(ipKey(ip), t.Policy.MaxFailuresPerIP); err != nil {
+			return time.Time{}, err
+		}
+	}
+	if userID != "" {
+		var err error
+		if lockedUntil, err = t.recordFailure(accountKey(userID), t.Policy.MaxFailures); err != nil {
+			return time.Time{}, err
+		}
+	}
+	return lockedUntil, nil
+}
+
+func (t *LoginThrottler) recordFailure(key string, max int) (time.Time, error) {
+	tx, err := t.begin()
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer tx.Rollback()
+
+	now := t.Clock.Now()
+	la, err := t.repo.Get(tx, key)
+	if err == ErrorNotFound || (err == nil && t.Policy.ResetAfter > 0 && now.Sub(la.LastFailure) > t.Policy.ResetAfter) {
+		la, err = LoginAttempts{Key: key}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	la.Failures++
+	la.LastFailure = now
+
+	var lockedUntil time.Time
+	if d := t.Policy.lockDuration(la.Failures, max); d > 0 {
+		lockedUntil = now.Add(d)
+		la.LockedUntil = lockedUntil
+	}
+
+	if err := t.repo.Put(tx, la); err != nil {
+		return time.Time{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return time.Time{}, err
+	}
+	return lockedUntil, nil
+}
+
+// RecordSuccess forgets the failed logins to the given account. Failures
+// from the remote address are kept, so that an attacker cannot reset them by
+// logging into an account of their own.
+func (t *LoginThrottler) RecordSuccess(userID string) error {
+	return t.Unlock(userID)
+}
+
+// Unlock forgets the failed logins to the given account, lifting any
+// lockout.
+func (t *LoginThrottler) Unlock(userID string) error {
+	err := t.repo.Delete(nil, accountKey(userID))
+	if err == ErrorNotFound {
+		return nil
+	}
+	return err
+}
+
+// DeleteAccountLoginAttempts forgets the failed logins to the given account
+// within tx, for use when the account itself is deleted.
+func DeleteAccountLoginAttempts(tx repo.Transaction, r LoginAttemptRepo, userID string) error {
+	err := r.Delete(tx, accountKey(userID))
+	if err == ErrorNotFound {
+		return nil
+	}
+	return err
+}
+
+func (t *LoginThrottler) keys(userID, ip string) []string {
+	var keys []string
+	if userID != "" {
+		keys = append(keys, accountKey(userID))
+	}
+	if ip != "" {
+		keys = append(keys, ipKey(ip))
+	}
+	return keys
+}
+
+func accountKey(userID string) string {
+	return "user:" + userID
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/repo"
+)
+
+func TestLockDuration(t *testing.T) {
+	p := LockoutPolicy{
+		Duration:    time.Minute,
+		MaxDuration: 10 * time.Minute,
+	}
+
+	tests := []struct {
+		failures int
+		max      int
+		want     time.Duration
+	}{
+		{failures: 2, max: 3, want: 0},
+		{failures: 3, max: 3, want: time.Minute},
+		{failures: 4, max: 3, want: 2 * time.Minute},
+		{failures: 6, max: 3, want: 8 * time.Minute},
+		{failures: 7, max: 3, want: 10 * time.Minute},
+		{failures: 1000, max: 3, want: 10 * time.Minute},
+		// disabled
+		{failures: 1000, max: 0, want: 0},
+	}
+
+	for i, tt := range tests {
+		if got := p.lockDuration(tt.failures, tt.max); got != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, got)
+		}
+	}
+}
+
+func TestLoginThrottler(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	lt := NewLoginThrottler(NewLoginAttemptRepo(), repo.InMemTransactionFactory, LockoutPolicy{
+		MaxFailures:      2,
+		MaxFailuresPerIP: 3,
+		Duration:         time.Minute,
+		MaxDuration:      time.Hour,
+		ResetAfter:       time.Hour,
+	})
+	lt.Clock = clock
+
+	record := func(userID, ip string) time.Time {
+		lockedUntil, err := lt.RecordFailure(userID, ip)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return lockedUntil
+	}
+
+	if until := record("ID-1", "192.0.2.1"); !until.IsZero() {
+		t.Fatalf("locked after one failure until %v", until)
+	}
+
+	// Failures older than ResetAfter are forgotten.
+	clock.Advance(2 * time.Hour)
+	if until := record("ID-1", "192.0.2.2"); !until.IsZero() {
+		t.Fatalf("locked after stale failure until %v", until)
+	}
+	if until := record("ID-1", "192.0.2.2"); !until.Equal(clock.Now().Add(time.Minute)) {
+		t.Fatalf("want account locked for a minute, got %v", until)
+	}
+	if err := lt.Locked("ID-1", ""); err != ErrorLockedOut {
+		t.Fatalf("want=%v, got=%v", ErrorLockedOut, err)
+	}
+	if err := lt.Locked("ID-2", "192.0.2.1"); err != nil {
+		t.Fatalf("other account locked: %v", err)
+	}
+
+	// The address is locked independently of the accounts tried.
+	record("ID-2", "192.0.2.2")
+	if err := lt.Locked("ID-3", "192.0.2.2"); err != ErrorLockedOut {
+		t.Fatalf("want=%v, got=%v", ErrorLockedOut, err)
+	}
+
+	// A success or an unlock only clears the account.
+	if err := lt.RecordSuccess("ID-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := lt.Locked("ID-1", ""); err != nil {
+		t.Fatalf("want account unlocked, got %v", err)
+	}
+	if err := lt.Locked("ID-1", "192.0.2.2"); err != ErrorLockedOut {
+		t.Fatalf("want=%v, got=%v", ErrorLockedOut, err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := lt.Locked("ID-1", "192.0.2.2"); err != nil {
+		t.Fatalf("want address unlocked after lockout, got %v", err)
+	}
+}
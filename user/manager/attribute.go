@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"github.com/coreos/dex/user"
+)
+
+// GetAttributes returns the custom attributes of the user with the given ID.
+func (m *UserManager) GetAttributes(userID string) (user.Attributes, error) {
+	if m.attributeRepo == nil {
+		return nil, ErrorAttributesUnavailable
+	}
+	if _, err := m.userRepo.Get(nil, userID); err != nil {
+		return nil, err
+	}
+	return m.attributeRepo.Get(nil, userID)
+}
+
+// SetAttributes replaces the custom attributes of the user with the given ID.
+// The normalized attributes are returned.
+func (m *UserManager) SetAttributes(userID string, attrs user.Attributes) (user.Attributes, error) {
+	if m.attributeRepo == nil {
+		return nil, ErrorAttributesUnavailable
+	}
+
+	attrs, err := attrs.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := m.begin()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := m.userRepo.Get(tx, userID); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+	if err := m.attributeRepo.Set(tx, userID, attrs); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+	return attrs, nil
+}
@@ -0,0 +1,68 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+func TestSetAttributes(t *testing.T) {
+	tests := []struct {
+		userID string
+		attrs  user.Attributes
+		want   user.Attributes
+		err    error
+	}{
+		{
+			userID: "ID-1",
+			attrs:  user.Attributes{"level": 3, "roles": []interface{}{"dev"}},
+			want:   user.Attributes{"level": float64(3), "roles": []string{"dev"}},
+		},
+		{
+			userID: "ID-1",
+			attrs:  user.Attributes{"roles": []interface{}{3}},
+			err:    user.ErrorInvalidAttributeValue,
+		},
+		{
+			userID: "ID-3",
+			attrs:  user.Attributes{"level": 3},
+			err:    user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+			AttributeRepo: user.NewAttributeRepo(),
+		})
+
+		got, err := f.mgr.SetAttributes(tt.userID, tt.attrs)
+		if err != tt.err {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.err, err)
+			continue
+		}
+		if tt.err != nil {
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+
+		stored, err := f.mgr.GetAttributes(tt.userID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.want, stored); diff != "" {
+			t.Errorf("case %d: Compare(want, stored): %v", i, diff)
+		}
+	}
+
+	f := makeTestFixtures()
+	if _, err := f.mgr.SetAttributes("ID-1", user.Attributes{}); err != ErrorAttributesUnavailable {
+		t.Errorf("want err=%v, got %v", ErrorAttributesUnavailable, err)
+	}
+}
@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+func (m *UserManager) GetGroup(id string) (user.Group, error) {
+	if m.groupRepo == nil {
+		return user.Group{}, ErrorGroupsUnavailable
+	}
+	return m.groupRepo.Get(nil, id)
+}
+
+// ListGroups returns all groups, ordered by display name.
+func (m *UserManager) ListGroups() ([]user.Group, error) {
+	if m.groupRepo == nil {
+		return nil, ErrorGroupsUnavailable
+	}
+	return m.groupRepo.List(nil)
+}
+
+// GetGroupsOfUser returns the groups the user with the given ID is a member
+// of, ordered by display name.
+func (m *UserManager) GetGroupsOfUser(userID string) ([]user.Group, error) {
+	if m.groupRepo == nil {
+		return nil, ErrorGroupsUnavailable
+	}
+	return m.groupRepo.GetByMember(nil, userID)
+}
+
+// CreateGroup creates a group with the given display name and members, which
+// must be IDs of existing users. The created group is returned.
+func (m *UserManager) CreateGroup(displayName string, members []string) (user.Group, error) {
+	if m.groupRepo == nil {
+		return user.Group{}, ErrorGroupsUnavailable
+	}
+
+	tx, err := m.begin()
+	if err != nil {
+		return user.Group{}, err
+	}
+
+	id, err := m.userIDGenerator()
+	if err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+	g := user.Group{
+		ID:          id,
+		DisplayName: displayName,
+		Members:     members,
+		CreatedAt:   m.Clock.Now(),
+	}
+	if err := m.checkMembers(tx, g.Members); err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+	if err := m.groupRepo.Create(tx, g); err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+
+	return m.commitGroup(tx, g.ID)
+}
+
+// UpdateGroup replaces the display name and members of the group with the
+// ID of g. The updated group is returned.
+func (m *UserManager) UpdateGroup(g user.Group) (user.Group, error) {
+	if m.groupRepo == nil {
+		return user.Group{}, ErrorGroupsUnavailable
+	}
+
+	tx, err := m.begin()
+	if err != nil {
+		return user.Group{}, err
+	}
+
+	if err := m.checkMembers(tx, g.Members); err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+	if err := m.groupRepo.Update(tx, g); err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+
+	return m.commitGroup(tx, g.ID)
+}
+
+func (m *UserManager) DeleteGroup(id string) error {
+	if m.groupRepo == nil {
+		return ErrorGroupsUnavailable
+	}
+
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+
+	if err := m.groupRepo.Delete(tx, id); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return err
+	}
+	return nil
+}
+
+// checkMembers returns user.ErrorNotFound unless all members are IDs of
+// existing users.
+func (m *UserManager) checkMembers(tx repo.Transaction, members []string) error {
+	for _, userID := range members {
+		if _, err := m.userRepo.Get(tx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// commitGroup reads back the group with the given ID and commits tx.
+func (m *UserManager) commitGroup(tx repo.Transaction, id string) (user.Group, error) {
+	g, err := m.groupRepo.Get(tx, id)
+	if err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return user.Group{}, err
+	}
+	return g, nil
+}
@@ -0,0 +1,142 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+func makeGroupTestFixtures() *testFixtures {
+	f := makeTestFixtures()
+	f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+		GroupRepo: user.NewGroupRepo(),
+	})
+	f.mgr.Clock = f.clock
+	f.mgr.userIDGenerator = func() (string, error) {
+		return "group-1", nil
+	}
+	return f
+}
+
+func TestCreateGroup(t *testing.T) {
+	tests := []struct {
+		displayName string
+		members     []string
+		want        user.Group
+		wantErr     error
+	}{
+		{
+			displayName: "Engineering",
+			members:     []string{"ID-2", "ID-1", "ID-2"},
+			want: user.Group{
+				ID:          "group-1",
+				DisplayName: "Engineering",
+				Members:     []string{"ID-1", "ID-2"},
+			},
+		},
+		{
+			displayName: "Empty",
+			want: user.Group{
+				ID:          "group-1",
+				DisplayName: "Empty",
+			},
+		},
+		{
+			displayName: "Engineering",
+			members:     []string{"ID-1", "ID-3"},
+			wantErr:     user.ErrorNotFound,
+		},
+		{
+			members: []string{"ID-1"},
+			wantErr: user.ErrorInvalidGroupName,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeGroupTestFixtures()
+		tt.want.CreatedAt = f.clock.Now()
+
+		got, err := f.mgr.CreateGroup(tt.displayName, tt.members)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			if groups, _ := f.mgr.ListGroups(); len(groups) != 0 {
+				t.Errorf("case %d: want no groups, got %v", i, groups)
+			}
+			continue
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestUpdateGroup(t *testing.T) {
+	tests := []struct {
+		group   user.Group
+		want    user.Group
+		wantErr error
+	}{
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Developers", Members: []string{"ID-2"}},
+			want:  user.Group{ID: "group-1", DisplayName: "Developers", Members: []string{"ID-2"}},
+		},
+		{
+			group: user.Group{ID: "group-1", DisplayName: "Engineering"},
+			want:  user.Group{ID: "group-1", DisplayName: "Engineering"},
+		},
+		{
+			group:   user.Group{ID: "group-1", DisplayName: "Engineering", Members: []string{"ID-3"}},
+			wantErr: user.ErrorNotFound,
+		},
+		{
+			group:   user.Group{ID: "group-1", DisplayName: "Sales"},
+			wantErr: user.ErrorDuplicateGroupName,
+		},
+		{
+			group:   user.Group{ID: "group-2", DisplayName: "Marketing"},
+			wantErr: user.ErrorGroupNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeGroupTestFixtures()
+		if _, err := f.mgr.CreateGroup("Engineering", []string{"ID-1"}); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		f.mgr.userIDGenerator = func() (string, error) {
+			return "group-3", nil
+		}
+		if _, err := f.mgr.CreateGroup("Sales", nil); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		got, err := f.mgr.UpdateGroup(tt.group)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+		tt.want.CreatedAt = f.clock.Now()
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
+func TestGroupsUnavailable(t *testing.T) {
+	f := makeTestFixtures()
+	if _, err := f.mgr.CreateGroup("Engineering", nil); err != ErrorGroupsUnavailable {
+		t.Errorf("want err=%v, got %v", ErrorGroupsUnavailable, err)
+	}
+	if _, err := f.mgr.ListGroups(); err != ErrorGroupsUnavailable {
+		t.Errorf("want err=%v, got %v", ErrorGroupsUnavailable, err)
+	}
+}
@@ -0,0 +1,381 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const (
+	// The strategies for importing records of users that already exist.
+	ImportSkip   = "skip"
+	ImportUpdate = "update"
+	ImportFail   = "fail"
+
+	DefaultImportBatchSize = 100
+
+	exportPageSize = 100
+)
+
+var (
+	ErrorUnknownImportStrategy  = errors.New("unknown import conflict strategy")
+	ErrorImportConflict         = errors.New("user already exists")
+	ErrorImportAmbiguous        = errors.New("record matches more than one existing user")
+	ErrorImportNoLocalConnector = errors.New("no local connector to log in with the password")
+)
+
+type ImportOptions struct {
+	// OnConflict is what to do with records of users that already exist:
+	// ImportSkip, ImportUpdate or ImportFail. It defaults to ImportFail.
+	OnConflict string
+
+	// BatchSize is the number of records imported in one transaction. It
+	// defaults to DefaultImportBatchSize.
+	BatchSize int
+
+	// DryRun only checks the records against the existing users, without
+	// importing them. Conflicts between records of the same import are not
+	// detected.
+	DryRun bool
+}
+
+type ImportResult struct {
+	Created int
+	Updated int
+	Skipped int
+}
+
+type importAction int
+
+const (
+	importCreated importAction = iota
+	importUpdated
+	importSkipped
+)
+
+// ImportUsers imports the user records read from r. A record belongs to an
+// existing user if its ID, email or any of its remote identities do; such
+// records are handled according to opts.OnConflict. Users imported with a
+// password hash are linked to the local connector, which they log in with.
+//
+// Records are imported in batches, each in its own transaction. If a record
+// fails to import, its batch is rolled back and the import stops; the
+// result covers the batches imported up to then.
+func (m *UserManager) ImportUsers(r user.UserRecordReader, opts ImportOptions) (ImportResult, error) {
+	switch opts.OnConflict {
+	case "":
+		opts.OnConflict = ImportFail
+	case ImportSkip, ImportUpdate, ImportFail:
+	default:
+		return ImportResult{}, ErrorUnknownImportStrategy
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = DefaultImportBatchSize
+	}
+
+	localConnID, err := m.localConnectorID()
+	if err != nil {
+		return ImportResult{}, err
+	}
+
+	var res ImportResult
+	n := 0
+	for {
+		var recs []user.UserRecord
+		for len(recs) < opts.BatchSize {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return res, err
+			}
+			recs = append(recs, rec)
+		}
+		if len(recs) == 0 {
+			return res, nil
+		}
+
+		batch, err := m.importBatch(recs, n, localConnID, opts)
+		if err != nil {
+			return res, err
+		}
+		res.Created += batch.Created
+		res.Updated += batch.Updated
+		res.Skipped += batch.Skipped
+		n += len(recs)
+	}
+}
+
+// localConnectorID returns the ID of the local connector, or an empty string
+// if there is none.
+func (m *UserManager) localConnectorID() (string, error) {
+	cfgs, err := m.connCfgRepo.All()
+	if err != nil {
+		return "", err
+	}
+	for _, cfg := range cfgs {
+		if cfg.ConnectorType() == connector.LocalConnectorType {
+			return cfg.ConnectorID(), nil
+		}
+	}
+	return "", nil
+}
+
+// importBatch imports recs, which follow the first n records of the import.
+// Users with a password are linked to the local connector localConnID.
+func (m *UserManager) importBatch(recs []user.UserRecord, n int, localConnID string, opts ImportOptions) (ImportResult, error) {
+	var (
+		tx  repo.Transaction
+		err error
+	)
+	if !opts.DryRun {
+		if tx, err = m.begin(); err != nil {
+			return ImportResult{}, err
+		}
+	}
+
+	var res ImportResult
+	for i, rec := range recs {
+		action, err := m.importRecord(tx, rec, localConnID, opts)
+		if err != nil {
+			if tx != nil {
+				rollback(tx)
+			}
+			return ImportResult{}, fmt.Errorf("record %d (%s): %v", n+i+1, rec.Email, err)
+		}
+		switch action {
+		case importCreated:
+			res.Created++
+		case importUpdated:
+			res.Updated++
+		case importSkipped:
+			res.Skipped++
+		}
+	}
+
+	if tx != nil {
+		if err = tx.Commit(); err != nil {
+			rollback(tx)
+			return ImportResult{}, err
+		}
+	}
+	return res, nil
+}
+
+func (m *UserManager) importRecord(tx repo.Transaction, rec user.UserRecord, localConnID string, opts ImportOptions) (importAction, error) {
+	usr := rec.User()
+	rids := rec.Identities()
+	if !user.ValidEmail(usr.Email) {
+		return 0, user.ErrorInvalidEmail
+	}
+	if rec.PasswordHash != "" {
+		if !user.ValidPasswordHash(user.Password(rec.PasswordHash)) {
+			return 0, user.ErrorUnknownPasswordHash
+		}
+		if localConnID == "" {
+			return 0, ErrorImportNoLocalConnector
+		}
+	}
+	for _, rid := range rids {
+		if _, err := m.connCfgRepo.GetConnectorByID(tx, rid.ConnectorID); err != nil {
+			return 0, err
+		}
+	}
+
+	existing, err := m.importedUser(tx, rec)
+	if err == ErrorImportAmbiguous && opts.OnConflict == ImportSkip {
+		return importSkipped, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if existing == nil {
+		if opts.DryRun {
+			return importCreated, nil
+		}
+		if usr.ID == "" {
+			if usr.ID, err = m.userIDGenerator(); err != nil {
+				return 0, err
+			}
+		}
+		if usr.CreatedAt.IsZero() {
+			usr.CreatedAt = m.Clock.Now()
+		}
+		if err := m.userRepo.Create(tx, usr); err != nil {
+			return 0, err
+		}
+		if err := m.importIdentities(tx, usr.ID, localIdentity(rids, usr.ID, localConnID, rec)); err != nil {
+			return 0, err
+		}
+		if err := m.importPassword(tx, usr.ID, rec); err != nil {
+			return 0, err
+		}
+		return importCreated, nil
+	}
+
+	switch opts.OnConflict {
+	case ImportSkip:
+		return importSkipped, nil
+	case ImportFail:
+		return 0, ErrorImportConflict
+	}
+	if opts.DryRun {
+		return importUpdated, nil
+	}
+
+	usr.ID = existing.ID
+	if usr.CreatedAt.IsZero() {
+		usr.CreatedAt = existing.CreatedAt
+	}
+	if err := m.userRepo.Update(tx, usr); err != nil {
+		return 0, err
+	}
+	if err := m.importIdentities(tx, usr.ID, localIdentity(rids, usr.ID, localConnID, rec)); err != nil {
+		return 0, err
+	}
+	if err := m.importPassword(tx, usr.ID, rec); err != nil {
+		return 0, err
+	}
+	return importUpdated, nil
+}
+
+// importedUser returns the existing user the record belongs to, or nil if
+// there is none.
+func (m *UserManager) importedUser(tx repo.Transaction, rec user.UserRecord) (*user.User, error) {
+	var found []user.User
+	add := func(usr user.User, err error) error {
+		if err == user.ErrorNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = append(found, usr)
+		return nil
+	}
+
+	if rec.ID != "" {
+		if err := add(m.userRepo.Get(tx, rec.ID)); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(m.userRepo.GetByEmail(tx, rec.Email)); err != nil {
+		return nil, err
+	}
+	for _, rid := range rec.Identities() {
+		if err := add(m.userRepo.GetByRemoteIdentity(tx, rid)); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(found) == 0 {
+		return nil, nil
+	}
+	for _, usr := range found[1:] {
+		if usr.ID != found[0].ID {
+			return nil, ErrorImportAmbiguous
+		}
+	}
+	return &found[0], nil
+}
+
+// localIdentity adds the identity of the user userID at the local connector
+// to rids if rec has a password: the local connector identifies users by
+// their ID.
+func localIdentity(rids []user.RemoteIdentity, userID, localConnID string, rec user.UserRecord) []user.RemoteIdentity {
+	if rec.PasswordHash == "" {
+		return rids
+	}
+	local := user.RemoteIdentity{ConnectorID: localConnID, ID: userID}
+	for _, rid := range rids {
+		if rid == local {
+			return rids
+		}
+	}
+	return append(rids, local)
+}
+
+// importIdentities links the remote identities to the user, skipping those
+// already linked.
+func (m *UserManager) importIdentities(tx repo.Transaction, userID string, rids []user.RemoteIdentity) error {
+	for _, rid := range rids {
+		_, err := m.userRepo.GetByRemoteIdentity(tx, rid)
+		if err == nil {
+			continue
+		}
+		if err != user.ErrorNotFound {
+			return err
+		}
+		if err := m.userRepo.AddRemoteIdentity(tx, userID, rid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *UserManager) importPassword(tx repo.Transaction, userID string, rec user.UserRecord) error {
+	if rec.PasswordHash == "" {
+		return nil
+	}
+
+	pwi, err := m.pwRepo.Get(tx, userID)
+	if err == user.ErrorNotFound {
+		return m.pwRepo.Create(tx, user.PasswordInfo{
+			UserID:          userID,
+			Password:        user.Password(rec.PasswordHash),
+			PasswordExpires: rec.PasswordExpires,
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	if string(pwi.Password) != rec.PasswordHash {
+		pwi.History = passwordHistory(pwi, m.passwordPolicy.HistorySize())
+	}
+	pwi.Password = user.Password(rec.PasswordHash)
+	pwi.PasswordExpires = rec.PasswordExpires
+	return m.pwRepo.Update(tx, pwi)
+}
+
+// ExportUsers calls fn with the record of every user, in the order of List.
+func (m *UserManager) ExportUsers(fn func(user.UserRecord) error) error {
+	nextPageToken := ""
+	for {
+		users, tok, err := m.userRepo.List(nil, user.UserFilter{}, exportPageSize, nextPageToken)
+		if err != nil && err != user.ErrorNotFound {
+			return err
+		}
+
+		for _, usr := range users {
+			rids, err := m.userRepo.GetRemoteIdentities(nil, usr.ID)
+			if err != nil && err != user.ErrorNotFound {
+				return err
+			}
+			sort.Sort(remoteIdentitiesByConnector(rids))
+
+			var pwi *user.PasswordInfo
+			if p, err := m.pwRepo.Get(nil, usr.ID); err == nil {
+				pwi = &p
+			} else if err != user.ErrorNotFound {
+				return err
+			}
+
+			if err := fn(user.NewUserRecord(usr, rids, pwi)); err != nil {
+				return err
+			}
+		}
+
+		if tok == "" {
+			return nil
+		}
+		nextPageToken = tok
+	}
+}
@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/user"
+)
+
+const testPasswordHash = "$2a$10$DSy4dOo3dG4qCTtjaPl/9eKuFyTGvQyBQDqC2JmSJHQ3bN0FYWVg6"
+
+type recordSlice []user.UserRecord
+
+func (r *recordSlice) Read() (user.UserRecord, error) {
+	if len(*r) == 0 {
+		return user.UserRecord{}, io.EOF
+	}
+	rec := (*r)[0]
+	*r = (*r)[1:]
+	return rec, nil
+}
+
+func TestImportUsers(t *testing.T) {
+	newRecord := user.UserRecord{
+		ID:               "ID-3",
+		Email:            "Email-3@example.com",
+		RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "local", ID: "3"}},
+		PasswordHash:     testPasswordHash,
+	}
+	existingRecord := user.UserRecord{
+		Email:            "Email-1@example.com",
+		DisplayName:      "One",
+		RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "local", ID: "1b"}},
+		PasswordHash:     testPasswordHash,
+	}
+	ambiguousRecord := user.UserRecord{
+		Email:            "Email-1@example.com",
+		RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "local", ID: "2"}},
+	}
+
+	tests := []struct {
+		recs []user.UserRecord
+		opts ImportOptions
+		want ImportResult
+		// wantUsers are the emails of the users afterwards.
+		wantUsers []string
+		wantErr   bool
+	}{
+		{
+			recs:      []user.UserRecord{newRecord, existingRecord},
+			opts:      ImportOptions{OnConflict: ImportSkip},
+			want:      ImportResult{Created: 1, Skipped: 1},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com", "Email-3@example.com"},
+		},
+		{
+			recs:      []user.UserRecord{newRecord, existingRecord},
+			opts:      ImportOptions{OnConflict: ImportUpdate},
+			want:      ImportResult{Created: 1, Updated: 1},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com", "Email-3@example.com"},
+		},
+		{
+			recs:      []user.UserRecord{newRecord, existingRecord},
+			opts:      ImportOptions{OnConflict: ImportUpdate, DryRun: true},
+			want:      ImportResult{Created: 1, Updated: 1},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+		},
+		{
+			recs:      []user.UserRecord{existingRecord, newRecord},
+			opts:      ImportOptions{},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+			wantErr:   true,
+		},
+		{
+			// The first batch is kept.
+			recs:      []user.UserRecord{newRecord, existingRecord},
+			opts:      ImportOptions{OnConflict: ImportFail, BatchSize: 1},
+			want:      ImportResult{Created: 1},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com", "Email-3@example.com"},
+			wantErr:   true,
+		},
+		{
+			recs:      []user.UserRecord{ambiguousRecord},
+			opts:      ImportOptions{OnConflict: ImportSkip},
+			want:      ImportResult{Skipped: 1},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+		},
+		{
+			recs:      []user.UserRecord{ambiguousRecord},
+			opts:      ImportOptions{OnConflict: ImportUpdate},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+			wantErr:   true,
+		},
+		{
+			recs: []user.UserRecord{{
+				Email:            "Email-3@example.com",
+				RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "nope", ID: "3"}},
+			}},
+			opts:      ImportOptions{OnConflict: ImportSkip},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+			wantErr:   true,
+		},
+		{
+			recs:      []user.UserRecord{{Email: "Email-3@example.com", PasswordHash: "plaintext"}},
+			opts:      ImportOptions{OnConflict: ImportSkip},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+			wantErr:   true,
+		},
+		{
+			recs:      []user.UserRecord{newRecord},
+			opts:      ImportOptions{OnConflict: "overwrite"},
+			wantUsers: []string{"Email-1@example.com", "Email-2@example.com"},
+			wantErr:   true,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		recs := recordSlice(tt.recs)
+		got, err := f.mgr.ImportUsers(&recs, tt.opts)
+		if tt.wantErr != (err != nil) {
+			t.Errorf("case %d: want error=%v, got %v", i, tt.wantErr, err)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+
+		users, _, err := f.ur.List(nil, user.UserFilter{}, 10, "")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		var emails []string
+		for _, usr := range users {
+			emails = append(emails, usr.Email)
+		}
+		if diff := pretty.Compare(tt.wantUsers, emails); diff != "" {
+			t.Errorf("case %d: Compare(wantUsers, got): %v", i, diff)
+		}
+	}
+}
+
+func TestImportUsersUpdate(t *testing.T) {
+	f := makeTestFixtures()
+	recs := recordSlice{{
+		Email:            "Email-1@example.com",
+		DisplayName:      "One",
+		Admin:            true,
+		RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "local", ID: "1b"}},
+		PasswordHash:     testPasswordHash,
+	}}
+	if _, err := f.mgr.ImportUsers(&recs, ImportOptions{OnConflict: ImportUpdate}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	usr, err := f.ur.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := user.User{ID: "ID-1", Email: "Email-1@example.com", DisplayName: "One", Admin: true}
+	if diff := pretty.Compare(want, usr); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+
+	rids, err := f.ur.GetRemoteIdentities(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rids) != 3 {
+		t.Errorf("want 3 remote identities, got %v", rids)
+	}
+
+	pwi, err := f.pwr.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(pwi.Password) != testPasswordHash {
+		t.Errorf("want password=%q, got %q", testPasswordHash, pwi.Password)
+	}
+}
+
+func TestImportUsersLocalLogin(t *testing.T) {
+	hash, err := user.NewPasswordFromPlaintext("secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	f := makeTestFixtures()
+	recs := recordSlice{{Email: "Email-3@example.com", PasswordHash: string(hash)}}
+	if _, err := f.mgr.ImportUsers(&recs, ImportOptions{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	idp := &connector.LocalIdentityProvider{UserRepo: f.ur, PasswordInfoRepo: f.pwr}
+	ident, err := idp.Identity("Email-3@example.com", "secret")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	usr, err := f.ur.GetByRemoteIdentity(nil, user.RemoteIdentity{ConnectorID: "local", ID: ident.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if usr.Email != "Email-3@example.com" {
+		t.Errorf("want user Email-3@example.com, got %v", usr)
+	}
+
+	// Without a local connector, the password could never be used.
+	f = makeTestFixtures()
+	f.mgr = NewUserManager(f.ur, f.pwr, connector.NewConnectorConfigRepoFromConfigs(nil), repo.InMemTransactionFactory, ManagerOptions{})
+	recs = recordSlice{{Email: "Email-3@example.com", PasswordHash: string(hash)}}
+	if _, err := f.mgr.ImportUsers(&recs, ImportOptions{}); err == nil {
+		t.Errorf("want error without a local connector")
+	}
+}
+
+func TestExportUsers(t *testing.T) {
+	f := makeTestFixtures()
+	for i := 3; i <= exportPageSize+1; i++ {
+		usr := user.User{
+			ID:        fmt.Sprintf("ID-%d", i),
+			Email:     fmt.Sprintf("Email-%d@example.com", i),
+			CreatedAt: time.Unix(1234567890, 0).UTC(),
+		}
+		if err := f.ur.Create(nil, usr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	var got []user.UserRecord
+	err := f.mgr.ExportUsers(func(rec user.UserRecord) error {
+		got = append(got, rec)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(got) != exportPageSize+1 {
+		t.Fatalf("want %d records, got %d", exportPageSize+1, len(got))
+	}
+	recs := make(map[string]user.UserRecord)
+	for _, rec := range got {
+		recs[rec.ID] = rec
+	}
+	want := user.UserRecord{
+		ID:               "ID-1",
+		Email:            "Email-1@example.com",
+		RemoteIdentities: []user.RemoteIdentityRecord{{ConnectorID: "local", ID: "1"}},
+		PasswordHash:     "password-1",
+	}
+	if diff := pretty.Compare(want, recs["ID-1"]); diff != "" {
+		t.Errorf("Compare(want, got): %v", diff)
+	}
+	if rec := recs["ID-3"]; rec.Email != "Email-3@example.com" || rec.PasswordHash != "" {
+		t.Errorf("unexpected record %v", rec)
+	}
+}
@@ -3,13 +3,18 @@ package manager
 import (
 	"errors"
 	"net/url"
+	"sort"
 
 	"github.com/jonboulle/clockwork"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/connector"
 	"github.com/coreos/dex/pkg/log"
+	"github.com/coreos/dex/refresh"
 	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/webhook"
 )
 
 var (
@@ -17,6 +22,11 @@ var (
 	ErrorEmailAlreadyVerified = errors.New("email already verified")
 
 	ErrorPasswordAlreadyChanged = errors.New("password has already been changed")
+
+	ErrorLastRemoteIdentity = errors.New("cannot remove the last remote identity of a user")
+
+	ErrorGroupsUnavailable     = errors.New("no group repo configured")
+	ErrorAttributesUnavailable = errors.New("no attribute repo configured")
 )
 
 // Manager performs user-related "business-logic" functions on user and related objects.
@@ -29,15 +39,56 @@ type UserManager struct {
 	connCfgRepo     connector.ConnectorConfigRepo
 	begin           repo.TransactionFactory
 	userIDGenerator user.UserIDGenerator
+	passwordPolicy  user.PasswordPolicy
+
+	refreshTokenRepo refresh.RefreshTokenRepo
+	sessionRepo      session.SessionRepo
+	totpRepo         user.TOTPInfoRepo
+	webAuthnRepo     user.WebAuthnCredentialRepo
+	loginAttemptRepo user.LoginAttemptRepo
+	groupRepo        user.GroupRepo
+	attributeRepo    user.AttributeRepo
+	auditSink        audit.AuditSink
+	webhookNotifier  webhook.Notifier
 }
 
 type ManagerOptions struct {
-	// This is empty right now but will soon contain configuration information
-	// such as passowrd length, name length, password expiration time and other
-	// variable policies
+	// PasswordPolicy is the policy new passwords must satisfy. It defaults
+	// to user.DefaultPasswordPolicy.
+	PasswordPolicy user.PasswordPolicy
+
+	// The following repos hold further data of users, which is removed
+	// along with them by Delete. Those left nil are skipped.
+	RefreshTokenRepo       refresh.RefreshTokenRepo
+	SessionRepo            session.SessionRepo
+	TOTPInfoRepo           user.TOTPInfoRepo
+	WebAuthnCredentialRepo user.WebAuthnCredentialRepo
+	LoginAttemptRepo       user.LoginAttemptRepo
+
+	// GroupRepo stores the groups managed through the UserManager. Without
+	// it the group methods return ErrorGroupsUnavailable.
+	GroupRepo user.GroupRepo
+
+	// AttributeRepo stores the custom attributes of users. Without it the
+	// attribute methods return ErrorAttributesUnavailable.
+	AttributeRepo user.AttributeRepo
+
+	// AuditSink, if set, records the creation of admins and the disabling
+	// and enabling of users.
+	AuditSink audit.AuditSink
+
+	// WebhookNotifier, if set, queues webhook events when users are
+	// created, verify their email address, or are disabled or enabled. The
+	// events are queued in the same transaction as the change itself.
+	WebhookNotifier webhook.Notifier
 }
 
 func NewUserManager(userRepo user.UserRepo, pwRepo user.PasswordInfoRepo, connCfgRepo connector.ConnectorConfigRepo, txnFactory repo.TransactionFactory, options ManagerOptions) *UserManager {
+	policy := options.PasswordPolicy
+	if policy == nil {
+		policy = user.DefaultPasswordPolicy
+	}
+
 	return &UserManager{
 		Clock: clockwork.NewRealClock(),
 
@@ -46,6 +97,17 @@ func NewUserManager(userRepo user.UserRepo, pwRepo user.PasswordInfoRepo, connCf
 		connCfgRepo:     connCfgRepo,
 		begin:           txnFactory,
 		userIDGenerator: user.DefaultUserIDGenerator,
+		passwordPolicy:  policy,
+
+		refreshTokenRepo: options.RefreshTokenRepo,
+		sessionRepo:      options.SessionRepo,
+		totpRepo:         options.TOTPInfoRepo,
+		webAuthnRepo:     options.WebAuthnCredentialRepo,
+		loginAttemptRepo: options.LoginAttemptRepo,
+		groupRepo:        options.GroupRepo,
+		attributeRepo:    options.AttributeRepo,
+		auditSink:        options.AuditSink,
+		webhookNotifier:  options.WebhookNotifier,
 	}
 }
 
@@ -98,11 +160,20 @@ func (m *UserManager) CreateUser(usr user.User, hashedPassword user.Password, co
 		return "", err
 	}
 
+	if err := m.notify(tx, webhook.EventUserCreated, usr); err != nil {
+		rollback(tx)
+		return "", err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		rollback(tx)
 		return "", err
 	}
+
+	if usr.Admin {
+		audit.Record(m.auditSink, audit.Event{Type: audit.EventAdminCreated, UserID: usr.ID})
+	}
 	return usr.ID, nil
 }
 
@@ -114,6 +185,51 @@ func (m *UserManager) Disable(userID string, disabled bool) error {
 		return err
 	}
 
+	if m.webhookNotifier != nil {
+		usr, err := m.userRepo.Get(tx, userID)
+		if err != nil {
+			rollback(tx)
+			return err
+		}
+		typ := webhook.EventUserEnabled
+		if disabled {
+			typ = webhook.EventUserDisabled
+		}
+		if err := m.notify(tx, typ, usr); err != nil {
+			rollback(tx)
+			return err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	typ := audit.EventUserEnabled
+	if disabled {
+		typ = audit.EventUserDisabled
+	}
+	audit.Record(m.auditSink, audit.Event{Type: typ, UserID: userID})
+	return nil
+}
+
+// Delete removes the user with the given ID for good, along with their
+// password, remote identities, second factors, refresh tokens, sessions,
+// failed logins, group memberships and custom attributes. Outstanding
+// invitations and email verifications of the user are rejected once they
+// are gone.
+func (m *UserManager) Delete(userID string) error {
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+
+	if err = m.delete(tx, userID); err != nil {
+		rollback(tx)
+		return err
+	}
+
 	if err = tx.Commit(); err != nil {
 		rollback(tx)
 		return err
@@ -122,6 +238,214 @@ func (m *UserManager) Disable(userID string, disabled bool) error {
 	return nil
 }
 
+func (m *UserManager) delete(tx repo.Transaction, userID string) error {
+	if _, err := m.userRepo.Get(tx, userID); err != nil {
+		return err
+	}
+
+	if err := m.pwRepo.Delete(tx, userID); err != nil && err != user.ErrorNotFound {
+		return err
+	}
+	if m.totpRepo != nil {
+		if err := m.totpRepo.Delete(tx, userID); err != nil && err != user.ErrorNotFound {
+			return err
+		}
+	}
+	if m.webAuthnRepo != nil {
+		if err := m.webAuthnRepo.DeleteByUserID(tx, userID); err != nil {
+			return err
+		}
+	}
+	if m.loginAttemptRepo != nil {
+		if err := user.DeleteAccountLoginAttempts(tx, m.loginAttemptRepo, userID); err != nil {
+			return err
+		}
+	}
+	if m.refreshTokenRepo != nil {
+		if err := m.refreshTokenRepo.RevokeTokensForUser(tx, userID); err != nil {
+			return err
+		}
+	}
+	if m.sessionRepo != nil {
+		if err := m.sessionRepo.DeleteByUserID(tx, userID); err != nil {
+			return err
+		}
+	}
+	if m.groupRepo != nil {
+		if err := m.groupRepo.RemoveMember(tx, userID); err != nil {
+			return err
+		}
+	}
+	if m.attributeRepo != nil {
+		if err := m.attributeRepo.Set(tx, userID, nil); err != nil {
+			return err
+		}
+	}
+
+	return m.userRepo.Delete(tx, userID)
+}
+
+// UpdateProfile changes the display name and email address of the user with
+// the given ID. A changed email address has to be verified again. The updated
+// user is returned.
+func (m *UserManager) UpdateProfile(userID, displayName, email string) (user.User, error) {
+	tx, err := m.begin()
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr, err := m.userRepo.Get(tx, userID)
+	if err != nil {
+		rollback(tx)
+		return user.User{}, err
+	}
+
+	if email != usr.Email {
+		if !user.ValidEmail(email) {
+			rollback(tx)
+			return user.User{}, user.ErrorInvalidEmail
+		}
+		if _, err := m.userRepo.GetByEmail(tx, email); err == nil {
+			rollback(tx)
+			return user.User{}, user.ErrorDuplicateEmail
+		} else if err != user.ErrorNotFound {
+			rollback(tx)
+			return user.User{}, err
+		}
+		usr.Email = email
+		usr.EmailVerified = false
+	}
+	usr.DisplayName = displayName
+
+	if err := m.userRepo.Update(tx, usr); err != nil {
+		rollback(tx)
+		return user.User{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return user.User{}, err
+	}
+	return usr, nil
+}
+
+// RefreshProfile updates the profile claims of the user with the given ID
+// from the profile of the remote identity they logged in with. The user is
+// returned, and only written if it changed.
+func (m *UserManager) RefreshProfile(userID string, profile user.Profile) (user.User, error) {
+	tx, err := m.begin()
+	if err != nil {
+		return user.User{}, err
+	}
+
+	usr, err := m.userRepo.Get(tx, userID)
+	if err != nil {
+		rollback(tx)
+		return user.User{}, err
+	}
+
+	if usr.RefreshProfile(profile) {
+		if err := m.userRepo.Update(tx, usr); err != nil {
+			rollback(tx)
+			return user.User{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return user.User{}, err
+	}
+	return usr, nil
+}
+
+// GetRemoteIdentities returns the remote identities linked to the user with
+// the given ID, sorted by connector.
+func (m *UserManager) GetRemoteIdentities(userID string) ([]user.RemoteIdentity, error) {
+	if _, err := m.userRepo.Get(nil, userID); err != nil {
+		return nil, err
+	}
+	rids, err := m.userRepo.GetRemoteIdentities(nil, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(remoteIdentitiesByConnector(rids))
+	return rids, nil
+}
+
+type remoteIdentitiesByConnector []user.RemoteIdentity
+
+func (r remoteIdentitiesByConnector) Len() int      { return len(r) }
+func (r remoteIdentitiesByConnector) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+func (r remoteIdentitiesByConnector) Less(i, j int) bool {
+	if r[i].ConnectorID != r[j].ConnectorID {
+		return r[i].ConnectorID < r[j].ConnectorID
+	}
+	return r[i].ID < r[j].ID
+}
+
+// AddRemoteIdentity links the given remote identity to an existing user, who
+// can then log in with it. The connector of the remote identity must exist,
+// and the remote identity must not belong to another user.
+func (m *UserManager) AddRemoteIdentity(userID string, rid user.RemoteIdentity) error {
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+
+	if err := m.addRemoteIdentity(tx, userID, rid); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return err
+	}
+	return nil
+}
+
+// RemoveRemoteIdentity unlinks the given remote identity from the user.
+// Users must keep at least one remote identity to be able to log in, so
+// ErrorLastRemoteIdentity is returned when removing it.
+func (m *UserManager) RemoveRemoteIdentity(userID string, rid user.RemoteIdentity) error {
+	tx, err := m.begin()
+	if err != nil {
+		return err
+	}
+
+	rids, err := m.userRepo.GetRemoteIdentities(tx, userID)
+	if err != nil {
+		rollback(tx)
+		return err
+	}
+	found := false
+	for _, r := range rids {
+		if r == rid {
+			found = true
+			break
+		}
+	}
+	if !found {
+		rollback(tx)
+		return user.ErrorNotFound
+	}
+	if len(rids) == 1 {
+		rollback(tx)
+		return ErrorLastRemoteIdentity
+	}
+
+	if err := m.userRepo.RemoveRemoteIdentity(tx, userID, rid); err != nil {
+		rollback(tx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		rollback(tx)
+		return err
+	}
+	return nil
+}
+
 // RegisterWithRemoteIdentity creates new user and attaches the given remote identity.
 func (m *UserManager) RegisterWithRemoteIdentity(email string, emailVerified bool, rid user.RemoteIdentity) (string, error) {
 	tx, err := m.begin()
@@ -149,6 +473,11 @@ func (m *UserManager) RegisterWithRemoteIdentity(email string, emailVerified boo
 		return "", err
 	}
 
+	if err := m.notify(tx, webhook.EventUserCreated, usr); err != nil {
+		rollback(tx)
+		return "", err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		rollback(tx)
@@ -165,10 +494,14 @@ func (m *UserManager) RegisterWithPassword(email, plaintext, connID string) (str
 		return "", err
 	}
 
-	if !user.ValidPassword(plaintext) {
+	if plaintext == "" {
 		rollback(tx)
 		return "", user.ErrorInvalidPassword
 	}
+	if err := m.passwordPolicy.Check(user.User{Email: email}, user.PasswordInfo{}, plaintext); err != nil {
+		rollback(tx)
+		return "", err
+	}
 
 	usr, err := m.insertNewUser(tx, email, false)
 	if err != nil {
@@ -201,6 +534,11 @@ func (m *UserManager) RegisterWithPassword(email, plaintext, connID string) (str
 		return "", err
 	}
 
+	if err := m.notify(tx, webhook.EventUserCreated, usr); err != nil {
+		rollback(tx)
+		return "", err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		rollback(tx)
@@ -252,6 +590,11 @@ func (m *UserManager) VerifyEmail(ev EmailVerifiable) (*url.URL, error) {
 		return nil, err
 	}
 
+	if err := m.notify(tx, webhook.EventUserVerified, usr); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
 	err = tx.Commit()
 	if err != nil {
 		rollback(tx)
@@ -272,7 +615,7 @@ func (m *UserManager) ChangePassword(pwr PasswordChangeable, plaintext string) (
 		return nil, err
 	}
 
-	if !user.ValidPassword(plaintext) {
+	if plaintext == "" {
 		rollback(tx)
 		return nil, user.ErrorInvalidPassword
 	}
@@ -288,12 +631,24 @@ func (m *UserManager) ChangePassword(pwr PasswordChangeable, plaintext string) (
 		return nil, ErrorPasswordAlreadyChanged
 	}
 
+	usr, err := m.userRepo.Get(tx, pwr.UserID())
+	if err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
+	if err := m.passwordPolicy.Check(usr, pwi, plaintext); err != nil {
+		rollback(tx)
+		return nil, err
+	}
+
 	newPass, err := user.NewPasswordFromPlaintext(plaintext)
 	if err != nil {
 		rollback(tx)
 		return nil, err
 	}
 
+	pwi.History = passwordHistory(pwi, m.passwordPolicy.HistorySize())
 	pwi.Password = newPass
 	err = m.pwRepo.Update(tx, pwi)
 	if err != nil {
@@ -309,6 +664,33 @@ func (m *UserManager) ChangePassword(pwr PasswordChangeable, plaintext string) (
 	return pwr.Callback(), nil
 }
 
+// passwordHistory returns the history of pwi once its current password has
+// been replaced, keeping at most size passwords.
+func passwordHistory(pwi user.PasswordInfo, size int) []user.Password {
+	if size == 0 {
+		return nil
+	}
+
+	history := append([]user.Password{pwi.Password}, pwi.History...)
+	if len(history) > size {
+		history = history[:size]
+	}
+	return history
+}
+
+// notify queues a webhook event about usr as part of tx, if the manager has a
+// WebhookNotifier.
+func (m *UserManager) notify(tx repo.Transaction, typ webhook.EventType, usr user.User) error {
+	if m.webhookNotifier == nil {
+		return nil
+	}
+	return m.webhookNotifier.Notify(tx, webhook.Event{
+		Type:   typ,
+		UserID: usr.ID,
+		Email:  usr.Email,
+	})
+}
+
 func (m *UserManager) insertNewUser(tx repo.Transaction, email string, emailVerified bool) (user.User, error) {
 	if !user.ValidEmail(email) {
 		return user.User{}, user.ErrorInvalidEmail
@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"errors"
 	"net/url"
 	"testing"
 	"time"
@@ -9,9 +10,13 @@ import (
 	"github.com/jonboulle/clockwork"
 	"github.com/kylelemons/godebug/pretty"
 
+	"github.com/coreos/dex/audit"
 	"github.com/coreos/dex/connector"
+	"github.com/coreos/dex/refresh"
 	"github.com/coreos/dex/repo"
+	"github.com/coreos/dex/session"
 	"github.com/coreos/dex/user"
+	"github.com/coreos/dex/webhook"
 )
 
 type testFixtures struct {
@@ -463,3 +468,476 @@ func TestCreateUser(t *testing.T) {
 		}
 	}
 }
+
+func TestChangePasswordPolicy(t *testing.T) {
+	f := makeTestFixtures()
+	f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+		PasswordPolicy: user.PasswordPolicy{user.MinLengthRule(8), user.HistoryRule(2)},
+	})
+
+	change := func(plaintext string) error {
+		pwi, err := f.pwr.Get(nil, "ID-1")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		claims := jose.Claims{
+			"sub":                           "ID-1",
+			user.ClaimPasswordResetPassword: string(pwi.Password),
+			user.ClaimPasswordResetCallback: "",
+		}
+		_, err = f.mgr.ChangePassword(user.PasswordReset{Claims: claims}, plaintext)
+		return err
+	}
+
+	tests := []struct {
+		plaintext   string
+		wantRule    string
+		wantHistory int
+	}{
+		{plaintext: "short", wantRule: "min_length", wantHistory: 0},
+		{plaintext: "new-password-1", wantHistory: 1},
+		// the current password may not be reused
+		{plaintext: "new-password-1", wantRule: "history", wantHistory: 1},
+		// only one earlier password is kept
+		{plaintext: "new-password-2", wantHistory: 1},
+		{plaintext: "new-password-1", wantRule: "history", wantHistory: 1},
+		{plaintext: "new-password-3", wantHistory: 1},
+		{plaintext: "new-password-1", wantHistory: 1},
+	}
+
+	for i, tt := range tests {
+		err := change(tt.plaintext)
+		if tt.wantRule != "" {
+			perr, ok := err.(user.PasswordPolicyError)
+			if !ok || len(perr.Violations) != 1 || perr.Violations[0].Rule != tt.wantRule {
+				t.Errorf("case %d: want %q violation, got %v", i, tt.wantRule, err)
+			}
+		} else if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+
+		pwi, err := f.pwr.Get(nil, "ID-1")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if len(pwi.History) != tt.wantHistory {
+			t.Errorf("case %d: want %d passwords in history, got %d", i, tt.wantHistory, len(pwi.History))
+		}
+	}
+}
+
+func TestRegisterWithPasswordPolicy(t *testing.T) {
+	f := makeTestFixtures()
+	f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+		PasswordPolicy: user.PasswordPolicy{user.EmailRule{}},
+	})
+
+	_, err := f.mgr.RegisterWithPassword("elroy@jetsons.com", "elroy-rocks", "local")
+	if perr, ok := err.(user.PasswordPolicyError); !ok || perr.Violations[0].Rule != "email" {
+		t.Fatalf("want email violation, got %v", err)
+	}
+	if _, err := f.ur.GetByEmail(nil, "elroy@jetsons.com"); err != user.ErrorNotFound {
+		t.Errorf("want=%v, got=%v", user.ErrorNotFound, err)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tests := []struct {
+		userID      string
+		displayName string
+		email       string
+		want        user.User
+		wantErr     error
+	}{
+		{
+			// Keeping the email address keeps it verified.
+			userID:      "ID-2",
+			displayName: "Two",
+			email:       "Email-2@example.com",
+			want: user.User{
+				ID:            "ID-2",
+				DisplayName:   "Two",
+				Email:         "Email-2@example.com",
+				EmailVerified: true,
+			},
+		},
+		{
+			userID:      "ID-2",
+			displayName: "Two",
+			email:       "two@example.com",
+			want: user.User{
+				ID:          "ID-2",
+				DisplayName: "Two",
+				Email:       "two@example.com",
+			},
+		},
+		{
+			userID:  "ID-2",
+			email:   "Email-1@example.com",
+			wantErr: user.ErrorDuplicateEmail,
+		},
+		{
+			userID:  "ID-2",
+			email:   "not-an-email",
+			wantErr: user.ErrorInvalidEmail,
+		},
+		{
+			userID:  "ID-3",
+			email:   "three@example.com",
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		got, err := f.mgr.UpdateProfile(tt.userID, tt.displayName, tt.email)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+		stored, err := f.ur.Get(nil, tt.userID)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(tt.want, stored); diff != "" {
+			t.Errorf("case %d: Compare(want, stored) = %v", i, diff)
+		}
+	}
+}
+
+func TestRefreshProfile(t *testing.T) {
+	f := makeTestFixtures()
+	profile := user.Profile{GivenName: "One", Picture: "https://example.com/1.png"}
+	got, err := f.mgr.RefreshProfile("ID-1", profile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	stored, err := f.ur.Get(nil, "ID-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare(got, stored); diff != "" {
+		t.Errorf("Compare(got, stored) = %v", diff)
+	}
+	if stored.GivenName != "One" || stored.Picture != profile.Picture {
+		t.Errorf("profile not refreshed: %#v", stored)
+	}
+
+	if _, err := f.mgr.RefreshProfile("ID-3", profile); err != user.ErrorNotFound {
+		t.Errorf("want err=%v, got %v", user.ErrorNotFound, err)
+	}
+}
+
+func TestAddRemoteIdentity(t *testing.T) {
+	tests := []struct {
+		userID  string
+		rid     user.RemoteIdentity
+		wantErr error
+	}{
+		{
+			userID: "ID-1",
+			rid:    user.RemoteIdentity{ConnectorID: "local", ID: "3"},
+		},
+		{
+			userID:  "ID-1",
+			rid:     user.RemoteIdentity{ConnectorID: "local", ID: "2"},
+			wantErr: user.ErrorDuplicateRemoteIdentity,
+		},
+		{
+			userID:  "ID-1",
+			rid:     user.RemoteIdentity{ConnectorID: "idonotexist", ID: "3"},
+			wantErr: connector.ErrorNotFound,
+		},
+		{
+			userID:  "ID-3",
+			rid:     user.RemoteIdentity{ConnectorID: "local", ID: "3"},
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		err := f.mgr.AddRemoteIdentity(tt.userID, tt.rid)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		usr, err := f.ur.GetByRemoteIdentity(nil, tt.rid)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if usr.ID != tt.userID {
+			t.Errorf("case %d: want user=%q, got %q", i, tt.userID, usr.ID)
+		}
+	}
+}
+
+func TestRemoveRemoteIdentity(t *testing.T) {
+	tests := []struct {
+		add     []user.RemoteIdentity
+		rid     user.RemoteIdentity
+		want    []user.RemoteIdentity
+		wantErr error
+	}{
+		{
+			add:  []user.RemoteIdentity{{ConnectorID: "local", ID: "3"}},
+			rid:  user.RemoteIdentity{ConnectorID: "local", ID: "1"},
+			want: []user.RemoteIdentity{{ConnectorID: "local", ID: "3"}},
+		},
+		{
+			rid:     user.RemoteIdentity{ConnectorID: "local", ID: "1"},
+			wantErr: ErrorLastRemoteIdentity,
+		},
+		{
+			// The remote identity of another user.
+			add:     []user.RemoteIdentity{{ConnectorID: "local", ID: "3"}},
+			rid:     user.RemoteIdentity{ConnectorID: "local", ID: "2"},
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		for _, rid := range tt.add {
+			if err := f.mgr.AddRemoteIdentity("ID-1", rid); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+		}
+
+		err := f.mgr.RemoveRemoteIdentity("ID-1", tt.rid)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+		if tt.wantErr != nil {
+			continue
+		}
+
+		got, err := f.mgr.GetRemoteIdentities("ID-1")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if diff := pretty.Compare(tt.want, got); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		userID  string
+		wantErr error
+	}{
+		{
+			userID: "ID-1",
+		},
+		{
+			userID:  "ID-3",
+			wantErr: user.ErrorNotFound,
+		},
+	}
+
+	for i, tt := range tests {
+		f := makeTestFixtures()
+		refreshRepo := refresh.NewRefreshTokenRepo()
+		sessionRepo := session.NewSessionRepo()
+		totpRepo := user.NewTOTPInfoRepo()
+		webAuthnRepo := user.NewWebAuthnCredentialRepo()
+		loginAttemptRepo := user.NewLoginAttemptRepo()
+		groupRepo := user.NewGroupRepo()
+		attrRepo := user.NewAttributeRepo()
+		f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+			RefreshTokenRepo:       refreshRepo,
+			SessionRepo:            sessionRepo,
+			TOTPInfoRepo:           totpRepo,
+			WebAuthnCredentialRepo: webAuthnRepo,
+			LoginAttemptRepo:       loginAttemptRepo,
+			GroupRepo:              groupRepo,
+			AttributeRepo:          attrRepo,
+		})
+		if err := groupRepo.Create(nil, user.Group{ID: "group", DisplayName: "Group", Members: []string{"ID-1", "ID-2"}}); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		for _, userID := range []string{"ID-1", "ID-2"} {
+			if _, err := refreshRepo.Create(userID, "client"); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			ses := session.Session{ID: "session-" + userID, UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+			if err := sessionRepo.Create(ses); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if err := totpRepo.Create(nil, user.TOTPInfo{UserID: userID, Secret: []byte("secret")}); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			cred := user.WebAuthnCredential{ID: []byte("cred-" + userID), UserID: userID, PublicKey: []byte("key")}
+			if err := webAuthnRepo.Create(nil, cred); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if err := loginAttemptRepo.Put(nil, user.LoginAttempts{Key: "user:" + userID, Failures: 1}); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if err := attrRepo.Set(nil, userID, user.Attributes{"department": "Engineering"}); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+		}
+
+		err := f.mgr.Delete(tt.userID)
+		if err != tt.wantErr {
+			t.Errorf("case %d: want err=%v, got %v", i, tt.wantErr, err)
+			continue
+		}
+
+		// Nothing is left of the deleted user, and the others are untouched.
+		for _, userID := range []string{"ID-1", "ID-2"} {
+			want := userID != tt.userID
+
+			_, err := f.ur.Get(nil, userID)
+			got := []bool{err == nil}
+			_, err = f.ur.GetByRemoteIdentity(nil, user.RemoteIdentity{ConnectorID: "local", ID: userID[len("ID-"):]})
+			got = append(got, err == nil)
+			_, err = f.pwr.Get(nil, userID)
+			got = append(got, err == nil)
+			clientIDs, err := refreshRepo.ClientsWithRefreshTokens(userID)
+			got = append(got, err == nil && len(clientIDs) > 0)
+			_, err = sessionRepo.Get("session-" + userID)
+			got = append(got, err == nil)
+			_, err = totpRepo.Get(nil, userID)
+			got = append(got, err == nil)
+			creds, err := webAuthnRepo.GetByUserID(nil, userID)
+			got = append(got, err == nil && len(creds) > 0)
+			_, err = loginAttemptRepo.Get(nil, "user:"+userID)
+			got = append(got, err == nil)
+			groups, err := groupRepo.GetByMember(nil, userID)
+			got = append(got, err == nil && len(groups) > 0)
+			attrs, err := attrRepo.Get(nil, userID)
+			got = append(got, err == nil && len(attrs) > 0)
+
+			for j, exists := range got {
+				if exists != want {
+					t.Errorf("case %d: user %s: want data %d present=%v, got %v", i, userID, j, want, exists)
+				}
+			}
+		}
+	}
+}
+
+func TestAuditEvents(t *testing.T) {
+	f := makeTestFixtures()
+	aer := audit.NewEventRepo()
+	f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+		AuditSink: aer,
+	})
+
+	if _, err := f.mgr.CreateUser(user.User{Email: "user@example.com"}, user.Password("password"), "local"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	adminID, err := f.mgr.CreateUser(user.User{Email: "admin@example.com", Admin: true}, user.Password("password"), "local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.mgr.Disable("ID-1", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.mgr.Disable("ID-1", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.mgr.Disable("ID-3", true); err != user.ErrorNotFound {
+		t.Fatalf("want err=%v, got %v", user.ErrorNotFound, err)
+	}
+
+	events, _, err := aer.List(audit.EventFilter{}, 10, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	type typeAndUser struct {
+		Type   audit.EventType
+		UserID string
+	}
+	var got []typeAndUser
+	for _, e := range events {
+		got = append(got, typeAndUser{e.Type, e.UserID})
+	}
+	want := []typeAndUser{
+		{audit.EventUserEnabled, "ID-1"},
+		{audit.EventUserDisabled, "ID-1"},
+		{audit.EventAdminCreated, adminID},
+	}
+	if diff := pretty.Compare(want, got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+type recordingNotifier struct {
+	events []webhook.Event
+	err    error
+}
+
+func (n *recordingNotifier) Notify(_ repo.Transaction, e webhook.Event) error {
+	n.events = append(n.events, e)
+	return n.err
+}
+
+func TestWebhookEvents(t *testing.T) {
+	f := makeTestFixtures()
+	n := &recordingNotifier{}
+	f.mgr = NewUserManager(f.ur, f.pwr, f.ccr, repo.InMemTransactionFactory, ManagerOptions{
+		WebhookNotifier: n,
+	})
+
+	createdID, err := f.mgr.CreateUser(user.User{Email: "created@example.com"}, user.Password("password"), "local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	registeredID, err := f.mgr.RegisterWithPassword("registered@example.com", "password", "local")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	remoteID, err := f.mgr.RegisterWithRemoteIdentity("remote@example.com", true, user.RemoteIdentity{ConnectorID: "local", ID: "remote"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ev := user.EmailVerification{Claims: jose.Claims{
+		"sub":                               "ID-1",
+		user.ClaimEmailVerificationEmail:    "Email-1@example.com",
+		user.ClaimEmailVerificationCallback: "http://client.example.com/callback",
+	}}
+	if _, err := f.mgr.VerifyEmail(ev); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.mgr.Disable("ID-2", true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := f.mgr.Disable("ID-2", false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []webhook.Event{
+		{Type: webhook.EventUserCreated, UserID: createdID, Email: "created@example.com"},
+		{Type: webhook.EventUserCreated, UserID: registeredID, Email: "registered@example.com"},
+		{Type: webhook.EventUserCreated, UserID: remoteID, Email: "remote@example.com"},
+		{Type: webhook.EventUserVerified, UserID: "ID-1", Email: "Email-1@example.com"},
+		{Type: webhook.EventUserDisabled, UserID: "ID-2", Email: "Email-2@example.com"},
+		{Type: webhook.EventUserEnabled, UserID: "ID-2", Email: "Email-2@example.com"},
+	}
+	if diff := pretty.Compare(want, n.events); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// Failing to queue the event fails the change it is about.
+	n.err = errors.New("queue unavailable")
+	if _, err := f.mgr.CreateUser(user.User{Email: "failed@example.com"}, user.Password("password"), "local"); err != n.err {
+		t.Errorf("want err=%v, got %v", n.err, err)
+	}
+}
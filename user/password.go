@@ -7,10 +7,9 @@ import (
 	"io"
 	"net/url"
 	"os"
+	"sync"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/coreos/go-oidc/jose"
 	"github.com/coreos/go-oidc/key"
 	"github.com/coreos/go-oidc/oidc"
@@ -38,12 +37,13 @@ var (
 
 type Hasher func(string) ([]byte, error)
 
+// DefaultPasswordHasher hashes passwords with DefaultPasswordHashAlgorithm.
 func DefaultPasswordHasher(s string) ([]byte, error) {
-	pwHash, err := bcrypt.GenerateFromPassword([]byte(s), bcryptHashCost)
+	pwHash, err := DefaultPasswordHashAlgorithm.Hash(s)
 	if err != nil {
 		return nil, err
 	}
-	return Password(pwHash), nil
+	return pwHash, nil
 }
 
 type Password []byte
@@ -58,10 +58,14 @@ type PasswordInfo struct {
 	Password Password
 
 	PasswordExpires time.Time
+
+	// History holds the hashes of the user's earlier passwords, most recent
+	// first, as far as the PasswordPolicy needs them.
+	History []Password
 }
 
 func (p PasswordInfo) Authenticate(plaintext string) (*oidc.Identity, error) {
-	if err := bcrypt.CompareHashAndPassword(p.Password, []byte(plaintext)); err != nil {
+	if err := ComparePassword(p.Password, plaintext); err != nil {
 		return nil, ErrorPasswordHashNoMatch
 	}
 
@@ -83,6 +87,9 @@ type PasswordInfoRepo interface {
 	Get(tx repo.Transaction, id string) (PasswordInfo, error)
 	Update(repo.Transaction, PasswordInfo) error
 	Create(repo.Transaction, PasswordInfo) error
+
+	// Delete removes the password of the user with the given ID.
+	Delete(tx repo.Transaction, id string) error
 }
 
 func NewPasswordInfoRepo() PasswordInfoRepo {
@@ -92,10 +99,13 @@ func NewPasswordInfoRepo() PasswordInfoRepo {
 }
 
 type memPasswordInfoRepo struct {
+	mu  sync.Mutex
 	pws map[string]PasswordInfo
 }
 
 func (m *memPasswordInfoRepo) Get(_ repo.Transaction, id string) (PasswordInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	pw, ok := m.pws[id]
 	if !ok {
 		return PasswordInfo{}, ErrorNotFound
@@ -104,6 +114,8 @@ func (m *memPasswordInfoRepo) Get(_ repo.Transaction, id string) (PasswordInfo,
 }
 
 func (m *memPasswordInfoRepo) Create(_ repo.Transaction, pw PasswordInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	_, ok := m.pws[pw.UserID]
 	if ok {
 		return ErrorDuplicateID
@@ -122,6 +134,8 @@ func (m *memPasswordInfoRepo) Create(_ repo.Transaction, pw PasswordInfo) error
 }
 
 func (m *memPasswordInfoRepo) Update(_ repo.Transaction, pw PasswordInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	if pw.UserID == "" {
 		return ErrorInvalidID
 	}
@@ -139,6 +153,46 @@ func (m *memPasswordInfoRepo) Update(_ repo.Transaction, pw PasswordInfo) error
 	return nil
 }
 
+func (m *memPasswordInfoRepo) Delete(_ repo.Transaction, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.pws[id]; !ok {
+		return ErrorNotFound
+	}
+	delete(m.pws, id)
+	return nil
+}
+
+// snapshotPasswordInfo is a PasswordInfo in a snapshot of a
+// memPasswordInfoRepo, encoded without the custom JSON decoding of password
+// info files.
+type snapshotPasswordInfo PasswordInfo
+
+func (m *memPasswordInfoRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pws := make([]snapshotPasswordInfo, 0, len(m.pws))
+	for _, pw := range m.pws {
+		pws = append(pws, snapshotPasswordInfo(pw))
+	}
+	return json.Marshal(pws)
+}
+
+func (m *memPasswordInfoRepo) Restore(b []byte) error {
+	var pws []snapshotPasswordInfo
+	if err := json.Unmarshal(b, &pws); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pws = make(map[string]PasswordInfo, len(pws))
+	for _, pw := range pws {
+		m.pws[pw.UserID] = PasswordInfo(pw)
+	}
+	return nil
+}
+
 func (u *PasswordInfo) UnmarshalJSON(data []byte) error {
 	var dec struct {
 		UserID            string    `json:"userId"`
@@ -0,0 +1,348 @@
+package user
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/scrypt"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+)
+
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashScrypt   = "scrypt"
+
+	passwordSaltLength = 16
+	passwordKeyLength  = 32
+
+	// Hashes may be imported, so their parameters are bounded before a
+	// login spends memory or time on them. The bounds are well above the
+	// defaults and the parameters recommended for interactive logins.
+	maxBcryptCost         = 16
+	maxArgon2idMemory     = 1024 * 1024 // KiB
+	maxArgon2idTime       = 16
+	maxArgon2idThreads    = 16
+	maxScryptMemory       = 1 << 30 // bytes, 128 * N * r
+	maxScryptP            = 16
+	maxPasswordKeyLength  = 64
+	maxPasswordSaltLength = 64
+)
+
+var (
+	ErrorUnknownPasswordHash   = errors.New("unknown password hash format")
+	ErrorMalformedPasswordHash = errors.New("malformed password hash")
+
+	// DefaultPasswordHashAlgorithm hashes the passwords created by
+	// DefaultPasswordHasher. Passwords hashed with another algorithm, or
+	// with other parameters, are rehashed with it when their users log in.
+	DefaultPasswordHashAlgorithm PasswordHashAlgorithm = BcryptAlgorithm{Cost: bcryptHashCost}
+
+	// The phc encoding omits padding, as specified by the PHC string
+	// format.
+	phcEncoding = base64.RawStdEncoding
+)
+
+// PasswordHashAlgorithm creates and checks password hashes of one kind. All
+// hashes are tagged with the algorithm and parameters used to create them,
+// so that hashes of any supported kind can be checked with
+// ComparePassword.
+type PasswordHashAlgorithm interface {
+	Hash(plaintext string) (Password, error)
+
+	// Current reports whether hash was created by this algorithm with its
+	// current parameters.
+	Current(hash Password) bool
+
+	// Valid returns an error if the parameters are outside the bounds
+	// ComparePassword accepts, so that hashes made with them could never
+	// be checked.
+	Valid() error
+}
+
+// ComparePassword checks plaintext against a hash created by any of the
+// supported algorithms. It returns ErrorPasswordHashNoMatch if they do not
+// match, and ErrorUnknownPasswordHash if the kind of hash is not known.
+func ComparePassword(hash Password, plaintext string) error {
+	var (
+		ok  bool
+		err error
+	)
+	switch passwordHashName(hash) {
+	case PasswordHashBcrypt:
+		if !validBcrypt(hash) {
+			return ErrorMalformedPasswordHash
+		}
+		if bcrypt.CompareHashAndPassword(hash, []byte(plaintext)) != nil {
+			return ErrorPasswordHashNoMatch
+		}
+		return nil
+	case PasswordHashArgon2id:
+		ok, err = compareArgon2id(hash, plaintext)
+	case PasswordHashScrypt:
+		ok, err = compareScrypt(hash, plaintext)
+	default:
+		return ErrorUnknownPasswordHash
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrorPasswordHashNoMatch
+	}
+	return nil
+}
+
+// PasswordNeedsRehash reports whether hash was created with another
+// algorithm, or other parameters, than DefaultPasswordHashAlgorithm.
+func PasswordNeedsRehash(hash Password) bool {
+	return !DefaultPasswordHashAlgorithm.Current(hash)
+}
+
+// ValidPasswordHash reports whether hash was created by one of the supported
+// algorithms, with parameters within bounds, and can thus be checked with
+// ComparePassword.
+func ValidPasswordHash(hash Password) bool {
+	var err error
+	switch passwordHashName(hash) {
+	case PasswordHashBcrypt:
+		return validBcrypt(hash)
+	case PasswordHashArgon2id:
+		_, _, _, err = parseArgon2id(hash)
+	case PasswordHashScrypt:
+		_, _, _, err = parseScrypt(hash)
+	default:
+		return false
+	}
+	return err == nil
+}
+
+func passwordHashName(hash Password) string {
+	s := string(hash)
+	switch {
+	case strings.HasPrefix(s, "$2a$"), strings.HasPrefix(s, "$2b$"), strings.HasPrefix(s, "$2y$"):
+		return PasswordHashBcrypt
+	case strings.HasPrefix(s, "$argon2id$"):
+		return PasswordHashArgon2id
+	case strings.HasPrefix(s, "$scrypt$"):
+		return PasswordHashScrypt
+	}
+	return ""
+}
+
+// BcryptAlgorithm hashes passwords with bcrypt. Its hashes are in the
+// modular crypt format, such as "$2a$10$...".
+type BcryptAlgorithm struct {
+	Cost int
+}
+
+func (a BcryptAlgorithm) Hash(plaintext string) (Password, error) {
+	return bcrypt.GenerateFromPassword([]byte(plaintext), a.Cost)
+}
+
+func (a BcryptAlgorithm) Valid() error {
+	if a.Cost < bcrypt.MinCost || a.Cost > maxBcryptCost {
+		return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, maxBcryptCost)
+	}
+	return nil
+}
+
+func validBcrypt(hash Password) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err == nil && BcryptAlgorithm{Cost: cost}.Valid() == nil
+}
+
+func (a BcryptAlgorithm) Current(hash Password) bool {
+	if passwordHashName(hash) != PasswordHashBcrypt {
+		return false
+	}
+	cost, err := bcrypt.Cost(hash)
+	return err == nil && cost == a.Cost
+}
+
+// DefaultArgon2idAlgorithm uses 64 MiB of memory and three passes, which
+// takes well under a second on current hardware.
+var DefaultArgon2idAlgorithm = Argon2idAlgorithm{
+	Time:    3,
+	Memory:  64 * 1024,
+	Threads: 4,
+}
+
+// Argon2idAlgorithm hashes passwords with Argon2id. Its hashes are in the
+// PHC string format, such as "$argon2id$v=19$m=65536,t=3,p=4$salt$hash".
+type Argon2idAlgorithm struct {
+	Time uint32
+
+	// Memory is in KiB.
+	Memory uint32
+
+	Threads uint8
+}
+
+func (a Argon2idAlgorithm) Hash(plaintext string) (Password, error) {
+	salt, err := pcrypto.RandBytes(passwordSaltLength)
+	if err != nil {
+		return nil, err
+	}
+
+	key := argon2.IDKey([]byte(plaintext), salt, a.Time, a.Memory, a.Threads, passwordKeyLength)
+	return a.encode(salt, key), nil
+}
+
+func (a Argon2idAlgorithm) encode(salt, key []byte) Password {
+	return Password(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		a.Memory, a.Time, a.Threads, phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)))
+}
+
+// Valid requires at least 8 KiB of memory per thread, as Argon2 does.
+func (a Argon2idAlgorithm) Valid() error {
+	switch {
+	case a.Time == 0 || a.Time > maxArgon2idTime:
+		return fmt.Errorf("argon2id time must be between 1 and %d", maxArgon2idTime)
+	case a.Threads == 0 || a.Threads > maxArgon2idThreads:
+		return fmt.Errorf("argon2id threads must be between 1 and %d", maxArgon2idThreads)
+	case a.Memory < 8*uint32(a.Threads) || a.Memory > maxArgon2idMemory:
+		return fmt.Errorf("argon2id memory must be between 8 KiB per thread and %d KiB", maxArgon2idMemory)
+	}
+	return nil
+}
+
+func (a Argon2idAlgorithm) Current(hash Password) bool {
+	b, _, _, err := parseArgon2id(hash)
+	return err == nil && b == a
+}
+
+func parseArgon2id(hash Password) (a Argon2idAlgorithm, salt, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.Memory, &a.Time, &a.Threads); err != nil {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+	if a.Valid() != nil {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+
+	if salt, key, err = decodeSaltAndKey(parts[4], parts[5]); err != nil {
+		return a, nil, nil, err
+	}
+	return a, salt, key, nil
+}
+
+func compareArgon2id(hash Password, plaintext string) (bool, error) {
+	a, salt, key, err := parseArgon2id(hash)
+	if err != nil {
+		return false, err
+	}
+
+	got := argon2.IDKey([]byte(plaintext), salt, a.Time, a.Memory, a.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+// DefaultScryptAlgorithm uses the parameters recommended for interactive
+// logins, N=2^15, r=8 and p=1.
+var DefaultScryptAlgorithm = ScryptAlgorithm{
+	LogN: 15,
+	R:    8,
+	P:    1,
+}
+
+// ScryptAlgorithm hashes passwords with scrypt. Its hashes are in the PHC
+// string format, such as "$scrypt$ln=15,r=8,p=1$salt$hash".
+type ScryptAlgorithm struct {
+	// LogN is the base-2 logarithm of the CPU/memory cost N.
+	LogN uint8
+
+	R int
+	P int
+}
+
+func (a ScryptAlgorithm) Hash(plaintext string) (Password, error) {
+	salt, err := pcrypto.RandBytes(passwordSaltLength)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := scrypt.Key([]byte(plaintext), salt, 1<<a.LogN, a.R, a.P, passwordKeyLength)
+	if err != nil {
+		return nil, err
+	}
+	return a.encode(salt, key), nil
+}
+
+func (a ScryptAlgorithm) encode(salt, key []byte) Password {
+	return Password(fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", a.LogN, a.R, a.P,
+		phcEncoding.EncodeToString(salt), phcEncoding.EncodeToString(key)))
+}
+
+// Valid bounds the memory used, 128 * 2^LogN * R bytes, as well as P.
+func (a ScryptAlgorithm) Valid() error {
+	switch {
+	case a.P <= 0 || a.P > maxScryptP:
+		return fmt.Errorf("scrypt p must be between 1 and %d", maxScryptP)
+	case a.LogN == 0 || a.LogN > 30 || a.R <= 0 || a.R > maxScryptMemory/128>>a.LogN:
+		return fmt.Errorf("scrypt ln and r must be positive and use at most %d MiB, 128 * 2^ln * r bytes", maxScryptMemory>>20)
+	}
+	return nil
+}
+
+func (a ScryptAlgorithm) Current(hash Password) bool {
+	b, _, _, err := parseScrypt(hash)
+	return err == nil && b == a
+}
+
+func parseScrypt(hash Password) (a ScryptAlgorithm, salt, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 5 || parts[1] != PasswordHashScrypt {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &a.LogN, &a.R, &a.P); err != nil {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+	if a.Valid() != nil {
+		return a, nil, nil, ErrorMalformedPasswordHash
+	}
+
+	if salt, key, err = decodeSaltAndKey(parts[3], parts[4]); err != nil {
+		return a, nil, nil, err
+	}
+	return a, salt, key, nil
+}
+
+// decodeSaltAndKey decodes the salt and key of a hash in the PHC string
+// format.
+func decodeSaltAndKey(encSalt, encKey string) (salt, key []byte, err error) {
+	if salt, err = phcEncoding.DecodeString(encSalt); err != nil || len(salt) > maxPasswordSaltLength {
+		return nil, nil, ErrorMalformedPasswordHash
+	}
+	if key, err = phcEncoding.DecodeString(encKey); err != nil || len(key) == 0 || len(key) > maxPasswordKeyLength {
+		return nil, nil, ErrorMalformedPasswordHash
+	}
+	return salt, key, nil
+}
+
+func compareScrypt(hash Password, plaintext string) (bool, error) {
+	a, salt, key, err := parseScrypt(hash)
+	if err != nil {
+		return false, err
+	}
+
+	got, err := scrypt.Key([]byte(plaintext), salt, 1<<a.LogN, a.R, a.P, len(key))
+	if err != nil {
+		return false, ErrorMalformedPasswordHash
+	}
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
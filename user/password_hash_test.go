@@ -0,0 +1,244 @@
+package user
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Cheap parameters keep the tests fast.
+var (
+	testBcrypt   = BcryptAlgorithm{Cost: 4}
+	testArgon2id = Argon2idAlgorithm{Time: 1, Memory: 64, Threads: 1}
+	testScrypt   = ScryptAlgorithm{LogN: 4, R: 8, P: 1}
+)
+
+func TestPasswordHashAlgorithms(t *testing.T) {
+	tests := []struct {
+		alg    PasswordHashAlgorithm
+		prefix string
+	}{
+		{testBcrypt, "$2a$04$"},
+		{testArgon2id, "$argon2id$v=19$m=64,t=1,p=1$"},
+		{testScrypt, "$scrypt$ln=4,r=8,p=1$"},
+	}
+
+	for i, tt := range tests {
+		hash, err := tt.alg.Hash("hunter2")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if !strings.HasPrefix(string(hash), tt.prefix) {
+			t.Errorf("case %d: want hash with prefix %q, got %q", i, tt.prefix, hash)
+		}
+		if err := ComparePassword(hash, "hunter2"); err != nil {
+			t.Errorf("case %d: want nil err, got %v", i, err)
+		}
+		if err := ComparePassword(hash, "hunter3"); err != ErrorPasswordHashNoMatch {
+			t.Errorf("case %d: want %v, got %v", i, ErrorPasswordHashNoMatch, err)
+		}
+		if !tt.alg.Current(hash) {
+			t.Errorf("case %d: want hash to be current", i)
+		}
+
+		again, err := tt.alg.Hash("hunter2")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if string(again) == string(hash) {
+			t.Errorf("case %d: want hashes of the same password to differ", i)
+		}
+	}
+}
+
+func TestComparePassword(t *testing.T) {
+	tests := []struct {
+		hash string
+		want error
+	}{
+		// Created by Python's hashlib.scrypt.
+		{
+			hash: "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$7rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao",
+			want: nil,
+		},
+		{
+			hash: "$scrypt$ln=4,r=8,p=1$c2FsdHNhbHRzYWx0c2FsdA$8rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao",
+			want: ErrorPasswordHashNoMatch,
+		},
+		{
+			hash: "$scrypt$ln=4,r=8$c2FsdHNhbHRzYWx0c2FsdA$7rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao",
+			want: ErrorMalformedPasswordHash,
+		},
+		{
+			hash: "$argon2id$v=16$m=64,t=1,p=1$c2FsdHNhbHRzYWx0c2FsdA$7rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao",
+			want: ErrorMalformedPasswordHash,
+		},
+		{
+			hash: "$argon2id$v=19$m=64,t=1,p=1$!!!$7rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao",
+			want: ErrorMalformedPasswordHash,
+		},
+		{
+			hash: "$1$salt$hash",
+			want: ErrorUnknownPasswordHash,
+		},
+		{
+			hash: "hunter2",
+			want: ErrorUnknownPasswordHash,
+		},
+	}
+
+	for i, tt := range tests {
+		if err := ComparePassword(Password(tt.hash), "hunter2"); err != tt.want {
+			t.Errorf("case %d: want %v, got %v", i, tt.want, err)
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	defer func(alg PasswordHashAlgorithm) {
+		DefaultPasswordHashAlgorithm = alg
+	}(DefaultPasswordHashAlgorithm)
+	DefaultPasswordHashAlgorithm = testArgon2id
+
+	hashes := map[string]PasswordHashAlgorithm{
+		"bcrypt":   testBcrypt,
+		"argon2id": testArgon2id,
+		"stronger": Argon2idAlgorithm{Time: 2, Memory: 64, Threads: 1},
+		"scrypt":   testScrypt,
+	}
+	tests := []struct {
+		hash string
+		want bool
+	}{
+		{"bcrypt", true},
+		{"argon2id", false},
+		{"stronger", true},
+		{"scrypt", true},
+	}
+
+	for i, tt := range tests {
+		hash, err := hashes[tt.hash].Hash("hunter2")
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		if got := PasswordNeedsRehash(hash); got != tt.want {
+			t.Errorf("case %d: want %t, got %t", i, tt.want, got)
+		}
+	}
+
+	if !PasswordNeedsRehash(Password("WORDPASS")) {
+		t.Errorf("want unknown hash to need rehash")
+	}
+}
+
+func TestPasswordHashParameterBounds(t *testing.T) {
+	bcryptHash, err := testBcrypt.Hash("hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	const saltAndKey = "$c2FsdHNhbHRzYWx0c2FsdA$7rY1ZUtQrNs0gR2ZzLDipSKkJ6K4ghvSvyxCeIMYcao"
+
+	tests := []struct {
+		hash  string
+		valid bool
+	}{
+		{string(bcryptHash), true},
+		{strings.Replace(string(bcryptHash), "$04$", "$31$", 1), false},
+		{"$argon2id$v=19$m=65536,t=3,p=4" + saltAndKey, true},
+		{"$argon2id$v=19$m=4294967295,t=1,p=1" + saltAndKey, false},
+		{"$argon2id$v=19$m=64,t=4294967295,p=1" + saltAndKey, false},
+		{"$argon2id$v=19$m=64,t=1,p=255" + saltAndKey, false},
+		{"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$" + strings.Repeat("A", 1000), false},
+		{"$scrypt$ln=15,r=8,p=1" + saltAndKey, true},
+		{"$scrypt$ln=30,r=8,p=1" + saltAndKey, false},
+		{"$scrypt$ln=4,r=1073741823,p=1" + saltAndKey, false},
+		{"$scrypt$ln=4,r=8,p=1000" + saltAndKey, false},
+	}
+
+	for i, tt := range tests {
+		if got := ValidPasswordHash(Password(tt.hash)); got != tt.valid {
+			t.Errorf("case %d: want valid=%t, got %t", i, tt.valid, got)
+		}
+		if tt.valid {
+			continue
+		}
+		// Oversized parameters are rejected before any hashing is done.
+		if err := ComparePassword(Password(tt.hash), "hunter2"); err != ErrorMalformedPasswordHash {
+			t.Errorf("case %d: want %v, got %v", i, ErrorMalformedPasswordHash, err)
+		}
+	}
+}
+
+func TestPasswordHashAlgorithmValid(t *testing.T) {
+	bcryptHash, err := testBcrypt.Hash("hunter2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	salt, key := []byte("saltsaltsaltsalt"), make([]byte, passwordKeyLength)
+
+	// encode returns a hash with the parameters of alg without computing
+	// it, as hashing at the largest parameters takes seconds and a GiB.
+	encode := func(alg PasswordHashAlgorithm) Password {
+		switch a := alg.(type) {
+		case BcryptAlgorithm:
+			return Password(strings.Replace(string(bcryptHash), "$04$", fmt.Sprintf("$%02d$", a.Cost), 1))
+		case Argon2idAlgorithm:
+			return a.encode(salt, key)
+		case ScryptAlgorithm:
+			return a.encode(salt, key)
+		}
+		t.Fatalf("unknown algorithm %T", alg)
+		return nil
+	}
+
+	tests := []struct {
+		alg   PasswordHashAlgorithm
+		valid bool
+		// hash is set for parameters cheap enough to hash with.
+		hash bool
+	}{
+		{BcryptAlgorithm{Cost: 4}, true, true},
+		{BcryptAlgorithm{Cost: maxBcryptCost}, true, false},
+		{BcryptAlgorithm{Cost: 3}, false, false},
+		{BcryptAlgorithm{Cost: maxBcryptCost + 1}, false, false},
+
+		{Argon2idAlgorithm{Time: maxArgon2idTime, Memory: 8 * maxArgon2idThreads, Threads: maxArgon2idThreads}, true, true},
+		{Argon2idAlgorithm{Time: 1, Memory: maxArgon2idMemory, Threads: 1}, true, false},
+		{Argon2idAlgorithm{Time: maxArgon2idTime + 1, Memory: 64, Threads: 1}, false, false},
+		{Argon2idAlgorithm{Time: 1, Memory: 8 * 17, Threads: maxArgon2idThreads + 1}, false, false},
+		{Argon2idAlgorithm{Time: 1, Memory: maxArgon2idMemory + 1, Threads: 1}, false, false},
+		{Argon2idAlgorithm{Time: 1, Memory: 8, Threads: 2}, false, false},
+		{Argon2idAlgorithm{Time: 0, Memory: 64, Threads: 1}, false, false},
+
+		// The largest --scrypt-ln, with the default r of 8, uses 1 GiB.
+		{ScryptAlgorithm{LogN: 20, R: 8, P: 1}, true, false},
+		{ScryptAlgorithm{LogN: 21, R: 8, P: 1}, false, false},
+		{ScryptAlgorithm{LogN: 1, R: 8, P: maxScryptP}, true, true},
+		{ScryptAlgorithm{LogN: 4, R: 8, P: maxScryptP + 1}, false, false},
+		{ScryptAlgorithm{LogN: 4, R: 0, P: 1}, false, false},
+		{ScryptAlgorithm{LogN: 0, R: 8, P: 1}, false, false},
+	}
+
+	for i, tt := range tests {
+		if err := tt.alg.Valid(); (err == nil) != tt.valid {
+			t.Errorf("case %d: want valid=%t, got error %v", i, tt.valid, err)
+		}
+		// Hashes made with valid parameters can be checked.
+		if got := ValidPasswordHash(encode(tt.alg)); got != tt.valid {
+			t.Errorf("case %d: want hash valid=%t, got %t", i, tt.valid, got)
+		}
+		if !tt.hash {
+			continue
+		}
+		hash, err := tt.alg.Hash("hunter2")
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if err := ComparePassword(hash, "hunter2"); err != nil {
+			t.Errorf("case %d: want nil err, got %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,224 @@
+package user
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+)
+
+const (
+	// minEmailSubstringLength is the shortest part of an email address
+	// EmailRule looks for in passwords; shorter parts match too often by
+	// accident.
+	minEmailSubstringLength = 4
+)
+
+var (
+	// DefaultPasswordPolicy only requires passwords to be at least six
+	// characters long.
+	DefaultPasswordPolicy = PasswordPolicy{MinLengthRule(6)}
+)
+
+// PasswordViolation describes a way in which a password fails a
+// PasswordRule.
+type PasswordViolation struct {
+	// Rule is a short machine-readable name of the rule, such as
+	// "min_length".
+	Rule string
+
+	// Message is a description of the rule suitable for end users.
+	Message string
+}
+
+// PasswordPolicyError is returned for passwords which violate the rules of a
+// PasswordPolicy.
+type PasswordPolicyError struct {
+	Violations []PasswordViolation
+}
+
+func (e PasswordPolicyError) Error() string {
+	msgs := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		msgs[i] = v.Message
+	}
+	return "password violates policy: " + strings.Join(msgs, "; ")
+}
+
+// PasswordRule checks one aspect of a new password for a user. pwi holds the
+// user's current PasswordInfo, and is empty for new users.
+type PasswordRule interface {
+	Check(usr User, pwi PasswordInfo, plaintext string) *PasswordViolation
+}
+
+// PasswordPolicy is the set of rules all new passwords must satisfy.
+type PasswordPolicy []PasswordRule
+
+// Check returns a PasswordPolicyError listing every rule of the policy the
+// password violates, or nil if it satisfies all of them.
+func (p PasswordPolicy) Check(usr User, pwi PasswordInfo, plaintext string) error {
+	var violations []PasswordViolation
+	for _, rule := range p {
+		if v := rule.Check(usr, pwi, plaintext); v != nil {
+			violations = append(violations, *v)
+		}
+	}
+	if len(violations) > 0 {
+		return PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// HistorySize returns the number of earlier passwords which must be kept in
+// PasswordInfo.History to enforce the policy.
+func (p PasswordPolicy) HistorySize() int {
+	n := 0
+	for _, rule := range p {
+		if h, ok := rule.(HistoryRule); ok && int(h)-1 > n {
+			n = int(h) - 1
+		}
+	}
+	return n
+}
+
+// MinLengthRule requires passwords to have at least the given number of
+// characters.
+type MinLengthRule int
+
+func (r MinLengthRule) Check(_ User, _ PasswordInfo, plaintext string) *PasswordViolation {
+	if len([]rune(plaintext)) >= int(r) {
+		return nil
+	}
+	return &PasswordViolation{
+		Rule:    "min_length",
+		Message: fmt.Sprintf("Passwords must be at least %d characters long.", int(r)),
+	}
+}
+
+// CharacterClassesRule requires passwords to contain characters from at
+// least the given number of classes: lowercase letters, uppercase letters,
+// digits and everything else.
+type CharacterClassesRule int
+
+func (r CharacterClassesRule) Check(_ User, _ PasswordInfo, plaintext string) *PasswordViolation {
+	var lower, upper, digit, other int
+	for _, c := range plaintext {
+		switch {
+		case unicode.IsLower(c):
+			lower = 1
+		case unicode.IsUpper(c):
+			upper = 1
+		case unicode.IsDigit(c):
+			digit = 1
+		default:
+			other = 1
+		}
+	}
+
+	if lower+upper+digit+other >= int(r) {
+		return nil
+	}
+	return &PasswordViolation{
+		Rule:    "character_classes",
+		Message: fmt.Sprintf("Passwords must contain at least %d of: lowercase letters, uppercase letters, digits and symbols.", int(r)),
+	}
+}
+
+// DictionaryRule rejects passwords found in a list of common passwords,
+// ignoring case.
+type DictionaryRule map[string]struct{}
+
+// NewDictionaryRuleFromReader reads a DictionaryRule from a list with one
+// password per line. Blank lines are ignored.
+func NewDictionaryRuleFromReader(r io.Reader) (DictionaryRule, error) {
+	d := DictionaryRule{}
+	s := bufio.NewScanner(r)
+	for s.Scan() {
+		if w := strings.TrimSpace(s.Text()); w != "" {
+			d[strings.ToLower(w)] = struct{}{}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+func NewDictionaryRuleFromFile(loc string) (DictionaryRule, error) {
+	f, err := os.Open(loc)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewDictionaryRuleFromReader(f)
+}
+
+func (r DictionaryRule) Check(_ User, _ PasswordInfo, plaintext string) *PasswordViolation {
+	if _, ok := r[strings.ToLower(plaintext)]; !ok {
+		return nil
+	}
+	return &PasswordViolation{
+		Rule:    "dictionary",
+		Message: "This password is too common.",
+	}
+}
+
+// EmailRule rejects passwords containing the user's email address, or its
+// local part or domain name, ignoring case.
+type EmailRule struct{}
+
+func (EmailRule) Check(usr User, _ PasswordInfo, plaintext string) *PasswordViolation {
+	email := strings.ToLower(usr.Email)
+	if email == "" {
+		return nil
+	}
+
+	pw := strings.ToLower(plaintext)
+	parts := []string{email}
+	if at := strings.LastIndex(email, "@"); at >= 0 {
+		parts = append(parts, email[:at])
+		domain := email[at+1:]
+		if dot := strings.Index(domain, "."); dot >= 0 {
+			domain = domain[:dot]
+		}
+		parts = append(parts, domain)
+	}
+
+	for _, part := range parts {
+		if len(part) >= minEmailSubstringLength && strings.Contains(pw, part) {
+			return &PasswordViolation{
+				Rule:    "email",
+				Message: "Passwords must not contain your email address.",
+			}
+		}
+	}
+	return nil
+}
+
+// HistoryRule rejects the user's last passwords, up to the given number and
+// including the current one.
+type HistoryRule int
+
+func (r HistoryRule) Check(_ User, pwi PasswordInfo, plaintext string) *PasswordViolation {
+	previous := append([]Password{pwi.Password}, pwi.History...)
+	if len(previous) > int(r) {
+		previous = previous[:int(r)]
+	}
+
+	for _, p := range previous {
+		if len(p) > 0 && ComparePassword(p, plaintext) == nil {
+			msg := "Passwords must differ from your current password."
+			if r > 1 {
+				msg = fmt.Sprintf("Passwords must differ from your last %d passwords.", int(r))
+			}
+			return &PasswordViolation{
+				Rule:    "history",
+				Message: msg,
+			}
+		}
+	}
+	return nil
+}
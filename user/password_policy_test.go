@@ -0,0 +1,109 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestPasswordRules(t *testing.T) {
+	dict, err := NewDictionaryRuleFromReader(strings.NewReader("password\n\n  Letmein  \n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	old, err := NewPasswordFromPlaintext("old-password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	older, err := NewPasswordFromPlaintext("older-password")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pwi := PasswordInfo{UserID: "ID-1", Password: old, History: []Password{older}}
+	usr := User{ID: "ID-1", Email: "elroy@jetsons.com"}
+
+	tests := []struct {
+		rule      PasswordRule
+		plaintext string
+		wantRule  string
+	}{
+		{rule: MinLengthRule(6), plaintext: "abcdef"},
+		{rule: MinLengthRule(6), plaintext: "abcde", wantRule: "min_length"},
+		// characters, not bytes, are counted
+		{rule: MinLengthRule(6), plaintext: "ääääa", wantRule: "min_length"},
+
+		{rule: CharacterClassesRule(3), plaintext: "abcD1"},
+		{rule: CharacterClassesRule(3), plaintext: "abc-1"},
+		{rule: CharacterClassesRule(3), plaintext: "abcdef1", wantRule: "character_classes"},
+
+		{rule: dict, plaintext: "PassWord", wantRule: "dictionary"},
+		{rule: dict, plaintext: "letmein", wantRule: "dictionary"},
+		{rule: dict, plaintext: "password1"},
+
+		{rule: EmailRule{}, plaintext: "xElroy@Jetsons.comx", wantRule: "email"},
+		{rule: EmailRule{}, plaintext: "elroy123", wantRule: "email"},
+		{rule: EmailRule{}, plaintext: "ilovejetsons", wantRule: "email"},
+		// the top-level domain is too short to count
+		{rule: EmailRule{}, plaintext: "dotcom-rules"},
+
+		{rule: HistoryRule(2), plaintext: "old-password", wantRule: "history"},
+		{rule: HistoryRule(2), plaintext: "older-password", wantRule: "history"},
+		{rule: HistoryRule(1), plaintext: "older-password"},
+		{rule: HistoryRule(2), plaintext: "new-password"},
+	}
+
+	for i, tt := range tests {
+		v := tt.rule.Check(usr, pwi, tt.plaintext)
+		gotRule := ""
+		if v != nil {
+			gotRule = v.Rule
+			if v.Message == "" {
+				t.Errorf("case %d: violation without message", i)
+			}
+		}
+		if gotRule != tt.wantRule {
+			t.Errorf("case %d: want rule %q, got %q", i, tt.wantRule, gotRule)
+		}
+	}
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	p := PasswordPolicy{MinLengthRule(8), CharacterClassesRule(2), EmailRule{}}
+	usr := User{Email: "elroy@jetsons.com"}
+
+	if err := p.Check(usr, PasswordInfo{}, "correct horse"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := p.Check(usr, PasswordInfo{}, "elroy")
+	perr, ok := err.(PasswordPolicyError)
+	if !ok {
+		t.Fatalf("want PasswordPolicyError, got %#v", err)
+	}
+	var got []string
+	for _, v := range perr.Violations {
+		got = append(got, v.Rule)
+	}
+	if diff := pretty.Compare([]string{"min_length", "character_classes", "email"}, got); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
+
+func TestPasswordPolicyHistorySize(t *testing.T) {
+	tests := []struct {
+		policy PasswordPolicy
+		want   int
+	}{
+		{policy: DefaultPasswordPolicy, want: 0},
+		{policy: PasswordPolicy{HistoryRule(1)}, want: 0},
+		{policy: PasswordPolicy{MinLengthRule(6), HistoryRule(5), HistoryRule(3)}, want: 4},
+	}
+
+	for i, tt := range tests {
+		if got := tt.policy.HistorySize(); got != tt.want {
+			t.Errorf("case %d: want=%d, got=%d", i, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,341 @@
+package user
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+const (
+	RecordFormatJSONL = "jsonl"
+	RecordFormatCSV   = "csv"
+)
+
+var (
+	ErrorUnknownRecordFormat = errors.New("unknown user record format")
+
+	// recordCSVHeader are the columns of the CSV record format. A user has
+	// one row per remote identity, or a single row without one.
+	recordCSVHeader = []string{"id", "email", "displayName", "emailVerified", "admin", "disabled", "createdAt", "passwordHash", "passwordExpires", "connectorID", "remoteID"}
+)
+
+// UserRecord holds a user with their remote identities and password hash,
+// as exported from and imported into dex.
+type UserRecord struct {
+	ID            string    `json:"id"`
+	Email         string    `json:"email"`
+	DisplayName   string    `json:"displayName,omitempty"`
+	EmailVerified bool      `json:"emailVerified"`
+	Admin         bool      `json:"admin"`
+	Disabled      bool      `json:"disabled"`
+	CreatedAt     time.Time `json:"createdAt"`
+
+	RemoteIdentities []RemoteIdentityRecord `json:"remoteIdentities,omitempty"`
+
+	// PasswordHash is a hash in any format accepted by ComparePassword.
+	PasswordHash    string    `json:"passwordHash,omitempty"`
+	PasswordExpires time.Time `json:"passwordExpires"`
+}
+
+// recordJSON is the JSON form of a UserRecord, which omits unset times.
+type recordJSON struct {
+	userRecordFields
+	CreatedAt       string `json:"createdAt,omitempty"`
+	PasswordExpires string `json:"passwordExpires,omitempty"`
+}
+
+type userRecordFields UserRecord
+
+func (r UserRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(recordJSON{
+		userRecordFields: userRecordFields(r),
+		CreatedAt:        formatRecordTime(r.CreatedAt),
+		PasswordExpires:  formatRecordTime(r.PasswordExpires),
+	})
+}
+
+func (r *UserRecord) UnmarshalJSON(data []byte) error {
+	var dec recordJSON
+	if err := json.Unmarshal(data, &dec); err != nil {
+		return err
+	}
+
+	*r = UserRecord(dec.userRecordFields)
+	var err error
+	if r.CreatedAt, err = parseRecordTime(dec.CreatedAt); err != nil {
+		return fmt.Errorf("invalid createdAt: %q", dec.CreatedAt)
+	}
+	if r.PasswordExpires, err = parseRecordTime(dec.PasswordExpires); err != nil {
+		return fmt.Errorf("invalid passwordExpires: %q", dec.PasswordExpires)
+	}
+	return nil
+}
+
+type RemoteIdentityRecord struct {
+	ConnectorID string `json:"connectorID"`
+	ID          string `json:"id"`
+}
+
+// NewUserRecord returns the record of usr with the given remote identities
+// and password, which may be nil.
+func NewUserRecord(usr User, rids []RemoteIdentity, pwi *PasswordInfo) UserRecord {
+	rec := UserRecord{
+		ID:            usr.ID,
+		Email:         usr.Email,
+		DisplayName:   usr.DisplayName,
+		EmailVerified: usr.EmailVerified,
+		Admin:         usr.Admin,
+		Disabled:      usr.Disabled,
+		CreatedAt:     usr.CreatedAt.UTC(),
+	}
+	for _, rid := range rids {
+		rec.RemoteIdentities = append(rec.RemoteIdentities, RemoteIdentityRecord{
+			ConnectorID: rid.ConnectorID,
+			ID:          rid.ID,
+		})
+	}
+	if pwi != nil {
+		rec.PasswordHash = string(pwi.Password)
+		if !pwi.PasswordExpires.IsZero() {
+			rec.PasswordExpires = pwi.PasswordExpires.UTC()
+		}
+	}
+	return rec
+}
+
+func (r UserRecord) User() User {
+	return User{
+		ID:            r.ID,
+		Email:         r.Email,
+		DisplayName:   r.DisplayName,
+		EmailVerified: r.EmailVerified,
+		Admin:         r.Admin,
+		Disabled:      r.Disabled,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func (r UserRecord) Identities() []RemoteIdentity {
+	var rids []RemoteIdentity
+	for _, rid := range r.RemoteIdentities {
+		rids = append(rids, RemoteIdentity{ConnectorID: rid.ConnectorID, ID: rid.ID})
+	}
+	return rids
+}
+
+// UserRecordWriter writes user records in one of the record formats.
+type UserRecordWriter interface {
+	Write(UserRecord) error
+
+	// Flush writes any buffered records.
+	Flush() error
+}
+
+// UserRecordReader reads user records in one of the record formats. Read
+// returns io.EOF after the last record.
+type UserRecordReader interface {
+	Read() (UserRecord, error)
+}
+
+func NewUserRecordWriter(w io.Writer, format string) (UserRecordWriter, error) {
+	switch format {
+	case RecordFormatJSONL:
+		bw := bufio.NewWriter(w)
+		return &jsonlRecordWriter{w: bw, enc: json.NewEncoder(bw)}, nil
+	case RecordFormatCSV:
+		return &csvRecordWriter{w: csv.NewWriter(w)}, nil
+	}
+	return nil, ErrorUnknownRecordFormat
+}
+
+func NewUserRecordReader(r io.Reader, format string) (UserRecordReader, error) {
+	switch format {
+	case RecordFormatJSONL:
+		return &jsonlRecordReader{dec: json.NewDecoder(r)}, nil
+	case RecordFormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = len(recordCSVHeader)
+		return &csvRecordReader{r: cr}, nil
+	}
+	return nil, ErrorUnknownRecordFormat
+}
+
+type jsonlRecordWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlRecordWriter) Write(rec UserRecord) error {
+	return w.enc.Encode(rec)
+}
+
+func (w *jsonlRecordWriter) Flush() error {
+	return w.w.Flush()
+}
+
+type jsonlRecordReader struct {
+	dec  *json.Decoder
+	line int
+}
+
+func (r *jsonlRecordReader) Read() (UserRecord, error) {
+	var rec UserRecord
+	r.line++
+	if err := r.dec.Decode(&rec); err != nil {
+		if err == io.EOF {
+			return UserRecord{}, err
+		}
+		return UserRecord{}, fmt.Errorf("record %d: %v", r.line, err)
+	}
+	return rec, nil
+}
+
+type csvRecordWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (w *csvRecordWriter) Write(rec UserRecord) error {
+	if !w.wroteHeader {
+		if err := w.w.Write(recordCSVHeader); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+
+	row := []string{
+		rec.ID,
+		rec.Email,
+		rec.DisplayName,
+		strconv.FormatBool(rec.EmailVerified),
+		strconv.FormatBool(rec.Admin),
+		strconv.FormatBool(rec.Disabled),
+		formatRecordTime(rec.CreatedAt),
+		rec.PasswordHash,
+		formatRecordTime(rec.PasswordExpires),
+		"",
+		"",
+	}
+	if len(rec.RemoteIdentities) == 0 {
+		return w.w.Write(row)
+	}
+	for _, rid := range rec.RemoteIdentities {
+		row[9], row[10] = rid.ConnectorID, rid.ID
+		if err := w.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *csvRecordWriter) Flush() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+type csvRecordReader struct {
+	r *csv.Reader
+
+	readHeader bool
+	// next is the first row of the next record, if already read.
+	next []string
+	line int
+}
+
+func (r *csvRecordReader) Read() (UserRecord, error) {
+	if !r.readHeader {
+		if _, err := r.row(); err != nil {
+			return UserRecord{}, err
+		}
+		r.readHeader = true
+	}
+
+	row := r.next
+	r.next = nil
+	if row == nil {
+		var err error
+		if row, err = r.row(); err != nil {
+			return UserRecord{}, err
+		}
+	}
+
+	rec, err := parseCSVRecord(row)
+	if err != nil {
+		return UserRecord{}, fmt.Errorf("line %d: %v", r.line, err)
+	}
+
+	// Following rows of the same user add remote identities.
+	for {
+		next, err := r.row()
+		if err == io.EOF {
+			return rec, nil
+		}
+		if err != nil {
+			return UserRecord{}, err
+		}
+		if next[0] != rec.ID || rec.ID == "" {
+			r.next = next
+			return rec, nil
+		}
+		if next[9] != "" || next[10] != "" {
+			rec.RemoteIdentities = append(rec.RemoteIdentities, RemoteIdentityRecord{ConnectorID: next[9], ID: next[10]})
+		}
+	}
+}
+
+func (r *csvRecordReader) row() ([]string, error) {
+	row, err := r.r.Read()
+	if err == nil {
+		r.line++
+	}
+	return row, err
+}
+
+func parseCSVRecord(row []string) (UserRecord, error) {
+	rec := UserRecord{
+		ID:           row[0],
+		Email:        row[1],
+		DisplayName:  row[2],
+		PasswordHash: row[7],
+	}
+
+	var err error
+	bools := []*bool{&rec.EmailVerified, &rec.Admin, &rec.Disabled}
+	for i, b := range bools {
+		if row[3+i] == "" {
+			continue
+		}
+		if *b, err = strconv.ParseBool(row[3+i]); err != nil {
+			return UserRecord{}, fmt.Errorf("invalid %s: %q", recordCSVHeader[3+i], row[3+i])
+		}
+	}
+	if rec.CreatedAt, err = parseRecordTime(row[6]); err != nil {
+		return UserRecord{}, fmt.Errorf("invalid createdAt: %q", row[6])
+	}
+	if rec.PasswordExpires, err = parseRecordTime(row[8]); err != nil {
+		return UserRecord{}, fmt.Errorf("invalid passwordExpires: %q", row[8])
+	}
+	if row[9] != "" || row[10] != "" {
+		rec.RemoteIdentities = []RemoteIdentityRecord{{ConnectorID: row[9], ID: row[10]}}
+	}
+	return rec, nil
+}
+
+func formatRecordTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func parseRecordTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
@@ -0,0 +1,104 @@
+package user
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+var testUserRecords = []UserRecord{
+	{
+		ID:            "ID-1",
+		Email:         "jane@example.com",
+		DisplayName:   "Jane, \"JD\" Doe",
+		EmailVerified: true,
+		Admin:         true,
+		CreatedAt:     time.Unix(1234567890, 0).UTC(),
+		RemoteIdentities: []RemoteIdentityRecord{
+			{ConnectorID: "local", ID: "ID-1"},
+			{ConnectorID: "google", ID: "1234"},
+		},
+		PasswordHash:    "$2a$10$DSy4dOo3dG4qCTtjaPl/9eKuFyTGvQyBQDqC2JmSJHQ3bN0FYWVg6",
+		PasswordExpires: time.Unix(1234567899, 0).UTC(),
+	},
+	{
+		ID:       "ID-2",
+		Email:    "john@example.com",
+		Disabled: true,
+	},
+	{
+		Email: "new@example.com",
+		RemoteIdentities: []RemoteIdentityRecord{
+			{ConnectorID: "google", ID: "5678"},
+		},
+	},
+}
+
+func TestUserRecordRoundTrip(t *testing.T) {
+	for _, format := range []string{RecordFormatJSONL, RecordFormatCSV} {
+		var buf bytes.Buffer
+		w, err := NewUserRecordWriter(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		for _, rec := range testUserRecords {
+			if err := w.Write(rec); err != nil {
+				t.Fatalf("%s: unexpected error: %v", format, err)
+			}
+		}
+		if err := w.Flush(); err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+
+		r, err := NewUserRecordReader(&buf, format)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", format, err)
+		}
+		var got []UserRecord
+		for {
+			rec, err := r.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: unexpected error: %v", format, err)
+			}
+			got = append(got, rec)
+		}
+
+		if diff := pretty.Compare(testUserRecords, got); diff != "" {
+			t.Errorf("%s: Compare(want, got): %v", format, diff)
+		}
+	}
+}
+
+func TestUserRecordReaderInvalid(t *testing.T) {
+	tests := []struct {
+		format string
+		data   string
+	}{
+		{RecordFormatJSONL, `{"id": "ID-1", "email": "jane@example.com", "createdAt": "yesterday"}`},
+		{RecordFormatJSONL, `{"id": "ID-1", "email": `},
+		{RecordFormatCSV, strings.Join(recordCSVHeader, ",") + "\nID-1,jane@example.com,,yes,,,,,,,\n"},
+		{RecordFormatCSV, strings.Join(recordCSVHeader, ",") + "\nID-1,jane@example.com\n"},
+	}
+
+	for i, tt := range tests {
+		r, err := NewUserRecordReader(strings.NewReader(tt.data), tt.format)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if _, err := r.Read(); err == nil || err == io.EOF {
+			t.Errorf("case %d: want error, got %v", i, err)
+		}
+	}
+
+	if _, err := NewUserRecordReader(strings.NewReader(""), "xml"); err != ErrorUnknownRecordFormat {
+		t.Errorf("want err=%v, got %v", ErrorUnknownRecordFormat, err)
+	}
+}
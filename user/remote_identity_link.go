@@ -0,0 +1,89 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
+	"github.com/coreos/go-oidc/oidc"
+)
+
+const (
+	// ClaimLinkConnectorID is the connector of the remote identity to be
+	// linked.
+	ClaimLinkConnectorID = "http://coreos.com/link/connector-id"
+
+	// ClaimLinkRemoteID is the ID of the remote identity to be linked, if
+	// it is known yet.
+	ClaimLinkRemoteID = "http://coreos.com/link/remote-id"
+)
+
+// NewRemoteIdentityLink creates an object which carries a request to link a
+// remote identity to the user with the given ID through a login. rid.ID may
+// be empty if the user has yet to log in with the connector.
+func NewRemoteIdentityLink(userID string, rid RemoteIdentity, clientID string, issuer url.URL, expires time.Duration) RemoteIdentityLink {
+	claims := oidc.NewClaims(issuer.String(), userID, clientID, clock.Now(), clock.Now().Add(expires))
+	claims.Add(ClaimLinkConnectorID, rid.ConnectorID)
+	if rid.ID != "" {
+		claims.Add(ClaimLinkRemoteID, rid.ID)
+	}
+	return RemoteIdentityLink{claims}
+}
+
+type RemoteIdentityLink struct {
+	Claims jose.Claims
+}
+
+// ParseAndVerifyRemoteIdentityLinkToken parses a string into a
+// RemoteIdentityLink, verifies the signature, and ensures that required
+// claims are present. In addition to the usual claims required by the OIDC
+// spec, "aud" and "sub" must be present as well as ClaimLinkConnectorID.
+func ParseAndVerifyRemoteIdentityLinkToken(token string, issuer url.URL, keys []key.PublicKey) (RemoteIdentityLink, error) {
+	tokenClaims, err := parseAndVerifyTokenClaims(token, issuer, keys)
+	if err != nil {
+		return RemoteIdentityLink{}, err
+	}
+
+	connectorID, ok, err := tokenClaims.Claims.StringClaim(ClaimLinkConnectorID)
+	if err != nil {
+		return RemoteIdentityLink{}, err
+	}
+	if !ok || connectorID == "" {
+		return RemoteIdentityLink{}, fmt.Errorf("no %q claim", ClaimLinkConnectorID)
+	}
+
+	if _, _, err := tokenClaims.Claims.StringClaim(ClaimLinkRemoteID); err != nil {
+		return RemoteIdentityLink{}, err
+	}
+
+	return RemoteIdentityLink{tokenClaims.Claims}, nil
+}
+
+// Token serializes the link request into a signed JWT.
+func (l RemoteIdentityLink) Token(signer jose.Signer) (string, error) {
+	jwt, err := jose.NewSignedJWT(l.Claims, signer)
+	if err != nil {
+		return "", err
+	}
+	return jwt.Encode(), nil
+}
+
+func (l RemoteIdentityLink) UserID() string {
+	return assertStringClaim(l.Claims, "sub")
+}
+
+func (l RemoteIdentityLink) ClientID() string {
+	return assertStringClaim(l.Claims, "aud")
+}
+
+// RemoteIdentity returns the remote identity to be linked. Its ID is empty
+// if it was not known when the link was requested.
+func (l RemoteIdentityLink) RemoteIdentity() RemoteIdentity {
+	id, _, _ := l.Claims.StringClaim(ClaimLinkRemoteID)
+	return RemoteIdentity{
+		ConnectorID: assertStringClaim(l.Claims, ClaimLinkConnectorID),
+		ID:          id,
+	}
+}
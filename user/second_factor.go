@@ -0,0 +1,90 @@
+package user
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/jose"
+	"github.com/coreos/go-oidc/key"
+	"github.com/coreos/go-oidc/oidc"
+)
+
+const (
+	// ClaimSecondFactorPurpose represents which second-factor step a
+	// SecondFactorChallenge is good for.
+	ClaimSecondFactorPurpose = "http://coreos.com/second-factor/purpose"
+
+	// SecondFactorPurposeTOTP is the purpose of challenges which let a user
+	// complete a login with a TOTP or recovery code.
+	SecondFactorPurposeTOTP = "totp"
+
+	// SecondFactorPurposeTOTPEnroll is the purpose of challenges which let a
+	// user confirm a new TOTP secret.
+	SecondFactorPurposeTOTPEnroll = "totp-enroll"
+
+	// SecondFactorPurposeWebAuthn is the purpose of challenges which let a
+	// user complete a login with a registered security key.
+	SecondFactorPurposeWebAuthn = "webauthn"
+
+	// SecondFactorPurposeWebAuthnRegister is the purpose of challenges which
+	// let a user register a new security key.
+	SecondFactorPurposeWebAuthnRegister = "webauthn-register"
+)
+
+// NewSecondFactorChallenge creates an object which is handed to a user in
+// serialized form once they have passed the first factor of a login, and
+// which they present along with their second factor to complete it. The
+// challenge is bound to the login session through sessionKey.
+func NewSecondFactorChallenge(userID, sessionKey, purpose string, issuer url.URL, expires time.Duration) SecondFactorChallenge {
+	claims := oidc.NewClaims(issuer.String(), userID, sessionKey, clock.Now(), clock.Now().Add(expires))
+	claims.Add(ClaimSecondFactorPurpose, purpose)
+	return SecondFactorChallenge{claims}
+}
+
+type SecondFactorChallenge struct {
+	Claims jose.Claims
+}
+
+// ParseAndVerifySecondFactorChallengeToken parses a string into a
+// SecondFactorChallenge, verifies the signature, and ensures that required
+// claims are present. In addition to the usual claims required by the OIDC
+// spec, "aud" and "sub" must be present as well as
+// ClaimSecondFactorPurpose.
+func ParseAndVerifySecondFactorChallengeToken(token string, issuer url.URL, keys []key.PublicKey) (SecondFactorChallenge, error) {
+	tokenClaims, err := parseAndVerifyTokenClaims(token, issuer, keys)
+	if err != nil {
+		return SecondFactorChallenge{}, err
+	}
+
+	purpose, ok, err := tokenClaims.Claims.StringClaim(ClaimSecondFactorPurpose)
+	if err != nil {
+		return SecondFactorChallenge{}, err
+	}
+	if !ok || purpose == "" {
+		return SecondFactorChallenge{}, fmt.Errorf("no %q claim", ClaimSecondFactorPurpose)
+	}
+
+	return SecondFactorChallenge{tokenClaims.Claims}, nil
+}
+
+// Token serializes the challenge into a signed JWT.
+func (c SecondFactorChallenge) Token(signer jose.Signer) (string, error) {
+	jwt, err := jose.NewSignedJWT(c.Claims, signer)
+	if err != nil {
+		return "", err
+	}
+	return jwt.Encode(), nil
+}
+
+func (c SecondFactorChallenge) UserID() string {
+	return assertStringClaim(c.Claims, "sub")
+}
+
+func (c SecondFactorChallenge) SessionKey() string {
+	return assertStringClaim(c.Claims, "aud")
+}
+
+func (c SecondFactorChallenge) Purpose() string {
+	return assertStringClaim(c.Claims, ClaimSecondFactorPurpose)
+}
@@ -0,0 +1,295 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	pcrypto "github.com/coreos/dex/pkg/crypto"
+	"github.com/coreos/dex/repo"
+)
+
+const (
+	// TOTPDigits is the number of digits in a TOTP code.
+	TOTPDigits = 6
+
+	// TOTPPeriod is the length of time for which a TOTP code is valid.
+	TOTPPeriod = 30 * time.Second
+
+	// RecoveryCodeCount is the number of recovery codes issued at once.
+	RecoveryCodeCount = 10
+
+	// totpSecretLength is the length of TOTP secrets in bytes, as
+	// recommended by RFC 4226 for HMAC-SHA1.
+	totpSecretLength = 20
+
+	// totpSkew is the number of periods before and after the current one
+	// for which codes are still accepted, to allow for clock drift.
+	totpSkew = 1
+
+	// recoveryCodeLength is the number of characters in a recovery code,
+	// not counting the separator.
+	recoveryCodeLength = 10
+)
+
+var (
+	ErrorTOTPAlreadyEnrolled = errors.New("TOTP already enrolled")
+
+	totpEncoding = base32.StdEncoding
+)
+
+// TOTPInfo is the time-based one-time password (RFC 6238) second factor of
+// a local user.
+type TOTPInfo struct {
+	UserID string
+
+	// Secret is the key shared with the user's authenticator.
+	Secret []byte
+
+	// Confirmed is set once the user has proven possession of the secret by
+	// entering a valid code. Until then the second factor is not required
+	// to log in.
+	Confirmed bool
+
+	// RecoveryCodes holds the SHA-256 hashes of the unused recovery codes.
+	// Each recovery code may be used once in place of a TOTP code.
+	RecoveryCodes [][]byte
+
+	// LastCounter is the time step of the last accepted code. Codes from
+	// this or earlier time steps are rejected so that they cannot be
+	// replayed.
+	LastCounter int64
+
+	CreatedAt time.Time
+}
+
+// NewTOTPInfo generates a new, unconfirmed TOTPInfo with a random secret.
+func NewTOTPInfo(userID string, createdAt time.Time) (TOTPInfo, error) {
+	secret, err := pcrypto.RandBytes(totpSecretLength)
+	if err != nil {
+		return TOTPInfo{}, err
+	}
+
+	return TOTPInfo{
+		UserID:    userID,
+		Secret:    secret,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// EncodedSecret returns the secret in the base32 form expected by
+// authenticator apps when it is entered by hand.
+func (t TOTPInfo) EncodedSecret() string {
+	return strings.TrimRight(totpEncoding.EncodeToString(t.Secret), "=")
+}
+
+// KeyURI returns the otpauth:// URI understood by authenticator apps,
+// typically presented to the user as a QR code or link.
+func (t TOTPInfo) KeyURI(issuer, accountName string) string {
+	q := url.Values{}
+	q.Set("secret", t.EncodedSecret())
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", TOTPDigits))
+	q.Set("period", fmt.Sprintf("%d", int64(TOTPPeriod/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + accountName,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
+
+// Validate reports whether code is valid at the given time. A valid code
+// advances LastCounter, so the caller must persist the TOTPInfo afterwards.
+func (t *TOTPInfo) Validate(code string, now time.Time) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != TOTPDigits {
+		return false
+	}
+
+	counter := totpCounter(now)
+	for i := counter - totpSkew; i <= counter+totpSkew; i++ {
+		if i <= t.LastCounter {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(code), []byte(totpCode(t.Secret, i))) == 1 {
+			t.LastCounter = i
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateRecoveryCodes replaces the recovery codes with RecoveryCodeCount
+// new ones and returns them in plaintext. The plaintext codes are not kept,
+// so they must be shown to the user right away.
+func (t *TOTPInfo) GenerateRecoveryCodes() ([]string, error) {
+	codes := make([]string, RecoveryCodeCount)
+	hashes := make([][]byte, RecoveryCodeCount)
+	for i := range codes {
+		b, err := pcrypto.RandBytes(recoveryCodeLength)
+		if err != nil {
+			return nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(b))[:recoveryCodeLength]
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	t.RecoveryCodes = hashes
+	return codes, nil
+}
+
+// UseRecoveryCode reports whether code is one of the unused recovery codes.
+// A matching code is removed, so the caller must persist the TOTPInfo
+// afterwards.
+func (t *TOTPInfo) UseRecoveryCode(code string) bool {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	if len(code) != recoveryCodeLength {
+		return false
+	}
+
+	h := hashRecoveryCode(code)
+	for i, rc := range t.RecoveryCodes {
+		if subtle.ConstantTimeCompare(h, rc) == 1 {
+			t.RecoveryCodes = append(t.RecoveryCodes[:i:i], t.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateTOTPCode returns the TOTP code for the given secret at the given
+// time.
+func GenerateTOTPCode(secret []byte, now time.Time) string {
+	return totpCode(secret, totpCounter(now))
+}
+
+func totpCounter(t time.Time) int64 {
+	return t.Unix() / int64(TOTPPeriod/time.Second)
+}
+
+// totpCode implements the HOTP algorithm of RFC 4226.
+func totpCode(secret []byte, counter int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", TOTPDigits, bin%mod)
+}
+
+func hashRecoveryCode(code string) []byte {
+	h := sha256.Sum256([]byte(code))
+	return h[:]
+}
+
+type TOTPInfoRepo interface {
+	Get(tx repo.Transaction, userID string) (TOTPInfo, error)
+	Create(repo.Transaction, TOTPInfo) error
+	Update(repo.Transaction, TOTPInfo) error
+	Delete(tx repo.Transaction, userID string) error
+}
+
+func NewTOTPInfoRepo() TOTPInfoRepo {
+	return &memTOTPInfoRepo{
+		infos: make(map[string]TOTPInfo),
+	}
+}
+
+type memTOTPInfoRepo struct {
+	mu    sync.Mutex
+	infos map[string]TOTPInfo
+}
+
+func (m *memTOTPInfoRepo) Get(_ repo.Transaction, userID string) (TOTPInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, ok := m.infos[userID]
+	if !ok {
+		return TOTPInfo{}, ErrorNotFound
+	}
+	return info, nil
+}
+
+func (m *memTOTPInfoRepo) Create(_ repo.Transaction, info TOTPInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if info.UserID == "" {
+		return ErrorInvalidID
+	}
+
+	if _, ok := m.infos[info.UserID]; ok {
+		return ErrorDuplicateID
+	}
+
+	m.infos[info.UserID] = info
+	return nil
+}
+
+func (m *memTOTPInfoRepo) Update(_ repo.Transaction, info TOTPInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if info.UserID == "" {
+		return ErrorInvalidID
+	}
+
+	if _, ok := m.infos[info.UserID]; !ok {
+		return ErrorNotFound
+	}
+
+	m.infos[info.UserID] = info
+	return nil
+}
+
+func (m *memTOTPInfoRepo) Delete(_ repo.Transaction, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.infos[userID]; !ok {
+		return ErrorNotFound
+	}
+
+	delete(m.infos, userID)
+	return nil
+}
+
+func (m *memTOTPInfoRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(m.infos)
+}
+
+func (m *memTOTPInfoRepo) Restore(b []byte) error {
+	infos := make(map[string]TOTPInfo)
+	if err := json.Unmarshal(b, &infos); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.infos = infos
+	return nil
+}
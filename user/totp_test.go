@@ -0,0 +1,167 @@
+package user
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateTOTPCode(t *testing.T) {
+	// Test vectors from RFC 6238, Appendix B, truncated to six digits.
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+		{unix: 20000000000, want: "353130"},
+	}
+
+	for i, tt := range tests {
+		got := GenerateTOTPCode(secret, time.Unix(tt.unix, 0))
+		if got != tt.want {
+			t.Errorf("case %d: want=%q, got=%q", i, tt.want, got)
+		}
+	}
+}
+
+func TestTOTPInfoValidate(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	info, err := NewTOTPInfo("ID-1", now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		code string
+		at   time.Time
+		want bool
+	}{
+		// code from the previous period is accepted
+		{
+			code: GenerateTOTPCode(info.Secret, now.Add(-TOTPPeriod)),
+			at:   now,
+			want: true,
+		},
+		// current code is accepted
+		{
+			code: GenerateTOTPCode(info.Secret, now),
+			at:   now,
+			want: true,
+		},
+		// the same code may not be used twice
+		{
+			code: GenerateTOTPCode(info.Secret, now),
+			at:   now,
+			want: false,
+		},
+		// nor may an older one once a newer one was used
+		{
+			code: GenerateTOTPCode(info.Secret, now.Add(-TOTPPeriod)),
+			at:   now,
+			want: false,
+		},
+		// code from too far in the future
+		{
+			code: GenerateTOTPCode(info.Secret, now.Add(3*TOTPPeriod)),
+			at:   now,
+			want: false,
+		},
+		// malformed codes
+		{
+			code: "",
+			at:   now,
+			want: false,
+		},
+		{
+			code: "1234567",
+			at:   now,
+			want: false,
+		},
+		// code from the next period
+		{
+			code: GenerateTOTPCode(info.Secret, now.Add(TOTPPeriod)),
+			at:   now,
+			want: true,
+		},
+	}
+
+	for i, tt := range tests {
+		got := info.Validate(tt.code, tt.at)
+		if got != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, got)
+		}
+	}
+}
+
+func TestTOTPInfoRecoveryCodes(t *testing.T) {
+	info, err := NewTOTPInfo("ID-1", time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	codes, err := info.GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(codes) != RecoveryCodeCount || len(info.RecoveryCodes) != RecoveryCodeCount {
+		t.Fatalf("want %d recovery codes, got %d codes and %d hashes", RecoveryCodeCount, len(codes), len(info.RecoveryCodes))
+	}
+
+	if info.UseRecoveryCode("bogus-codes") {
+		t.Errorf("bogus recovery code accepted")
+	}
+
+	if !info.UseRecoveryCode(codes[3]) {
+		t.Errorf("recovery code not accepted")
+	}
+	if info.UseRecoveryCode(codes[3]) {
+		t.Errorf("recovery code accepted twice")
+	}
+	if len(info.RecoveryCodes) != RecoveryCodeCount-1 {
+		t.Errorf("want %d remaining recovery codes, got %d", RecoveryCodeCount-1, len(info.RecoveryCodes))
+	}
+
+	// Codes may be entered without the separator and in upper case.
+	code := codes[0][:5] + codes[0][6:]
+	if !info.UseRecoveryCode(" " + strings.ToUpper(code) + " ") {
+		t.Errorf("recovery code without separator not accepted")
+	}
+
+	// Generating new codes invalidates the old ones.
+	if _, err := info.GenerateRecoveryCodes(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.UseRecoveryCode(codes[1]) {
+		t.Errorf("old recovery code accepted after regeneration")
+	}
+}
+
+func TestTOTPInfoKeyURI(t *testing.T) {
+	info := TOTPInfo{
+		UserID: "ID-1",
+		Secret: []byte("12345678901234567890"),
+	}
+
+	u, err := url.Parse(info.KeyURI("dex.example.com", "someone@example.com"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if u.Scheme != "otpauth" || u.Host != "totp" || u.Path != "/dex.example.com:someone@example.com" {
+		t.Errorf("unexpected key URI: %v", u)
+	}
+
+	q := u.Query()
+	if got, want := q.Get("secret"), "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"; got != want {
+		t.Errorf("want secret=%q, got %q", want, got)
+	}
+	if got, want := q.Get("issuer"), "dex.example.com"; got != want {
+		t.Errorf("want issuer=%q, got %q", want, got)
+	}
+}
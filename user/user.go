@@ -11,6 +11,8 @@ import (
 	"net/url"
 	"os"
 	"sort"
+	"strings"
+	"sync"
 
 	"github.com/jonboulle/clockwork"
 	"github.com/pborman/uuid"
@@ -78,6 +80,14 @@ type User struct {
 	// DisplayName is not neccesarily unique with a UserRepo.
 	DisplayName string
 
+	// GivenName, FamilyName, Picture and Locale are the standard profile
+	// claims of the user, refreshed from their remote identity at each
+	// login.
+	GivenName  string
+	FamilyName string
+	Picture    string
+	Locale     string
+
 	Email string
 
 	EmailVerified bool
@@ -89,8 +99,67 @@ type User struct {
 	CreatedAt time.Time
 }
 
+// Profile holds the standard profile claims of a remote identity, as far
+// as its connector knows them.
+type Profile struct {
+	GivenName  string `json:",omitempty"`
+	FamilyName string `json:",omitempty"`
+	Picture    string `json:",omitempty"`
+	Locale     string `json:",omitempty"`
+}
+
+// UserFilter selects the users returned by UserRepo.List. Users must match
+// every field which is set; the zero UserFilter matches all users. Text is
+// matched ignoring case.
 type UserFilter struct {
-	// TODO(bobbyrullo): actually put stuff in here.
+	// EmailPrefix matches users whose email address starts with it.
+	EmailPrefix string `json:",omitempty"`
+
+	// DisplayName matches users whose display name contains it.
+	DisplayName string `json:",omitempty"`
+
+	Admin         *bool `json:",omitempty"`
+	Disabled      *bool `json:",omitempty"`
+	EmailVerified *bool `json:",omitempty"`
+
+	// ConnectorID matches users with a remote identity at the connector.
+	ConnectorID string `json:",omitempty"`
+
+	// CreatedAfter and CreatedBefore match users created at or after, and
+	// before, the given times.
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+// Matches reports whether usr, having the given remote identities, matches
+// the filter.
+func (f UserFilter) Matches(usr User, rids []RemoteIdentity) bool {
+	switch {
+	case f.EmailPrefix != "" && !strings.HasPrefix(strings.ToLower(usr.Email), strings.ToLower(f.EmailPrefix)):
+		return false
+	case f.DisplayName != "" && !strings.Contains(strings.ToLower(usr.DisplayName), strings.ToLower(f.DisplayName)):
+		return false
+	case f.Admin != nil && usr.Admin != *f.Admin:
+		return false
+	case f.Disabled != nil && usr.Disabled != *f.Disabled:
+		return false
+	case f.EmailVerified != nil && usr.EmailVerified != *f.EmailVerified:
+		return false
+	case !f.CreatedAfter.IsZero() && usr.CreatedAt.Before(f.CreatedAfter):
+		return false
+	case !f.CreatedBefore.IsZero() && !usr.CreatedAt.Before(f.CreatedBefore):
+		return false
+	}
+
+	if f.ConnectorID == "" {
+		return true
+	}
+	for _, rid := range rids {
+		if rid.ConnectorID == f.ConnectorID {
+			return true
+		}
+	}
+	return false
 }
 
 // AddToClaims adds basic information about the user to the given Claims.
@@ -105,6 +174,39 @@ func (u *User) AddToClaims(claims jose.Claims) {
 	}
 }
 
+// AddProfileClaims adds the profile claims the user has to the given Claims.
+// They are only added when the "profile" scope is requested.
+func (u *User) AddProfileClaims(claims jose.Claims) {
+	for claim, v := range map[string]string{
+		"given_name":  u.GivenName,
+		"family_name": u.FamilyName,
+		"picture":     u.Picture,
+		"locale":      u.Locale,
+	} {
+		if v != "" {
+			claims.Add(claim, v)
+		}
+	}
+}
+
+// RefreshProfile copies the profile claims of a remote identity to the
+// user, keeping those it lacks, and reports whether the user changed.
+func (u *User) RefreshProfile(p Profile) bool {
+	changed := false
+	for dst, src := range map[*string]string{
+		&u.GivenName:  p.GivenName,
+		&u.FamilyName: p.FamilyName,
+		&u.Picture:    p.Picture,
+		&u.Locale:     p.Locale,
+	} {
+		if src != "" && *dst != src {
+			*dst = src
+			changed = true
+		}
+	}
+	return changed
+}
+
 // UserRepo implementations maintain a persistent set of users.
 // The following invariants must be maintained:
 //  * Users must have a unique Email and ID
@@ -125,6 +227,10 @@ type UserRepo interface {
 
 	Disable(tx repo.Transaction, id string, disabled bool) error
 
+	// Delete removes the user with the given ID along with their remote
+	// identities.
+	Delete(tx repo.Transaction, id string) error
+
 	Update(repo.Transaction, User) error
 
 	GetByRemoteIdentity(repo.Transaction, RemoteIdentity) (User, error)
@@ -168,10 +274,6 @@ func ValidEmail(email string) bool {
 	return address.Address == email
 }
 
-func ValidPassword(plaintext string) bool {
-	return len(plaintext) > 5
-}
-
 // NewUserRepo returns an in-memory UserRepo useful for development.
 func NewUserRepo() UserRepo {
 	return &memUserRepo{
@@ -183,6 +285,7 @@ func NewUserRepo() UserRepo {
 }
 
 type memUserRepo struct {
+	mu                sync.Mutex
 	usersByID         map[string]User
 	userIDsByEmail    map[string]string
 	userIDsByRemoteID map[RemoteIdentity]string
@@ -190,6 +293,8 @@ type memUserRepo struct {
 }
 
 func (r *memUserRepo) Get(_ repo.Transaction, id string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	user, ok := r.usersByID[id]
 	if !ok {
 		return User{}, ErrorNotFound
@@ -204,6 +309,8 @@ func (s usersByEmail) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
 func (s usersByEmail) Less(i, j int) bool { return s[i].Email < s[j].Email }
 
 func (r *memUserRepo) List(tx repo.Transaction, filter UserFilter, maxResults int, nextPageToken string) ([]User, string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	var offset int
 	var err error
 	if nextPageToken != "" {
@@ -215,7 +322,13 @@ func (r *memUserRepo) List(tx repo.Transaction, filter UserFilter, maxResults in
 
 	users := []User{}
 	for _, usr := range r.usersByID {
-		users = append(users, usr)
+		var rids []RemoteIdentity
+		for rid := range r.remoteIDsByUserID[usr.ID] {
+			rids = append(rids, rid)
+		}
+		if filter.Matches(usr, rids) {
+			users = append(users, usr)
+		}
 	}
 
 	sort.Sort(usersByEmail(users))
@@ -240,14 +353,22 @@ func (r *memUserRepo) List(tx repo.Transaction, filter UserFilter, maxResults in
 }
 
 func (r *memUserRepo) GetByEmail(tx repo.Transaction, email string) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	userID, ok := r.userIDsByEmail[email]
 	if !ok {
 		return User{}, ErrorNotFound
 	}
-	return r.Get(tx, userID)
+	user, ok := r.usersByID[userID]
+	if !ok {
+		return User{}, ErrorNotFound
+	}
+	return user, nil
 }
 
 func (r *memUserRepo) Create(_ repo.Transaction, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if user.ID == "" {
 		return ErrorInvalidID
 	}
@@ -274,6 +395,8 @@ func (r *memUserRepo) Create(_ repo.Transaction, user User) error {
 }
 
 func (r *memUserRepo) Update(_ repo.Transaction, user User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if user.ID == "" {
 		return ErrorInvalidID
 	}
@@ -299,6 +422,8 @@ func (r *memUserRepo) Update(_ repo.Transaction, user User) error {
 }
 
 func (r *memUserRepo) Disable(_ repo.Transaction, id string, disable bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if id == "" {
 		return ErrorInvalidID
 	}
@@ -311,7 +436,28 @@ func (r *memUserRepo) Disable(_ repo.Transaction, id string, disable bool) error
 	return nil
 }
 
+func (r *memUserRepo) Delete(_ repo.Transaction, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == "" {
+		return ErrorInvalidID
+	}
+	user, ok := r.usersByID[id]
+	if !ok {
+		return ErrorNotFound
+	}
+	for ri := range r.remoteIDsByUserID[id] {
+		delete(r.userIDsByRemoteID, ri)
+	}
+	delete(r.remoteIDsByUserID, id)
+	delete(r.userIDsByEmail, user.Email)
+	delete(r.usersByID, id)
+	return nil
+}
+
 func (r *memUserRepo) AddRemoteIdentity(_ repo.Transaction, userID string, ri RemoteIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	_, ok := r.usersByID[userID]
 	if !ok {
 		return ErrorNotFound
@@ -333,6 +479,8 @@ func (r *memUserRepo) AddRemoteIdentity(_ repo.Transaction, userID string, ri Re
 }
 
 func (r *memUserRepo) RemoveRemoteIdentity(_ repo.Transaction, userID string, ri RemoteIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	otherID, ok := r.userIDsByRemoteID[ri]
 	if !ok {
 		return ErrorNotFound
@@ -346,6 +494,8 @@ func (r *memUserRepo) RemoveRemoteIdentity(_ repo.Transaction, userID string, ri
 }
 
 func (r *memUserRepo) GetByRemoteIdentity(_ repo.Transaction, ri RemoteIdentity) (User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	userID, ok := r.userIDsByRemoteID[ri]
 	if !ok {
 		return User{}, ErrorNotFound
@@ -359,6 +509,8 @@ func (r *memUserRepo) GetByRemoteIdentity(_ repo.Transaction, ri RemoteIdentity)
 }
 
 func (r *memUserRepo) GetRemoteIdentities(_ repo.Transaction, userID string) ([]RemoteIdentity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	ids := []RemoteIdentity{}
 	for id := range r.remoteIDsByUserID[userID] {
 		ids = append(ids, id)
@@ -367,6 +519,8 @@ func (r *memUserRepo) GetRemoteIdentities(_ repo.Transaction, userID string) ([]
 }
 
 func (r *memUserRepo) GetAdminCount(_ repo.Transaction) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	var i int
 	for _, usr := range r.usersByID {
 		if usr.Admin {
@@ -377,11 +531,65 @@ func (r *memUserRepo) GetAdminCount(_ repo.Transaction) (int, error) {
 }
 
 func (r *memUserRepo) set(user User) error {
+	if old, ok := r.usersByID[user.ID]; ok && old.Email != user.Email {
+		delete(r.userIDsByEmail, old.Email)
+	}
 	r.usersByID[user.ID] = user
 	r.userIDsByEmail[user.Email] = user.ID
 	return nil
 }
 
+// memUserSnapshot is a user in a snapshot of a memUserRepo. Its fields are
+// defined types so that they are encoded without the custom JSON decoding
+// of users files.
+type memUserSnapshot struct {
+	User             snapshotUser
+	RemoteIdentities []snapshotRemoteIdentity
+}
+
+type snapshotUser User
+
+type snapshotRemoteIdentity RemoteIdentity
+
+func (r *memUserRepo) Snapshot() ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	us := make([]memUserSnapshot, 0, len(r.usersByID))
+	for id, u := range r.usersByID {
+		s := memUserSnapshot{User: snapshotUser(u)}
+		for ri := range r.remoteIDsByUserID[id] {
+			s.RemoteIdentities = append(s.RemoteIdentities, snapshotRemoteIdentity(ri))
+		}
+		us = append(us, s)
+	}
+	return json.Marshal(us)
+}
+
+func (r *memUserRepo) Restore(b []byte) error {
+	var us []memUserSnapshot
+	if err := json.Unmarshal(b, &us); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.usersByID = make(map[string]User)
+	r.userIDsByEmail = make(map[string]string)
+	r.userIDsByRemoteID = make(map[RemoteIdentity]string)
+	r.remoteIDsByUserID = make(map[string]map[RemoteIdentity]struct{})
+	for _, s := range us {
+		r.set(User(s.User))
+		rIDs := make(map[RemoteIdentity]struct{})
+		for _, sri := range s.RemoteIdentities {
+			ri := RemoteIdentity(sri)
+			r.userIDsByRemoteID[ri] = s.User.ID
+			rIDs[ri] = struct{}{}
+		}
+		r.remoteIDsByUserID[s.User.ID] = rIDs
+	}
+	return nil
+}
+
 type UserWithRemoteIdentities struct {
 	User             User             `json:"user"`
 	RemoteIdentities []RemoteIdentity `json:"remoteIdentities"`
@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kylelemons/godebug/pretty"
 
@@ -94,6 +95,75 @@ func TestAddToClaims(t *testing.T) {
 	}
 }
 
+func TestAddProfileClaims(t *testing.T) {
+	tests := []struct {
+		user         User
+		wantedClaims jose.Claims
+	}{
+		{
+			user:         User{DisplayName: "Test User Name"},
+			wantedClaims: jose.Claims{},
+		},
+		{
+			user: User{
+				GivenName:  "Test",
+				FamilyName: "User",
+				Picture:    "https://example.com/test.png",
+				Locale:     "en-US",
+			},
+			wantedClaims: jose.Claims{
+				"given_name":  "Test",
+				"family_name": "User",
+				"picture":     "https://example.com/test.png",
+				"locale":      "en-US",
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		claims := jose.Claims{}
+		tt.user.AddProfileClaims(claims)
+		if !reflect.DeepEqual(claims, tt.wantedClaims) {
+			t.Errorf("case %d: want=%#v, got=%#v", i, tt.wantedClaims, claims)
+		}
+	}
+}
+
+func TestRefreshProfile(t *testing.T) {
+	tests := []struct {
+		user        User
+		profile     Profile
+		want        User
+		wantChanged bool
+	}{
+		{
+			user:    User{GivenName: "Test", Picture: "https://example.com/old.png"},
+			profile: Profile{Picture: "https://example.com/new.png", Locale: "fr"},
+			want: User{
+				GivenName: "Test",
+				Picture:   "https://example.com/new.png",
+				Locale:    "fr",
+			},
+			wantChanged: true,
+		},
+		{
+			user:    User{GivenName: "Test", Locale: "fr"},
+			profile: Profile{GivenName: "Test"},
+			want:    User{GivenName: "Test", Locale: "fr"},
+		},
+	}
+
+	for i, tt := range tests {
+		usr := tt.user
+		if changed := usr.RefreshProfile(tt.profile); changed != tt.wantChanged {
+			t.Errorf("case %d: want changed=%v, got %v", i, tt.wantChanged, changed)
+		}
+		if diff := pretty.Compare(tt.want, usr); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
+
 func TestValidEmail(t *testing.T) {
 	tests := []struct {
 		email string
@@ -116,11 +186,24 @@ func TestValidEmail(t *testing.T) {
 }
 
 func TestEncodeDecodeNextPageToken(t *testing.T) {
+	admin := true
 	tests := []nextPageToken{
 		{},
 		{MaxResults: 100},
 		{Offset: 200},
 		{MaxResults: 20, Offset: 30},
+		{
+			Filter: UserFilter{
+				EmailPrefix:   "al",
+				DisplayName:   "smith",
+				Admin:         &admin,
+				ConnectorID:   "local",
+				CreatedAfter:  time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC),
+				CreatedBefore: time.Date(2016, time.February, 1, 0, 0, 0, 0, time.UTC),
+			},
+			MaxResults: 20,
+			Offset:     30,
+		},
 	}
 
 	for i, tt := range tests {
@@ -140,3 +223,57 @@ func TestEncodeDecodeNextPageToken(t *testing.T) {
 		}
 	}
 }
+
+func TestMemUserRepoSnapshot(t *testing.T) {
+	us := []UserWithRemoteIdentities{
+		{
+			User: User{
+				ID:            "ID-1",
+				Email:         "Email-1@example.com",
+				EmailVerified: true,
+				DisplayName:   "Name-1",
+				Admin:         true,
+				CreatedAt:     time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC),
+			},
+			RemoteIdentities: []RemoteIdentity{
+				{ConnectorID: "local", ID: "1"},
+			},
+		},
+		{
+			User: User{
+				ID:       "ID-2",
+				Email:    "Email-2@example.com",
+				Disabled: true,
+			},
+		},
+	}
+	r := NewUserRepoFromUsers(us).(*memUserRepo)
+	b, err := r.Snapshot()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := NewUserRepo().(*memUserRepo)
+	if err := restored.Restore(b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i, tt := range us {
+		u, err := restored.GetByEmail(nil, tt.User.Email)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.User, u); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+		ris, err := restored.GetRemoteIdentities(nil, tt.User.ID)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+			continue
+		}
+		if diff := pretty.Compare(tt.RemoteIdentities, ris); diff != "" {
+			t.Errorf("case %d: Compare(want, got): %v", i, diff)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package user
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/dex/repo"
+)
+
+// WebAuthnCredential is a security key registered by a user as a second
+// factor.
+type WebAuthnCredential struct {
+	// ID is the credential ID assigned by the authenticator.
+	ID []byte
+
+	UserID string
+
+	// PublicKey is the credential public key, in COSE_Key format.
+	PublicKey []byte
+
+	// SignCount is the last value of the authenticator's signature counter,
+	// which must increase with every assertion.
+	SignCount uint32
+
+	CreatedAt time.Time
+}
+
+type WebAuthnCredentialRepo interface {
+	// Get returns the credential with the given ID.
+	Get(tx repo.Transaction, id []byte) (WebAuthnCredential, error)
+
+	// GetByUserID returns the credentials of a user, in order of creation.
+	GetByUserID(tx repo.Transaction, userID string) ([]WebAuthnCredential, error)
+
+	Create(repo.Transaction, WebAuthnCredential) error
+
+	// Update stores the new SignCount of a credential.
+	Update(repo.Transaction, WebAuthnCredential) error
+
+	// DeleteByUserID removes all credentials of a user.
+	DeleteByUserID(tx repo.Transaction, userID string) error
+}
+
+func NewWebAuthnCredentialRepo() WebAuthnCredentialRepo {
+	return &memWebAuthnCredentialRepo{
+		creds: make(map[string]WebAuthnCredential),
+	}
+}
+
+type memWebAuthnCredentialRepo struct {
+	mu    sync.Mutex
+	creds map[string]WebAuthnCredential
+}
+
+func (m *memWebAuthnCredentialRepo) Get(_ repo.Transaction, id []byte) (WebAuthnCredential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	cred, ok := m.creds[string(id)]
+	if !ok {
+		return WebAuthnCredential{}, ErrorNotFound
+	}
+	return cred, nil
+}
+
+func (m *memWebAuthnCredentialRepo) GetByUserID(_ repo.Transaction, userID string) ([]WebAuthnCredential, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var creds []WebAuthnCredential
+	for _, cred := range m.creds {
+		if cred.UserID == userID {
+			creds = append(creds, cred)
+		}
+	}
+	sort.Sort(byCreatedAt(creds))
+	return creds, nil
+}
+
+func (m *memWebAuthnCredentialRepo) Create(_ repo.Transaction, cred WebAuthnCredential) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(cred.ID) == 0 || cred.UserID == "" {
+		return ErrorInvalidID
+	}
+
+	if _, ok := m.creds[string(cred.ID)]; ok {
+		return ErrorDuplicateID
+	}
+
+	m.creds[string(cred.ID)] = cred
+	return nil
+}
+
+func (m *memWebAuthnCredentialRepo) Update(_ repo.Transaction, cred WebAuthnCredential) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.creds[string(cred.ID)]
+	if !ok {
+		return ErrorNotFound
+	}
+
+	existing.SignCount = cred.SignCount
+	m.creds[string(cred.ID)] = existing
+	return nil
+}
+
+func (m *memWebAuthnCredentialRepo) DeleteByUserID(_ repo.Transaction, userID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, cred := range m.creds {
+		if cred.UserID == userID {
+			delete(m.creds, id)
+		}
+	}
+	return nil
+}
+
+// Snapshot encodes the credentials as a list, as their IDs are binary.
+func (m *memWebAuthnCredentialRepo) Snapshot() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	creds := make([]WebAuthnCredential, 0, len(m.creds))
+	for _, cred := range m.creds {
+		creds = append(creds, cred)
+	}
+	sort.Sort(byCreatedAt(creds))
+	return json.Marshal(creds)
+}
+
+func (m *memWebAuthnCredentialRepo) Restore(b []byte) error {
+	var creds []WebAuthnCredential
+	if err := json.Unmarshal(b, &creds); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.creds = make(map[string]WebAuthnCredential, len(creds))
+	for _, cred := range creds {
+		m.creds[string(cred.ID)] = cred
+	}
+	return nil
+}
+
+type byCreatedAt []WebAuthnCredential
+
+func (s byCreatedAt) Len() int      { return len(s) }
+func (s byCreatedAt) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byCreatedAt) Less(i, j int) bool {
+	if s[i].CreatedAt.Equal(s[j].CreatedAt) {
+		return string(s[i].ID) < string(s[j].ID)
+	}
+	return s[i].CreatedAt.Before(s[j].CreatedAt)
+}
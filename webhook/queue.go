@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/pborman/uuid"
+
+	"github.com/coreos/dex/repo"
+)
+
+// Delivery is the queued sending of an event to a subscription.
+type Delivery struct {
+	ID             int64
+	SubscriptionID string
+	EventID        string
+	EventType      EventType
+	Payload        []byte
+
+	// Attempts is the number of times sending has failed so far.
+	Attempts    int
+	NextAttempt time.Time
+	LastError   string
+}
+
+// DeliveryQueue holds deliveries until they have been sent. A delivery stays
+// queued until it is removed, so that every event is sent at least once.
+type DeliveryQueue interface {
+	Enqueue(tx repo.Transaction, ds []Delivery) error
+
+	// Claim returns up to max deliveries whose next attempt is due at now,
+	// oldest first, and postpones their next attempt until now plus lease
+	// so that other senders leave them alone meanwhile.
+	Claim(now time.Time, lease time.Duration, max int) ([]Delivery, error)
+
+	// Retry stores the attempts, next attempt and last error of d.
+	Retry(d Delivery) error
+
+	Remove(id int64) error
+}
+
+// Notifier queues events for the subscriptions wanting them.
+type Notifier interface {
+	// Notify queues e as part of tx, so that it is sent only if tx is
+	// committed. The ID and time of e are set if it has none.
+	Notify(tx repo.Transaction, e Event) error
+}
+
+func NewNotifier(subs SubscriptionRepo, queue DeliveryQueue) Notifier {
+	return &queueNotifier{
+		subs:  subs,
+		queue: queue,
+		clock: clockwork.NewRealClock(),
+	}
+}
+
+type queueNotifier struct {
+	subs  SubscriptionRepo
+	queue DeliveryQueue
+	clock clockwork.Clock
+}
+
+func (n *queueNotifier) Notify(tx repo.Transaction, e Event) error {
+	if !e.Type.Valid() {
+		return ErrorInvalidEventType
+	}
+
+	subs, err := n.subs.All(tx)
+	if err != nil {
+		return err
+	}
+
+	now := n.clock.Now().UTC()
+	if e.ID == "" {
+		e.ID = uuid.New()
+	}
+	if e.Time.IsZero() {
+		e.Time = now
+	}
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	var ds []Delivery
+	for _, s := range subs {
+		if !s.Wants(e.Type) {
+			continue
+		}
+		ds = append(ds, Delivery{
+			SubscriptionID: s.ID,
+			EventID:        e.ID,
+			EventType:      e.Type,
+			Payload:        payload,
+			NextAttempt:    now,
+		})
+	}
+	if len(ds) == 0 {
+		return nil
+	}
+	return n.queue.Enqueue(tx, ds)
+}
@@ -0,0 +1,136 @@
+package webhook
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestNotify(t *testing.T) {
+	clock := clockwork.NewFakeClock()
+	subs := NewSubscriptionRepo()
+	for _, s := range []Subscription{
+		{ID: "sub-1", URL: "https://one.example.com", Events: []EventType{EventUserCreated, EventUserLogin}},
+		{ID: "sub-2", URL: "https://two.example.com", Events: []EventType{EventUserLogin}, CreatedAt: time.Unix(1, 0)},
+	} {
+		if err := subs.Create(nil, s); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	queue := NewDeliveryQueue()
+	n := &queueNotifier{subs: subs, queue: queue, clock: clock}
+
+	if err := n.Notify(nil, Event{Type: "user_deleted"}); err != ErrorInvalidEventType {
+		t.Fatalf("want=%v, got=%v", ErrorInvalidEventType, err)
+	}
+
+	tests := []struct {
+		event   Event
+		wantIDs []string
+	}{
+		{
+			event:   Event{Type: EventUserCreated, UserID: "ID-1", Email: "elroy@example.com"},
+			wantIDs: []string{"sub-1"},
+		},
+		{
+			event:   Event{ID: "event-2", Type: EventUserLogin, UserID: "ID-1", ClientID: "XXX"},
+			wantIDs: []string{"sub-1", "sub-2"},
+		},
+		{
+			event: Event{Type: EventUserDisabled, UserID: "ID-1"},
+		},
+	}
+
+	for i, tt := range tests {
+		if err := n.Notify(nil, tt.event); err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+
+		ds, err := queue.Claim(clock.Now(), time.Minute, 10)
+		if err != nil {
+			t.Fatalf("case %d: unexpected error: %v", i, err)
+		}
+		var gotIDs []string
+		for _, d := range ds {
+			gotIDs = append(gotIDs, d.SubscriptionID)
+			if err := queue.Remove(d.ID); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+
+			var e Event
+			if err := json.Unmarshal(d.Payload, &e); err != nil {
+				t.Fatalf("case %d: unexpected error: %v", i, err)
+			}
+			if e.ID == "" || e.ID != d.EventID || (tt.event.ID != "" && e.ID != tt.event.ID) {
+				t.Errorf("case %d: unexpected event ID %q, delivery event ID %q", i, e.ID, d.EventID)
+			}
+			if !e.Time.Equal(clock.Now()) {
+				t.Errorf("case %d: want time=%v, got %v", i, clock.Now(), e.Time)
+			}
+			want := tt.event
+			want.ID, want.Time = e.ID, e.Time
+			if diff := pretty.Compare(want, e); diff != "" {
+				t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+			}
+		}
+		if diff := pretty.Compare(tt.wantIDs, gotIDs); diff != "" {
+			t.Errorf("case %d: Compare(want, got) = %v", i, diff)
+		}
+	}
+}
+
+func TestMemDeliveryQueue(t *testing.T) {
+	now := time.Unix(1000, 0).UTC()
+	q := NewDeliveryQueue()
+	err := q.Enqueue(nil, []Delivery{
+		{SubscriptionID: "sub-1", EventID: "event-1", NextAttempt: now},
+		{SubscriptionID: "sub-1", EventID: "event-2", NextAttempt: now.Add(time.Minute)},
+		{SubscriptionID: "sub-2", EventID: "event-1", NextAttempt: now},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	claim := func(at time.Time) []string {
+		ds, err := q.Claim(at, time.Hour, 10)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		var ids []string
+		for _, d := range ds {
+			ids = append(ids, d.SubscriptionID+"/"+d.EventID)
+		}
+		return ids
+	}
+
+	if diff := pretty.Compare([]string{"sub-1/event-1", "sub-2/event-1"}, claim(now)); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// Claimed deliveries are leased, so only the newly due one is returned.
+	if diff := pretty.Compare([]string{"sub-1/event-2"}, claim(now.Add(time.Minute))); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	if err := q.Remove(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := q.Remove(1); err != ErrorDeliveryNotFound {
+		t.Fatalf("want=%v, got=%v", ErrorDeliveryNotFound, err)
+	}
+	err = q.Retry(Delivery{ID: 3, SubscriptionID: "sub-2", EventID: "event-1", Attempts: 1, NextAttempt: now.Add(2 * time.Minute)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff := pretty.Compare([]string{"sub-2/event-1"}, claim(now.Add(2*time.Minute))); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+
+	// Once the lease runs out the deliveries are due again.
+	if diff := pretty.Compare([]string{"sub-1/event-2", "sub-2/event-1"}, claim(now.Add(3*time.Hour))); diff != "" {
+		t.Errorf("Compare(want, got) = %v", diff)
+	}
+}
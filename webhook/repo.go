@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/coreos/dex/repo"
+)
+
+func NewSubscriptionRepo() SubscriptionRepo {
+	return &memSubscriptionRepo{
+		subs: make(map[string]Subscription),
+	}
+}
+
+type memSubscriptionRepo struct {
+	mu   sync.Mutex
+	subs map[string]Subscription
+}
+
+func (r *memSubscriptionRepo) Create(_ repo.Transaction, s Subscription) error {
+	if err := s.Valid(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[s.ID]; ok {
+		return ErrorDuplicateID
+	}
+	r.subs[s.ID] = s
+	return nil
+}
+
+func (r *memSubscriptionRepo) Get(_ repo.Transaction, id string) (Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.subs[id]
+	if !ok {
+		return Subscription{}, ErrorNotFound
+	}
+	return s, nil
+}
+
+func (r *memSubscriptionRepo) Delete(_ repo.Transaction, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.subs[id]; !ok {
+		return ErrorNotFound
+	}
+	delete(r.subs, id)
+	return nil
+}
+
+func (r *memSubscriptionRepo) All(_ repo.Transaction) ([]Subscription, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subs := make([]Subscription, 0, len(r.subs))
+	for _, s := range r.subs {
+		subs = append(subs, s)
+	}
+	sort.Sort(byCreatedAt(subs))
+	return subs, nil
+}
+
+type byCreatedAt []Subscription
+
+func (s byCreatedAt) Len() int      { return len(s) }
+func (s byCreatedAt) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byCreatedAt) Less(i, j int) bool {
+	if !s[i].CreatedAt.Equal(s[j].CreatedAt) {
+		return s[i].CreatedAt.Before(s[j].CreatedAt)
+	}
+	return s[i].ID < s[j].ID
+}
+
+func NewDeliveryQueue() DeliveryQueue {
+	return &memDeliveryQueue{}
+}
+
+type memDeliveryQueue struct {
+	mu         sync.Mutex
+	lastID     int64
+	deliveries []Delivery
+}
+
+func (q *memDeliveryQueue) Enqueue(_ repo.Transaction, ds []Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, d := range ds {
+		q.lastID++
+		d.ID = q.lastID
+		q.deliveries = append(q.deliveries, d)
+	}
+	return nil
+}
+
+func (q *memDeliveryQueue) Claim(now time.Time, lease time.Duration, max int) ([]Delivery, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var ds []Delivery
+	for i := range q.deliveries {
+		if len(ds) == max {
+			break
+		}
+		if q.deliveries[i].NextAttempt.After(now) {
+			continue
+		}
+		ds = append(ds, q.deliveries[i])
+		q.deliveries[i].NextAttempt = now.Add(lease)
+	}
+	return ds, nil
+}
+
+func (q *memDeliveryQueue) Retry(d Delivery) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.deliveries {
+		if q.deliveries[i].ID == d.ID {
+			q.deliveries[i] = d
+			return nil
+		}
+	}
+	return ErrorDeliveryNotFound
+}
+
+func (q *memDeliveryQueue) Remove(id int64) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i := range q.deliveries {
+		if q.deliveries[i].ID == id {
+			q.deliveries = append(q.deliveries[:i], q.deliveries[i+1:]...)
+			return nil
+		}
+	}
+	return ErrorDeliveryNotFound
+}
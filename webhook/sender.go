@@ -0,0 +1,175 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+
+	"github.com/coreos/dex/pkg/log"
+	ptime "github.com/coreos/dex/pkg/time"
+)
+
+const (
+	DefaultInterval    = 5 * time.Second
+	DefaultMaxAttempts = 15
+	DefaultMaxBackoff  = time.Hour
+
+	// sendTimeout bounds each POST, and sendBatch the number of deliveries
+	// claimed at once. lease must outlast sending a whole batch.
+	sendTimeout = 10 * time.Second
+	sendBatch   = 10
+	lease       = 5 * time.Minute
+)
+
+// Sender POSTs queued deliveries to the URLs of their subscriptions,
+// retrying failed deliveries with exponential backoff.
+type Sender struct {
+	subs  SubscriptionRepo
+	queue DeliveryQueue
+
+	Client *http.Client
+	Clock  clockwork.Clock
+
+	// Interval is the time between checks of the queue for due deliveries.
+	Interval time.Duration
+
+	// MaxAttempts is the number of failed attempts after which a delivery
+	// is dropped, and MaxBackoff the longest wait between attempts.
+	MaxAttempts int
+	MaxBackoff  time.Duration
+}
+
+func NewSender(subs SubscriptionRepo, queue DeliveryQueue) *Sender {
+	return &Sender{
+		subs:        subs,
+		queue:       queue,
+		Client:      &http.Client{Timeout: sendTimeout},
+		Clock:       clockwork.NewRealClock(),
+		Interval:    DefaultInterval,
+		MaxAttempts: DefaultMaxAttempts,
+		MaxBackoff:  DefaultMaxBackoff,
+	}
+}
+
+// SendDue sends the deliveries which are due, and reports how many were
+// sent successfully.
+func (s *Sender) SendDue() (int, error) {
+	var sent int
+	for {
+		ds, err := s.queue.Claim(s.Clock.Now(), lease, sendBatch)
+		if err != nil {
+			return sent, err
+		}
+		for _, d := range ds {
+			ok, err := s.deliver(d)
+			if err != nil {
+				return sent, err
+			}
+			if ok {
+				sent++
+			}
+		}
+		if len(ds) < sendBatch {
+			return sent, nil
+		}
+	}
+}
+
+// deliver makes one attempt at sending d, then removes it from the queue or
+// schedules the next attempt.
+func (s *Sender) deliver(d Delivery) (bool, error) {
+	sub, err := s.subs.Get(nil, d.SubscriptionID)
+	if err == ErrorNotFound {
+		log.Infof("Dropping webhook delivery %d of event %s: subscription %s no longer exists", d.ID, d.EventID, d.SubscriptionID)
+		return false, s.queue.Remove(d.ID)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	sendErr := s.send(sub, d)
+	if sendErr == nil {
+		return true, s.queue.Remove(d.ID)
+	}
+
+	d.Attempts++
+	d.LastError = sendErr.Error()
+	if d.Attempts >= s.MaxAttempts {
+		log.Errorf("Dropping webhook delivery %d of event %s to %s after %d attempts: %v", d.ID, d.EventID, sub.URL, d.Attempts, sendErr)
+		return false, s.queue.Remove(d.ID)
+	}
+
+	d.NextAttempt = s.Clock.Now().Add(s.backoff(d.Attempts))
+	log.Errorf("Failed webhook delivery %d of event %s to %s, retrying at %v: %v", d.ID, d.EventID, sub.URL, d.NextAttempt, sendErr)
+	return false, s.queue.Retry(d)
+}
+
+// backoff returns the wait before the attempt following the given number of
+// failed attempts.
+func (s *Sender) backoff(attempts int) time.Duration {
+	var b time.Duration
+	for i := 0; i < attempts; i++ {
+		b = ptime.ExpBackoff(b, s.MaxBackoff)
+	}
+	return b
+}
+
+func (s *Sender) send(sub Subscription, d Delivery) error {
+	req, err := http.NewRequest("POST", sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(d.EventType))
+	req.Header.Set(DeliveryHeader, d.EventID)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(sub.Secret, d.Payload))
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status %s", resp.Status)
+	}
+	return nil
+}
+
+// Run sends due deliveries every Interval until the returned channel is
+// closed.
+func (s *Sender) Run() chan struct{} {
+	stop := make(chan struct{})
+
+	go func() {
+		var failing bool
+		next := s.Interval
+		for {
+			select {
+			case <-s.Clock.After(next):
+				if _, err := s.SendDue(); err != nil {
+					if !failing {
+						failing = true
+						next = time.Second
+					} else {
+						next = ptime.ExpBackoff(next, time.Minute)
+					}
+					log.Errorf("Failed sending webhooks, retrying in %v: %v", next, err)
+					break
+				}
+				failing = false
+				next = s.Interval
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return stop
+}
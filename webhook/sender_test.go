@@ -0,0 +1,126 @@
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+)
+
+type receivedHook struct {
+	event    string
+	delivery string
+	verified bool
+}
+
+func TestSenderSendDue(t *testing.T) {
+	secret := []byte("secret")
+	var received []receivedHook
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		received = append(received, receivedHook{
+			event:    r.Header.Get(EventHeader),
+			delivery: r.Header.Get(DeliveryHeader),
+			verified: VerifySignature(secret, body, r.Header.Get(SignatureHeader)),
+		})
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	clock := clockwork.NewFakeClock()
+	subs := NewSubscriptionRepo()
+	if err := subs.Create(nil, Subscription{ID: "sub-1", URL: srv.URL, Secret: secret, Events: []EventType{EventUserCreated}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	queue := NewDeliveryQueue()
+	n := &queueNotifier{subs: subs, queue: queue, clock: clock}
+	s := NewSender(subs, queue)
+	s.Clock = clock
+	s.MaxAttempts = 3
+
+	sendDue := func() int {
+		sent, err := s.SendDue()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sent
+	}
+
+	if err := n.Notify(nil, Event{ID: "event-1", Type: EventUserCreated, UserID: "ID-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent := sendDue(); sent != 1 {
+		t.Fatalf("want 1 sent, got %d", sent)
+	}
+	if len(received) != 1 || received[0] != (receivedHook{"user_created", "event-1", true}) {
+		t.Fatalf("unexpected hooks received: %#v", received)
+	}
+
+	// Sent deliveries are gone from the queue.
+	if sent := sendDue(); sent != 0 || len(received) != 1 {
+		t.Fatalf("delivery sent twice")
+	}
+
+	// A failed delivery is retried with backoff, then dropped.
+	status = http.StatusInternalServerError
+	if err := n.Notify(nil, Event{ID: "event-2", Type: EventUserCreated, UserID: "ID-2"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, wait := range []time.Duration{0, time.Second, 2 * time.Second} {
+		clock.Advance(wait - time.Millisecond)
+		if sendDue(); len(received) != i+1 {
+			t.Fatalf("attempt %d: sent before backoff passed", i)
+		}
+		clock.Advance(time.Millisecond)
+		if sent := sendDue(); sent != 0 || len(received) != i+2 {
+			t.Fatalf("attempt %d: want another failed attempt, got %d sent and %d received", i, sent, len(received))
+		}
+	}
+	clock.Advance(time.Hour)
+	if sendDue(); len(received) != 4 {
+		t.Fatalf("want delivery dropped after %d attempts, got %d received", s.MaxAttempts, len(received)-1)
+	}
+
+	// Deliveries to subscriptions which have since been deleted are dropped.
+	status = http.StatusOK
+	if err := n.Notify(nil, Event{ID: "event-3", Type: EventUserCreated, UserID: "ID-3"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := subs.Delete(nil, "sub-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sent := sendDue(); sent != 0 || len(received) != 4 {
+		t.Fatalf("sent delivery of deleted subscription")
+	}
+	if ds, _ := queue.Claim(clock.Now().Add(time.Hour), time.Minute, 10); len(ds) != 0 {
+		t.Fatalf("want empty queue, got %#v", ds)
+	}
+}
+
+func TestSenderBackoff(t *testing.T) {
+	s := NewSender(nil, nil)
+	s.MaxBackoff = time.Minute
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{20, time.Minute},
+	}
+
+	for i, tt := range tests {
+		if got := s.backoff(tt.attempts); got != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, got)
+		}
+	}
+}
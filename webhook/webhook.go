@@ -0,0 +1,146 @@
+// Package webhook notifies downstream systems of user lifecycle events, such
+// as users being created or logging in, by POSTing signed JSON payloads to
+// subscribed URLs.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/pborman/uuid"
+
+	"github.com/coreos/dex/repo"
+)
+
+type EventType string
+
+const (
+	EventUserCreated  EventType = "user_created"
+	EventUserVerified EventType = "user_verified"
+	EventUserDisabled EventType = "user_disabled"
+	EventUserEnabled  EventType = "user_enabled"
+	EventUserLogin    EventType = "user_login"
+)
+
+const (
+	// EventHeader holds the type of the event being delivered.
+	EventHeader = "X-Dex-Event"
+
+	// DeliveryHeader holds the ID of the event being delivered. An event
+	// may be delivered more than once, so receivers should use it to
+	// ignore duplicates.
+	DeliveryHeader = "X-Dex-Delivery"
+
+	// SignatureHeader holds the signature of the payload, as computed by
+	// Sign, prefixed with "sha256=".
+	SignatureHeader = "X-Dex-Signature"
+)
+
+var (
+	ErrorNotFound         = errors.New("webhook subscription not found")
+	ErrorDuplicateID      = errors.New("webhook subscription ID already in use")
+	ErrorInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrorInvalidEventType = errors.New("invalid event type")
+	ErrorNoEvents         = errors.New("webhook subscription must have at least one event type")
+	ErrorDeliveryNotFound = errors.New("webhook delivery not found")
+
+	eventTypes = map[EventType]bool{
+		EventUserCreated:  true,
+		EventUserVerified: true,
+		EventUserDisabled: true,
+		EventUserEnabled:  true,
+		EventUserLogin:    true,
+	}
+)
+
+// Valid reports whether t is one of the known event types.
+func (t EventType) Valid() bool {
+	return eventTypes[t]
+}
+
+// Event is a user lifecycle event, and is the payload POSTed to subscribed
+// URLs. Fields which do not apply to the event are left empty.
+type Event struct {
+	// ID is unique to the event, and is assigned when it is queued.
+	ID   string    `json:"id"`
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	UserID string `json:"userId"`
+	Email  string `json:"email,omitempty"`
+
+	// ClientID and ConnectorID are those of user_login events.
+	ClientID    string `json:"clientId,omitempty"`
+	ConnectorID string `json:"connectorId,omitempty"`
+}
+
+// Subscription asks for events of the given types to be POSTed to URL.
+type Subscription struct {
+	ID  string
+	URL string
+
+	// Secret is the key with which payloads sent to URL are signed.
+	Secret []byte
+
+	Events    []EventType
+	CreatedAt time.Time
+}
+
+// Valid returns an error if s is not a valid subscription.
+func (s Subscription) Valid() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || !u.IsAbs() || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return ErrorInvalidURL
+	}
+	if len(s.Events) == 0 {
+		return ErrorNoEvents
+	}
+	for _, t := range s.Events {
+		if !t.Valid() {
+			return ErrorInvalidEventType
+		}
+	}
+	return nil
+}
+
+// Wants reports whether s subscribes to events of type t.
+func (s Subscription) Wants(t EventType) bool {
+	for _, st := range s.Events {
+		if st == t {
+			return true
+		}
+	}
+	return false
+}
+
+type SubscriptionRepo interface {
+	Create(tx repo.Transaction, s Subscription) error
+	Get(tx repo.Transaction, id string) (Subscription, error)
+	Delete(tx repo.Transaction, id string) error
+
+	// All returns every subscription, oldest first.
+	All(tx repo.Transaction) ([]Subscription, error)
+}
+
+// NewSubscriptionID returns a random ID for a new subscription.
+func NewSubscriptionID() string {
+	return uuid.New()
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of payload keyed with secret.
+func Sign(secret, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether sig, the value of a SignatureHeader, is the
+// signature of payload with secret.
+func VerifySignature(secret, payload []byte, sig string) bool {
+	want := "sha256=" + Sign(secret, payload)
+	return hmac.Equal([]byte(want), []byte(sig))
+}
@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"testing"
+)
+
+func TestSubscriptionValid(t *testing.T) {
+	tests := []struct {
+		sub  Subscription
+		want error
+	}{
+		{
+			sub: Subscription{URL: "https://example.com/hook", Events: []EventType{EventUserCreated}},
+		},
+		{
+			sub: Subscription{URL: "http://example.com:8080/hook", Events: []EventType{EventUserLogin, EventUserDisabled}},
+		},
+		{
+			sub:  Subscription{URL: "/hook", Events: []EventType{EventUserCreated}},
+			want: ErrorInvalidURL,
+		},
+		{
+			sub:  Subscription{URL: "ftp://example.com/hook", Events: []EventType{EventUserCreated}},
+			want: ErrorInvalidURL,
+		},
+		{
+			sub:  Subscription{URL: "https://example.com/hook"},
+			want: ErrorNoEvents,
+		},
+		{
+			sub:  Subscription{URL: "https://example.com/hook", Events: []EventType{EventUserCreated, "user_deleted"}},
+			want: ErrorInvalidEventType,
+		},
+	}
+
+	for i, tt := range tests {
+		if got := tt.sub.Valid(); got != tt.want {
+			t.Errorf("case %d: want=%v, got=%v", i, tt.want, got)
+		}
+	}
+}
+
+func TestSubscriptionWants(t *testing.T) {
+	sub := Subscription{Events: []EventType{EventUserCreated, EventUserLogin}}
+	tests := []struct {
+		typ  EventType
+		want bool
+	}{
+		{EventUserCreated, true},
+		{EventUserLogin, true},
+		{EventUserDisabled, false},
+	}
+
+	for i, tt := range tests {
+		if got := sub.Wants(tt.typ); got != tt.want {
+			t.Errorf("case %d: want=%t, got=%t", i, tt.want, got)
+		}
+	}
+}
+
+func TestSignature(t *testing.T) {
+	secret := []byte("secret")
+	payload := []byte(`{"id":"1"}`)
+
+	// Computed with: printf '{"id":"1"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=6146142a2ce0159e84c0767881e4ec80bc397da62526e7d19f70795eb79460c0"
+	if !VerifySignature(secret, payload, want) {
+		t.Errorf("signature %q does not verify", want)
+	}
+
+	tests := []struct {
+		secret  []byte
+		payload []byte
+		sig     string
+	}{
+		{[]byte("other"), payload, want},
+		{secret, []byte(`{"id":"2"}`), want},
+		{secret, payload, want[len("sha256="):]},
+		{secret, payload, ""},
+	}
+	for i, tt := range tests {
+		if VerifySignature(tt.secret, tt.payload, tt.sig) {
+			t.Errorf("case %d: want signature to be rejected", i)
+		}
+	}
+}